@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/matijazezelj/aib/internal/certs"
+	"github.com/matijazezelj/aib/internal/graph"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Geometry of the rendered impact tree. Glyphs are basicfont's 7x13 face,
+// so label widths are exact in the PNG and close enough in the SVG.
+const (
+	renderCharWidth = 7
+	renderLineH     = 15
+	renderPadX      = 8
+	renderBoxH      = 2*renderLineH + 8
+	renderColGap    = 48
+	renderRowGap    = 12
+	renderMargin    = 16
+)
+
+// Severity palette: box fill and stroke per severity.
+var renderPalette = map[string][2]string{
+	"root":     {"#dbeafe", "#1d4ed8"},
+	"ok":       {"#f3f4f6", "#6b7280"},
+	"degraded": {"#fef3c7", "#d97706"},
+	"down":     {"#fee2e2", "#dc2626"},
+}
+
+// renderBox is one laid-out node of an impact tree.
+type renderBox struct {
+	X, Y, W  int
+	Lines    [2]string
+	Severity string
+	Parent   int    // index of the parent box, -1 for the root
+	EdgeType string // edge to the parent
+}
+
+// layoutImpactTree places the tree in layers, one column per depth. Leaves
+// are stacked top to bottom and each parent is centred on its children.
+// It returns the boxes in pre-order and the canvas size.
+func layoutImpactTree(tree *graph.ImpactNode, expiry certs.ExpiryThresholds) ([]renderBox, int, int) {
+	var boxes []renderBox
+	var depths []int
+	var colW []int
+	nextRow := 0
+
+	var walk func(n *graph.ImpactNode, parent, depth int) int
+	walk = func(n *graph.ImpactNode, parent, depth int) int {
+		b := renderBox{Parent: parent, EdgeType: string(n.EdgeType), Severity: renderSeverity(n, expiry)}
+		if parent < 0 {
+			b.Severity = "root"
+			b.EdgeType = ""
+		}
+		b.Lines[0] = n.NodeID
+		if n.Node != nil {
+			b.Lines[1] = string(n.Node.Type) + expiryLabel(n.Node, expiry)
+		}
+		if n.Status != "" {
+			b.Lines[1] += " [" + n.Status + "]"
+		}
+		b.W = 2*renderPadX + renderCharWidth*max(len(b.Lines[0]), len(b.Lines[1]))
+
+		idx := len(boxes)
+		boxes = append(boxes, b)
+		depths = append(depths, depth)
+		if depth == len(colW) {
+			colW = append(colW, 0)
+		}
+		colW[depth] = max(colW[depth], b.W)
+
+		if len(n.Children) == 0 {
+			boxes[idx].Y = renderMargin + nextRow*(renderBoxH+renderRowGap)
+			nextRow++
+			return boxes[idx].Y
+		}
+		first, last := 0, 0
+		for i := range n.Children {
+			y := walk(&n.Children[i], idx, depth+1)
+			if i == 0 {
+				first = y
+			}
+			last = y
+		}
+		boxes[idx].Y = (first + last) / 2
+		return boxes[idx].Y
+	}
+	walk(tree, -1, 0)
+
+	colX := make([]int, len(colW))
+	x := renderMargin
+	for d, w := range colW {
+		colX[d] = x
+		x += w + renderColGap
+	}
+	for i := range boxes {
+		boxes[i].X = colX[depths[i]]
+	}
+
+	width := x - renderColGap + renderMargin
+	height := 2*renderMargin + nextRow*(renderBoxH+renderRowGap) - renderRowGap
+	return boxes, width, height
+}
+
+// renderSeverity grades a non-root node: down or degraded from redundancy
+// analysis, down for assets inside their expiry warning window.
+func renderSeverity(n *graph.ImpactNode, expiry certs.ExpiryThresholds) string {
+	if n.Status == "down" || (n.Node != nil && expiryLabel(n.Node, expiry) != "") {
+		return "down"
+	}
+	if n.Status == "degraded" {
+		return "degraded"
+	}
+	return "ok"
+}
+
+// renderImpactSVG draws an impact tree as a standalone SVG document.
+func renderImpactSVG(tree *graph.ImpactNode, expiry certs.ExpiryThresholds) []byte {
+	boxes, width, height := layoutImpactTree(tree, expiry)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+
+	for _, box := range boxes {
+		if box.Parent < 0 {
+			continue
+		}
+		p := boxes[box.Parent]
+		x1, y1 := p.X+p.W, p.Y+renderBoxH/2
+		x2, y2 := box.X, box.Y+renderBoxH/2
+		mid := x2 - renderColGap/2
+		fmt.Fprintf(&b, `<path d="M%d %d H%d V%d H%d" fill="none" stroke="%s" stroke-width="1.5"><title>%s</title></path>`+"\n",
+			x1, y1, mid, y2, x2, renderPalette[box.Severity][1], xmlEscape(box.EdgeType))
+	}
+	for _, box := range boxes {
+		colors := renderPalette[box.Severity]
+		fmt.Fprintf(&b, `<g><rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="%s" stroke="%s" stroke-width="1.5"/>`,
+			box.X, box.Y, box.W, renderBoxH, colors[0], colors[1])
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-weight="bold">%s</text>`, box.X+renderPadX, box.Y+renderLineH, xmlEscape(box.Lines[0]))
+		if box.Lines[1] != "" {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#374151">%s</text>`, box.X+renderPadX, box.Y+2*renderLineH, xmlEscape(box.Lines[1]))
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// xmlEscape escapes s for use in SVG text and attribute values.
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// renderImpactPNG rasterizes the same layout as renderImpactSVG.
+func renderImpactPNG(tree *graph.ImpactNode, expiry certs.ExpiryThresholds) ([]byte, error) {
+	boxes, width, height := layoutImpactTree(tree, expiry)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, box := range boxes {
+		if box.Parent < 0 {
+			continue
+		}
+		p := boxes[box.Parent]
+		stroke := hexColor(renderPalette[box.Severity][1])
+		x1, y1 := p.X+p.W, p.Y+renderBoxH/2
+		x2, y2 := box.X, box.Y+renderBoxH/2
+		mid := x2 - renderColGap/2
+		fillRect(img, x1, y1, mid+1, y1+1, stroke)
+		fillRect(img, mid, min(y1, y2), mid+1, max(y1, y2)+1, stroke)
+		fillRect(img, mid, y2, x2, y2+1, stroke)
+	}
+
+	text := &font.Drawer{Dst: img, Face: basicfont.Face7x13}
+	for _, box := range boxes {
+		colors := renderPalette[box.Severity]
+		stroke := hexColor(colors[1])
+		fillRect(img, box.X, box.Y, box.X+box.W, box.Y+renderBoxH, stroke)
+		fillRect(img, box.X+1, box.Y+1, box.X+box.W-1, box.Y+renderBoxH-1, hexColor(colors[0]))
+
+		text.Src = image.NewUniform(color.Black)
+		text.Dot = fixed.P(box.X+renderPadX, box.Y+renderLineH)
+		text.DrawString(box.Lines[0])
+		text.Src = image.NewUniform(hexColor("#374151"))
+		text.Dot = fixed.P(box.X+renderPadX, box.Y+2*renderLineH)
+		text.DrawString(box.Lines[1])
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// fillRect fills the half-open rectangle [x0,x1)x[y0,y1) with c.
+func fillRect(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// hexColor parses a "#rrggbb" palette entry.
+func hexColor(s string) color.RGBA {
+	var r, g, b uint8
+	_, _ = fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b)
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
+
+// writeRendering writes a rendered image to path, creating parent
+// directories as needed.
+func writeRendering(path string, data []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -4,22 +4,35 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/matijazezelj/aib/internal/alert"
 	"github.com/matijazezelj/aib/internal/certs"
 	"github.com/matijazezelj/aib/internal/config"
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser/kubernetes"
+	"github.com/matijazezelj/aib/internal/parser/terraform"
 	"github.com/matijazezelj/aib/internal/scanner"
+	"github.com/matijazezelj/aib/internal/schedule"
 	"github.com/matijazezelj/aib/internal/server"
+	"github.com/matijazezelj/aib/internal/telemetry"
+	"github.com/matijazezelj/aib/internal/tui"
+	"github.com/matijazezelj/aib/pkg/models"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/spf13/cobra"
 )
@@ -31,38 +44,108 @@ type cliApp struct {
 	outputFormat                         string // "text" or "json"
 	logger                               *slog.Logger
 	version                              string
-	out                                  io.Writer // os.Stdout in prod, bytes.Buffer in tests
-	errOut                               io.Writer // os.Stderr in prod
-	in                                   io.Reader // os.Stdin in prod (for prune/backup confirmation)
+	out                                  io.Writer     // os.Stdout in prod, bytes.Buffer in tests
+	errOut                               io.Writer     // os.Stderr in prod
+	in                                   io.Reader     // os.Stdin in prod (for prune/backup confirmation)
+	strictScan                           bool          // fail scans when any input path fails
+	dryRunScan                           bool          // parse and report scans without storing them
+	scanInclude, scanExclude             []string      // file globs filtering directory scans
+	scanExcludeTypes                     []string      // asset types dropped from scan results
+	scanTimeout                          time.Duration // per-scan parser timeout (0 = scan.timeout)
+	resolveDNS                           bool          // look up DNS record names live after scans
 }
 
-// writeJSON encodes v as indented JSON and writes it to a.out.
-func (a *cliApp) writeJSON(v any) error {
+// writeOutput writes v to a.out in the --output format, JSON or YAML.
+func (a *cliApp) writeOutput(v any) error {
+	return a.writeAs(a.outputFormat, v)
+}
+
+// writeAs writes v to a.out as YAML if format is "yaml", else as JSON.
+func (a *cliApp) writeAs(format string, v any) error {
+	if format == "yaml" {
+		return writeYAML(a.out, v)
+	}
 	enc := json.NewEncoder(a.out)
 	enc.SetIndent("", "  ")
 	return enc.Encode(v)
 }
 
-// jsonOutput returns true if the user requested JSON output.
-func (a *cliApp) jsonOutput() bool {
-	return a.outputFormat == "json"
+// structuredOutput returns true if the user requested JSON or YAML output.
+func (a *cliApp) structuredOutput() bool {
+	return a.outputFormat == "json" || a.outputFormat == "yaml"
+}
+
+// newTracker creates a certificate tracker with the configured expiry thresholds.
+func (a *cliApp) newTracker(store *graph.SQLiteStore, cfg *config.Config) *certs.Tracker {
+	tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
+	tracker.SetExpiryThresholds(cfg.Expiry.Thresholds)
+	tracker.SetCheckRevocation(cfg.Certs.CheckRevocation)
+	return tracker
+}
+
+// newScanner creates a scanner that reports asset changes through the
+// configured alerters when alerts.on_change is set.
+func (a *cliApp) newScanner(store *graph.SQLiteStore, cfg *config.Config) *scanner.Scanner {
+	sc := scanner.New(store, cfg, a.logger)
+	if cfg.Alerts.OnChange {
+		sc.SetAlerter(a.newAlerter(cfg, store))
+	}
+	if a.resolveDNS {
+		sc.SetDNSResolver(net.DefaultResolver)
+	}
+	return sc
 }
 
-// buildAlerters creates the configured alert backends from config.
+// buildAlerters creates the configured alert backends from config. Each
+// backend is wrapped with its min_severity filter, if one is set.
 func (a *cliApp) buildAlerters(cfg *config.Config) []alert.Alerter {
 	var alerters []alert.Alerter
+	add := func(al alert.Alerter, minSeverity string) {
+		filtered, err := alert.WithMinSeverity(al, minSeverity)
+		if err != nil {
+			a.logger.Warn("skipping alerter", "alerter", al.Name(), "error", err)
+			return
+		}
+		alerters = append(alerters, filtered)
+	}
 	if cfg.Alerts.Stdout.Enabled {
-		alerters = append(alerters, alert.NewStdoutAlerter())
+		add(alert.NewStdoutAlerter(), cfg.Alerts.Stdout.MinSeverity)
 	}
 	if cfg.Alerts.Webhook.Enabled && cfg.Alerts.Webhook.URL != "" {
-		alerters = append(alerters, alert.NewWebhookAlerter(cfg.Alerts.Webhook.URL, cfg.Alerts.Webhook.Headers))
+		add(alert.NewWebhookAlerter(cfg.Alerts.Webhook.URL, cfg.Alerts.Webhook.Headers), cfg.Alerts.Webhook.MinSeverity)
 	}
 	if cfg.Alerts.Slack.Enabled && cfg.Alerts.Slack.WebhookURL != "" {
-		alerters = append(alerters, alert.NewSlackAlerter(cfg.Alerts.Slack.WebhookURL, cfg.Alerts.Slack.Channel))
+		add(alert.NewSlackAlerter(cfg.Alerts.Slack.WebhookURL, cfg.Alerts.Slack.Channel), cfg.Alerts.Slack.MinSeverity)
+	}
+	if cfg.Alerts.PagerDuty.Enabled && cfg.Alerts.PagerDuty.RoutingKey != "" {
+		add(alert.NewPagerDutyAlerter(cfg.Alerts.PagerDuty.RoutingKey), cfg.Alerts.PagerDuty.MinSeverity)
+	}
+	if em := cfg.Alerts.Email; em.Enabled {
+		add(alert.NewEmailAlerter(alert.SMTPConfig{
+			Host:     em.Host,
+			Port:     em.Port,
+			Username: em.Username,
+			Password: em.Password,
+			From:     em.From,
+			To:       em.To,
+			TLS:      em.TLS,
+		}), em.MinSeverity)
 	}
 	return alerters
 }
 
+// newAlerter fans events out to the configured backends, dropping repeats
+// within alerts.dedup_window. The window is tracked in store so separate
+// runs of "aib certs check" don't re-alert.
+func (a *cliApp) newAlerter(cfg *config.Config, store *graph.SQLiteStore) alert.Alerter {
+	multi := alert.NewMulti(a.buildAlerters(cfg)...)
+	window, _ := time.ParseDuration(cfg.Alerts.DedupWindow) // validated at load
+	if window <= 0 {
+		return multi
+	}
+	return alert.NewThrottler(multi, window, store)
+}
+
 func main() {
 	app := &cliApp{
 		version:      version,
@@ -91,7 +174,8 @@ func main() {
 			default:
 				return fmt.Errorf("invalid --log-format %q (use: text, json)", app.logFormat)
 			}
-			return nil
+			app.outputFormat, err = parseOutputFormat(app.outputFormat)
+			return err
 		},
 	}
 
@@ -99,7 +183,7 @@ func main() {
 	root.PersistentFlags().StringVar(&app.dbPath, "db", "", "database path (overrides config)")
 	root.PersistentFlags().StringVar(&app.logFormat, "log-format", "text", "log output format (text, json)")
 	root.PersistentFlags().StringVar(&app.logLevel, "log-level", "info", "log level (debug, info, warn, error)")
-	root.PersistentFlags().StringVarP(&app.outputFormat, "output", "o", "text", "output format: text, json")
+	root.PersistentFlags().StringVarP(&app.outputFormat, "output", "o", "table", "output format: table, json, yaml")
 
 	root.AddCommand(
 		app.scanCmd(),
@@ -108,6 +192,7 @@ func main() {
 		app.reportCmd(),
 		app.certsCmd(),
 		app.dbCmd(),
+		app.configCmd(),
 		app.serveCmd(),
 		app.versionCmd(),
 		app.completionCmd(),
@@ -123,6 +208,15 @@ func (a *cliApp) openStore() (*graph.SQLiteStore, *config.Config, error) {
 	if err != nil {
 		return nil, nil, fmt.Errorf("loading config: %w", err)
 	}
+	// Type mappings apply to every Terraform parse, so they are loaded
+	// with the config.
+	if cfg.Scan.TypeMappings != "" {
+		mappings, err := terraform.LoadTypeMappings(cfg.Scan.TypeMappings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading scan.type_mappings: %w", err)
+		}
+		terraform.SetTypeMappings(mappings)
+	}
 
 	path := cfg.Storage.Path
 	if a.dbPath != "" {
@@ -138,6 +232,7 @@ func (a *cliApp) openStore() (*graph.SQLiteStore, *config.Config, error) {
 		_ = store.Close()
 		return nil, nil, fmt.Errorf("initializing database: %w", err)
 	}
+	store.SetNodeHistory(cfg.Storage.NodeHistory)
 
 	return store, cfg, nil
 }
@@ -180,6 +275,13 @@ func (a *cliApp) scanCmd() *cobra.Command {
 		Short: "Discover assets from infrastructure sources",
 	}
 
+	cmd.PersistentFlags().BoolVar(&a.strictScan, "strict", false, "fail the scan, storing nothing, if any input path fails to parse")
+	cmd.PersistentFlags().BoolVar(&a.dryRunScan, "dry-run", false, "parse and report what the scan would store, without writing anything")
+	cmd.PersistentFlags().StringArrayVar(&a.scanInclude, "include", nil, "only parse discovered files matching this glob (repeatable)")
+	cmd.PersistentFlags().StringArrayVar(&a.scanExclude, "exclude", nil, "skip discovered files matching this glob, e.g. testdata or vendor/* (repeatable)")
+	cmd.PersistentFlags().StringArrayVar(&a.scanExcludeTypes, "exclude-type", nil, "drop assets of this type from the results (repeatable)")
+	cmd.PersistentFlags().DurationVar(&a.scanTimeout, "timeout", 0, "fail the scan if parsing takes longer than this, e.g. 10m (default: scan.timeout)")
+	cmd.PersistentFlags().BoolVar(&a.resolveDNS, "resolve-dns", false, "look up DNS record names live when linking records to IPs and load balancers")
 	cmd.AddCommand(a.scanTerraformCmd())
 	cmd.AddCommand(a.scanTerraformPlanCmd())
 	cmd.AddCommand(a.scanAnsibleCmd())
@@ -187,32 +289,56 @@ func (a *cliApp) scanCmd() *cobra.Command {
 	cmd.AddCommand(a.scanComposeCmd())
 	cmd.AddCommand(a.scanCloudFormationCmd())
 	cmd.AddCommand(a.scanPulumiCmd())
+	cmd.AddCommand(a.scanAWSCmd())
+	cmd.AddCommand(a.scanGCPCmd())
 	cmd.AddCommand(a.scanAutoCmd())
+	cmd.AddCommand(a.scanReplayCmd())
+	cmd.AddCommand(a.scanCancelCmd())
 	return cmd
 }
 
 func (a *cliApp) scanTerraformCmd() *cobra.Command {
 	var remote bool
 	var workspace string
+	var backends []string
+	var backendRegion string
 
 	cmd := &cobra.Command{
-		Use:   "terraform <path> [path...]",
+		Use:   "terraform [path...]",
 		Short: "Scan Terraform state files, directories, or remote backends",
-		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(backends) > 0 && (len(args) > 0 || remote) {
+				return fmt.Errorf("--backend cannot be combined with paths or --remote")
+			}
+			if len(backends) == 0 && len(args) == 0 {
+				return fmt.Errorf("requires at least 1 path or --backend")
+			}
+
 			store, cfg, err := a.openStore()
 			if err != nil {
 				return err
 			}
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
-			_, _ = fmt.Fprintf(a.out, "Scanning Terraform state across %d path(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			if len(backends) > 0 {
+				_, _ = fmt.Fprintf(a.out, "Reading Terraform state from %d backend(s)...\n", len(backends))
+			} else {
+				_, _ = fmt.Fprintf(a.out, "Scanning Terraform state across %d path(s)...\n", len(args))
+			}
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source:    "terraform",
-				Paths:     args,
-				Remote:    remote,
-				Workspace: workspace,
+				Source:        "terraform",
+				Paths:         args,
+				Remote:        remote,
+				Workspace:     workspace,
+				Backends:      backends,
+				BackendRegion: backendRegion,
+				Strict:        a.strictScan,
+				DryRun:        a.dryRunScan,
+				Include:       a.scanInclude,
+				Exclude:       a.scanExclude,
+				ExcludeTypes:  a.scanExcludeTypes,
+				Timeout:       a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -224,6 +350,8 @@ func (a *cliApp) scanTerraformCmd() *cobra.Command {
 
 	cmd.Flags().BoolVar(&remote, "remote", false, "pull state from remote backend via 'terraform state pull'")
 	cmd.Flags().StringVar(&workspace, "workspace", "", "terraform workspace to pull (use '*' for all workspaces)")
+	cmd.Flags().StringSliceVar(&backends, "backend", nil, "read state directly from s3://bucket/key, gs://bucket/key or azurerm://account/container/key (repeatable; no terraform CLI needed)")
+	cmd.Flags().StringVar(&backendRegion, "backend-region", "", "AWS region for s3:// backends (default from AWS config)")
 	return cmd
 }
 
@@ -241,15 +369,25 @@ func (a *cliApp) scanTerraformPlanCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
 			_, _ = fmt.Fprintf(a.out, "Scanning Terraform plan across %d file(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source: "terraform-plan",
-				Paths:  args,
+				Source:       "terraform-plan",
+				Paths:        args,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			if r.Error != nil {
 				_, _ = fmt.Fprintf(a.out, "Scan failed: %v\n", r.Error)
 				return r.Error
 			}
+			if r.DryRun {
+				a.printScanResult(r)
+				return nil
+			}
 
 			// Print summary with action breakdown.
 			_, _ = fmt.Fprintf(a.out, "Discovered %d nodes, %d edges\n", r.NodesFound, r.EdgesFound)
@@ -276,11 +414,17 @@ func (a *cliApp) scanAnsibleCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
 			_, _ = fmt.Fprintf(a.out, "Scanning Ansible inventory across %d path(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source:    "ansible",
-				Paths:     args,
-				Playbooks: playbooks,
+				Source:       "ansible",
+				Paths:        args,
+				Playbooks:    playbooks,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -301,6 +445,7 @@ func (a *cliApp) scanK8sCmd() *cobra.Command {
 	var kubeconfig string
 	var kubeCtx string
 	var namespaces []string
+	var customResources []string
 
 	cmd := &cobra.Command{
 		Use:     "kubernetes <path> [path...]",
@@ -314,15 +459,19 @@ func (a *cliApp) scanK8sCmd() *cobra.Command {
 			}
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 
 			if live {
 				_, _ = fmt.Fprintln(a.out, "Scanning live Kubernetes cluster...")
 				r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-					Source:     "kubernetes-live",
-					Kubeconfig: kubeconfig,
-					Context:    kubeCtx,
-					Namespaces: namespaces,
+					Source:          "kubernetes-live",
+					Kubeconfig:      kubeconfig,
+					Context:         kubeCtx,
+					Namespaces:      namespaces,
+					CustomResources: customResources,
+					DryRun:          a.dryRunScan,
+					ExcludeTypes:    a.scanExcludeTypes,
+					Timeout:         a.scanTimeoutSpec(),
 				})
 				a.printScanResult(r)
 				if r.Error != nil {
@@ -337,10 +486,16 @@ func (a *cliApp) scanK8sCmd() *cobra.Command {
 
 			_, _ = fmt.Fprintf(a.out, "Scanning Kubernetes manifests across %d path(s)...\n", len(args))
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source:     "kubernetes",
-				Paths:      args,
-				Helm:       helm,
-				ValuesFile: valuesFile,
+				Source:       "kubernetes",
+				Paths:        args,
+				Helm:         helm,
+				ValuesFile:   valuesFile,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -356,13 +511,16 @@ func (a *cliApp) scanK8sCmd() *cobra.Command {
 	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig file (used with --live)")
 	cmd.Flags().StringVar(&kubeCtx, "context", "", "Kubernetes context (used with --live)")
 	cmd.Flags().StringSliceVar(&namespaces, "namespace", nil, "namespace to scan (repeatable; default: all non-system)")
+	cmd.Flags().StringSliceVar(&customResources, "crd", nil, "CRD name, glob (e.g. '*.aws.upbound.io') or kind whose instances to scan (repeatable; used with --live)")
 	return cmd
 }
 
 func (a *cliApp) scanComposeCmd() *cobra.Command {
-	return &cobra.Command{
+	var merge bool
+	cmd := &cobra.Command{
 		Use:   "compose <path> [path...]",
 		Short: "Scan Docker Compose files for service dependencies",
+		Long:  "Scans each path as its own project, layering a directory's override file (e.g. docker-compose.override.yml) over its compose file. With --merge, the paths are deep-merged into one project in order, later files overriding earlier ones, like 'docker compose -f a.yml -f b.yml'.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, cfg, err := a.openStore()
@@ -372,10 +530,17 @@ func (a *cliApp) scanComposeCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
 			_, _ = fmt.Fprintf(a.out, "Scanning Docker Compose across %d path(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source: "compose",
-				Paths:  args,
+				Source:       "compose",
+				Paths:        args,
+				Merge:        merge,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -384,6 +549,8 @@ func (a *cliApp) scanComposeCmd() *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().BoolVar(&merge, "merge", false, "merge the files into one project, later files overriding earlier ones")
+	return cmd
 }
 
 func (a *cliApp) scanCloudFormationCmd() *cobra.Command {
@@ -399,10 +566,16 @@ func (a *cliApp) scanCloudFormationCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
 			_, _ = fmt.Fprintf(a.out, "Scanning CloudFormation templates across %d path(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source: "cloudformation",
-				Paths:  args,
+				Source:       "cloudformation",
+				Paths:        args,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -427,10 +600,99 @@ func (a *cliApp) scanPulumiCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 
 			_, _ = fmt.Fprintf(a.out, "Scanning Pulumi state across %d path(s)...\n", len(args))
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
+			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
+				Source:       "pulumi",
+				Paths:        args,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				Include:      a.scanInclude,
+				Exclude:      a.scanExclude,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
+			})
+			a.printScanResult(r)
+			if r.Error != nil {
+				return r.Error
+			}
+			return nil
+		},
+	}
+}
+
+func (a *cliApp) scanAWSCmd() *cobra.Command {
+	var regions []string
+	var profile string
+
+	cmd := &cobra.Command{
+		Use:   "aws",
+		Short: "Discover running AWS resources through the AWS SDK",
+		Long: `Lists EC2 instances, VPCs, subnets, security groups, RDS instances, S3
+buckets and load balancers, with membership edges between them.
+Credentials come from the standard AWS chain (environment, shared config,
+instance role); --profile picks a shared config profile.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, cfg, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			_, _ = fmt.Fprintln(a.out, "Discovering AWS resources...")
+			sc := a.newScanner(store, cfg)
+			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
+				Source:       "aws",
+				Regions:      regions,
+				Profile:      profile,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
+			})
+			a.printScanResult(r)
+			if r.Error != nil {
+				return r.Error
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&regions, "region", nil, "AWS region to discover (repeatable; default: the SDK's configured region)")
+	cmd.Flags().StringVar(&profile, "profile", "", "AWS shared config profile")
+	return cmd
+}
+
+func (a *cliApp) scanGCPCmd() *cobra.Command {
+	var project string
+	var credentials string
+
+	cmd := &cobra.Command{
+		Use:   "gcp",
+		Short: "Discover running Google Cloud resources through the GCP APIs",
+		Long: `Lists Compute Engine instances, networks, subnetworks and forwarding
+rules, Cloud SQL instances and Cloud Storage buckets in a project, with
+instances connected to the subnetworks of their network interfaces.
+Authenticates with --credentials (a service account key file) or
+Application Default Credentials.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, cfg, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			_, _ = fmt.Fprintf(a.out, "Discovering GCP resources in project %s...\n", project)
+			sc := a.newScanner(store, cfg)
 			r := sc.RunSync(cmd.Context(), scanner.ScanRequest{
-				Source: "pulumi",
-				Paths:  args,
+				Source:       "gcp",
+				Project:      project,
+				Credentials:  credentials,
+				Strict:       a.strictScan,
+				DryRun:       a.dryRunScan,
+				ExcludeTypes: a.scanExcludeTypes,
+				Timeout:      a.scanTimeoutSpec(),
 			})
 			a.printScanResult(r)
 			if r.Error != nil {
@@ -439,14 +701,193 @@ func (a *cliApp) scanPulumiCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&project, "project", "", "GCP project ID (required)")
+	cmd.Flags().StringVar(&credentials, "credentials", "", "service account key file (default: Application Default Credentials)")
+	_ = cmd.MarkFlagRequired("project")
+	return cmd
+}
+
+func (a *cliApp) scanReplayCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay <scan-id>",
+		Short: "Re-run a previous scan with its original parameters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid scan ID %q", args[0])
+			}
+
+			store, cfg, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+			ctx := cmd.Context()
+
+			sc := a.newScanner(store, cfg)
+			req, err := sc.ReplayRequest(ctx, id)
+			if err != nil {
+				return fmt.Errorf("scan %d: %w", id, err)
+			}
+
+			req.DryRun = a.dryRunScan
+			req.Include, req.Exclude, req.ExcludeTypes = a.scanInclude, a.scanExclude, a.scanExcludeTypes
+			if a.scanTimeout > 0 {
+				req.Timeout = a.scanTimeoutSpec()
+			}
+			if req.Source == "all" {
+				if req.DryRun {
+					return fmt.Errorf("--dry-run is not supported when replaying a scan of all configured sources")
+				}
+				_, _ = fmt.Fprintf(a.out, "Replaying scan %d (all configured sources)...\n", id)
+				for _, r := range sc.RunAll(ctx, req) {
+					a.printScanResult(r)
+				}
+				return nil
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Replaying scan %d (%s across %d path(s))...\n", id, req.Source, len(req.Paths))
+			r := sc.RunSync(ctx, req)
+			a.printScanResult(r)
+			if r.Error != nil {
+				return r.Error
+			}
+			return nil
+		},
+	}
+}
+
+func (a *cliApp) scanCancelCmd() *cobra.Command {
+	var serverURL, token string
+
+	cmd := &cobra.Command{
+		Use:   "cancel <scan-id>",
+		Short: "Cancel a scan running in aib serve",
+		Long: `Cancel an async scan (one triggered through the API, the UI or the
+scheduler) running in an aib serve process. The server is reached at
+server.listen from the config unless --server is given, authenticating with
+server.api_token or the first admin-scoped server.api_tokens entry.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid scan ID %q", args[0])
+			}
+			cfg, err := config.Load(a.cfgFile)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			if token == "" {
+				token = adminToken(cfg.Server)
+			}
+			base, client := serverClient(cfg.Server.Listen, serverURL)
+
+			req, err := http.NewRequestWithContext(cmd.Context(), http.MethodDelete, fmt.Sprintf("%s/api/v1/scan/%d", base, id), nil)
+			if err != nil {
+				return err
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp, err := client.Do(req) //#nosec G704 -- URL is the user's own aib server
+			if err != nil {
+				return fmt.Errorf("contacting aib server at %s: %w", base, err)
+			}
+			defer resp.Body.Close() //nolint:errcheck // best-effort cleanup
+
+			if resp.StatusCode != http.StatusOK {
+				var body struct {
+					Error struct {
+						Code    string `json:"code"`
+						Message string `json:"message"`
+					} `json:"error"`
+				}
+				if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Error.Message == "" {
+					return fmt.Errorf("cancelling scan %d: server returned %s", id, resp.Status)
+				}
+				return fmt.Errorf("cancelling scan %d: %s", id, body.Error.Message)
+			}
+			_, _ = fmt.Fprintf(a.out, "Scan %d cancelled.\n", id)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&serverURL, "server", "", "aib server URL (default: derived from server.listen)")
+	cmd.Flags().StringVar(&token, "token", "", "API bearer token (default: from the config)")
+	return cmd
+}
+
+// adminToken returns a token from the server config that may call mutating
+// endpoints, or "" if auth is off.
+func adminToken(sc config.ServerConfig) string {
+	if sc.APIToken != "" {
+		return sc.APIToken
+	}
+	for _, t := range sc.APITokens {
+		if t.Scope == config.ScopeAdmin {
+			return t.Token
+		}
+	}
+	return ""
+}
+
+// serverClient returns the base URL and HTTP client for talking to a local
+// aib server listening on listen, or at serverURL if given. Unix socket
+// listeners are dialled directly.
+func serverClient(listen, serverURL string) (string, *http.Client) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	if serverURL != "" {
+		return strings.TrimSuffix(serverURL, "/"), client
+	}
+	if path, ok := config.UnixSocketPath(listen); ok {
+		client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}
+		return "http://aib", client
+	}
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return "http://" + listen, client
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port), client
+}
+
+// scanTimeoutSpec returns the --timeout flag as a ScanRequest timeout, or ""
+// to fall back to scan.timeout.
+func (a *cliApp) scanTimeoutSpec() string {
+	if a.scanTimeout == 0 {
+		return ""
+	}
+	return a.scanTimeout.String()
 }
 
 func (a *cliApp) printScanResult(r scanner.ScanResult) {
 	if r.Error != nil {
 		_, _ = fmt.Fprintf(a.out, "Scan failed: %v\n", r.Error)
+		for _, w := range r.Warnings {
+			_, _ = fmt.Fprintf(a.out, "  warning: %s\n", w)
+		}
 		return
 	}
-	_, _ = fmt.Fprintf(a.out, "Discovered %d nodes, %d edges\n", r.NodesFound, r.EdgesFound)
+	if r.DryRun {
+		_, _ = fmt.Fprintf(a.out, "Dry run: would store %d nodes, %d edges\n", r.NodesFound, r.EdgesFound)
+		printTypeCounts(a.out, "Nodes", r.Nodes, func(n models.Node) string { return string(n.Type) })
+		printTypeCounts(a.out, "Edges", r.Edges, func(e models.Edge) string { return string(e.Type) })
+	} else {
+		_, _ = fmt.Fprintf(a.out, "Discovered %d nodes, %d edges\n", r.NodesFound, r.EdgesFound)
+	}
+	if r.PathsFailed > 0 {
+		_, _ = fmt.Fprintf(a.out, "Partial scan: %d path(s) scanned, %d failed\n", r.PathsScanned, r.PathsFailed)
+	}
+	if r.NodesSwept > 0 {
+		_, _ = fmt.Fprintf(a.out, "Swept %d node(s) no longer found (scan.prune_missing)\n", r.NodesSwept)
+	}
 	for _, w := range r.Warnings {
 		_, _ = fmt.Fprintf(a.out, "  warning: %s\n", w)
 	}
@@ -462,6 +903,35 @@ func (a *cliApp) printScanResult(r scanner.ScanResult) {
 				len(r.Drift.EdgesAdded), len(r.Drift.EdgesRemoved))
 		}
 	}
+	if r.DryRun {
+		_, _ = fmt.Fprintln(a.out, "Nothing was written (dry run).")
+	}
+}
+
+// printTypeCounts prints how many items there are of each type, most
+// common first.
+func printTypeCounts[T any](w io.Writer, label string, items []T, typeOf func(T) string) {
+	if len(items) == 0 {
+		return
+	}
+	counts := make(map[string]int)
+	for _, it := range items {
+		counts[typeOf(it)]++
+	}
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(x, y string) int {
+		if counts[x] != counts[y] {
+			return counts[y] - counts[x]
+		}
+		return strings.Compare(x, y)
+	})
+	_, _ = fmt.Fprintf(w, "  %s by type:\n", label)
+	for _, t := range types {
+		_, _ = fmt.Fprintf(w, "    %-24s %d\n", t, counts[t])
+	}
 }
 
 // --- graph ---
@@ -471,7 +941,7 @@ func (a *cliApp) graphCmd() *cobra.Command {
 		Use:   "graph",
 		Short: "Query the asset graph",
 	}
-	cmd.AddCommand(a.graphShowCmd(), a.graphNodesCmd(), a.graphEdgesCmd(), a.graphNeighborsCmd(), a.graphPathCmd(), a.graphDepsCmd(), a.graphPruneCmd(), a.graphExportCmd(), a.graphSyncCmd(), a.graphCyclesCmd(), a.graphSPOFCmd(), a.graphOrphansCmd(), a.graphAuditCmd())
+	cmd.AddCommand(a.graphShowCmd(), a.graphNodesCmd(), a.graphSearchCmd(), a.graphQueryCmd(), a.graphEdgesCmd(), a.graphNodeCmd(), a.graphTagCmd(), a.graphBrowseCmd(), a.graphNeighborsCmd(), a.graphPathCmd(), a.graphDepsCmd(), a.graphPruneCmd(), a.graphExportCmd(), a.graphImportCmd(), a.graphSyncCmd(), a.graphCyclesCmd(), a.graphOrderCmd(), a.graphSPOFCmd(), a.graphCriticalCmd(), a.graphOrphansCmd(), a.graphEntryPointsCmd(), a.graphRegionsCmd(), a.graphAuditCmd(), a.graphDiffCmd(), a.graphHistoryCmd())
 	return cmd
 }
 
@@ -492,8 +962,8 @@ func (a *cliApp) graphShowCmd() *cobra.Command {
 			nodesByType, _ := store.NodeCountByType(ctx)
 			edgesByType, _ := store.EdgeCountByType(ctx)
 
-			if a.jsonOutput() {
-				return a.writeJSON(map[string]any{
+			if a.structuredOutput() {
+				return a.writeOutput(map[string]any{
 					"total_nodes":   nodeCount,
 					"total_edges":   edgeCount,
 					"nodes_by_type": nodesByType,
@@ -520,8 +990,117 @@ func (a *cliApp) graphShowCmd() *cobra.Command {
 	}
 }
 
-func (a *cliApp) graphNodesCmd() *cobra.Command {
+func (a *cliApp) graphSearchCmd() *cobra.Command {
 	var nodeType, source, provider string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search nodes by ID, name or metadata",
+		Long:  "Find nodes whose ID, name or metadata values contain the query (case-insensitive). A query of the form key=value matches nodes whose metadata has that key set to that value; key= matches nodes that have the key.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			nodes, err := store.SearchNodes(cmd.Context(), args[0], graph.NodeFilter{
+				Type: nodeType, Source: source, Provider: provider, Limit: limit,
+			})
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				if nodes == nil {
+					nodes = []models.Node{}
+				}
+				return a.writeOutput(nodes)
+			}
+			if len(nodes) == 0 {
+				_, _ = fmt.Fprintf(a.out, "No nodes match %q.\n", args[0])
+				return nil
+			}
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE\tPROVIDER")
+			for _, n := range nodes {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source, n.Provider)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&nodeType, "type", "", "filter by asset type")
+	cmd.Flags().StringVar(&source, "source", "", "filter by source")
+	cmd.Flags().StringVar(&provider, "provider", "", "filter by provider")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of results (0 for no limit)")
+	return cmd
+}
+
+func (a *cliApp) graphQueryCmd() *cobra.Command {
+	var sortBy string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "query <expression>",
+		Short: "List nodes matching a filter expression",
+		Long: `List nodes matching a filter expression such as
+  type=vm AND provider=google AND metadata.region=us-east1
+
+Comparisons use = or != and combine with AND, OR, NOT and parentheses; AND
+binds tighter than OR. Fields are id, name, type, source, source_file,
+provider, metadata.<key> and tags.<key>. Quote values containing spaces or
+symbols.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			expr, err := graph.ParseFilterExpr(args[0])
+			if err != nil {
+				return err
+			}
+			if !graph.ValidNodeOrder(sortBy) {
+				return fmt.Errorf("unsupported sort field %q", sortBy)
+			}
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			nodes, err := store.ListNodes(cmd.Context(), graph.NodeFilter{Expr: expr, OrderBy: sortBy, Limit: limit})
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				if nodes == nil {
+					nodes = []models.Node{}
+				}
+				return a.writeOutput(nodes)
+			}
+			if len(nodes) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No nodes match.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE\tPROVIDER")
+			for _, n := range nodes {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source, n.Provider)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&sortBy, "sort", "", "sort field (id, name, type, source, provider, last_seen, first_seen); prefix with - for descending")
+	cmd.Flags().IntVar(&limit, "limit", 0, "maximum number of results (0 for no limit)")
+	return cmd
+}
+
+func (a *cliApp) graphNodesCmd() *cobra.Command {
+	var nodeType, source, provider, status, tag string
 
 	cmd := &cobra.Command{
 		Use:   "nodes",
@@ -534,21 +1113,23 @@ func (a *cliApp) graphNodesCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
 
+			tagKey, tagValue, _ := strings.Cut(tag, "=")
 			nodes, err := store.ListNodes(ctx, graph.NodeFilter{
-				Type: nodeType, Source: source, Provider: provider,
+				Type: nodeType, Source: source, Provider: provider, Status: status,
+				TagKey: tagKey, TagValue: tagValue,
 			})
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(nodes)
+			if a.structuredOutput() {
+				return a.writeOutput(nodes)
 			}
 
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE\tPROVIDER")
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE\tPROVIDER\tSTATUS")
 			for _, n := range nodes {
-				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source, n.Provider)
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source, n.Provider, n.Metadata["tf_status"])
 			}
 			return w.Flush()
 		},
@@ -557,6 +1138,8 @@ func (a *cliApp) graphNodesCmd() *cobra.Command {
 	cmd.Flags().StringVar(&nodeType, "type", "", "filter by asset type")
 	cmd.Flags().StringVar(&source, "source", "", "filter by source")
 	cmd.Flags().StringVar(&provider, "provider", "", "filter by provider")
+	cmd.Flags().StringVar(&status, "status", "", "filter by Terraform instance status: tainted, deposed")
+	cmd.Flags().StringVar(&tag, "tag", "", "only nodes with this tag, as key or key=value")
 	return cmd
 }
 
@@ -581,8 +1164,8 @@ func (a *cliApp) graphEdgesCmd() *cobra.Command {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(edges)
+			if a.structuredOutput() {
+				return a.writeOutput(edges)
 			}
 
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
@@ -600,10 +1183,10 @@ func (a *cliApp) graphEdgesCmd() *cobra.Command {
 	return cmd
 }
 
-func (a *cliApp) graphNeighborsCmd() *cobra.Command {
+func (a *cliApp) graphNodeCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "neighbors <node-id>",
-		Short: "Show direct neighbors of a node",
+		Use:   "node <node-id>",
+		Short: "Show a node's fields, metadata and edges",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, _, err := a.openStore()
@@ -621,21 +1204,221 @@ func (a *cliApp) graphNeighborsCmd() *cobra.Command {
 			if node == nil {
 				return fmt.Errorf("node %q not found", nodeID)
 			}
-
-			neighbors, err := store.GetNeighbors(ctx, nodeID)
+			outgoing, err := store.GetEdgesFrom(ctx, nodeID)
 			if err != nil {
 				return err
 			}
-
-			if a.jsonOutput() {
-				return a.writeJSON(neighbors)
+			incoming, err := store.GetEdgesTo(ctx, nodeID)
+			if err != nil {
+				return err
 			}
 
-			_, _ = fmt.Fprintf(a.out, "Neighbors of %s (%s, %s)\n\n", node.Name, node.Type, node.Source)
+			if a.structuredOutput() {
+				if outgoing == nil {
+					outgoing = []models.Edge{}
+				}
+				if incoming == nil {
+					incoming = []models.Edge{}
+				}
+				return a.writeOutput(map[string]any{
+					"node":     node,
+					"outgoing": outgoing,
+					"incoming": incoming,
+				})
+			}
 
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE")
-			for _, n := range neighbors {
+			_, _ = fmt.Fprintf(w, "ID:\t%s\n", node.ID)
+			_, _ = fmt.Fprintf(w, "Name:\t%s\n", node.Name)
+			_, _ = fmt.Fprintf(w, "Type:\t%s\n", node.Type)
+			_, _ = fmt.Fprintf(w, "Source:\t%s\n", node.Source)
+			_, _ = fmt.Fprintf(w, "Source file:\t%s\n", orDash(node.SourceFile))
+			_, _ = fmt.Fprintf(w, "Provider:\t%s\n", orDash(node.Provider))
+			if node.ExpiresAt != nil {
+				_, _ = fmt.Fprintf(w, "Expires:\t%s\n", node.ExpiresAt.Format(time.RFC3339))
+			}
+			_, _ = fmt.Fprintf(w, "First seen:\t%s\n", node.FirstSeen.Format(time.RFC3339))
+			_, _ = fmt.Fprintf(w, "Last seen:\t%s\n", node.LastSeen.Format(time.RFC3339))
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintf(a.out, "\nMetadata (%d):\n", len(node.Metadata))
+			w = tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			for _, k := range slices.Sorted(maps.Keys(node.Metadata)) {
+				_, _ = fmt.Fprintf(w, "  %s\t%s\n", k, node.Metadata[k])
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if len(node.Tags) > 0 {
+				_, _ = fmt.Fprintf(a.out, "\nTags (%d):\n", len(node.Tags))
+				if err := a.printTags(node.Tags); err != nil {
+					return err
+				}
+			}
+
+			a.printEdgesByType(fmt.Sprintf("Outgoing edges (%d):", len(outgoing)), outgoing, "→", func(e models.Edge) string { return e.ToID })
+			a.printEdgesByType(fmt.Sprintf("Incoming edges (%d):", len(incoming)), incoming, "←", func(e models.Edge) string { return e.FromID })
+			return nil
+		},
+	}
+}
+
+func (a *cliApp) graphTagCmd() *cobra.Command {
+	var remove []string
+
+	cmd := &cobra.Command{
+		Use:   "tag <node-id> [key=value...]",
+		Short: "Set, remove or list a node's tags",
+		Long:  "Tags are your own annotations on a node, such as an owner or cost center. They are stored apart from scanned metadata, so re-scans keep them, and can be matched in filter expressions as tags.<key>. With no key=value arguments or --remove, lists the node's tags.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			set := make(map[string]string)
+			for _, arg := range args[1:] {
+				k, v, ok := strings.Cut(arg, "=")
+				if !ok {
+					return fmt.Errorf("invalid tag %q: expected key=value", arg)
+				}
+				if err := graph.ValidateTag(k, v); err != nil {
+					return err
+				}
+				set[k] = v
+			}
+
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+			ctx := cmd.Context()
+
+			nodeID := args[0]
+			var tags map[string]string
+			if len(set) == 0 && len(remove) == 0 {
+				node, err := store.GetNode(ctx, nodeID)
+				if err != nil {
+					return err
+				}
+				if node == nil {
+					return fmt.Errorf("node %q not found", nodeID)
+				}
+				tags = node.Tags
+			} else {
+				tags, err = store.UpdateTags(ctx, nodeID, set, remove)
+				if errors.Is(err, graph.ErrNodeNotFound) {
+					return fmt.Errorf("node %q not found", nodeID)
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			if a.structuredOutput() {
+				if tags == nil {
+					tags = map[string]string{}
+				}
+				return a.writeOutput(tags)
+			}
+			if len(tags) == 0 {
+				_, _ = fmt.Fprintf(a.out, "%s has no tags.\n", nodeID)
+				return nil
+			}
+			return a.printTags(tags)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&remove, "remove", nil, "remove the tag with this key (repeatable)")
+	return cmd
+}
+
+// printTags writes tags as an indented key/value list, sorted by key.
+func (a *cliApp) printTags(tags map[string]string) error {
+	w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		_, _ = fmt.Fprintf(w, "  %s\t%s\n", k, tags[k])
+	}
+	return w.Flush()
+}
+
+func (a *cliApp) graphBrowseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "browse",
+		Short: "Explore the graph interactively in the terminal",
+		Long:  "Opens a terminal UI listing nodes. Filter by type (t), source (s) or a search term (/), open a node to see its metadata and edges, and follow edges from node to node.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			model, err := tui.New(cmd.Context(), store)
+			if err != nil {
+				return err
+			}
+			_, err = tea.NewProgram(model, tea.WithAltScreen(), tea.WithContext(cmd.Context())).Run()
+			return err
+		},
+	}
+}
+
+// printEdgesByType prints edges under heading, grouped by edge type, naming
+// the node at the other end of each.
+func (a *cliApp) printEdgesByType(heading string, edges []models.Edge, arrow string, peer func(models.Edge) string) {
+	_, _ = fmt.Fprintf(a.out, "\n%s\n", heading)
+	byType := make(map[models.EdgeType][]string)
+	for _, e := range edges {
+		byType[e.Type] = append(byType[e.Type], peer(e))
+	}
+	for _, t := range slices.Sorted(maps.Keys(byType)) {
+		_, _ = fmt.Fprintf(a.out, "  %s\n", t)
+		ids := byType[t]
+		slices.Sort(ids)
+		for _, id := range ids {
+			_, _ = fmt.Fprintf(a.out, "    %s %s\n", arrow, id)
+		}
+	}
+}
+
+func (a *cliApp) graphNeighborsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "neighbors <node-id>",
+		Short: "Show direct neighbors of a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+			ctx := cmd.Context()
+
+			nodeID := args[0]
+			node, err := store.GetNode(ctx, nodeID)
+			if err != nil {
+				return err
+			}
+			if node == nil {
+				return fmt.Errorf("node %q not found", nodeID)
+			}
+
+			neighbors, err := store.GetNeighbors(ctx, nodeID)
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(neighbors)
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Neighbors of %s (%s, %s)\n\n", node.Name, node.Type, node.Source)
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE")
+			for _, n := range neighbors {
 				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source)
 			}
 			return w.Flush()
@@ -644,7 +1427,9 @@ func (a *cliApp) graphNeighborsCmd() *cobra.Command {
 }
 
 func (a *cliApp) graphPathCmd() *cobra.Command {
-	return &cobra.Command{
+	var count int
+
+	cmd := &cobra.Command{
 		Use:   "path <from-id> <to-id>",
 		Short: "Find shortest path between two nodes",
 		Args:  cobra.ExactArgs(2),
@@ -675,40 +1460,82 @@ func (a *cliApp) graphPathCmd() *cobra.Command {
 				return fmt.Errorf("node %q not found", toID)
 			}
 
-			nodes, _, err := engine.ShortestPath(ctx, fromID, toID)
+			paths, err := engine.ShortestPaths(ctx, fromID, toID, count)
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(map[string]any{
+			if a.structuredOutput() {
+				if count > 1 {
+					return a.writeOutput(map[string]any{
+						"from":  fromID,
+						"to":    toID,
+						"paths": paths,
+					})
+				}
+				return a.writeOutput(map[string]any{
 					"from":  fromID,
 					"to":    toID,
-					"steps": len(nodes) - 1,
-					"nodes": nodes,
+					"steps": len(paths[0].Nodes) - 1,
+					"nodes": paths[0].Nodes,
+					"edges": paths[0].Edges,
 				})
 			}
 
-			_, _ = fmt.Fprintf(a.out, "Shortest path: %s → %s (%d steps)\n\n", fromID, toID, len(nodes)-1)
+			for i, p := range paths {
+				if count > 1 {
+					_, _ = fmt.Fprintf(a.out, "Path %d: %s → %s (%d steps)\n\n", i+1, fromID, toID, len(p.Nodes)-1)
+				} else {
+					_, _ = fmt.Fprintf(a.out, "Shortest path: %s → %s (%d steps)\n\n", fromID, toID, len(p.Nodes)-1)
+				}
 
-			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "STEP\tNODE ID\tNAME\tTYPE")
-			for i, n := range nodes {
-				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, n.ID, n.Name, n.Type)
+				w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintln(w, "STEP\tVIA\tNODE ID\tNAME\tTYPE")
+				for j, n := range p.Nodes {
+					via := ""
+					if j > 0 && j-1 < len(p.Edges) {
+						via = pathStepLabel(p.Edges[j-1], n.ID)
+					}
+					_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", j, via, n.ID, n.Name, n.Type)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+				if i < len(paths)-1 {
+					_, _ = fmt.Fprintln(a.out)
+				}
 			}
-			return w.Flush()
+			return nil
 		},
 	}
+
+	cmd.Flags().IntVar(&count, "paths", 1, "number of distinct shortest paths to list")
+	return cmd
+}
+
+// pathStepLabel shows the edge type used to reach node, with an arrow for
+// the edge's direction relative to the path.
+func pathStepLabel(e models.Edge, node string) string {
+	if e.ToID == node {
+		return fmt.Sprintf("-[%s]->", e.Type)
+	}
+	return fmt.Sprintf("<-[%s]-", e.Type)
 }
 
 func (a *cliApp) graphDepsCmd() *cobra.Command {
 	var depth int
+	var direction string
 
 	cmd := &cobra.Command{
 		Use:   "deps <node-id>",
 		Short: "Show downstream dependencies of a node",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := graph.ParseDirection(direction)
+			if err != nil {
+				return err
+			}
+
 			store, engine, _, err := a.openStoreAndEngine()
 			if err != nil {
 				return err
@@ -726,16 +1553,16 @@ func (a *cliApp) graphDepsCmd() *cobra.Command {
 				return fmt.Errorf("node %q not found", nodeID)
 			}
 
-			deps, err := engine.DependencyChain(ctx, nodeID, depth)
+			deps, err := engine.DependencyChain(ctx, nodeID, depth, dir)
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(deps)
+			if a.structuredOutput() {
+				return a.writeOutput(deps)
 			}
 
-			_, _ = fmt.Fprintf(a.out, "Dependencies of %s (%s, %s) — depth %d\n\n", node.Name, node.Type, node.Source, depth)
+			_, _ = fmt.Fprintf(a.out, "Dependencies of %s (%s, %s) — depth %d, %s\n\n", node.Name, node.Type, node.Source, depth, dir)
 
 			if len(deps) == 0 {
 				_, _ = fmt.Fprintln(a.out, "No dependencies found.")
@@ -752,20 +1579,32 @@ func (a *cliApp) graphDepsCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&depth, "depth", 10, "maximum traversal depth (1-50)")
+	cmd.Flags().StringVar(&direction, "direction", string(graph.DirectionDownstream), "follow edges downstream, upstream, or both")
 	return cmd
 }
 
 func (a *cliApp) graphPruneCmd() *cobra.Command {
 	var staleDays int
 	var source string
+	var olderThan string
+	var keepSources []string
 	var force bool
 
 	cmd := &cobra.Command{
 		Use:   "prune",
 		Short: "Remove stale nodes from the graph",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			if staleDays <= 0 && source == "" {
-				return fmt.Errorf("specify at least one filter: --stale-days or --source")
+			if staleDays <= 0 && source == "" && olderThan == "" {
+				return fmt.Errorf("specify at least one filter: --stale-days, --source, or --older-than")
+			}
+
+			var firstSeenBefore time.Time
+			if olderThan != "" {
+				age, err := parseAge(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid --older-than: %w", err)
+				}
+				firstSeenBefore = time.Now().Add(-age)
 			}
 
 			store, _, err := a.openStore()
@@ -776,8 +1615,10 @@ func (a *cliApp) graphPruneCmd() *cobra.Command {
 			ctx := cmd.Context()
 
 			nodes, err := store.ListNodes(ctx, graph.NodeFilter{
-				StaleDays: staleDays,
-				Source:    source,
+				StaleDays:       staleDays,
+				Source:          source,
+				FirstSeenBefore: firstSeenBefore,
+				ExcludeSources:  keepSources,
 			})
 			if err != nil {
 				return err
@@ -794,7 +1635,8 @@ func (a *cliApp) graphPruneCmd() *cobra.Command {
 				limit = len(nodes)
 			}
 			for _, n := range nodes[:limit] {
-				_, _ = fmt.Fprintf(a.out, "  %s (%s, last seen: %s)\n", n.ID, n.Type, n.LastSeen.Format("2006-01-02"))
+				_, _ = fmt.Fprintf(a.out, "  %s (%s, first seen: %s, last seen: %s)\n", n.ID, n.Type,
+					n.FirstSeen.Format("2006-01-02"), n.LastSeen.Format("2006-01-02"))
 			}
 			if len(nodes) > 10 {
 				_, _ = fmt.Fprintf(a.out, "  ... and %d more\n", len(nodes)-10)
@@ -811,13 +1653,13 @@ func (a *cliApp) graphPruneCmd() *cobra.Command {
 				}
 			}
 
-			deleted := 0
-			for _, n := range nodes {
-				if err := store.DeleteNode(ctx, n.ID); err != nil {
-					_, _ = fmt.Fprintf(a.errOut, "error deleting %s: %v\n", n.ID, err)
-					continue
-				}
-				deleted++
+			ids := make([]string, len(nodes))
+			for i, n := range nodes {
+				ids[i] = n.ID
+			}
+			deleted, err := store.DeleteNodes(ctx, ids)
+			if err != nil {
+				return fmt.Errorf("pruning nodes (nothing was deleted): %w", err)
 			}
 
 			_, _ = fmt.Fprintf(a.out, "Deleted %d nodes (and their edges).\n", deleted)
@@ -827,35 +1669,154 @@ func (a *cliApp) graphPruneCmd() *cobra.Command {
 
 	cmd.Flags().IntVar(&staleDays, "stale-days", 0, "delete nodes not seen in N days")
 	cmd.Flags().StringVar(&source, "source", "", "delete nodes from this source")
+	cmd.Flags().StringVar(&olderThan, "older-than", "", "delete nodes first seen longer ago than this (e.g. 90d, 720h)")
+	cmd.Flags().StringSliceVar(&keepSources, "keep-sources", nil, "never delete nodes from these sources (e.g. manual)")
 	cmd.Flags().BoolVar(&force, "force", false, "skip confirmation prompt")
 	return cmd
 }
 
+func (a *cliApp) graphImportCmd() *cobra.Command {
+	var merge, replace, force bool
+
+	cmd := &cobra.Command{
+		Use:   "import <file.json>",
+		Short: "Import a graph written by graph export --format=json",
+		Long:  "Upserts the file's nodes and edges into the store (--merge, the default) or replaces the whole graph with them (--replace). Edges whose endpoints are not in the file are skipped and reported.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := graph.LoadGraphData(args[0])
+			if err != nil {
+				return err
+			}
+
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+			ctx := cmd.Context()
+
+			if replace && !force {
+				count, err := store.NodeCount(ctx)
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(a.out, "Replace the current graph (%d nodes) with %d nodes from %s? [y/N]: ", count, len(data.Nodes), args[0])
+				reader := bufio.NewReader(a.in)
+				answer, _ := reader.ReadString('\n')
+				answer = strings.TrimSpace(strings.ToLower(answer))
+				if answer != "y" && answer != "yes" {
+					_, _ = fmt.Fprintln(a.out, "Aborted.")
+					return nil
+				}
+			}
+
+			result, err := graph.ImportGraph(ctx, store, data, replace)
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(result)
+			}
+
+			mode := "Merged"
+			if replace {
+				mode = "Replaced graph with"
+			}
+			_, _ = fmt.Fprintf(a.out, "%s %d nodes and %d edges from %s.\n", mode, result.Nodes, result.Edges, args[0])
+			if len(result.SkippedEdges) > 0 {
+				_, _ = fmt.Fprintf(a.out, "Skipped %d edges referencing nodes not in the file:\n", len(result.SkippedEdges))
+				for _, e := range result.SkippedEdges {
+					_, _ = fmt.Fprintf(a.out, "  %s -[%s]-> %s\n", e.FromID, e.Type, e.ToID)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&merge, "merge", true, "upsert into the existing graph")
+	cmd.Flags().BoolVar(&replace, "replace", false, "delete the existing graph before importing")
+	cmd.Flags().BoolVar(&force, "force", false, "skip confirmation prompt for --replace")
+	cmd.MarkFlagsMutuallyExclusive("merge", "replace")
+	return cmd
+}
+
+// parseAge parses a duration that may also be given in whole days ("90d").
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%q is not a positive number of days", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q must be positive", s)
+	}
+	return d, nil
+}
+
 func (a *cliApp) graphExportCmd() *cobra.Command {
-	var format string
+	var format, source, nodeType, seed, label string
+	var depth int
+	var color bool
 
 	cmd := &cobra.Command{
 		Use:   "export",
 		Short: "Export graph in various formats",
+		Long:  "Export the graph, or the subgraph of nodes matching --type and --source and the edges between them. --seed limits the export to a node and its blast radius, up to --depth hops.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			store, _, err := a.openStore()
+			store, cfg, err := a.openStore()
 			if err != nil {
 				return err
 			}
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
 
+			opts := graph.ExportOptions{Filter: graph.NodeFilter{Source: source, Type: nodeType}, Label: label}
+			if color {
+				opts.Color = true
+				opts.Warn = certs.ExpiryThresholds(cfg.Expiry.Thresholds).Warning
+			}
+			if seed != "" {
+				node, err := store.GetNode(ctx, seed)
+				if err != nil {
+					return err
+				}
+				if node == nil {
+					return fmt.Errorf("node %q not found", seed)
+				}
+				tree, err := graph.BlastRadiusTree(ctx, store, seed, graph.ImpactOptions{MaxDepth: depth})
+				if err != nil {
+					return err
+				}
+				opts.NodeIDs = graph.SubgraphIDs(tree)
+			}
+
 			var output string
 
 			switch format {
 			case "json":
-				output, err = graph.ExportJSON(ctx, store)
+				output, err = graph.ExportJSON(ctx, store, opts)
 			case "dot":
-				output, err = graph.ExportDOT(ctx, store)
+				output, err = graph.ExportDOT(ctx, store, opts)
 			case "mermaid":
-				output, err = graph.ExportMermaid(ctx, store)
+				output, err = graph.ExportMermaid(ctx, store, opts)
+			case "arrows":
+				output, err = graph.ExportArrows(ctx, store, opts)
+			case "graphml":
+				output, err = graph.ExportGraphML(ctx, store, opts)
+			case "cytoscape":
+				output, err = graph.ExportCytoscape(ctx, store, opts)
+			case "tf-import":
+				output, err = graph.ExportTerraformImports(ctx, store, opts)
 			default:
-				return fmt.Errorf("unsupported format %q (use: json, dot, mermaid)", format)
+				return fmt.Errorf("unsupported format %q (use: json, dot, mermaid, arrows, graphml, cytoscape, tf-import)", format)
 			}
 
 			if err != nil {
@@ -867,14 +1828,24 @@ func (a *cliApp) graphExportCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&format, "format", "json", "export format: json, dot, mermaid")
+	cmd.Flags().StringVar(&format, "format", "json", "export format: json, dot, mermaid, arrows (arrows.app), graphml (Gephi, yEd), cytoscape (Cytoscape.js elements), tf-import (terraform import blocks)")
+	cmd.Flags().StringVar(&source, "source", "", "only export nodes from this source")
+	cmd.Flags().StringVar(&nodeType, "type", "", "only export nodes of this asset type")
+	cmd.Flags().StringVar(&seed, "seed", "", "only export this node and the nodes in its blast radius")
+	cmd.Flags().IntVar(&depth, "depth", 0, "with --seed, maximum number of hops from the seed (0 for no limit)")
+	cmd.Flags().StringVar(&label, "label", "", "node label: name (default), id, another node field, or metadata.<key>; json exports add a labels map")
+	cmd.Flags().BoolVar(&color, "color", false, "dot and mermaid: color nodes by type, draw assets inside their expiry window in red, and add a legend")
 	return cmd
 }
 
 func (a *cliApp) graphSyncCmd() *cobra.Command {
-	return &cobra.Command{
+	var full bool
+	var batchSize int
+
+	cmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Synchronize graph data from SQLite to Memgraph",
+		Long:  "Pushes the nodes and edges written since the last successful sync to Memgraph and removes nodes deleted from SQLite. The first sync, or one with --full, clears Memgraph and pushes the whole graph. Failed syncs are retried with backoff.",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			store, cfg, err := a.openStore()
 			if err != nil {
@@ -886,20 +1857,42 @@ func (a *cliApp) graphSyncCmd() *cobra.Command {
 				return fmt.Errorf("memgraph is not enabled in configuration (set storage.memgraph.enabled: true)")
 			}
 
-			auth := neo4j.NoAuth()
-			if cfg.Storage.Memgraph.Username != "" {
-				auth = neo4j.BasicAuth(cfg.Storage.Memgraph.Username, cfg.Storage.Memgraph.Password, "")
-			}
-
-			driver, err := neo4j.NewDriverWithContext(cfg.Storage.Memgraph.URI, auth)
+			driver, err := a.memgraphDriver(cfg)
 			if err != nil {
-				return fmt.Errorf("connecting to memgraph: %w", err)
+				return err
 			}
 			defer driver.Close(context.Background()) //nolint:errcheck // best-effort cleanup
 
-			return graph.SyncToMemgraph(cmd.Context(), store, driver, a.logger)
+			syncer := graph.NewMemgraphSyncer(store, driver, a.logger)
+			if batchSize > 0 {
+				syncer.BatchSize = batchSize
+			} else if cfg.Storage.Memgraph.SyncBatchSize > 0 {
+				syncer.BatchSize = cfg.Storage.Memgraph.SyncBatchSize
+			}
+			if full {
+				return syncer.SyncFull(cmd.Context())
+			}
+			return syncer.SyncChanged(cmd.Context())
 		},
 	}
+
+	cmd.Flags().BoolVar(&full, "full", false, "clear Memgraph and push the whole graph")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 0, "nodes or edges per statement (default storage.memgraph.sync_batch_size)")
+	return cmd
+}
+
+// memgraphDriver returns a driver for the configured Memgraph. It connects
+// lazily, so an unreachable Memgraph is only reported on first use.
+func (a *cliApp) memgraphDriver(cfg *config.Config) (neo4j.DriverWithContext, error) {
+	auth := neo4j.NoAuth()
+	if cfg.Storage.Memgraph.Username != "" {
+		auth = neo4j.BasicAuth(cfg.Storage.Memgraph.Username, cfg.Storage.Memgraph.Password, "")
+	}
+	driver, err := neo4j.NewDriverWithContext(cfg.Storage.Memgraph.URI, auth)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to memgraph: %w", err)
+	}
+	return driver, nil
 }
 
 func (a *cliApp) graphCyclesCmd() *cobra.Command {
@@ -919,8 +1912,8 @@ func (a *cliApp) graphCyclesCmd() *cobra.Command {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(cycles)
+			if a.structuredOutput() {
+				return a.writeOutput(cycles)
 			}
 
 			if len(cycles) == 0 {
@@ -938,91 +1931,469 @@ func (a *cliApp) graphCyclesCmd() *cobra.Command {
 	}
 }
 
+func (a *cliApp) graphOrderCmd() *cobra.Command {
+	var format string
+	var reverse bool
+
+	cmd := &cobra.Command{
+		Use:   "order",
+		Short: "List assets in dependency order (create order; --reverse for teardown)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !cmd.Flags().Changed("format") {
+				format = a.outputFormat
+			}
+			if format != "text" && format != "json" && format != "yaml" {
+				return fmt.Errorf("unsupported format: %s (use text, json or yaml)", format)
+			}
+
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			order, err := graph.TopoSort(cmd.Context(), store)
+			if err != nil {
+				return err
+			}
+			if reverse {
+				slices.Reverse(order.Nodes)
+			}
+
+			if format != "text" {
+				return a.writeAs(format, order)
+			}
+
+			if len(order.Nodes) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No nodes found.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "STEP\tID\tNAME\tTYPE\tSOURCE")
+			for i, n := range order.Nodes {
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i+1, n.ID, n.Name, n.Type, n.Source)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if len(order.Cycles) > 0 {
+				_, _ = fmt.Fprintf(a.out, "\nWarning: %d circular dependency group(s); the order ignores these edges:\n", len(order.Cycles))
+				for _, e := range order.BrokenEdges {
+					_, _ = fmt.Fprintf(a.out, "  %s → %s\n", e.FromID, e.ToID)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, yaml (default from --output)")
+	cmd.Flags().BoolVar(&reverse, "reverse", false, "list in teardown order (dependents first)")
+	return cmd
+}
+
+func (a *cliApp) graphCriticalCmd() *cobra.Command {
+	var top int
+
+	cmd := &cobra.Command{
+		Use:   "critical",
+		Short: "Rank assets by how much breaks if they fail",
+		Long:  "Score every asset by the size of its blast radius, as a fraction of all other assets, and list the most critical first.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			ranked, err := graph.RankCriticality(cmd.Context(), store)
+			if err != nil {
+				return err
+			}
+			if top > 0 && len(ranked) > top {
+				ranked = ranked[:top]
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(ranked)
+			}
+
+			if len(ranked) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No assets in the graph.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "RANK\tID\tTYPE\tAFFECTED\tDEPENDENTS\tSCORE")
+			for i, c := range ranked {
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%.2f\n", i+1, c.Node.ID, c.Node.Type, c.Affected, c.Dependents, c.Score)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVar(&top, "top", 20, "number of assets to list (0 = all)")
+	return cmd
+}
+
 func (a *cliApp) graphSPOFCmd() *cobra.Command {
 	var minAffected int
 	var limit int
 
 	cmd := &cobra.Command{
-		Use:   "spof",
-		Short: "Identify single points of failure ranked by blast radius",
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			store, engine, _, err := a.openStoreAndEngine()
-			if err != nil {
-				return err
+		Use:   "spof",
+		Short: "Identify single points of failure ranked by blast radius",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, engine, _, err := a.openStoreAndEngine()
+			if err != nil {
+				return err
+			}
+			defer store.Close()  //nolint:errcheck // best-effort cleanup
+			defer engine.Close() //nolint:errcheck // best-effort cleanup
+
+			spofs, err := engine.FindSPOF(cmd.Context(), minAffected)
+			if err != nil {
+				return err
+			}
+
+			if limit > 0 && len(spofs) > limit {
+				spofs = spofs[:limit]
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(spofs)
+			}
+
+			if len(spofs) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No single points of failure found.")
+				return nil
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Top %d single points of failure (min affected: %d):\n\n", len(spofs), minAffected)
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "RANK\tID\tNAME\tTYPE\tAFFECTED")
+			for i, s := range spofs {
+				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\n", i+1, s.Node.ID, s.Node.Name, s.Node.Type, s.AffectedCount)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVar(&minAffected, "min-affected", 1, "minimum blast radius to report")
+	cmd.Flags().IntVar(&limit, "limit", 20, "maximum number of results (0 = unlimited)")
+	return cmd
+}
+
+func (a *cliApp) graphOrphansCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "orphans",
+		Short: "List nodes with no connections",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, engine, _, err := a.openStoreAndEngine()
+			if err != nil {
+				return err
+			}
+			defer store.Close()  //nolint:errcheck // best-effort cleanup
+			defer engine.Close() //nolint:errcheck // best-effort cleanup
+
+			orphans, err := engine.FindOrphans(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(orphans)
+			}
+
+			if len(orphans) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No orphan nodes found.")
+				return nil
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Found %d orphan node(s):\n\n", len(orphans))
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE")
+			for _, n := range orphans {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func (a *cliApp) graphEntryPointsCmd() *cobra.Command {
+	var sinks bool
+
+	cmd := &cobra.Command{
+		Use:   "entrypoints",
+		Short: "List entry points: nodes nothing depends on",
+		Long:  "List nodes with outgoing edges but no incoming ones, typically ingresses, DNS records and load balancers: the ways in to review for external attack surface. --sinks lists the opposite end, leaf dependencies such as databases that depend on nothing. Orphans and provider/region grouping are ignored.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			var nodes []models.Node
+			kind := "entry point"
+			if sinks {
+				kind = "sink"
+				nodes, err = graph.Sinks(cmd.Context(), store)
+			} else {
+				nodes, err = graph.EntryPoints(cmd.Context(), store)
+			}
+			if err != nil {
+				return err
+			}
+
+			if a.structuredOutput() {
+				if nodes == nil {
+					nodes = []models.Node{}
+				}
+				return a.writeOutput(nodes)
+			}
+
+			if len(nodes) == 0 {
+				_, _ = fmt.Fprintf(a.out, "No %ss found.\n", kind)
+				return nil
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Found %d %s(s):\n\n", len(nodes), kind)
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE")
+			for _, n := range nodes {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source)
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().BoolVar(&sinks, "sinks", false, "list sinks instead: nodes with incoming edges but no outgoing ones")
+	return cmd
+}
+
+func (a *cliApp) graphRegionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "regions",
+		Short: "Count assets per region",
+		Long:  "Count assets per region, taken from their region, zone, availability_zone or location metadata. Set scan.group_by_region to also link assets to region nodes, so `aib impact node aib:region:<region>` shows what breaks if a region goes down.",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, _, err := a.openStore()
+			if err != nil {
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			nodes, err := store.ListNodes(cmd.Context(), graph.NodeFilter{})
+			if err != nil {
+				return err
+			}
+			regions := graph.CountByRegion(nodes)
+
+			if a.structuredOutput() {
+				return a.writeOutput(regions)
+			}
+
+			if len(regions) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No assets with a region found.")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "REGION\tNODES\tPROVIDERS\tTYPES")
+			for _, r := range regions {
+				types := make([]string, 0, len(r.ByType))
+				for t, n := range r.ByType {
+					types = append(types, fmt.Sprintf("%s=%d", t, n))
+				}
+				slices.Sort(types)
+				_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", r.Region, r.Nodes, strings.Join(r.Providers, ","), strings.Join(types, ", "))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func (a *cliApp) graphDiffCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "diff <snapshot.json> [new-snapshot.json]",
+		Short: "Show what changed since a snapshot taken with graph export",
+		Long:  "Compares the current graph against a JSON snapshot written by 'aib graph export --format=json' and lists added, removed and modified nodes and edges. With a second snapshot, compares the two snapshots instead.",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("format") {
+				format = a.outputFormat
+			}
+			if format != "text" && format != "json" && format != "yaml" {
+				return fmt.Errorf("unsupported format: %s (use text, json or yaml)", format)
 			}
-			defer store.Close()  //nolint:errcheck // best-effort cleanup
-			defer engine.Close() //nolint:errcheck // best-effort cleanup
 
-			spofs, err := engine.FindSPOF(cmd.Context(), minAffected)
+			old, err := graph.LoadGraphData(args[0])
 			if err != nil {
 				return err
 			}
-
-			if limit > 0 && len(spofs) > limit {
-				spofs = spofs[:limit]
+			var current graph.GraphData
+			if len(args) == 2 {
+				current, err = graph.LoadGraphData(args[1])
+				if err != nil {
+					return err
+				}
+			} else {
+				store, _, err := a.openStore()
+				if err != nil {
+					return err
+				}
+				defer store.Close() //nolint:errcheck // best-effort cleanup
+				if current.Nodes, err = store.ListNodes(cmd.Context(), graph.NodeFilter{}); err != nil {
+					return err
+				}
+				if current.Edges, err = store.ListEdges(cmd.Context(), graph.EdgeFilter{}); err != nil {
+					return err
+				}
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(spofs)
+			diff := graph.Diff(old, current)
+			if format != "text" {
+				return a.writeAs(format, diff)
 			}
 
-			if len(spofs) == 0 {
-				_, _ = fmt.Fprintln(a.out, "No single points of failure found.")
+			if !diff.HasChanges() {
+				_, _ = fmt.Fprintln(a.out, "No changes.")
 				return nil
 			}
 
-			_, _ = fmt.Fprintf(a.out, "Top %d single points of failure (min affected: %d):\n\n", len(spofs), minAffected)
+			_, _ = fmt.Fprintf(a.out, "Nodes: %d added, %d removed, %d modified; edges: %d added, %d removed, %d modified\n\n",
+				len(diff.NodesAdded), len(diff.NodesRemoved), len(diff.NodesModified),
+				len(diff.EdgesAdded), len(diff.EdgesRemoved), len(diff.EdgesModified))
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "RANK\tID\tNAME\tTYPE\tAFFECTED")
-			for i, s := range spofs {
-				_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\n", i+1, s.Node.ID, s.Node.Name, s.Node.Type, s.AffectedCount)
+			_, _ = fmt.Fprintln(w, "CHANGE\tID\tTYPE\tDETAILS")
+			for _, n := range diff.NodesAdded {
+				_, _ = fmt.Fprintf(w, "+ node\t%s\t%s\t%s\n", n.ID, n.Type, n.Name)
+			}
+			for _, n := range diff.NodesRemoved {
+				_, _ = fmt.Fprintf(w, "- node\t%s\t%s\t%s\n", n.ID, n.Type, n.Name)
+			}
+			for _, n := range diff.NodesModified {
+				_, _ = fmt.Fprintf(w, "~ node\t%s\t\t%s\n", n.ID, strings.Join(n.Changes, ", "))
+			}
+			for _, e := range diff.EdgesAdded {
+				_, _ = fmt.Fprintf(w, "+ edge\t%s → %s\t%s\t\n", e.FromID, e.ToID, e.Type)
+			}
+			for _, e := range diff.EdgesRemoved {
+				_, _ = fmt.Fprintf(w, "- edge\t%s → %s\t%s\t\n", e.FromID, e.ToID, e.Type)
+			}
+			for _, e := range diff.EdgesModified {
+				edgeType := e.Type
+				if e.OldType != "" {
+					edgeType = e.OldType + " → " + e.Type
+				}
+				_, _ = fmt.Fprintf(w, "~ edge\t%s → %s\t%s\t%s\n", e.FromID, e.ToID, edgeType, strings.Join(e.Changes, ", "))
 			}
 			return w.Flush()
 		},
 	}
 
-	cmd.Flags().IntVar(&minAffected, "min-affected", 1, "minimum blast radius to report")
-	cmd.Flags().IntVar(&limit, "limit", 20, "maximum number of results (0 = unlimited)")
+	cmd.Flags().StringVar(&format, "format", "text", "output format: text, json, yaml (default from --output)")
 	return cmd
 }
 
-func (a *cliApp) graphOrphansCmd() *cobra.Command {
+func (a *cliApp) graphHistoryCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "orphans",
-		Short: "List nodes with no connections",
-		RunE: func(cmd *cobra.Command, _ []string) error {
-			store, engine, _, err := a.openStoreAndEngine()
+		Use:   "history <node-id>",
+		Short: "Show how a node changed across scans",
+		Long:  "Lists the recorded versions of a node and what changed in each. Versions are only recorded while storage.node_history is enabled in the config.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, cfg, err := a.openStore()
 			if err != nil {
 				return err
 			}
-			defer store.Close()  //nolint:errcheck // best-effort cleanup
-			defer engine.Close() //nolint:errcheck // best-effort cleanup
+			defer store.Close() //nolint:errcheck // best-effort cleanup
 
-			orphans, err := engine.FindOrphans(cmd.Context())
+			versions, err := store.NodeHistory(cmd.Context(), args[0])
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(orphans)
+			if a.structuredOutput() {
+				if versions == nil {
+					versions = []graph.NodeVersion{}
+				}
+				return a.writeOutput(versions)
 			}
 
-			if len(orphans) == 0 {
-				_, _ = fmt.Fprintln(a.out, "No orphan nodes found.")
+			if len(versions) == 0 {
+				_, _ = fmt.Fprintf(a.out, "No history for %s.\n", args[0])
+				if !cfg.Storage.NodeHistory {
+					_, _ = fmt.Fprintln(a.out, "Node history is disabled; set storage.node_history: true in the config to record it.")
+				}
 				return nil
 			}
 
-			_, _ = fmt.Fprintf(a.out, "Found %d orphan node(s):\n\n", len(orphans))
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "ID\tNAME\tTYPE\tSOURCE")
-			for _, n := range orphans {
-				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", n.ID, n.Name, n.Type, n.Source)
+			_, _ = fmt.Fprintln(w, "RECORDED\tCHANGES")
+			for i, v := range versions {
+				changes := "first recorded"
+				if i > 0 {
+					changes = strings.Join(versionChanges(versions[i-1], v), "; ")
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\n", v.RecordedAt.Format(time.RFC3339), changes)
 			}
 			return w.Flush()
 		},
 	}
 }
 
+// versionChanges describes each field that differs between two versions
+// of a node as "field: old → new".
+func versionChanges(old, new graph.NodeVersion) []string {
+	value := func(v graph.NodeVersion, field string) string {
+		switch field {
+		case "name":
+			return v.Name
+		case "type":
+			return string(v.Type)
+		case "source":
+			return v.Source
+		case "provider":
+			return v.Provider
+		case "expires_at":
+			if v.ExpiresAt == nil {
+				return ""
+			}
+			return v.ExpiresAt.Format(time.RFC3339)
+		}
+		return v.Metadata[strings.TrimPrefix(field, "metadata.")]
+	}
+
+	fields := graph.NodeChanges(
+		models.Node{Name: old.Name, Type: old.Type, Metadata: old.Metadata},
+		models.Node{Name: new.Name, Type: new.Type, Metadata: new.Metadata},
+	)
+	for _, f := range []string{"source", "provider", "expires_at"} {
+		if value(old, f) != value(new, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	changes := make([]string, 0, len(fields))
+	for _, f := range fields {
+		from, to := value(old, f), value(new, f)
+		if from == "" {
+			from = "(unset)"
+		}
+		if to == "" {
+			to = "(unset)"
+		}
+		changes = append(changes, fmt.Sprintf("%s: %s → %s", f, from, to))
+	}
+	return changes
+}
+
 // --- impact ---
 
 func (a *cliApp) graphAuditCmd() *cobra.Command {
@@ -1041,8 +2412,8 @@ func (a *cliApp) graphAuditCmd() *cobra.Command {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(report)
+			if a.structuredOutput() {
+				return a.writeOutput(report)
 			}
 
 			if len(report.Findings) == 0 {
@@ -1074,18 +2445,31 @@ func (a *cliApp) impactCmd() *cobra.Command {
 }
 
 func (a *cliApp) impactNodeCmd() *cobra.Command {
-	return &cobra.Command{
+	var redundancy bool
+	var edgeTypeNames []string
+	var depth int
+	var direction string
+	var savePath string
+	var svgPath, pngPath string
+
+	cmd := &cobra.Command{
 		Use:   "node <node-id>",
 		Short: "Analyze what breaks if a node fails",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			store, engine, _, err := a.openStoreAndEngine()
+			dir, err := graph.ParseDirection(direction)
+			if err != nil {
+				return err
+			}
+
+			store, engine, cfg, err := a.openStoreAndEngine()
 			if err != nil {
 				return err
 			}
 			defer store.Close()  //nolint:errcheck // best-effort cleanup
 			defer engine.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
+			expiry := certs.ExpiryThresholds(cfg.Expiry.Thresholds)
 
 			nodeID := args[0]
 			node, err := store.GetNode(ctx, nodeID)
@@ -1096,36 +2480,63 @@ func (a *cliApp) impactNodeCmd() *cobra.Command {
 				return fmt.Errorf("node %q not found", nodeID)
 			}
 
-			tree, err := engine.BlastRadiusTree(ctx, nodeID)
+			var edgeTypes []models.EdgeType
+			for _, t := range edgeTypeNames {
+				edgeTypes = append(edgeTypes, models.EdgeType(t))
+			}
+			opts := graph.ImpactOptions{EdgeTypes: edgeTypes, MaxDepth: depth, Direction: dir}
+			tree, err := engine.BlastRadiusTree(ctx, nodeID, opts)
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(map[string]any{
-					"node_id":      nodeID,
-					"type":         node.Type,
-					"provider":     node.Provider,
-					"source":       node.Source,
-					"blast_radius": countTreeNodes(tree) - 1,
-					"impact_tree":  tree,
-					"warnings":     collectWarnings(tree),
-				})
+			report := graph.NewImpactReport(node, tree, opts, expiry.Warning)
+			if redundancy {
+				report.ApplyRedundancy()
+			}
+			if savePath != "" {
+				if err := saveImpactReport(savePath, report); err != nil {
+					return err
+				}
+			}
+			if svgPath != "" {
+				if err := writeRendering(svgPath, renderImpactSVG(tree, expiry)); err != nil {
+					return err
+				}
+			}
+			if pngPath != "" {
+				data, err := renderImpactPNG(tree, expiry)
+				if err != nil {
+					return err
+				}
+				if err := writeRendering(pngPath, data); err != nil {
+					return err
+				}
+			}
+
+			if a.structuredOutput() {
+				return a.writeOutput(report)
 			}
 
-			// Count total affected
-			total := countTreeNodes(tree) - 1
 			_, _ = fmt.Fprintf(a.out, "\nImpact Analysis: %s\n", nodeID)
 			_, _ = fmt.Fprintf(a.out, "   Type: %s | Provider: %s | Source: %s\n", node.Type, node.Provider, node.Source)
-			_, _ = fmt.Fprintf(a.out, "\n   Blast Radius: %d affected assets\n\n", total)
+			_, _ = fmt.Fprintf(a.out, "\n   Blast Radius: %d affected assets\n", report.BlastRadius)
+			if len(edgeTypes) > 0 {
+				_, _ = fmt.Fprintf(a.out, "   Edge types: %s\n", strings.Join(edgeTypeNames, ", "))
+			}
+			if dir != graph.DirectionUpstream {
+				_, _ = fmt.Fprintf(a.out, "   Direction: %s\n", dir)
+			}
+			if redundancy {
+				_, _ = fmt.Fprintf(a.out, "   Redundancy: %d instance(s) | Impact Score: %.2f\n", report.RedundancyFactor, report.ImpactScore)
+			}
+			_, _ = fmt.Fprintln(a.out)
 
-			a.printTree(ctx, tree, "   ", true)
+			a.printTree(ctx, tree, "   ", true, expiry)
 
-			// Check for expiring certs in the tree
-			warnings := collectWarnings(tree)
-			if len(warnings) > 0 {
+			if len(report.Warnings) > 0 {
 				_, _ = fmt.Fprintf(a.out, "\n   Warnings:\n")
-				for _, w := range warnings {
+				for _, w := range report.Warnings {
 					_, _ = fmt.Fprintf(a.out, "   - %s\n", w)
 				}
 			}
@@ -1134,26 +2545,35 @@ func (a *cliApp) impactNodeCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVar(&redundancy, "redundancy", false, "account for replica counts and report degraded vs down per node")
+	cmd.Flags().StringSliceVar(&edgeTypeNames, "edge-type", nil, "only follow edges of this type, e.g. depends_on (repeatable; default: all)")
+	cmd.Flags().IntVar(&depth, "depth", 0, "maximum number of hops from the node (0 for no limit)")
+	cmd.Flags().StringVar(&direction, "direction", string(graph.DirectionUpstream), "follow edges upstream (what depends on the node), downstream, or both")
+	cmd.Flags().StringVar(&savePath, "save", "", "also write the report as JSON to this file")
+	cmd.Flags().StringVar(&svgPath, "svg", "", "also render the impact tree as an SVG image to this file")
+	cmd.Flags().StringVar(&pngPath, "png", "", "also render the impact tree as a PNG image to this file")
+	return cmd
 }
 
-func countTreeNodes(n *graph.ImpactNode) int {
-	count := 1
-	for i := range n.Children {
-		count += countTreeNodes(&n.Children[i])
+// saveImpactReport writes report to path as indented JSON.
+func saveImpactReport(path string, report *graph.ImpactReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return err
+		}
 	}
-	return count
+	return os.WriteFile(path, append(data, '\n'), 0o600)
 }
 
-func (a *cliApp) printTree(ctx context.Context, n *graph.ImpactNode, prefix string, isRoot bool) {
+func (a *cliApp) printTree(ctx context.Context, n *graph.ImpactNode, prefix string, isRoot bool, expiry certs.ExpiryThresholds) {
 	label := n.NodeID
 	if n.Node != nil {
-		label = fmt.Sprintf("%s (%s)", n.NodeID, n.Node.Type)
-		if n.Node.ExpiresAt != nil {
-			days := certs.DaysUntilExpiry(*n.Node.ExpiresAt)
-			if days <= 30 {
-				label += fmt.Sprintf(" [!] expires in %dd", days)
-			}
-		}
+		label = fmt.Sprintf("%s (%s)", n.NodeID, n.Node.Type) + expiryLabel(n.Node, expiry)
 	}
 
 	if isRoot {
@@ -1169,31 +2589,27 @@ func (a *cliApp) printTree(ctx context.Context, n *graph.ImpactNode, prefix stri
 		}
 		childLabel := child.NodeID
 		if child.Node != nil {
-			childLabel = fmt.Sprintf("%s (%s)", child.NodeID, child.Node.Type)
-			if child.Node.ExpiresAt != nil {
-				days := certs.DaysUntilExpiry(*child.Node.ExpiresAt)
-				if days <= 30 {
-					childLabel += fmt.Sprintf(" [!] expires in %dd", days)
-				}
-			}
+			childLabel = fmt.Sprintf("%s (%s)", child.NodeID, child.Node.Type) + expiryLabel(child.Node, expiry)
+		}
+		if child.Status != "" {
+			childLabel += " [" + child.Status + "]"
 		}
 		_, _ = fmt.Fprintf(a.out, "%s%s[%s] %s\n", prefix, connector, child.EdgeType, childLabel)
-		a.printTree(ctx, &child, childPrefix, false)
+		a.printTree(ctx, &child, childPrefix, false, expiry)
 	}
 }
 
-func collectWarnings(n *graph.ImpactNode) []string {
-	var warnings []string
-	if n.Node != nil && n.Node.ExpiresAt != nil {
-		days := certs.DaysUntilExpiry(*n.Node.ExpiresAt)
-		if days <= 30 {
-			warnings = append(warnings, fmt.Sprintf("%s expires in %d days", n.NodeID, days))
-		}
+// expiryLabel returns a " [!] expires in Nd" suffix for nodes inside their
+// type's expiry warning window, or "" otherwise.
+func expiryLabel(n *models.Node, expiry certs.ExpiryThresholds) string {
+	if n.ExpiresAt == nil {
+		return ""
 	}
-	for i := range n.Children {
-		warnings = append(warnings, collectWarnings(&n.Children[i])...)
+	days := certs.DaysUntilExpiry(*n.ExpiresAt)
+	if expiry.Status(n.Type, days) == "ok" {
+		return ""
 	}
-	return warnings
+	return fmt.Sprintf(" [!] expires in %dd", days)
 }
 
 // --- certs ---
@@ -1208,7 +2624,9 @@ func (a *cliApp) certsCmd() *cobra.Command {
 }
 
 func (a *cliApp) certsListCmd() *cobra.Command {
-	return &cobra.Command{
+	var wide bool
+
+	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all tracked certificates",
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -1219,14 +2637,14 @@ func (a *cliApp) certsListCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
 
-			tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
+			tracker := a.newTracker(store, cfg)
 			certList, err := tracker.ListCerts(ctx)
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(certList)
+			if a.structuredOutput() {
+				return a.writeOutput(certList)
 			}
 
 			if len(certList) == 0 {
@@ -1235,22 +2653,35 @@ func (a *cliApp) certsListCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
-			_, _ = fmt.Fprintln(w, "ID\tNAME\tEXPIRES\tDAYS\tSTATUS")
+			if wide {
+				_, _ = fmt.Fprintln(w, "ID\tNAME\tEXPIRES\tDAYS\tSTATUS\tKEY\tSANS")
+			} else {
+				_, _ = fmt.Fprintln(w, "ID\tNAME\tEXPIRES\tDAYS\tSTATUS")
+			}
 			for _, c := range certList {
 				expires := "-"
 				if c.Node.ExpiresAt != nil {
 					expires = c.Node.ExpiresAt.Format("2006-01-02")
 				}
-				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s",
 					c.Node.ID, c.Node.Name, expires, c.DaysRemaining, strings.ToUpper(c.Status))
+				if wide {
+					_, _ = fmt.Fprintf(w, "\t%s\t%s", orDash(c.Node.Metadata["key_type"]),
+						orDash(strings.ReplaceAll(c.Node.Metadata["sans"], ",", ", ")))
+				}
+				_, _ = fmt.Fprintln(w)
 			}
 			return w.Flush()
 		},
 	}
+
+	cmd.Flags().BoolVar(&wide, "wide", false, "also show key type and subject alternative names")
+	return cmd
 }
 
 func (a *cliApp) certsExpiringCmd() *cobra.Command {
 	var days int
+	var allTypes bool
 
 	cmd := &cobra.Command{
 		Use:   "expiring",
@@ -1263,18 +2694,27 @@ func (a *cliApp) certsExpiringCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
 
-			tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
-			certList, err := tracker.ExpiringCerts(ctx, days)
+			tracker := a.newTracker(store, cfg)
+			var certList []certs.CertInfo
+			if allTypes {
+				certList, err = tracker.ExpiringAssets(ctx, days)
+			} else {
+				certList, err = tracker.ExpiringCerts(ctx, days)
+			}
 			if err != nil {
 				return err
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(certList)
+			if a.structuredOutput() {
+				return a.writeOutput(certList)
 			}
 
 			if len(certList) == 0 {
-				_, _ = fmt.Fprintf(a.out, "No certificates expiring within %d days.\n", days)
+				what := "certificates"
+				if allTypes {
+					what = "assets"
+				}
+				_, _ = fmt.Fprintf(a.out, "No %s expiring within %d days.\n", what, days)
 				return nil
 			}
 
@@ -1289,42 +2729,146 @@ func (a *cliApp) certsExpiringCmd() *cobra.Command {
 	}
 
 	cmd.Flags().IntVar(&days, "days", 30, "expiry threshold in days")
+	cmd.Flags().BoolVar(&allTypes, "all-types", false, "include every asset with an expiry date, not just certificates")
 	return cmd
 }
 
 func (a *cliApp) certsProbeCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "probe <host:port>",
-		Short: "Probe a TLS endpoint",
-		Args:  cobra.ExactArgs(1),
+	var (
+		file       string
+		k8sSecrets bool
+		kubeconfig string
+		kubeCtx    string
+		namespaces []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "probe [host:port]",
+		Short: "Probe a TLS endpoint, PEM file, or Kubernetes TLS secrets",
+		Long: `Probe a TLS endpoint and record its certificate.
+
+With --file, read certificates from a local PEM file or bundle instead.
+With --k8s-secrets, read the kubernetes.io/tls secrets of a live cluster
+and record the expiry of their embedded certificates.`,
+		Example: `  aib certs probe example.com:443
+  aib certs probe --file /etc/ssl/certs/server.pem
+  aib certs probe --k8s-secrets --namespace=shop`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			modes := 0
+			for _, set := range []bool{len(args) == 1, file != "", k8sSecrets} {
+				if set {
+					modes++
+				}
+			}
+			if modes != 1 {
+				return fmt.Errorf("specify exactly one of host:port, --file or --k8s-secrets")
+			}
+
 			store, cfg, err := a.openStore()
 			if err != nil {
 				return err
 			}
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
+			tracker := a.newTracker(store, cfg)
 
-			tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
-			ci, err := tracker.ProbeAndStore(ctx, args[0])
-			if err != nil {
-				return err
+			var infos []certs.CertInfo
+			switch {
+			case file != "":
+				infos, err = tracker.StorePEMFile(ctx, file)
+				if err != nil {
+					return err
+				}
+			case k8sSecrets:
+				result, err := kubernetes.FetchTLSSecrets(ctx, kubeconfig, kubeCtx, namespaces)
+				if err != nil {
+					return err
+				}
+				for _, w := range result.Warnings {
+					a.logger.Warn(w)
+				}
+				if err := store.UpsertBatch(ctx, result.Nodes, result.Edges); err != nil {
+					return fmt.Errorf("storing TLS secrets: %w", err)
+				}
+				for _, n := range result.Nodes {
+					if n.Type == models.AssetCertificate {
+						infos = append(infos, tracker.Info(n))
+					}
+				}
+			default:
+				ci, err := tracker.ProbeAndStore(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				if a.structuredOutput() {
+					return a.writeOutput(ci)
+				}
+				a.printCertInfo(*ci)
+				return nil
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(ci)
+			if a.structuredOutput() {
+				return a.writeOutput(infos)
 			}
-
-			_, _ = fmt.Fprintf(a.out, "Certificate: %s\n", ci.Node.Name)
-			_, _ = fmt.Fprintf(a.out, "  ID:      %s\n", ci.Node.ID)
-			_, _ = fmt.Fprintf(a.out, "  Issuer:  %s\n", ci.Node.Provider)
-			if ci.Node.ExpiresAt != nil {
-				_, _ = fmt.Fprintf(a.out, "  Expires: %s (%d days)\n", ci.Node.ExpiresAt.Format("2006-01-02"), ci.DaysRemaining)
+			if len(infos) == 0 {
+				_, _ = fmt.Fprintln(a.out, "No certificates found.")
+			}
+			for i, ci := range infos {
+				if i > 0 {
+					_, _ = fmt.Fprintln(a.out)
+				}
+				a.printCertInfo(ci)
 			}
-			_, _ = fmt.Fprintf(a.out, "  Status:  %s\n", strings.ToUpper(ci.Status))
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&file, "file", "", "read certificates from a PEM file or bundle")
+	cmd.Flags().BoolVar(&k8sSecrets, "k8s-secrets", false, "read kubernetes.io/tls secrets from a live cluster")
+	cmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "path to kubeconfig (with --k8s-secrets)")
+	cmd.Flags().StringVar(&kubeCtx, "context", "", "kubeconfig context (with --k8s-secrets)")
+	cmd.Flags().StringSliceVar(&namespaces, "namespace", nil, "namespaces to read (with --k8s-secrets; default all)")
+	return cmd
+}
+
+// orDash returns s, or "-" if it is empty, for table cells.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// printCertInfo prints one certificate in the "certs probe" text format.
+func (a *cliApp) printCertInfo(ci certs.CertInfo) {
+	_, _ = fmt.Fprintf(a.out, "Certificate: %s\n", ci.Node.Name)
+	_, _ = fmt.Fprintf(a.out, "  ID:      %s\n", ci.Node.ID)
+	_, _ = fmt.Fprintf(a.out, "  Issuer:  %s\n", ci.Node.Provider)
+	if ci.Node.ExpiresAt != nil {
+		_, _ = fmt.Fprintf(a.out, "  Expires: %s (%d days)\n", ci.Node.ExpiresAt.Format("2006-01-02"), ci.DaysRemaining)
+	}
+	_, _ = fmt.Fprintf(a.out, "  Status:  %s\n", strings.ToUpper(ci.Status))
+	switch {
+	case ci.Node.Metadata["chain_valid"] == "true":
+		_, _ = fmt.Fprintf(a.out, "  Chain:   valid\n")
+	case ci.Node.Metadata["self_signed"] == "true":
+		_, _ = fmt.Fprintf(a.out, "  Chain:   self-signed\n")
+	case ci.Node.Metadata["chain_valid"] == "false":
+		_, _ = fmt.Fprintf(a.out, "  Chain:   INVALID (%s)\n", ci.Node.Metadata["chain_error"])
+	}
+	if rev := ci.Node.Metadata["revocation_status"]; rev != "" {
+		_, _ = fmt.Fprintf(a.out, "  Revocation: %s\n", rev)
+	}
+	if key := ci.Node.Metadata["key_type"]; key != "" {
+		_, _ = fmt.Fprintf(a.out, "  Key:     %s, %s\n", key, ci.Node.Metadata["signature_algorithm"])
+	}
+	if sans := ci.Node.Metadata["sans"]; sans != "" {
+		_, _ = fmt.Fprintf(a.out, "  SANs:    %s\n", strings.ReplaceAll(sans, ",", ", "))
+	}
+	if weak := ci.Node.Metadata["weaknesses"]; weak != "" {
+		_, _ = fmt.Fprintf(a.out, "  WARNING: %s\n", weak)
+	}
 }
 
 func (a *cliApp) certsCheckCmd() *cobra.Command {
@@ -1339,33 +2883,19 @@ func (a *cliApp) certsCheckCmd() *cobra.Command {
 			defer store.Close() //nolint:errcheck // best-effort cleanup
 			ctx := cmd.Context()
 
-			tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
-			results := certs.ProbeAll(ctx, tracker, store, a.logger)
-
-			// Send alerts for expiring certs
-			multi := alert.NewMulti(a.buildAlerters(cfg)...)
-
-			for _, ci := range results {
-				if ci.Status == "warning" || ci.Status == "critical" || ci.Status == "expired" {
-					event := alert.Event{
-						Source:    "aib",
-						EventType: "cert_expiring",
-						Severity:  ci.Status,
-						Asset: alert.Asset{
-							ID:            ci.Node.ID,
-							Name:          ci.Node.Name,
-							Type:          string(ci.Node.Type),
-							DaysRemaining: ci.DaysRemaining,
-						},
-						Message:   fmt.Sprintf("Certificate %s expires in %d days", ci.Node.Name, ci.DaysRemaining),
-						Timestamp: time.Now(),
-					}
-					if ci.Node.ExpiresAt != nil {
-						event.Asset.ExpiresAt = ci.Node.ExpiresAt.Format(time.RFC3339)
-					}
-					_ = multi.Send(ctx, event)
-				}
+			tracker := a.newTracker(store, cfg)
+			certs.ProbeAll(ctx, tracker, store, a.logger)
+
+			// Send alerts for expiring certs and any other expiring assets
+			assets, err := tracker.AlertingAssets(ctx)
+			if err != nil {
+				return err
 			}
+			events := make([]alert.Event, 0, len(assets))
+			for _, ci := range assets {
+				events = append(events, certs.ExpiryEvent(ci))
+			}
+			_ = alert.SendAll(ctx, a.newAlerter(cfg, store), events)
 
 			return nil
 		},
@@ -1391,9 +2921,27 @@ func (a *cliApp) serveCmd() *cobra.Command {
 				listen = cfg.Server.Listen
 			}
 
-			tracker := certs.NewTracker(store, cfg.Certs.AlertThresholds, a.logger)
-			sc := scanner.New(store, cfg, a.logger)
+			tracker := a.newTracker(store, cfg)
+			sc := a.newScanner(store, cfg)
 			srv := server.New(store, engine, tracker, sc, a.logger, listen, readOnly || cfg.Server.ReadOnly, cfg.Server.APIToken, cfg.Server.CORSOrigin, cfg.Scan.AllowedPaths, a.version)
+			srv.SetRateLimit(cfg.Server.RateLimit)
+			srv.SetAPITokens(cfg.Server.APITokens)
+
+			shutdownTelemetry, tracing, err := telemetry.Setup(cmd.Context(), cfg.Telemetry, a.version)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := shutdownTelemetry(flushCtx); err != nil {
+					a.logger.Warn("flushing traces", "error", err)
+				}
+			}()
+			if tracing {
+				srv.SetTracing(true)
+				a.logger.Info("tracing enabled", "otlp_endpoint", cfg.Telemetry.OTLPEndpoint)
+			}
 
 			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer stop()
@@ -1416,7 +2964,7 @@ func (a *cliApp) serveCmd() *cobra.Command {
 
 			// Scheduled cert probing
 			if cfg.Certs.ProbeEnabled && cfg.Certs.ProbeInterval != "" {
-				certSched, err := certs.NewCertScheduler(tracker, store, alert.NewMulti(a.buildAlerters(cfg)...), cfg.Certs.ProbeInterval, a.logger)
+				certSched, err := certs.NewCertScheduler(tracker, store, a.newAlerter(cfg, store), cfg.Certs.ProbeInterval, a.logger)
 				if err != nil {
 					a.logger.Error("invalid cert probe interval", "error", err)
 				} else {
@@ -1425,8 +2973,33 @@ func (a *cliApp) serveCmd() *cobra.Command {
 				}
 			}
 
+			// Memgraph reconciliation, so writes missed while it was
+			// unreachable catch up
+			if cfg.Storage.Memgraph.Enabled && cfg.Storage.Memgraph.SyncInterval != "" {
+				syncSched, err := schedule.Parse(cfg.Storage.Memgraph.SyncInterval)
+				if err != nil {
+					a.logger.Error("invalid memgraph sync interval", "error", err)
+				} else if driver, err := a.memgraphDriver(cfg); err != nil {
+					a.logger.Error("memgraph reconciliation disabled", "error", err)
+				} else {
+					syncDone := make(chan struct{})
+					syncer := graph.NewMemgraphSyncer(store, driver, a.logger)
+					if cfg.Storage.Memgraph.SyncBatchSize > 0 {
+						syncer.BatchSize = cfg.Storage.Memgraph.SyncBatchSize
+					}
+					go func() {
+						defer close(syncDone)
+						syncer.Run(ctx, syncSched)
+					}()
+					defer func() {
+						<-syncDone
+						_ = driver.Close(context.Background())
+					}()
+				}
+			}
+
 			// Scheduled scans
-			if cfg.Scan.Schedule != "" {
+			if cfg.Scan.Schedule != "" || len(cfg.Sources.Schedules()) > 0 {
 				sched, err := scanner.NewScheduler(sc, cfg.Scan.Schedule, a.logger)
 				if err != nil {
 					a.logger.Error("invalid scan schedule", "error", err)
@@ -1449,19 +3022,74 @@ func (a *cliApp) serveCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&listen, "listen", "", "listen address (default from config or :8080)")
-	cmd.Flags().BoolVar(&readOnly, "read-only", false, "disable scan triggers via API")
+	cmd.Flags().StringVar(&listen, "listen", "", "listen address or unix:///path socket (default from config or :8080)")
+	cmd.Flags().BoolVar(&readOnly, "read-only", false, "disable scan triggers and deletes via API")
 	return cmd
 }
 
 // --- db ---
 
+func (a *cliApp) configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	cmd.AddCommand(a.configValidateCmd())
+	return cmd
+}
+
+func (a *cliApp) configValidateCmd() *cobra.Command {
+	var show bool
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config file and exit non-zero if it is invalid",
+		Long:  "Loads the config the same way every other command does (file, defaults, AIB_* environment variables) and reports every validation error. Use --show to print the effective configuration with secrets redacted.",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			cfg, err := config.Load(a.cfgFile)
+			if err != nil {
+				var joined interface{ Unwrap() []error }
+				if !errors.As(err, &joined) {
+					return err
+				}
+				problems := joined.Unwrap()
+				_, _ = fmt.Fprintf(a.out, "Config %s has %d problem(s):\n", configName(), len(problems))
+				for _, p := range problems {
+					_, _ = fmt.Fprintf(a.out, "  - %v\n", p)
+				}
+				return fmt.Errorf("invalid config: %w", err)
+			}
+
+			if show {
+				settings := cfg.Redacted().Settings()
+				if a.outputFormat == "json" {
+					return a.writeOutput(settings)
+				}
+				return writeYAML(a.out, settings)
+			}
+			_, _ = fmt.Fprintf(a.out, "Config %s is valid.\n", configName())
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&show, "show", false, "print the effective configuration, secrets redacted, instead of a summary")
+	return cmd
+}
+
+// configName describes the config file the last config.Load read.
+func configName() string {
+	if f := config.FileUsed(); f != "" {
+		return f
+	}
+	return "(defaults and environment only)"
+}
+
 func (a *cliApp) dbCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "db",
 		Short: "Database management",
 	}
-	cmd.AddCommand(a.dbStatsCmd(), a.dbBackupCmd())
+	cmd.AddCommand(a.dbStatsCmd(), a.dbBackupCmd(), a.dbVacuumCmd())
 	return cmd
 }
 
@@ -1503,8 +3131,8 @@ func (a *cliApp) dbStatsCmd() *cobra.Command {
 				statusCounts[s.Status]++
 			}
 
-			if a.jsonOutput() {
-				return a.writeJSON(map[string]any{
+			if a.structuredOutput() {
+				return a.writeOutput(map[string]any{
 					"path":            path,
 					"size":            sizeStr,
 					"total_nodes":     nodeCount,
@@ -1539,18 +3167,10 @@ func (a *cliApp) dbStatsCmd() *cobra.Command {
 func (a *cliApp) dbBackupCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "backup <output-path>",
-		Short: "Copy database file to a backup location",
+		Short: "Write a consistent snapshot of the database to a backup location",
+		Long:  "Uses SQLite's VACUUM INTO to produce a consistent copy, safe to run while scans are writing.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg, err := config.Load(a.cfgFile)
-			if err != nil {
-				return err
-			}
-
-			srcPath := cfg.Storage.Path
-			if a.dbPath != "" {
-				srcPath = a.dbPath
-			}
 			dstPath := args[0]
 
 			// Check if destination exists
@@ -1565,34 +3185,82 @@ func (a *cliApp) dbBackupCmd() *cobra.Command {
 				}
 			}
 
-			// Create parent directory
-			if err := os.MkdirAll(filepath.Dir(dstPath), 0o750); err != nil {
-				return fmt.Errorf("creating backup directory: %w", err)
+			store, cfg, err := a.openStore()
+			if err != nil {
+				return err
 			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
 
-			src, err := os.Open(srcPath) // #nosec G304 -- path from config/flag
-			if err != nil {
-				return fmt.Errorf("opening source database: %w", err)
+			srcPath := cfg.Storage.Path
+			if a.dbPath != "" {
+				srcPath = a.dbPath
 			}
-			defer src.Close() //nolint:errcheck // best-effort cleanup
 
-			dst, err := os.Create(dstPath) // #nosec G304 -- path from user CLI arg
-			if err != nil {
-				return fmt.Errorf("creating backup file: %w", err)
+			if err := store.BackupTo(cmd.Context(), dstPath); err != nil {
+				return err
 			}
-			defer dst.Close() //nolint:errcheck // best-effort cleanup
 
-			n, err := io.Copy(dst, src)
+			size := "unknown size"
+			if info, err := os.Stat(dstPath); err == nil {
+				size = formatBytes(info.Size())
+			}
+
+			_, _ = fmt.Fprintf(a.out, "Backed up %s to %s (%s)\n", srcPath, dstPath, size) //#nosec G705 -- CLI output, not HTTP response
+			return nil
+		},
+	}
+}
+
+func (a *cliApp) dbVacuumCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "vacuum",
+		Short: "Reclaim the space left by deleted nodes and edges",
+		Long:  "Rebuilds the database with VACUUM and truncates the write-ahead log. Run it after a large graph prune; the database file does not shrink otherwise.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			store, cfg, err := a.openStore()
 			if err != nil {
-				return fmt.Errorf("copying database: %w", err)
+				return err
+			}
+			defer store.Close() //nolint:errcheck // best-effort cleanup
+
+			path := cfg.Storage.Path
+			if a.dbPath != "" {
+				path = a.dbPath
+			}
+
+			before := dbFileSize(path)
+			if err := store.Vacuum(cmd.Context()); err != nil {
+				return err
 			}
+			after := dbFileSize(path)
 
-			_, _ = fmt.Fprintf(a.out, "Backed up %s to %s (%s)\n", srcPath, dstPath, formatBytes(n)) //#nosec G705 -- CLI output, not HTTP response
+			if a.structuredOutput() {
+				return a.writeOutput(map[string]any{
+					"path":            path,
+					"size_before":     before,
+					"size_after":      after,
+					"bytes_reclaimed": before - after,
+				})
+			}
+			_, _ = fmt.Fprintf(a.out, "Vacuumed %s: %s -> %s\n", path, formatBytes(before), formatBytes(after))
 			return nil
 		},
 	}
 }
 
+// dbFileSize returns the size of the SQLite database at path including its
+// write-ahead log.
+func dbFileSize(path string) int64 {
+	var total int64
+	for _, p := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(p); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
 func formatBytes(b int64) string {
 	const unit = 1024
 	if b < unit {
@@ -1613,8 +3281,8 @@ func (a *cliApp) versionCmd() *cobra.Command {
 		Use:   "version",
 		Short: "Print version",
 		Run: func(_ *cobra.Command, _ []string) {
-			if a.jsonOutput() {
-				_ = a.writeJSON(map[string]string{"version": a.version})
+			if a.structuredOutput() {
+				_ = a.writeOutput(map[string]string{"version": a.version})
 				return
 			}
 			_, _ = fmt.Fprintf(a.out, "aib %s\n", a.version)
@@ -1637,6 +3305,21 @@ func parseLogLevel(s string) (slog.Level, error) {
 	}
 }
 
+// parseOutputFormat validates an --output value. "table" is returned as
+// "text", the name the commands use for tabwriter output.
+func parseOutputFormat(s string) (string, error) {
+	switch strings.ToLower(s) {
+	case "table", "text":
+		return "text", nil
+	case "json":
+		return "json", nil
+	case "yaml", "yml":
+		return "yaml", nil
+	default:
+		return "", fmt.Errorf("invalid --output %q (use: table, json, yaml)", s)
+	}
+}
+
 func (a *cliApp) completionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
@@ -3,20 +3,34 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"encoding/xml"
 	"fmt"
+	"image/png"
 	"io"
 	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser/terraform"
 	"github.com/matijazezelj/aib/internal/scanner"
 	"github.com/matijazezelj/aib/pkg/models"
 	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
 	_ "modernc.org/sqlite"
 )
 
@@ -89,6 +103,32 @@ func runCmdWithContext(ctx context.Context, app *cliApp, cmd *cobra.Command, arg
 
 // --- Pure utility tests (no cliApp needed) ---
 
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"table", "text", false},
+		{"text", "text", false},
+		{"json", "json", false},
+		{"JSON", "json", false},
+		{"yaml", "yaml", false},
+		{"yml", "yaml", false},
+		{"csv", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := parseOutputFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseOutputFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseOutputFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestParseLogLevel(t *testing.T) {
 	tests := []struct {
 		input   string
@@ -147,79 +187,6 @@ func TestFormatBytes(t *testing.T) {
 	}
 }
 
-func TestCountTreeNodes(t *testing.T) {
-	tree := &graph.ImpactNode{
-		NodeID: "root",
-		Children: []graph.ImpactNode{
-			{NodeID: "child1"},
-			{NodeID: "child2", Children: []graph.ImpactNode{
-				{NodeID: "grandchild"},
-			}},
-		},
-	}
-
-	count := countTreeNodes(tree)
-	if count != 4 {
-		t.Errorf("countTreeNodes = %d, want 4", count)
-	}
-}
-
-func TestCountTreeNodes_Leaf(t *testing.T) {
-	tree := &graph.ImpactNode{NodeID: "leaf"}
-	if count := countTreeNodes(tree); count != 1 {
-		t.Errorf("countTreeNodes(leaf) = %d, want 1", count)
-	}
-}
-
-func TestCollectWarnings_NoExpiry(t *testing.T) {
-	tree := &graph.ImpactNode{
-		NodeID: "root",
-		Node:   &models.Node{ID: "root"},
-	}
-	warnings := collectWarnings(tree)
-	if len(warnings) != 0 {
-		t.Errorf("expected 0 warnings, got %d", len(warnings))
-	}
-}
-
-func TestCollectWarnings_ExpiringCert(t *testing.T) {
-	soon := time.Now().Add(5 * 24 * time.Hour)
-	tree := &graph.ImpactNode{
-		NodeID: "cert1",
-		Node: &models.Node{
-			ID:        "cert1",
-			ExpiresAt: &soon,
-		},
-	}
-	warnings := collectWarnings(tree)
-	if len(warnings) != 1 {
-		t.Errorf("expected 1 warning, got %d", len(warnings))
-	}
-}
-
-func TestCollectWarnings_Recursive(t *testing.T) {
-	soon := time.Now().Add(5 * 24 * time.Hour)
-	far := time.Now().Add(90 * 24 * time.Hour)
-	tree := &graph.ImpactNode{
-		NodeID: "root",
-		Node:   &models.Node{ID: "root", ExpiresAt: &soon},
-		Children: []graph.ImpactNode{
-			{
-				NodeID: "child",
-				Node:   &models.Node{ID: "child", ExpiresAt: &far},
-			},
-			{
-				NodeID: "child2",
-				Node:   &models.Node{ID: "child2", ExpiresAt: &soon},
-			},
-		},
-	}
-	warnings := collectWarnings(tree)
-	if len(warnings) != 2 {
-		t.Errorf("expected 2 warnings, got %d", len(warnings))
-	}
-}
-
 // --- version ---
 
 func TestVersionCmd(t *testing.T) {
@@ -347,7 +314,7 @@ func TestPrintTree(t *testing.T) {
 		},
 	}
 
-	app.printTree(context.Background(), tree, "  ", true)
+	app.printTree(context.Background(), tree, "  ", true, nil)
 
 	output := buf.String()
 	if !strings.Contains(output, "root") {
@@ -369,6 +336,66 @@ func TestOpenStore_InvalidConfig(t *testing.T) {
 	}
 }
 
+func TestDBVacuumCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.dbCmd(), "db", "vacuum"); err != nil {
+		t.Fatalf("db vacuum error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Vacuumed "+app.dbPath) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+// --- config validate ---
+
+func TestConfigValidateCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	app.cfgFile = write("good.yaml", "server:\n  read_only: false\n  api_token: s3cret-token\n")
+	if err := runCmd(app, app.configCmd(), "config", "validate"); err != nil {
+		t.Fatalf("config validate on a good config: %v", err)
+	}
+	if !strings.Contains(buf.String(), "good.yaml is valid") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.configCmd(), "config", "validate", "--show"); err != nil {
+		t.Fatalf("config validate --show: %v", err)
+	}
+	var settings map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &settings); err != nil {
+		t.Fatalf("--show output is not YAML: %v\n%s", err, buf.String())
+	}
+	server, _ := settings["server"].(map[string]any)
+	if server["api_token"] != "REDACTED" || strings.Contains(buf.String(), "s3cret-token") {
+		t.Errorf("api_token not redacted: %s", buf.String())
+	}
+
+	buf.Reset()
+	app.cfgFile = write("bad.yaml", "storage:\n  memgraph:\n    enabled: true\n    uri: http://memgraph:7687\ncerts:\n  probe_enabled: true\n  probe_interval: sometimes\n")
+	err := runCmd(app, app.configCmd(), "config", "validate")
+	if err == nil {
+		t.Fatal("expected an error for an invalid config")
+	}
+	output := buf.String()
+	for _, want := range []string{"bad.yaml has 2 problem(s)", "storage.memgraph.uri must start with bolt:// or neo4j://", "certs.probe_interval"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
 // --- graph show ---
 
 func TestGraphShowCmd(t *testing.T) {
@@ -472,6 +499,93 @@ func TestDetectAutoScanRequests(t *testing.T) {
 	}
 }
 
+func TestDetectAutoScanRequests_ComposeProject(t *testing.T) {
+	dir := "../../internal/parser/compose/testdata/layered"
+	reqs := detectAutoScanRequests([]string{dir})
+	if len(reqs) != 1 || reqs[0].Source != "compose" {
+		t.Fatalf("detectAutoScanRequests = %+v, want one compose request", reqs)
+	}
+	// The base, override and extends files are scanned as one project.
+	if got := strings.Join(reqs[0].Paths, ","); got != dir {
+		t.Errorf("paths = %s, want the project directory %s", got, dir)
+	}
+}
+
+func TestDetectAutoScanRequests_AnsiblePlaybooks(t *testing.T) {
+	reqs := detectAutoScanRequests([]string{
+		"../../testdata/ansible/inventory.ini",
+		"../../testdata/ansible/playbook.yml",
+	})
+	if len(reqs) != 1 || reqs[0].Source != "ansible" {
+		t.Fatalf("detectAutoScanRequests = %+v, want one ansible request", reqs)
+	}
+	if got := strings.Join(reqs[0].Paths, ","); got != "../../testdata/ansible/inventory.ini" {
+		t.Errorf("paths = %s, want only the inventory", got)
+	}
+	if reqs[0].Playbooks != "../../testdata/ansible" {
+		t.Errorf("playbooks = %q, want the playbook directory", reqs[0].Playbooks)
+	}
+
+	if reqs := detectAutoScanRequests([]string{"../../testdata/ansible/playbook.yml"}); len(reqs) != 0 {
+		t.Errorf("playbook without inventory = %+v, want no requests", reqs)
+	}
+
+	// Playbooks in several directories each get a request.
+	other := t.TempDir()
+	if err := os.WriteFile(filepath.Join(other, "site.yml"), []byte("- hosts: all\n  tasks: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reqs = detectAutoScanRequests([]string{
+		"../../testdata/ansible/inventory.ini",
+		"../../testdata/ansible/playbook.yml",
+		filepath.Join(other, "site.yml"),
+	})
+	var dirs []string
+	for _, req := range reqs {
+		if req.Source != "ansible" || strings.Join(req.Paths, ",") != "../../testdata/ansible/inventory.ini" {
+			t.Errorf("unexpected request %+v", req)
+		}
+		dirs = append(dirs, req.Playbooks)
+	}
+	if want := []string{"../../testdata/ansible", other}; !slices.Equal(dirs, want) {
+		t.Errorf("playbook dirs = %v, want %v", dirs, want)
+	}
+}
+
+func TestDetectSourceForPath_SniffsContent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"../../testdata/terraform/sample.tfstate", "terraform"},
+		{"../../testdata/kubernetes/manifests.yaml", "kubernetes"},
+		{"../../testdata/compose/docker-compose.yml", "compose"},
+		{"../../testdata/cloudformation/template.yaml", "cloudformation"},
+		{"../../testdata/ansible/inventory.ini", "ansible"},
+		{"../../testdata/ansible/inventory.yml", "ansible"},
+		{"../../testdata/ansible/playbook.yml", "ansible"},
+		{write("site.yml", "---\n- name: Configure web\n  hosts: web\n  roles:\n    - nginx\n"), "ansible"},
+		{write("list.yaml", "- name: a\n  value: b\n"), ""},
+		{write("app.yaml", "apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"), "kubernetes"},
+		{write("stack.yml", "services:\n  web:\n    image: nginx\n"), "compose"},
+		{write("values.yaml", "replicaCount: 2\n"), ""},
+	}
+	for _, tt := range tests {
+		if got := detectSourceForPath(tt.path); got != tt.want {
+			t.Errorf("detectSourceForPath(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
 // --- graph nodes ---
 
 func TestGraphNodesCmd(t *testing.T) {
@@ -510,6 +624,123 @@ func TestGraphNodesCmd_Filter(t *testing.T) {
 	}
 }
 
+// --- graph node ---
+
+func TestGraphNodeCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := store.UpsertNode(context.Background(), models.Node{
+		ID: "vm:web1", Name: "web1", Type: models.AssetVM, Source: "terraform", Provider: "aws",
+		Metadata: map[string]string{"instance_type": "t3.micro", "zone": "eu-west-1a"},
+		LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertNode(context.Background(), models.Node{
+		ID: "lb:front", Name: "front", Type: models.AssetLoadBalancer, Source: "terraform",
+		Metadata: map[string]string{}, LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertEdge(context.Background(), models.Edge{
+		ID: "lb:front->vm:web1", FromID: "lb:front", ToID: "vm:web1", Type: models.EdgeRoutesTo,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	if err := runCmd(app, app.graphNodeCmd(), "node", "vm:web1"); err != nil {
+		t.Fatalf("graph node error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{
+		"Name:", "web1",
+		"instance_type", "t3.micro", "zone", "eu-west-1a",
+		"Outgoing edges (1):", string(models.EdgeDependsOn), "→ db:pg1",
+		"Incoming edges (1):", string(models.EdgeRoutesTo), "← lb:front",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	app.outputFormat = "json"
+	if err := runCmd(app, app.graphNodeCmd(), "node", "vm:web1"); err != nil {
+		t.Fatalf("graph node --output=json error: %v", err)
+	}
+	var result struct {
+		Node     models.Node   `json:"node"`
+		Outgoing []models.Edge `json:"outgoing"`
+		Incoming []models.Edge `json:"incoming"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if result.Node.Metadata["zone"] != "eu-west-1a" || len(result.Outgoing) != 1 || len(result.Incoming) != 1 {
+		t.Errorf("unexpected JSON result: %+v", result)
+	}
+
+	if err := runCmd(app, app.graphNodeCmd(), "node", "vm:missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+// --- graph tag ---
+
+func TestGraphTagCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphTagCmd(), "tag", "vm:web1", "owner=team-a", "env=prod"); err != nil {
+		t.Fatalf("graph tag error: %v", err)
+	}
+	if err := runCmd(app, app.graphTagCmd(), "tag", "vm:web1", "--remove", "env"); err != nil {
+		t.Fatalf("graph tag --remove error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "owner") || !strings.Contains(out, "team-a") {
+		t.Errorf("expected tags in output, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphNodeCmd(), "node", "vm:web1"); err != nil {
+		t.Fatalf("graph node error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Tags (1):") || !strings.Contains(out, "team-a") || strings.Contains(out, "prod") {
+		t.Errorf("expected only the owner tag in node output, got:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphNodesCmd(), "nodes", "--tag", "owner=team-a"); err != nil {
+		t.Fatalf("graph nodes --tag error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "vm:web1") || strings.Contains(out, "db:pg1") {
+		t.Errorf("expected only vm:web1 for --tag, got:\n%s", out)
+	}
+
+	buf.Reset()
+	app.outputFormat = "json"
+	if err := runCmd(app, app.graphTagCmd(), "tag", "db:pg1"); err != nil {
+		t.Fatalf("graph tag list error: %v", err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "{}" {
+		t.Errorf("untagged node JSON = %s, want {}", got)
+	}
+
+	if err := runCmd(app, app.graphTagCmd(), "tag", "vm:web1", "owner"); err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Errorf("expected key=value error, got %v", err)
+	}
+	if err := runCmd(app, app.graphTagCmd(), "tag", "vm:missing", "a=b"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
 // --- graph edges ---
 
 func TestGraphEdgesCmd(t *testing.T) {
@@ -591,6 +822,85 @@ func TestGraphExportCmd_DOT(t *testing.T) {
 	}
 }
 
+func TestGraphExportCmd_GraphML(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "graphml"); err != nil {
+		t.Fatalf("graph export graphml error: %v", err)
+	}
+	output := buf.String()
+	if !strings.Contains(output, "<graphml") || !strings.Contains(output, `source="vm:web1" target="db:pg1"`) {
+		t.Errorf("export GraphML missing graph or edge, got: %s", output)
+	}
+}
+
+func TestGraphExportCmd_Cytoscape(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "cytoscape"); err != nil {
+		t.Fatalf("graph export cytoscape error: %v", err)
+	}
+	var doc map[string]map[string][]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("export cytoscape is not valid JSON: %v", err)
+	}
+	if len(doc["elements"]["nodes"]) != 2 || len(doc["elements"]["edges"]) != 1 {
+		t.Errorf("unexpected elements: %s", buf.String())
+	}
+}
+
+func TestGraphExportCmd_Subgraph(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "dot", "--type", "vm"); err != nil {
+		t.Fatalf("graph export --type error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, `"vm:web1"`) || strings.Contains(out, "db:pg1") {
+		t.Errorf("expected only vm:web1 and no edge to db:pg1, got: %s", out)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "dot", "--seed", "vm:web1"); err != nil {
+		t.Fatalf("graph export --seed error: %v", err)
+	}
+	// Nothing depends on vm:web1, so its blast radius is just itself.
+	if out := buf.String(); !strings.Contains(out, `"vm:web1"`) || strings.Contains(out, "db:pg1") {
+		t.Errorf("expected only the seed, got: %s", out)
+	}
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--seed", "missing"); err == nil {
+		t.Error("expected error for unknown seed")
+	}
+}
+
+func TestGraphImportCmd_RoundTrip(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "json"); err != nil {
+		t.Fatalf("graph export error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "graph.json")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphImportCmd(), "import", path, "--replace", "--force"); err != nil {
+		t.Fatalf("graph import error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Replaced graph with 2 nodes and 1 edges") {
+		t.Errorf("unexpected import output: %s", out)
+	}
+
+	if err := runCmd(app, app.graphImportCmd(), "import", path, "--merge", "--replace"); err == nil {
+		t.Error("expected error for --merge with --replace")
+	}
+}
+
 // --- graph path ---
 
 func TestGraphPathCmd(t *testing.T) {
@@ -606,6 +916,21 @@ func TestGraphPathCmd(t *testing.T) {
 	if !strings.Contains(output, "Shortest path") {
 		t.Errorf("expected 'Shortest path' in output, got: %s", output)
 	}
+	if !strings.Contains(output, "-[depends_on]->") {
+		t.Errorf("expected edge type between steps, got: %s", output)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphPathCmd(), "path", "db:pg1", "vm:web1", "--paths", "2"); err != nil {
+		t.Fatalf("graph path --paths error: %v", err)
+	}
+	output = buf.String()
+	if !strings.Contains(output, "Path 1:") || strings.Contains(output, "Path 2:") {
+		t.Errorf("expected exactly one path, got: %s", output)
+	}
+	if !strings.Contains(output, "<-[depends_on]-") {
+		t.Errorf("expected reversed edge arrow, got: %s", output)
+	}
 }
 
 // --- graph deps ---
@@ -642,51 +967,255 @@ func TestGraphCyclesCmd(t *testing.T) {
 	}
 }
 
-// --- graph spof ---
+// --- graph order ---
 
-func TestGraphSPOFCmd(t *testing.T) {
+func TestGraphOrderCmd(t *testing.T) {
 	app, buf := newTestApp(t)
 	seedTestData(t, app)
 
-	err := runCmd(app, app.graphSPOFCmd(), "spof")
-	if err != nil {
-		t.Fatalf("graph spof error: %v", err)
+	if err := runCmd(app, app.graphOrderCmd(), "order"); err != nil {
+		t.Fatalf("graph order error: %v", err)
 	}
-
 	output := buf.String()
-	// With 2 nodes and 1 edge, there may or may not be SPOFs depending on direction
-	if output == "" {
-		t.Error("expected some output from spof command")
+	db, vm := strings.Index(output, "db:pg1"), strings.Index(output, "vm:web1")
+	if db < 0 || vm < 0 || db > vm {
+		t.Errorf("expected db:pg1 before vm:web1, got: %s", output)
 	}
-}
 
-// --- graph orphans ---
+	buf.Reset()
+	if err := runCmd(app, app.graphOrderCmd(), "order", "--reverse", "--format", "json"); err != nil {
+		t.Fatalf("graph order --reverse error: %v", err)
+	}
+	var order graph.TopoOrder
+	if err := json.Unmarshal(buf.Bytes(), &order); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(order.Nodes) != 2 || order.Nodes[0].ID != "vm:web1" {
+		t.Errorf("expected vm:web1 first in teardown order, got %+v", order.Nodes)
+	}
+}
 
-func TestGraphOrphansCmd(t *testing.T) {
+func TestGraphDiffCmd(t *testing.T) {
 	app, buf := newTestApp(t)
-	// Seed data, then add an orphan node
 	seedTestData(t, app)
 
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "json"); err != nil {
+		t.Fatalf("graph export error: %v", err)
+	}
+	snapshot := filepath.Join(t.TempDir(), "before.json")
+	if err := os.WriteFile(snapshot, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphDiffCmd(), "diff", snapshot); err != nil {
+		t.Fatalf("graph diff error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No changes.") {
+		t.Errorf("expected no changes against a fresh snapshot, got: %s", buf.String())
+	}
+
 	store, _, err := app.openStore()
 	if err != nil {
 		t.Fatal(err)
 	}
 	now := time.Now().Truncate(time.Second)
-	_ = store.UpsertNode(context.Background(), models.Node{
-		ID: "orphan:lonely", Name: "lonely", Type: models.AssetVM,
-		Source: "terraform", Metadata: map[string]string{},
+	if err := store.UpsertNode(context.Background(), models.Node{
+		ID: "vm:web2", Name: "web2", Type: models.AssetVM,
+		Source: "terraform", Provider: "aws", Metadata: map[string]string{},
 		LastSeen: now, FirstSeen: now,
-	})
+	}); err != nil {
+		t.Fatal(err)
+	}
 	_ = store.Close()
 
-	err = runCmd(app, app.graphOrphansCmd(), "orphans")
-	if err != nil {
-		t.Fatalf("graph orphans error: %v", err)
+	buf.Reset()
+	if err := runCmd(app, app.graphDiffCmd(), "diff", snapshot, "--format", "json"); err != nil {
+		t.Fatalf("graph diff --format json error: %v", err)
 	}
-
-	output := buf.String()
-	if !strings.Contains(output, "orphan:lonely") {
-		t.Errorf("expected 'orphan:lonely' in output, got: %s", output)
+	var diff graph.GraphDiff
+	if err := json.Unmarshal(buf.Bytes(), &diff); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(diff.NodesAdded) != 1 || diff.NodesAdded[0].ID != "vm:web2" {
+		t.Errorf("nodes added = %+v, want vm:web2", diff.NodesAdded)
+	}
+}
+
+func TestGraphSearchCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphSearchCmd(), "search", "pg"); err != nil {
+		t.Fatalf("graph search error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "db:pg1") || strings.Contains(out, "vm:web1") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphSearchCmd(), "search", "nothing"); err != nil {
+		t.Fatalf("graph search error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `No nodes match "nothing"`) {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+func TestGraphQueryCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphQueryCmd(), "query", "type=database OR id=vm:web1"); err != nil {
+		t.Fatalf("graph query error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "db:pg1") || !strings.Contains(out, "vm:web1") {
+		t.Errorf("unexpected output: %s", out)
+	}
+
+	buf.Reset()
+	err := runCmd(app, app.graphQueryCmd(), "query", "colour=red")
+	if err == nil || !strings.Contains(err.Error(), `unknown field "colour"`) {
+		t.Errorf("error = %v, want unknown field", err)
+	}
+}
+
+func TestGraphHistoryCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	app.cfgFile = filepath.Join(t.TempDir(), "aib.yaml")
+	if err := os.WriteFile(app.cfgFile, []byte("storage:\n  node_history: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	vm := models.Node{
+		ID: "vm:web1", Name: "web1", Type: models.AssetVM, Source: "terraform",
+		Metadata: map[string]string{"machine_type": "e2-small"}, LastSeen: now, FirstSeen: now,
+	}
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+	vm.Metadata = map[string]string{"machine_type": "e2-medium"}
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	if err := runCmd(app, app.graphHistoryCmd(), "history", "vm:web1"); err != nil {
+		t.Fatalf("graph history error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "metadata.machine_type: e2-small → e2-medium") {
+		t.Errorf("expected machine_type change, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.graphHistoryCmd(), "history", "vm:missing"); err != nil {
+		t.Fatalf("graph history error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No history for vm:missing") {
+		t.Errorf("unexpected output: %s", buf.String())
+	}
+}
+
+// --- graph spof ---
+
+func TestGraphSPOFCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.graphSPOFCmd(), "spof")
+	if err != nil {
+		t.Fatalf("graph spof error: %v", err)
+	}
+
+	output := buf.String()
+	// With 2 nodes and 1 edge, there may or may not be SPOFs depending on direction
+	if output == "" {
+		t.Error("expected some output from spof command")
+	}
+}
+
+// --- graph orphans ---
+
+func TestGraphOrphansCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	// Seed data, then add an orphan node
+	seedTestData(t, app)
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	_ = store.UpsertNode(context.Background(), models.Node{
+		ID: "orphan:lonely", Name: "lonely", Type: models.AssetVM,
+		Source: "terraform", Metadata: map[string]string{},
+		LastSeen: now, FirstSeen: now,
+	})
+	_ = store.Close()
+
+	err = runCmd(app, app.graphOrphansCmd(), "orphans")
+	if err != nil {
+		t.Fatalf("graph orphans error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "orphan:lonely") {
+		t.Errorf("expected 'orphan:lonely' in output, got: %s", output)
+	}
+}
+
+func TestGraphRegionsCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	for id, meta := range map[string]map[string]string{
+		"vm:web1":  {"availability_zone": "us-east-1a"},
+		"vm:web2":  {"region": "us-east-1"},
+		"vm:eu1":   {"region": "eu-west-1"},
+		"vm:noreg": {},
+	} {
+		if err := store.UpsertNode(context.Background(), models.Node{
+			ID: id, Name: id, Type: models.AssetVM, Source: "terraform", Provider: "aws",
+			Metadata: meta, LastSeen: now, FirstSeen: now,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = store.Close()
+
+	if err := runCmd(app, app.graphRegionsCmd(), "regions"); err != nil {
+		t.Fatalf("graph regions error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 regions, got:\n%s", buf.String())
+	}
+	if f := strings.Fields(lines[1]); f[0] != "us-east-1" || f[1] != "2" || f[2] != "aws" {
+		t.Errorf("first region row = %q, want us-east-1 with 2 aws nodes", lines[1])
+	}
+
+	buf.Reset()
+	app.outputFormat = "json"
+	if err := runCmd(app, app.graphRegionsCmd(), "regions"); err != nil {
+		t.Fatal(err)
+	}
+	var regions []graph.RegionCount
+	if err := json.Unmarshal(buf.Bytes(), &regions); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(regions) != 2 || regions[1].Region != "eu-west-1" || regions[1].ByType["vm"] != 1 {
+		t.Errorf("regions = %+v", regions)
 	}
 }
 
@@ -717,6 +1246,59 @@ func TestGraphPruneCmd_NoFilter(t *testing.T) {
 	}
 }
 
+func TestGraphPruneCmd_OlderThanKeepSources(t *testing.T) {
+	app, buf := newTestApp(t)
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	old := now.Add(-100 * 24 * time.Hour)
+	for _, n := range []models.Node{
+		{ID: "vm:old", Name: "old", Type: models.AssetVM, Source: "terraform", Metadata: map[string]string{}, LastSeen: now, FirstSeen: old},
+		{ID: "vm:pinned", Name: "pinned", Type: models.AssetVM, Source: "manual", Metadata: map[string]string{}, LastSeen: now, FirstSeen: old},
+		{ID: "vm:new", Name: "new", Type: models.AssetVM, Source: "terraform", Metadata: map[string]string{}, LastSeen: now, FirstSeen: now},
+	} {
+		if err := store.UpsertNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.Close() //nolint:errcheck // best-effort cleanup in test
+
+	err = runCmd(app, app.graphPruneCmd(), "prune", "--older-than", "90d", "--keep-sources", "manual", "--force")
+	if err != nil {
+		t.Fatalf("graph prune error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Deleted 1 nodes") {
+		t.Errorf("expected only vm:old to be deleted, got: %s", buf.String())
+	}
+
+	store, _, err = app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck // best-effort cleanup in test
+	nodes, err := store.ListNodes(ctx, graph.NodeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("expected 2 remaining nodes, got %d", len(nodes))
+	}
+}
+
+func TestGraphPruneCmd_InvalidOlderThan(t *testing.T) {
+	app, _ := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.graphPruneCmd(), "prune", "--older-than", "soon")
+	if err == nil || !strings.Contains(err.Error(), "--older-than") {
+		t.Errorf("expected --older-than error, got %v", err)
+	}
+}
+
 // --- impact node ---
 
 func TestImpactNodeCmd(t *testing.T) {
@@ -728,43 +1310,315 @@ func TestImpactNodeCmd(t *testing.T) {
 		t.Fatalf("impact node error: %v", err)
 	}
 
-	output := buf.String()
-	if !strings.Contains(output, "Impact Analysis") {
-		t.Errorf("expected 'Impact Analysis' in output, got: %s", output)
+	output := buf.String()
+	if !strings.Contains(output, "Impact Analysis") {
+		t.Errorf("expected 'Impact Analysis' in output, got: %s", output)
+	}
+	if !strings.Contains(output, "Blast Radius") {
+		t.Errorf("expected 'Blast Radius' in output, got: %s", output)
+	}
+}
+
+func TestImpactNodeCmd_Redundancy(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.impactCmd(), "impact", "node", "db:pg1", "--redundancy")
+	if err != nil {
+		t.Fatalf("impact node error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Impact Score: 1.00") {
+		t.Errorf("expected impact score in output, got: %s", output)
+	}
+	if !strings.Contains(output, "[down]") {
+		t.Errorf("expected single-instance dependents to be down, got: %s", output)
+	}
+}
+
+func TestImpactNodeCmd_EdgeType(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	// The seeded vm -> db edge is depends_on, so filtering to connects_to
+	// leaves nothing affected.
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "db:pg1", "--edge-type", "connects_to"); err != nil {
+		t.Fatalf("impact node error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Blast Radius: 0 affected assets") {
+		t.Errorf("expected empty blast radius, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "db:pg1", "--edge-type", "depends_on"); err != nil {
+		t.Fatalf("impact node error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Blast Radius: 1 affected assets") {
+		t.Errorf("expected vm:web1 affected, got: %s", buf.String())
+	}
+}
+
+func TestImpactNodeCmd_Depth(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "db:pg1", "--depth", "1"); err != nil {
+		t.Fatalf("impact node error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Blast Radius: 1 affected assets") {
+		t.Errorf("expected direct dependent vm:web1, got: %s", buf.String())
+	}
+}
+
+func TestImpactNodeCmd_Direction(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	// vm:web1 depends on db:pg1, so nothing is upstream of vm:web1.
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "vm:web1", "--direction", "both"); err != nil {
+		t.Fatalf("impact node error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "Blast Radius: 1 affected assets") || !strings.Contains(out, "db:pg1") {
+		t.Errorf("expected db:pg1 reached downstream, got: %s", out)
+	}
+	if !strings.Contains(out, "Direction: both") {
+		t.Errorf("expected direction in header, got: %s", out)
+	}
+
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "vm:web1", "--direction", "sideways"); err == nil {
+		t.Error("expected error for invalid direction")
+	}
+}
+
+func TestGraphCriticalCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphCriticalCmd(), "critical", "--top", "1"); err != nil {
+		t.Fatalf("graph critical error: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "db:pg1") || strings.Contains(out, "vm:web1") {
+		t.Errorf("expected only db:pg1 ranked, got: %s", out)
+	}
+	if !strings.Contains(out, "1.00") {
+		t.Errorf("expected score 1.00, got: %s", out)
+	}
+}
+
+func TestImpactNodeCmd_NotFound(t *testing.T) {
+	app, _ := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.impactCmd(), "impact", "node", "nonexistent:node")
+	if err == nil {
+		t.Error("expected error for nonexistent node")
+	}
+}
+
+// --- scan commands (real fixtures) ---
+
+func TestScanTerraformCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+
+	fixture, err := filepath.Abs("../../testdata/terraform/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = runCmd(app, app.scanCmd(), "scan", "terraform", fixture)
+	if err != nil {
+		t.Fatalf("scan terraform error: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "Discovered") {
+		t.Errorf("expected 'Discovered' in output, got: %s", output)
+	}
+}
+
+func TestScanTerraformCmd_TypeMappings(t *testing.T) {
+	app, buf := newTestApp(t)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	mappings := write("type_mappings.yaml", "mycorp_widget: service\n")
+	app.cfgFile = write("aib.yaml", "scan:\n  type_mappings: "+mappings+"\n")
+	t.Cleanup(func() { terraform.SetTypeMappings(nil) })
+	state := write("widget.tfstate", `{"version": 4, "resources": [{"mode": "managed", "type": "mycorp_widget", "name": "frontend",
+		"provider": "provider[\"registry.terraform.io/mycorp/mycorp\"]", "instances": [{"attributes": {"id": "w-1"}}]}]}`)
+
+	if err := runCmd(app, app.scanCmd(), "scan", "terraform", state); err != nil {
+		t.Fatalf("scan terraform error: %v", err)
+	}
+	buf.Reset()
+	if err := runCmd(app, app.graphNodesCmd(), "nodes", "--type", "service"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "frontend") {
+		t.Errorf("expected mapped service node, got: %s", buf.String())
+	}
+
+	app.cfgFile = write("broken.yaml", "scan:\n  type_mappings: "+filepath.Join(dir, "missing.yaml")+"\n")
+	if err := runCmd(app, app.scanCmd(), "scan", "terraform", state); err == nil || !strings.Contains(err.Error(), "scan.type_mappings") {
+		t.Errorf("expected type_mappings error, got %v", err)
+	}
+}
+
+func TestScanTerraformCmd_DryRun(t *testing.T) {
+	app, buf := newTestApp(t)
+
+	fixture, err := filepath.Abs("../../testdata/terraform/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmd(app, app.scanCmd(), "scan", "terraform", "--dry-run", fixture); err != nil {
+		t.Fatalf("scan terraform --dry-run error: %v", err)
+	}
+	output := buf.String()
+	for _, want := range []string{"Dry run: would store", "Nodes by type:", "Nothing was written"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got: %s", want, output)
+		}
+	}
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck // test cleanup
+	nodes, err := store.ListNodes(context.Background(), graph.NodeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("dry run stored %d nodes, want 0", len(nodes))
+	}
+}
+
+func TestScanTerraformCmd_Exclude(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	data, err := os.ReadFile("../../testdata/terraform/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "testdata", "fixture.tfstate")
+	if err := os.MkdirAll(filepath.Dir(fixture), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fixture, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.tfstate"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmd(app, app.scanCmd(), "scan", "terraform", "--exclude", "testdata", dir); err != nil {
+		t.Fatalf("scan terraform --exclude error: %v", err)
+	}
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck // test cleanup
+	nodes, err := store.ListNodes(context.Background(), graph.NodeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) == 0 {
+		t.Fatal("expected nodes from main.tfstate")
+	}
+	for _, n := range nodes {
+		if n.SourceFile == fixture {
+			t.Errorf("node %s stored from excluded %s", n.ID, fixture)
+		}
+	}
+}
+
+func TestScanReplayCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+
+	fixture, err := filepath.Abs("../../testdata/terraform/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runCmd(app, app.scanCmd(), "scan", "terraform", fixture); err != nil {
+		t.Fatalf("scan terraform error: %v", err)
+	}
+	buf.Reset()
+
+	if err := runCmd(app, app.scanCmd(), "scan", "replay", "1"); err != nil {
+		t.Fatalf("scan replay error: %v", err)
 	}
-	if !strings.Contains(output, "Blast Radius") {
-		t.Errorf("expected 'Blast Radius' in output, got: %s", output)
+	output := buf.String()
+	if !strings.Contains(output, "Replaying scan 1 (terraform across 1 path(s))") || !strings.Contains(output, "Discovered") {
+		t.Errorf("unexpected replay output: %s", output)
 	}
-}
-
-func TestImpactNodeCmd_NotFound(t *testing.T) {
-	app, _ := newTestApp(t)
-	seedTestData(t, app)
 
-	err := runCmd(app, app.impactCmd(), "impact", "node", "nonexistent:node")
-	if err == nil {
-		t.Error("expected error for nonexistent node")
+	if err := runCmd(app, app.scanCmd(), "scan", "replay", "42"); err == nil || !strings.Contains(err.Error(), "scan not found") {
+		t.Errorf("expected scan not found error, got %v", err)
 	}
 }
 
-// --- scan commands (real fixtures) ---
+func TestScanCancelCmd(t *testing.T) {
+	var gotMethod, gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath, gotAuth = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/scan/7" {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":{"code":"SCAN_NOT_RUNNING","message":"scan 7 is not running on this server (status: completed)"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"cancelling","scan_id":3}`))
+	}))
+	defer srv.Close()
 
-func TestScanTerraformCmd(t *testing.T) {
 	app, buf := newTestApp(t)
-
-	fixture, err := filepath.Abs("../../testdata/terraform/sample.tfstate")
-	if err != nil {
-		t.Fatal(err)
+	if err := runCmd(app, app.scanCmd(), "scan", "cancel", "3", "--server", srv.URL, "--token", "secret"); err != nil {
+		t.Fatalf("scan cancel error: %v", err)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/api/v1/scan/3" || gotAuth != "Bearer secret" {
+		t.Errorf("request = %s %s (auth %q), want DELETE /api/v1/scan/3 with bearer token", gotMethod, gotPath, gotAuth)
+	}
+	if !strings.Contains(buf.String(), "Scan 3 cancelled.") {
+		t.Errorf("unexpected output: %s", buf.String())
 	}
 
-	err = runCmd(app, app.scanCmd(), "scan", "terraform", fixture)
-	if err != nil {
-		t.Fatalf("scan terraform error: %v", err)
+	err := runCmd(app, app.scanCmd(), "scan", "cancel", "7", "--server", srv.URL)
+	if err == nil || !strings.Contains(err.Error(), "not running on this server") {
+		t.Errorf("expected not running error, got %v", err)
+	}
+	if err := runCmd(app, app.scanCmd(), "scan", "cancel", "abc"); err == nil || !strings.Contains(err.Error(), "invalid scan ID") {
+		t.Errorf("expected invalid scan ID error, got %v", err)
 	}
+}
 
-	output := buf.String()
-	if !strings.Contains(output, "Discovered") {
-		t.Errorf("expected 'Discovered' in output, got: %s", output)
+func TestServerClient(t *testing.T) {
+	tests := []struct {
+		listen, server, want string
+	}{
+		{":8080", "", "http://127.0.0.1:8080"},
+		{"0.0.0.0:9000", "", "http://127.0.0.1:9000"},
+		{"10.0.0.5:8080", "", "http://10.0.0.5:8080"},
+		{"unix:///run/aib.sock", "", "http://aib"},
+		{":8080", "https://aib.example.com/", "https://aib.example.com"},
+	}
+	for _, tt := range tests {
+		if got, _ := serverClient(tt.listen, tt.server); got != tt.want {
+			t.Errorf("serverClient(%q, %q) = %q, want %q", tt.listen, tt.server, got, tt.want)
+		}
 	}
 }
 
@@ -912,6 +1766,36 @@ func TestScanComposeCmd(t *testing.T) {
 	}
 }
 
+func TestScanComposeCmd_Merge(t *testing.T) {
+	app, _ := newTestApp(t)
+
+	base, err := filepath.Abs("../../internal/parser/compose/testdata/layered/docker-compose.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	override, err := filepath.Abs("../../internal/parser/compose/testdata/layered/docker-compose.override.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmd(app, app.scanCmd(), "scan", "compose", "--merge", base, override); err != nil {
+		t.Fatalf("scan compose --merge error: %v", err)
+	}
+
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck // test cleanup
+	web, err := store.GetNode(context.Background(), "compose:container:web")
+	if err != nil || web == nil {
+		t.Fatalf("GetNode(web) = %v, %v", web, err)
+	}
+	if web.Metadata["image"] != "myapp/web:dev" {
+		t.Errorf("web image = %q, want override myapp/web:dev", web.Metadata["image"])
+	}
+}
+
 func TestScanTerraformPlanCmd(t *testing.T) {
 	app, buf := newTestApp(t)
 
@@ -948,6 +1832,40 @@ func TestGraphExportCmd_Mermaid(t *testing.T) {
 	}
 }
 
+func TestGraphExportCmd_Label(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.graphExportCmd(), "export", "--format", "dot", "--label", "id")
+	if err != nil {
+		t.Fatalf("graph export --label error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `label="vm:web1\\n(vm)"`) {
+		t.Errorf("export dot --label id should label nodes by ID, got: %s", buf.String())
+	}
+
+	if err := runCmd(app, app.graphExportCmd(), "export", "--format", "dot", "--label", "bogus"); err == nil {
+		t.Error("expected error for invalid --label")
+	}
+}
+
+func TestGraphExportCmd_MermaidColor(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	err := runCmd(app, app.graphExportCmd(), "export", "--format", "mermaid", "--color")
+	if err != nil {
+		t.Fatalf("graph export mermaid --color error: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{`subgraph legend["Legend"]`, "class vm_web1 type_vm", "class db_pg1 type_database"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("export mermaid --color missing %q, got: %s", want, output)
+		}
+	}
+}
+
 // --- db backup ---
 
 func TestDBBackupCmd(t *testing.T) {
@@ -1173,6 +2091,32 @@ func TestCertsListCmd_WithCerts(t *testing.T) {
 	}
 }
 
+func TestCertsListCmd_Wide(t *testing.T) {
+	app, buf := newTestApp(t)
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	expires := now.Add(30 * 24 * time.Hour)
+	_ = store.UpsertNode(context.Background(), models.Node{
+		ID: "probe:certificate:example.com", Name: "example.com", Type: models.AssetCertificate,
+		Source: "probe", Metadata: map[string]string{"key_type": "RSA-2048", "sans": "example.com,www.example.com"},
+		ExpiresAt: &expires, LastSeen: now, FirstSeen: now,
+	})
+	_ = store.Close()
+
+	if err := runCmd(app, app.certsCmd(), "certs", "list", "--wide"); err != nil {
+		t.Fatalf("certs list --wide error: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"SANS", "RSA-2048", "example.com, www.example.com"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output, got: %s", want, out)
+		}
+	}
+}
+
 func TestCertsExpiringCmd_WithExpiring(t *testing.T) {
 	app, buf := newTestApp(t)
 	store, _, err := app.openStore()
@@ -1200,6 +2144,50 @@ func TestCertsExpiringCmd_WithExpiring(t *testing.T) {
 	}
 }
 
+func TestCertsExpiringCmd_AllTypes(t *testing.T) {
+	app, buf := newTestApp(t)
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	expires := now.Add(10 * 24 * time.Hour)
+	_ = store.UpsertNode(ctx, models.Node{
+		ID: "tf:secret:api-key", Name: "api-key", Type: models.AssetSecret,
+		Source: "terraform", Metadata: map[string]string{},
+		ExpiresAt: &expires, LastSeen: now, FirstSeen: now,
+	})
+	_ = store.Close()
+
+	if err := runCmd(app, app.certsCmd(), "certs", "expiring"); err != nil {
+		t.Fatalf("certs expiring error: %v", err)
+	}
+	if strings.Contains(buf.String(), "api-key") {
+		t.Errorf("secrets should be excluded without --all-types, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := runCmd(app, app.certsCmd(), "certs", "expiring", "--all-types"); err != nil {
+		t.Fatalf("certs expiring --all-types error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "api-key") {
+		t.Errorf("expected api-key with --all-types, got: %s", buf.String())
+	}
+}
+
+func TestGraphDepsCmd_Upstream(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphDepsCmd(), "deps", "db:pg1", "--direction", "upstream"); err != nil {
+		t.Fatalf("graph deps error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "vm:web1") {
+		t.Errorf("expected vm:web1 upstream of db:pg1, got: %s", buf.String())
+	}
+}
+
 func TestGraphDepsCmd_NoDeps(t *testing.T) {
 	app, buf := newTestApp(t)
 	seedTestData(t, app)
@@ -1248,6 +2236,31 @@ func TestGraphCyclesCmd_WithCycle(t *testing.T) {
 	}
 }
 
+func TestGraphEntryPointsCmd(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphEntryPointsCmd(), "entrypoints"); err != nil {
+		t.Fatalf("graph entrypoints error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "Found 1 entry point(s)") || !strings.Contains(out, "vm:web1") || strings.Contains(out, "db:pg1") {
+		t.Errorf("expected vm:web1 as the only entry point, got: %s", out)
+	}
+
+	buf.Reset()
+	app.outputFormat = "json"
+	if err := runCmd(app, app.graphEntryPointsCmd(), "entrypoints", "--sinks"); err != nil {
+		t.Fatalf("graph entrypoints --sinks error: %v", err)
+	}
+	var sinks []models.Node
+	if err := json.Unmarshal(buf.Bytes(), &sinks); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if len(sinks) != 1 || sinks[0].ID != "db:pg1" {
+		t.Errorf("sinks = %+v, want db:pg1", sinks)
+	}
+}
+
 func TestGraphOrphansCmd_WithOrphans(t *testing.T) {
 	app, buf := newTestApp(t)
 	store, _, err := app.openStore()
@@ -1650,6 +2663,48 @@ func TestGraphNodesCmd_JSON(t *testing.T) {
 	}
 }
 
+func TestGraphNodesCmd_YAML(t *testing.T) {
+	app, buf := newTestApp(t)
+	app.outputFormat = "yaml"
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.graphNodesCmd(), "nodes"); err != nil {
+		t.Fatalf("graph nodes --output=yaml error: %v", err)
+	}
+
+	var nodes []map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &nodes); err != nil {
+		t.Fatalf("invalid YAML output: %v\n%s", err, buf.String())
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("expected 2 nodes, got %d", len(nodes))
+	}
+	// Keys follow the JSON field names.
+	if nodes[0]["id"] == nil || nodes[0]["type"] == nil {
+		t.Errorf("expected id and type keys, got %v", nodes[0])
+	}
+}
+
+func TestDBStatsCmd_YAML(t *testing.T) {
+	app, buf := newTestApp(t)
+	app.outputFormat = "yaml"
+	seedTestData(t, app)
+
+	if err := runCmd(app, app.dbStatsCmd(), "stats"); err != nil {
+		t.Fatalf("db stats --output=yaml error: %v", err)
+	}
+	if strings.HasPrefix(strings.TrimSpace(buf.String()), "{") {
+		t.Fatalf("expected YAML, got JSON: %s", buf.String())
+	}
+	var stats map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid YAML output: %v\n%s", err, buf.String())
+	}
+	if n, ok := stats["total_nodes"].(int); !ok || n != 2 {
+		t.Errorf("expected total_nodes: 2 as an integer, got %#v", stats["total_nodes"])
+	}
+}
+
 func TestGraphEdgesCmd_JSON(t *testing.T) {
 	app, buf := newTestApp(t)
 	app.outputFormat = "json"
@@ -1842,6 +2897,141 @@ func TestImpactNodeCmd_JSON(t *testing.T) {
 	}
 }
 
+func TestImpactNodeCmd_Save(t *testing.T) {
+	app, buf := newTestApp(t)
+	seedTestData(t, app)
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	soon := now.Add(5 * 24 * time.Hour)
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "cert:web", Name: "web.example.com", Type: models.AssetCertificate,
+		Source: "tls-probe", Metadata: map[string]string{}, ExpiresAt: &soon,
+		LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertEdge(ctx, models.Edge{
+		ID: "vm:web1->terminates_tls->cert:web", FromID: "vm:web1", ToID: "cert:web", Type: models.EdgeTerminatesTLS,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	path := filepath.Join(t.TempDir(), "reports", "web1.json")
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "vm:web1", "--direction", "downstream", "--save", path); err != nil {
+		t.Fatalf("impact node --save error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "cert:web expires in") {
+		t.Errorf("expected expiry warning in output, got: %s", buf.String())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report graph.ImpactReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("invalid report JSON: %v\n%s", err, data)
+	}
+	if report.NodeID != "vm:web1" || report.BlastRadius != 2 || report.Direction != graph.DirectionDownstream {
+		t.Errorf("report = %+v", report)
+	}
+	if report.AffectedByType["certificate"] != 1 || report.AffectedByType["database"] != 1 {
+		t.Errorf("affected_by_type = %v", report.AffectedByType)
+	}
+	if len(report.Warnings) != 1 || !strings.HasPrefix(report.Warnings[0], "cert:web expires in") {
+		t.Errorf("warnings = %q, want the expiring cert", report.Warnings)
+	}
+	if report.Tree == nil || len(report.Tree.Children) != 2 {
+		t.Errorf("impact_tree = %+v", report.Tree)
+	}
+}
+
+func TestImpactNodeCmd_Render(t *testing.T) {
+	app, _ := newTestApp(t)
+	seedTestData(t, app)
+	store, _, err := app.openStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+	soon := now.Add(5 * 24 * time.Hour)
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "cert:web&co", Name: "web.example.com", Type: models.AssetCertificate,
+		Source: "tls-probe", Metadata: map[string]string{}, ExpiresAt: &soon,
+		LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertEdge(ctx, models.Edge{
+		ID: "vm:web1->terminates_tls->cert:web&co", FromID: "vm:web1", ToID: "cert:web&co", Type: models.EdgeTerminatesTLS,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	dir := t.TempDir()
+	svgPath := filepath.Join(dir, "out", "web1.svg")
+	pngPath := filepath.Join(dir, "web1.png")
+	if err := runCmd(app, app.impactCmd(), "impact", "node", "vm:web1", "--direction", "downstream", "--svg", svgPath, "--png", pngPath); err != nil {
+		t.Fatalf("impact node --svg --png error: %v", err)
+	}
+
+	data, err := os.ReadFile(svgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var texts []string
+	var redBox bool
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG is not well-formed XML: %v\n%s", err, data)
+		}
+		switch tok := tok.(type) {
+		case xml.CharData:
+			texts = append(texts, string(tok))
+		case xml.StartElement:
+			for _, attr := range tok.Attr {
+				if tok.Name.Local == "rect" && attr.Name.Local == "stroke" && attr.Value == renderPalette["down"][1] {
+					redBox = true
+				}
+			}
+		}
+	}
+	all := strings.Join(texts, "\n")
+	for _, id := range []string{"vm:web1", "db:pg1", "cert:web&co"} {
+		if !strings.Contains(all, id) {
+			t.Errorf("SVG text missing node %q:\n%s", id, data)
+		}
+	}
+	if !redBox {
+		t.Errorf("expected the expiring cert in red:\n%s", data)
+	}
+
+	f, err := os.Open(pngPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close() //nolint:errcheck // test cleanup
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("invalid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() < 100 || b.Dy() < renderBoxH {
+		t.Errorf("PNG bounds = %v", b)
+	}
+}
+
 func TestDBStatsCmd_JSON(t *testing.T) {
 	app, buf := newTestApp(t)
 	app.outputFormat = "json"
@@ -1906,3 +3096,45 @@ func TestCertsListCmd_JSON_Empty(t *testing.T) {
 		t.Fatalf("expected valid JSON, got: %s", buf.String())
 	}
 }
+
+func TestCertsProbeCmd_File(t *testing.T) {
+	app, buf := newTestApp(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "files.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCmd(app, app.certsCmd(), "certs", "probe", "--file", path); err != nil {
+		t.Fatalf("certs probe --file error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "file:certificate:files.example.com") || !strings.Contains(out, "self-signed") {
+		t.Errorf("unexpected output: %s", out)
+	}
+}
+
+func TestCertsProbeCmd_RequiresOneMode(t *testing.T) {
+	app, _ := newTestApp(t)
+	for _, args := range [][]string{
+		{"certs", "probe"},
+		{"certs", "probe", "example.com:443", "--file", "cert.pem"},
+	} {
+		if err := runCmd(app, app.certsCmd(), args...); err == nil || !strings.Contains(err.Error(), "exactly one") {
+			t.Errorf("%v: err = %v, want exactly-one error", args, err)
+		}
+	}
+}
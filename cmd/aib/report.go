@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -12,6 +13,10 @@ import (
 	"time"
 
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser/cloudformation"
+	"github.com/matijazezelj/aib/internal/parser/compose"
+	"github.com/matijazezelj/aib/internal/parser/pulumi"
+	"github.com/matijazezelj/aib/internal/parser/terraform"
 	"github.com/matijazezelj/aib/internal/scanner"
 	"github.com/matijazezelj/aib/pkg/models"
 	"github.com/spf13/cobra"
@@ -370,8 +375,12 @@ func (a *cliApp) scanAutoCmd() *cobra.Command {
 				return err
 			}
 			defer store.Close() //nolint:errcheck // best-effort cleanup
-			sc := scanner.New(store, cfg, a.logger)
+			sc := a.newScanner(store, cfg)
 			for _, req := range reqs {
+				req.Strict = a.strictScan
+				req.DryRun = a.dryRunScan
+				req.Include, req.Exclude, req.ExcludeTypes = a.scanInclude, a.scanExclude, a.scanExcludeTypes
+				req.Timeout = a.scanTimeoutSpec()
 				_, _ = fmt.Fprintf(a.out, "Scanning %s across %d path(s)...\n", req.Source, len(req.Paths))
 				result := sc.RunSync(cmd.Context(), req)
 				a.printScanResult(result)
@@ -399,11 +408,57 @@ func detectAutoScanRequests(paths []string) []scanner.ScanRequest {
 		if len(groups[source]) == 0 {
 			continue
 		}
-		reqs = append(reqs, scanner.ScanRequest{Source: source, Paths: dedupeStrings(groups[source])})
+		req := scanner.ScanRequest{Source: source, Paths: dedupeStrings(groups[source])}
+		if source == "compose" {
+			req.Paths = composeProjectPaths(req.Paths)
+		}
+		if source == "ansible" {
+			// Playbooks are read from a directory alongside the inventories
+			// rather than parsed as inventories themselves. A request takes
+			// one playbook directory, so each gets its own request.
+			var inventories, playbookDirs []string
+			for _, path := range req.Paths {
+				if isAnsiblePlaybook(path) {
+					playbookDirs = append(playbookDirs, filepath.Dir(path))
+					continue
+				}
+				inventories = append(inventories, path)
+			}
+			if len(inventories) == 0 {
+				continue
+			}
+			req.Paths = inventories
+			for _, dir := range dedupeStrings(playbookDirs) {
+				dirReq := req
+				dirReq.Playbooks = dir
+				reqs = append(reqs, dirReq)
+			}
+			if len(playbookDirs) > 0 {
+				continue
+			}
+		}
+		reqs = append(reqs, req)
 	}
 	return reqs
 }
 
+// composeProjectPaths replaces each compose file that lives in a project
+// directory (one holding a standard compose file) with that directory, so
+// the project's base and override files are layered and extends files are
+// resolved, as docker compose does, rather than each being scanned as a
+// project of its own.
+func composeProjectPaths(paths []string) []string {
+	p := compose.NewComposeParser()
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if dir := filepath.Dir(path); p.Supported(dir) {
+			path = dir
+		}
+		out = append(out, path)
+	}
+	return dedupeStrings(out)
+}
+
 func expandAutoScanPath(input string) []string {
 	info, err := os.Stat(input)
 	if err != nil || !info.IsDir() {
@@ -429,7 +484,120 @@ func expandAutoScanPath(input string) []string {
 	return paths
 }
 
+// detectSourceForPath picks the scan source for a single file. Files that
+// exist are identified by content (reusing each parser's Supported check
+// where it inspects content); paths that cannot be read fall back to
+// file-name heuristics.
 func detectSourceForPath(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return detectSourceByName(path)
+	}
+	if info.IsDir() {
+		return ""
+	}
+	return sniffSource(path)
+}
+
+// sniffSource identifies a file's source by its contents.
+func sniffSource(path string) string {
+	switch {
+	case terraform.NewStateParser().Supported(path):
+		return "terraform"
+	case terraform.NewPlanParser().Supported(path):
+		return "terraform-plan"
+	case pulumi.NewPulumiParser().Supported(path):
+		return "pulumi"
+	case compose.NewComposeParser().Supported(path):
+		return "compose"
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	base := strings.ToLower(filepath.Base(path))
+	if ext == ".ini" || base == "hosts" || base == "inventory" {
+		return "ansible"
+	}
+	if ext != ".yml" && ext != ".yaml" && ext != ".json" {
+		return ""
+	}
+
+	keys, firstItem := topLevelYAMLKeys(path)
+	switch {
+	case isPlaybookItem(firstItem):
+		return "ansible"
+	case keys["apiVersion"] && keys["kind"]:
+		return "kubernetes"
+	case keys["services"] && !keys["Resources"]:
+		return "compose"
+	case cloudformation.NewCFNParser().Supported(path):
+		return "cloudformation"
+	case keys["all"]:
+		return "ansible"
+	}
+	return ""
+}
+
+// topLevelYAMLKeys returns the unindented mapping keys found in the first
+// 64 KiB of a YAML file, across all documents, and the keys of the first
+// item when a document is a top-level list (as Ansible playbooks are). It is
+// a cheap sniff, not a parse, so it tolerates templated or partially invalid
+// files.
+func topLevelYAMLKeys(path string) (keys, firstItem map[string]bool) {
+	keys, firstItem = map[string]bool{}, map[string]bool{}
+	f, err := os.Open(path) // #nosec G304 -- path from CLI args
+	if err != nil {
+		return keys, firstItem
+	}
+	defer f.Close() //nolint:errcheck // best-effort cleanup
+
+	buf := make([]byte, 64<<10)
+	n, _ := io.ReadFull(f, buf)
+	inFirstItem, seenItem := false, false
+	for _, line := range strings.Split(string(buf[:n]), "\n") {
+		if line == "" || line[0] == '#' || line == "---" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "- "):
+			inFirstItem = !seenItem
+			seenItem = true
+			line = "  " + line[2:]
+		case line[0] == '-':
+			inFirstItem = false
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			inFirstItem = false
+			if i := strings.Index(line, ":"); i > 0 {
+				keys[strings.Trim(line[:i], `"' `)] = true
+			}
+			continue
+		}
+		// Keys of the first list item sit at exactly two spaces.
+		if inFirstItem && len(line) > 2 && line[:2] == "  " && line[2] != ' ' && line[2] != '#' && line[2] != '-' {
+			if i := strings.Index(line, ":"); i > 2 {
+				firstItem[strings.Trim(line[2:i], `"' `)] = true
+			}
+		}
+	}
+	return keys, firstItem
+}
+
+// isPlaybookItem reports whether the keys of a top-level list item look
+// like an Ansible play.
+func isPlaybookItem(item map[string]bool) bool {
+	return item["hosts"] || item["tasks"] || item["roles"]
+}
+
+// isAnsiblePlaybook reports whether path is a YAML Ansible playbook rather
+// than an inventory.
+func isAnsiblePlaybook(path string) bool {
+	_, firstItem := topLevelYAMLKeys(path)
+	return isPlaybookItem(firstItem)
+}
+
+// detectSourceByName guesses a source from the file name alone.
+func detectSourceByName(path string) string {
 	lower := strings.ToLower(filepath.ToSlash(path))
 	base := filepath.Base(lower)
 	switch {
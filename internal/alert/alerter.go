@@ -40,6 +40,44 @@ type Alerter interface {
 	Send(ctx context.Context, event Event) error
 }
 
+// BatchAlerter is implemented by alerters that deliver several events at
+// once, such as a digest email, instead of one message per event.
+type BatchAlerter interface {
+	Alerter
+
+	// SendBatch dispatches all events together.
+	SendBatch(ctx context.Context, events []Event) error
+}
+
+// SendAll delivers events through a, as one batch if it is a BatchAlerter
+// and one by one otherwise. It returns the last error.
+func SendAll(ctx context.Context, a Alerter, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if b, ok := a.(BatchAlerter); ok {
+		return b.SendBatch(ctx, events)
+	}
+	var lastErr error
+	for _, e := range events {
+		if err := a.Send(ctx, e); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// SendBatch hands the events to each alerter with SendAll.
+func (m *Multi) SendBatch(ctx context.Context, events []Event) error {
+	var lastErr error
+	for _, a := range m.alerters {
+		if err := SendAll(ctx, a, events); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
 // Multi sends events to multiple alerters.
 type Multi struct {
 	alerters []Alerter
@@ -149,3 +149,68 @@ func TestMulti_ReturnsLastError(t *testing.T) {
 		t.Error("expected error from failing alerter")
 	}
 }
+
+type recordingAlerter struct {
+	sent []Event
+}
+
+func (r *recordingAlerter) Name() string { return "recording" }
+
+func (r *recordingAlerter) Send(_ context.Context, event Event) error {
+	r.sent = append(r.sent, event)
+	return nil
+}
+
+func TestSeverityRank_Ordering(t *testing.T) {
+	order := []string{SeverityInfo, SeverityWarning, SeverityCritical, SeverityExpired}
+	for i := 1; i < len(order); i++ {
+		if SeverityRank(order[i-1]) >= SeverityRank(order[i]) {
+			t.Errorf("%s should rank below %s", order[i-1], order[i])
+		}
+	}
+	if SeverityRank("bogus") != -1 {
+		t.Error("unknown severity should rank -1")
+	}
+}
+
+func TestWithMinSeverity_FiltersPerBackend(t *testing.T) {
+	pager := &recordingAlerter{}
+	chat := &recordingAlerter{}
+	filteredPager, err := WithMinSeverity(pager, SeverityCritical)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi := NewMulti(filteredPager, chat)
+
+	for _, sev := range []string{SeverityWarning, SeverityCritical, SeverityExpired, "custom"} {
+		ev := testEvent()
+		ev.Severity = sev
+		if err := multi.Send(context.Background(), ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(chat.sent) != 4 {
+		t.Errorf("unfiltered backend got %d events, want 4", len(chat.sent))
+	}
+	if len(pager.sent) != 3 {
+		t.Fatalf("filtered backend got %d events, want 3 (critical, expired, unknown)", len(pager.sent))
+	}
+	if pager.sent[0].Severity != SeverityCritical {
+		t.Errorf("first filtered event = %q, want critical", pager.sent[0].Severity)
+	}
+	if filteredPager.Name() != "recording" {
+		t.Errorf("Name = %q, want wrapped alerter's name", filteredPager.Name())
+	}
+}
+
+func TestWithMinSeverity_Invalid(t *testing.T) {
+	if _, err := WithMinSeverity(&recordingAlerter{}, "urgent"); err == nil {
+		t.Error("expected error for unknown severity")
+	}
+	a := &recordingAlerter{}
+	got, err := WithMinSeverity(a, "")
+	if err != nil || got != a {
+		t.Error("empty min severity should return the alerter unchanged")
+	}
+}
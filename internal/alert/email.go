@@ -0,0 +1,186 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP connection security modes for SMTPConfig.TLS.
+const (
+	SMTPStartTLS = "starttls" // upgrade a plain connection (usually port 587)
+	SMTPTLS      = "tls"      // implicit TLS from the start (usually port 465)
+	SMTPNoTLS    = "none"     // plaintext, for local relays only
+)
+
+// SMTPConfig holds the mail server and addresses for EmailAlerter. An empty
+// TLS means SMTPStartTLS; Username may be empty for relays without auth.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string //#nosec G117 -- config field, not a hardcoded secret
+	From     string
+	To       []string
+	TLS      string
+}
+
+// EmailAlerter sends events as HTML email. A batch of events becomes one
+// digest message rather than one email each.
+type EmailAlerter struct {
+	cfg  SMTPConfig
+	send func(ctx context.Context, msg []byte) error
+	now  func() time.Time
+}
+
+// NewEmailAlerter creates an alerter that mails cfg.To through cfg.Host.
+func NewEmailAlerter(cfg SMTPConfig) *EmailAlerter {
+	if cfg.TLS == "" {
+		cfg.TLS = SMTPStartTLS
+	}
+	e := &EmailAlerter{cfg: cfg, now: time.Now}
+	e.send = e.smtpSend
+	return e
+}
+
+// Name returns "email".
+func (e *EmailAlerter) Name() string {
+	return "email"
+}
+
+// Send mails a single event.
+func (e *EmailAlerter) Send(ctx context.Context, event Event) error {
+	return e.SendBatch(ctx, []Event{event})
+}
+
+// SendBatch mails all events as one digest.
+func (e *EmailAlerter) SendBatch(ctx context.Context, events []Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	msg, err := e.buildMessage(events)
+	if err != nil {
+		return err
+	}
+	if err := e.send(ctx, msg); err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+	return nil
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<p>{{len .}} alert(s) from AIB:</p>
+<table cellpadding="6" style="border-collapse: collapse">
+<tr style="text-align: left"><th>Severity</th><th>Asset</th><th>Type</th><th>Expires</th><th>Message</th></tr>
+{{range .}}<tr style="border-top: 1px solid #ddd">
+<td>{{.Severity}}</td><td>{{.Asset.Name}}<br><small>{{.Asset.ID}}</small></td><td>{{.Asset.Type}}</td><td>{{.Asset.ExpiresAt}}</td><td>{{.Message}}{{if .Impact}}<br><small>Blast radius: {{.Impact.AffectedCount}} affected</small>{{end}}</td>
+</tr>
+{{end}}</table>
+</body></html>
+`))
+
+// emailSubject names the single event, or counts a digest's events and
+// gives the most severe level.
+func emailSubject(events []Event) string {
+	if len(events) == 1 {
+		return fmt.Sprintf("[aib] %s: %s", events[0].Severity, events[0].Message)
+	}
+	worst := events[0].Severity
+	for _, e := range events[1:] {
+		if SeverityRank(e.Severity) > SeverityRank(worst) {
+			worst = e.Severity
+		}
+	}
+	return fmt.Sprintf("[aib] %d alerts (worst: %s)", len(events), worst)
+}
+
+func (e *EmailAlerter) buildMessage(events []Event) ([]byte, error) {
+	var body bytes.Buffer
+	if err := emailTemplate.Execute(&body, events); err != nil {
+		return nil, fmt.Errorf("rendering email: %w", err)
+	}
+
+	var msg bytes.Buffer
+	header := func(k, v string) {
+		// Strip CR/LF so values can't inject headers.
+		v = strings.NewReplacer("\r", "", "\n", " ").Replace(v)
+		fmt.Fprintf(&msg, "%s: %s\r\n", k, v)
+	}
+	header("From", e.cfg.From)
+	header("To", strings.Join(e.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", emailSubject(events)))
+	header("Date", e.now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/html; charset="utf-8"`)
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// smtpSend delivers msg over SMTP, securing the connection as cfg.TLS says.
+func (e *EmailAlerter) smtpSend(ctx context.Context, msg []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: e.cfg.Host, MinVersion: tls.VersionTLS12}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if e.cfg.TLS == SMTPTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close() //nolint:errcheck // best-effort cleanup
+
+	if e.cfg.TLS == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.cfg.From); err != nil {
+		return err
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
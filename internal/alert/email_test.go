@@ -0,0 +1,136 @@
+package alert
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP accepts one SMTP session on a local port and reports the
+// recipients and message data it received.
+type fakeSMTP struct {
+	addr string
+	rcpt []string
+	data string
+	done chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	f := &fakeSMTP{addr: ln.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint:errcheck // test cleanup
+		tp := textproto.NewConn(conn)
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				_ = tp.PrintfLine("250 localhost")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				f.rcpt = append(f.rcpt, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				_ = tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "DATA"):
+				_ = tp.PrintfLine("354 go ahead")
+				b, _ := tp.ReadDotBytes()
+				f.data = string(b)
+				_ = tp.PrintfLine("250 OK")
+			case strings.HasPrefix(cmd, "QUIT"):
+				_ = tp.PrintfLine("221 bye")
+				return
+			default:
+				_ = tp.PrintfLine("250 OK")
+			}
+		}
+	}()
+	return f
+}
+
+func (f *fakeSMTP) config(t *testing.T) SMTPConfig {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(f.addr)
+	p, _ := strconv.Atoi(port)
+	return SMTPConfig{
+		Host: host,
+		Port: p,
+		From: "aib@example.com",
+		To:   []string{"ops@example.com", "sre@example.com"},
+		TLS:  SMTPNoTLS,
+	}
+}
+
+func subjectOf(t *testing.T, data string) string {
+	t.Helper()
+	hdr, err := textproto.NewReader(bufio.NewReader(strings.NewReader(data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("parsing headers: %v", err)
+	}
+	return hdr.Get("Subject")
+}
+
+func TestEmailAlerter_Send(t *testing.T) {
+	srv := newFakeSMTP(t)
+	e := NewEmailAlerter(srv.config(t))
+
+	if err := e.Send(context.Background(), testEvent()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-srv.done
+
+	if strings.Join(srv.rcpt, ",") != "ops@example.com,sre@example.com" {
+		t.Errorf("recipients = %v", srv.rcpt)
+	}
+	if got, want := subjectOf(t, srv.data), "[aib] warning: "+testEvent().Message; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if !strings.Contains(srv.data, "text/html") || !strings.Contains(srv.data, testEvent().Asset.Name) {
+		t.Errorf("body missing HTML content type or asset name:\n%s", srv.data)
+	}
+}
+
+func TestEmailAlerter_SendBatchIsOneDigest(t *testing.T) {
+	var msgs [][]byte
+	e := NewEmailAlerter(SMTPConfig{From: "aib@example.com", To: []string{"ops@example.com"}})
+	e.send = func(_ context.Context, msg []byte) error {
+		msgs = append(msgs, msg)
+		return nil
+	}
+
+	second := testEvent()
+	second.Asset.ID = "tls:other.example.com:443"
+	second.Asset.Name = "other.example.com"
+	second.Severity = SeverityCritical
+	if err := SendAll(context.Background(), e, []Event{testEvent(), second}); err != nil {
+		t.Fatalf("SendAll: %v", err)
+	}
+
+	if len(msgs) != 1 {
+		t.Fatalf("sent %d emails, want 1 digest", len(msgs))
+	}
+	data := string(msgs[0])
+	if got, want := subjectOf(t, data), "[aib] 2 alerts (worst: critical)"; got != want {
+		t.Errorf("subject = %q, want %q", got, want)
+	}
+	if !strings.Contains(data, testEvent().Asset.Name) || !strings.Contains(data, "other.example.com") {
+		t.Errorf("digest should list both assets:\n%s", data)
+	}
+}
@@ -0,0 +1,143 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyAlerter sends events to the PagerDuty Events API v2. Each asset
+// is one incident, keyed by its ID: alerts trigger (or update) it and a
+// SeverityOK event resolves it.
+type PagerDutyAlerter struct {
+	routingKey string
+	url        string
+	client     *http.Client
+}
+
+// NewPagerDutyAlerter creates an alerter for the service integration with
+// the given routing key.
+func NewPagerDutyAlerter(routingKey string) *PagerDutyAlerter {
+	return &PagerDutyAlerter{
+		routingKey: routingKey,
+		url:        pagerDutyEventsURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Name returns "pagerduty".
+func (p *PagerDutyAlerter) Name() string {
+	return "pagerduty"
+}
+
+// pagerDutyEvent is an Events API v2 request body.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp,omitempty"`
+	Component     string         `json:"component,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// Send triggers or resolves the asset's incident.
+func (p *PagerDutyAlerter) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(p.buildEvent(event))
+	if err != nil {
+		return fmt.Errorf("marshaling pagerduty event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req) //#nosec G704 -- URL is a constant, overridden only in tests
+	if err != nil {
+		return fmt.Errorf("sending pagerduty event: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort cleanup
+
+	// Drain body to enable HTTP connection reuse.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("pagerduty returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (p *PagerDutyAlerter) buildEvent(event Event) pagerDutyEvent {
+	ev := pagerDutyEvent{
+		RoutingKey:  p.routingKey,
+		EventAction: "trigger",
+		DedupKey:    event.Asset.ID,
+	}
+	if event.Severity == SeverityOK {
+		ev.EventAction = "resolve"
+		return ev
+	}
+
+	summary := event.Message
+	if len(summary) > 1024 { // Events API limit
+		summary = summary[:1024]
+	}
+	details := map[string]any{
+		"asset_id":   event.Asset.ID,
+		"asset_type": event.Asset.Type,
+		"severity":   event.Severity,
+	}
+	if event.Asset.ExpiresAt != "" {
+		details["expires_at"] = event.Asset.ExpiresAt
+		details["days_remaining"] = event.Asset.DaysRemaining
+	}
+	if event.Impact != nil {
+		details["affected_count"] = event.Impact.AffectedCount
+		details["affected_services"] = event.Impact.AffectedServices
+	}
+	ev.Payload = &pagerDutyPayload{
+		Summary:       summary,
+		Source:        event.Source,
+		Severity:      pagerDutySeverity(event.Severity),
+		Component:     event.Asset.Name,
+		Class:         event.EventType,
+		CustomDetails: details,
+	}
+	if !event.Timestamp.IsZero() {
+		ev.Payload.Timestamp = event.Timestamp.Format(time.RFC3339)
+	}
+	return ev
+}
+
+// pagerDutySeverity maps a severity onto PagerDuty's critical, error,
+// warning and info levels.
+func pagerDutySeverity(severity string) string {
+	switch severity {
+	case SeverityExpired:
+		return "critical"
+	case SeverityCritical:
+		return "error"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestPagerDuty(t *testing.T, status int) (*PagerDutyAlerter, *[]pagerDutyEvent) {
+	t.Helper()
+	var received []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("decoding payload: %v", err)
+		}
+		received = append(received, ev)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	a := NewPagerDutyAlerter("routing-key-123")
+	a.url = server.URL
+	return a, &received
+}
+
+func TestPagerDutyAlerter_Trigger(t *testing.T) {
+	a, received := newTestPagerDuty(t, http.StatusAccepted)
+
+	event := testEvent()
+	event.Severity = SeverityCritical
+	if err := a.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*received) != 1 {
+		t.Fatalf("received %d events, want 1", len(*received))
+	}
+	ev := (*received)[0]
+	if ev.RoutingKey != "routing-key-123" {
+		t.Errorf("routing_key = %q", ev.RoutingKey)
+	}
+	if ev.EventAction != "trigger" {
+		t.Errorf("event_action = %q, want trigger", ev.EventAction)
+	}
+	if ev.DedupKey != "probe:certificate:example.com" {
+		t.Errorf("dedup_key = %q, want the asset ID", ev.DedupKey)
+	}
+	if ev.Payload == nil {
+		t.Fatal("payload missing")
+	}
+	if ev.Payload.Severity != "error" {
+		t.Errorf("payload.severity = %q, want error", ev.Payload.Severity)
+	}
+	if ev.Payload.Summary != event.Message || ev.Payload.Class != "cert_expiring" {
+		t.Errorf("payload = %+v", ev.Payload)
+	}
+}
+
+func TestPagerDutyAlerter_Resolve(t *testing.T) {
+	a, received := newTestPagerDuty(t, http.StatusAccepted)
+
+	event := testEvent()
+	event.Severity = SeverityOK
+	if err := a.Send(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := (*received)[0]
+	if ev.EventAction != "resolve" || ev.DedupKey != "probe:certificate:example.com" {
+		t.Errorf("event = %+v, want resolve of the asset ID", ev)
+	}
+	if ev.Payload != nil {
+		t.Error("resolve events carry no payload")
+	}
+}
+
+func TestPagerDutyAlerter_ServerError(t *testing.T) {
+	a, _ := newTestPagerDuty(t, http.StatusBadRequest)
+	if err := a.Send(context.Background(), testEvent()); err == nil {
+		t.Error("expected error for 400 response")
+	}
+}
+
+func TestPagerDutySeverity(t *testing.T) {
+	for severity, want := range map[string]string{
+		SeverityExpired:  "critical",
+		SeverityCritical: "error",
+		SeverityWarning:  "warning",
+		SeverityInfo:     "info",
+		"unknown":        "warning",
+	} {
+		if got := pagerDutySeverity(severity); got != want {
+			t.Errorf("pagerDutySeverity(%q) = %q, want %q", severity, got, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+)
+
+// Severity levels in ascending order of urgency.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+	SeverityExpired  = "expired"
+)
+
+// SeverityOK marks an event reporting that an earlier alert has cleared,
+// e.g. a renewed certificate. It is outside the ordered scale, so
+// min_severity filters never drop it.
+const SeverityOK = "ok"
+
+var severityRanks = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+	SeverityExpired:  3,
+}
+
+// SeverityRank returns the position of a severity on the ordered scale
+// (info < warning < critical < expired), or -1 for unknown values.
+func SeverityRank(severity string) int {
+	if r, ok := severityRanks[severity]; ok {
+		return r
+	}
+	return -1
+}
+
+// ValidSeverity reports whether severity is a known level. The empty string
+// is accepted and means "no threshold".
+func ValidSeverity(severity string) bool {
+	return severity == "" || SeverityRank(severity) >= 0
+}
+
+// filtered wraps an alerter and drops events below a minimum severity.
+type filtered struct {
+	Alerter
+	minRank int
+}
+
+// WithMinSeverity returns an alerter that only forwards events whose
+// severity is at or above min. An empty min returns a unchanged.
+func WithMinSeverity(a Alerter, min string) (Alerter, error) {
+	if min == "" {
+		return a, nil
+	}
+	rank := SeverityRank(min)
+	if rank < 0 {
+		return nil, fmt.Errorf("unknown severity %q", min)
+	}
+	return &filtered{Alerter: a, minRank: rank}, nil
+}
+
+// Send forwards the event only if it meets the minimum severity. Events
+// with an unknown severity are always forwarded so nothing is lost silently.
+func (f *filtered) Send(ctx context.Context, event Event) error {
+	if r := SeverityRank(event.Severity); r >= 0 && r < f.minRank {
+		return nil
+	}
+	return f.Alerter.Send(ctx, event)
+}
+
+// SendBatch forwards the events that meet the minimum severity.
+func (f *filtered) SendBatch(ctx context.Context, events []Event) error {
+	var kept []Event
+	for _, e := range events {
+		if r := SeverityRank(e.Severity); r < 0 || r >= f.minRank {
+			kept = append(kept, e)
+		}
+	}
+	return SendAll(ctx, f.Alerter, kept)
+}
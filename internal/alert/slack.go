@@ -184,13 +184,16 @@ func (s *SlackAlerter) buildPayload(event Event) slackPayload {
 	return payload
 }
 
-// severityColor maps severity levels to Slack color hex codes.
+// severityColor maps severity levels to Slack color hex codes: red for
+// critical and expired, yellow for warning, blue for info.
 func severityColor(severity string) string {
 	switch strings.ToLower(severity) {
-	case "critical", "expired":
+	case SeverityCritical, SeverityExpired:
 		return "#E01E5A"
-	case "warning":
+	case SeverityWarning:
 		return "#ECB22E"
+	case SeverityInfo:
+		return "#36C5F0"
 	case "ok":
 		return "#2EB886"
 	default:
@@ -201,10 +204,12 @@ func severityColor(severity string) string {
 // severityEmoji maps severity levels to Slack emoji.
 func severityEmoji(severity string) string {
 	switch strings.ToLower(severity) {
-	case "critical", "expired":
+	case SeverityCritical, SeverityExpired:
 		return ":red_circle:"
-	case "warning":
+	case SeverityWarning:
 		return ":warning:"
+	case SeverityInfo:
+		return ":information_source:"
 	case "ok":
 		return ":large_green_circle:"
 	default:
@@ -132,6 +132,7 @@ func TestSlackAlerter_SeverityColors(t *testing.T) {
 		{"critical", "#E01E5A"},
 		{"expired", "#E01E5A"},
 		{"warning", "#ECB22E"},
+		{"info", "#36C5F0"},
 		{"ok", "#2EB886"},
 		{"unknown", "#CCCCCC"},
 	}
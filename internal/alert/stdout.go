@@ -43,6 +43,8 @@ func severityIcon(severity string) string {
 		return "[INFO]"
 	case "expired":
 		return "[EXPD]"
+	case SeverityOK:
+		return "[ OK ]"
 	default:
 		return "[----]"
 	}
@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ThrottleStore persists when each alert was last sent, so a restart does
+// not re-send alerts that are still inside the dedup window.
+type ThrottleStore interface {
+	LastAlertSent(ctx context.Context, key string) (time.Time, bool, error)
+	RecordAlertSent(ctx context.Context, key string, at time.Time) error
+}
+
+// Throttler wraps an alerter and drops an event if one for the same asset
+// and severity was sent within the window. Because thresholds map to
+// severities, an asset crossing into a new threshold still alerts at once.
+type Throttler struct {
+	next   Alerter
+	window time.Duration
+	store  ThrottleStore // optional
+	now    func() time.Time
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+}
+
+// NewThrottler returns a Throttler forwarding to next. store may be nil to
+// keep state in memory only.
+func NewThrottler(next Alerter, window time.Duration, store ThrottleStore) *Throttler {
+	return &Throttler{
+		next:   next,
+		window: window,
+		store:  store,
+		now:    time.Now,
+		sent:   make(map[string]time.Time),
+	}
+}
+
+// Name returns the wrapped alerter's name.
+func (t *Throttler) Name() string { return t.next.Name() }
+
+// Send forwards the event unless a duplicate was sent within the window.
+// Only successful sends start the window, so a failed delivery is retried
+// on the next cycle. Store errors fail open: the event is sent.
+func (t *Throttler) Send(ctx context.Context, event Event) error {
+	return t.SendBatch(ctx, []Event{event})
+}
+
+// SendBatch forwards the events that are not duplicates as one batch.
+func (t *Throttler) SendBatch(ctx context.Context, events []Event) error {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var due []Event
+	for _, e := range events {
+		if !t.recentlySent(ctx, throttleKey(e), now) {
+			due = append(due, e)
+		}
+	}
+	if err := SendAll(ctx, t.next, due); err != nil {
+		return err
+	}
+	for _, e := range due {
+		key := throttleKey(e)
+		t.sent[key] = now
+		if t.store != nil {
+			_ = t.store.RecordAlertSent(ctx, key, now)
+		}
+	}
+	return nil
+}
+
+func throttleKey(e Event) string {
+	return e.Asset.ID + "|" + e.Severity
+}
+
+// recentlySent reports whether key was sent within the window before now.
+// t.mu must be held.
+func (t *Throttler) recentlySent(ctx context.Context, key string, now time.Time) bool {
+	last, ok := t.sent[key]
+	if !ok && t.store != nil {
+		if at, found, err := t.store.LastAlertSent(ctx, key); err == nil && found {
+			last, ok = at, true
+		}
+	}
+	return ok && now.Sub(last) < t.window
+}
@@ -0,0 +1,88 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memThrottleStore is an in-memory ThrottleStore standing in for SQLite.
+type memThrottleStore map[string]time.Time
+
+func (m memThrottleStore) LastAlertSent(_ context.Context, key string) (time.Time, bool, error) {
+	t, ok := m[key]
+	return t, ok, nil
+}
+
+func (m memThrottleStore) RecordAlertSent(_ context.Context, key string, at time.Time) error {
+	m[key] = at
+	return nil
+}
+
+type failingAlerter struct{ calls int }
+
+func (f *failingAlerter) Name() string { return "failing" }
+
+func (f *failingAlerter) Send(context.Context, Event) error {
+	f.calls++
+	return errors.New("boom")
+}
+
+func TestThrottler_DropsDuplicateWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	rec := &recordingAlerter{}
+	th := NewThrottler(rec, 24*time.Hour, nil)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	th.now = func() time.Time { return now }
+
+	_ = th.Send(ctx, testEvent())
+	now = now.Add(6 * time.Hour)
+	_ = th.Send(ctx, testEvent())
+	if len(rec.sent) != 1 {
+		t.Fatalf("sent %d events, want 1 (duplicate within window dropped)", len(rec.sent))
+	}
+
+	// A new severity for the same asset is a new threshold and goes out.
+	critical := testEvent()
+	critical.Severity = SeverityCritical
+	_ = th.Send(ctx, critical)
+	if len(rec.sent) != 2 {
+		t.Fatalf("sent %d events, want 2 (new severity)", len(rec.sent))
+	}
+
+	now = now.Add(18 * time.Hour)
+	_ = th.Send(ctx, testEvent())
+	if len(rec.sent) != 3 {
+		t.Errorf("sent %d events, want 3 (window elapsed)", len(rec.sent))
+	}
+}
+
+func TestThrottler_FailedSendIsRetried(t *testing.T) {
+	ctx := context.Background()
+	f := &failingAlerter{}
+	th := NewThrottler(f, time.Hour, nil)
+
+	if err := th.Send(ctx, testEvent()); err == nil {
+		t.Fatal("expected error from failing alerter")
+	}
+	_ = th.Send(ctx, testEvent())
+	if f.calls != 2 {
+		t.Errorf("calls = %d, want 2 (failures don't start the window)", f.calls)
+	}
+}
+
+func TestThrottler_PersistsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	store := memThrottleStore{}
+
+	first := &recordingAlerter{}
+	_ = NewThrottler(first, time.Hour, store).Send(ctx, testEvent())
+
+	// A fresh throttler, as after a restart, reads the window from the store.
+	second := &recordingAlerter{}
+	_ = NewThrottler(second, time.Hour, store).Send(ctx, testEvent())
+	if len(first.sent) != 1 || len(second.sent) != 0 {
+		t.Errorf("sent %d then %d events, want 1 then 0", len(first.sent), len(second.sent))
+	}
+}
@@ -0,0 +1,142 @@
+package certs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/alert"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// DefaultExpiryThresholds are the warning and critical windows, in days,
+// used for asset types without a configured override.
+var DefaultExpiryThresholds = []int{30, 7}
+
+// ExpiryThresholds maps asset types (e.g. "certificate", "secret") to their
+// expiry windows in days, sorted descending. The first value starts the
+// warning window and the last starts the critical window; a single value
+// marks the asset critical as soon as it enters the window.
+type ExpiryThresholds map[string][]int
+
+// For returns the thresholds for an asset type, falling back to
+// DefaultExpiryThresholds.
+func (t ExpiryThresholds) For(assetType models.AssetType) []int {
+	if th, ok := t[string(assetType)]; ok && len(th) > 0 {
+		return th
+	}
+	return DefaultExpiryThresholds
+}
+
+// Status classifies an asset expiring in the given number of days as
+// "ok", "warning", "critical", or "expired".
+func (t ExpiryThresholds) Status(assetType models.AssetType, days int) string {
+	th := t.For(assetType)
+	switch {
+	case days < 0:
+		return "expired"
+	case days <= th[len(th)-1]:
+		return "critical"
+	case days <= th[0]:
+		return "warning"
+	default:
+		return "ok"
+	}
+}
+
+// Warning returns "<id> expires in N days" for a node inside its type's
+// expiry warning window, or "" for nodes without an expiry or outside it.
+func (t ExpiryThresholds) Warning(n *models.Node) string {
+	if n.ExpiresAt == nil {
+		return ""
+	}
+	days := DaysUntilExpiry(*n.ExpiresAt)
+	if t.Status(n.Type, days) == "ok" {
+		return ""
+	}
+	return fmt.Sprintf("%s expires in %d days", n.ID, days)
+}
+
+// StatusRevoked is the status of a certificate its CA has revoked, which
+// takes precedence over its expiry status.
+const StatusRevoked = "revoked"
+
+// IsAlerting reports whether an expiry status should raise an alert.
+func IsAlerting(status string) bool {
+	return status == "warning" || status == "critical" || status == "expired" || status == StatusRevoked
+}
+
+// ExpiryEvent builds the alert event for an expiring asset. Certificates keep
+// the "cert_expiring" event type; other assets use "asset_expiring".
+func ExpiryEvent(ci CertInfo) alert.Event {
+	if ci.Status == StatusRevoked {
+		return revokedEvent(ci)
+	}
+	eventType := "asset_expiring"
+	message := fmt.Sprintf("%s %s expires in %d days", ci.Node.Type, ci.Node.Name, ci.DaysRemaining)
+	if ci.Node.Type == models.AssetCertificate {
+		eventType = "cert_expiring"
+		message = fmt.Sprintf("Certificate %s expires in %d days", ci.Node.Name, ci.DaysRemaining)
+	}
+	event := alert.Event{
+		Source:    "aib",
+		EventType: eventType,
+		Severity:  ci.Status,
+		Asset: alert.Asset{
+			ID:            ci.Node.ID,
+			Name:          ci.Node.Name,
+			Type:          string(ci.Node.Type),
+			DaysRemaining: ci.DaysRemaining,
+		},
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+	if ci.Node.ExpiresAt != nil {
+		event.Asset.ExpiresAt = ci.Node.ExpiresAt.Format(time.RFC3339)
+	}
+	return event
+}
+
+// ResolvedEvent builds the event reporting that an asset which alerted
+// earlier is no longer expiring, typically because it was renewed.
+func ResolvedEvent(ci CertInfo) alert.Event {
+	eventType := "asset_renewed"
+	message := fmt.Sprintf("%s %s is no longer expiring", ci.Node.Type, ci.Node.Name)
+	if ci.Node.Type == models.AssetCertificate {
+		eventType = "cert_renewed"
+		message = fmt.Sprintf("Certificate %s is no longer expiring", ci.Node.Name)
+	}
+	return alert.Event{
+		Source:    "aib",
+		EventType: eventType,
+		Severity:  alert.SeverityOK,
+		Asset: alert.Asset{
+			ID:   ci.Node.ID,
+			Name: ci.Node.Name,
+			Type: string(ci.Node.Type),
+		},
+		Message:   message,
+		Timestamp: time.Now(),
+	}
+}
+
+// revokedEvent builds the critical "cert_revoked" event for a certificate
+// whose CA has revoked it.
+func revokedEvent(ci CertInfo) alert.Event {
+	event := alert.Event{
+		Source:    "aib",
+		EventType: "cert_revoked",
+		Severity:  alert.SeverityCritical,
+		Asset: alert.Asset{
+			ID:            ci.Node.ID,
+			Name:          ci.Node.Name,
+			Type:          string(ci.Node.Type),
+			DaysRemaining: ci.DaysRemaining,
+		},
+		Message:   fmt.Sprintf("Certificate %s has been revoked", ci.Node.Name),
+		Timestamp: time.Now(),
+	}
+	if ci.Node.ExpiresAt != nil {
+		event.Asset.ExpiresAt = ci.Node.ExpiresAt.Format(time.RFC3339)
+	}
+	return event
+}
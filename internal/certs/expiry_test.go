@@ -0,0 +1,119 @@
+package certs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestExpiryThresholds_Status(t *testing.T) {
+	th := ExpiryThresholds{
+		"secret":   {90, 14},
+		"kms_key":  {60},
+		"empty":    {},
+		"disabled": nil,
+	}
+	tests := []struct {
+		typ  models.AssetType
+		days int
+		want string
+	}{
+		{models.AssetCertificate, 20, "warning"}, // default 30/7
+		{models.AssetCertificate, 5, "critical"},
+		{models.AssetSecret, 60, "warning"},
+		{models.AssetSecret, 14, "critical"},
+		{models.AssetSecret, 91, "ok"},
+		{models.AssetKMSKey, 45, "critical"}, // single threshold is critical
+		{models.AssetKMSKey, 61, "ok"},
+		{"empty", 20, "warning"}, // empty list falls back to defaults
+		{models.AssetSecret, -1, "expired"},
+	}
+	for _, tt := range tests {
+		if got := th.Status(tt.typ, tt.days); got != tt.want {
+			t.Errorf("Status(%s, %d) = %q, want %q", tt.typ, tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestExpiryThresholds_Warning(t *testing.T) {
+	th := ExpiryThresholds{"secret": {90, 14}}
+	soon := time.Now().Add(5*24*time.Hour + time.Hour)
+	far := time.Now().Add(60*24*time.Hour + time.Hour)
+
+	if got := th.Warning(&models.Node{ID: "cert1", Type: models.AssetCertificate, ExpiresAt: &soon}); got != "cert1 expires in 5 days" {
+		t.Errorf("expiring cert warning = %q", got)
+	}
+	if got := th.Warning(&models.Node{ID: "cert2", Type: models.AssetCertificate, ExpiresAt: &far}); got != "" {
+		t.Errorf("cert outside the default window warned: %q", got)
+	}
+	if got := th.Warning(&models.Node{ID: "secret1", Type: models.AssetSecret, ExpiresAt: &far}); got != "secret1 expires in 60 days" {
+		t.Errorf("secret inside its configured window warning = %q", got)
+	}
+	if got := th.Warning(&models.Node{ID: "vm1", Type: models.AssetVM}); got != "" {
+		t.Errorf("node without expiry warned: %q", got)
+	}
+}
+
+func TestAlertingAssets_AllTypes(t *testing.T) {
+	store := newTestStore(t)
+	tracker := NewTracker(store, nil, newNopLogger())
+	tracker.SetExpiryThresholds(ExpiryThresholds{"secret": {60, 7}})
+	ctx := context.Background()
+
+	now := time.Now()
+	in45 := now.Add(45 * 24 * time.Hour)
+	expired := now.Add(-48 * time.Hour)
+	for _, n := range []models.Node{
+		{ID: "secret:rotating", Name: "api-key", Type: models.AssetSecret, ExpiresAt: &in45},
+		{ID: "cert:far", Name: "far", Type: models.AssetCertificate, ExpiresAt: &in45},
+		{ID: "cert:gone", Name: "gone", Type: models.AssetCertificate, ExpiresAt: &expired},
+		{ID: "vm:web", Name: "web", Type: models.AssetVM},
+	} {
+		n.Source, n.Metadata, n.LastSeen, n.FirstSeen = "test", map[string]string{}, now, now
+		if err := store.UpsertNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assets, err := tracker.AlertingAssets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, a := range assets {
+		got[a.Node.ID] = a.Status
+	}
+	want := map[string]string{"secret:rotating": "warning", "cert:gone": "expired"}
+	if len(got) != len(want) || got["secret:rotating"] != "warning" || got["cert:gone"] != "expired" {
+		t.Errorf("AlertingAssets = %v, want %v", got, want)
+	}
+
+	expiring, err := tracker.ExpiringAssets(ctx, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expiring) != 2 {
+		t.Errorf("ExpiringAssets(60) returned %d assets, want 2 (secret and cert, not the expired one)", len(expiring))
+	}
+}
+
+func TestExpiryEvent(t *testing.T) {
+	expires := time.Now().Add(5 * 24 * time.Hour)
+	cert := ExpiryEvent(CertInfo{
+		Node:          models.Node{ID: "cert:a", Name: "a", Type: models.AssetCertificate, ExpiresAt: &expires},
+		DaysRemaining: 5, Status: "critical",
+	})
+	if cert.EventType != "cert_expiring" || cert.Severity != "critical" || cert.Asset.ExpiresAt == "" {
+		t.Errorf("unexpected cert event: %+v", cert)
+	}
+
+	secret := ExpiryEvent(CertInfo{
+		Node:          models.Node{ID: "secret:b", Name: "b", Type: models.AssetSecret},
+		DaysRemaining: 20, Status: "warning",
+	})
+	if secret.EventType != "asset_expiring" || secret.Message != "secret b expires in 20 days" {
+		t.Errorf("unexpected secret event: %+v", secret)
+	}
+}
@@ -0,0 +1,114 @@
+package certs
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// ErrNoCertificates is returned by ParsePEM when the input holds no
+// CERTIFICATE blocks.
+var ErrNoCertificates = errors.New("no certificates found in PEM data")
+
+// ParsePEM extracts every certificate of a PEM file or bundle into a
+// certificate node, skipping other blocks such as private keys. Nodes are
+// keyed by subject common name, so a renewed certificate replaces the old
+// one. Nothing is stored; see StorePEMFile.
+func (t *Tracker) ParsePEM(data []byte) ([]CertInfo, error) {
+	now := time.Now()
+	var infos []CertInfo
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate %d: %w", len(infos)+1, err)
+		}
+		infos = append(infos, t.Info(pemNode(cert, now)))
+	}
+	if len(infos) == 0 {
+		return nil, ErrNoCertificates
+	}
+	return infos, nil
+}
+
+// StorePEMFile parses the PEM file at path and stores its certificates.
+func (t *Tracker) StorePEMFile(ctx context.Context, path string) ([]CertInfo, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- path is supplied by the CLI user
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	infos, err := t.ParsePEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range infos {
+		infos[i].Node.SourceFile = path
+		if err := t.store.UpsertNode(ctx, infos[i].Node); err != nil {
+			return nil, fmt.Errorf("storing certificate: %w", err)
+		}
+	}
+	return infos, nil
+}
+
+// Info computes the expiry status of a node under the configured thresholds.
+// Nodes without an expiry date have status "unknown".
+func (t *Tracker) Info(n models.Node) CertInfo {
+	if n.ExpiresAt == nil {
+		return CertInfo{Node: n, DaysRemaining: -1, Status: "unknown"}
+	}
+	ci := CertInfo{Node: n, DaysRemaining: DaysUntilExpiry(*n.ExpiresAt)}
+	ci.Status = t.status(n, ci.DaysRemaining)
+	return ci
+}
+
+func pemNode(cert *x509.Certificate, now time.Time) models.Node {
+	name := cert.Subject.CommonName
+	if name == "" && len(cert.DNSNames) > 0 {
+		name = cert.DNSNames[0]
+	}
+	if name == "" {
+		name = cert.SerialNumber.String()
+	}
+	notAfter := cert.NotAfter
+	node := models.Node{
+		ID:        "file:certificate:" + name,
+		Name:      name,
+		Type:      models.AssetCertificate,
+		Source:    "file",
+		Provider:  cert.Issuer.CommonName,
+		ExpiresAt: &notAfter,
+		LastSeen:  now,
+		FirstSeen: now,
+		Metadata: map[string]string{
+			"issuer":              cert.Issuer.CommonName,
+			"serial":              cert.SerialNumber.String(),
+			"dns_names":           fmt.Sprintf("%v", cert.DNSNames),
+			"not_before":          cert.NotBefore.Format(time.RFC3339),
+			"not_after":           cert.NotAfter.Format(time.RFC3339),
+			"is_ca":               strconv.FormatBool(cert.IsCA),
+			"self_signed":         strconv.FormatBool(isSelfSigned(cert)),
+			"sans":                strings.Join(subjectAltNames(cert), ","),
+			"key_type":            keyType(cert),
+			"signature_algorithm": cert.SignatureAlgorithm.String(),
+		},
+	}
+	if weak := weaknesses(cert); len(weak) > 0 {
+		node.Metadata["weaknesses"] = strings.Join(weak, "; ")
+	}
+	return node
+}
@@ -0,0 +1,113 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// selfSignedPEM returns a PEM bundle holding a self-signed certificate for
+// dnsNames followed by its private key.
+func selfSignedPEM(t *testing.T, cn string, dnsNames []string, notAfter time.Time) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(out, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})...)
+}
+
+func newPEMTracker(t *testing.T) *Tracker {
+	t.Helper()
+	return NewTracker(newTestStore(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestParsePEM(t *testing.T) {
+	notAfter := time.Now().Add(10 * 24 * time.Hour).Truncate(time.Second).UTC()
+	data := selfSignedPEM(t, "api.example.com", []string{"api.example.com", "www.example.com"}, notAfter)
+
+	infos, err := newPEMTracker(t).ParsePEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 {
+		t.Fatalf("got %d certificates, want 1 (the key block is skipped)", len(infos))
+	}
+	ci := infos[0]
+	if ci.Node.ID != "file:certificate:api.example.com" {
+		t.Errorf("ID = %q", ci.Node.ID)
+	}
+	if ci.Node.ExpiresAt == nil || !ci.Node.ExpiresAt.Equal(notAfter) {
+		t.Errorf("ExpiresAt = %v, want %v", ci.Node.ExpiresAt, notAfter)
+	}
+	if got := ci.Node.Metadata["dns_names"]; got != "[api.example.com www.example.com]" {
+		t.Errorf("dns_names = %q", got)
+	}
+	if ci.Node.Metadata["self_signed"] != "true" {
+		t.Errorf("self_signed = %q, want true", ci.Node.Metadata["self_signed"])
+	}
+	if ci.Status != "warning" {
+		t.Errorf("Status = %q, want warning at 10 days", ci.Status)
+	}
+}
+
+func TestParsePEM_NoCertificates(t *testing.T) {
+	_, err := newPEMTracker(t).ParsePEM([]byte("not a certificate"))
+	if !errors.Is(err, ErrNoCertificates) {
+		t.Errorf("err = %v, want ErrNoCertificates", err)
+	}
+}
+
+func TestStorePEMFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	data := append(
+		selfSignedPEM(t, "a.example.com", []string{"a.example.com"}, time.Now().AddDate(1, 0, 0)),
+		selfSignedPEM(t, "b.example.com", []string{"b.example.com"}, time.Now().AddDate(1, 0, 0))...,
+	)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := newPEMTracker(t)
+	if _, err := tracker.StorePEMFile(context.Background(), path); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := tracker.ListCerts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("stored %d certificates, want 2", len(stored))
+	}
+	if stored[0].Node.SourceFile != path {
+		t.Errorf("SourceFile = %q, want %q", stored[0].Node.SourceFile, path)
+	}
+}
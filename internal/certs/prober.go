@@ -1,12 +1,21 @@
 package certs
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 	"time"
 )
 
+// probeRoots is the trust store chains are verified against; nil means the
+// system pool. Tests substitute their own CA.
+var probeRoots *x509.CertPool
+
 // ProbeResult contains the result of probing a TLS endpoint.
 type ProbeResult struct {
 	Host       string     `json:"host"`
@@ -18,6 +27,41 @@ type ProbeResult struct {
 	DNSNames   []string   `json:"dns_names"`
 	Serial     string     `json:"serial"`
 	Error      string     `json:"error,omitempty"`
+
+	SANs               []string `json:"sans,omitempty"`
+	KeyType            string   `json:"key_type"`            // e.g. "RSA-2048", "ECDSA-P256"
+	SignatureAlgorithm string   `json:"signature_algorithm"` // e.g. "SHA256-RSA"
+	Weaknesses         []string `json:"weaknesses,omitempty"`
+
+	// Chain lists the intermediates between the leaf and the root: those of
+	// the verified chain, or the presented ones if verification failed.
+	Chain      []ChainCert `json:"chain,omitempty"`
+	ChainValid bool        `json:"chain_valid"`
+	ChainError string      `json:"chain_error,omitempty"`
+	SelfSigned bool        `json:"self_signed"`
+
+	// leaf and its issuer, if known, for revocation checks.
+	leaf, issuer *x509.Certificate
+}
+
+// ChainCert describes one intermediate certificate of a probed chain.
+type ChainCert struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// EarliestExpiry returns when the first certificate of the chain, leaf
+// included, expires. An intermediate expiring before the leaf breaks the
+// chain at that time.
+func (r *ProbeResult) EarliestExpiry() time.Time {
+	earliest := r.NotAfter
+	for _, c := range r.Chain {
+		if c.NotAfter.Before(earliest) {
+			earliest = c.NotAfter
+		}
+	}
+	return earliest
 }
 
 // Probe connects to a TLS endpoint and inspects the certificate chain.
@@ -52,7 +96,7 @@ func Probe(hostPort string, timeout time.Duration) (*ProbeResult, error) {
 	}
 
 	leaf := certs[0]
-	return &ProbeResult{
+	result := &ProbeResult{
 		Host:      host,
 		Port:      port,
 		Subject:   leaf.Subject.CommonName,
@@ -61,7 +105,115 @@ func Probe(hostPort string, timeout time.Duration) (*ProbeResult, error) {
 		NotAfter:  leaf.NotAfter,
 		DNSNames:  leaf.DNSNames,
 		Serial:    leaf.SerialNumber.String(),
-	}, nil
+
+		SANs:               subjectAltNames(leaf),
+		KeyType:            keyType(leaf),
+		SignatureAlgorithm: leaf.SignatureAlgorithm.String(),
+		Weaknesses:         weaknesses(leaf),
+
+		leaf: leaf,
+	}
+	checkChain(result, certs)
+	return result, nil
+}
+
+// checkChain verifies the presented certificates against probeRoots and
+// fills in the chain fields of r. Only trust is checked, not the hostname:
+// endpoints are often probed by IP or through a load balancer name.
+func checkChain(r *ProbeResult, presented []*x509.Certificate) {
+	leaf := presented[0]
+	r.SelfSigned = isSelfSigned(leaf)
+
+	intermediates := x509.NewCertPool()
+	for _, c := range presented[1:] {
+		intermediates.AddCert(c)
+	}
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         probeRoots,
+		Intermediates: intermediates,
+	})
+	if err == nil && len(chains) > 0 {
+		r.ChainValid = true
+		// chains[0] runs leaf, intermediates..., root. A leaf that is itself
+		// a trusted root makes a chain of one.
+		chain := chains[0]
+		if len(chain) < 2 {
+			return
+		}
+		r.issuer = chain[1]
+		for _, c := range chain[1 : len(chain)-1] {
+			r.Chain = append(r.Chain, chainCert(c))
+		}
+		return
+	}
+
+	r.ChainError = err.Error()
+	if len(presented) > 1 {
+		r.issuer = presented[1]
+	}
+	// Skip presented roots: servers often send a stale self-signed root
+	// that clients ignore, and it shouldn't count toward expiry.
+	for _, c := range presented[1:] {
+		if !isSelfSigned(c) {
+			r.Chain = append(r.Chain, chainCert(c))
+		}
+	}
+}
+
+func chainCert(c *x509.Certificate) ChainCert {
+	return ChainCert{
+		Subject:  c.Subject.CommonName,
+		Issuer:   c.Issuer.CommonName,
+		NotAfter: c.NotAfter,
+	}
+}
+
+// subjectAltNames lists every SAN of c: DNS names, IPs, emails and URIs.
+func subjectAltNames(c *x509.Certificate) []string {
+	sans := append([]string(nil), c.DNSNames...)
+	for _, ip := range c.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, c.EmailAddresses...)
+	for _, u := range c.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// keyType describes c's public key as algorithm and size, e.g. "RSA-2048"
+// or "ECDSA-P256".
+func keyType(c *x509.Certificate) string {
+	switch k := c.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return c.PublicKeyAlgorithm.String()
+	}
+}
+
+// weaknesses flags keys and signature algorithms that clients reject or
+// are phasing out: RSA keys under 2048 bits and MD5 or SHA-1 signatures.
+func weaknesses(c *x509.Certificate) []string {
+	var weak []string
+	if k, ok := c.PublicKey.(*rsa.PublicKey); ok && k.N.BitLen() < 2048 {
+		weak = append(weak, fmt.Sprintf("weak key: RSA-%d", k.N.BitLen()))
+	}
+	switch c.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		weak = append(weak, "deprecated signature algorithm: "+c.SignatureAlgorithm.String())
+	}
+	return weak
+}
+
+// isSelfSigned reports whether c is its own issuer and signed with its own key.
+func isSelfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawSubject, c.RawIssuer) &&
+		c.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature) == nil
 }
 
 // DaysUntilExpiry returns the number of days until a certificate expires.
@@ -1,7 +1,14 @@
 package certs
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -100,3 +107,150 @@ func TestProbe_CertDetails(t *testing.T) {
 		t.Errorf("Serial = %q, want %q", result.Serial, wantSerial)
 	}
 }
+
+// testCA issues certificates for chain tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate for cn signed by parent, or self-signed if
+// parent is nil.
+func issue(t *testing.T, parent *testCA, cn string, isCA bool, notAfter time.Time) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// serveChain starts a TLS server presenting leaf followed by chain.
+func serveChain(t *testing.T, leaf *testCA, chain ...*testCA) string {
+	t.Helper()
+	tlsCert := tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+	for _, c := range chain {
+		tlsCert.Certificate = append(tlsCert.Certificate, c.cert.Raw)
+	}
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts.Listener.Addr().String()
+}
+
+func TestProbe_Chain(t *testing.T) {
+	year := time.Now().AddDate(1, 0, 0)
+	root := issue(t, nil, "Test Root", true, year.AddDate(5, 0, 0))
+	// The intermediate expires before the leaf.
+	intermediate := issue(t, root, "Test Intermediate", true, time.Now().AddDate(0, 0, 20))
+	leaf := issue(t, intermediate, "leaf.test", false, year)
+
+	probeRoots = x509.NewCertPool()
+	probeRoots.AddCert(root.cert)
+	t.Cleanup(func() { probeRoots = nil })
+
+	t.Run("complete", func(t *testing.T) {
+		result, err := Probe(serveChain(t, leaf, intermediate), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.ChainValid || result.SelfSigned {
+			t.Errorf("ChainValid = %v, SelfSigned = %v, want true, false (%s)", result.ChainValid, result.SelfSigned, result.ChainError)
+		}
+		if len(result.Chain) != 1 || result.Chain[0].Subject != "Test Intermediate" {
+			t.Fatalf("Chain = %+v, want the intermediate only", result.Chain)
+		}
+		if !result.Chain[0].NotAfter.Equal(intermediate.cert.NotAfter) {
+			t.Errorf("intermediate NotAfter = %v, want %v", result.Chain[0].NotAfter, intermediate.cert.NotAfter)
+		}
+		if !result.EarliestExpiry().Equal(intermediate.cert.NotAfter) {
+			t.Errorf("EarliestExpiry = %v, want the intermediate's %v", result.EarliestExpiry(), intermediate.cert.NotAfter)
+		}
+	})
+
+	t.Run("missing intermediate", func(t *testing.T) {
+		result, err := Probe(serveChain(t, leaf), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ChainValid || result.ChainError == "" {
+			t.Errorf("ChainValid = %v, ChainError = %q, want an invalid chain", result.ChainValid, result.ChainError)
+		}
+	})
+
+	t.Run("self-signed", func(t *testing.T) {
+		self := issue(t, nil, "self.test", false, year)
+		result, err := Probe(serveChain(t, self), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.ChainValid || !result.SelfSigned {
+			t.Errorf("ChainValid = %v, SelfSigned = %v, want false, true", result.ChainValid, result.SelfSigned)
+		}
+	})
+}
+
+func TestProbe_KeyAndSANs(t *testing.T) {
+	leaf := issue(t, nil, "leaf.test", false, time.Now().AddDate(1, 0, 0))
+
+	result, err := Probe(serveChain(t, leaf), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.SANs) != 1 || result.SANs[0] != "127.0.0.1" {
+		t.Errorf("SANs = %v, want [127.0.0.1]", result.SANs)
+	}
+	if result.KeyType != "ECDSA-P-256" {
+		t.Errorf("KeyType = %q, want ECDSA-P-256", result.KeyType)
+	}
+	if result.SignatureAlgorithm != "ECDSA-SHA256" {
+		t.Errorf("SignatureAlgorithm = %q, want ECDSA-SHA256", result.SignatureAlgorithm)
+	}
+	if len(result.Weaknesses) != 0 {
+		t.Errorf("Weaknesses = %v, want none", result.Weaknesses)
+	}
+}
+
+func TestWeaknesses(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &x509.Certificate{
+		PublicKey:          &key.PublicKey,
+		SignatureAlgorithm: x509.SHA1WithRSA,
+	}
+	weak := weaknesses(cert)
+	if len(weak) != 2 {
+		t.Fatalf("weaknesses = %v, want weak key and SHA-1", weak)
+	}
+	if keyType(cert) != "RSA-1024" {
+		t.Errorf("keyType = %q, want RSA-1024", keyType(cert))
+	}
+}
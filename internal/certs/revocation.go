@@ -0,0 +1,134 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// Revocation statuses recorded as "revocation_status" metadata.
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+)
+
+// Response size limits for revocation checks. OCSP responses are small;
+// CRLs of large CAs can run to several megabytes.
+const (
+	maxOCSPResponse = 1 << 20
+	maxCRL          = 32 << 20
+)
+
+// errNoRevocationInfo is returned when a certificate names neither an OCSP
+// responder nor a CRL.
+var errNoRevocationInfo = errors.New("certificate has no OCSP responder or CRL distribution point")
+
+// checkRevocation asks leaf's OCSP responder whether it is revoked, falling
+// back to its CRL when there is no responder or it does not answer. method
+// is "ocsp" or "crl", whichever gave the answer.
+func checkRevocation(ctx context.Context, client *http.Client, leaf, issuer *x509.Certificate) (status, method string, err error) {
+	if issuer == nil {
+		return RevocationUnknown, "", errors.New("issuer certificate not available")
+	}
+	var errs []error
+	if len(leaf.OCSPServer) > 0 {
+		status, err := checkOCSP(ctx, client, leaf, issuer)
+		if err == nil {
+			return status, "ocsp", nil
+		}
+		errs = append(errs, err)
+	}
+	if len(leaf.CRLDistributionPoints) > 0 {
+		status, err := checkCRL(ctx, client, leaf, issuer)
+		if err == nil {
+			return status, "crl", nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return RevocationUnknown, "", errNoRevocationInfo
+	}
+	return RevocationUnknown, "", errors.Join(errs...)
+}
+
+func checkOCSP(ctx context.Context, client *http.Client, leaf, issuer *x509.Certificate) (string, error) {
+	reqBody, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating OCSP request: %w", err)
+	}
+	server := leaf.OCSPServer[0]
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("creating OCSP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+
+	body, err := fetch(client, req, maxOCSPResponse)
+	if err != nil {
+		return "", fmt.Errorf("querying OCSP responder %s: %w", server, err)
+	}
+	resp, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return "", fmt.Errorf("parsing OCSP response from %s: %w", server, err)
+	}
+	switch resp.Status {
+	case ocsp.Good:
+		return RevocationGood, nil
+	case ocsp.Revoked:
+		return RevocationRevoked, nil
+	default:
+		return RevocationUnknown, nil
+	}
+}
+
+func checkCRL(ctx context.Context, client *http.Client, leaf, issuer *x509.Certificate) (string, error) {
+	url := leaf.CRLDistributionPoints[0]
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating CRL request: %w", err)
+	}
+	body, err := fetch(client, req, maxCRL)
+	if err != nil {
+		return "", fmt.Errorf("fetching CRL %s: %w", url, err)
+	}
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return "", fmt.Errorf("parsing CRL %s: %w", url, err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return "", fmt.Errorf("verifying CRL %s: %w", url, err)
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return RevocationRevoked, nil
+		}
+	}
+	return RevocationGood, nil
+}
+
+// fetch performs req and returns at most limit bytes of a 200 response.
+func fetch(client *http.Client, req *http.Request, limit int64) ([]byte, error) {
+	resp, err := client.Do(req) //#nosec G704 -- URL comes from the certificate being checked
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // best-effort cleanup
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response larger than %d bytes", limit)
+	}
+	return body, nil
+}
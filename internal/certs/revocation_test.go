@@ -0,0 +1,187 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// revocationFixture is a CA, a leaf it issued pointing at a mock OCSP
+// responder and CRL, and a TLS server presenting the leaf.
+type revocationFixture struct {
+	ca, leaf *testCA
+	addr     string
+}
+
+func newRevocationFixture(t *testing.T, ocspStatus int, ocspUp bool) *revocationFixture {
+	t.Helper()
+	f := &revocationFixture{ca: issue(t, nil, "Revocation CA", true, time.Now().AddDate(5, 0, 0))}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ocsp", func(w http.ResponseWriter, r *http.Request) {
+		if !ocspUp {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		req, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err := ocsp.CreateResponse(f.ca.cert, f.ca.cert, ocsp.Response{
+			Status:       ocspStatus,
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+			RevokedAt:    time.Now().Add(-time.Hour),
+		}, f.ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(resp)
+	})
+	mux.HandleFunc("/crl", func(w http.ResponseWriter, _ *http.Request) {
+		crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Hour),
+			NextUpdate: time.Now().Add(time.Hour),
+			RevokedCertificateEntries: []x509.RevocationListEntry{
+				{SerialNumber: f.leaf.cert.SerialNumber, RevocationTime: time.Now().Add(-time.Hour)},
+			},
+		}, f.ca.cert, f.ca.key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(crl)
+	})
+	responder := httptest.NewServer(mux)
+	t.Cleanup(responder.Close)
+
+	f.leaf = issueWithRevocation(t, f.ca, responder.URL)
+	f.addr = serveChain(t, f.leaf)
+
+	probeRoots = x509.NewCertPool()
+	probeRoots.AddCert(f.ca.cert)
+	t.Cleanup(func() { probeRoots = nil })
+	return f
+}
+
+// issueWithRevocation issues a leaf naming baseURL's /ocsp and /crl.
+func issueWithRevocation(t *testing.T, ca *testCA, baseURL string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(7),
+		Subject:               pkix.Name{CommonName: "revoked.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		OCSPServer:            []string{baseURL + "/ocsp"},
+		CRLDistributionPoints: []string{baseURL + "/crl"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+func newRevocationTracker(t *testing.T) *Tracker {
+	t.Helper()
+	tracker := NewTracker(newTestStore(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tracker.SetCheckRevocation(true)
+	return tracker
+}
+
+func TestProbeAndStore_RevokedByOCSP(t *testing.T) {
+	f := newRevocationFixture(t, ocsp.Revoked, true)
+	tracker := newRevocationTracker(t)
+	ctx := context.Background()
+
+	ci, err := tracker.ProbeAndStore(ctx, f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Node.Metadata["revocation_status"] != RevocationRevoked || ci.Node.Metadata["revocation_method"] != "ocsp" {
+		t.Errorf("revocation = %q via %q, want revoked via ocsp",
+			ci.Node.Metadata["revocation_status"], ci.Node.Metadata["revocation_method"])
+	}
+	if ci.Status != StatusRevoked {
+		t.Errorf("Status = %q, want revoked", ci.Status)
+	}
+
+	// The certificate is a year from expiry but still alerts.
+	assets, err := tracker.AlertingAssets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &mockAlerter{}
+	cs, err := NewCertScheduler(tracker, nil, mock, "1m", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.sendAlerts(ctx, assets)
+	events := mock.getEvents()
+	if len(events) != 1 || events[0].EventType != "cert_revoked" || events[0].Severity != "critical" {
+		t.Errorf("events = %+v, want one critical cert_revoked", events)
+	}
+}
+
+func TestProbeAndStore_GoodByOCSP(t *testing.T) {
+	f := newRevocationFixture(t, ocsp.Good, true)
+	ci, err := newRevocationTracker(t).ProbeAndStore(context.Background(), f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Node.Metadata["revocation_status"] != RevocationGood || ci.Status != "ok" {
+		t.Errorf("revocation_status = %q, Status = %q, want good and ok", ci.Node.Metadata["revocation_status"], ci.Status)
+	}
+}
+
+func TestProbeAndStore_CRLFallback(t *testing.T) {
+	f := newRevocationFixture(t, ocsp.Good, false)
+	ci, err := newRevocationTracker(t).ProbeAndStore(context.Background(), f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.Node.Metadata["revocation_status"] != RevocationRevoked || ci.Node.Metadata["revocation_method"] != "crl" {
+		t.Errorf("revocation = %q via %q, want revoked via crl",
+			ci.Node.Metadata["revocation_status"], ci.Node.Metadata["revocation_method"])
+	}
+}
+
+func TestProbeAndStore_RevocationOffByDefault(t *testing.T) {
+	f := newRevocationFixture(t, ocsp.Revoked, true)
+	tracker := NewTracker(newTestStore(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ci, err := tracker.ProbeAndStore(context.Background(), f.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ci.Node.Metadata["revocation_status"]; ok {
+		t.Error("revocation should not be checked unless enabled")
+	}
+}
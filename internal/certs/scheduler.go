@@ -5,41 +5,45 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
-	"time"
 
 	"github.com/matijazezelj/aib/internal/alert"
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/schedule"
 )
 
-// CertScheduler periodically probes TLS endpoints and sends alerts.
+// CertScheduler periodically probes TLS endpoints and sends expiry alerts
+// for certificates and any other asset with an expiration date.
 type CertScheduler struct {
 	tracker  *Tracker
 	store    *graph.SQLiteStore
 	alerter  alert.Alerter
-	interval time.Duration
+	schedule schedule.Schedule
+	clock    schedule.Clock
 	logger   *slog.Logger
 	stopCh   chan struct{}
 	doneCh   chan struct{}
 	mu       sync.Mutex
 	started  bool
 	stopOnce sync.Once
+
+	// alerting holds the assets that alerted on the last cycle, so the
+	// cycle after they recover can send a resolve event.
+	alerting map[string]CertInfo
 }
 
-// NewCertScheduler creates a scheduler that probes certs on the given interval.
-// The interval string is parsed with time.ParseDuration (e.g. "6h", "30m").
-func NewCertScheduler(tracker *Tracker, store *graph.SQLiteStore, alerter alert.Alerter, interval string, logger *slog.Logger) (*CertScheduler, error) {
-	d, err := time.ParseDuration(interval)
+// NewCertScheduler creates a scheduler that probes certs on the given
+// schedule: a Go duration ("6h", "30m") or a cron expression ("0 6 * * 1").
+func NewCertScheduler(tracker *Tracker, store *graph.SQLiteStore, alerter alert.Alerter, spec string, logger *slog.Logger) (*CertScheduler, error) {
+	sched, err := schedule.Parse(spec)
 	if err != nil {
-		return nil, fmt.Errorf("invalid cert probe interval %q: %w", interval, err)
-	}
-	if d < 1*time.Minute {
-		return nil, fmt.Errorf("cert probe interval must be at least 1m, got %s", d)
+		return nil, fmt.Errorf("invalid cert probe interval: %w", err)
 	}
 	return &CertScheduler{
 		tracker:  tracker,
 		store:    store,
 		alerter:  alerter,
-		interval: d,
+		schedule: sched,
+		clock:    schedule.RealClock{},
 		logger:   logger,
 		stopCh:   make(chan struct{}),
 		doneCh:   make(chan struct{}),
@@ -58,22 +62,20 @@ func (cs *CertScheduler) Start(ctx context.Context) {
 
 	go func() {
 		defer close(cs.doneCh)
-		ticker := time.NewTicker(cs.interval)
-		defer ticker.Stop()
 
-		cs.logger.Info("cert probe scheduler started", "interval", cs.interval.String())
+		cs.logger.Info("cert probe scheduler started", "schedule", cs.schedule.String())
 
-		for {
-			select {
-			case <-ticker.C:
-				cs.logger.Info("starting scheduled cert probe")
-				results := ProbeAll(ctx, cs.tracker, cs.store, cs.logger)
-				cs.sendAlerts(ctx, results)
-			case <-cs.stopCh:
-				return
-			case <-ctx.Done():
-				return
+		for schedule.Wait(ctx, cs.schedule, cs.clock, cs.stopCh) {
+			cs.logger.Info("starting scheduled cert probe")
+			ProbeAll(ctx, cs.tracker, cs.store, cs.logger)
+			// Probed certificates are stored, so one pass over the store
+			// covers them along with every other expiring asset.
+			assets, err := cs.tracker.AlertingAssets(ctx)
+			if err != nil {
+				cs.logger.Warn("failed to list expiring assets", "error", err)
+				continue
 			}
+			cs.sendAlerts(ctx, assets)
 		}
 	}()
 }
@@ -97,27 +99,29 @@ func (cs *CertScheduler) sendAlerts(ctx context.Context, results []CertInfo) {
 	if cs.alerter == nil {
 		return
 	}
+	// One batch per cycle, so digest backends such as email send a single
+	// message.
+	var events []alert.Event
+	current := make(map[string]CertInfo)
 	for _, ci := range results {
-		if ci.Status == "warning" || ci.Status == "critical" || ci.Status == "expired" {
-			event := alert.Event{
-				Source:    "aib",
-				EventType: "cert_expiring",
-				Severity:  ci.Status,
-				Asset: alert.Asset{
-					ID:            ci.Node.ID,
-					Name:          ci.Node.Name,
-					Type:          string(ci.Node.Type),
-					DaysRemaining: ci.DaysRemaining,
-				},
-				Message:   fmt.Sprintf("Certificate %s expires in %d days", ci.Node.Name, ci.DaysRemaining),
-				Timestamp: time.Now(),
-			}
-			if ci.Node.ExpiresAt != nil {
-				event.Asset.ExpiresAt = ci.Node.ExpiresAt.Format(time.RFC3339)
-			}
-			if err := cs.alerter.Send(ctx, event); err != nil {
-				cs.logger.Warn("failed to send cert alert", "cert", ci.Node.Name, "error", err)
-			}
+		if !IsAlerting(ci.Status) {
+			continue
+		}
+		current[ci.Node.ID] = ci
+		events = append(events, ExpiryEvent(ci))
+	}
+	var resolved []CertInfo
+	for id, ci := range cs.alerting {
+		if _, still := current[id]; !still {
+			resolved = append(resolved, ci)
+			events = append(events, ResolvedEvent(ci))
+		}
+	}
+	if err := alert.SendAll(ctx, cs.alerter, events); err != nil {
+		cs.logger.Warn("failed to send expiry alerts", "events", len(events), "error", err)
+		for _, ci := range resolved {
+			current[ci.Node.ID] = ci // retry the resolve next cycle
 		}
 	}
+	cs.alerting = current
 }
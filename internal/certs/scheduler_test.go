@@ -48,6 +48,9 @@ func TestNewCertScheduler_ValidDuration(t *testing.T) {
 		{"30s", true},  // below 1m minimum
 		{"invalid", true},
 		{"", true},
+		{"0 2 * * *", false}, // cron: nightly at 02:00
+		{"@hourly", false},
+		{"0 25 * * *", true}, // cron hour out of range
 	}
 
 	for _, tt := range tests {
@@ -244,3 +247,93 @@ func TestCertScheduler_SendAlerts_WithExpiry(t *testing.T) {
 		t.Errorf("severity = %q, want critical", events[0].Severity)
 	}
 }
+
+func TestCertScheduler_SendAlerts_ResolvesRecovered(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	mock := &mockAlerter{}
+
+	cs, err := NewCertScheduler(nil, nil, mock, "1m", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expiring := CertInfo{
+		Node:          models.Node{ID: "cert:renewed", Name: "renewed-cert", Type: models.AssetCertificate},
+		DaysRemaining: 5,
+		Status:        "critical",
+	}
+	cs.sendAlerts(context.Background(), []CertInfo{expiring})
+	// Renewed: it no longer shows up among the alerting assets.
+	cs.sendAlerts(context.Background(), nil)
+	cs.sendAlerts(context.Background(), nil)
+
+	events := mock.getEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected alert then one resolve, got %d events", len(events))
+	}
+	if events[1].Severity != alert.SeverityOK || events[1].EventType != "cert_renewed" || events[1].Asset.ID != "cert:renewed" {
+		t.Errorf("resolve event = %+v", events[1])
+	}
+}
+
+// chanClock reports each requested wait on waits and fires when the test
+// sends on fire.
+type chanClock struct {
+	now   time.Time
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func (c *chanClock) Now() time.Time { return c.now }
+
+func (c *chanClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+func TestCertScheduler_CronTick(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	soon := time.Now().Add(3 * 24 * time.Hour)
+	now := time.Now()
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tls:api.example.com:443", Name: "api.example.com", Type: models.AssetCertificate,
+		Source: "test", ExpiresAt: &soon, Metadata: map[string]string{}, LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := &mockAlerter{}
+	cs, err := NewCertScheduler(NewTracker(store, nil, newNopLogger()), store, mock, "0 6 * * 1", newNopLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &chanClock{
+		now:   time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC), // Saturday
+		waits: make(chan time.Duration),
+		fire:  make(chan time.Time),
+	}
+	cs.clock = clock
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	cs.Start(runCtx)
+	defer cs.Stop()
+
+	if d := <-clock.waits; d != 48*time.Hour {
+		t.Errorf("first wait = %s, want 48h until Monday 06:00", d)
+	}
+	if len(mock.getEvents()) != 0 {
+		t.Fatal("alerts sent before the cron tick")
+	}
+
+	clock.now = time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	clock.fire <- clock.now
+	if d := <-clock.waits; d != 7*24*time.Hour {
+		t.Errorf("second wait = %s, want a week", d)
+	}
+	events := mock.getEvents()
+	if len(events) != 1 || events[0].Asset.ID != "tls:api.example.com:443" {
+		t.Errorf("expected one expiry alert after the tick, got %+v", events)
+	}
+}
@@ -2,19 +2,28 @@ package certs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/matijazezelj/aib/internal/graph"
 	"github.com/matijazezelj/aib/pkg/models"
 )
 
-// Tracker manages certificate discovery and expiry tracking.
+// Tracker manages certificate discovery and expiry tracking. Expiry is
+// tracked for every asset with an expiration date, not only certificates.
 type Tracker struct {
 	store      *graph.SQLiteStore
 	thresholds []int
+	expiry     ExpiryThresholds
 	logger     *slog.Logger
+
+	checkRevocation bool
+	httpClient      *http.Client
 }
 
 // NewTracker creates a new certificate tracker.
@@ -26,14 +35,42 @@ func NewTracker(store *graph.SQLiteStore, thresholds []int, logger *slog.Logger)
 		store:      store,
 		thresholds: thresholds,
 		logger:     logger,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SetExpiryThresholds configures per-asset-type expiry windows. Types
+// without an entry use DefaultExpiryThresholds.
+func (t *Tracker) SetExpiryThresholds(expiry ExpiryThresholds) {
+	t.expiry = expiry
+}
+
+// ExpiryWarning returns the expiry warning for n under the tracker's
+// thresholds, or "" if it is not expiring. See ExpiryThresholds.Warning.
+func (t *Tracker) ExpiryWarning(n *models.Node) string {
+	return t.expiry.Warning(n)
+}
+
+// SetCheckRevocation turns on OCSP (with CRL fallback) revocation checks
+// when probing endpoints.
+func (t *Tracker) SetCheckRevocation(enabled bool) {
+	t.checkRevocation = enabled
+}
+
+// status classifies n: "revoked" if a probe found it revoked, otherwise its
+// expiry status.
+func (t *Tracker) status(n models.Node, days int) string {
+	if n.Metadata["revocation_status"] == RevocationRevoked {
+		return StatusRevoked
 	}
+	return t.expiry.Status(n.Type, days)
 }
 
 // CertInfo holds certificate information with expiry details.
 type CertInfo struct {
 	Node          models.Node `json:"node"`
 	DaysRemaining int         `json:"days_remaining"`
-	Status        string      `json:"status"` // "ok", "warning", "critical", "expired"
+	Status        string      `json:"status"` // "ok", "warning", "critical", "expired", "revoked"
 }
 
 // ListCerts returns all certificate nodes with expiry info.
@@ -48,7 +85,7 @@ func (t *Tracker) ListCerts(ctx context.Context) ([]CertInfo, error) {
 		ci := CertInfo{Node: n}
 		if n.ExpiresAt != nil {
 			ci.DaysRemaining = DaysUntilExpiry(*n.ExpiresAt)
-			ci.Status = expiryStatus(ci.DaysRemaining)
+			ci.Status = t.status(n, ci.DaysRemaining)
 		} else {
 			ci.Status = "unknown"
 			ci.DaysRemaining = -1
@@ -74,12 +111,55 @@ func (t *Tracker) ExpiringCerts(ctx context.Context, days int) ([]CertInfo, erro
 			Node:          n,
 			DaysRemaining: DaysUntilExpiry(*n.ExpiresAt),
 		}
-		ci.Status = expiryStatus(ci.DaysRemaining)
+		ci.Status = t.status(n, ci.DaysRemaining)
 		certs = append(certs, ci)
 	}
 	return certs, nil
 }
 
+// ExpiringAssets returns assets of any type expiring within the given number
+// of days, soonest first.
+func (t *Tracker) ExpiringAssets(ctx context.Context, days int) ([]CertInfo, error) {
+	nodes, err := t.store.ExpiringNodes(ctx, days)
+	if err != nil {
+		return nil, fmt.Errorf("listing expiring nodes: %w", err)
+	}
+
+	assets := make([]CertInfo, 0, len(nodes))
+	for _, n := range nodes {
+		ci := CertInfo{
+			Node:          n,
+			DaysRemaining: DaysUntilExpiry(*n.ExpiresAt),
+		}
+		ci.Status = t.status(n, ci.DaysRemaining)
+		assets = append(assets, ci)
+	}
+	return assets, nil
+}
+
+// AlertingAssets returns every asset with an expiration date whose status,
+// under its type's thresholds, is warning, critical, or expired. Unlike
+// ExpiringAssets it includes assets that have already expired.
+func (t *Tracker) AlertingAssets(ctx context.Context) ([]CertInfo, error) {
+	nodes, err := t.store.NodesWithExpiry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes with expiry: %w", err)
+	}
+
+	var assets []CertInfo
+	for _, n := range nodes {
+		ci := CertInfo{
+			Node:          n,
+			DaysRemaining: DaysUntilExpiry(*n.ExpiresAt),
+		}
+		ci.Status = t.status(n, ci.DaysRemaining)
+		if IsAlerting(ci.Status) {
+			assets = append(assets, ci)
+		}
+	}
+	return assets, nil
+}
+
 // ProbeAndStore probes a TLS endpoint and stores the result as a certificate node.
 func (t *Tracker) ProbeAndStore(ctx context.Context, hostPort string) (*CertInfo, error) {
 	result, err := Probe(hostPort, 10*time.Second)
@@ -89,6 +169,9 @@ func (t *Tracker) ProbeAndStore(ctx context.Context, hostPort string) (*CertInfo
 
 	now := time.Now()
 	nodeID := fmt.Sprintf("probe:certificate:%s", result.Host)
+	// The endpoint breaks when any certificate in its chain expires, so
+	// an intermediate expiring first is what gets tracked and alerted on.
+	expiresAt := result.EarliestExpiry()
 
 	node := models.Node{
 		ID:         nodeID,
@@ -97,18 +180,44 @@ func (t *Tracker) ProbeAndStore(ctx context.Context, hostPort string) (*CertInfo
 		Source:     "probe",
 		SourceFile: hostPort,
 		Provider:   result.Issuer,
-		ExpiresAt:  &result.NotAfter,
+		ExpiresAt:  &expiresAt,
 		LastSeen:   now,
 		FirstSeen:  now,
 		Metadata: map[string]string{
-			"host":       result.Host,
-			"port":       result.Port,
-			"issuer":     result.Issuer,
-			"serial":     result.Serial,
-			"dns_names":  fmt.Sprintf("%v", result.DNSNames),
-			"not_before": result.NotBefore.Format(time.RFC3339),
+			"host":                result.Host,
+			"port":                result.Port,
+			"issuer":              result.Issuer,
+			"serial":              result.Serial,
+			"dns_names":           fmt.Sprintf("%v", result.DNSNames),
+			"not_before":          result.NotBefore.Format(time.RFC3339),
+			"not_after":           result.NotAfter.Format(time.RFC3339),
+			"chain_valid":         strconv.FormatBool(result.ChainValid),
+			"self_signed":         strconv.FormatBool(result.SelfSigned),
+			"sans":                strings.Join(result.SANs, ","),
+			"key_type":            result.KeyType,
+			"signature_algorithm": result.SignatureAlgorithm,
 		},
 	}
+	if len(result.Weaknesses) > 0 {
+		node.Metadata["weaknesses"] = strings.Join(result.Weaknesses, "; ")
+	}
+	if result.ChainError != "" {
+		node.Metadata["chain_error"] = result.ChainError
+	}
+	if t.checkRevocation {
+		status, method, err := checkRevocation(ctx, t.httpClient, result.leaf, result.issuer)
+		if err != nil {
+			t.logger.Warn("revocation check failed", "host", hostPort, "error", err)
+		}
+		node.Metadata["revocation_status"] = status
+		if method != "" {
+			node.Metadata["revocation_method"] = method
+		}
+	}
+	if len(result.Chain) > 0 {
+		chain, _ := json.Marshal(result.Chain)
+		node.Metadata["chain"] = string(chain)
+	}
 
 	if err := t.store.UpsertNode(ctx, node); err != nil {
 		return nil, fmt.Errorf("storing certificate: %w", err)
@@ -116,29 +225,29 @@ func (t *Tracker) ProbeAndStore(ctx context.Context, hostPort string) (*CertInfo
 
 	ci := &CertInfo{
 		Node:          node,
-		DaysRemaining: DaysUntilExpiry(result.NotAfter),
+		DaysRemaining: DaysUntilExpiry(expiresAt),
 	}
-	ci.Status = expiryStatus(ci.DaysRemaining)
+	ci.Status = t.status(node, ci.DaysRemaining)
 
 	t.logger.Info("probed certificate",
 		"host", hostPort,
 		"subject", result.Subject,
-		"expires", result.NotAfter.Format("2006-01-02"),
+		"expires", expiresAt.Format("2006-01-02"),
 		"days_remaining", ci.DaysRemaining,
 	)
+	for _, w := range result.Weaknesses {
+		t.logger.Warn("weak certificate", "host", hostPort, "issue", w)
+	}
+	if ci.Status == StatusRevoked {
+		t.logger.Warn("certificate has been revoked", "host", hostPort, "subject", result.Subject)
+	}
+	if !result.ChainValid {
+		t.logger.Warn("certificate chain does not verify",
+			"host", hostPort,
+			"self_signed", result.SelfSigned,
+			"error", result.ChainError,
+		)
+	}
 
 	return ci, nil
 }
-
-func expiryStatus(days int) string {
-	switch {
-	case days < 0:
-		return "expired"
-	case days <= 7:
-		return "critical"
-	case days <= 30:
-		return "warning"
-	default:
-		return "ok"
-	}
-}
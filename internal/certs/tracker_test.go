@@ -2,8 +2,10 @@ package certs
 
 import (
 	"context"
+	"crypto/x509"
 	"io"
 	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,9 +80,9 @@ func TestExpiryStatus(t *testing.T) {
 		{365, "ok"},
 	}
 	for _, tt := range tests {
-		got := expiryStatus(tt.days)
+		got := ExpiryThresholds(nil).Status(models.AssetCertificate, tt.days)
 		if got != tt.want {
-			t.Errorf("expiryStatus(%d) = %q, want %q", tt.days, got, tt.want)
+			t.Errorf("Status(certificate, %d) = %q, want %q", tt.days, got, tt.want)
 		}
 	}
 }
@@ -154,3 +156,33 @@ func TestExpiringCerts(t *testing.T) {
 func newNopLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(io.Discard, nil))
 }
+
+func TestProbeAndStore_ChainMetadata(t *testing.T) {
+	root := issue(t, nil, "Test Root", true, time.Now().AddDate(5, 0, 0))
+	intermediate := issue(t, root, "Test Intermediate", true, time.Now().AddDate(0, 0, 20))
+	leaf := issue(t, intermediate, "leaf.test", false, time.Now().AddDate(1, 0, 0))
+	probeRoots = x509.NewCertPool()
+	probeRoots.AddCert(root.cert)
+	t.Cleanup(func() { probeRoots = nil })
+
+	tracker := NewTracker(newTestStore(t), nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ci, err := tracker.ProbeAndStore(context.Background(), serveChain(t, leaf, intermediate))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := ci.Node.Metadata
+	if md["chain_valid"] != "true" || md["self_signed"] != "false" {
+		t.Errorf("chain_valid = %q, self_signed = %q", md["chain_valid"], md["self_signed"])
+	}
+	if md["key_type"] != "ECDSA-P-256" || md["sans"] != "127.0.0.1" {
+		t.Errorf("key_type = %q, sans = %q", md["key_type"], md["sans"])
+	}
+	if !strings.Contains(md["chain"], "Test Intermediate") {
+		t.Errorf("chain metadata = %q, want the intermediate", md["chain"])
+	}
+	// The intermediate expires first, so it sets the tracked expiry.
+	if ci.DaysRemaining > 20 || ci.Status != "warning" {
+		t.Errorf("DaysRemaining = %d, Status = %q, want the intermediate's expiry", ci.DaysRemaining, ci.Status)
+	}
+}
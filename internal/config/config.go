@@ -3,38 +3,52 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/matijazezelj/aib/internal/alert"
+	"github.com/matijazezelj/aib/internal/schedule"
 	"github.com/spf13/viper"
 )
 
 // Config holds all AIB configuration loaded from file and environment.
 type Config struct {
-	Storage StorageConfig `mapstructure:"storage"`
-	Sources SourcesConfig `mapstructure:"sources"`
-	Certs   CertsConfig   `mapstructure:"certs"`
-	Alerts  AlertsConfig  `mapstructure:"alerts"`
-	Server  ServerConfig  `mapstructure:"server"`
-	Scan    ScanConfig    `mapstructure:"scan"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Sources   SourcesConfig   `mapstructure:"sources"`
+	Certs     CertsConfig     `mapstructure:"certs"`
+	Expiry    ExpiryConfig    `mapstructure:"expiry"`
+	Alerts    AlertsConfig    `mapstructure:"alerts"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Scan      ScanConfig      `mapstructure:"scan"`
+	Telemetry TelemetryConfig `mapstructure:"telemetry"`
 }
 
 // StorageConfig configures the SQLite database and optional Memgraph connection.
+// NodeHistory records a version of a node each time a scan changes it.
 type StorageConfig struct {
-	Path     string         `mapstructure:"path"`
-	Memgraph MemgraphConfig `mapstructure:"memgraph"`
+	Path        string         `mapstructure:"path"`
+	NodeHistory bool           `mapstructure:"node_history"`
+	Memgraph    MemgraphConfig `mapstructure:"memgraph"`
 }
 
 // MemgraphConfig configures the optional Memgraph graph database.
+// SyncInterval is how often aib serve pushes changes from SQLite to
+// Memgraph, as a duration or cron expression; empty disables it.
+// SyncBatchSize is how many nodes or edges each sync statement carries.
 type MemgraphConfig struct {
-	Enabled  bool   `mapstructure:"enabled"`
-	URI      string `mapstructure:"uri"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"` //#nosec G117 -- config field, not a hardcoded secret
+	Enabled       bool   `mapstructure:"enabled"`
+	URI           string `mapstructure:"uri"`
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"` //#nosec G117 -- config field, not a hardcoded secret
+	SyncInterval  string `mapstructure:"sync_interval"`
+	SyncBatchSize int    `mapstructure:"sync_batch_size"`
 }
 
 // SourcesConfig lists all infrastructure sources to scan.
@@ -45,27 +59,87 @@ type SourcesConfig struct {
 	Compose        []ComposeSource        `mapstructure:"compose"`
 	CloudFormation []CloudFormationSource `mapstructure:"cloudformation"`
 	Pulumi         []PulumiSource         `mapstructure:"pulumi"`
+	AWS            []AWSSource            `mapstructure:"aws"`
+	GCP            []GCPSource            `mapstructure:"gcp"`
+}
+
+// Schedules returns the sources that set their own schedule, keyed by
+// config path (e.g. "sources.terraform[0].schedule").
+func (s SourcesConfig) Schedules() map[string]string {
+	out := make(map[string]string)
+	add := func(kind string, i int, spec string) {
+		if spec != "" {
+			out[fmt.Sprintf("sources.%s[%d].schedule", kind, i)] = spec
+		}
+	}
+	for i, src := range s.Terraform {
+		add("terraform", i, src.Schedule)
+	}
+	for i, src := range s.Kubernetes {
+		add("kubernetes", i, src.Schedule)
+	}
+	for i, src := range s.Ansible {
+		add("ansible", i, src.Schedule)
+	}
+	for i, src := range s.Compose {
+		add("compose", i, src.Schedule)
+	}
+	for i, src := range s.CloudFormation {
+		add("cloudformation", i, src.Schedule)
+	}
+	for i, src := range s.Pulumi {
+		add("pulumi", i, src.Schedule)
+	}
+	for i, src := range s.AWS {
+		add("aws", i, src.Schedule)
+	}
+	for i, src := range s.GCP {
+		add("gcp", i, src.Schedule)
+	}
+	return out
 }
 
 // ComposeSource configures a Docker Compose file or directory to scan.
 type ComposeSource struct {
-	Path string `mapstructure:"path"`
+	Path     string `mapstructure:"path"`
+	Schedule string `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // CloudFormationSource configures a CloudFormation template file or directory to scan.
 type CloudFormationSource struct {
-	Path string `mapstructure:"path"`
+	Path     string `mapstructure:"path"`
+	Schedule string `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // PulumiSource configures a Pulumi state file or directory to scan.
 type PulumiSource struct {
-	Path string `mapstructure:"path"`
+	Path     string `mapstructure:"path"`
+	Schedule string `mapstructure:"schedule"` // overrides scan.schedule
+}
+
+// AWSSource configures live discovery of an AWS account through the AWS
+// SDK. Regions defaults to the SDK's configured region and Profile to the
+// default credential chain.
+type AWSSource struct {
+	Regions  []string `mapstructure:"regions"`
+	Profile  string   `mapstructure:"profile"`
+	Schedule string   `mapstructure:"schedule"` // overrides scan.schedule
+}
+
+// GCPSource configures live discovery of a Google Cloud project.
+// Credentials is a service account key file; if empty, Application
+// Default Credentials are used.
+type GCPSource struct {
+	Project     string `mapstructure:"project"`
+	Credentials string `mapstructure:"credentials"`
+	Schedule    string `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // TerraformSource configures a Terraform state file or directory to scan.
 type TerraformSource struct {
 	Path      string `mapstructure:"path"`
 	StateFile string `mapstructure:"state_file"`
+	Schedule  string `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // KubernetesSource configures a Kubernetes manifest path, Helm chart, or live cluster.
@@ -77,12 +151,17 @@ type KubernetesSource struct {
 	Context    string   `mapstructure:"context"`
 	Live       bool     `mapstructure:"live"`
 	Namespaces []string `mapstructure:"namespaces"`
+	// CustomResources lists CRD names (globs allowed) or kinds whose
+	// instances are pulled during live scans.
+	CustomResources []string `mapstructure:"custom_resources"`
+	Schedule        string   `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // AnsibleSource configures an Ansible inventory and optional playbook directory.
 type AnsibleSource struct {
 	Inventory string `mapstructure:"inventory"`
 	Playbooks string `mapstructure:"playbooks"`
+	Schedule  string `mapstructure:"schedule"` // overrides scan.schedule
 }
 
 // CertsConfig configures TLS certificate probing and alert thresholds.
@@ -90,40 +169,120 @@ type CertsConfig struct {
 	ProbeEnabled    bool   `mapstructure:"probe_enabled"`
 	ProbeInterval   string `mapstructure:"probe_interval"`
 	AlertThresholds []int  `mapstructure:"alert_thresholds"`
+	CheckRevocation bool   `mapstructure:"check_revocation"` // OCSP/CRL check on each probe
 }
 
-// AlertsConfig configures alert backends (webhook, stdout, and slack).
+// ExpiryConfig configures expiry warnings for any asset with an expiration
+// date. Thresholds maps an asset type to its warning and critical windows in
+// days, sorted descending (e.g. access_key: [30, 7]). Types without an entry
+// warn at 30 days and turn critical at 7.
+type ExpiryConfig struct {
+	Thresholds map[string][]int `mapstructure:"thresholds"`
+}
+
+// AlertsConfig configures alert backends (webhook, stdout, slack,
+// pagerduty, and email).
+// Each backend accepts a min_severity (info, warning, critical, expired)
+// below which events are not sent to it. DedupWindow suppresses repeats of
+// an event for the same asset and severity; "0" turns it off.
 type AlertsConfig struct {
-	Webhook WebhookConfig `mapstructure:"webhook"`
-	Stdout  StdoutConfig  `mapstructure:"stdout"`
-	Slack   SlackConfig   `mapstructure:"slack"`
+	Webhook     WebhookConfig   `mapstructure:"webhook"`
+	Stdout      StdoutConfig    `mapstructure:"stdout"`
+	Slack       SlackConfig     `mapstructure:"slack"`
+	PagerDuty   PagerDutyConfig `mapstructure:"pagerduty"`
+	Email       EmailConfig     `mapstructure:"email"`
+	DedupWindow string          `mapstructure:"dedup_window"`
+	OnChange    bool            `mapstructure:"on_change"` // alert on assets discovered or removed between scans
 }
 
 // WebhookConfig configures the webhook alert backend.
 type WebhookConfig struct {
-	Enabled bool              `mapstructure:"enabled"`
-	URL     string            `mapstructure:"url"`
-	Headers map[string]string `mapstructure:"headers"`
+	Enabled     bool              `mapstructure:"enabled"`
+	URL         string            `mapstructure:"url"`
+	Headers     map[string]string `mapstructure:"headers"`
+	MinSeverity string            `mapstructure:"min_severity"`
 }
 
 // StdoutConfig configures the stdout alert backend.
 type StdoutConfig struct {
-	Enabled bool `mapstructure:"enabled"`
+	Enabled     bool   `mapstructure:"enabled"`
+	MinSeverity string `mapstructure:"min_severity"`
 }
 
 // SlackConfig configures the Slack alert backend.
 type SlackConfig struct {
-	Enabled    bool   `mapstructure:"enabled"`
-	WebhookURL string `mapstructure:"webhook_url"`
-	Channel    string `mapstructure:"channel"`
+	Enabled     bool   `mapstructure:"enabled"`
+	WebhookURL  string `mapstructure:"webhook_url"`
+	Channel     string `mapstructure:"channel"`
+	MinSeverity string `mapstructure:"min_severity"`
+}
+
+// PagerDutyConfig configures the PagerDuty Events API v2 alert backend.
+type PagerDutyConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	RoutingKey  string `mapstructure:"routing_key"` //#nosec G117 -- config field, not a hardcoded secret
+	MinSeverity string `mapstructure:"min_severity"`
+}
+
+// EmailConfig configures the SMTP email alert backend. TLS is "starttls"
+// (the default), "tls" for implicit TLS, or "none" for a local relay.
+type EmailConfig struct {
+	Enabled     bool     `mapstructure:"enabled"`
+	Host        string   `mapstructure:"host"`
+	Port        int      `mapstructure:"port"`
+	Username    string   `mapstructure:"username"`
+	Password    string   `mapstructure:"password"` //#nosec G117 -- config field, not a hardcoded secret
+	From        string   `mapstructure:"from"`
+	To          []string `mapstructure:"to"`
+	TLS         string   `mapstructure:"tls"`
+	MinSeverity string   `mapstructure:"min_severity"`
 }
 
-// ServerConfig configures the HTTP server, API auth, and CORS.
+// ServerConfig configures the HTTP server, API auth, and CORS. Listen is a
+// host:port or a Unix domain socket as unix:///path/to/aib.sock. RateLimit
+// is the per-IP API request rate in requests per second (0 means 10).
+// APIToken is a single admin token; APITokens adds named, scoped ones.
 type ServerConfig struct {
-	Listen     string `mapstructure:"listen"`
-	ReadOnly   bool   `mapstructure:"read_only"`
-	APIToken   string `mapstructure:"api_token"` //#nosec G117 -- config field, not a hardcoded secret
-	CORSOrigin string `mapstructure:"cors_origin"`
+	Listen     string           `mapstructure:"listen"`
+	ReadOnly   bool             `mapstructure:"read_only"`
+	APIToken   string           `mapstructure:"api_token"` //#nosec G117 -- config field, not a hardcoded secret
+	APITokens  []APITokenConfig `mapstructure:"api_tokens"`
+	CORSOrigin string           `mapstructure:"cors_origin"`
+	RateLimit  float64          `mapstructure:"rate_limit"`
+}
+
+// TelemetryConfig configures OpenTelemetry tracing of scans and API
+// requests. OTLPEndpoint is an OTLP/HTTP collector URL such as
+// http://localhost:4318; tracing is off when it is empty.
+type TelemetryConfig struct {
+	OTLPEndpoint string `mapstructure:"otlp_endpoint"`
+}
+
+// API token scopes. A read token may only call GET endpoints; an admin
+// token may also trigger scans and delete nodes.
+const (
+	ScopeRead  = "read"
+	ScopeAdmin = "admin"
+)
+
+// APITokenConfig is a named API bearer token with a scope.
+type APITokenConfig struct {
+	Name  string `mapstructure:"name"`
+	Token string `mapstructure:"token"` //#nosec G117 -- config field, not a hardcoded secret
+	Scope string `mapstructure:"scope"`
+}
+
+// HasAdminToken reports whether any configured token grants admin scope.
+func (c ServerConfig) HasAdminToken() bool {
+	if c.APIToken != "" {
+		return true
+	}
+	for _, t := range c.APITokens {
+		if t.Scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
 }
 
 // ScanConfig configures automatic scan scheduling.
@@ -131,10 +290,55 @@ type ScanConfig struct {
 	Schedule     string   `mapstructure:"schedule"`
 	OnStartup    bool     `mapstructure:"on_startup"`
 	AllowedPaths []string `mapstructure:"allowed_paths"`
+	// Include and Exclude are file globs applied to every Terraform,
+	// Kubernetes and Compose directory scan; ExcludeTypes lists asset
+	// types never stored.
+	Include      []string `mapstructure:"include"`
+	Exclude      []string `mapstructure:"exclude"`
+	ExcludeTypes []string `mapstructure:"exclude_types"`
+	// Timeout is a Go duration after which a scan's parser is abandoned
+	// and the scan fails; empty means no limit.
+	Timeout string `mapstructure:"timeout"`
+	// PruneMissing is what a scan does with stored nodes of its source
+	// that it no longer finds: "keep" (the default) leaves them, "stale"
+	// marks them with stale_since metadata, "delete" removes them.
+	PruneMissing string `mapstructure:"prune_missing"`
+	// GroupByProvider adds a node per provider (google, aws, ...) after
+	// each scan, with a member_of edge from every asset of that provider.
+	// GroupByRegion does the same per region with located_in edges.
+	GroupByProvider bool `mapstructure:"group_by_provider"`
+	GroupByRegion   bool `mapstructure:"group_by_region"`
+	// TypeMappings is a YAML file mapping Terraform resource types to
+	// asset types, extending or overriding the built-in mappings.
+	TypeMappings string `mapstructure:"type_mappings"`
+	// EdgeRules infer edges from node fields after each scan.
+	EdgeRules []EdgeRule `mapstructure:"edge_rules"`
 }
 
+// EdgeRule adds an Edge from each node matching the From filter
+// expression to each other node matching To whose ToKey field equals the
+// from node's Key field. Keys are node fields or metadata.<key>; ToKey
+// defaults to Key and empty expressions match every node.
+type EdgeRule struct {
+	Name  string `mapstructure:"name"`
+	From  string `mapstructure:"from"`
+	To    string `mapstructure:"to"`
+	Key   string `mapstructure:"key"`
+	ToKey string `mapstructure:"to_key"`
+	Edge  string `mapstructure:"edge"`
+}
+
+// Prune modes for ScanConfig.PruneMissing.
+const (
+	PruneKeep   = "keep"
+	PruneStale  = "stale"
+	PruneDelete = "delete"
+)
+
 // Load reads the configuration from file and environment variables.
 func Load(cfgFile string) (*Config, error) {
+	// Start clean so a file read by an earlier Load can't leak into this one.
+	viper.Reset()
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -152,15 +356,21 @@ func Load(cfgFile string) (*Config, error) {
 
 	// Defaults
 	viper.SetDefault("storage.path", "./data/aib.db")
+	viper.SetDefault("storage.node_history", false)
 	viper.SetDefault("storage.memgraph.enabled", false)
 	viper.SetDefault("storage.memgraph.uri", "bolt://localhost:7687")
+	viper.SetDefault("storage.memgraph.sync_interval", "5m")
+	viper.SetDefault("storage.memgraph.sync_batch_size", 500)
 	viper.SetDefault("server.listen", ":8080")
 	viper.SetDefault("server.read_only", true)
 	viper.SetDefault("certs.probe_enabled", true)
 	viper.SetDefault("certs.probe_interval", "6h")
 	viper.SetDefault("certs.alert_thresholds", []int{90, 60, 30, 14, 7, 1})
 	viper.SetDefault("alerts.stdout.enabled", true)
+	viper.SetDefault("alerts.dedup_window", "24h")
+	viper.SetDefault("alerts.email.port", 587)
 	viper.SetDefault("scan.on_startup", true)
+	viper.SetDefault("scan.prune_missing", PruneKeep)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -178,7 +388,13 @@ func Load(cfgFile string) (*Config, error) {
 	cfg.Storage.Memgraph.Username = os.ExpandEnv(cfg.Storage.Memgraph.Username)
 	cfg.Alerts.Webhook.URL = os.ExpandEnv(cfg.Alerts.Webhook.URL)
 	cfg.Alerts.Slack.WebhookURL = os.ExpandEnv(cfg.Alerts.Slack.WebhookURL)
+	cfg.Alerts.PagerDuty.RoutingKey = os.ExpandEnv(cfg.Alerts.PagerDuty.RoutingKey)
+	cfg.Alerts.Email.Username = os.ExpandEnv(cfg.Alerts.Email.Username)
+	cfg.Alerts.Email.Password = os.ExpandEnv(cfg.Alerts.Email.Password)
 	cfg.Server.APIToken = os.ExpandEnv(cfg.Server.APIToken)
+	for i := range cfg.Server.APITokens {
+		cfg.Server.APITokens[i].Token = os.ExpandEnv(cfg.Server.APITokens[i].Token)
+	}
 	for k, v := range cfg.Alerts.Webhook.Headers {
 		cfg.Alerts.Webhook.Headers[k] = os.ExpandEnv(v)
 	}
@@ -190,6 +406,12 @@ func Load(cfgFile string) (*Config, error) {
 	return &cfg, nil
 }
 
+// FileUsed returns the config file the last Load read, or "" if none was
+// found and only defaults and environment variables applied.
+func FileUsed() string {
+	return viper.ConfigFileUsed()
+}
+
 // Validate checks the configuration for common errors and returns a joined
 // multi-error if any problems are found.
 func (c *Config) Validate() error {
@@ -204,14 +426,19 @@ func (c *Config) Validate() error {
 		if !strings.HasPrefix(uri, "bolt://") && !strings.HasPrefix(uri, "neo4j://") {
 			errs = append(errs, fmt.Errorf("storage.memgraph.uri must start with bolt:// or neo4j://, got %q", uri))
 		}
+		if c.Storage.Memgraph.SyncInterval != "" {
+			if _, err := schedule.Parse(c.Storage.Memgraph.SyncInterval); err != nil {
+				errs = append(errs, fmt.Errorf("storage.memgraph.sync_interval: %w", err))
+			}
+		}
+		if c.Storage.Memgraph.SyncBatchSize < 1 {
+			errs = append(errs, fmt.Errorf("storage.memgraph.sync_batch_size must be at least 1, got %d", c.Storage.Memgraph.SyncBatchSize))
+		}
 	}
 
 	if c.Certs.ProbeEnabled && c.Certs.ProbeInterval != "" {
-		d, err := time.ParseDuration(c.Certs.ProbeInterval)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("certs.probe_interval %q is not a valid duration: %w", c.Certs.ProbeInterval, err))
-		} else if d < time.Minute {
-			errs = append(errs, fmt.Errorf("certs.probe_interval must be >= 1m, got %s", d))
+		if _, err := schedule.Parse(c.Certs.ProbeInterval); err != nil {
+			errs = append(errs, fmt.Errorf("certs.probe_interval: %w", err))
 		}
 	}
 
@@ -227,6 +454,28 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Alerts.DedupWindow != "" {
+		if d, err := time.ParseDuration(c.Alerts.DedupWindow); err != nil {
+			errs = append(errs, fmt.Errorf("alerts.dedup_window %q is not a valid duration: %w", c.Alerts.DedupWindow, err))
+		} else if d < 0 {
+			errs = append(errs, fmt.Errorf("alerts.dedup_window must not be negative, got %s", d))
+		}
+	}
+
+	if em := c.Alerts.Email; em.Enabled {
+		if em.Host == "" || em.From == "" || len(em.To) == 0 {
+			errs = append(errs, fmt.Errorf("alerts.email requires host, from and at least one to address"))
+		}
+		if em.Port < 1 || em.Port > 65535 {
+			errs = append(errs, fmt.Errorf("alerts.email.port must be between 1 and 65535, got %d", em.Port))
+		}
+		switch em.TLS {
+		case "", "starttls", "tls", "none":
+		default:
+			errs = append(errs, fmt.Errorf("alerts.email.tls must be starttls, tls or none, got %q", em.TLS))
+		}
+	}
+
 	if c.Alerts.Webhook.Enabled && c.Alerts.Webhook.URL != "" {
 		u, err := url.Parse(c.Alerts.Webhook.URL)
 		if err != nil {
@@ -236,6 +485,15 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.Telemetry.OTLPEndpoint != "" {
+		u, err := url.Parse(c.Telemetry.OTLPEndpoint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("telemetry.otlp_endpoint is not a valid URL: %w", err))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("telemetry.otlp_endpoint must use http or https scheme, got %q", u.Scheme))
+		}
+	}
+
 	if c.Alerts.Slack.Enabled && c.Alerts.Slack.WebhookURL != "" {
 		u, err := url.Parse(c.Alerts.Slack.WebhookURL)
 		if err != nil {
@@ -245,32 +503,118 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	expiryTypes := make([]string, 0, len(c.Expiry.Thresholds))
+	for typ := range c.Expiry.Thresholds {
+		expiryTypes = append(expiryTypes, typ)
+	}
+	sort.Strings(expiryTypes)
+	for _, typ := range expiryTypes {
+		th := c.Expiry.Thresholds[typ]
+		if len(th) == 0 {
+			errs = append(errs, fmt.Errorf("expiry.thresholds.%s must list at least one threshold", typ))
+			continue
+		}
+		for i, v := range th {
+			if v <= 0 {
+				errs = append(errs, fmt.Errorf("expiry.thresholds.%s[%d] must be positive, got %d", typ, i, v))
+				break
+			}
+			if i > 0 && v >= th[i-1] {
+				errs = append(errs, fmt.Errorf("expiry.thresholds.%s must be sorted descending, but [%d]=%d >= [%d]=%d",
+					typ, i-1, th[i-1], i, v))
+				break
+			}
+		}
+	}
+
+	for _, sev := range []struct{ key, value string }{
+		{"alerts.webhook.min_severity", c.Alerts.Webhook.MinSeverity},
+		{"alerts.stdout.min_severity", c.Alerts.Stdout.MinSeverity},
+		{"alerts.slack.min_severity", c.Alerts.Slack.MinSeverity},
+		{"alerts.pagerduty.min_severity", c.Alerts.PagerDuty.MinSeverity},
+		{"alerts.email.min_severity", c.Alerts.Email.MinSeverity},
+	} {
+		if !alert.ValidSeverity(sev.value) {
+			errs = append(errs, fmt.Errorf("%s must be one of info, warning, critical, expired, got %q", sev.key, sev.value))
+		}
+	}
+
 	if c.Server.Listen != "" {
-		_, _, err := net.SplitHostPort(c.Server.Listen)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("server.listen %q is not a valid host:port: %w", c.Server.Listen, err))
+		if path, ok := UnixSocketPath(c.Server.Listen); ok {
+			if !filepath.IsAbs(path) {
+				errs = append(errs, fmt.Errorf("server.listen %q must use an absolute socket path (unix:///path/to/aib.sock)", c.Server.Listen))
+			}
+		} else if _, _, err := net.SplitHostPort(c.Server.Listen); err != nil {
+			errs = append(errs, fmt.Errorf("server.listen %q is not a valid host:port or unix:// socket: %w", c.Server.Listen, err))
 		}
 	}
 
+	if c.Server.RateLimit < 0 {
+		errs = append(errs, fmt.Errorf("server.rate_limit must not be negative, got %g", c.Server.RateLimit))
+	}
+
 	if c.Server.APIToken != "" && len(c.Server.APIToken) < 8 {
 		errs = append(errs, fmt.Errorf("server.api_token is too short (%d chars), use at least 8 characters", len(c.Server.APIToken)))
 	}
 
-	if !c.Server.ReadOnly && c.Server.APIToken == "" {
-		errs = append(errs, fmt.Errorf("server.api_token is required when server.read_only is false"))
+	names := make(map[string]bool)
+	for i, t := range c.Server.APITokens {
+		switch {
+		case t.Name == "":
+			errs = append(errs, fmt.Errorf("server.api_tokens[%d].name is required", i))
+		case names[t.Name]:
+			errs = append(errs, fmt.Errorf("server.api_tokens: duplicate name %q", t.Name))
+		}
+		names[t.Name] = true
+		if len(t.Token) < 8 {
+			errs = append(errs, fmt.Errorf("server.api_tokens[%d].token is too short (%d chars), use at least 8 characters", i, len(t.Token)))
+		}
+		if t.Scope != ScopeRead && t.Scope != ScopeAdmin {
+			errs = append(errs, fmt.Errorf("server.api_tokens[%d].scope must be %q or %q, got %q", i, ScopeRead, ScopeAdmin, t.Scope))
+		}
+	}
+
+	if !c.Server.ReadOnly && !c.Server.HasAdminToken() {
+		errs = append(errs, fmt.Errorf("server.api_token is required when server.read_only is false (or add an admin-scoped server.api_tokens entry)"))
 	}
 
 	if c.Scan.Schedule != "" {
-		d, err := time.ParseDuration(c.Scan.Schedule)
+		if _, err := schedule.Parse(c.Scan.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("scan.schedule: %w", err))
+		}
+	}
+	if c.Scan.Timeout != "" {
+		if d, err := time.ParseDuration(c.Scan.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("scan.timeout %q is not a valid duration: %w", c.Scan.Timeout, err))
+		} else if d <= 0 {
+			errs = append(errs, fmt.Errorf("scan.timeout must be positive, got %s", d))
+		}
+	}
+	switch c.Scan.PruneMissing {
+	case "", PruneKeep, PruneStale, PruneDelete:
+	default:
+		errs = append(errs, fmt.Errorf("scan.prune_missing must be keep, stale or delete, got %q", c.Scan.PruneMissing))
+	}
+	ruleNames := make(map[string]bool, len(c.Scan.EdgeRules))
+	for i, r := range c.Scan.EdgeRules {
 		switch {
-		case err != nil && strings.Contains(c.Scan.Schedule, " "):
-			// Cron expressions used to be silently accepted here but the
-			// scheduler only understands Go durations, so they failed at startup.
-			errs = append(errs, fmt.Errorf("scan.schedule %q looks like a cron expression; cron schedules are not supported, use Go duration format (e.g. 4h, 30m, 1h30m)", c.Scan.Schedule))
-		case err != nil:
-			errs = append(errs, fmt.Errorf("scan.schedule %q is not a valid duration: %w", c.Scan.Schedule, err))
-		case d < time.Minute:
-			errs = append(errs, fmt.Errorf("scan.schedule must be >= 1m, got %s", d))
+		case r.Name == "":
+			errs = append(errs, fmt.Errorf("scan.edge_rules[%d]: name is required", i))
+		case ruleNames[r.Name]:
+			errs = append(errs, fmt.Errorf("scan.edge_rules[%d]: duplicate name %q", i, r.Name))
+		}
+		ruleNames[r.Name] = true
+		if r.Key == "" {
+			errs = append(errs, fmt.Errorf("scan.edge_rules[%d]: key is required", i))
+		}
+		if r.Edge == "" {
+			errs = append(errs, fmt.Errorf("scan.edge_rules[%d]: edge is required", i))
+		}
+	}
+	sourceSchedules := c.Sources.Schedules()
+	for _, key := range slices.Sorted(maps.Keys(sourceSchedules)) {
+		if _, err := schedule.Parse(sourceSchedules[key]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
 		}
 	}
 
@@ -279,6 +623,22 @@ func (c *Config) Validate() error {
 			errs = append(errs, fmt.Errorf("scan.allowed_paths[%d] %q must be absolute", i, p))
 		}
 	}
+	for _, globs := range []struct {
+		key      string
+		patterns []string
+	}{{"scan.include", c.Scan.Include}, {"scan.exclude", c.Scan.Exclude}} {
+		for i, p := range globs.patterns {
+			if _, err := filepath.Match(p, ""); err != nil {
+				errs = append(errs, fmt.Errorf("%s[%d] %q is not a valid glob: %w", globs.key, i, p, err))
+			}
+		}
+	}
 
 	return errors.Join(errs...)
 }
+
+// UnixSocketPath returns the socket path of a unix:// listen address and
+// whether listen is one.
+func UnixSocketPath(listen string) (string, bool) {
+	return strings.CutPrefix(listen, "unix://")
+}
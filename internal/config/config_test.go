@@ -30,6 +30,12 @@ func TestDefaults(t *testing.T) {
 	if cfg.Storage.Memgraph.URI != "bolt://localhost:7687" {
 		t.Errorf("memgraph.uri = %q", cfg.Storage.Memgraph.URI)
 	}
+	if cfg.Storage.Memgraph.SyncInterval != "5m" {
+		t.Errorf("memgraph.sync_interval = %q, want 5m", cfg.Storage.Memgraph.SyncInterval)
+	}
+	if cfg.Storage.Memgraph.SyncBatchSize != 500 {
+		t.Errorf("memgraph.sync_batch_size = %d, want 500", cfg.Storage.Memgraph.SyncBatchSize)
+	}
 	if cfg.Server.Listen != ":8080" {
 		t.Errorf("server.listen = %q, want :8080", cfg.Server.Listen)
 	}
@@ -216,6 +222,30 @@ func TestValidate_InvalidMemgraphURI(t *testing.T) {
 	}
 }
 
+func TestValidate_MemgraphSyncInterval(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Storage.Memgraph.Enabled = true
+	for _, spec := range []string{"5m", "*/10 * * * *", ""} {
+		cfg.Storage.Memgraph.SyncInterval = spec
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("sync_interval %q: %v", spec, err)
+		}
+	}
+	cfg.Storage.Memgraph.SyncInterval = "10s"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "storage.memgraph.sync_interval") {
+		t.Errorf("expected sync_interval error, got %v", err)
+	}
+}
+
+func TestValidate_MemgraphSyncBatchSize(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Storage.Memgraph.Enabled = true
+	cfg.Storage.Memgraph.SyncBatchSize = 0
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "storage.memgraph.sync_batch_size") {
+		t.Errorf("expected sync_batch_size error, got %v", err)
+	}
+}
+
 func TestValidate_InvalidProbeInterval(t *testing.T) {
 	cfg, _ := loadDefaults()
 	cfg.Certs.ProbeEnabled = true
@@ -264,6 +294,18 @@ func TestValidate_InvalidServerListen(t *testing.T) {
 	}
 }
 
+func TestValidate_UnixSocketListen(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Server.Listen = "unix:///run/aib.sock"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unix socket listen should be valid: %v", err)
+	}
+	cfg.Server.Listen = "unix://aib.sock"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for relative socket path")
+	}
+}
+
 func TestValidate_ShortAPIToken(t *testing.T) {
 	cfg, _ := loadDefaults()
 	cfg.Server.APIToken = "abc"
@@ -273,6 +315,59 @@ func TestValidate_ShortAPIToken(t *testing.T) {
 	}
 }
 
+func TestValidate_NegativeRateLimit(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Server.RateLimit = -1
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for negative rate limit")
+	}
+}
+
+func TestValidate_DedupWindow(t *testing.T) {
+	for _, tt := range []struct {
+		window  string
+		wantErr bool
+	}{
+		{"24h", false},
+		{"0", false},
+		{"daily", true},
+		{"-1h", true},
+	} {
+		cfg, _ := loadDefaults()
+		cfg.Alerts.DedupWindow = tt.window
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("dedup_window %q: error = %v, wantErr %v", tt.window, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidate_Email(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Alerts.Email = EmailConfig{
+		Enabled: true,
+		Host:    "smtp.example.com",
+		Port:    587,
+		From:    "aib@example.com",
+		To:      []string{"ops@example.com"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid email config: %v", err)
+	}
+
+	cfg.Alerts.Email.To = nil
+	cfg.Alerts.Email.TLS = "ssl"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for missing recipients and bad tls")
+	}
+	for _, want := range []string{"at least one to address", "alerts.email.tls"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
+
 func TestValidate_InvalidScanSchedule(t *testing.T) {
 	cfg, _ := loadDefaults()
 	cfg.Scan.Schedule = "bad"
@@ -282,15 +377,37 @@ func TestValidate_InvalidScanSchedule(t *testing.T) {
 	}
 }
 
-func TestValidate_CronScheduleRejected(t *testing.T) {
+func TestValidate_CronSchedule(t *testing.T) {
 	cfg, _ := loadDefaults()
-	cfg.Scan.Schedule = "0 */6 * * *"
+	cfg.Scan.Schedule = "0 2 * * *"
+	cfg.Certs.ProbeEnabled = true
+	cfg.Certs.ProbeInterval = "@daily"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("cron schedules should be accepted: %v", err)
+	}
+
+	cfg.Scan.Schedule = "0 25 * * *"
 	err := cfg.Validate()
 	if err == nil {
-		t.Fatal("cron schedule should be rejected (scheduler only supports Go durations)")
+		t.Fatal("expected error for out-of-range cron hour")
+	}
+	if !strings.Contains(err.Error(), "scan.schedule") || !strings.Contains(err.Error(), "cron") {
+		t.Errorf("error %q should name scan.schedule and the cron expression", err)
+	}
+}
+
+func TestValidate_SourceSchedules(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Sources.Terraform = []TerraformSource{{Path: "/infra/tf", Schedule: "1h"}}
+	cfg.Sources.Kubernetes = []KubernetesSource{{Live: true, Schedule: "*/5 * * * *"}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid source schedules rejected: %v", err)
 	}
-	if !strings.Contains(err.Error(), "cron") || !strings.Contains(err.Error(), "duration") {
-		t.Errorf("error %q should explain cron is unsupported and suggest duration format", err)
+
+	cfg.Sources.Kubernetes[0].Schedule = "10s"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "sources.kubernetes[0].schedule") {
+		t.Errorf("expected sources.kubernetes[0].schedule error, got: %v", err)
 	}
 }
 
@@ -337,6 +454,119 @@ func TestValidate_WritableModeWithToken(t *testing.T) {
 	}
 }
 
+func TestValidate_WritableModeWithScopedTokens(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Server.ReadOnly = false
+	cfg.Server.APIToken = ""
+	cfg.Server.APITokens = []APITokenConfig{{Name: "dash", Token: "read-token-1", Scope: ScopeRead}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("writable mode with only a read token should be invalid")
+	}
+
+	cfg.Server.APITokens = append(cfg.Server.APITokens, APITokenConfig{Name: "ci", Token: "admin-token-1", Scope: ScopeAdmin})
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("writable mode with an admin token should be valid, got: %v", err)
+	}
+}
+
+func TestValidate_APITokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []APITokenConfig
+		want   string
+	}{
+		{"missing name", []APITokenConfig{{Token: "long-enough", Scope: ScopeRead}}, "name is required"},
+		{"duplicate name", []APITokenConfig{{Name: "a", Token: "long-enough", Scope: ScopeRead}, {Name: "a", Token: "long-enough-2", Scope: ScopeRead}}, "duplicate name"},
+		{"short token", []APITokenConfig{{Name: "a", Token: "short", Scope: ScopeRead}}, "too short"},
+		{"bad scope", []APITokenConfig{{Name: "a", Token: "long-enough", Scope: "write"}}, "scope must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := loadDefaults()
+			cfg.Server.APITokens = tt.tokens
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_APITokensExpandEnv(t *testing.T) {
+	t.Setenv("AIB_TEST_READ_TOKEN", "from-environment")
+	content := `
+server:
+  api_tokens:
+    - name: dashboards
+      token: "${AIB_TEST_READ_TOKEN}"
+      scope: read
+`
+	tmpFile := t.TempDir() + "/aib.yaml"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(tmpFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Server.APITokens) != 1 {
+		t.Fatalf("api_tokens = %d, want 1", len(cfg.Server.APITokens))
+	}
+	if got := cfg.Server.APITokens[0]; got.Name != "dashboards" || got.Token != "from-environment" || got.Scope != ScopeRead {
+		t.Errorf("api_tokens[0] = %+v", got)
+	}
+}
+
+func TestLoad_EdgeRules(t *testing.T) {
+	content := `
+scan:
+  edge_rules:
+    - name: app-peers
+      key: metadata.tag:app
+      edge: connects_to
+    - name: cluster-members
+      to: type=node
+      key: metadata.tag:cluster
+      to_key: name
+      edge: member_of
+`
+	tmpFile := t.TempDir() + "/aib.yaml"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(tmpFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Scan.EdgeRules) != 2 {
+		t.Fatalf("edge_rules = %d, want 2", len(cfg.Scan.EdgeRules))
+	}
+	want := EdgeRule{Name: "cluster-members", To: "type=node", Key: "metadata.tag:cluster", ToKey: "name", Edge: "member_of"}
+	if got := cfg.Scan.EdgeRules[1]; got != want {
+		t.Errorf("edge_rules[1] = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidate_EdgeRules(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Scan.EdgeRules = []EdgeRule{
+		{Name: "a", Key: "name", Edge: "connects_to"},
+		{Name: "a", Key: "name", Edge: "connects_to"},
+		{Key: "name"},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors for invalid edge rules")
+	}
+	for _, want := range []string{"edge_rules[1]: duplicate name", "edge_rules[2]: name is required", "edge_rules[2]: edge is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should contain %q, got: %v", want, err)
+		}
+	}
+}
+
 func TestValidate_AllowedPaths_Relative(t *testing.T) {
 	cfg, _ := loadDefaults()
 	cfg.Scan.AllowedPaths = []string{"relative/path"}
@@ -349,6 +579,73 @@ func TestValidate_AllowedPaths_Relative(t *testing.T) {
 	}
 }
 
+func TestValidate_ScanGlobs(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Scan.Exclude = []string{"testdata", "vendor/*"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid globs rejected: %v", err)
+	}
+	cfg.Scan.Include = []string{"envs/[prod"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "scan.include[0]") {
+		t.Errorf("expected invalid scan.include glob error, got: %v", err)
+	}
+}
+
+func TestValidate_ScanTimeout(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Scan.Timeout = "10m"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid timeout rejected: %v", err)
+	}
+	for _, bad := range []string{"forever", "-5m", "0s"} {
+		cfg.Scan.Timeout = bad
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), "scan.timeout") {
+			t.Errorf("timeout %q: expected scan.timeout error, got: %v", bad, err)
+		}
+	}
+}
+
+func TestValidate_PruneMissing(t *testing.T) {
+	cfg, _ := loadDefaults()
+	for _, mode := range []string{PruneKeep, PruneStale, PruneDelete} {
+		cfg.Scan.PruneMissing = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("prune_missing %q rejected: %v", mode, err)
+		}
+	}
+	cfg.Scan.PruneMissing = "purge"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "scan.prune_missing") {
+		t.Errorf("expected scan.prune_missing error, got: %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Server.APIToken = "admin-secret"
+	cfg.Server.APITokens = []APITokenConfig{{Name: "ci", Token: "ci-secret", Scope: ScopeRead}}
+	cfg.Alerts.Webhook.Headers = map[string]string{"Authorization": "Bearer hook-secret"}
+	cfg.Storage.Memgraph.URI = "bolt://memgraph:7687"
+
+	r := cfg.Redacted()
+	if r.Server.APIToken != "REDACTED" || r.Server.APITokens[0].Token != "REDACTED" ||
+		r.Alerts.Webhook.Headers["Authorization"] != "REDACTED" {
+		t.Errorf("secrets not redacted: %+v", r.Server)
+	}
+	if cfg.Server.APITokens[0].Token != "ci-secret" || cfg.Alerts.Webhook.Headers["Authorization"] != "Bearer hook-secret" {
+		t.Error("Redacted modified the original config")
+	}
+	if r.Storage.Memgraph.Password != "" {
+		t.Errorf("empty password should stay empty, got %q", r.Storage.Memgraph.Password)
+	}
+
+	storage := r.Settings()["storage"].(map[string]any)
+	if uri := storage["memgraph"].(map[string]any)["uri"]; uri != "bolt://memgraph:7687" {
+		t.Errorf(`Settings()["storage"]["memgraph"]["uri"] = %v`, uri)
+	}
+}
+
 func TestValidate_InvalidSlackWebhookURL(t *testing.T) {
 	cfg, _ := loadDefaults()
 	cfg.Alerts.Slack.Enabled = true
@@ -381,8 +678,10 @@ func loadDefaults() (*Config, error) {
 		Storage: StorageConfig{
 			Path: "./data/aib.db",
 			Memgraph: MemgraphConfig{
-				Enabled: false,
-				URI:     "bolt://localhost:7687",
+				Enabled:       false,
+				URI:           "bolt://localhost:7687",
+				SyncInterval:  "5m",
+				SyncBatchSize: 500,
 			},
 		},
 		Server: ServerConfig{
@@ -402,3 +701,56 @@ func loadDefaults() (*Config, error) {
 		},
 	}, nil
 }
+
+func TestValidate_InvalidMinSeverity(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Alerts.Slack.MinSeverity = "urgent"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for unknown min_severity")
+	}
+	if !strings.Contains(err.Error(), "alerts.slack.min_severity") {
+		t.Errorf("error should mention alerts.slack.min_severity, got: %v", err)
+	}
+
+	cfg.Alerts.Slack.MinSeverity = "critical"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("critical should be valid, got: %v", err)
+	}
+}
+
+func TestValidate_ExpiryThresholds(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Expiry.Thresholds = map[string][]int{"secret": {30, 7}, "kms_key": {7, 30}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for ascending thresholds")
+	}
+	if !strings.Contains(err.Error(), "expiry.thresholds.kms_key") {
+		t.Errorf("error should mention expiry.thresholds.kms_key, got: %v", err)
+	}
+
+	// Any type string is accepted, including types nothing built in
+	// produces and Kubernetes custom resource kinds.
+	cfg.Expiry.Thresholds = map[string][]int{"access_key": {30, 7}, "externalsecret": {14, 3}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error for custom types: %v", err)
+	}
+
+	cfg.Expiry.Thresholds = map[string][]int{"secret": {30, 7}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("valid thresholds rejected: %v", err)
+	}
+}
+
+func TestValidate_TelemetryEndpoint(t *testing.T) {
+	cfg, _ := loadDefaults()
+	cfg.Telemetry.OTLPEndpoint = "http://otel-collector:4318"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("http endpoint should be valid: %v", err)
+	}
+	cfg.Telemetry.OTLPEndpoint = "otel-collector:4318"
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for endpoint without http scheme")
+	}
+}
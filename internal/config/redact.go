@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secret values in Redacted.
+const redacted = "REDACTED"
+
+// Redacted returns a copy of c with passwords, tokens, routing keys,
+// webhook URLs and webhook headers replaced, safe to print or log.
+func (c Config) Redacted() Config {
+	hide := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+	hide(&c.Storage.Memgraph.Password)
+	hide(&c.Alerts.Webhook.URL)
+	hide(&c.Alerts.Slack.WebhookURL)
+	hide(&c.Alerts.PagerDuty.RoutingKey)
+	hide(&c.Alerts.Email.Password)
+	hide(&c.Server.APIToken)
+
+	if c.Alerts.Webhook.Headers != nil {
+		headers := make(map[string]string, len(c.Alerts.Webhook.Headers))
+		for k, v := range c.Alerts.Webhook.Headers {
+			hide(&v)
+			headers[k] = v
+		}
+		c.Alerts.Webhook.Headers = headers
+	}
+	if c.Server.APITokens != nil {
+		c.Server.APITokens = append([]APITokenConfig(nil), c.Server.APITokens...)
+		for i := range c.Server.APITokens {
+			hide(&c.Server.APITokens[i].Token)
+		}
+	}
+	return c
+}
+
+// Settings returns c as nested maps keyed like the config file, e.g.
+// Settings()["storage"]["memgraph"]["uri"].
+func (c Config) Settings() map[string]any {
+	return settings(reflect.ValueOf(c)).(map[string]any)
+}
+
+func settings(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			f := v.Type().Field(i)
+			key, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+			if key == "" || !f.IsExported() {
+				continue
+			}
+			m[key] = settings(v.Field(i))
+		}
+		return m
+	case reflect.Slice:
+		if v.IsNil() {
+			return []any{}
+		}
+		s := make([]any, v.Len())
+		for i := range v.Len() {
+			s[i] = settings(v.Index(i))
+		}
+		return s
+	case reflect.Map:
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = settings(iter.Value())
+		}
+		return m
+	default:
+		return v.Interface()
+	}
+}
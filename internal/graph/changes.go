@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"sync"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// Change operations carried by a ChangeEvent.
+const (
+	ChangeUpsert = "upsert" // a node or edge was added or updated
+	ChangeDelete = "delete" // a node was deleted, along with its edges
+	ChangeReset  = "reset"  // the whole graph was replaced; reload it
+)
+
+// changeBuffer is how many events a subscriber may fall behind by before it
+// is dropped.
+const changeBuffer = 1024
+
+// ChangeEvent reports one committed mutation of the graph. Exactly one of
+// Node, Edge or NodeID is set, except for ChangeReset, which carries none.
+type ChangeEvent struct {
+	Op     string       `json:"op"`
+	Node   *models.Node `json:"node,omitempty"`
+	Edge   *models.Edge `json:"edge,omitempty"`
+	NodeID string       `json:"node_id,omitempty"`
+}
+
+// changeHub fans change events out to subscribers. Publishing never blocks:
+// a subscriber whose buffer is full is dropped and its channel closed, so a
+// slow client reloads instead of stalling writes.
+type changeHub struct {
+	mu   sync.Mutex
+	subs map[chan ChangeEvent]struct{}
+}
+
+func (h *changeHub) publish(events ...ChangeEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		for _, ev := range events {
+			select {
+			case ch <- ev:
+			default:
+				delete(h.subs, ch)
+				close(ch)
+			}
+			if _, ok := h.subs[ch]; !ok {
+				break
+			}
+		}
+	}
+}
+
+// active reports whether anyone is subscribed, so writers can skip building
+// events nobody reads.
+func (h *changeHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs) > 0
+}
+
+func (h *changeHub) subscribe() (<-chan ChangeEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[chan ChangeEvent]struct{})
+	}
+	ch := make(chan ChangeEvent, changeBuffer)
+	h.subs[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscribeChanges returns a channel of graph mutations committed through
+// this store from now on. The channel is closed when unsubscribe is called,
+// or early if the subscriber falls more than changeBuffer events behind.
+// Changes written by other processes sharing the database are not seen.
+func (s *SQLiteStore) SubscribeChanges() (events <-chan ChangeEvent, unsubscribe func()) {
+	return s.changes.subscribe()
+}
+
+// publishUpserts publishes an upsert event for each node and edge.
+func (s *SQLiteStore) publishUpserts(nodes []models.Node, edges []models.Edge) {
+	if !s.changes.active() {
+		return
+	}
+	events := make([]ChangeEvent, 0, len(nodes)+len(edges))
+	for _, n := range nodes {
+		events = append(events, ChangeEvent{Op: ChangeUpsert, Node: &n})
+	}
+	for _, e := range edges {
+		events = append(events, ChangeEvent{Op: ChangeUpsert, Edge: &e})
+	}
+	s.changes.publish(events...)
+}
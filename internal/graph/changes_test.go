@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestSubscribeChanges(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	events, unsubscribe := store.SubscribeChanges()
+	defer unsubscribe()
+
+	nodes := []models.Node{
+		correlationTestNode("a", "a", models.AssetVM, "terraform", nil),
+		correlationTestNode("b", "b", models.AssetDatabase, "terraform", nil),
+	}
+	edges := []models.Edge{{ID: "a->b", FromID: "a", ToID: "b", Type: models.EdgeConnectsTo}}
+	if err := store.UpsertBatch(ctx, nodes, edges); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteNode(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.ReplaceGraph(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for range 5 {
+		ev := <-events
+		switch {
+		case ev.Node != nil:
+			got = append(got, ev.Op+" node "+ev.Node.ID)
+		case ev.Edge != nil:
+			got = append(got, ev.Op+" edge "+ev.Edge.ID)
+		default:
+			got = append(got, ev.Op+" "+ev.NodeID)
+		}
+	}
+	want := []string{"upsert node a", "upsert node b", "upsert edge a->b", "delete b", "reset "}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %q, want %q", got, want)
+		}
+	}
+
+	// A failed batch publishes nothing.
+	bad := []models.Edge{{ID: "x->y", FromID: "x", ToID: "y", Type: models.EdgeConnectsTo}}
+	if err := store.UpsertBatch(ctx, nil, bad); err == nil {
+		t.Fatal("expected a foreign key error")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event after failed batch: %+v", ev)
+	default:
+	}
+}
+
+func TestSubscribeChanges_DropsSlowSubscriber(t *testing.T) {
+	store := newTestStore(t)
+	events, unsubscribe := store.SubscribeChanges()
+	defer unsubscribe()
+
+	nodes := make([]models.Node, changeBuffer+1)
+	for i := range nodes {
+		nodes[i] = correlationTestNode(fmt.Sprintf("n%d", i), "n", models.AssetVM, "terraform", nil)
+	}
+	store.publishUpserts(nodes, nil)
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != changeBuffer {
+		t.Errorf("received %d events before the channel closed, want %d", n, changeBuffer)
+	}
+	if store.changes.active() {
+		t.Error("dropped subscriber should be removed")
+	}
+}
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// CriticalNode ranks a node by how much of the graph depends on it.
+type CriticalNode struct {
+	Node       *models.Node `json:"node"`
+	Affected   int          `json:"affected"`   // nodes in its upstream blast radius
+	Dependents int          `json:"dependents"` // nodes with an edge directly to it
+	Score      float64      `json:"score"`      // Affected as a fraction of all other nodes
+}
+
+// RankCriticality scores every node by the size of its blast radius and
+// returns them most critical first; ties are broken by direct dependents,
+// then ID. The adjacency is loaded once for all traversals.
+func RankCriticality(ctx context.Context, store *SQLiteStore) ([]CriticalNode, error) {
+	adj, err := loadAdjacency(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	others := float64(len(adj.nodes) - 1)
+	ranked := make([]CriticalNode, 0, len(adj.nodes))
+	for i := range adj.nodes {
+		n := &adj.nodes[i]
+		affected := adj.blastRadius(n.ID, 0, DirectionUpstream).AffectedNodes
+		dependents := make(map[string]bool)
+		for _, e := range adj.upstream[n.ID] {
+			dependents[e.FromID] = true
+		}
+		c := CriticalNode{Node: n, Affected: affected, Dependents: len(dependents)}
+		if others > 0 {
+			c.Score = float64(affected) / others
+		}
+		ranked = append(ranked, c)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Affected != b.Affected {
+			return a.Affected > b.Affected
+		}
+		if a.Dependents != b.Dependents {
+			return a.Dependents > b.Dependents
+		}
+		return a.Node.ID < b.Node.ID
+	})
+	return ranked, nil
+}
+
+// CriticalityScores returns each node's criticality score: the fraction of
+// the other nodes that break if it fails.
+func CriticalityScores(ctx context.Context, store *SQLiteStore) (map[string]float64, error) {
+	ranked, err := RankCriticality(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(ranked))
+	for _, c := range ranked {
+		scores[c.Node.ID] = c.Score
+	}
+	return scores, nil
+}
@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestRankCriticality(t *testing.T) {
+	store := newTestStore(t)
+	// A shared VPC with two subnets, each hosting a VM; a database sits
+	// directly in the VPC and a DNS record points at one VM.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("vpc", models.AssetNetwork, "tf"),
+			makeNode("subnet-a", models.AssetSubnet, "tf"),
+			makeNode("subnet-b", models.AssetSubnet, "tf"),
+			makeNode("vm-a", models.AssetVM, "tf"),
+			makeNode("vm-b", models.AssetVM, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+			makeNode("dns", models.AssetDNSRecord, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("subnet-a", "vpc", models.EdgeMemberOf),
+			makeEdge("subnet-b", "vpc", models.EdgeMemberOf),
+			makeEdge("vm-a", "subnet-a", models.EdgeConnectsTo),
+			makeEdge("vm-b", "subnet-b", models.EdgeConnectsTo),
+			makeEdge("db", "vpc", models.EdgeDependsOn),
+			makeEdge("dns", "vm-a", models.EdgeResolvesTo),
+		},
+	)
+	ctx := context.Background()
+
+	ranked, err := RankCriticality(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranked) != 7 {
+		t.Fatalf("ranked = %d nodes, want 7", len(ranked))
+	}
+
+	want := []struct {
+		id         string
+		affected   int
+		dependents int
+	}{
+		{"vpc", 6, 3},
+		{"subnet-a", 2, 1},
+		{"subnet-b", 1, 1},
+		{"vm-a", 1, 1},
+	}
+	for i, w := range want {
+		got := ranked[i]
+		if got.Node.ID != w.id || got.Affected != w.affected || got.Dependents != w.dependents {
+			t.Errorf("rank %d = %s (affected %d, dependents %d), want %s (%d, %d)",
+				i+1, got.Node.ID, got.Affected, got.Dependents, w.id, w.affected, w.dependents)
+		}
+	}
+	if ranked[0].Score != 1 {
+		t.Errorf("vpc score = %v, want 1 (every other node breaks)", ranked[0].Score)
+	}
+	if last := ranked[len(ranked)-1]; last.Affected != 0 || last.Score != 0 {
+		t.Errorf("last = %+v, want a leaf with score 0", last)
+	}
+
+	scores, err := CriticalityScores(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores["subnet-a"] != 2.0/6 {
+		t.Errorf("subnet-a score = %v, want %v", scores["subnet-a"], 2.0/6)
+	}
+}
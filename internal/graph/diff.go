@@ -0,0 +1,186 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// GraphDiff describes what changed between two graph snapshots.
+type GraphDiff struct {
+	NodesAdded    []NodeChange       `json:"nodes_added"`
+	NodesRemoved  []NodeChange       `json:"nodes_removed"`
+	NodesModified []NodeModification `json:"nodes_modified"`
+	EdgesAdded    []EdgeChange       `json:"edges_added"`
+	EdgesRemoved  []EdgeChange       `json:"edges_removed"`
+	EdgesModified []EdgeModification `json:"edges_modified"`
+}
+
+// EdgeModification records an edge whose type or metadata changed. An edge
+// between the same two nodes whose type changed is reported here rather
+// than as a removal plus an addition.
+type EdgeModification struct {
+	ID      string   `json:"id"`
+	FromID  string   `json:"from_id"`
+	ToID    string   `json:"to_id"`
+	OldType string   `json:"old_type,omitempty"`
+	Type    string   `json:"type"`
+	Changes []string `json:"changes"` // e.g. ["type", "metadata.port"]
+}
+
+// HasChanges returns true if the diff contains any change.
+func (d *GraphDiff) HasChanges() bool {
+	return len(d.NodesAdded) > 0 || len(d.NodesRemoved) > 0 || len(d.NodesModified) > 0 ||
+		len(d.EdgesAdded) > 0 || len(d.EdgesRemoved) > 0 || len(d.EdgesModified) > 0
+}
+
+// LoadGraphData reads a snapshot written by ExportJSON.
+func LoadGraphData(path string) (GraphData, error) {
+	var data GraphData
+	b, err := os.ReadFile(path) // #nosec G304 -- user-provided snapshot path
+	if err != nil {
+		return data, fmt.Errorf("reading snapshot: %w", err)
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return data, fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Diff compares two graph snapshots. Nodes and edges are matched by ID;
+// edges left unmatched on both sides that connect the same two nodes are
+// paired as a type change. All lists are sorted by ID.
+func Diff(old, new GraphData) GraphDiff {
+	var d GraphDiff
+
+	oldNodes := make(map[string]models.Node, len(old.Nodes))
+	for _, n := range old.Nodes {
+		oldNodes[n.ID] = n
+	}
+	newNodes := make(map[string]models.Node, len(new.Nodes))
+	for _, n := range new.Nodes {
+		newNodes[n.ID] = n
+		o, ok := oldNodes[n.ID]
+		if !ok {
+			d.NodesAdded = append(d.NodesAdded, NodeChange{ID: n.ID, Name: n.Name, Type: string(n.Type)})
+			continue
+		}
+		if changes := NodeChanges(o, n); len(changes) > 0 {
+			d.NodesModified = append(d.NodesModified, NodeModification{ID: n.ID, Name: n.Name, Changes: changes})
+		}
+	}
+	for _, n := range old.Nodes {
+		if _, ok := newNodes[n.ID]; !ok {
+			d.NodesRemoved = append(d.NodesRemoved, NodeChange{ID: n.ID, Name: n.Name, Type: string(n.Type)})
+		}
+	}
+
+	oldEdges := make(map[string]models.Edge, len(old.Edges))
+	for _, e := range old.Edges {
+		oldEdges[e.ID] = e
+	}
+	newEdges := make(map[string]models.Edge, len(new.Edges))
+	var added []models.Edge
+	for _, e := range new.Edges {
+		newEdges[e.ID] = e
+		o, ok := oldEdges[e.ID]
+		if !ok {
+			added = append(added, e)
+			continue
+		}
+		changes := compareMetadata(o.Metadata, e.Metadata)
+		if o.Type != e.Type {
+			changes = append([]string{"type"}, changes...)
+		}
+		if len(changes) > 0 {
+			d.EdgesModified = append(d.EdgesModified, edgeModification(o, e, changes))
+		}
+	}
+
+	// Unmatched old edges, keyed by endpoints, can pair with an added edge.
+	removed := make(map[[2]string][]models.Edge)
+	for _, e := range old.Edges {
+		if _, ok := newEdges[e.ID]; !ok {
+			key := [2]string{e.FromID, e.ToID}
+			removed[key] = append(removed[key], e)
+		}
+	}
+	for _, e := range added {
+		key := [2]string{e.FromID, e.ToID}
+		if candidates := removed[key]; len(candidates) > 0 {
+			o := candidates[0]
+			removed[key] = candidates[1:]
+			changes := append([]string{"type"}, compareMetadata(o.Metadata, e.Metadata)...)
+			d.EdgesModified = append(d.EdgesModified, edgeModification(o, e, changes))
+			continue
+		}
+		d.EdgesAdded = append(d.EdgesAdded, EdgeChange{ID: e.ID, FromID: e.FromID, ToID: e.ToID, Type: string(e.Type)})
+	}
+	for _, edges := range removed {
+		for _, e := range edges {
+			d.EdgesRemoved = append(d.EdgesRemoved, EdgeChange{ID: e.ID, FromID: e.FromID, ToID: e.ToID, Type: string(e.Type)})
+		}
+	}
+
+	sort.Slice(d.NodesAdded, func(i, j int) bool { return d.NodesAdded[i].ID < d.NodesAdded[j].ID })
+	sort.Slice(d.NodesRemoved, func(i, j int) bool { return d.NodesRemoved[i].ID < d.NodesRemoved[j].ID })
+	sort.Slice(d.NodesModified, func(i, j int) bool { return d.NodesModified[i].ID < d.NodesModified[j].ID })
+	sort.Slice(d.EdgesAdded, func(i, j int) bool { return d.EdgesAdded[i].ID < d.EdgesAdded[j].ID })
+	sort.Slice(d.EdgesRemoved, func(i, j int) bool { return d.EdgesRemoved[i].ID < d.EdgesRemoved[j].ID })
+	sort.Slice(d.EdgesModified, func(i, j int) bool { return d.EdgesModified[i].ID < d.EdgesModified[j].ID })
+	return d
+}
+
+func edgeModification(old, new models.Edge, changes []string) EdgeModification {
+	m := EdgeModification{ID: new.ID, FromID: new.FromID, ToID: new.ToID, Type: string(new.Type), Changes: changes}
+	if old.Type != new.Type {
+		m.OldType = string(old.Type)
+	}
+	return m
+}
+
+// NodeChanges lists the attributes that differ between an old and new
+// version of a node: "name", "type" and "metadata.<key>".
+func NodeChanges(old, new models.Node) []string {
+	var changes []string
+
+	if old.Name != new.Name {
+		changes = append(changes, "name")
+	}
+	if old.Type != new.Type {
+		changes = append(changes, "type")
+	}
+
+	// Compare metadata
+	metaChanges := compareMetadata(old.Metadata, new.Metadata)
+	changes = append(changes, metaChanges...)
+
+	return changes
+}
+
+// compareMetadata detects changed, added, and removed metadata keys.
+func compareMetadata(old, new map[string]string) []string {
+	var changes []string
+
+	// Check for changed or removed keys
+	for k, v := range old {
+		if newV, ok := new[k]; !ok {
+			changes = append(changes, "metadata."+k)
+		} else if v != newV {
+			changes = append(changes, "metadata."+k)
+		}
+	}
+
+	// Check for added keys
+	for k := range new {
+		if _, ok := old[k]; !ok {
+			changes = append(changes, "metadata."+k)
+		}
+	}
+
+	sort.Strings(changes)
+	return changes
+}
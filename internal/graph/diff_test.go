@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestDiff(t *testing.T) {
+	web := makeNode("web", models.AssetVM, "terraform")
+	db := makeNode("db", models.AssetDatabase, "terraform")
+	cache := makeNode("cache", models.AssetDatabase, "terraform")
+	queue := makeNode("queue", models.AssetQueue, "terraform")
+
+	resized := db
+	resized.Metadata = map[string]string{"size": "large"}
+
+	old := GraphData{
+		Nodes: []models.Node{web, db, cache},
+		Edges: []models.Edge{
+			makeEdge("web", "db", models.EdgeDependsOn),
+			makeEdge("web", "cache", models.EdgeConnectsTo),
+		},
+	}
+	new := GraphData{
+		Nodes: []models.Node{web, resized, queue},
+		Edges: []models.Edge{
+			makeEdge("web", "db", models.EdgeConnectsTo),
+			makeEdge("web", "queue", models.EdgeDependsOn),
+		},
+	}
+
+	d := Diff(old, new)
+
+	if len(d.NodesAdded) != 1 || d.NodesAdded[0].ID != "queue" {
+		t.Errorf("nodes added = %+v, want queue", d.NodesAdded)
+	}
+	if len(d.NodesRemoved) != 1 || d.NodesRemoved[0].ID != "cache" {
+		t.Errorf("nodes removed = %+v, want cache", d.NodesRemoved)
+	}
+	if len(d.NodesModified) != 1 || !reflect.DeepEqual(d.NodesModified[0].Changes, []string{"metadata.size"}) {
+		t.Errorf("nodes modified = %+v, want db metadata.size", d.NodesModified)
+	}
+
+	if len(d.EdgesModified) != 1 {
+		t.Fatalf("edges modified = %+v, want the retyped web -> db edge", d.EdgesModified)
+	}
+	retyped := d.EdgesModified[0]
+	if retyped.FromID != "web" || retyped.ToID != "db" || retyped.OldType != "depends_on" || retyped.Type != "connects_to" {
+		t.Errorf("unexpected retyped edge: %+v", retyped)
+	}
+	if len(d.EdgesAdded) != 1 || d.EdgesAdded[0].ToID != "queue" {
+		t.Errorf("edges added = %+v, want web -> queue", d.EdgesAdded)
+	}
+	if len(d.EdgesRemoved) != 1 || d.EdgesRemoved[0].ToID != "cache" {
+		t.Errorf("edges removed = %+v, want web -> cache", d.EdgesRemoved)
+	}
+	if !d.HasChanges() {
+		t.Error("HasChanges() = false")
+	}
+
+	if same := Diff(old, old); same.HasChanges() {
+		t.Errorf("diff of identical graphs = %+v", same)
+	}
+}
+
+func TestLoadGraphData(t *testing.T) {
+	store := newTestStore(t)
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("a", models.AssetVM, "terraform"), makeNode("b", models.AssetVM, "terraform")},
+		[]models.Edge{makeEdge("a", "b", models.EdgeDependsOn)})
+
+	out, err := ExportJSON(t.Context(), store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	if err := os.WriteFile(path, []byte(out), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := LoadGraphData(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Nodes) != 2 || len(data.Edges) != 1 {
+		t.Errorf("loaded %d nodes, %d edges; want 2, 1", len(data.Nodes), len(data.Edges))
+	}
+	if _, err := LoadGraphData(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing snapshot")
+	}
+}
@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// DNSSummary describes the DNS resolution pass.
+type DNSSummary struct {
+	Records    int `json:"records"`
+	EdgesAdded int `json:"edges_added"`
+	Lookups    int `json:"lookups"`
+}
+
+// HostResolver looks up the addresses of a host name. *net.Resolver
+// satisfies it.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// dnsRecordKeys hold a record's declared targets; values may be
+// comma-separated.
+var dnsRecordKeys = []string{"records", "alias_target", "value", "content", "target"}
+
+// dnsAddressKeys hold the addresses and host names an IP-address or
+// load-balancer node answers on.
+var dnsAddressKeys = []string{
+	"address", "ip_address", "public_ip", "private_ip", "external_ip",
+	"internal_ip", "nat_ip", "dns_name", "hostname",
+}
+
+// ResolveDNS adds resolves_to edges from DNS record nodes to the IP-address
+// and load-balancer nodes their targets match. Targets come from record
+// metadata (records, alias targets); when resolver is non-nil each record's
+// name is also looked up live and the returned addresses matched too.
+// Lookup failures are skipped, so an unresolvable name never fails a scan.
+func ResolveDNS(ctx context.Context, store *SQLiteStore, resolver HostResolver) (*DNSSummary, error) {
+	records, err := store.ListNodes(ctx, NodeFilter{Type: string(models.AssetDNSRecord)})
+	if err != nil {
+		return nil, err
+	}
+	summary := &DNSSummary{Records: len(records)}
+	if len(records) == 0 {
+		return summary, nil
+	}
+
+	byAddress := make(map[string][]string)
+	for _, t := range []models.AssetType{models.AssetIPAddress, models.AssetLoadBalancer} {
+		nodes, err := store.ListNodes(ctx, NodeFilter{Type: string(t)})
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nodes {
+			for _, key := range dnsAddressKeys {
+				for _, addr := range splitDNSValues(n.Metadata[key]) {
+					byAddress[addr] = append(byAddress[addr], n.ID)
+				}
+			}
+		}
+	}
+	if len(byAddress) == 0 {
+		return summary, nil
+	}
+
+	existingEdges, err := store.ListEdges(ctx, EdgeFilter{Type: string(models.EdgeResolvesTo)})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(existingEdges))
+	for _, edge := range existingEdges {
+		existing[edge.ID] = true
+	}
+
+	for _, rec := range records {
+		targets := map[string]string{} // target -> method
+		for _, key := range dnsRecordKeys {
+			for _, v := range splitDNSValues(rec.Metadata[key]) {
+				targets[v] = "record"
+			}
+		}
+		if resolver != nil {
+			if host := recordHost(rec); host != "" {
+				summary.Lookups++
+				if addrs, err := resolver.LookupHost(ctx, host); err == nil {
+					for _, a := range addrs {
+						if _, ok := targets[a]; !ok {
+							targets[a] = "lookup"
+						}
+					}
+				}
+			}
+		}
+
+		keys := make([]string, 0, len(targets))
+		for t := range targets {
+			keys = append(keys, t)
+		}
+		sort.Strings(keys)
+		for _, target := range keys {
+			for _, toID := range byAddress[target] {
+				if toID == rec.ID {
+					continue
+				}
+				edge := models.Edge{
+					ID:     GenerateEdgeID(rec.ID, toID, models.EdgeResolvesTo),
+					FromID: rec.ID,
+					ToID:   toID,
+					Type:   models.EdgeResolvesTo,
+					Metadata: map[string]string{
+						"target": target,
+						"method": targets[target],
+					},
+				}
+				if existing[edge.ID] {
+					continue
+				}
+				if err := store.UpsertEdge(ctx, edge); err != nil {
+					return nil, err
+				}
+				existing[edge.ID] = true
+				summary.EdgesAdded++
+			}
+		}
+	}
+	return summary, nil
+}
+
+// recordHost returns the host name to look up for a DNS record node, or ""
+// if it has none that looks like a name.
+func recordHost(n models.Node) string {
+	for _, v := range []string{n.Metadata["fqdn"], n.Metadata["hostname"], n.Name} {
+		v = strings.TrimSuffix(strings.TrimSpace(v), ".")
+		if strings.Contains(v, ".") && net.ParseIP(v) == nil {
+			return v
+		}
+	}
+	return ""
+}
+
+// splitDNSValues splits a comma-separated metadata value into normalized
+// targets: lowercased, without the trailing root dot.
+func splitDNSValues(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), "."))
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
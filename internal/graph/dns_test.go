@@ -0,0 +1,98 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestResolveDNS_LinksRecordToIP(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	buildTestGraph(t, store, []models.Node{
+		correlationTestNode("tf:dns_record:www", "www.example.com", models.AssetDNSRecord, "terraform", map[string]string{"records": "203.0.113.10"}),
+		correlationTestNode("tf:dns_record:api", "api.example.com", models.AssetDNSRecord, "terraform", map[string]string{"alias_target": "web-lb-123.eu-west-1.elb.amazonaws.com."}),
+		correlationTestNode("tf:ip_address:web", "web-ip", models.AssetIPAddress, "terraform", map[string]string{"public_ip": "203.0.113.10"}),
+		correlationTestNode("tf:load_balancer:web", "web-lb", models.AssetLoadBalancer, "terraform", map[string]string{"dns_name": "WEB-LB-123.eu-west-1.elb.amazonaws.com"}),
+		correlationTestNode("tf:vm:app", "app", models.AssetVM, "terraform", map[string]string{"public_ip": "203.0.113.10"}),
+	}, nil)
+
+	summary, err := ResolveDNS(ctx, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Records != 2 || summary.EdgesAdded != 2 || summary.Lookups != 0 {
+		t.Fatalf("summary = %+v", summary)
+	}
+
+	edges, err := store.ListEdges(ctx, EdgeFilter{Type: string(models.EdgeResolvesTo)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, e := range edges {
+		got[e.FromID] = e.ToID
+	}
+	if got["tf:dns_record:www"] != "tf:ip_address:web" {
+		t.Errorf("www resolves to %q, want the IP node, not the VM sharing its address", got["tf:dns_record:www"])
+	}
+	if got["tf:dns_record:api"] != "tf:load_balancer:web" {
+		t.Errorf("api resolves to %q, want the load balancer", got["tf:dns_record:api"])
+	}
+
+	// Losing the load balancer now takes the record down with it.
+	impact, err := NewLocalEngine(store).BlastRadius(ctx, "tf:load_balancer:web", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := impact.ImpactTree["tf:dns_record:api"]; !ok {
+		t.Errorf("impact of the load balancer should include the record: %+v", impact.ImpactTree)
+	}
+
+	// A second pass adds nothing.
+	again, err := ResolveDNS(ctx, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.EdgesAdded != 0 {
+		t.Errorf("second pass added %d edges", again.EdgesAdded)
+	}
+}
+
+func TestResolveDNS_LiveLookup(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	buildTestGraph(t, store, []models.Node{
+		correlationTestNode("cfn:dns_record:shop", "shop.example.com.", models.AssetDNSRecord, "cloudformation", nil),
+		correlationTestNode("cfn:dns_record:gone", "gone.example.com", models.AssetDNSRecord, "cloudformation", nil),
+		correlationTestNode("aws:ip_address:eip", "eip", models.AssetIPAddress, "aws", map[string]string{"public_ip": "198.51.100.7"}),
+	}, nil)
+
+	summary, err := ResolveDNS(ctx, store, stubResolver{"shop.example.com": {"198.51.100.7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Lookups != 2 || summary.EdgesAdded != 1 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	edges, err := store.ListEdges(ctx, EdgeFilter{Type: string(models.EdgeResolvesTo)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 1 || edges[0].FromID != "cfn:dns_record:shop" || edges[0].Metadata["method"] != "lookup" {
+		t.Errorf("edges = %+v", edges)
+	}
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"errors"
 
 	"github.com/matijazezelj/aib/pkg/models"
 )
@@ -13,15 +14,29 @@ type SPOFNode struct {
 	AffectedByType map[string]int `json:"affected_by_type"`
 }
 
+// ErrNoPath is returned by ShortestPath and ShortestPaths when the two
+// nodes are not connected.
+var ErrNoPath = errors.New("no path found")
+
+// Path is one route between two nodes: its nodes in order and the edge
+// joining each consecutive pair. Paths ignore direction, so an edge may
+// point from a later node back to an earlier one.
+type Path struct {
+	Nodes []models.Node `json:"nodes"`
+	Edges []models.Edge `json:"edges"`
+}
+
 // GraphEngine abstracts graph traversal operations.
 // Implementations may use in-memory BFS (LocalEngine) or
 // a native graph database like Memgraph (MemgraphEngine).
 type GraphEngine interface {
 	// BlastRadius returns a flat map of all nodes affected if startNodeID fails.
-	BlastRadius(ctx context.Context, startNodeID string) (*ImpactResult, error)
+	// opts can limit the traversal to some edge types or a maximum depth, or
+	// change its direction.
+	BlastRadius(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactResult, error)
 
 	// BlastRadiusTree returns the same analysis as a tree rooted at startNodeID.
-	BlastRadiusTree(ctx context.Context, startNodeID string) (*ImpactNode, error)
+	BlastRadiusTree(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactNode, error)
 
 	// Neighbors returns all nodes directly connected to nodeID (both directions).
 	Neighbors(ctx context.Context, nodeID string) ([]models.Node, error)
@@ -29,9 +44,14 @@ type GraphEngine interface {
 	// ShortestPath returns the shortest path between two nodes, if one exists.
 	ShortestPath(ctx context.Context, fromID, toID string) ([]models.Node, []models.Edge, error)
 
+	// ShortestPaths returns up to k distinct loop-free paths between two
+	// nodes, shortest first.
+	ShortestPaths(ctx context.Context, fromID, toID string, k int) ([]Path, error)
+
 	// DependencyChain returns all nodes reachable downstream from nodeID
-	// (what does nodeID depend on, transitively).
-	DependencyChain(ctx context.Context, nodeID string, maxDepth int) ([]models.Node, error)
+	// (what does nodeID depend on, transitively). A non-empty dir follows
+	// edges upstream or both ways instead.
+	DependencyChain(ctx context.Context, nodeID string, maxDepth int, dir Direction) ([]models.Node, error)
 
 	// FindCycles detects circular dependencies in the graph.
 	// Returns a slice of cycles, where each cycle is a slice of node IDs.
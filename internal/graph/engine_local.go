@@ -1,9 +1,13 @@
 package graph
 
 import (
+	"cmp"
+	"container/heap"
 	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/matijazezelj/aib/pkg/models"
 )
@@ -19,13 +23,13 @@ func NewLocalEngine(store *SQLiteStore) *LocalEngine {
 }
 
 // BlastRadius returns a flat map of all nodes affected if startNodeID fails.
-func (e *LocalEngine) BlastRadius(ctx context.Context, startNodeID string) (*ImpactResult, error) {
-	return BlastRadius(ctx, e.store, startNodeID)
+func (e *LocalEngine) BlastRadius(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactResult, error) {
+	return BlastRadius(ctx, e.store, startNodeID, opts)
 }
 
 // BlastRadiusTree returns the impact analysis as a tree rooted at startNodeID.
-func (e *LocalEngine) BlastRadiusTree(ctx context.Context, startNodeID string) (*ImpactNode, error) {
-	return BlastRadiusTree(ctx, e.store, startNodeID)
+func (e *LocalEngine) BlastRadiusTree(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactNode, error) {
+	return BlastRadiusTree(ctx, e.store, startNodeID, opts)
 }
 
 // Neighbors returns all nodes directly connected to nodeID in either direction.
@@ -33,96 +37,249 @@ func (e *LocalEngine) Neighbors(ctx context.Context, nodeID string) ([]models.No
 	return e.store.GetNeighbors(ctx, nodeID)
 }
 
-// ShortestPath finds the shortest path between two nodes using BFS.
+// ShortestPath finds the lowest-weight path between two nodes.
 func (e *LocalEngine) ShortestPath(ctx context.Context, fromID, toID string) ([]models.Node, []models.Edge, error) {
-	downstream, upstream, err := e.store.BuildAdjacency(ctx)
+	paths, err := e.ShortestPaths(ctx, fromID, toID, 1)
 	if err != nil {
 		return nil, nil, err
 	}
+	return paths[0].Nodes, paths[0].Edges, nil
+}
 
-	// BFS using both directions
-	type queueItem struct {
-		nodeID string
-		path   []string
+// ShortestPaths finds up to k loop-free paths between two nodes, ignoring
+// edge direction, using Yen's algorithm with Dijkstra over edge weights
+// (models.Edge.Cost) for each spur search. Paths of equal weight are
+// ordered by their node IDs.
+func (e *LocalEngine) ShortestPaths(ctx context.Context, fromID, toID string, k int) ([]Path, error) {
+	downstream, _, err := e.store.BuildAdjacency(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if k < 1 {
+		k = 1
 	}
 
-	visited := make(map[string]bool)
-	queue := []queueItem{{nodeID: fromID, path: []string{fromID}}}
-	visited[fromID] = true
-
-	// Merge both adjacency maps into a unified neighbor map
-	allNeighbors := make(map[string][]string)
-	for nodeID, edges := range downstream {
-		for _, e := range edges {
-			allNeighbors[nodeID] = append(allNeighbors[nodeID], e.ToID)
+	// Undirected neighbors, plus the edge to report for each step: the
+	// cheapest edge joining the pair, and among equals one pointing the way
+	// the step goes.
+	between := make(map[[2]string]models.Edge)
+	consider := func(step [2]string, edge models.Edge) {
+		cur, ok := between[step]
+		if !ok || edge.Cost() < cur.Cost() ||
+			(edge.Cost() == cur.Cost() && edge.FromID == step[0] && cur.FromID != step[0]) {
+			between[step] = edge
 		}
 	}
-	for nodeID, edges := range upstream {
-		for _, e := range edges {
-			allNeighbors[nodeID] = append(allNeighbors[nodeID], e.FromID)
+	for _, edges := range downstream {
+		for _, edge := range edges {
+			consider([2]string{edge.FromID, edge.ToID}, edge)
+			consider([2]string{edge.ToID, edge.FromID}, edge)
 		}
 	}
+	neighbors := make(map[string][]step)
+	for pair, edge := range between {
+		neighbors[pair[0]] = append(neighbors[pair[0]], step{to: pair[1], cost: edge.Cost()})
+	}
+	for _, steps := range neighbors {
+		slices.SortFunc(steps, func(a, b step) int { return strings.Compare(a.to, b.to) })
+	}
 
-	// Build a reverse lookup: for any pair of neighbors, find the edge between them.
-	allEdgesMap := make(map[string]models.Edge) // "from->to" → edge
-	for _, edgeList := range downstream {
-		for _, edge := range edgeList {
-			allEdgesMap[edge.FromID+"->"+edge.ToID] = edge
-		}
+	found := yenKShortest(neighbors, fromID, toID, k)
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%w between %s and %s", ErrNoPath, fromID, toID)
 	}
-	for _, edgeList := range upstream {
-		for _, edge := range edgeList {
-			allEdgesMap[edge.FromID+"->"+edge.ToID] = edge
+
+	paths := make([]Path, 0, len(found))
+	for _, ids := range found {
+		var p Path
+		for _, nid := range ids {
+			n, _ := e.store.GetNode(ctx, nid)
+			if n != nil {
+				p.Nodes = append(p.Nodes, *n)
+			}
+		}
+		for i := 0; i+1 < len(ids); i++ {
+			if edge, ok := between[[2]string{ids[i], ids[i+1]}]; ok {
+				p.Edges = append(p.Edges, edge)
+			}
 		}
+		paths = append(paths, p)
 	}
+	return paths, nil
+}
 
-	for len(queue) > 0 {
-		current := queue[0]
-		queue = queue[1:]
+// step is a move to a neighboring node and what it costs.
+type step struct {
+	to   string
+	cost float64
+}
 
-		if current.nodeID == toID {
-			// Reconstruct nodes and edges along the path
-			var nodes []models.Node
-			var edges []models.Edge
-			for _, nid := range current.path {
-				n, _ := e.store.GetNode(ctx, nid)
-				if n != nil {
-					nodes = append(nodes, *n)
+// yenKShortest returns up to k loop-free paths from → to, cheapest first.
+func yenKShortest(neighbors map[string][]step, from, to string, k int) [][]string {
+	first, _ := dijkstraPath(neighbors, from, to, nil, nil)
+	if first == nil {
+		return nil
+	}
+	accepted := [][]string{first}
+	type candidate struct {
+		ids  []string
+		cost float64
+	}
+	var candidates []candidate
+	seen := map[string]bool{pathKey(first): true}
+
+	for len(accepted) < k {
+		prev := accepted[len(accepted)-1]
+		for i := 0; i+1 < len(prev); i++ {
+			spur := prev[i]
+			root := prev[:i+1]
+
+			// Block the next step of every accepted path sharing this root,
+			// and the root's nodes before the spur, so the spur path differs.
+			blockedEdges := make(map[[2]string]bool)
+			for _, p := range accepted {
+				if len(p) > i+1 && slices.Equal(p[:i+1], root) {
+					blockedEdges[[2]string{p[i], p[i+1]}] = true
+					blockedEdges[[2]string{p[i+1], p[i]}] = true
 				}
 			}
-			// Reconstruct edges between consecutive path nodes
-			for i := 0; i+1 < len(current.path); i++ {
-				a, b := current.path[i], current.path[i+1]
-				if edge, ok := allEdgesMap[a+"->"+b]; ok {
-					edges = append(edges, edge)
-				} else if edge, ok := allEdgesMap[b+"->"+a]; ok {
-					edges = append(edges, edge)
-				}
+			blockedNodes := make(map[string]bool, i)
+			for _, id := range root[:i] {
+				blockedNodes[id] = true
+			}
+
+			spurPath, spurCost := dijkstraPath(neighbors, spur, to, blockedNodes, blockedEdges)
+			if spurPath == nil {
+				continue
+			}
+			ids := append(append([]string(nil), root[:i]...), spurPath...)
+			if key := pathKey(ids); !seen[key] {
+				seen[key] = true
+				candidates = append(candidates, candidate{ids: ids, cost: pathCost(neighbors, root) + spurCost})
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		slices.SortFunc(candidates, func(a, b candidate) int {
+			if a.cost != b.cost {
+				return cmp.Compare(a.cost, b.cost)
+			}
+			return strings.Compare(pathKey(a.ids), pathKey(b.ids))
+		})
+		accepted = append(accepted, candidates[0].ids)
+		candidates = candidates[1:]
+	}
+	return accepted
+}
+
+// pathCost sums the cost of each step along ids.
+func pathCost(neighbors map[string][]step, ids []string) float64 {
+	var total float64
+	for i := 0; i+1 < len(ids); i++ {
+		for _, s := range neighbors[ids[i]] {
+			if s.to == ids[i+1] {
+				total += s.cost
+				break
 			}
-			return nodes, edges, nil
 		}
+	}
+	return total
+}
 
-		for _, neighbor := range allNeighbors[current.nodeID] {
-			if visited[neighbor] {
+// dijkstraPath returns the cheapest path from → to avoiding the blocked
+// nodes and edges, and its cost, or nil if there is none. Nodes are
+// settled by distance, then ID, and keep the first parent that reached them
+// at their final distance, so ties resolve the same way every run.
+func dijkstraPath(neighbors map[string][]step, from, to string, blockedNodes map[string]bool, blockedEdges map[[2]string]bool) ([]string, float64) {
+	dist := map[string]float64{from: 0}
+	parent := map[string]string{}
+	done := map[string]bool{}
+	pq := &stepQueue{{to: from}}
+	for pq.Len() > 0 {
+		cur := heap.Pop(pq).(step)
+		if done[cur.to] {
+			continue
+		}
+		done[cur.to] = true
+		if cur.to == to {
+			path := []string{to}
+			for id := to; id != from; {
+				id = parent[id]
+				path = append(path, id)
+			}
+			slices.Reverse(path)
+			return path, cur.cost
+		}
+		for _, next := range neighbors[cur.to] {
+			if done[next.to] || blockedNodes[next.to] || blockedEdges[[2]string{cur.to, next.to}] {
 				continue
 			}
-			visited[neighbor] = true
-			newPath := make([]string, len(current.path)+1)
-			copy(newPath, current.path)
-			newPath[len(current.path)] = neighbor
-			queue = append(queue, queueItem{nodeID: neighbor, path: newPath})
+			d := cur.cost + next.cost
+			if old, ok := dist[next.to]; ok && d >= old {
+				continue
+			}
+			dist[next.to] = d
+			parent[next.to] = cur.to
+			heap.Push(pq, step{to: next.to, cost: d})
 		}
 	}
+	return nil, 0
+}
+
+// stepQueue is a min-heap of nodes by distance, then ID.
+type stepQueue []step
 
-	return nil, nil, fmt.Errorf("no path found between %s and %s", fromID, toID)
+func (q stepQueue) Len() int { return len(q) }
+func (q stepQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].to < q[j].to
+}
+func (q stepQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *stepQueue) Push(x any)   { *q = append(*q, x.(step)) }
+func (q *stepQueue) Pop() any {
+	old := *q
+	x := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return x
 }
 
-// DependencyChain returns all downstream dependencies of nodeID up to maxDepth.
-func (e *LocalEngine) DependencyChain(ctx context.Context, nodeID string, maxDepth int) ([]models.Node, error) {
-	downstream, _, err := e.store.BuildAdjacency(ctx)
+func pathKey(ids []string) string {
+	return strings.Join(ids, "\x00")
+}
+
+// pathEdges returns the stored edge joining each consecutive pair of ids,
+// preferring one that points along the path.
+func (e *LocalEngine) pathEdges(ctx context.Context, ids []string) ([]models.Edge, error) {
+	var edges []models.Edge
+	for i := 0; i+1 < len(ids); i++ {
+		found, err := e.store.ListEdges(ctx, EdgeFilter{FromID: ids[i], ToID: ids[i+1]})
+		if err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			if found, err = e.store.ListEdges(ctx, EdgeFilter{FromID: ids[i+1], ToID: ids[i]}); err != nil {
+				return nil, err
+			}
+		}
+		if len(found) > 0 {
+			edges = append(edges, found[0])
+		}
+	}
+	return edges, nil
+}
+
+// DependencyChain returns all downstream dependencies of nodeID up to
+// maxDepth, or the nodes reachable in direction dir if it is set.
+func (e *LocalEngine) DependencyChain(ctx context.Context, nodeID string, maxDepth int, dir Direction) ([]models.Node, error) {
+	downstream, upstream, err := e.store.BuildAdjacency(ctx)
 	if err != nil {
 		return nil, err
 	}
+	adj := &adjacency{downstream: downstream, upstream: upstream}
+	dir = dir.or(DirectionDownstream)
 
 	type queueItem struct {
 		nodeID string
@@ -142,16 +299,16 @@ func (e *LocalEngine) DependencyChain(ctx context.Context, nodeID string, maxDep
 			continue
 		}
 
-		for _, edge := range downstream[current.nodeID] {
-			if visited[edge.ToID] {
+		for _, h := range adj.hops(current.nodeID, dir) {
+			if visited[h.to] {
 				continue
 			}
-			visited[edge.ToID] = true
-			n, _ := e.store.GetNode(ctx, edge.ToID)
+			visited[h.to] = true
+			n, _ := e.store.GetNode(ctx, h.to)
 			if n != nil {
 				result = append(result, *n)
 			}
-			queue = append(queue, queueItem{nodeID: edge.ToID, depth: current.depth + 1})
+			queue = append(queue, queueItem{nodeID: h.to, depth: current.depth + 1})
 		}
 	}
 
@@ -160,12 +317,20 @@ func (e *LocalEngine) DependencyChain(ctx context.Context, nodeID string, maxDep
 
 // FindCycles detects circular dependencies using DFS with a recursion stack.
 func (e *LocalEngine) FindCycles(ctx context.Context) ([][]string, error) {
-	downstream, _, err := e.store.BuildAdjacency(ctx)
+	return FindCycles(ctx, e.store)
+}
+
+// FindCycles detects circular dependencies using DFS with a recursion stack
+// over the stored edges. Each cycle is normalized to start at its smallest
+// ID, and cycles are returned in order, so results are stable across runs.
+func FindCycles(ctx context.Context, store *SQLiteStore) ([][]string, error) {
+	downstream, _, err := store.BuildAdjacency(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// Collect all node IDs that appear in any edge.
+	// Collect all node IDs that appear in any edge, in a fixed order so
+	// the DFS (and so which cycles it reports) doesn't depend on map order.
 	nodeSet := make(map[string]bool)
 	for from, edges := range downstream {
 		nodeSet[from] = true
@@ -173,7 +338,18 @@ func (e *LocalEngine) FindCycles(ctx context.Context) ([][]string, error) {
 			nodeSet[edge.ToID] = true
 		}
 	}
+	nodeIDs := make([]string, 0, len(nodeSet))
+	for id := range nodeSet {
+		nodeIDs = append(nodeIDs, id)
+	}
+	sort.Strings(nodeIDs)
+	return findCycles(nodeIDs, downstream), nil
+}
 
+// findCycles runs the cycle-detecting DFS over downstream, starting from
+// nodeIDs in order. Each cycle is normalized to start at its smallest ID,
+// and the cycles are returned sorted.
+func findCycles(nodeIDs []string, downstream map[string][]models.Edge) [][]string {
 	visited := make(map[string]bool)
 	onStack := make(map[string]bool)
 	var cycles [][]string
@@ -214,13 +390,16 @@ func (e *LocalEngine) FindCycles(ctx context.Context) ([][]string, error) {
 		onStack[nodeID] = false
 	}
 
-	for nodeID := range nodeSet {
+	for _, nodeID := range nodeIDs {
 		if !visited[nodeID] {
 			dfs(nodeID, []string{nodeID})
 		}
 	}
 
-	return cycles, nil
+	sort.Slice(cycles, func(i, j int) bool {
+		return strings.Join(cycles[i], "\x00") < strings.Join(cycles[j], "\x00")
+	})
+	return cycles
 }
 
 // normalizeCycle rotates a cycle so it starts with the smallest ID.
@@ -253,7 +432,7 @@ func (e *LocalEngine) FindSPOF(ctx context.Context, minAffected int) ([]SPOFNode
 	var results []SPOFNode
 	for i := range adj.nodes {
 		n := &adj.nodes[i]
-		result := adj.blastRadius(n.ID)
+		result := adj.blastRadius(n.ID, 0, DirectionUpstream)
 		if result.AffectedNodes >= minAffected {
 			results = append(results, SPOFNode{
 				Node:           n,
@@ -275,7 +454,13 @@ func (e *LocalEngine) FindSPOF(ctx context.Context, minAffected int) ([]SPOFNode
 
 // FindOrphans returns nodes with no edges.
 func (e *LocalEngine) FindOrphans(ctx context.Context) ([]models.Node, error) {
-	return e.store.FindOrphanNodes(ctx)
+	return Orphans(ctx, e.store)
+}
+
+// Orphans returns nodes with no edges in either direction: assets nothing
+// depends on and that depend on nothing, often leftovers worth pruning.
+func Orphans(ctx context.Context, store *SQLiteStore) ([]models.Node, error) {
+	return store.FindOrphanNodes(ctx)
 }
 
 // Close is a no-op for the local engine (no external resources).
@@ -3,6 +3,8 @@ package graph
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/matijazezelj/aib/pkg/models"
@@ -31,7 +33,7 @@ func TestBlastRadius_Linear(t *testing.T) {
 	ctx := context.Background()
 
 	// If C fails, B and A are affected (they depend on C transitively)
-	result, err := engine.BlastRadius(ctx, "C")
+	result, err := engine.BlastRadius(ctx, "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -65,7 +67,7 @@ func TestBlastRadius_Diamond(t *testing.T) {
 	)
 	engine := NewLocalEngine(store)
 
-	result, _ := engine.BlastRadius(context.Background(), "C")
+	result, _ := engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if result.AffectedNodes != 2 {
 		t.Errorf("AffectedNodes = %d, want 2 (A and B)", result.AffectedNodes)
 	}
@@ -76,16 +78,205 @@ func TestBlastRadius_Isolated(t *testing.T) {
 	buildTestGraph(t, store, []models.Node{makeNode("X", models.AssetVM, "tf")}, nil)
 	engine := NewLocalEngine(store)
 
-	result, _ := engine.BlastRadius(context.Background(), "X")
+	result, _ := engine.BlastRadius(context.Background(), "X", ImpactOptions{})
 	if result.AffectedNodes != 0 {
 		t.Errorf("AffectedNodes = %d, want 0", result.AffectedNodes)
 	}
 }
 
+func TestBlastRadius_EdgeTypeFilter(t *testing.T) {
+	store := newTestStore(t)
+	// subnet and vm reach the network over connects_to; the database and
+	// the vm's DNS record depend_on things.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("net", models.AssetNetwork, "tf"),
+			makeNode("subnet", models.AssetSubnet, "tf"),
+			makeNode("vm", models.AssetVM, "tf"),
+			makeNode("dns", models.AssetDNSRecord, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("subnet", "net", models.EdgeConnectsTo),
+			makeEdge("vm", "subnet", models.EdgeConnectsTo),
+			makeEdge("dns", "vm", models.EdgeDependsOn),
+			makeEdge("db", "net", models.EdgeDependsOn),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+
+	all, err := engine.BlastRadius(ctx, "net", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.AffectedNodes != 4 {
+		t.Errorf("unfiltered AffectedNodes = %d, want 4", all.AffectedNodes)
+	}
+
+	result, err := engine.BlastRadius(ctx, "net", ImpactOptions{EdgeTypes: []models.EdgeType{models.EdgeDependsOn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AffectedNodes != 1 {
+		t.Errorf("depends_on AffectedNodes = %d, want 1 (db)", result.AffectedNodes)
+	}
+	if _, ok := result.ImpactTree["db"]; !ok {
+		t.Error("db should be in impact tree")
+	}
+	if _, ok := result.ImpactTree["dns"]; ok {
+		t.Error("dns is only reachable through connects_to and should be excluded")
+	}
+
+	tree, err := engine.BlastRadiusTree(ctx, "net", ImpactOptions{EdgeTypes: []models.EdgeType{models.EdgeDependsOn}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Children) != 1 || tree.Children[0].NodeID != "db" {
+		t.Errorf("depends_on tree children = %+v, want [db]", tree.Children)
+	}
+
+	tree, err = engine.BlastRadiusTree(ctx, "net", ImpactOptions{EdgeTypes: []models.EdgeType{models.EdgeDependsOn, models.EdgeConnectsTo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countImpactNodes(tree) - 1; got != 4 {
+		t.Errorf("both types tree size = %d, want 4", got)
+	}
+}
+
+func TestBlastRadius_MaxDepth(t *testing.T) {
+	store := newTestStore(t)
+	// A -> B -> C -> D, plus a shortcut A -> D: A is one hop from D even
+	// though the long path reaches it at depth three.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("A", models.AssetVM, "tf"),
+			makeNode("B", models.AssetVM, "tf"),
+			makeNode("C", models.AssetVM, "tf"),
+			makeNode("D", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("C", "D", models.EdgeDependsOn),
+			makeEdge("B", "C", models.EdgeDependsOn),
+			makeEdge("A", "B", models.EdgeDependsOn),
+			makeEdge("A", "D", models.EdgeDependsOn),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+
+	result, err := engine.BlastRadius(ctx, "D", ImpactOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AffectedNodes != 2 {
+		t.Errorf("depth 1 AffectedNodes = %d, want 2 (A, C)", result.AffectedNodes)
+	}
+	if _, ok := result.ImpactTree["B"]; ok {
+		t.Error("B is two hops away and should be excluded at depth 1")
+	}
+
+	tree, err := engine.BlastRadiusTree(ctx, "D", ImpactOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var direct []string
+	for _, c := range tree.Children {
+		direct = append(direct, c.NodeID)
+		if len(c.Children) != 0 {
+			t.Errorf("%s has children past depth 1: %+v", c.NodeID, c.Children)
+		}
+	}
+	sort.Strings(direct)
+	if !reflect.DeepEqual(direct, []string{"A", "C"}) {
+		t.Errorf("depth 1 children = %v, want [A C]", direct)
+	}
+
+	tree, err = engine.BlastRadiusTree(ctx, "D", ImpactOptions{MaxDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countImpactNodes(tree) - 1; got != 3 {
+		t.Errorf("depth 2 tree size = %d, want 3", got)
+	}
+
+	unlimited, err := engine.BlastRadius(ctx, "D", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unlimited.AffectedNodes != 3 {
+		t.Errorf("unlimited AffectedNodes = %d, want 3", unlimited.AffectedNodes)
+	}
+}
+
+func TestBlastRadius_Direction(t *testing.T) {
+	store := newTestStore(t)
+	// A -> B <- C, and B -> D: C is neither upstream nor downstream of A.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("A", models.AssetVM, "tf"),
+			makeNode("B", models.AssetNetwork, "tf"),
+			makeNode("C", models.AssetVM, "tf"),
+			makeNode("D", models.AssetSubnet, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("A", "B", models.EdgeConnectsTo),
+			makeEdge("C", "B", models.EdgeConnectsTo),
+			makeEdge("B", "D", models.EdgeConnectsTo),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+
+	tests := []struct {
+		dir  Direction
+		from string
+		want []string
+	}{
+		{"", "B", []string{"A", "C"}},
+		{DirectionUpstream, "B", []string{"A", "C"}},
+		{DirectionDownstream, "B", []string{"D"}},
+		{DirectionBoth, "B", []string{"A", "C", "D"}},
+		{DirectionDownstream, "A", []string{"B", "D"}},
+		{DirectionBoth, "A", []string{"B", "C", "D"}},
+	}
+	for _, tt := range tests {
+		result, err := engine.BlastRadius(ctx, tt.from, ImpactOptions{Direction: tt.dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for id := range result.ImpactTree {
+			got = append(got, id)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("BlastRadius(%s, %q) = %v, want %v", tt.from, tt.dir, got, tt.want)
+		}
+
+		tree, err := engine.BlastRadiusTree(ctx, tt.from, ImpactOptions{Direction: tt.dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := countImpactNodes(tree) - 1; n != len(tt.want) {
+			t.Errorf("BlastRadiusTree(%s, %q) has %d nodes, want %d", tt.from, tt.dir, n, len(tt.want))
+		}
+	}
+}
+
+func countImpactNodes(n *ImpactNode) int {
+	count := 1
+	for i := range n.Children {
+		count += countImpactNodes(&n.Children[i])
+	}
+	return count
+}
+
 func TestBlastRadiusTree_Linear(t *testing.T) {
 	_, engine := buildLinearGraph(t)
 
-	tree, err := engine.BlastRadiusTree(context.Background(), "C")
+	tree, err := engine.BlastRadiusTree(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -123,7 +314,7 @@ func TestBlastRadiusTree_Fan(t *testing.T) {
 	)
 	engine := NewLocalEngine(store)
 
-	tree, _ := engine.BlastRadiusTree(context.Background(), "D")
+	tree, _ := engine.BlastRadiusTree(context.Background(), "D", ImpactOptions{})
 	if len(tree.Children) != 3 {
 		t.Errorf("fan children = %d, want 3", len(tree.Children))
 	}
@@ -182,6 +373,169 @@ func TestShortestPath_TwoHops(t *testing.T) {
 	}
 }
 
+func TestShortestPath_ReturnsEdges(t *testing.T) {
+	_, engine := buildLinearGraph(t)
+
+	// C -> A walks both edges against their direction.
+	nodes, edges, err := engine.ShortestPath(context.Background(), "C", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 3 || len(edges) != 2 {
+		t.Fatalf("nodes = %d, edges = %d, want 3 and 2", len(nodes), len(edges))
+	}
+	if edges[0].FromID != "B" || edges[0].ToID != "C" || edges[1].FromID != "A" || edges[1].ToID != "B" {
+		t.Errorf("edges = %+v, want B->C then A->B", edges)
+	}
+}
+
+func TestShortestPaths_Diamond(t *testing.T) {
+	store := newTestStore(t)
+	// Two routes from app to db of equal length, and a longer third one.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("app", models.AssetService, "tf"),
+			makeNode("lb-a", models.AssetLoadBalancer, "tf"),
+			makeNode("lb-b", models.AssetLoadBalancer, "tf"),
+			makeNode("proxy", models.AssetService, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("app", "lb-a", models.EdgeDependsOn),
+			makeEdge("app", "lb-b", models.EdgeDependsOn),
+			makeEdge("lb-a", "db", models.EdgeRoutesTo),
+			makeEdge("lb-b", "db", models.EdgeConnectsTo),
+			makeEdge("lb-b", "proxy", models.EdgeDependsOn),
+			makeEdge("proxy", "db", models.EdgeConnectsTo),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+
+	ids := func(p Path) []string {
+		var out []string
+		for _, n := range p.Nodes {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+
+	paths, err := engine.ShortestPaths(ctx, "app", "db", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("paths = %d, want 2", len(paths))
+	}
+	if got := ids(paths[0]); !reflect.DeepEqual(got, []string{"app", "lb-a", "db"}) {
+		t.Errorf("path 1 = %v", got)
+	}
+	if got := ids(paths[1]); !reflect.DeepEqual(got, []string{"app", "lb-b", "db"}) {
+		t.Errorf("path 2 = %v", got)
+	}
+	if len(paths[1].Edges) != 2 || paths[1].Edges[1].Type != models.EdgeConnectsTo {
+		t.Errorf("path 2 edges = %+v, want depends_on then connects_to", paths[1].Edges)
+	}
+
+	paths, err = engine.ShortestPaths(ctx, "app", "db", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("all paths = %d, want 3", len(paths))
+	}
+	if got := ids(paths[2]); !reflect.DeepEqual(got, []string{"app", "lb-b", "proxy", "db"}) {
+		t.Errorf("path 3 = %v", got)
+	}
+}
+
+func TestShortestPath_PrefersLowerWeight(t *testing.T) {
+	store := newTestStore(t)
+	// app → db directly over an expensive edge, or via cache and queue over
+	// three default-weight hops.
+	direct := makeEdge("app", "db", models.EdgeDependsOn)
+	direct.Weight = 5
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("app", models.AssetService, "tf"),
+			makeNode("cache", models.AssetService, "tf"),
+			makeNode("queue", models.AssetService, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{
+			direct,
+			makeEdge("app", "cache", models.EdgeDependsOn),
+			makeEdge("cache", "queue", models.EdgeDependsOn),
+			makeEdge("queue", "db", models.EdgeDependsOn),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+
+	stored, err := store.ListEdges(ctx, EdgeFilter{FromID: "app", ToID: "db"})
+	if err != nil || len(stored) != 1 || stored[0].Weight != 5 {
+		t.Fatalf("stored direct edge = %+v (err %v), want weight 5", stored, err)
+	}
+
+	nodes, edges, err := engine.ShortestPath(ctx, "app", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	if want := []string{"app", "cache", "queue", "db"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("path = %v, want %v (weight 3 beats the direct edge's 5)", ids, want)
+	}
+	if len(edges) != 3 {
+		t.Errorf("edges = %d, want 3", len(edges))
+	}
+
+	// Without its weight the direct edge wins again.
+	direct.Weight = 0
+	if err := store.UpsertEdge(ctx, direct); err != nil {
+		t.Fatal(err)
+	}
+	nodes, _, err = engine.ShortestPath(ctx, "app", "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("path has %d nodes, want the 2-node direct path", len(nodes))
+	}
+}
+
+func TestShortestPath_SoftEdgesCostMore(t *testing.T) {
+	store := newTestStore(t)
+	// The connects_to shortcut costs its default weight of 2, more than
+	// the 1.5 of the two-hop route around it.
+	around := makeEdge("b", "c", models.EdgeDependsOn)
+	around.Weight = 0.5
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("a", models.AssetService, "tf"),
+			makeNode("b", models.AssetService, "tf"),
+			makeNode("c", models.AssetService, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("a", "c", models.EdgeConnectsTo),
+			makeEdge("a", "b", models.EdgeDependsOn),
+			around,
+		},
+	)
+	paths, err := NewLocalEngine(store).ShortestPaths(context.Background(), "a", "c", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("paths = %d, want 2", len(paths))
+	}
+	if len(paths[0].Nodes) != 3 || len(paths[1].Nodes) != 2 {
+		t.Errorf("paths = %d then %d nodes, want a→b→c then a→c", len(paths[0].Nodes), len(paths[1].Nodes))
+	}
+}
+
 func TestShortestPath_NoPath(t *testing.T) {
 	store := newTestStore(t)
 	buildTestGraph(t, store,
@@ -202,7 +556,7 @@ func TestShortestPath_NoPath(t *testing.T) {
 func TestDependencyChain_Linear(t *testing.T) {
 	_, engine := buildLinearGraph(t)
 
-	deps, _ := engine.DependencyChain(context.Background(), "A", 10)
+	deps, _ := engine.DependencyChain(context.Background(), "A", 10, "")
 	if len(deps) != 2 {
 		t.Errorf("deps = %d, want 2 (B, C)", len(deps))
 	}
@@ -211,12 +565,58 @@ func TestDependencyChain_Linear(t *testing.T) {
 func TestDependencyChain_MaxDepth(t *testing.T) {
 	_, engine := buildLinearGraph(t)
 
-	deps, _ := engine.DependencyChain(context.Background(), "A", 1)
+	deps, _ := engine.DependencyChain(context.Background(), "A", 1, "")
 	if len(deps) != 1 {
 		t.Errorf("deps with maxDepth=1: got %d, want 1 (B only)", len(deps))
 	}
 }
 
+func TestDependencyChain_Direction(t *testing.T) {
+	_, engine := buildLinearGraph(t)
+	ctx := context.Background()
+
+	ids := func(nodes []models.Node) []string {
+		var out []string
+		for _, n := range nodes {
+			out = append(out, n.ID)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	up, err := engine.DependencyChain(ctx, "C", 10, DirectionUpstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(up); !reflect.DeepEqual(got, []string{"A", "B"}) {
+		t.Errorf("upstream of C = %v, want [A B]", got)
+	}
+
+	both, err := engine.DependencyChain(ctx, "B", 10, DirectionBoth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(both); !reflect.DeepEqual(got, []string{"A", "C"}) {
+		t.Errorf("both ways from B = %v, want [A C]", got)
+	}
+
+	down, _ := engine.DependencyChain(ctx, "B", 10, DirectionDownstream)
+	if got := ids(down); !reflect.DeepEqual(got, []string{"C"}) {
+		t.Errorf("downstream of B = %v, want [C]", got)
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	for _, s := range []string{"upstream", "downstream", "both"} {
+		if d, err := ParseDirection(s); err != nil || string(d) != s {
+			t.Errorf("ParseDirection(%q) = %q, %v", s, d, err)
+		}
+	}
+	if _, err := ParseDirection("sideways"); err == nil {
+		t.Error("expected error for invalid direction")
+	}
+}
+
 func TestDependencyChain_Cycle(t *testing.T) {
 	store := newTestStore(t)
 	buildTestGraph(t, store,
@@ -234,7 +634,7 @@ func TestDependencyChain_Cycle(t *testing.T) {
 	engine := NewLocalEngine(store)
 
 	// Should terminate without infinite loop
-	deps, err := engine.DependencyChain(context.Background(), "A", 10)
+	deps, err := engine.DependencyChain(context.Background(), "A", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -317,6 +717,37 @@ func TestFindCycles_MultipleCycles(t *testing.T) {
 	}
 }
 
+func TestFindCycles_StableOrder(t *testing.T) {
+	store := newTestStore(t)
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("web", models.AssetVM, "tf"),
+			makeNode("api", models.AssetService, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+			makeNode("dns", models.AssetDNSRecord, "tf"),
+			makeNode("lb", models.AssetLoadBalancer, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("web", "lb", models.EdgeDependsOn),
+			makeEdge("lb", "web", models.EdgeDependsOn), // cycle: lb <-> web
+			makeEdge("db", "api", models.EdgeDependsOn),
+			makeEdge("api", "db", models.EdgeDependsOn), // cycle: api <-> db
+			makeEdge("dns", "lb", models.EdgeResolvesTo),
+		},
+	)
+
+	want := [][]string{{"api", "db"}, {"lb", "web"}}
+	for i := 0; i < 5; i++ {
+		cycles, err := FindCycles(context.Background(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cycles, want) {
+			t.Fatalf("run %d: cycles = %v, want %v", i, cycles, want)
+		}
+	}
+}
+
 func TestFindCycles_EmptyGraph(t *testing.T) {
 	store := newTestStore(t)
 	engine := NewLocalEngine(store)
@@ -405,7 +836,7 @@ func TestFindSPOF_EmptyGraph(t *testing.T) {
 func TestBlastRadius_HydratesNodes(t *testing.T) {
 	_, engine := buildLinearGraph(t)
 
-	result, err := engine.BlastRadius(context.Background(), "C")
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -528,7 +959,7 @@ func BenchmarkBlastRadius(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		result, err := engine.BlastRadius(ctx, "node-0000")
+		result, err := engine.BlastRadius(ctx, "node-0000", ImpactOptions{})
 		if err != nil {
 			b.Fatal(err)
 		}
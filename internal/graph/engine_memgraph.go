@@ -3,6 +3,7 @@ package graph
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -49,22 +50,38 @@ func NewMemgraphEngine(uri, username, password string, fallback *LocalEngine, lo
 	}, nil
 }
 
+// IsBackendUnavailable reports whether err means the Memgraph backend could
+// not be reached, as opposed to a query or data error.
+func IsBackendUnavailable(err error) bool {
+	var ce *neo4j.ConnectivityError
+	return errors.As(err, &ce)
+}
+
+// Ping checks that Memgraph is reachable. Queries fall back to the local
+// engine when it is not, but slower.
+func (e *MemgraphEngine) Ping(ctx context.Context) error {
+	return e.driver.VerifyConnectivity(ctx)
+}
+
 // Close closes the Memgraph driver connection.
 func (e *MemgraphEngine) Close() error {
 	return e.driver.Close(context.Background())
 }
 
 // BlastRadius returns all nodes affected if startNodeID fails, using Cypher traversal.
-func (e *MemgraphEngine) BlastRadius(ctx context.Context, startNodeID string) (*ImpactResult, error) {
+func (e *MemgraphEngine) BlastRadius(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactResult, error) {
 	session := e.newSession(ctx)
 	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
 
 	// Find all nodes that transitively point to the start node (upstream traversal).
 	// Edge direction: (from)-[:EDGE]->(to) means "from depends on to".
 	// If startNode fails, affected = all nodes with a path TO startNode.
+	// An empty $edgeTypes follows every edge.
+	in, out := arrows(opts.Direction.or(DirectionUpstream))
 	cypher := `
-		MATCH (affected:Asset)-[*1..]->(root:Asset {id: $startID})
+		MATCH p = (root:Asset {id: $startID})` + in + `[` + hopRange(opts.MaxDepth) + `]` + out + `(affected:Asset)
 		WHERE affected.id <> $startID
+		  AND (size($edgeTypes) = 0 OR all(r IN relationships(p) WHERE r.type IN $edgeTypes))
 		WITH DISTINCT affected
 		RETURN affected.id AS id,
 		       affected.name AS name,
@@ -79,10 +96,10 @@ func (e *MemgraphEngine) BlastRadius(ctx context.Context, startNodeID string) (*
 		ORDER BY type, name
 	`
 
-	result, err := session.Run(ctx, cypher, map[string]any{"startID": startNodeID})
+	result, err := session.Run(ctx, cypher, map[string]any{"startID": startNodeID, "edgeTypes": edgeTypeParams(opts.EdgeTypes)})
 	if err != nil {
 		e.logger.Warn("memgraph blast radius failed, falling back", "error", err)
-		return e.fallback.BlastRadius(ctx, startNodeID)
+		return e.fallback.BlastRadius(ctx, startNodeID, opts)
 	}
 
 	impactTree := make(map[string]ImpactNode)
@@ -96,7 +113,7 @@ func (e *MemgraphEngine) BlastRadius(ctx context.Context, startNodeID string) (*
 
 	if err := result.Err(); err != nil {
 		e.logger.Warn("memgraph result error, falling back", "error", err)
-		return e.fallback.BlastRadius(ctx, startNodeID)
+		return e.fallback.BlastRadius(ctx, startNodeID, opts)
 	}
 
 	affectedByType := make(map[string]int)
@@ -115,7 +132,13 @@ func (e *MemgraphEngine) BlastRadius(ctx context.Context, startNodeID string) (*
 }
 
 // BlastRadiusTree returns the impact analysis as a tree, using Cypher traversal.
-func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string) (*ImpactNode, error) {
+func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string, opts ImpactOptions) (*ImpactNode, error) {
+	// The tree is rebuilt from upstream edges only; other directions are
+	// traversed locally.
+	if opts.Direction.or(DirectionUpstream) != DirectionUpstream {
+		return e.fallback.BlastRadiusTree(ctx, startNodeID, opts)
+	}
+
 	// Fetch the root node and all upstream edges in the affected subgraph,
 	// then reconstruct the tree in Go (same structure as LocalEngine).
 	session := e.newSession(ctx)
@@ -131,7 +154,7 @@ func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string
 	`, map[string]any{"id": startNodeID})
 	if err != nil {
 		e.logger.Warn("memgraph tree root query failed, falling back", "error", err)
-		return e.fallback.BlastRadiusTree(ctx, startNodeID)
+		return e.fallback.BlastRadiusTree(ctx, startNodeID, opts)
 	}
 
 	var rootNode *models.Node
@@ -146,17 +169,18 @@ func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string
 	}
 
 	nodesResult, err := session.Run(ctx, `
-		MATCH (affected:Asset)-[*1..]->(root:Asset {id: $startID})
+		MATCH p = (affected:Asset)-[`+hopRange(opts.MaxDepth)+`]->(root:Asset {id: $startID})
+		WHERE size($edgeTypes) = 0 OR all(r IN relationships(p) WHERE r.type IN $edgeTypes)
 		WITH DISTINCT affected
 		RETURN affected.id AS id, affected.name AS name, affected.type AS type,
 		       affected.source AS source, affected.source_file AS source_file,
 		       affected.provider AS provider, affected.metadata AS metadata,
 		       affected.expires_at AS expires_at, affected.last_seen AS last_seen,
 		       affected.first_seen AS first_seen
-	`, map[string]any{"startID": startNodeID})
+	`, map[string]any{"startID": startNodeID, "edgeTypes": edgeTypeParams(opts.EdgeTypes)})
 	if err != nil {
 		e.logger.Warn("memgraph affected nodes query failed, falling back", "error", err)
-		return e.fallback.BlastRadiusTree(ctx, startNodeID)
+		return e.fallback.BlastRadiusTree(ctx, startNodeID, opts)
 	}
 
 	var affectedIDs []string
@@ -173,11 +197,12 @@ func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string
 	edgeResult, err := session.Run(ctx, `
 		MATCH (a:Asset)-[r:EDGE]->(b:Asset)
 		WHERE a.id IN $ids AND b.id IN $ids
+		  AND (size($edgeTypes) = 0 OR r.type IN $edgeTypes)
 		RETURN a.id AS from_id, r.type AS edge_type, b.id AS to_id
-	`, map[string]any{"ids": allIDs})
+	`, map[string]any{"ids": allIDs, "edgeTypes": edgeTypeParams(opts.EdgeTypes)})
 	if err != nil {
 		e.logger.Warn("memgraph tree edge query failed, falling back", "error", err)
-		return e.fallback.BlastRadiusTree(ctx, startNodeID)
+		return e.fallback.BlastRadiusTree(ctx, startNodeID, opts)
 	}
 
 	// Build upstream adjacency: map[to_id] → list of (from_id, edge_type)
@@ -197,7 +222,7 @@ func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string
 
 	if err := edgeResult.Err(); err != nil {
 		e.logger.Warn("memgraph edge result error, falling back", "error", err)
-		return e.fallback.BlastRadiusTree(ctx, startNodeID)
+		return e.fallback.BlastRadiusTree(ctx, startNodeID, opts)
 	}
 
 	// Build tree using the upstream edges
@@ -207,17 +232,65 @@ func (e *MemgraphEngine) BlastRadiusTree(ctx context.Context, startNodeID string
 		Depth:  0,
 	}
 
+	var depths map[string]int
+	if opts.MaxDepth > 0 {
+		depths = shortestDepths(startNodeID, opts.MaxDepth, func(id string) []string {
+			var from []string
+			for _, ei := range upstream[id] {
+				from = append(from, ei.fromID)
+			}
+			return from
+		})
+	}
+
 	visited := map[string]bool{startNodeID: true}
-	buildMgTree(root, upstream, nodeMap, visited, 0)
+	buildMgTree(root, upstream, nodeMap, visited, 0, depths)
 
 	return root, nil
 }
 
-func buildMgTree(parent *ImpactNode, upstream map[string][]mgEdgeInfo, nodeMap map[string]*models.Node, visited map[string]bool, depth int) {
+// hopRange returns the variable-length relationship pattern for a
+// traversal of at most maxDepth hops (unbounded if maxDepth <= 0).
+func hopRange(maxDepth int) string {
+	if maxDepth > 0 {
+		return fmt.Sprintf("*1..%d", maxDepth)
+	}
+	return "*1.."
+}
+
+// arrows returns the two ends of a relationship pattern written from the
+// start node, so that it is traversed in direction dir.
+func arrows(dir Direction) (string, string) {
+	switch dir {
+	case DirectionUpstream:
+		return "<-", "-"
+	case DirectionBoth:
+		return "-", "-"
+	}
+	return "-", "->"
+}
+
+// edgeTypeParams converts edge types to a Cypher list parameter. It is
+// never nil, so size($edgeTypes) = 0 means "all types".
+func edgeTypeParams(edgeTypes []models.EdgeType) []string {
+	out := make([]string, 0, len(edgeTypes))
+	for _, t := range edgeTypes {
+		out = append(out, string(t))
+	}
+	return out
+}
+
+// buildMgTree mirrors adjacency.buildTree for edges fetched from Memgraph.
+func buildMgTree(parent *ImpactNode, upstream map[string][]mgEdgeInfo, nodeMap map[string]*models.Node, visited map[string]bool, depth int, depths map[string]int) {
 	for _, ei := range upstream[parent.NodeID] {
 		if visited[ei.fromID] {
 			continue
 		}
+		if depths != nil {
+			if d, ok := depths[ei.fromID]; !ok || d != depth+1 {
+				continue
+			}
+		}
 		visited[ei.fromID] = true
 
 		child := ImpactNode{
@@ -226,7 +299,7 @@ func buildMgTree(parent *ImpactNode, upstream map[string][]mgEdgeInfo, nodeMap m
 			EdgeType: ei.edgeType,
 			Depth:    depth + 1,
 		}
-		buildMgTree(&child, upstream, nodeMap, visited, depth+1)
+		buildMgTree(&child, upstream, nodeMap, visited, depth+1, depths)
 		parent.Children = append(parent.Children, child)
 	}
 }
@@ -272,13 +345,15 @@ func (e *MemgraphEngine) Neighbors(ctx context.Context, nodeID string) ([]models
 	return nodes, nil
 }
 
-// ShortestPath finds the shortest path between two nodes using Cypher shortestPath.
+// ShortestPath finds the lowest-weight path between two nodes using
+// Memgraph's weighted shortest path. Edges synced before weights existed
+// count as 1.
 func (e *MemgraphEngine) ShortestPath(ctx context.Context, fromID, toID string) ([]models.Node, []models.Edge, error) {
 	session := e.newSession(ctx)
 	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
 
 	cypher := `
-		MATCH p = shortestPath((a:Asset {id: $fromID})-[*]-(b:Asset {id: $toID}))
+		MATCH p = (a:Asset {id: $fromID})-[*WSHORTEST (r, n | coalesce(r.weight, 1))]-(b:Asset {id: $toID})
 		UNWIND nodes(p) AS n
 		RETURN n.id AS id, n.name AS name, n.type AS type,
 		       n.source AS source, n.source_file AS source_file,
@@ -305,14 +380,37 @@ func (e *MemgraphEngine) ShortestPath(ctx context.Context, fromID, toID string)
 	}
 
 	if len(nodes) == 0 {
-		return nil, nil, fmt.Errorf("no path found between %s and %s", fromID, toID)
+		return nil, nil, fmt.Errorf("%w between %s and %s", ErrNoPath, fromID, toID)
 	}
 
-	return nodes, nil, nil
+	// Memgraph mirrors the store, so the path's edges are read from there.
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	edges, err := e.fallback.pathEdges(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, edges, nil
+}
+
+// ShortestPaths returns up to k paths between two nodes. A single path
+// uses Cypher; k > 1 runs Yen's algorithm locally.
+func (e *MemgraphEngine) ShortestPaths(ctx context.Context, fromID, toID string, k int) ([]Path, error) {
+	if k > 1 {
+		return e.fallback.ShortestPaths(ctx, fromID, toID, k)
+	}
+	nodes, edges, err := e.ShortestPath(ctx, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	return []Path{{Nodes: nodes, Edges: edges}}, nil
 }
 
-// DependencyChain returns all downstream dependencies up to maxDepth using Cypher.
-func (e *MemgraphEngine) DependencyChain(ctx context.Context, nodeID string, maxDepth int) ([]models.Node, error) {
+// DependencyChain returns all downstream dependencies up to maxDepth using
+// Cypher, or the nodes reachable in direction dir if it is set.
+func (e *MemgraphEngine) DependencyChain(ctx context.Context, nodeID string, maxDepth int, dir Direction) ([]models.Node, error) {
 	if maxDepth <= 0 || maxDepth > 50 {
 		maxDepth = 50
 	}
@@ -320,20 +418,22 @@ func (e *MemgraphEngine) DependencyChain(ctx context.Context, nodeID string, max
 	session := e.newSession(ctx)
 	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
 
+	in, out := arrows(dir.or(DirectionDownstream))
 	cypher := fmt.Sprintf(`
-		MATCH (start:Asset {id: $id})-[*1..%d]->(dep:Asset)
+		MATCH (start:Asset {id: $id})%s[*1..%d]%s(dep:Asset)
+		WHERE dep.id <> $id
 		RETURN DISTINCT dep.id AS id, dep.name AS name, dep.type AS type,
 		       dep.source AS source, dep.source_file AS source_file,
 		       dep.provider AS provider, dep.metadata AS metadata,
 		       dep.expires_at AS expires_at, dep.last_seen AS last_seen,
 		       dep.first_seen AS first_seen
 		ORDER BY type, name
-	`, maxDepth)
+	`, in, maxDepth, out)
 
 	result, err := session.Run(ctx, cypher, map[string]any{"id": nodeID})
 	if err != nil {
 		e.logger.Warn("memgraph dependency chain failed, falling back", "error", err)
-		return e.fallback.DependencyChain(ctx, nodeID, maxDepth)
+		return e.fallback.DependencyChain(ctx, nodeID, maxDepth, dir)
 	}
 
 	var nodes []models.Node
@@ -344,7 +444,7 @@ func (e *MemgraphEngine) DependencyChain(ctx context.Context, nodeID string, max
 
 	if err := result.Err(); err != nil {
 		e.logger.Warn("memgraph dependency chain result error, falling back", "error", err)
-		return e.fallback.DependencyChain(ctx, nodeID, maxDepth)
+		return e.fallback.DependencyChain(ctx, nodeID, maxDepth, dir)
 	}
 
 	return nodes, nil
@@ -447,14 +547,19 @@ func (e *MemgraphEngine) FindSPOF(ctx context.Context, minAffected int) ([]SPOFN
 	return spofs, nil
 }
 
-// FindOrphans returns nodes with no edges using Cypher.
+// FindOrphans returns nodes with no edges using Cypher. Like the SQLite
+// query, it ignores provider and region nodes and the edges to them.
 func (e *MemgraphEngine) FindOrphans(ctx context.Context) ([]models.Node, error) {
 	session := e.newSession(ctx)
 	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
 
 	cypher := `
 		MATCH (n:Asset)
-		WHERE NOT (n)-[]-()
+		WHERE NOT n.type IN ['provider', 'region']
+		OPTIONAL MATCH (n)-[]-(m:Asset)
+		WHERE NOT m.type IN ['provider', 'region']
+		WITH n, count(m) AS links
+		WHERE links = 0
 		RETURN n.id AS id, n.name AS name, n.type AS type,
 		       n.source AS source, n.source_file AS source_file,
 		       n.provider AS provider, n.metadata AS metadata,
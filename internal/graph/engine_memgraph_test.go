@@ -2,6 +2,7 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -150,7 +151,7 @@ func TestBuildMgTree_NoUpstream(t *testing.T) {
 	nodeMap := map[string]*models.Node{}
 	visited := map[string]bool{"root": true}
 
-	buildMgTree(parent, upstream, nodeMap, visited, 0)
+	buildMgTree(parent, upstream, nodeMap, visited, 0, nil)
 	if len(parent.Children) != 0 {
 		t.Errorf("expected 0 children, got %d", len(parent.Children))
 	}
@@ -166,7 +167,7 @@ func TestBuildMgTree_SingleChild(t *testing.T) {
 	}
 	visited := map[string]bool{"root": true}
 
-	buildMgTree(parent, upstream, nodeMap, visited, 0)
+	buildMgTree(parent, upstream, nodeMap, visited, 0, nil)
 	if len(parent.Children) != 1 {
 		t.Fatalf("expected 1 child, got %d", len(parent.Children))
 	}
@@ -189,7 +190,7 @@ func TestBuildMgTree_CycleProtection(t *testing.T) {
 	}
 	visited := map[string]bool{"A": true}
 
-	buildMgTree(parent, upstream, nodeMap, visited, 0)
+	buildMgTree(parent, upstream, nodeMap, visited, 0, nil)
 	if len(parent.Children) != 1 {
 		t.Fatalf("expected 1 child, got %d", len(parent.Children))
 	}
@@ -242,7 +243,7 @@ func TestMemgraph_BlastRadius_Success(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	result, err := engine.BlastRadius(context.Background(), "C")
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -265,7 +266,7 @@ func TestMemgraph_BlastRadius_Fallback(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	result, err := engine.BlastRadius(context.Background(), "C")
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -283,7 +284,7 @@ func TestMemgraph_BlastRadius_ResultError(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	result, err := engine.BlastRadius(context.Background(), "C")
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -293,6 +294,74 @@ func TestMemgraph_BlastRadius_ResultError(t *testing.T) {
 	}
 }
 
+func TestMemgraph_BlastRadius_EdgeTypes(t *testing.T) {
+	var params map[string]any
+	sess := &mockSession{
+		runFunc: func(_ string, p map[string]any) (resultIterator, error) {
+			params = p
+			return nil, fmt.Errorf("memgraph down")
+		},
+	}
+	engine, _ := newTestMemgraphEngine(t, sess)
+
+	// The fallback graph is linked by depends_on only.
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{EdgeTypes: []models.EdgeType{models.EdgeConnectsTo}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := params["edgeTypes"].([]string); !ok || len(got) != 1 || got[0] != "connects_to" {
+		t.Errorf("edgeTypes param = %v, want [connects_to]", params["edgeTypes"])
+	}
+	if result.AffectedNodes != 0 {
+		t.Errorf("AffectedNodes (filtered fallback) = %d, want 0", result.AffectedNodes)
+	}
+}
+
+func TestMemgraph_BlastRadius_MaxDepth(t *testing.T) {
+	var cypher string
+	sess := &mockSession{
+		runFunc: func(c string, _ map[string]any) (resultIterator, error) {
+			cypher = c
+			return &mockResult{records: []*neo4j.Record{makeNodeRecord("B", "B", "network", "tf")}}, nil
+		},
+	}
+	engine, _ := newTestMemgraphEngine(t, sess)
+
+	result, err := engine.BlastRadius(context.Background(), "C", ImpactOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(cypher, "[*1..1]") {
+		t.Errorf("expected bounded traversal, got: %s", cypher)
+	}
+	if result.AffectedNodes != 1 {
+		t.Errorf("AffectedNodes = %d, want 1", result.AffectedNodes)
+	}
+}
+
+func TestBuildMgTree_MaxDepth(t *testing.T) {
+	root := &ImpactNode{NodeID: "C"}
+	upstream := map[string][]mgEdgeInfo{
+		"C": {{fromID: "B", edgeType: models.EdgeDependsOn}},
+		"B": {{fromID: "A", edgeType: models.EdgeDependsOn}},
+	}
+	depths := shortestDepths("C", 1, func(id string) []string {
+		var from []string
+		for _, ei := range upstream[id] {
+			from = append(from, ei.fromID)
+		}
+		return from
+	})
+
+	buildMgTree(root, upstream, map[string]*models.Node{}, map[string]bool{"C": true}, 0, depths)
+	if len(root.Children) != 1 || root.Children[0].NodeID != "B" {
+		t.Fatalf("children = %+v, want [B]", root.Children)
+	}
+	if len(root.Children[0].Children) != 0 {
+		t.Errorf("A is past depth 1 and should be excluded")
+	}
+}
+
 func TestMemgraph_BlastRadiusTree_Success(t *testing.T) {
 	callCount := 0
 	sess := &mockSession{
@@ -326,7 +395,7 @@ func TestMemgraph_BlastRadiusTree_Success(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	tree, err := engine.BlastRadiusTree(context.Background(), "C")
+	tree, err := engine.BlastRadiusTree(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -349,7 +418,7 @@ func TestMemgraph_BlastRadiusTree_Fallback(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	tree, err := engine.BlastRadiusTree(context.Background(), "C")
+	tree, err := engine.BlastRadiusTree(context.Background(), "C", ImpactOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -429,13 +498,50 @@ func TestMemgraph_ShortestPath_Success(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	nodes, _, err := engine.ShortestPath(context.Background(), "A", "C")
+	nodes, edges, err := engine.ShortestPath(context.Background(), "A", "C")
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(nodes) != 3 {
 		t.Errorf("path length = %d, want 3", len(nodes))
 	}
+	if len(edges) != 2 || edges[0].FromID != "A" || edges[1].ToID != "C" {
+		t.Errorf("edges = %+v, want A->B, B->C", edges)
+	}
+}
+
+func TestMemgraph_ShortestPaths_Diamond(t *testing.T) {
+	sess := &mockSession{
+		runFunc: func(_ string, _ map[string]any) (resultIterator, error) {
+			t.Error("k > 1 should not query memgraph")
+			return nil, fmt.Errorf("unexpected query")
+		},
+	}
+	engine, local := newTestMemgraphEngine(t, sess)
+	// Add D so A->D->C parallels A->B->C.
+	buildTestGraph(t, local.store,
+		[]models.Node{makeNode("D", models.AssetNetwork, "tf")},
+		[]models.Edge{
+			makeEdge("A", "D", models.EdgeConnectsTo),
+			makeEdge("D", "C", models.EdgeConnectsTo),
+		},
+	)
+
+	paths, err := engine.ShortestPaths(context.Background(), "A", "C", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("paths = %d, want 2", len(paths))
+	}
+	for i, p := range paths {
+		if len(p.Nodes) != 3 || len(p.Edges) != 2 {
+			t.Errorf("path %d: nodes = %d, edges = %d, want 3 and 2", i+1, len(p.Nodes), len(p.Edges))
+		}
+	}
+	if paths[1].Nodes[1].ID != "D" || paths[1].Edges[0].Type != models.EdgeConnectsTo {
+		t.Errorf("path 2 = %+v, want via D over connects_to", paths[1])
+	}
 }
 
 func TestMemgraph_ShortestPath_NoPath(t *testing.T) {
@@ -485,7 +591,7 @@ func TestMemgraph_DependencyChain_Success(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	deps, err := engine.DependencyChain(context.Background(), "A", 10)
+	deps, err := engine.DependencyChain(context.Background(), "A", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -502,7 +608,7 @@ func TestMemgraph_DependencyChain_Fallback(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	deps, err := engine.DependencyChain(context.Background(), "A", 10)
+	deps, err := engine.DependencyChain(context.Background(), "A", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -519,7 +625,7 @@ func TestMemgraph_DependencyChain_ResultError(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	deps, err := engine.DependencyChain(context.Background(), "A", 10)
+	deps, err := engine.DependencyChain(context.Background(), "A", 10, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -539,19 +645,19 @@ func TestMemgraph_DependencyChain_MaxDepthDefault(t *testing.T) {
 	engine, _ := newTestMemgraphEngine(t, sess)
 
 	// maxDepth=0 should default to 50
-	_, _ = engine.DependencyChain(context.Background(), "A", 0)
+	_, _ = engine.DependencyChain(context.Background(), "A", 0, "")
 	if !strings.Contains(capturedCypher, "50") {
 		t.Errorf("cypher should contain maxDepth 50 for default, got: %s", capturedCypher)
 	}
 
 	// maxDepth=-1 should default to 50
-	_, _ = engine.DependencyChain(context.Background(), "A", -1)
+	_, _ = engine.DependencyChain(context.Background(), "A", -1, "")
 	if !strings.Contains(capturedCypher, "50") {
 		t.Errorf("cypher should contain maxDepth 50 for negative, got: %s", capturedCypher)
 	}
 
 	// maxDepth=999 should default to 50
-	_, _ = engine.DependencyChain(context.Background(), "A", 999)
+	_, _ = engine.DependencyChain(context.Background(), "A", 999, "")
 	if !strings.Contains(capturedCypher, "50") {
 		t.Errorf("cypher should contain maxDepth 50 for >50, got: %s", capturedCypher)
 	}
@@ -565,7 +671,7 @@ func TestMemgraph_SessionClosed(t *testing.T) {
 	}
 	engine, _ := newTestMemgraphEngine(t, sess)
 
-	_, _ = engine.BlastRadius(context.Background(), "C")
+	_, _ = engine.BlastRadius(context.Background(), "C", ImpactOptions{})
 	if !sess.closed {
 		t.Error("session should be closed after BlastRadius")
 	}
@@ -766,3 +872,86 @@ func TestMemgraph_FindOrphans_ResultError(t *testing.T) {
 		t.Errorf("orphans (result error fallback) = %d, want 1", len(orphans))
 	}
 }
+
+func TestIsBackendUnavailable(t *testing.T) {
+	connErr := fmt.Errorf("running query: %w", &neo4j.ConnectivityError{})
+	if !IsBackendUnavailable(connErr) {
+		t.Error("wrapped ConnectivityError should be reported as unavailable")
+	}
+	if IsBackendUnavailable(errors.New("syntax error")) {
+		t.Error("query errors should not be reported as unavailable")
+	}
+}
+
+func TestMemgraph_DependencyChain_Direction(t *testing.T) {
+	var capturedCypher string
+	sess := &mockSession{
+		runFunc: func(cypher string, _ map[string]any) (resultIterator, error) {
+			capturedCypher = cypher
+			return &mockResult{}, nil
+		},
+	}
+	engine, _ := newTestMemgraphEngine(t, sess)
+	ctx := context.Background()
+
+	tests := []struct {
+		dir  Direction
+		want string
+	}{
+		{"", "(start:Asset {id: $id})-[*1..5]->(dep:Asset)"},
+		{DirectionUpstream, "(start:Asset {id: $id})<-[*1..5]-(dep:Asset)"},
+		{DirectionBoth, "(start:Asset {id: $id})-[*1..5]-(dep:Asset)"},
+	}
+	for _, tt := range tests {
+		_, _ = engine.DependencyChain(ctx, "A", 5, tt.dir)
+		if !strings.Contains(capturedCypher, tt.want) {
+			t.Errorf("direction %q: cypher should contain %s, got: %s", tt.dir, tt.want, capturedCypher)
+		}
+	}
+}
+
+func TestMemgraph_BlastRadius_Both(t *testing.T) {
+	var capturedCypher string
+	sess := &mockSession{
+		runFunc: func(cypher string, _ map[string]any) (resultIterator, error) {
+			capturedCypher = cypher
+			return &mockResult{
+				records: []*neo4j.Record{
+					makeNodeRecord("A", "A", "vm", "tf"),
+					makeNodeRecord("C", "C", "subnet", "tf"),
+				},
+			}, nil
+		},
+	}
+	engine, _ := newTestMemgraphEngine(t, sess)
+
+	result, err := engine.BlastRadius(context.Background(), "B", ImpactOptions{Direction: DirectionBoth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(capturedCypher, "(root:Asset {id: $startID})-[*1..]-(affected:Asset)") {
+		t.Errorf("cypher should traverse both ways, got: %s", capturedCypher)
+	}
+	if result.AffectedNodes != 2 {
+		t.Errorf("affected = %d, want 2", result.AffectedNodes)
+	}
+}
+
+func TestMemgraph_BlastRadiusTree_BothUsesFallback(t *testing.T) {
+	sess := &mockSession{
+		runFunc: func(_ string, _ map[string]any) (resultIterator, error) {
+			t.Error("a non-upstream tree should not query memgraph")
+			return nil, fmt.Errorf("unexpected query")
+		},
+	}
+	engine, _ := newTestMemgraphEngine(t, sess)
+
+	// A -> B -> C: both ways from B reaches A and C.
+	tree, err := engine.BlastRadiusTree(context.Background(), "B", ImpactOptions{Direction: DirectionBoth})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countImpactNodes(tree) - 1; n != 2 {
+		t.Errorf("tree nodes = %d, want 2", n)
+	}
+}
@@ -3,7 +3,9 @@ package graph
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/matijazezelj/aib/pkg/models"
@@ -11,19 +13,126 @@ import (
 
 // GraphData holds a full graph snapshot for export.
 type GraphData struct {
-	Nodes []models.Node `json:"nodes"`
-	Edges []models.Edge `json:"edges"`
+	Nodes  []models.Node     `json:"nodes"`
+	Edges  []models.Edge     `json:"edges"`
+	Labels map[string]string `json:"labels,omitempty"` // node ID to label, with ExportOptions.Label
 }
 
-// ExportJSON returns the graph as a JSON string.
-func ExportJSON(ctx context.Context, store Store) (string, error) {
-	nodes, err := store.ListNodes(ctx, NodeFilter{})
+// ExportOptions narrows an export to a subgraph: the nodes matching Filter
+// (and NodeIDs, if set) and the edges between them. The zero value exports
+// the whole graph.
+type ExportOptions struct {
+	Filter  NodeFilter
+	NodeIDs []string // if set, only these nodes, e.g. a blast radius from SubgraphIDs
+
+	// Color adds a legend of the asset type palette to DOT and Mermaid
+	// exports (Mermaid nodes are only colored with it set) and draws the
+	// nodes Warn flags, such as expiring certificates, in red.
+	Color bool
+	Warn  NodeWarner
+
+	// Label picks the node label of diagram exports: "name" (the
+	// default), "id", another node field, or metadata.<key>. Nodes where
+	// it is empty fall back to their name. The asset type is always shown
+	// as a sublabel.
+	Label string
+}
+
+// label returns the label of n selected by opts.Label.
+func (opts ExportOptions) label(n models.Node) string {
+	if opts.Label != "" {
+		if v := nodeField(n, opts.Label); v != "" {
+			return v
+		}
+	}
+	if n.Name == "" {
+		return n.ID
+	}
+	return n.Name
+}
+
+// Colors of nodes flagged by ExportOptions.Warn.
+const (
+	warnFillColor   = "#FF6B6B"
+	warnStrokeColor = "#C0392B"
+)
+
+// warned reports whether opts.Color is set and opts.Warn flags n.
+func (opts ExportOptions) warned(n *models.Node) bool {
+	return opts.Color && opts.Warn != nil && opts.Warn(n) != ""
+}
+
+// legendTypes returns the distinct asset types of nodes, sorted, and
+// whether any node is flagged by opts.Warn.
+func legendTypes(nodes []models.Node, opts ExportOptions) ([]models.AssetType, bool) {
+	var types []models.AssetType
+	warned := false
+	for i := range nodes {
+		if !slices.Contains(types, nodes[i].Type) {
+			types = append(types, nodes[i].Type)
+		}
+		warned = warned || opts.warned(&nodes[i])
+	}
+	slices.Sort(types)
+	return types, warned
+}
+
+// subgraph loads the nodes and edges selected by opts.
+func subgraph(ctx context.Context, store Store, opts ExportOptions) ([]models.Node, []models.Edge, error) {
+	if opts.Label != "" && !validNodeKey(opts.Label) {
+		return nil, nil, fmt.Errorf("invalid label %q (valid: %s, metadata.<key>)", opts.Label, strings.Join(filterFieldNames(), ", "))
+	}
+	nodes, err := store.ListNodes(ctx, opts.Filter)
 	if err != nil {
-		return "", fmt.Errorf("listing nodes: %w", err)
+		return nil, nil, fmt.Errorf("listing nodes: %w", err)
 	}
 	edges, err := store.ListEdges(ctx, EdgeFilter{})
 	if err != nil {
-		return "", fmt.Errorf("listing edges: %w", err)
+		return nil, nil, fmt.Errorf("listing edges: %w", err)
+	}
+	if opts.NodeIDs != nil {
+		wanted := make(map[string]bool, len(opts.NodeIDs))
+		for _, id := range opts.NodeIDs {
+			wanted[id] = true
+		}
+		kept := nodes[:0]
+		for _, n := range nodes {
+			if wanted[n.ID] {
+				kept = append(kept, n)
+			}
+		}
+		nodes = kept
+	}
+
+	// Drop edges leaving the subgraph so no export has dangling endpoints.
+	included := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		included[n.ID] = true
+	}
+	var kept []models.Edge
+	for _, e := range edges {
+		if included[e.FromID] && included[e.ToID] {
+			kept = append(kept, e)
+		}
+	}
+	return nodes, kept, nil
+}
+
+// SubgraphIDs returns the IDs of every node in an impact tree, root
+// included, for use as ExportOptions.NodeIDs.
+func SubgraphIDs(tree *ImpactNode) []string {
+	ids := []string{tree.NodeID}
+	for i := range tree.Children {
+		ids = append(ids, SubgraphIDs(&tree.Children[i])...)
+	}
+	return ids
+}
+
+// ExportJSON returns the graph as a JSON string.
+func ExportJSON(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
+	if err != nil {
+		return "", err
 	}
 
 	data := GraphData{Nodes: nodes, Edges: edges}
@@ -33,6 +142,12 @@ func ExportJSON(ctx context.Context, store Store) (string, error) {
 	if data.Edges == nil {
 		data.Edges = []models.Edge{}
 	}
+	if opts.Label != "" {
+		data.Labels = make(map[string]string, len(nodes))
+		for _, n := range nodes {
+			data.Labels[n.ID] = opts.label(n)
+		}
+	}
 
 	b, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -42,14 +157,10 @@ func ExportJSON(ctx context.Context, store Store) (string, error) {
 }
 
 // ExportDOT returns the graph in Graphviz DOT format.
-func ExportDOT(ctx context.Context, store Store) (string, error) {
-	nodes, err := store.ListNodes(ctx, NodeFilter{})
+func ExportDOT(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
 	if err != nil {
-		return "", fmt.Errorf("listing nodes: %w", err)
-	}
-	edges, err := store.ListEdges(ctx, EdgeFilter{})
-	if err != nil {
-		return "", fmt.Errorf("listing edges: %w", err)
+		return "", err
 	}
 
 	var b strings.Builder
@@ -59,7 +170,11 @@ func ExportDOT(ctx context.Context, store Store) (string, error) {
 
 	for _, n := range nodes {
 		color := nodeColor(n.Type)
-		label := fmt.Sprintf("%s\\n(%s)", n.Name, n.Type)
+		label := fmt.Sprintf("%s\\n(%s)", opts.label(n), n.Type)
+		if opts.warned(&n) {
+			fmt.Fprintf(&b, "  %q [label=%q, fillcolor=%q, color=%q, penwidth=2];\n", n.ID, label, warnFillColor, warnStrokeColor)
+			continue
+		}
 		fmt.Fprintf(&b, "  %q [label=%q, fillcolor=%q];\n", n.ID, label, color)
 	}
 
@@ -69,19 +184,29 @@ func ExportDOT(ctx context.Context, store Store) (string, error) {
 		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", e.FromID, e.ToID, e.Type)
 	}
 
+	if opts.Color {
+		types, warned := legendTypes(nodes, opts)
+		b.WriteString("\n  subgraph cluster_legend {\n")
+		b.WriteString("    label=\"Legend\";\n")
+		b.WriteString("    style=dashed;\n")
+		for _, t := range types {
+			fmt.Fprintf(&b, "    %q [label=%q, fillcolor=%q];\n", "legend:"+string(t), string(t), nodeColor(t))
+		}
+		if warned {
+			fmt.Fprintf(&b, "    %q [label=%q, fillcolor=%q, color=%q, penwidth=2];\n", "legend:expiring", "expiring", warnFillColor, warnStrokeColor)
+		}
+		b.WriteString("  }\n")
+	}
+
 	b.WriteString("}\n")
 	return b.String(), nil
 }
 
 // ExportMermaid returns the graph in Mermaid format.
-func ExportMermaid(ctx context.Context, store Store) (string, error) {
-	nodes, err := store.ListNodes(ctx, NodeFilter{})
-	if err != nil {
-		return "", fmt.Errorf("listing nodes: %w", err)
-	}
-	edges, err := store.ListEdges(ctx, EdgeFilter{})
+func ExportMermaid(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
 	if err != nil {
-		return "", fmt.Errorf("listing edges: %w", err)
+		return "", err
 	}
 
 	var b strings.Builder
@@ -89,7 +214,8 @@ func ExportMermaid(ctx context.Context, store Store) (string, error) {
 
 	for _, n := range nodes {
 		safeID := mermaidSafeID(n.ID)
-		fmt.Fprintf(&b, "  %s[\"%s (%s)\"]\n", safeID, n.Name, n.Type)
+		label := strings.ReplaceAll(opts.label(n), `"`, "#quot;")
+		fmt.Fprintf(&b, "  %s[\"%s (%s)\"]\n", safeID, label, n.Type)
 	}
 
 	for _, e := range edges {
@@ -98,9 +224,499 @@ func ExportMermaid(ctx context.Context, store Store) (string, error) {
 		fmt.Fprintf(&b, "  %s -->|%s| %s\n", fromID, e.Type, toID)
 	}
 
+	if opts.Color {
+		types, warned := legendTypes(nodes, opts)
+		b.WriteString("  subgraph legend[\"Legend\"]\n")
+		for _, t := range types {
+			fmt.Fprintf(&b, "    legend_%s[\"%s\"]:::%s\n", mermaidSafeID(string(t)), t, mermaidClass(t))
+		}
+		if warned {
+			b.WriteString("    legend_expiring[\"expiring\"]:::expiring\n")
+		}
+		b.WriteString("  end\n")
+
+		for _, t := range types {
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", mermaidClass(t), nodeColor(t))
+		}
+		fmt.Fprintf(&b, "  classDef expiring fill:%s,stroke:%s,stroke-width:2px\n", warnFillColor, warnStrokeColor)
+		for _, n := range nodes {
+			class := mermaidClass(n.Type)
+			if opts.warned(&n) {
+				class = "expiring"
+			}
+			fmt.Fprintf(&b, "  class %s %s\n", mermaidSafeID(n.ID), class)
+		}
+	}
+
 	return b.String(), nil
 }
 
+// arrowsDocument is the JSON document format read and written by arrows.app.
+type arrowsDocument struct {
+	Nodes         []arrowsNode         `json:"nodes"`
+	Relationships []arrowsRelationship `json:"relationships"`
+	Style         map[string]any       `json:"style"`
+}
+
+type arrowsNode struct {
+	ID         string            `json:"id"`
+	Position   arrowsPosition    `json:"position"`
+	Caption    string            `json:"caption"`
+	Labels     []string          `json:"labels"`
+	Properties map[string]string `json:"properties"`
+	Style      map[string]any    `json:"style"`
+}
+
+type arrowsPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type arrowsRelationship struct {
+	ID         string            `json:"id"`
+	FromID     string            `json:"fromId"`
+	ToID       string            `json:"toId"`
+	Type       string            `json:"type"`
+	Properties map[string]string `json:"properties"`
+	Style      map[string]any    `json:"style"`
+}
+
+// Spacing of the arrows.app layout: one column per asset type.
+const (
+	arrowsColumnWidth = 300
+	arrowsRowHeight   = 150
+)
+
+// ExportArrows returns the graph as an arrows.app JSON document. Nodes carry
+// the Asset label used by Memgraph sync, are laid out in one column per
+// asset type, and are filled with the same colors as the DOT export.
+// Relationship types are the upper-cased edge types.
+func ExportArrows(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
+	if err != nil {
+		return "", err
+	}
+
+	doc := arrowsDocument{
+		Nodes:         []arrowsNode{},
+		Relationships: []arrowsRelationship{},
+		Style:         map[string]any{},
+	}
+
+	// ListNodes orders by type, so each type forms a contiguous column.
+	arrowsIDs := make(map[string]string, len(nodes))
+	col, row := -1, 0
+	var lastType models.AssetType
+	for i, n := range nodes {
+		if i == 0 || n.Type != lastType {
+			col++
+			row = 0
+			lastType = n.Type
+		}
+
+		props := map[string]string{}
+		for k, v := range n.Metadata {
+			props[k] = v
+		}
+		props["id"] = n.ID
+		props["type"] = string(n.Type)
+		props["source"] = n.Source
+		if n.Provider != "" {
+			props["provider"] = n.Provider
+		}
+
+		id := fmt.Sprintf("n%d", i)
+		arrowsIDs[n.ID] = id
+		doc.Nodes = append(doc.Nodes, arrowsNode{
+			ID:         id,
+			Position:   arrowsPosition{X: float64(col * arrowsColumnWidth), Y: float64(row * arrowsRowHeight)},
+			Caption:    opts.label(n),
+			Labels:     []string{"Asset"},
+			Properties: props,
+			Style:      map[string]any{"node-color": nodeColor(n.Type)},
+		})
+		row++
+	}
+
+	for i, e := range edges {
+		from, okFrom := arrowsIDs[e.FromID]
+		to, okTo := arrowsIDs[e.ToID]
+		if !okFrom || !okTo {
+			continue
+		}
+		doc.Relationships = append(doc.Relationships, arrowsRelationship{
+			ID:         fmt.Sprintf("r%d", i),
+			FromID:     from,
+			ToID:       to,
+			Type:       strings.ToUpper(string(e.Type)),
+			Properties: map[string]string{"id": e.ID},
+			Style:      map[string]any{},
+		})
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// cytoscapeDocument is the elements JSON read by Cytoscape.js (cy.add or
+// the elements option) and Cytoscape desktop's .cyjs import.
+type cytoscapeDocument struct {
+	Elements cytoscapeElements `json:"elements"`
+}
+
+type cytoscapeElements struct {
+	Nodes []cytoscapeElement `json:"nodes"`
+	Edges []cytoscapeElement `json:"edges"`
+}
+
+type cytoscapeElement struct {
+	Data    map[string]string `json:"data"`
+	Classes string            `json:"classes"`
+}
+
+// ExportCytoscape returns the graph as Cytoscape.js elements JSON. Node data
+// holds id, label, type, source, provider and the node's metadata; edge data
+// holds id, source, target and type. Each element's asset or edge type is
+// also its class, so stylesheets can select on it. Edges to nodes missing
+// from the store are left out, since Cytoscape rejects them.
+func ExportCytoscape(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
+	if err != nil {
+		return "", err
+	}
+
+	doc := cytoscapeDocument{Elements: cytoscapeElements{
+		Nodes: []cytoscapeElement{},
+		Edges: []cytoscapeElement{},
+	}}
+
+	known := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		known[n.ID] = true
+		data := make(map[string]string, len(n.Metadata)+5)
+		for k, v := range n.Metadata {
+			data[k] = v
+		}
+		data["id"] = n.ID
+		data["label"] = opts.label(n)
+		data["type"] = string(n.Type)
+		data["source"] = n.Source
+		if n.Provider != "" {
+			data["provider"] = n.Provider
+		}
+		doc.Elements.Nodes = append(doc.Elements.Nodes, cytoscapeElement{Data: data, Classes: string(n.Type)})
+	}
+
+	for _, e := range edges {
+		if !known[e.FromID] || !known[e.ToID] {
+			continue
+		}
+		doc.Elements.Edges = append(doc.Elements.Edges, cytoscapeElement{
+			Data: map[string]string{
+				"id":     e.ID,
+				"source": e.FromID,
+				"target": e.ToID,
+				"type":   string(e.Type),
+			},
+			Classes: string(e.Type),
+		})
+	}
+
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// graphmlDocument is the subset of GraphML read by Gephi and yEd.
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+type graphmlKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// graphmlKeys declares the node and edge attributes. Gephi shows "label" as
+// the node caption; the edge type gets its own key ID since key IDs are
+// shared between nodes and edges.
+var graphmlKeys = []graphmlKey{
+	{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+	{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+	{ID: "source", For: "node", AttrName: "source", AttrType: "string"},
+	{ID: "provider", For: "node", AttrName: "provider", AttrType: "string"},
+	{ID: "edge_type", For: "edge", AttrName: "type", AttrType: "string"},
+}
+
+// ExportGraphML returns the graph as a directed GraphML document for Gephi
+// and yEd. Nodes carry label, type, source and provider attributes, edges
+// their type. Edges to nodes missing from the store are left out, since
+// GraphML readers reject dangling endpoints.
+func ExportGraphML(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, edges, err := subgraph(ctx, store, opts)
+	if err != nil {
+		return "", err
+	}
+
+	doc := graphmlDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys:  graphmlKeys,
+		Graph: graphmlGraph{ID: "aib", EdgeDefault: "directed"},
+	}
+
+	known := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		known[n.ID] = true
+		data := []graphmlData{
+			{Key: "label", Value: opts.label(n)},
+			{Key: "type", Value: string(n.Type)},
+			{Key: "source", Value: n.Source},
+		}
+		if n.Provider != "" {
+			data = append(data, graphmlData{Key: "provider", Value: n.Provider})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphmlNode{ID: n.ID, Data: data})
+	}
+
+	for _, e := range edges {
+		if !known[e.FromID] || !known[e.ToID] {
+			continue
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, graphmlEdge{
+			ID:     e.ID,
+			Source: e.FromID,
+			Target: e.ToID,
+			Data:   []graphmlData{{Key: "edge_type", Value: string(e.Type)}},
+		})
+	}
+
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(b) + "\n", nil
+}
+
+// ExportTerraformImports returns Terraform 1.5+ import blocks for the nodes
+// selected by opts, so discovered resources can be adopted into IaC. The
+// import target is the node's tf_address metadata, or tf_type plus the node
+// name when only the resource type is known. The import ID is taken from the
+// id, arn or self_link metadata, in that order. Nodes found by the aws, gcp,
+// kubernetes and kubernetes-live scanners carry neither, so their resource
+// type and import ID are derived from the node (see adoptionTarget). Nodes
+// that still lack either are listed as comments at the end of the output.
+func ExportTerraformImports(ctx context.Context, store Store, opts ExportOptions) (string, error) {
+	nodes, _, err := subgraph(ctx, store, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	var skipped []string
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		to := terraformAddress(n)
+		id := terraformImportID(n)
+		if to == "" {
+			if tfType, label, importID := adoptionTarget(n); tfType != "" {
+				to = tfType + "." + terraformIdentifier(label)
+				id = importID
+			}
+		}
+		if to == "" || id == "" || seen[to] {
+			skipped = append(skipped, n.ID)
+			continue
+		}
+		seen[to] = true
+		fmt.Fprintf(&b, "import {\n  to = %s\n  id = %q\n}\n\n", to, id)
+	}
+
+	if len(skipped) > 0 {
+		b.WriteString("# Skipped (no terraform resource type, provider ID or unique address):\n")
+		for _, id := range skipped {
+			fmt.Fprintf(&b, "#   %s\n", id)
+		}
+	}
+	return b.String(), nil
+}
+
+func terraformAddress(n models.Node) string {
+	if addr := n.Metadata["tf_address"]; addr != "" {
+		return addr
+	}
+	tfType := n.Metadata["tf_type"]
+	if tfType == "" {
+		return ""
+	}
+	return tfType + "." + terraformIdentifier(n.Name)
+}
+
+func terraformImportID(n models.Node) string {
+	for _, key := range []string{"id", "arn", "self_link"} {
+		if v := n.Metadata[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// awsResourceTypes maps the asset types of aws scanner nodes to Terraform
+// resource types.
+var awsResourceTypes = map[models.AssetType]string{
+	models.AssetNetwork:      "aws_vpc",
+	models.AssetSubnet:       "aws_subnet",
+	models.AssetFirewallRule: "aws_security_group",
+	models.AssetVM:           "aws_instance",
+	models.AssetDatabase:     "aws_db_instance",
+	models.AssetLoadBalancer: "aws_lb",
+	models.AssetBucket:       "aws_s3_bucket",
+}
+
+// k8sResourceTypes maps the kind segment of Kubernetes node IDs
+// ("k8s:<kind>:<namespace>/<name>") to Terraform resource types. Workloads,
+// whose IDs all use "pod", are mapped by their kind metadata instead.
+var k8sResourceTypes = map[string]string{
+	"barepod":            "kubernetes_pod_v1",
+	"clusterrole":        "kubernetes_cluster_role_v1",
+	"clusterrolebinding": "kubernetes_cluster_role_binding_v1",
+	"configmap":          "kubernetes_config_map_v1",
+	"cronjob":            "kubernetes_cron_job_v1",
+	"hpa":                "kubernetes_horizontal_pod_autoscaler_v2",
+	"ingress":            "kubernetes_ingress_v1",
+	"job":                "kubernetes_job_v1",
+	"namespace":          "kubernetes_namespace_v1",
+	"networkpolicy":      "kubernetes_network_policy_v1",
+	"pv":                 "kubernetes_persistent_volume_v1",
+	"pvc":                "kubernetes_persistent_volume_claim_v1",
+	"role":               "kubernetes_role_v1",
+	"rolebinding":        "kubernetes_role_binding_v1",
+	"secret":             "kubernetes_secret_v1",
+	"service":            "kubernetes_service_v1",
+	"serviceaccount":     "kubernetes_service_account_v1",
+}
+
+var k8sWorkloadResourceTypes = map[string]string{
+	"Deployment":  "kubernetes_deployment_v1",
+	"StatefulSet": "kubernetes_stateful_set_v1",
+	"DaemonSet":   "kubernetes_daemon_set_v1",
+}
+
+// adoptionTarget returns the Terraform resource type, block label and import
+// ID of a node found by the aws, gcp, kubernetes or kubernetes-live scanners,
+// derived from its ID ("<prefix>:<type>:<ref>"). tfType is empty for nodes
+// with no matching Terraform resource.
+func adoptionTarget(n models.Node) (tfType, label, importID string) {
+	parts := strings.SplitN(n.ID, ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return "", "", ""
+	}
+	ref := parts[2]
+	switch parts[0] {
+	case "aws":
+		tfType = awsResourceTypes[n.Type]
+		switch n.Type {
+		case models.AssetDatabase:
+			importID = n.Name // the ref is "<region>/<identifier>"
+		case models.AssetLoadBalancer:
+			importID = n.Metadata["arn"]
+		default:
+			importID = ref
+		}
+		return tfType, n.Name, importID
+	case "gcp":
+		return gcpAdoptionTarget(n.Type, ref)
+	case "k8s":
+		if parts[1] == "pod" {
+			tfType = k8sWorkloadResourceTypes[n.Metadata["kind"]]
+		} else {
+			tfType = k8sResourceTypes[parts[1]]
+		}
+		// The ref is "<namespace>/<name>", or "<name>" for cluster-scoped
+		// resources, which is what the kubernetes provider imports.
+		return tfType, ref, ref
+	}
+	return "", "", ""
+}
+
+// gcpAdoptionTarget returns the Terraform resource type, block label and
+// import ID of a gcp scanner node from its type and NodeID ref
+// ("<project>/<name>", "<project>/<region or zone>/<name>" or, for
+// buckets, "<name>").
+func gcpAdoptionTarget(t models.AssetType, ref string) (tfType, label, importID string) {
+	parts := strings.Split(ref, "/")
+	name := parts[len(parts)-1]
+	switch {
+	case t == models.AssetBucket && len(parts) == 1:
+		return "google_storage_bucket", name, name
+	case t == models.AssetNetwork && len(parts) == 2:
+		return "google_compute_network", name, fmt.Sprintf("projects/%s/global/networks/%s", parts[0], name)
+	case t == models.AssetDatabase && len(parts) == 2:
+		return "google_sql_database_instance", name, fmt.Sprintf("projects/%s/instances/%s", parts[0], name)
+	case t == models.AssetLoadBalancer && len(parts) == 2:
+		return "google_compute_global_forwarding_rule", name, fmt.Sprintf("projects/%s/global/forwardingRules/%s", parts[0], name)
+	case t == models.AssetLoadBalancer && len(parts) == 3:
+		return "google_compute_forwarding_rule", parts[1] + "_" + name, fmt.Sprintf("projects/%s/regions/%s/forwardingRules/%s", parts[0], parts[1], name)
+	case t == models.AssetSubnet && len(parts) == 3:
+		return "google_compute_subnetwork", parts[1] + "_" + name, fmt.Sprintf("projects/%s/regions/%s/subnetworks/%s", parts[0], parts[1], name)
+	case t == models.AssetVM && len(parts) == 3:
+		return "google_compute_instance", parts[1] + "_" + name, fmt.Sprintf("projects/%s/zones/%s/instances/%s", parts[0], parts[1], name)
+	}
+	return "", "", ""
+}
+
+// terraformIdentifier turns a resource name into a valid Terraform block
+// label: letters, digits, underscores and dashes, not starting with a digit.
+func terraformIdentifier(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	id := b.String()
+	if id == "" || (id[0] >= '0' && id[0] <= '9') || id[0] == '-' {
+		id = "_" + id
+	}
+	return id
+}
+
 func nodeColor(t models.AssetType) string {
 	switch t {
 	case models.AssetVM, models.AssetNode:
@@ -134,6 +750,11 @@ func nodeColor(t models.AssetType) string {
 	}
 }
 
+// mermaidClass returns the Mermaid class name for an asset type's color.
+func mermaidClass(t models.AssetType) string {
+	return "type_" + mermaidSafeID(string(t))
+}
+
 func mermaidSafeID(id string) string {
 	r := strings.NewReplacer(":", "_", ".", "_", "-", "_", "/", "_")
 	return r.Replace(id)
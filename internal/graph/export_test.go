@@ -3,8 +3,12 @@ package graph
 import (
 	"context"
 	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/matijazezelj/aib/pkg/models"
 )
@@ -24,7 +28,7 @@ func TestExportJSON(t *testing.T) {
 	}
 	buildTestGraph(t, store, nodes, edges)
 
-	out, err := ExportJSON(ctx, store)
+	out, err := ExportJSON(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -45,7 +49,7 @@ func TestExportJSON_Empty(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	out, err := ExportJSON(ctx, store)
+	out, err := ExportJSON(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -75,7 +79,7 @@ func TestExportDOT(t *testing.T) {
 	}
 	buildTestGraph(t, store, nodes, edges)
 
-	out, err := ExportDOT(ctx, store)
+	out, err := ExportDOT(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -95,7 +99,7 @@ func TestExportDOT_Empty(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	out, err := ExportDOT(ctx, store)
+	out, err := ExportDOT(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -117,7 +121,7 @@ func TestExportMermaid(t *testing.T) {
 	}
 	buildTestGraph(t, store, nodes, edges)
 
-	out, err := ExportMermaid(ctx, store)
+	out, err := ExportMermaid(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -137,7 +141,7 @@ func TestExportMermaid_Empty(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
 
-	out, err := ExportMermaid(ctx, store)
+	out, err := ExportMermaid(ctx, store, ExportOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -145,3 +149,514 @@ func TestExportMermaid_Empty(t *testing.T) {
 		t.Error("Mermaid output missing 'graph LR'")
 	}
 }
+
+// buildColorTestGraph stores a VM terminating TLS with a certificate that
+// expires in three days, and returns options flagging it.
+func buildColorTestGraph(t *testing.T, store *SQLiteStore) ExportOptions {
+	t.Helper()
+	soon := time.Now().Add(3 * 24 * time.Hour)
+	cert := makeNode("cert1", models.AssetCertificate, "tls-probe")
+	cert.ExpiresAt = &soon
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("vm1", models.AssetVM, "terraform"), cert},
+		[]models.Edge{makeEdge("vm1", "cert1", models.EdgeTerminatesTLS)})
+	return ExportOptions{Color: true, Warn: func(n *models.Node) string {
+		if n.ExpiresAt != nil && time.Until(*n.ExpiresAt) < 30*24*time.Hour {
+			return n.ID + " expires soon"
+		}
+		return ""
+	}}
+}
+
+func TestExportDOT_Color(t *testing.T) {
+	store := newTestStore(t)
+	opts := buildColorTestGraph(t, store)
+
+	out, err := ExportDOT(context.Background(), store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"cert1" [label="cert1\\n(certificate)", fillcolor="#FF6B6B"`) {
+		t.Errorf("expiring cert not filled red:\n%s", out)
+	}
+	if !strings.Contains(out, `"vm1" [label="vm1\\n(vm)", fillcolor="#AED6F1"]`) {
+		t.Errorf("vm not colored by type:\n%s", out)
+	}
+	if !strings.Contains(out, "subgraph cluster_legend {") {
+		t.Errorf("missing legend cluster:\n%s", out)
+	}
+	for _, entry := range []string{`"legend:certificate"`, `"legend:vm"`, `"legend:expiring"`} {
+		if !strings.Contains(out, entry) {
+			t.Errorf("legend missing %s:\n%s", entry, out)
+		}
+	}
+
+	plain, err := ExportDOT(context.Background(), store, ExportOptions{Warn: opts.Warn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(plain, "cluster_legend") || strings.Contains(plain, "#FF6B6B") {
+		t.Errorf("uncolored export has legend or highlight:\n%s", plain)
+	}
+}
+
+func TestExportDOT_Label(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	pod := makeNode("k8s:pod:production/api-backend", models.AssetPod, "kubernetes")
+	pod.Name = "api-backend"
+	pod.Metadata["app"] = "api"
+	bare := makeNode("k8s:pod:production/worker", models.AssetPod, "kubernetes")
+	bare.Name = "worker"
+	buildTestGraph(t, store, []models.Node{pod, bare}, nil)
+
+	tests := []struct {
+		label     string
+		pod, bare string
+	}{
+		{"", `"api-backend\\n(pod)"`, `"worker\\n(pod)"`},
+		{"name", `"api-backend\\n(pod)"`, `"worker\\n(pod)"`},
+		{"id", `"k8s:pod:production/api-backend\\n(pod)"`, `"k8s:pod:production/worker\\n(pod)"`},
+		{"metadata.app", `"api\\n(pod)"`, `"worker\\n(pod)"`},
+	}
+	for _, tt := range tests {
+		out, err := ExportDOT(ctx, store, ExportOptions{Label: tt.label})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "label="+tt.pod) || !strings.Contains(out, "label="+tt.bare) {
+			t.Errorf("label %q: want %s and %s in:\n%s", tt.label, tt.pod, tt.bare, out)
+		}
+	}
+
+	if _, err := ExportDOT(ctx, store, ExportOptions{Label: "nmae"}); err == nil || !strings.Contains(err.Error(), "invalid label") {
+		t.Errorf("expected invalid label error, got %v", err)
+	}
+}
+
+func TestExportJSON_Label(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	n := makeNode("k8s:pod:production/api-backend", models.AssetPod, "kubernetes")
+	n.Name = "api-backend"
+	buildTestGraph(t, store, []models.Node{n}, nil)
+
+	out, err := ExportJSON(ctx, store, ExportOptions{Label: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data GraphData
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Labels[n.ID] != "api-backend" {
+		t.Errorf("labels = %v", data.Labels)
+	}
+
+	out, err = ExportJSON(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, `"labels"`) {
+		t.Errorf("labels should be omitted by default:\n%s", out)
+	}
+}
+
+func TestExportMermaid_Color(t *testing.T) {
+	store := newTestStore(t)
+	opts := buildColorTestGraph(t, store)
+
+	out, err := ExportMermaid(context.Background(), store, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`subgraph legend["Legend"]`,
+		"classDef type_vm fill:#AED6F1",
+		"classDef expiring fill:#FF6B6B",
+		"class vm1 type_vm",
+		"class cert1 expiring",
+		`legend_expiring["expiring"]:::expiring`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestExportArrows(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vm := makeNode("n1", models.AssetVM, "terraform")
+	vm.Metadata["zone"] = "us-east1-b"
+	nodes := []models.Node{
+		vm,
+		makeNode("n2", models.AssetNetwork, "terraform"),
+		makeNode("n3", models.AssetDatabase, "terraform"),
+	}
+	edges := []models.Edge{
+		makeEdge("n1", "n2", models.EdgeDependsOn),
+		makeEdge("n1", "n3", models.EdgeConnectsTo),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	out, err := ExportArrows(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc arrowsDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(doc.Nodes) != 3 || len(doc.Relationships) != 2 {
+		t.Fatalf("got %d nodes, %d relationships; want 3, 2", len(doc.Nodes), len(doc.Relationships))
+	}
+
+	byAsset := map[string]arrowsNode{}
+	for _, n := range doc.Nodes {
+		byAsset[n.Properties["id"]] = n
+	}
+	web := byAsset["n1"]
+	if web.Caption != "n1" || web.Labels[0] != "Asset" || web.Properties["zone"] != "us-east1-b" {
+		t.Errorf("unexpected vm node: %+v", web)
+	}
+	// One column per asset type.
+	if byAsset["n1"].Position.X == byAsset["n2"].Position.X {
+		t.Error("nodes of different types should be in different columns")
+	}
+
+	for _, r := range doc.Relationships {
+		if r.FromID != web.ID {
+			t.Errorf("relationship %s should start at %s, got %s", r.ID, web.ID, r.FromID)
+		}
+		if r.Type != "DEPENDS_ON" && r.Type != "CONNECTS_TO" {
+			t.Errorf("unexpected relationship type %q", r.Type)
+		}
+	}
+}
+
+func TestExportArrows_Empty(t *testing.T) {
+	store := newTestStore(t)
+
+	out, err := ExportArrows(context.Background(), store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"nodes": []`) || !strings.Contains(out, `"relationships": []`) {
+		t.Errorf("expected empty arrays, got: %s", out)
+	}
+}
+
+func TestExportCytoscape(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vm := makeNode("n1", models.AssetVM, "terraform")
+	vm.Metadata["zone"] = "us-east1-b"
+	nodes := []models.Node{
+		vm,
+		makeNode("n2", models.AssetNetwork, "terraform"),
+		makeNode("n3", models.AssetDatabase, "terraform"),
+	}
+	edges := []models.Edge{
+		makeEdge("n1", "n2", models.EdgeDependsOn),
+		makeEdge("n1", "n3", models.EdgeConnectsTo),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	out, err := ExportCytoscape(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc cytoscapeDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(doc.Elements.Nodes) != 3 || len(doc.Elements.Edges) != 2 {
+		t.Fatalf("got %d nodes, %d edges; want 3, 2", len(doc.Elements.Nodes), len(doc.Elements.Edges))
+	}
+
+	ids := map[string]bool{}
+	for _, n := range doc.Elements.Nodes {
+		ids[n.Data["id"]] = true
+		if n.Classes != n.Data["type"] {
+			t.Errorf("node %s classes = %q, want its type %q", n.Data["id"], n.Classes, n.Data["type"])
+		}
+		if n.Data["id"] == "n1" && (n.Data["label"] != "n1" || n.Data["zone"] != "us-east1-b" || n.Classes != "vm") {
+			t.Errorf("unexpected vm node: %+v", n)
+		}
+	}
+	for _, e := range doc.Elements.Edges {
+		if !ids[e.Data["source"]] || !ids[e.Data["target"]] {
+			t.Errorf("edge %s references a missing node: %s -> %s", e.Data["id"], e.Data["source"], e.Data["target"])
+		}
+		if e.Classes != e.Data["type"] || (e.Classes != "depends_on" && e.Classes != "connects_to") {
+			t.Errorf("unexpected edge classes %q for type %q", e.Classes, e.Data["type"])
+		}
+	}
+}
+
+func TestExportCytoscape_Empty(t *testing.T) {
+	store := newTestStore(t)
+
+	out, err := ExportCytoscape(context.Background(), store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"nodes": []`) || !strings.Contains(out, `"edges": []`) {
+		t.Errorf("expected empty arrays, got: %s", out)
+	}
+}
+
+func TestExportGraphML(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vm := makeNode("tf:vm:web<1>", models.AssetVM, "terraform")
+	vm.Provider = "google"
+	nodes := []models.Node{
+		vm,
+		makeNode("n2", models.AssetNetwork, "terraform"),
+		makeNode("n3", models.AssetDatabase, "terraform"),
+	}
+	edges := []models.Edge{
+		makeEdge("tf:vm:web<1>", "n2", models.EdgeDependsOn),
+		makeEdge("tf:vm:web<1>", "n3", models.EdgeConnectsTo),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	out, err := ExportGraphML(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "<?xml") {
+		t.Errorf("expected XML declaration, got: %.40s", out)
+	}
+
+	var doc graphmlDocument
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid XML output: %v", err)
+	}
+	if doc.XMLNS != "http://graphml.graphdrawing.org/xmlns" || doc.Graph.EdgeDefault != "directed" {
+		t.Errorf("unexpected graphml header: xmlns=%q edgedefault=%q", doc.XMLNS, doc.Graph.EdgeDefault)
+	}
+	if len(doc.Graph.Nodes) != 3 || len(doc.Graph.Edges) != 2 {
+		t.Fatalf("got %d nodes, %d edges; want 3, 2", len(doc.Graph.Nodes), len(doc.Graph.Edges))
+	}
+
+	keys := map[string]string{}
+	for _, k := range doc.Keys {
+		keys[k.ID] = k.For + ":" + k.AttrName
+	}
+	for id, want := range map[string]string{
+		"label": "node:label", "type": "node:type", "source": "node:source",
+		"provider": "node:provider", "edge_type": "edge:type",
+	} {
+		if keys[id] != want {
+			t.Errorf("key %s = %q, want %q", id, keys[id], want)
+		}
+	}
+
+	attrs := func(data []graphmlData) map[string]string {
+		m := map[string]string{}
+		for _, d := range data {
+			m[d.Key] = d.Value
+		}
+		return m
+	}
+	var web graphmlNode
+	for _, n := range doc.Graph.Nodes {
+		if n.ID == "tf:vm:web<1>" {
+			web = n
+		}
+	}
+	if a := attrs(web.Data); a["type"] != "vm" || a["source"] != "terraform" || a["provider"] != "google" {
+		t.Errorf("unexpected vm attributes: %v", a)
+	}
+	for _, e := range doc.Graph.Edges {
+		if e.Source != "tf:vm:web<1>" {
+			t.Errorf("edge %s should start at the vm, got %s", e.ID, e.Source)
+		}
+		if typ := attrs(e.Data)["edge_type"]; typ != "depends_on" && typ != "connects_to" {
+			t.Errorf("unexpected edge type %q", typ)
+		}
+	}
+}
+
+func TestExportGraphML_Empty(t *testing.T) {
+	store := newTestStore(t)
+
+	out, err := ExportGraphML(context.Background(), store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc graphmlDocument
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid XML output: %v", err)
+	}
+	if len(doc.Graph.Nodes) != 0 || len(doc.Graph.Edges) != 0 {
+		t.Errorf("expected empty graph, got: %s", out)
+	}
+}
+
+func TestExport_Subgraph(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	nodes := []models.Node{
+		makeNode("pod1", models.AssetPod, "kubernetes"),
+		makeNode("pod2", models.AssetPod, "kubernetes"),
+		makeNode("svc", models.AssetService, "kubernetes"),
+		makeNode("db", models.AssetDatabase, "terraform"),
+	}
+	edges := []models.Edge{
+		makeEdge("pod1", "svc", models.EdgeDependsOn),
+		makeEdge("pod2", "svc", models.EdgeDependsOn),
+		makeEdge("svc", "db", models.EdgeConnectsTo),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	parse := func(out string) GraphData {
+		t.Helper()
+		var data GraphData
+		if err := json.Unmarshal([]byte(out), &data); err != nil {
+			t.Fatalf("invalid JSON output: %v", err)
+		}
+		return data
+	}
+
+	// Filtering by type keeps only pods, and no pod-to-service edge is left
+	// pointing outside the export.
+	out, err := ExportJSON(ctx, store, ExportOptions{Filter: NodeFilter{Type: "pod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := parse(out)
+	if len(data.Nodes) != 2 || len(data.Edges) != 0 {
+		t.Errorf("type filter: got %d nodes, %d edges; want 2, 0", len(data.Nodes), len(data.Edges))
+	}
+	for _, n := range data.Nodes {
+		if n.Type != models.AssetPod {
+			t.Errorf("type filter exported %s of type %s", n.ID, n.Type)
+		}
+	}
+
+	out, err = ExportDOT(ctx, store, ExportOptions{Filter: NodeFilter{Source: "kubernetes"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, `"db"`) || !strings.Contains(out, `"pod1" -> "svc"`) {
+		t.Errorf("source filter: unexpected DOT output:\n%s", out)
+	}
+
+	// A seed's blast radius: db and everything that depends on it within one hop.
+	tree, err := BlastRadiusTree(ctx, store, "db", ImpactOptions{MaxDepth: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := SubgraphIDs(tree)
+	sort.Strings(ids)
+	if !reflect.DeepEqual(ids, []string{"db", "svc"}) {
+		t.Fatalf("SubgraphIDs = %v, want [db svc]", ids)
+	}
+	out, err = ExportJSON(ctx, store, ExportOptions{NodeIDs: ids})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = parse(out)
+	if len(data.Nodes) != 2 || len(data.Edges) != 1 || data.Edges[0].FromID != "svc" {
+		t.Errorf("seed export: got %+v", data)
+	}
+}
+
+func TestExportTerraformImports(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vm := makeNode("tf:vm:web", models.AssetVM, "terraform")
+	vm.Metadata["tf_address"] = "module.app.aws_instance.web"
+	vm.Metadata["id"] = "i-0abc"
+	vm.Metadata["arn"] = "arn:aws:ec2:us-east-1:123:instance/i-0abc"
+	bucket := makeNode("logs bucket", models.AssetBucket, "cloudformation")
+	bucket.Metadata["tf_type"] = "google_storage_bucket"
+	bucket.Metadata["self_link"] = "https://www.googleapis.com/storage/v1/b/logs"
+	noID := makeNode("tf:network:vpc", models.AssetNetwork, "terraform")
+	noID.Metadata["tf_address"] = "aws_vpc.main"
+	pod := makeNode("k8s:pod:web", models.AssetPod, "kubernetes")
+	buildTestGraph(t, store, []models.Node{vm, bucket, noID, pod}, nil)
+
+	out, err := ExportTerraformImports(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"import {\n  to = module.app.aws_instance.web\n  id = \"i-0abc\"\n}",
+		"to = google_storage_bucket.logs_bucket\n  id = \"https://www.googleapis.com/storage/v1/b/logs\"",
+		"#   tf:network:vpc",
+		"#   k8s:pod:web",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out, err = ExportTerraformImports(ctx, store, ExportOptions{Filter: NodeFilter{Source: "terraform"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "google_storage_bucket") || strings.Contains(out, "k8s:pod:web") {
+		t.Errorf("source filter not applied:\n%s", out)
+	}
+}
+
+func TestExportTerraformImports_DiscoveredNodes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vpc := makeNode("aws:network:vpc-123", models.AssetNetwork, "aws")
+	vpc.Name = "prod"
+	db := makeNode("aws:database:us-east-1/orders", models.AssetDatabase, "aws")
+	db.Name = "orders"
+	subnet := makeNode("gcp:subnet:proj/europe-west1/app", models.AssetSubnet, "gcp")
+	subnet.Name = "app"
+	deploy := makeNode("k8s:pod:prod/web", models.AssetPod, "kubernetes")
+	deploy.Metadata["kind"] = "Deployment"
+	ns := makeNode("k8s:namespace:prod", models.AssetNamespace, "kubernetes-live")
+	image := makeNode("k8s:image:nginx", models.AssetContainer, "kubernetes")
+	buildTestGraph(t, store, []models.Node{vpc, db, subnet, deploy, ns, image}, nil)
+
+	out, err := ExportTerraformImports(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"to = aws_vpc.prod\n  id = \"vpc-123\"",
+		"to = aws_db_instance.orders\n  id = \"orders\"",
+		"to = google_compute_subnetwork.europe-west1_app\n  id = \"projects/proj/regions/europe-west1/subnetworks/app\"",
+		"to = kubernetes_deployment_v1.prod_web\n  id = \"prod/web\"",
+		"to = kubernetes_namespace_v1.prod\n  id = \"prod\"",
+		"#   k8s:image:nginx",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTerraformIdentifier(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"web-prod-1", "web-prod-1"},
+		{"my.bucket/logs", "my_bucket_logs"},
+		{"1st", "_1st"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if got := terraformIdentifier(tt.in); got != tt.want {
+			t.Errorf("terraformIdentifier(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
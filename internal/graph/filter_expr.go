@@ -0,0 +1,313 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Limits on filter expressions, which arrive from API query strings.
+const (
+	maxFilterExprLen   = 4096
+	maxFilterExprDepth = 32
+)
+
+// filterColumns maps the node fields a filter expression may compare to
+// their columns. Nullable columns compare as empty strings.
+var filterColumns = map[string]string{
+	"id":          "id",
+	"name":        "name",
+	"type":        "type",
+	"source":      "source",
+	"source_file": "IFNULL(source_file, '')",
+	"provider":    "IFNULL(provider, '')",
+}
+
+// Prefixes selecting a metadata key or a tag in a filter expression.
+const (
+	metadataPrefix = "metadata."
+	tagPrefix      = "tags."
+)
+
+// exprDelimiters end a bare word in a filter expression.
+const exprDelimiters = " \t\n\r()=!\"'"
+
+// FilterExpr is a parsed node filter expression such as
+// `type=vm AND (provider=google OR metadata.region="us-east1")`.
+// Comparisons use = or !=; AND binds tighter than OR, NOT negates, and
+// parentheses group. Fields are id, name, type, source, source_file,
+// provider, metadata.<key> and tags.<key>. A metadata or tag != comparison
+// also matches nodes without the key. Values are bare words or quoted
+// strings and are always passed to SQL as parameters.
+type FilterExpr struct {
+	op          string // "and", "or", "not" or "cmp"
+	left, right *FilterExpr
+
+	// Set for comparisons. column is empty for metadata keys and tags.
+	column, key, value string
+	tag, negate        bool
+
+	src string
+}
+
+// ParseFilterExpr parses a node filter expression.
+func ParseFilterExpr(s string) (*FilterExpr, error) {
+	if len(s) > maxFilterExprLen {
+		return nil, fmt.Errorf("filter expression longer than %d bytes", maxFilterExprLen)
+	}
+	toks, err := lexFilterExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	if p.peek().kind == tokEOF {
+		return nil, fmt.Errorf("empty filter expression")
+	}
+	e, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d, expected AND or OR", t, t.pos)
+	}
+	e.src = s
+	return e, nil
+}
+
+// String returns the expression as it was parsed.
+func (e *FilterExpr) String() string {
+	return e.src
+}
+
+// sql renders the expression as a WHERE condition over the nodes table.
+func (e *FilterExpr) sql() (string, []any) {
+	switch e.op {
+	case "and", "or":
+		l, largs := e.left.sql()
+		r, rargs := e.right.sql()
+		return "(" + l + " " + strings.ToUpper(e.op) + " " + r + ")", append(largs, rargs...)
+	case "not":
+		c, args := e.left.sql()
+		return "NOT " + c, args
+	}
+	if e.column != "" {
+		op := "="
+		if e.negate {
+			op = "!="
+		}
+		return "(" + e.column + " " + op + " ?)", []any{e.value}
+	}
+	// json_each avoids building a JSON path from the key.
+	cond := "EXISTS (SELECT 1 FROM json_each(nodes.metadata) WHERE json_each.key = ? AND json_each.value = ?)"
+	if e.tag {
+		cond = "EXISTS (SELECT 1 FROM node_tags WHERE node_tags.node_id = nodes.id AND node_tags.key = ? AND node_tags.value = ?)"
+	}
+	if e.negate {
+		cond = "NOT " + cond
+	}
+	return "(" + cond + ")", []any{e.key, e.value}
+}
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokWord
+	tokString
+	tokLParen
+	tokRParen
+	tokEq
+	tokNe
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "'" + t.text + "'"
+}
+
+// keyword reports whether t is the bare word kw, ignoring case.
+func (t token) keyword(kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+func lexFilterExpr(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '=':
+			toks = append(toks, token{tokEq, "=", i})
+			i++
+		case c == '!':
+			if i+1 >= len(s) || s[i+1] != '=' {
+				return nil, fmt.Errorf("unexpected '!' at position %d, expected !=", i)
+			}
+			toks = append(toks, token{tokNe, "!=", i})
+			i += 2
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				} else if s[i] == c {
+					i++
+					break
+				}
+				b.WriteByte(s[i])
+			}
+			toks = append(toks, token{tokString, b.String(), start})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(exprDelimiters, rune(s[i])) {
+				i++
+			}
+			toks = append(toks, token{tokWord, s[start:i], start})
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+type exprParser struct {
+	toks []token
+	i    int
+}
+
+func (p *exprParser) peek() token { return p.toks[p.i] }
+
+func (p *exprParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *exprParser) parseOr(depth int) (*FilterExpr, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("or") {
+		p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &FilterExpr{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd(depth int) (*FilterExpr, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("and") {
+		p.next()
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = &FilterExpr{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary(depth int) (*FilterExpr, error) {
+	if depth > maxFilterExprDepth {
+		return nil, fmt.Errorf("filter expression nested deeper than %d levels", maxFilterExprDepth)
+	}
+	t := p.peek()
+	switch {
+	case t.keyword("not"):
+		p.next()
+		e, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &FilterExpr{op: "not", left: e}, nil
+	case t.kind == tokLParen:
+		p.next()
+		e, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, fmt.Errorf("unexpected %s at position %d, expected ')'", c, c.pos)
+		}
+		return e, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (*FilterExpr, error) {
+	f := p.next()
+	if f.kind != tokWord || f.keyword("and") || f.keyword("or") {
+		return nil, fmt.Errorf("unexpected %s at position %d, expected a field", f, f.pos)
+	}
+	e := &FilterExpr{op: "cmp"}
+	if key, ok := strings.CutPrefix(f.text, metadataPrefix); ok {
+		if key == "" {
+			return nil, fmt.Errorf("missing metadata key at position %d", f.pos)
+		}
+		e.key = key
+	} else if key, ok := strings.CutPrefix(f.text, tagPrefix); ok {
+		if key == "" {
+			return nil, fmt.Errorf("missing tag key at position %d", f.pos)
+		}
+		e.key, e.tag = key, true
+	} else if col, ok := filterColumns[f.text]; ok {
+		e.column = col
+	} else {
+		return nil, fmt.Errorf("unknown field %q at position %d (valid: %s, metadata.<key>, tags.<key>)", f.text, f.pos, strings.Join(filterFieldNames(), ", "))
+	}
+
+	switch op := p.next(); op.kind {
+	case tokEq:
+	case tokNe:
+		e.negate = true
+	default:
+		return nil, fmt.Errorf("unexpected %s at position %d, expected = or !=", op, op.pos)
+	}
+
+	v := p.next()
+	if v.kind != tokWord && v.kind != tokString {
+		return nil, fmt.Errorf("unexpected %s at position %d, expected a value", v, v.pos)
+	}
+	e.value = v.text
+	return e, nil
+}
+
+// filterFieldNames returns the node fields accepted in filter expressions.
+func filterFieldNames() []string {
+	names := make([]string, 0, len(filterColumns))
+	for name := range filterColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func seedFilterExprNodes(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store := newTestStore(t)
+	nodes := []models.Node{
+		correlationTestNode("vm-east", "web", models.AssetVM, "terraform", map[string]string{"region": "us-east1"}),
+		correlationTestNode("vm-west", "api", models.AssetVM, "terraform", map[string]string{"region": "us-west1"}),
+		correlationTestNode("db-east", "pg", models.AssetDatabase, "terraform", map[string]string{"region": "us-east1", "tier": "db-custom-2"}),
+		correlationTestNode("pod", "api", models.AssetPod, "kubernetes", nil),
+	}
+	nodes[0].Provider, nodes[1].Provider, nodes[2].Provider = "google", "aws", "google"
+	if err := store.UpsertBatch(context.Background(), nodes, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetTag(context.Background(), "vm-west", "owner", "team-a"); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestListNodes_FilterExpr(t *testing.T) {
+	store := seedFilterExprNodes(t)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"type=vm", []string{"vm-east", "vm-west"}},
+		{"type=vm AND provider=google", []string{"vm-east"}},
+		{"type = vm and provider = google and metadata.region = us-east1", []string{"vm-east"}},
+		{"metadata.region=us-east1", []string{"db-east", "vm-east"}},
+		{"type=database OR source=kubernetes", []string{"db-east", "pod"}},
+		// AND binds tighter than OR.
+		{"source=kubernetes OR type=vm AND provider=aws", []string{"pod", "vm-west"}},
+		{"(source=kubernetes OR type=vm) AND name=api", []string{"pod", "vm-west"}},
+		{"NOT type=vm", []string{"db-east", "pod"}},
+		{"type!=vm AND provider!=test", []string{"db-east"}},
+		// A missing metadata key counts as not equal.
+		{"metadata.region != us-east1", []string{"pod", "vm-west"}},
+		{`metadata.tier="db-custom-2"`, []string{"db-east"}},
+		{`name='pg' OR name="it's \"quoted\""`, []string{"db-east"}},
+		{"source_file=''", []string{"db-east", "pod", "vm-east", "vm-west"}},
+		{"tags.owner=team-a", []string{"vm-west"}},
+		{"type=vm AND tags.owner!=team-a", []string{"vm-east"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := ParseFilterExpr(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes, err := store.ListNodes(context.Background(), NodeFilter{Expr: expr, OrderBy: "id"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, n := range nodes {
+				got = append(got, n.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListNodes_FilterExprValuesAreParameters(t *testing.T) {
+	store := seedFilterExprNodes(t)
+	for _, expr := range []string{
+		`name="x' OR '1'='1"`,
+		`metadata.region="') OR 1=1 --"`,
+	} {
+		e, err := ParseFilterExpr(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := store.ListNodes(context.Background(), NodeFilter{Expr: e})
+		if err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if len(nodes) != 0 {
+			t.Errorf("%s matched %d nodes, want none", expr, len(nodes))
+		}
+	}
+}
+
+func TestParseFilterExpr_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "empty filter expression"},
+		{"colour=red", `unknown field "colour"`},
+		{"metadata=x", `unknown field "metadata"`},
+		{"metadata.=x", "missing metadata key"},
+		{"tags.=x", "missing tag key"},
+		{"1=1", `unknown field "1"`},
+		{"type", "expected = or !="},
+		{"type=", "expected a value"},
+		{"type=vm provider=aws", "expected AND or OR"},
+		{"type=vm AND", "expected a field"},
+		{"(type=vm", "expected ')'"},
+		{"type=vm)", "expected AND or OR"},
+		{`name="web`, "unterminated string"},
+		{"type!vm", "expected !="},
+		{strings.Repeat("(", 40) + "type=vm" + strings.Repeat(")", 40), "nested deeper"},
+		{strings.Repeat("type=vm OR ", 400) + "type=vm", "longer than"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFilterExpr(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseFilterExpr(%.40q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package graph
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// GroupSummary describes a grouping pass such as GroupByProvider.
+type GroupSummary struct {
+	Groups       int `json:"groups"`
+	EdgesAdded   int `json:"edges_added"`
+	EdgesRemoved int `json:"edges_removed"`
+	NodesRemoved int `json:"nodes_removed"`
+}
+
+// Prefixes of the IDs of grouping nodes.
+const (
+	providerNodePrefix = "aib:provider:"
+	regionNodePrefix   = "aib:region:"
+)
+
+// groupingTypes are the asset types of the synthetic nodes added by the
+// grouping passes. They tie otherwise unrelated assets together, so orphan
+// detection ignores them.
+var groupingTypes = []models.AssetType{models.AssetProvider, models.AssetRegion}
+
+// ProviderNodeID returns the ID of the node grouping provider's assets.
+func ProviderNodeID(provider string) string {
+	return providerNodePrefix + provider
+}
+
+// RegionNodeID returns the ID of the node grouping region's assets.
+func RegionNodeID(region string) string {
+	return regionNodePrefix + region
+}
+
+// GroupByProvider adds a provider node for each distinct provider value
+// (google, aws, kubernetes, ...) with a member_of edge from every asset of
+// that provider, so a whole cloud account can be listed or blast-radiused
+// from one node.
+func GroupByProvider(ctx context.Context, store *SQLiteStore) (*GroupSummary, error) {
+	return groupNodes(ctx, store, models.AssetProvider, models.EdgeMemberOf, providerNodePrefix,
+		func(n models.Node) string { return n.Provider })
+}
+
+// GroupByRegion adds a region node for each distinct region with a
+// located_in edge from every asset in it, so the blast radius of a region
+// node is what breaks if the region goes down. The region comes from
+// RegionOf.
+func GroupByRegion(ctx context.Context, store *SQLiteStore) (*GroupSummary, error) {
+	return groupNodes(ctx, store, models.AssetRegion, models.EdgeLocatedIn, regionNodePrefix, RegionOf)
+}
+
+// RegionOf returns the region of n: its region metadata, or the region of
+// its zone, availability zone or location. It returns "" for assets with
+// none of them, such as most Kubernetes objects.
+func RegionOf(n models.Node) string {
+	if r := n.Metadata["region"]; r != "" {
+		return r
+	}
+	for _, k := range []string{"zone", "availability_zone", "location"} {
+		if z := n.Metadata[k]; z != "" {
+			return zoneRegion(z)
+		}
+	}
+	return ""
+}
+
+// zoneRegion strips the zone suffix from a GCP zone (us-central1-a) or an
+// AWS availability zone (us-east-1a). Anything else is already a region.
+func zoneRegion(zone string) string {
+	n := len(zone)
+	if n < 3 || zone[n-1] < 'a' || zone[n-1] > 'z' {
+		return zone
+	}
+	switch c := zone[n-2]; {
+	case c == '-':
+		return zone[:n-2]
+	case c >= '0' && c <= '9':
+		return zone[:n-1]
+	}
+	return zone
+}
+
+// groupNodes links every asset to a node of nodeType for its key, with an
+// edge of edgeType. It is idempotent: existing edges are kept, edges of
+// assets whose key changed are removed, and so are group nodes left
+// without members. Assets with an empty key are left out.
+func groupNodes(ctx context.Context, store *SQLiteStore, nodeType models.AssetType, edgeType models.EdgeType, prefix string, key func(models.Node) string) (*GroupSummary, error) {
+	nodes, err := store.ListNodes(ctx, NodeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	memberEdges, err := store.ListEdges(ctx, EdgeFilter{Type: string(edgeType)})
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool)
+	for _, e := range memberEdges {
+		if strings.HasPrefix(e.ToID, prefix) {
+			existing[e.ID] = true
+		}
+	}
+
+	// providers collects the providers of each group's members; a group
+	// node carries its members' provider when they share one.
+	providers := make(map[string]map[string]bool)
+	var groupIDs []string
+	wanted := make(map[string]bool)
+	var edges []models.Edge
+	for _, n := range nodes {
+		if n.Type == nodeType {
+			groupIDs = append(groupIDs, n.ID)
+			continue
+		}
+		k := key(n)
+		if k == "" || isGroupingType(n.Type) {
+			continue
+		}
+		if providers[k] == nil {
+			providers[k] = make(map[string]bool)
+		}
+		providers[k][n.Provider] = true
+		to := prefix + k
+		id := GenerateEdgeID(n.ID, to, edgeType)
+		wanted[id] = true
+		if !existing[id] {
+			edges = append(edges, models.Edge{ID: id, FromID: n.ID, ToID: to, Type: edgeType})
+		}
+	}
+
+	now := time.Now()
+	keys := make([]string, 0, len(providers))
+	for k := range providers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	groups := make([]models.Node, len(keys))
+	for i, k := range keys {
+		var provider string
+		if len(providers[k]) == 1 {
+			for p := range providers[k] {
+				provider = p
+			}
+		}
+		groups[i] = models.Node{
+			ID:        prefix + k,
+			Name:      k,
+			Type:      nodeType,
+			Source:    "aib",
+			Provider:  provider,
+			Metadata:  map[string]string{},
+			LastSeen:  now,
+			FirstSeen: now,
+		}
+	}
+	if err := store.UpsertBatch(ctx, groups, edges); err != nil {
+		return nil, err
+	}
+
+	var staleEdges []string
+	for id := range existing {
+		if !wanted[id] {
+			staleEdges = append(staleEdges, id)
+		}
+	}
+	removedEdges, err := store.deleteEdges(ctx, staleEdges)
+	if err != nil {
+		return nil, err
+	}
+
+	var empty []string
+	for _, id := range groupIDs {
+		if providers[strings.TrimPrefix(id, prefix)] == nil {
+			empty = append(empty, id)
+		}
+	}
+	removedNodes, err := store.DeleteNodes(ctx, empty)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GroupSummary{
+		Groups:       len(keys),
+		EdgesAdded:   len(edges),
+		EdgesRemoved: removedEdges,
+		NodesRemoved: removedNodes,
+	}, nil
+}
+
+func isGroupingType(t models.AssetType) bool {
+	for _, g := range groupingTypes {
+		if t == g {
+			return true
+		}
+	}
+	return false
+}
+
+// RegionCount is the number of assets in one region, as listed by
+// aib graph regions.
+type RegionCount struct {
+	Region    string         `json:"region"`
+	Nodes     int            `json:"nodes"`
+	Providers []string       `json:"providers"`
+	ByType    map[string]int `json:"by_type"`
+}
+
+// CountByRegion counts nodes per RegionOf, largest region first. Grouping
+// nodes and nodes without a region are left out.
+func CountByRegion(nodes []models.Node) []RegionCount {
+	byRegion := make(map[string]*RegionCount)
+	providers := make(map[string]map[string]bool)
+	for _, n := range nodes {
+		region := RegionOf(n)
+		if region == "" || isGroupingType(n.Type) {
+			continue
+		}
+		rc := byRegion[region]
+		if rc == nil {
+			rc = &RegionCount{Region: region, ByType: make(map[string]int)}
+			byRegion[region] = rc
+			providers[region] = make(map[string]bool)
+		}
+		rc.Nodes++
+		rc.ByType[string(n.Type)]++
+		if n.Provider != "" && !providers[region][n.Provider] {
+			providers[region][n.Provider] = true
+			rc.Providers = append(rc.Providers, n.Provider)
+		}
+	}
+
+	out := make([]RegionCount, 0, len(byRegion))
+	for _, rc := range byRegion {
+		sort.Strings(rc.Providers)
+		out = append(out, *rc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Nodes != out[j].Nodes {
+			return out[i].Nodes > out[j].Nodes
+		}
+		return out[i].Region < out[j].Region
+	})
+	return out
+}
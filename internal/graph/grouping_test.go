@@ -0,0 +1,213 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func providerNode(id, provider string) models.Node {
+	n := makeNode(id, models.AssetVM, "terraform")
+	n.Provider = provider
+	return n
+}
+
+func TestGroupByProvider(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store,
+		[]models.Node{
+			providerNode("web", "google"),
+			providerNode("db", "google"),
+			providerNode("queue", "aws"),
+			providerNode("lone", ""),
+		},
+		[]models.Edge{makeEdge("web", "db", models.EdgeDependsOn)},
+	)
+
+	summary, err := GroupByProvider(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Groups != 2 || summary.EdgesAdded != 3 {
+		t.Errorf("summary = %+v, want 2 providers and 3 edges", summary)
+	}
+	members, err := store.GetEdgesTo(ctx, ProviderNodeID("google"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 {
+		t.Errorf("google members = %d, want 2", len(members))
+	}
+	impact, err := BlastRadius(ctx, store, ProviderNodeID("google"), ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if impact.AffectedNodes != 2 {
+		t.Errorf("google blast radius = %d nodes, want its 2 members", impact.AffectedNodes)
+	}
+
+	// Re-running adds nothing.
+	summary, err = GroupByProvider(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EdgesAdded != 0 || summary.EdgesRemoved != 0 {
+		t.Errorf("second pass = %+v, want no changes", summary)
+	}
+
+	// The only aws asset moves to google: its edge follows and the empty
+	// aws node goes.
+	if err := store.UpsertNode(ctx, providerNode("queue", "google")); err != nil {
+		t.Fatal(err)
+	}
+	summary, err = GroupByProvider(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EdgesAdded != 1 || summary.EdgesRemoved != 1 || summary.NodesRemoved != 1 {
+		t.Errorf("after move = %+v, want 1 edge added, 1 removed, 1 node removed", summary)
+	}
+	if n, _ := store.GetNode(ctx, ProviderNodeID("aws")); n != nil {
+		t.Error("empty aws provider node was kept")
+	}
+}
+
+func TestFindOrphanNodes_IgnoresProviderGrouping(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store,
+		[]models.Node{providerNode("web", "google"), providerNode("db", "google"), providerNode("lone", "google")},
+		[]models.Edge{makeEdge("web", "db", models.EdgeDependsOn)},
+	)
+	if _, err := GroupByProvider(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	orphans, err := store.FindOrphanNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orphans) != 1 || orphans[0].ID != "lone" {
+		t.Errorf("orphans = %v, want only lone", orphans)
+	}
+}
+
+func regionNode(id, provider string, meta map[string]string) models.Node {
+	n := providerNode(id, provider)
+	n.Metadata = meta
+	return n
+}
+
+func TestRegionOf(t *testing.T) {
+	for _, tc := range []struct {
+		meta map[string]string
+		want string
+	}{
+		{map[string]string{"region": "us-east1", "zone": "europe-west1-b"}, "us-east1"},
+		{map[string]string{"zone": "us-central1-a"}, "us-central1"},
+		{map[string]string{"availability_zone": "us-east-1a"}, "us-east-1"},
+		{map[string]string{"location": "westeurope"}, "westeurope"},
+		{map[string]string{"location": "europe-west4-c"}, "europe-west4"},
+		{map[string]string{"location": "eu"}, "eu"},
+		{map[string]string{}, ""},
+	} {
+		if got := RegionOf(models.Node{Metadata: tc.meta}); got != tc.want {
+			t.Errorf("RegionOf(%v) = %q, want %q", tc.meta, got, tc.want)
+		}
+	}
+}
+
+func TestGroupByRegion(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store,
+		[]models.Node{
+			regionNode("web", "google", map[string]string{"zone": "us-east1-b"}),
+			regionNode("db", "google", map[string]string{"region": "us-east1"}),
+			regionNode("cache", "google", map[string]string{"region": "europe-west1"}),
+			regionNode("queue", "aws", map[string]string{"region": "us-east-1"}),
+			regionNode("pod", "kubernetes", map[string]string{}),
+		},
+		[]models.Edge{makeEdge("web", "db", models.EdgeDependsOn)},
+	)
+
+	summary, err := GroupByRegion(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Groups != 3 || summary.EdgesAdded != 4 {
+		t.Errorf("summary = %+v, want 3 regions and 4 edges", summary)
+	}
+	regions, err := store.ListNodes(ctx, NodeFilter{Type: string(models.AssetRegion)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 3 {
+		t.Fatalf("region nodes = %d, want 3", len(regions))
+	}
+	for _, r := range regions {
+		if r.ID == RegionNodeID("us-east-1") && r.Provider != "aws" {
+			t.Errorf("us-east-1 provider = %q, want aws", r.Provider)
+		}
+	}
+	for id, region := range map[string]string{"web": "us-east1", "db": "us-east1", "cache": "europe-west1", "queue": "us-east-1"} {
+		edges, err := store.GetEdgesFrom(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, e := range edges {
+			if e.Type == models.EdgeLocatedIn {
+				if e.ToID != RegionNodeID(region) {
+					t.Errorf("%s located in %s, want %s", id, e.ToID, RegionNodeID(region))
+				}
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s has no located_in edge", id)
+		}
+	}
+	if edges, _ := store.GetEdgesFrom(ctx, "pod"); len(edges) != 0 {
+		t.Errorf("pod without a region got edges %v", edges)
+	}
+
+	// "If us-east1 goes down, what breaks?"
+	impact, err := BlastRadius(ctx, store, RegionNodeID("us-east1"), ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if impact.AffectedNodes != 2 {
+		t.Errorf("us-east1 blast radius = %d nodes, want 2", impact.AffectedNodes)
+	}
+
+	// Grouping by provider too leaves region nodes out of provider groups.
+	if _, err := GroupByProvider(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+	if edges, _ := store.GetEdgesFrom(ctx, RegionNodeID("us-east1")); len(edges) != 0 {
+		t.Errorf("region node got edges %v", edges)
+	}
+}
+
+func TestCountByRegion(t *testing.T) {
+	nodes := []models.Node{
+		regionNode("web", "google", map[string]string{"zone": "us-east1-b"}),
+		regionNode("db", "google", map[string]string{"region": "us-east1"}),
+		regionNode("queue", "aws", map[string]string{"region": "us-east-1"}),
+		regionNode("pod", "kubernetes", map[string]string{}),
+		{ID: RegionNodeID("us-east1"), Type: models.AssetRegion, Metadata: map[string]string{"region": "us-east1"}},
+	}
+	counts := CountByRegion(nodes)
+	if len(counts) != 2 {
+		t.Fatalf("regions = %+v, want 2", counts)
+	}
+	if counts[0].Region != "us-east1" || counts[0].Nodes != 2 || counts[0].ByType["vm"] != 2 {
+		t.Errorf("first = %+v, want us-east1 with 2 vms", counts[0])
+	}
+	if counts[1].Region != "us-east-1" || len(counts[1].Providers) != 1 || counts[1].Providers[0] != "aws" {
+		t.Errorf("second = %+v, want us-east-1 on aws", counts[1])
+	}
+}
@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// ImpactReport is a self-contained blast radius analysis of one node: the
+// affected assets as a tree, with totals and warnings, in a form that can
+// be saved and served as JSON.
+type ImpactReport struct {
+	NodeID      string           `json:"node_id"`
+	Type        models.AssetType `json:"type"`
+	Provider    string           `json:"provider"`
+	Source      string           `json:"source"`
+	GeneratedAt time.Time        `json:"generated_at"`
+
+	EdgeTypes []models.EdgeType `json:"edge_types,omitempty"`
+	MaxDepth  int               `json:"max_depth,omitempty"`
+	Direction Direction         `json:"direction,omitempty"` // omitted for the default, upstream
+
+	BlastRadius    int            `json:"blast_radius"` // affected nodes, excluding the root
+	AffectedByType map[string]int `json:"affected_by_type"`
+	Warnings       []string       `json:"warnings"`
+
+	// Set only by redundancy-aware analysis (see ApplyRedundancy).
+	RedundancyFactor int     `json:"redundancy_factor,omitempty"`
+	ImpactScore      float64 `json:"impact_score,omitempty"`
+
+	Tree *ImpactNode `json:"impact_tree"`
+}
+
+// NodeWarner returns a warning about an affected node, such as an
+// approaching expiry, or "" if there is nothing to report.
+type NodeWarner func(n *models.Node) string
+
+// NewImpactReport summarizes tree, the blast radius of root computed with
+// opts. warn, if non-nil, is asked about every node in the tree, root
+// included.
+func NewImpactReport(root *models.Node, tree *ImpactNode, opts ImpactOptions, warn NodeWarner) *ImpactReport {
+	r := &ImpactReport{
+		NodeID:         root.ID,
+		Type:           root.Type,
+		Provider:       root.Provider,
+		Source:         root.Source,
+		GeneratedAt:    time.Now().UTC(),
+		EdgeTypes:      opts.EdgeTypes,
+		MaxDepth:       opts.MaxDepth,
+		AffectedByType: make(map[string]int),
+		Warnings:       ImpactWarnings(tree, warn),
+		Tree:           tree,
+	}
+	if d := opts.Direction.or(DirectionUpstream); d != DirectionUpstream {
+		r.Direction = d
+	}
+	countAffected(tree, r.AffectedByType)
+	for _, n := range r.AffectedByType {
+		r.BlastRadius += n
+	}
+	if r.Warnings == nil {
+		r.Warnings = []string{}
+	}
+	return r
+}
+
+// ApplyRedundancy annotates the report's tree with per-node availability
+// and sets RedundancyFactor and ImpactScore.
+func (r *ImpactReport) ApplyRedundancy() {
+	r.ImpactScore = ApplyRedundancy(r.Tree)
+	r.RedundancyFactor = RedundancyFactor(r.Tree.Node)
+}
+
+// ImpactWarnings collects warn's warnings for every node in the tree, in
+// depth-first order.
+func ImpactWarnings(n *ImpactNode, warn NodeWarner) []string {
+	if warn == nil {
+		return nil
+	}
+	var warnings []string
+	if n.Node != nil {
+		if w := warn(n.Node); w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+	for i := range n.Children {
+		warnings = append(warnings, ImpactWarnings(&n.Children[i], warn)...)
+	}
+	return warnings
+}
+
+// countAffected counts the descendants of n by asset type.
+func countAffected(n *ImpactNode, byType map[string]int) {
+	for i := range n.Children {
+		child := &n.Children[i]
+		if child.Node != nil {
+			byType[string(child.Node.Type)]++
+		} else {
+			byType["unknown"]++
+		}
+		countAffected(child, byType)
+	}
+}
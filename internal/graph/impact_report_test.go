@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestImpactWarnings(t *testing.T) {
+	tree := &ImpactNode{
+		NodeID: "root",
+		Node:   &models.Node{ID: "root", Name: "warn"},
+		Children: []ImpactNode{
+			{NodeID: "child", Node: &models.Node{ID: "child"}},
+			{NodeID: "child2", Node: &models.Node{ID: "child2", Name: "warn"}, Children: []ImpactNode{
+				{NodeID: "grandchild", Node: &models.Node{ID: "grandchild", Name: "warn"}},
+			}},
+			{NodeID: "unresolved"},
+		},
+	}
+	warn := func(n *models.Node) string {
+		if n.Name == "warn" {
+			return n.ID + " is flagged"
+		}
+		return ""
+	}
+
+	want := []string{"root is flagged", "child2 is flagged", "grandchild is flagged"}
+	if got := ImpactWarnings(tree, warn); !slices.Equal(got, want) {
+		t.Errorf("ImpactWarnings = %q, want %q", got, want)
+	}
+	if got := ImpactWarnings(tree, nil); got != nil {
+		t.Errorf("ImpactWarnings with nil warner = %q, want nil", got)
+	}
+}
+
+func TestImpactResult_ApplyWarnings(t *testing.T) {
+	r := &ImpactResult{ImpactTree: map[string]ImpactNode{
+		"b":    {NodeID: "b", Node: &models.Node{ID: "b", Name: "warn"}},
+		"a":    {NodeID: "a", Node: &models.Node{ID: "a", Name: "warn"}},
+		"c":    {NodeID: "c", Node: &models.Node{ID: "c"}},
+		"gone": {NodeID: "gone"},
+	}}
+	warn := func(n *models.Node) string {
+		if n.Name == "warn" {
+			return n.ID + " is flagged"
+		}
+		return ""
+	}
+
+	r.ApplyWarnings(&models.Node{ID: "root", Name: "warn"}, warn)
+	want := []string{"root is flagged", "a is flagged", "b is flagged"}
+	if !slices.Equal(r.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", r.Warnings, want)
+	}
+
+	r.ApplyWarnings(nil, func(*models.Node) string { return "" })
+	if r.Warnings == nil || len(r.Warnings) != 0 {
+		t.Errorf("Warnings = %#v, want an empty list", r.Warnings)
+	}
+}
+
+func TestNewImpactReport(t *testing.T) {
+	store, engine := buildLinearGraph(t)
+	ctx := context.Background()
+
+	root, err := store.GetNode(ctx, "C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ImpactOptions{MaxDepth: 5, Direction: DirectionUpstream}
+	tree, err := engine.BlastRadiusTree(ctx, root.ID, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat, err := engine.BlastRadius(ctx, root.ID, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewImpactReport(root, tree, opts, nil)
+	if r.NodeID != root.ID || r.Type != root.Type || r.MaxDepth != 5 || r.Direction != "" {
+		t.Errorf("report header = %+v", r)
+	}
+	if r.BlastRadius != 2 || r.BlastRadius != flat.AffectedNodes {
+		t.Errorf("BlastRadius = %d, want %d", r.BlastRadius, flat.AffectedNodes)
+	}
+	for typ, n := range flat.AffectedByType {
+		if r.AffectedByType[typ] != n {
+			t.Errorf("AffectedByType[%s] = %d, want %d", typ, r.AffectedByType[typ], n)
+		}
+	}
+	if r.Warnings == nil {
+		t.Error("Warnings should be an empty list, not nil, so it encodes as []")
+	}
+
+	r.ApplyRedundancy()
+	if r.RedundancyFactor != 1 || r.ImpactScore != float64(r.BlastRadius) {
+		t.Errorf("redundancy = %d, score = %v, want 1 and %d", r.RedundancyFactor, r.ImpactScore, r.BlastRadius)
+	}
+}
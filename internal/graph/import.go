@@ -0,0 +1,52 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// ImportResult summarizes an ImportGraph run.
+type ImportResult struct {
+	Nodes        int           `json:"nodes"`
+	Edges        int           `json:"edges"`
+	SkippedEdges []models.Edge `json:"skipped_edges"` // edges whose endpoints are not in the import
+}
+
+// ImportGraph writes a snapshot read by LoadGraphData into the store. By
+// default nodes and edges are merged into the existing graph; with replace
+// the existing graph is deleted first. Edges must reference nodes included
+// in data; others are skipped and reported. Everything is written in one
+// transaction, so a failed import leaves the store unchanged.
+func ImportGraph(ctx context.Context, store *SQLiteStore, data GraphData, replace bool) (*ImportResult, error) {
+	included := make(map[string]bool, len(data.Nodes))
+	for i, n := range data.Nodes {
+		if n.ID == "" {
+			return nil, fmt.Errorf("node %d has no id", i)
+		}
+		included[n.ID] = true
+	}
+
+	result := &ImportResult{Nodes: len(data.Nodes), SkippedEdges: []models.Edge{}}
+	edges := make([]models.Edge, 0, len(data.Edges))
+	for _, e := range data.Edges {
+		if !included[e.FromID] || !included[e.ToID] {
+			result.SkippedEdges = append(result.SkippedEdges, e)
+			continue
+		}
+		edges = append(edges, e)
+	}
+	result.Edges = len(edges)
+
+	var err error
+	if replace {
+		err = store.ReplaceGraph(ctx, data.Nodes, edges)
+	} else {
+		err = store.UpsertBatch(ctx, data.Nodes, edges)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("importing graph: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestImportGraph_RoundTrip(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("A", models.AssetVM, "tf"),
+			makeNode("B", models.AssetNetwork, "tf"),
+			makeNode("C", models.AssetSubnet, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("A", "B", models.EdgeDependsOn),
+			makeEdge("B", "C", models.EdgeConnectsTo),
+		},
+	)
+
+	out, err := ExportJSON(ctx, store, ExportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data GraphData
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.ReplaceGraph(ctx, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.NodeCount(ctx); n != 0 {
+		t.Fatalf("nodes after wipe = %d, want 0", n)
+	}
+
+	result, err := ImportGraph(ctx, store, data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Nodes != 3 || result.Edges != 2 || len(result.SkippedEdges) != 0 {
+		t.Errorf("result = %+v, want 3 nodes, 2 edges, none skipped", result)
+	}
+	nodes, _ := store.NodeCount(ctx)
+	edges, _ := store.EdgeCount(ctx)
+	if nodes != 3 || edges != 2 {
+		t.Errorf("store has %d nodes, %d edges after import; want 3, 2", nodes, edges)
+	}
+	n, _ := store.GetNode(ctx, "A")
+	if n == nil || n.Type != models.AssetVM {
+		t.Errorf("node A not restored: %+v", n)
+	}
+}
+
+func TestImportGraph_MergeReplaceAndSkippedEdges(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store, []models.Node{makeNode("old", models.AssetVM, "tf")}, nil)
+
+	data := GraphData{
+		Nodes: []models.Node{
+			makeNode("X", models.AssetVM, "k8s"),
+			makeNode("Y", models.AssetService, "k8s"),
+		},
+		Edges: []models.Edge{
+			makeEdge("X", "Y", models.EdgeDependsOn),
+			makeEdge("X", "old", models.EdgeDependsOn), // "old" is not in the file
+		},
+	}
+
+	result, err := ImportGraph(ctx, store, data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Edges != 1 || len(result.SkippedEdges) != 1 || result.SkippedEdges[0].ToID != "old" {
+		t.Errorf("result = %+v, want X->old skipped", result)
+	}
+	if n, _ := store.NodeCount(ctx); n != 3 {
+		t.Errorf("nodes after merge = %d, want 3", n)
+	}
+
+	if _, err := ImportGraph(ctx, store, data, true); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := store.NodeCount(ctx); n != 2 {
+		t.Errorf("nodes after replace = %d, want 2", n)
+	}
+	if old, _ := store.GetNode(ctx, "old"); old != nil {
+		t.Error("replace should remove nodes missing from the import")
+	}
+
+	bad := GraphData{Nodes: []models.Node{{Name: "no-id"}}}
+	if _, err := ImportGraph(ctx, store, bad, true); err == nil {
+		t.Error("expected error for node without id")
+	}
+	if n, _ := store.NodeCount(ctx); n != 2 {
+		t.Errorf("failed import changed the store: %d nodes", n)
+	}
+}
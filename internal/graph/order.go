@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// TopoOrder is the graph's nodes in dependency order: every node comes after
+// the nodes it depends_on, so it is a safe creation order and, reversed, a
+// safe teardown order.
+type TopoOrder struct {
+	Nodes []models.Node `json:"nodes"`
+	// Cycles lists the node IDs of each circular dependency group, sorted.
+	Cycles [][]string `json:"cycles,omitempty"`
+	// BrokenEdges are the depends_on edges ignored to break cycles; the
+	// order does not respect them.
+	BrokenEdges []models.Edge `json:"broken_edges,omitempty"`
+}
+
+// TopoSort orders all nodes by their depends_on edges. Ties are broken by
+// node ID so the order is stable across runs. When only cyclic nodes remain,
+// one cycle member is placed early (see pickCycleBreak) and its unresolved
+// edges are reported in BrokenEdges; self-dependencies are always broken.
+func TopoSort(ctx context.Context, store Store) (*TopoOrder, error) {
+	nodes, err := store.ListNodes(ctx, NodeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	edges, err := store.ListEdges(ctx, EdgeFilter{Type: string(models.EdgeDependsOn)})
+	if err != nil {
+		return nil, err
+	}
+
+	nodeMap := make(map[string]models.Node, len(nodes))
+	for _, n := range nodes {
+		nodeMap[n.ID] = n
+	}
+
+	result := &TopoOrder{Nodes: make([]models.Node, 0, len(nodes))}
+
+	// deps holds each node's outgoing depends_on edges; dependents is the
+	// reverse index used to release nodes as their dependencies are placed.
+	// adjacency also keeps self-dependencies, for cycle detection.
+	deps := make(map[string][]models.Edge)
+	adjacency := make(map[string][]models.Edge)
+	dependents := make(map[string][]string)
+	pending := make(map[string]int, len(nodes))
+	seenEdge := make(map[[2]string]bool)
+	for _, e := range edges {
+		if _, ok := nodeMap[e.FromID]; !ok {
+			continue
+		}
+		if _, ok := nodeMap[e.ToID]; !ok {
+			continue
+		}
+		key := [2]string{e.FromID, e.ToID}
+		if seenEdge[key] {
+			continue
+		}
+		seenEdge[key] = true
+		adjacency[e.FromID] = append(adjacency[e.FromID], e)
+		if e.FromID == e.ToID {
+			result.BrokenEdges = append(result.BrokenEdges, e)
+			continue
+		}
+		deps[e.FromID] = append(deps[e.FromID], e)
+		dependents[e.ToID] = append(dependents[e.ToID], e.FromID)
+		pending[e.FromID]++
+	}
+	ids := slices.Sorted(maps.Keys(nodeMap))
+	result.Cycles = cycleGroups(findCycles(ids, adjacency))
+	component := make(map[string]int)
+	for i, cycle := range result.Cycles {
+		for _, id := range cycle {
+			if len(cycle) > 1 {
+				component[id] = i
+			}
+		}
+	}
+
+	var ready []string
+	for id := range nodeMap {
+		if pending[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	sort.Strings(ready)
+
+	placed := make(map[string]bool, len(nodes))
+	broken := make(map[[2]string]bool)
+	for len(result.Nodes) < len(nodeMap) {
+		if len(ready) == 0 {
+			id := pickCycleBreak(nodeMap, deps, component, placed, pending)
+			for _, e := range deps[id] {
+				if !placed[e.ToID] {
+					broken[[2]string{e.FromID, e.ToID}] = true
+					result.BrokenEdges = append(result.BrokenEdges, e)
+				}
+			}
+			pending[id] = 0
+			ready = []string{id}
+		}
+
+		id := ready[0]
+		ready = ready[1:]
+		placed[id] = true
+		result.Nodes = append(result.Nodes, nodeMap[id])
+
+		for _, from := range dependents[id] {
+			if placed[from] || broken[[2]string{from, id}] {
+				continue
+			}
+			pending[from]--
+			if pending[from] == 0 {
+				i := sort.SearchStrings(ready, from)
+				ready = append(ready, "")
+				copy(ready[i+1:], ready[i:])
+				ready[i] = from
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// pickCycleBreak returns the unplaced cycle member with the fewest unresolved
+// dependencies, preferring the lowest ID. Members whose unresolved
+// dependencies all lie in their own cycle group are preferred, so the edges
+// broken are cycle edges and nodes merely waiting on a cycle are not picked.
+// A group need not span every node that can reach its cycles, so when no
+// member qualifies any unplaced member is used.
+func pickCycleBreak(nodeMap map[string]models.Node, deps map[string][]models.Edge, component map[string]int, placed map[string]bool, pending map[string]int) string {
+	better := func(id, best string) bool {
+		return best == "" || pending[id] < pending[best] || (pending[id] == pending[best] && id < best)
+	}
+	best, fallback := "", ""
+	for id := range nodeMap {
+		comp, inCycle := component[id]
+		if placed[id] || !inCycle {
+			continue
+		}
+		if better(id, fallback) {
+			fallback = id
+		}
+		qualifies := true
+		for _, e := range deps[id] {
+			if c, ok := component[e.ToID]; !placed[e.ToID] && (!ok || c != comp) {
+				qualifies = false
+				break
+			}
+		}
+		if qualifies && better(id, best) {
+			best = id
+		}
+	}
+	if best == "" {
+		return fallback
+	}
+	return best
+}
+
+// cycleGroups merges cycles that share a node into circular dependency
+// groups. Each group's IDs are sorted, and groups are ordered by their
+// first ID.
+func cycleGroups(cycles [][]string) [][]string {
+	group := make(map[string]int) // node ID → index into members
+	var members []map[string]bool
+	for _, cycle := range cycles {
+		target := -1
+		for _, id := range cycle {
+			g, ok := group[id]
+			if !ok || g == target {
+				continue
+			}
+			if target == -1 {
+				target = g
+				continue
+			}
+			for m := range members[g] {
+				members[target][m] = true
+				group[m] = target
+			}
+			members[g] = nil
+		}
+		if target == -1 {
+			target = len(members)
+			members = append(members, make(map[string]bool))
+		}
+		for _, id := range cycle {
+			members[target][id] = true
+			group[id] = target
+		}
+	}
+
+	var groups [][]string
+	for _, m := range members {
+		if len(m) > 0 {
+			groups = append(groups, slices.Sorted(maps.Keys(m)))
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func orderIDs(order *TopoOrder) []string {
+	ids := make([]string, len(order.Nodes))
+	for i, n := range order.Nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestTopoSort_DAG(t *testing.T) {
+	store := newTestStore(t)
+
+	// app depends on db and cache, both depend on vpc; lb depends on app.
+	nodes := []models.Node{
+		makeNode("app", models.AssetService, "terraform"),
+		makeNode("cache", models.AssetDatabase, "terraform"),
+		makeNode("db", models.AssetDatabase, "terraform"),
+		makeNode("lb", models.AssetLoadBalancer, "terraform"),
+		makeNode("vpc", models.AssetNetwork, "terraform"),
+		makeNode("dns", models.AssetDNSRecord, "terraform"),
+	}
+	edges := []models.Edge{
+		makeEdge("app", "db", models.EdgeDependsOn),
+		makeEdge("app", "cache", models.EdgeDependsOn),
+		makeEdge("db", "vpc", models.EdgeDependsOn),
+		makeEdge("cache", "vpc", models.EdgeDependsOn),
+		makeEdge("lb", "app", models.EdgeDependsOn),
+		// Non-dependency edges don't constrain the order.
+		makeEdge("vpc", "dns", models.EdgeConnectsTo),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	order, err := TopoSort(context.Background(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"dns", "vpc", "cache", "db", "app", "lb"}
+	if got := orderIDs(order); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if len(order.Cycles) != 0 || len(order.BrokenEdges) != 0 {
+		t.Errorf("DAG should have no cycles, got %v / %v", order.Cycles, order.BrokenEdges)
+	}
+}
+
+func TestTopoSort_Cycle(t *testing.T) {
+	store := newTestStore(t)
+
+	// a -> b -> c -> a is a cycle; c also depends on base. "0waiter" depends
+	// on the cycle and must not be picked to break it despite its low ID.
+	nodes := []models.Node{
+		makeNode("a", models.AssetService, "kubernetes"),
+		makeNode("b", models.AssetService, "kubernetes"),
+		makeNode("c", models.AssetService, "kubernetes"),
+		makeNode("base", models.AssetNetwork, "kubernetes"),
+		makeNode("0waiter", models.AssetService, "kubernetes"),
+		makeNode("self", models.AssetService, "kubernetes"),
+	}
+	edges := []models.Edge{
+		makeEdge("a", "b", models.EdgeDependsOn),
+		makeEdge("b", "c", models.EdgeDependsOn),
+		makeEdge("c", "a", models.EdgeDependsOn),
+		makeEdge("c", "base", models.EdgeDependsOn),
+		makeEdge("0waiter", "a", models.EdgeDependsOn),
+		makeEdge("self", "self", models.EdgeDependsOn),
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	order, err := TopoSort(context.Background(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"base", "self", "a", "0waiter", "c", "b"}
+	if got := orderIDs(order); !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	wantCycles := [][]string{{"a", "b", "c"}, {"self"}}
+	if !reflect.DeepEqual(order.Cycles, wantCycles) {
+		t.Errorf("cycles = %v, want %v", order.Cycles, wantCycles)
+	}
+
+	broken := make(map[string]bool)
+	for _, e := range order.BrokenEdges {
+		broken[e.FromID+"->"+e.ToID] = true
+	}
+	if len(broken) != 2 || !broken["self->self"] || !broken["a->b"] {
+		t.Errorf("broken edges = %v, want self->self and a->b", broken)
+	}
+}
+
+func TestTopoSort_CycleMatchesFindCycles(t *testing.T) {
+	store := newTestStore(t)
+	// a <-> b, plus a second cycle a -> c -> b -> a that shares a and b.
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("a", models.AssetService, "kubernetes"),
+			makeNode("b", models.AssetService, "kubernetes"),
+			makeNode("c", models.AssetService, "kubernetes"),
+		},
+		[]models.Edge{
+			makeEdge("a", "b", models.EdgeDependsOn),
+			makeEdge("b", "a", models.EdgeDependsOn),
+			makeEdge("a", "c", models.EdgeDependsOn),
+			makeEdge("c", "b", models.EdgeDependsOn),
+		},
+	)
+	ctx := context.Background()
+
+	order, err := TopoSort(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := orderIDs(order); len(got) != 3 {
+		t.Fatalf("order = %v, want all 3 nodes", got)
+	}
+	cycles, err := FindCycles(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := cycleGroups(cycles); !reflect.DeepEqual(order.Cycles, want) {
+		t.Errorf("TopoSort cycles = %v, FindCycles groups = %v", order.Cycles, want)
+	}
+}
+
+func TestTopoSort_Empty(t *testing.T) {
+	order, err := TopoSort(context.Background(), newTestStore(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order.Nodes) != 0 {
+		t.Errorf("expected no nodes, got %d", len(order.Nodes))
+	}
+}
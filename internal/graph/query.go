@@ -2,6 +2,9 @@ package graph
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/matijazezelj/aib/pkg/models"
 )
@@ -13,6 +16,31 @@ type ImpactResult struct {
 	ImpactTree     map[string]ImpactNode `json:"impact_tree"`
 	AffectedByType map[string]int        `json:"affected_by_type"`
 	Nodes          []ImpactNode          `json:"nodes"` // flat list of affected nodes for easy iteration
+
+	// Set only by redundancy-aware analysis (see ApplyRedundancy).
+	RedundancyFactor int     `json:"redundancy_factor,omitempty"`
+	ImpactScore      float64 `json:"impact_score,omitempty"`
+
+	Warnings []string `json:"warnings"` // set by ApplyWarnings
+}
+
+// ApplyWarnings sets r.Warnings to warn's warnings about root, if non-nil,
+// and the affected nodes, ordered by node ID after the root's. The list is
+// empty rather than nil when there are none.
+func (r *ImpactResult) ApplyWarnings(root *models.Node, warn NodeWarner) {
+	r.Warnings = []string{}
+	if root != nil {
+		if w := warn(root); w != "" {
+			r.Warnings = append(r.Warnings, w)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(r.ImpactTree)) {
+		if n := r.ImpactTree[id].Node; n != nil {
+			if w := warn(n); w != "" {
+				r.Warnings = append(r.Warnings, w)
+			}
+		}
+	}
 }
 
 // ImpactNode represents a single node in the impact tree.
@@ -23,6 +51,43 @@ type ImpactNode struct {
 	Depth        int             `json:"depth"`
 	PathFromRoot []string        `json:"path_from_root"`
 	Children     []ImpactNode    `json:"children,omitempty"`
+	Status       string          `json:"status,omitempty"`   // "down" or "degraded" (redundancy-aware only)
+	Severity     float64         `json:"severity,omitempty"` // fraction of capacity lost (redundancy-aware only)
+}
+
+// Direction selects which way a traversal follows edges. An edge points
+// from the dependent node to its dependency.
+type Direction string
+
+const (
+	DirectionUpstream   Direction = "upstream"   // against edges: what depends on the node
+	DirectionDownstream Direction = "downstream" // along edges: what the node depends on
+	DirectionBoth       Direction = "both"       // either way, for bidirectional reachability
+)
+
+// ParseDirection validates a direction name.
+func ParseDirection(s string) (Direction, error) {
+	switch d := Direction(s); d {
+	case DirectionUpstream, DirectionDownstream, DirectionBoth:
+		return d, nil
+	}
+	return "", fmt.Errorf("invalid direction %q (valid: upstream, downstream, both)", s)
+}
+
+// or returns d, or def if d is unset.
+func (d Direction) or(def Direction) Direction {
+	if d == "" {
+		return def
+	}
+	return d
+}
+
+// ImpactOptions narrows a blast radius traversal. The zero value follows
+// every edge type upstream to any depth.
+type ImpactOptions struct {
+	EdgeTypes []models.EdgeType // if set, only edges of these types propagate impact
+	MaxDepth  int               // if > 0, stop this many hops from the start node
+	Direction Direction         // default upstream
 }
 
 // adjacency holds prebuilt edge maps and a node lookup so traversals can run
@@ -61,11 +126,36 @@ func loadAdjacency(ctx context.Context, store *SQLiteStore) (*adjacency, error)
 	}, nil
 }
 
+// hop is one step of a traversal: the node reached and the edge taken.
+type hop struct {
+	to   string
+	edge models.Edge
+}
+
+// hops returns the nodes one step from id in direction dir. Both lists
+// upstream neighbors before downstream ones.
+func (a *adjacency) hops(id string, dir Direction) []hop {
+	var out []hop
+	if dir == DirectionUpstream || dir == DirectionBoth {
+		for _, e := range a.upstream[id] {
+			out = append(out, hop{to: e.FromID, edge: e})
+		}
+	}
+	if dir == DirectionDownstream || dir == DirectionBoth {
+		for _, e := range a.downstream[id] {
+			out = append(out, hop{to: e.ToID, edge: e})
+		}
+	}
+	return out
+}
+
 // blastRadius performs a BFS traversal from the start node to find all
 // affected nodes, using only the prebuilt adjacency (no store access).
-// It traverses in reverse: finds nodes that depend ON the start node
-// (upstream edges), since if X fails, everything that depends on X is affected.
-func (a *adjacency) blastRadius(startNodeID string) *ImpactResult {
+// By default it traverses in reverse: finds nodes that depend ON the start
+// node (upstream edges), since if X fails, everything that depends on X is
+// affected. A maxDepth > 0 stops the traversal that many hops out.
+func (a *adjacency) blastRadius(startNodeID string, maxDepth int, dir Direction) *ImpactResult {
+	dir = dir.or(DirectionUpstream)
 	visited := make(map[string]bool)
 	impactTree := make(map[string]ImpactNode)
 	parentMap := make(map[string]string)
@@ -81,12 +171,14 @@ func (a *adjacency) blastRadius(startNodeID string) *ImpactResult {
 	for len(queue) > 0 {
 		current := queue[0]
 		queue = queue[1:]
+		if maxDepth > 0 && current.depth >= maxDepth {
+			continue
+		}
 
-		// upstream[nodeID] = edges where to_id == nodeID, meaning these are
-		// nodes that point TO current (i.e., they depend on current).
-		edges := a.upstream[current.nodeID]
-		for _, edge := range edges {
-			target := edge.FromID // the node that depends on current
+		// Upstream hops reach nodes that point TO current (i.e., they
+		// depend on current).
+		for _, h := range a.hops(current.nodeID, dir) {
+			target, edge := h.to, h.edge
 			if visited[target] {
 				continue
 			}
@@ -127,24 +219,55 @@ func (a *adjacency) blastRadius(startNodeID string) *ImpactResult {
 	}
 }
 
+// withEdgeTypes returns a copy of the adjacency that only contains edges of
+// the given types. With no types it returns a unchanged.
+func (a *adjacency) withEdgeTypes(edgeTypes []models.EdgeType) *adjacency {
+	if len(edgeTypes) == 0 {
+		return a
+	}
+	keep := make(map[models.EdgeType]bool, len(edgeTypes))
+	for _, t := range edgeTypes {
+		keep[t] = true
+	}
+	filter := func(in map[string][]models.Edge) map[string][]models.Edge {
+		out := make(map[string][]models.Edge, len(in))
+		for id, edges := range in {
+			for _, e := range edges {
+				if keep[e.Type] {
+					out[id] = append(out[id], e)
+				}
+			}
+		}
+		return out
+	}
+	return &adjacency{
+		downstream: filter(a.downstream),
+		upstream:   filter(a.upstream),
+		nodeByID:   a.nodeByID,
+		nodes:      a.nodes,
+	}
+}
+
 // BlastRadius performs a BFS traversal from the start node to find all affected nodes.
 // It traverses in reverse: finds nodes that depend ON the start node (upstream edges),
 // since if X fails, everything that depends on X is affected.
-func BlastRadius(ctx context.Context, store *SQLiteStore, startNodeID string) (*ImpactResult, error) {
+func BlastRadius(ctx context.Context, store *SQLiteStore, startNodeID string, opts ImpactOptions) (*ImpactResult, error) {
 	adj, err := loadAdjacency(ctx, store)
 	if err != nil {
 		return nil, err
 	}
-	return adj.blastRadius(startNodeID), nil
+	return adj.withEdgeTypes(opts.EdgeTypes).blastRadius(startNodeID, opts.MaxDepth, opts.Direction), nil
 }
 
 // BlastRadiusTree returns the impact result as a tree structure rooted at the start node.
-// Traverses upstream: finds all nodes that depend on the start node.
-func BlastRadiusTree(ctx context.Context, store *SQLiteStore, startNodeID string) (*ImpactNode, error) {
+// Traverses upstream by default: finds all nodes that depend on the start node.
+func BlastRadiusTree(ctx context.Context, store *SQLiteStore, startNodeID string, opts ImpactOptions) (*ImpactNode, error) {
 	adj, err := loadAdjacency(ctx, store)
 	if err != nil {
 		return nil, err
 	}
+	adj = adj.withEdgeTypes(opts.EdgeTypes)
+	dir := opts.Direction.or(DirectionUpstream)
 
 	visited := make(map[string]bool)
 	root := &ImpactNode{
@@ -153,20 +276,38 @@ func BlastRadiusTree(ctx context.Context, store *SQLiteStore, startNodeID string
 		Depth:  0,
 	}
 
+	var depths map[string]int
+	if opts.MaxDepth > 0 {
+		depths = shortestDepths(startNodeID, opts.MaxDepth, func(id string) []string {
+			var next []string
+			for _, h := range adj.hops(id, dir) {
+				next = append(next, h.to)
+			}
+			return next
+		})
+	}
+
 	visited[startNodeID] = true
-	adj.buildTree(root, visited, 0)
+	adj.buildTree(root, visited, 0, depths, dir)
 
 	return root, nil
 }
 
-func (a *adjacency) buildTree(parent *ImpactNode, visited map[string]bool, depth int) {
-	// upstream[nodeID] = edges where to_id == nodeID (nodes that point to this one)
-	edges := a.upstream[parent.NodeID]
-	for _, edge := range edges {
-		target := edge.FromID // the node that depends on parent
+// buildTree attaches everything reachable from parent in direction dir as
+// its children. If depths is non-nil, a node is only attached at its
+// shortest depth, so nodes past the depth cap (absent from depths) are left
+// out and a long path can't claim a node before a short one.
+func (a *adjacency) buildTree(parent *ImpactNode, visited map[string]bool, depth int, depths map[string]int, dir Direction) {
+	for _, h := range a.hops(parent.NodeID, dir) {
+		target, edge := h.to, h.edge
 		if visited[target] {
 			continue
 		}
+		if depths != nil {
+			if d, ok := depths[target]; !ok || d != depth+1 {
+				continue
+			}
+		}
 		visited[target] = true
 
 		child := ImpactNode{
@@ -176,11 +317,32 @@ func (a *adjacency) buildTree(parent *ImpactNode, visited map[string]bool, depth
 			Depth:    depth + 1,
 		}
 
-		a.buildTree(&child, visited, depth+1)
+		a.buildTree(&child, visited, depth+1, depths, dir)
 		parent.Children = append(parent.Children, child)
 	}
 }
 
+// shortestDepths returns the hop count from start to every node reachable
+// through next within maxDepth hops, start included at 0.
+func shortestDepths(start string, maxDepth int, next func(id string) []string) map[string]int {
+	depths := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if depths[id] >= maxDepth {
+			continue
+		}
+		for _, p := range next(id) {
+			if _, seen := depths[p]; !seen {
+				depths[p] = depths[id] + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+	return depths
+}
+
 func reconstructPath(parentMap map[string]string, start, end string) []string {
 	path := []string{end}
 	current := end
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// EntryPoints returns the nodes nothing depends on: they have outgoing
+// edges but no incoming ones. These are typically ingresses, DNS records,
+// load balancers and other ways in from outside, so they outline the
+// external attack surface.
+//
+// Grouping nodes and their edges are ignored, and so are orphans, which
+// have no edges at all (see FindOrphanNodes).
+func EntryPoints(ctx context.Context, store Store) ([]models.Node, error) {
+	return nodesByDegree(ctx, store, func(in, out int) bool { return in == 0 && out > 0 })
+}
+
+// Sinks returns the nodes that depend on nothing: they have incoming
+// edges but no outgoing ones, such as databases and other leaf
+// dependencies. Grouping nodes, their edges and orphans are ignored.
+func Sinks(ctx context.Context, store Store) ([]models.Node, error) {
+	return nodesByDegree(ctx, store, func(in, out int) bool { return in > 0 && out == 0 })
+}
+
+// nodesByDegree returns the non-grouping nodes whose incoming and outgoing
+// edge counts satisfy keep, in ListNodes order.
+func nodesByDegree(ctx context.Context, store Store, keep func(in, out int) bool) ([]models.Node, error) {
+	nodes, err := store.ListNodes(ctx, NodeFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+	edges, err := store.ListEdges(ctx, EdgeFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("listing edges: %w", err)
+	}
+
+	grouping := make(map[string]bool)
+	for _, n := range nodes {
+		if isGroupingType(n.Type) {
+			grouping[n.ID] = true
+		}
+	}
+	in := make(map[string]int)
+	out := make(map[string]int)
+	for _, e := range edges {
+		if grouping[e.FromID] || grouping[e.ToID] {
+			continue
+		}
+		out[e.FromID]++
+		in[e.ToID]++
+	}
+
+	var result []models.Node
+	for _, n := range nodes {
+		if !grouping[n.ID] && keep(in[n.ID], out[n.ID]) {
+			result = append(result, n)
+		}
+	}
+	return result, nil
+}
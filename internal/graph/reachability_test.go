@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func nodeIDs(nodes []models.Node) []string {
+	ids := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestEntryPointsAndSinks(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	// dns -> lb -> svc -> db, svc -> cache, and a second way in through an
+	// ingress. Every asset is a member of a provider grouping node.
+	nodes := []models.Node{
+		makeNode("dns", models.AssetDNSRecord, "terraform"),
+		makeNode("ingress", models.AssetIngress, "kubernetes"),
+		makeNode("lb", models.AssetLoadBalancer, "terraform"),
+		makeNode("svc", models.AssetService, "kubernetes"),
+		makeNode("db", models.AssetDatabase, "terraform"),
+		makeNode("cache", models.AssetDatabase, "terraform"),
+		makeNode("orphan", models.AssetVM, "terraform"),
+		makeNode(ProviderNodeID("test"), models.AssetProvider, "aib"),
+	}
+	edges := []models.Edge{
+		makeEdge("dns", "lb", models.EdgeResolvesTo),
+		makeEdge("lb", "svc", models.EdgeRoutesTo),
+		makeEdge("ingress", "svc", models.EdgeRoutesTo),
+		makeEdge("svc", "db", models.EdgeDependsOn),
+		makeEdge("svc", "cache", models.EdgeDependsOn),
+	}
+	for _, n := range nodes[:7] {
+		edges = append(edges, makeEdge(n.ID, ProviderNodeID("test"), models.EdgeMemberOf))
+	}
+	buildTestGraph(t, store, nodes, edges)
+
+	entries, err := EntryPoints(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodeIDs(entries), []string{"dns", "ingress"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EntryPoints = %v, want %v", got, want)
+	}
+
+	sinks, err := Sinks(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nodeIDs(sinks), []string{"cache", "db"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sinks = %v, want %v", got, want)
+	}
+}
+
+func TestEntryPointsAndSinks_Empty(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store, []models.Node{makeNode("orphan", models.AssetVM, "terraform")}, nil)
+
+	entries, err := EntryPoints(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sinks, err := Sinks(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 || len(sinks) != 0 {
+		t.Errorf("orphans are neither entry points nor sinks: %v, %v", nodeIDs(entries), nodeIDs(sinks))
+	}
+}
@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"strconv"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// Impact status values assigned by redundancy-aware analysis.
+const (
+	ImpactDown     = "down"     // the node loses its dependency entirely
+	ImpactDegraded = "degraded" // the node loses part of its capacity
+)
+
+// RedundancyFactor returns how many interchangeable instances back a node.
+// It reads an explicit "redundancy_factor" metadata value first, then the
+// workload "replicas" count, then an autoscaler's "min_replicas". Nodes
+// without any of these are treated as a single instance (factor 1).
+func RedundancyFactor(n *models.Node) int {
+	if n == nil {
+		return 1
+	}
+	for _, key := range []string{"redundancy_factor", "replicas", "min_replicas"} {
+		if v, ok := n.Metadata[key]; ok {
+			if f, err := strconv.Atoi(v); err == nil && f > 0 {
+				return f
+			}
+		}
+	}
+	return 1
+}
+
+// failureSeverity is the fraction of capacity lost when one instance of n
+// fails: 1.0 for a single instance, 1/k for k redundant instances.
+func failureSeverity(n *models.Node) float64 {
+	return 1 / float64(RedundancyFactor(n))
+}
+
+func statusForSeverity(severity float64) string {
+	if severity >= 1 {
+		return ImpactDown
+	}
+	return ImpactDegraded
+}
+
+// ApplyRedundancy annotates a blast radius tree with per-node availability.
+// The root's redundancy factor determines how much of its capacity is lost;
+// every affected node inherits that severity, so losing one replica of a
+// three-replica deployment degrades dependents instead of taking them down.
+// It returns the impact score: the sum of severities over affected nodes,
+// which equals the plain blast radius when nothing is redundant.
+func ApplyRedundancy(root *ImpactNode) float64 {
+	severity := failureSeverity(root.Node)
+	root.Status = statusForSeverity(severity)
+	root.Severity = severity
+	return annotateChildren(root, severity)
+}
+
+func annotateChildren(parent *ImpactNode, severity float64) float64 {
+	score := 0.0
+	for i := range parent.Children {
+		child := &parent.Children[i]
+		child.Status = statusForSeverity(severity)
+		child.Severity = severity
+		score += severity + annotateChildren(child, severity)
+	}
+	return score
+}
+
+// ApplyRedundancy annotates each affected node in the flat result with its
+// availability status and sets ImpactScore and RedundancyFactor on r.
+// See the tree variant for the scoring model.
+func (r *ImpactResult) ApplyRedundancy(root *models.Node) {
+	r.RedundancyFactor = RedundancyFactor(root)
+	severity := failureSeverity(root)
+	status := statusForSeverity(severity)
+
+	score := 0.0
+	for id, n := range r.ImpactTree {
+		n.Status = status
+		n.Severity = severity
+		r.ImpactTree[id] = n
+		score += severity
+	}
+	for i := range r.Nodes {
+		r.Nodes[i].Status = status
+		r.Nodes[i].Severity = severity
+	}
+	r.ImpactScore = score
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestRedundancyFactor(t *testing.T) {
+	tests := []struct {
+		name string
+		meta map[string]string
+		want int
+	}{
+		{"no metadata", map[string]string{}, 1},
+		{"replicas", map[string]string{"replicas": "3"}, 3},
+		{"min replicas", map[string]string{"min_replicas": "2"}, 2},
+		{"explicit override wins", map[string]string{"redundancy_factor": "5", "replicas": "3"}, 5},
+		{"zero replicas", map[string]string{"replicas": "0"}, 1},
+		{"garbage", map[string]string{"replicas": "many"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &models.Node{Metadata: tt.meta}
+			if got := RedundancyFactor(n); got != tt.want {
+				t.Errorf("RedundancyFactor = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	if got := RedundancyFactor(nil); got != 1 {
+		t.Errorf("RedundancyFactor(nil) = %d, want 1", got)
+	}
+}
+
+func TestApplyRedundancy_Tree(t *testing.T) {
+	store := newTestStore(t)
+	deploy := makeNode("deploy", models.AssetPod, "k8s")
+	deploy.Metadata["replicas"] = "4"
+	buildTestGraph(t, store,
+		[]models.Node{deploy, makeNode("svc", models.AssetService, "k8s"), makeNode("ing", models.AssetIngress, "k8s")},
+		[]models.Edge{
+			makeEdge("svc", "deploy", models.EdgeRoutesTo),
+			makeEdge("ing", "svc", models.EdgeRoutesTo),
+		},
+	)
+
+	tree, err := NewLocalEngine(store).BlastRadiusTree(context.Background(), "deploy", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	score := ApplyRedundancy(tree)
+	if score != 0.5 {
+		t.Errorf("score = %v, want 0.5 (2 nodes at 1/4 severity)", score)
+	}
+	if tree.Status != ImpactDegraded {
+		t.Errorf("root status = %q, want degraded", tree.Status)
+	}
+	svc := tree.Children[0]
+	if svc.Status != ImpactDegraded || svc.Children[0].Status != ImpactDegraded {
+		t.Errorf("dependents should be degraded, got %q / %q", svc.Status, svc.Children[0].Status)
+	}
+}
+
+func TestApplyRedundancy_SingleInstanceIsDown(t *testing.T) {
+	_, engine := buildLinearGraph(t)
+	ctx := context.Background()
+
+	result, err := engine.BlastRadius(ctx, "C", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := makeNode("C", models.AssetSubnet, "tf")
+	result.ApplyRedundancy(&root)
+
+	if result.RedundancyFactor != 1 {
+		t.Errorf("RedundancyFactor = %d, want 1", result.RedundancyFactor)
+	}
+	if result.ImpactScore != float64(result.AffectedNodes) {
+		t.Errorf("ImpactScore = %v, want %d", result.ImpactScore, result.AffectedNodes)
+	}
+	for id, n := range result.ImpactTree {
+		if n.Status != ImpactDown {
+			t.Errorf("%s status = %q, want down", id, n.Status)
+		}
+	}
+}
+
+func TestApplyRedundancy_DependentReplicasDoNotShield(t *testing.T) {
+	store := newTestStore(t)
+	// db (single) <- api (3 replicas) <- ing, and db <- cron (single).
+	api := makeNode("api", models.AssetPod, "k8s")
+	api.Metadata["replicas"] = "3"
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("db", models.AssetDatabase, "tf"), api, makeNode("ing", models.AssetIngress, "k8s"), makeNode("cron", models.AssetPod, "k8s")},
+		[]models.Edge{
+			makeEdge("api", "db", models.EdgeDependsOn),
+			makeEdge("ing", "api", models.EdgeRoutesTo),
+			makeEdge("cron", "db", models.EdgeDependsOn),
+		},
+	)
+	engine := NewLocalEngine(store)
+	ctx := context.Background()
+	// Every api replica needs the single db, so its replicas are no protection.
+	want := map[string]string{"api": ImpactDown, "ing": ImpactDown, "cron": ImpactDown}
+
+	tree, err := engine.BlastRadiusTree(ctx, "db", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ApplyRedundancy(tree)
+	if tree.Status != ImpactDown {
+		t.Errorf("root status = %q, want down", tree.Status)
+	}
+	var walk func(n *ImpactNode)
+	walk = func(n *ImpactNode) {
+		for i := range n.Children {
+			c := &n.Children[i]
+			if c.Status != want[c.NodeID] {
+				t.Errorf("tree: %s status = %q, want %q", c.NodeID, c.Status, want[c.NodeID])
+			}
+			walk(c)
+		}
+	}
+	walk(tree)
+
+	result, err := engine.BlastRadius(ctx, "db", ImpactOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := makeNode("db", models.AssetDatabase, "tf")
+	result.ApplyRedundancy(&root)
+	for id, n := range result.ImpactTree {
+		if n.Status != want[id] {
+			t.Errorf("flat: %s status = %q, want %q", id, n.Status, want[id])
+		}
+	}
+	for _, n := range result.Nodes {
+		if n.Status != want[n.NodeID] {
+			t.Errorf("flat nodes: %s status = %q, want %q", n.NodeID, n.Status, want[n.NodeID])
+		}
+	}
+	if result.ImpactScore != 3 {
+		t.Errorf("ImpactScore = %v, want 3", result.ImpactScore)
+	}
+}
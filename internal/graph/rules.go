@@ -0,0 +1,234 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// EdgeRuleKey is the edge metadata key naming the rule that inferred an
+// edge. ApplyRules owns every edge carrying it.
+const EdgeRuleKey = "edge_rule"
+
+// maxEdgesPerRule stops a rule with too broad a key, such as type with no
+// From or To, from adding an edge between every pair of nodes.
+const maxEdgesPerRule = 10000
+
+// edgeTypePattern is what the edge type of a rule must look like.
+var edgeTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// EdgeRule infers edges from node fields: it adds an Edge from every node
+// matching From to every other node matching To whose ToKey field has the
+// same, non-empty value as the from node's Key field. From and To are
+// filter expressions (see ParseFilterExpr); empty matches every node. Key
+// and ToKey are node fields or metadata.<key>; ToKey defaults to Key.
+//
+// When From equals To and ToKey equals Key the rule is symmetric, e.g.
+// "link nodes sharing metadata.tag:app", and each pair gets one edge, from
+// the lower node ID to the higher.
+type EdgeRule struct {
+	Name  string
+	From  string
+	To    string
+	Key   string
+	ToKey string
+	Edge  models.EdgeType
+}
+
+// RuleSummary describes an ApplyRules run.
+type RuleSummary struct {
+	Rules        int `json:"rules"`
+	EdgesAdded   int `json:"edges_added"`
+	EdgesRemoved int `json:"edges_removed"`
+}
+
+// compiledRule is an EdgeRule with its expressions parsed.
+type compiledRule struct {
+	EdgeRule
+	from, to *FilterExpr
+}
+
+// ValidateEdgeRules checks that rules can be applied: names are set and
+// unique, expressions parse, keys name node fields and edge types are
+// lowercase identifiers.
+func ValidateEdgeRules(rules []EdgeRule) error {
+	_, err := compileRules(rules)
+	return err
+}
+
+func compileRules(rules []EdgeRule) ([]compiledRule, error) {
+	seen := make(map[string]bool, len(rules))
+	out := make([]compiledRule, len(rules))
+	for i, r := range rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("rule %d: name is required", i)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("rule %q: duplicate name", r.Name)
+		}
+		seen[r.Name] = true
+		if r.ToKey == "" {
+			r.ToKey = r.Key
+		}
+		for _, key := range []string{r.Key, r.ToKey} {
+			if !validNodeKey(key) {
+				return nil, fmt.Errorf("rule %q: invalid key %q (valid: %s, metadata.<key>)", r.Name, key, strings.Join(filterFieldNames(), ", "))
+			}
+		}
+		if !edgeTypePattern.MatchString(string(r.Edge)) {
+			return nil, fmt.Errorf("rule %q: invalid edge type %q", r.Name, r.Edge)
+		}
+		c := compiledRule{EdgeRule: r}
+		var err error
+		if r.From != "" {
+			if c.from, err = ParseFilterExpr(r.From); err != nil {
+				return nil, fmt.Errorf("rule %q: from: %w", r.Name, err)
+			}
+		}
+		if r.To != "" {
+			if c.to, err = ParseFilterExpr(r.To); err != nil {
+				return nil, fmt.Errorf("rule %q: to: %w", r.Name, err)
+			}
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+func validNodeKey(key string) bool {
+	if k, ok := strings.CutPrefix(key, metadataPrefix); ok {
+		return k != ""
+	}
+	_, ok := filterColumns[key]
+	return ok
+}
+
+// nodeField returns the value of key, a node field or metadata.<key>, on n.
+func nodeField(n models.Node, key string) string {
+	if k, ok := strings.CutPrefix(key, metadataPrefix); ok {
+		return n.Metadata[k]
+	}
+	switch key {
+	case "id":
+		return n.ID
+	case "name":
+		return n.Name
+	case "type":
+		return string(n.Type)
+	case "source":
+		return n.Source
+	case "source_file":
+		return n.SourceFile
+	case "provider":
+		return n.Provider
+	}
+	return ""
+}
+
+// ApplyRules adds the edges rules infer and removes edges inferred earlier
+// that no rule produces any more, so edges follow metadata changes and
+// rules dropped from the config. Inferred edges carry the rule's name
+// under EdgeRuleKey; an edge a parser already created is left alone.
+func ApplyRules(ctx context.Context, store *SQLiteStore, rules []EdgeRule) (*RuleSummary, error) {
+	compiled, err := compileRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := store.AllEdges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inferred := make(map[string]bool)
+	parsed := make(map[string]bool)
+	for _, e := range all {
+		if e.Metadata[EdgeRuleKey] != "" {
+			inferred[e.ID] = true
+		} else {
+			parsed[e.ID] = true
+		}
+	}
+
+	wanted := make(map[string]bool)
+	var added []models.Edge
+	for _, r := range compiled {
+		edges, err := r.infer(ctx, store)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range edges {
+			if parsed[e.ID] || wanted[e.ID] {
+				continue
+			}
+			wanted[e.ID] = true
+			if !inferred[e.ID] {
+				added = append(added, e)
+			}
+		}
+	}
+	if err := store.UpsertEdges(ctx, added); err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for id := range inferred {
+		if !wanted[id] {
+			stale = append(stale, id)
+		}
+	}
+	sort.Strings(stale)
+	removed, err := store.deleteEdges(ctx, stale)
+	if err != nil {
+		return nil, err
+	}
+	return &RuleSummary{Rules: len(compiled), EdgesAdded: len(added), EdgesRemoved: removed}, nil
+}
+
+// infer returns the edges r produces over the nodes in store.
+func (r compiledRule) infer(ctx context.Context, store *SQLiteStore) ([]models.Edge, error) {
+	from, err := store.ListNodes(ctx, NodeFilter{Expr: r.from})
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+	}
+	to := from
+	if r.To != r.From {
+		if to, err = store.ListNodes(ctx, NodeFilter{Expr: r.to}); err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.Name, err)
+		}
+	}
+	symmetric := r.From == r.To && r.Key == r.ToKey
+
+	targets := make(map[string][]string)
+	for _, n := range to {
+		if v := nodeField(n, r.ToKey); v != "" {
+			targets[v] = append(targets[v], n.ID)
+		}
+	}
+	var edges []models.Edge
+	for _, n := range from {
+		v := nodeField(n, r.Key)
+		if v == "" {
+			continue
+		}
+		for _, toID := range targets[v] {
+			if toID == n.ID || (symmetric && toID < n.ID) {
+				continue
+			}
+			if len(edges) == maxEdgesPerRule {
+				return nil, fmt.Errorf("rule %q: infers more than %d edges; narrow it with from or to", r.Name, maxEdgesPerRule)
+			}
+			edges = append(edges, models.Edge{
+				ID:       GenerateEdgeID(n.ID, toID, r.Edge),
+				FromID:   n.ID,
+				ToID:     toID,
+				Type:     r.Edge,
+				Metadata: map[string]string{EdgeRuleKey: r.Name},
+			})
+		}
+	}
+	return edges, nil
+}
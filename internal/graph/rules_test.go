@@ -0,0 +1,166 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func taggedNode(id, app string) models.Node {
+	n := makeNode(id, models.AssetVM, "terraform")
+	if app != "" {
+		n.Metadata = map[string]string{"tag:app": app}
+	}
+	return n
+}
+
+func ruleEdges(t *testing.T, store *SQLiteStore) map[string]models.Edge {
+	t.Helper()
+	edges, err := store.AllEdges(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]models.Edge{}
+	for _, e := range edges {
+		if e.Metadata[EdgeRuleKey] != "" {
+			out[e.FromID+"->"+e.ToID] = e
+		}
+	}
+	return out
+}
+
+func TestApplyRules_SharedTag(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store,
+		[]models.Node{
+			taggedNode("a", "shop"), taggedNode("b", "shop"), taggedNode("c", "shop"),
+			taggedNode("d", "blog"), taggedNode("e", ""),
+		},
+		nil,
+	)
+	rules := []EdgeRule{{Name: "app-peers", Key: "metadata.tag:app", Edge: models.EdgeConnectsTo}}
+
+	summary, err := ApplyRules(ctx, store, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EdgesAdded != 3 {
+		t.Errorf("edges added = %d, want 3", summary.EdgesAdded)
+	}
+	edges := ruleEdges(t, store)
+	for _, pair := range []string{"a->b", "a->c", "b->c"} {
+		e, ok := edges[pair]
+		if !ok {
+			t.Errorf("missing edge %s", pair)
+			continue
+		}
+		if e.Type != models.EdgeConnectsTo || e.Metadata[EdgeRuleKey] != "app-peers" {
+			t.Errorf("edge %s = %+v", pair, e)
+		}
+	}
+	if len(edges) != 3 {
+		t.Errorf("edges = %v, want one per shop pair", edges)
+	}
+
+	summary, err = ApplyRules(ctx, store, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EdgesAdded != 0 || summary.EdgesRemoved != 0 {
+		t.Errorf("second run = %+v, want no changes", summary)
+	}
+
+	// c moves to the blog: its shop edges go and it pairs with d.
+	if err := store.UpsertNode(ctx, taggedNode("c", "blog")); err != nil {
+		t.Fatal(err)
+	}
+	summary, err = ApplyRules(ctx, store, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.EdgesAdded != 1 || summary.EdgesRemoved != 2 {
+		t.Errorf("after retag = %+v, want 1 added and 2 removed", summary)
+	}
+	if _, ok := ruleEdges(t, store)["c->d"]; !ok {
+		t.Error("missing edge c->d")
+	}
+
+	// Dropping the rule removes what it inferred.
+	if _, err := ApplyRules(ctx, store, nil); err != nil {
+		t.Fatal(err)
+	}
+	if edges := ruleEdges(t, store); len(edges) != 0 {
+		t.Errorf("edges left after dropping the rule: %v", edges)
+	}
+}
+
+func TestApplyRules_FromTo(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	pod := func(id, cluster string) models.Node {
+		n := makeNode(id, models.AssetPod, "kubernetes")
+		n.Metadata = map[string]string{"tag:cluster": cluster}
+		return n
+	}
+	buildTestGraph(t, store,
+		[]models.Node{
+			pod("web", "prod"), pod("api", "prod"), pod("dev-web", "dev"),
+			makeNode("prod", models.AssetNode, "terraform"),
+		},
+		[]models.Edge{makeEdge("web", "prod", models.EdgeMemberOf)},
+	)
+	rules := []EdgeRule{{
+		Name: "cluster-members", To: "type=node",
+		Key: "metadata.tag:cluster", ToKey: "name", Edge: models.EdgeMemberOf,
+	}}
+
+	summary, err := ApplyRules(ctx, store, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// web->prod already came from a parser; only api->prod is new.
+	if summary.EdgesAdded != 1 {
+		t.Errorf("edges added = %d, want 1", summary.EdgesAdded)
+	}
+	if _, ok := ruleEdges(t, store)["api->prod"]; !ok {
+		t.Error("missing edge api->prod")
+	}
+
+	if _, err := ApplyRules(ctx, store, nil); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := store.GetEdgesFrom(ctx, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || parsed[0].Metadata[EdgeRuleKey] != "" {
+		t.Errorf("parser edge was touched: %v", parsed)
+	}
+}
+
+func TestValidateEdgeRules(t *testing.T) {
+	valid := EdgeRule{Name: "r", Key: "metadata.app", Edge: models.EdgeConnectsTo}
+	if err := ValidateEdgeRules([]EdgeRule{valid}); err != nil {
+		t.Fatalf("valid rule: %v", err)
+	}
+	for _, tc := range []struct {
+		rule EdgeRule
+		want string
+	}{
+		{EdgeRule{Key: "name", Edge: "connects_to"}, "name is required"},
+		{EdgeRule{Name: "r", Key: "colour", Edge: "connects_to"}, "invalid key"},
+		{EdgeRule{Name: "r", Key: "metadata.", Edge: "connects_to"}, "invalid key"},
+		{EdgeRule{Name: "r", Key: "name", Edge: "Connects To"}, "invalid edge type"},
+		{EdgeRule{Name: "r", Key: "name", Edge: "connects_to", From: "type="}, "from:"},
+	} {
+		if err := ValidateEdgeRules([]EdgeRule{tc.rule}); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%+v: err = %v, want %q", tc.rule, err, tc.want)
+		}
+	}
+	if err := ValidateEdgeRules([]EdgeRule{valid, valid}); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Errorf("duplicate names: err = %v", err)
+	}
+}
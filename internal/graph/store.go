@@ -2,6 +2,9 @@ package graph
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/matijazezelj/aib/pkg/models"
@@ -57,6 +60,9 @@ type Store interface {
 	// ListScans returns recent scan records.
 	ListScans(ctx context.Context, limit int) ([]Scan, error)
 
+	// GetScan returns a scan record by ID, or nil if not found.
+	GetScan(ctx context.Context, id int64) (*Scan, error)
+
 	// FindOrphanNodes returns nodes that have no edges (neither incoming nor outgoing).
 	FindOrphanNodes(ctx context.Context) ([]models.Node, error)
 
@@ -67,12 +73,53 @@ type Store interface {
 	GetDiff(ctx context.Context, scanID int64) (*DriftSummary, error)
 }
 
+// ErrNodeNotFound is returned by writes that need an existing node.
+var ErrNodeNotFound = errors.New("node not found")
+
 // NodeFilter specifies criteria for listing nodes.
 type NodeFilter struct {
 	Type      string
 	Source    string
 	Provider  string
-	StaleDays int // if > 0, filter nodes with last_seen older than N days ago
+	Status    string // if set, filter nodes by tf_status metadata (e.g. "tainted", "deposed")
+	StaleDays int    // if > 0, filter nodes with last_seen older than N days ago
+
+	MetadataKey   string // if set, only nodes with this metadata key
+	MetadataValue string // if set with MetadataKey, the key must have this value
+
+	TagKey   string // if set, only nodes with this tag
+	TagValue string // if set with TagKey, the tag must have this value
+
+	FirstSeenBefore time.Time // if non-zero, filter nodes first seen before this time
+	UpdatedSince    time.Time // if non-zero, filter nodes written at or after this time
+	ExcludeSources  []string  // nodes from these sources are never returned
+
+	Expr *FilterExpr // if set, only nodes matching this expression (see ParseFilterExpr)
+
+	Limit   int    // if > 0, return at most this many nodes
+	Offset  int    // skip this many nodes (applied with Limit)
+	OrderBy string // a NodeOrderColumns key, "-" prefixed for descending; default type, name
+}
+
+// NodeOrderColumns maps the sort keys accepted in NodeFilter.OrderBy to
+// their columns.
+var NodeOrderColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"type":       "type",
+	"source":     "source",
+	"provider":   "provider",
+	"last_seen":  "last_seen",
+	"first_seen": "first_seen",
+}
+
+// ValidNodeOrder reports whether order is accepted as NodeFilter.OrderBy.
+func ValidNodeOrder(order string) bool {
+	if order == "" {
+		return true
+	}
+	_, ok := NodeOrderColumns[strings.TrimPrefix(order, "-")]
+	return ok
 }
 
 // EdgeFilter specifies criteria for listing edges.
@@ -80,6 +127,8 @@ type EdgeFilter struct {
 	Type   string
 	FromID string
 	ToID   string
+
+	UpdatedSince time.Time // if non-zero, only edges written at or after this time
 }
 
 // Scan represents a scan operation record.
@@ -92,4 +141,23 @@ type Scan struct {
 	NodesFound int        `json:"nodes_found"`
 	EdgesFound int        `json:"edges_found"`
 	Status     string     `json:"status"`
+
+	// Request is the JSON-encoded scanner request that produced this scan,
+	// kept so the scan can be replayed. Empty for scans recorded before it
+	// was stored.
+	Request json.RawMessage `json:"request,omitempty"`
+}
+
+// NodeVersion is a recorded state of a node. A version is written each time
+// an upsert changes the node's name, type, source, provider, metadata or
+// expiry, when node history is enabled.
+type NodeVersion struct {
+	NodeID     string            `json:"node_id"`
+	Name       string            `json:"name"`
+	Type       models.AssetType  `json:"type"`
+	Source     string            `json:"source"`
+	Provider   string            `json:"provider"`
+	Metadata   map[string]string `json:"metadata"`
+	ExpiresAt  *time.Time        `json:"expires_at,omitempty"`
+	RecordedAt time.Time         `json:"recorded_at"`
 }
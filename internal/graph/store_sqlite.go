@@ -14,7 +14,9 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-const schema = `
+// baseSchema is the original schema, migration 1. Later changes are
+// appended to migrations rather than edited in here.
+const baseSchema = `
 CREATE TABLE IF NOT EXISTS nodes (
     id          TEXT PRIMARY KEY,
     name        TEXT NOT NULL,
@@ -62,9 +64,88 @@ CREATE TABLE IF NOT EXISTS scan_diffs (
 );
 `
 
+// migration is one step in the schema's history. Steps must be idempotent:
+// databases created before schema_version existed start from version 0 and
+// replay every step over tables that may already have the change.
+type migration struct {
+	name string
+	up   func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations is applied in order by Init; a database at schema version n
+// has had the first n applied. Only ever append.
+var migrations = []migration{
+	{"base schema", execMigration(baseSchema)},
+	{"scan requests", func(ctx context.Context, tx *sql.Tx) error {
+		return addColumnIfMissing(ctx, tx, "scans", "request", "TEXT")
+	}},
+	{"node history", execMigration(`
+CREATE TABLE IF NOT EXISTS node_history (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    node_id     TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    type        TEXT NOT NULL,
+    source      TEXT NOT NULL,
+    provider    TEXT,
+    metadata    TEXT,
+    expires_at  DATETIME,
+    recorded_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_history_node ON node_history(node_id, id);
+`)},
+	{"alert log", execMigration(`
+CREATE TABLE IF NOT EXISTS alert_log (
+    key     TEXT PRIMARY KEY,
+    sent_at DATETIME NOT NULL
+);
+`)},
+	{"edge weights", func(ctx context.Context, tx *sql.Tx) error {
+		return addColumnIfMissing(ctx, tx, "edges", "weight", "REAL")
+	}},
+	{"node tags", execMigration(`
+CREATE TABLE IF NOT EXISTS node_tags (
+    node_id    TEXT NOT NULL REFERENCES nodes(id) ON DELETE CASCADE,
+    key        TEXT NOT NULL,
+    value      TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (node_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_node_tags_key ON node_tags(key, value);
+`)},
+	{"sync state", func(ctx context.Context, tx *sql.Tx) error {
+		if err := addColumnIfMissing(ctx, tx, "nodes", "updated_at", "DATETIME"); err != nil {
+			return err
+		}
+		if err := addColumnIfMissing(ctx, tx, "edges", "updated_at", "DATETIME"); err != nil {
+			return err
+		}
+		return execMigration(`
+CREATE TABLE IF NOT EXISTS sync_state (
+    name      TEXT PRIMARY KEY,
+    synced_at DATETIME NOT NULL
+);
+`)(ctx, tx)
+	}},
+}
+
+// sqlNow is the current UTC time in SQL, in the RFC 3339 form used for
+// stored timestamps. updated_at columns are set from it on every write.
+const sqlNow = `strftime('%Y-%m-%dT%H:%M:%SZ', 'now')`
+
+func execMigration(stmts string) func(context.Context, *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, stmts)
+		return err
+	}
+}
+
 // SQLiteStore implements Store using SQLite.
 type SQLiteStore struct {
-	db *sql.DB
+	db      *sql.DB
+	history bool
+	changes changeHub
 }
 
 // NewSQLiteStore creates a new SQLite-backed store.
@@ -81,10 +162,88 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 	return &SQLiteStore{db: db}, nil
 }
 
-// Init creates the database schema if it doesn't exist.
+// Init creates the database schema if it doesn't exist and brings
+// databases created by older versions up to date, applying each pending
+// migration in its own transaction.
 func (s *SQLiteStore) Init(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, schema)
-	return err
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("creating schema_version: %w", err)
+	}
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this aib supports (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		if err := s.migrate(ctx, i+1, migrations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrate applies m and records the database as being at version.
+func (s *SQLiteStore) migrate(ctx context.Context, version int, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // no-op after commit
+
+	if err := m.up(ctx, tx); err != nil {
+		return fmt.Errorf("migration %d (%s): %w", version, m.name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_version`); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SchemaVersion returns the number of migrations applied to the database.
+func (s *SQLiteStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	return version, nil
+}
+
+// addColumnIfMissing adds a column to an existing table. CREATE TABLE IF NOT
+// EXISTS leaves tables from older schemas untouched, so new columns need an
+// explicit ALTER TABLE.
+func addColumnIfMissing(ctx context.Context, tx *sql.Tx, table, column, def string) error {
+	var n int
+	err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("inspecting %s schema: %w", table, err)
+	}
+	if n > 0 {
+		return nil
+	}
+	//#nosec G202 -- identifiers are compile-time constants, not user input
+	if _, err := tx.ExecContext(ctx, `ALTER TABLE `+table+` ADD COLUMN `+column+` `+def); err != nil {
+		return fmt.Errorf("adding %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// SetNodeHistory turns on recording a NodeVersion whenever an upsert
+// changes a node. It is off by default, since the history table grows with
+// every change.
+func (s *SQLiteStore) SetNodeHistory(enabled bool) {
+	s.history = enabled
+}
+
+// Ping checks that the database connection is usable.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
 }
 
 // Close closes the database connection.
@@ -92,6 +251,41 @@ func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
+// Vacuum rebuilds the database to return the pages freed by deletions to
+// the filesystem, then checkpoints and truncates the write-ahead log, which
+// otherwise keeps its high-water size.
+func (s *SQLiteStore) Vacuum(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuuming database: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpointing WAL: %w", err)
+	}
+	return nil
+}
+
+// BackupTo writes a consistent snapshot of the database to path using
+// VACUUM INTO, which is safe while other connections are writing (WAL mode).
+// The snapshot is written to a temporary file next to path and renamed into
+// place, so an existing file at path is only replaced once the copy succeeds.
+func (s *SQLiteStore) BackupTo(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	_ = os.Remove(tmp) // VACUUM INTO refuses to overwrite an existing file
+	if _, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("snapshotting database: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("moving backup into place: %w", err)
+	}
+	return nil
+}
+
 // UpsertNode inserts or updates a node in the store.
 func (s *SQLiteStore) UpsertNode(ctx context.Context, node models.Node) error {
 	meta, err := json.Marshal(node.Metadata)
@@ -105,9 +299,15 @@ func (s *SQLiteStore) UpsertNode(ctx context.Context, node models.Node) error {
 		expiresAt = &t
 	}
 
+	if s.history {
+		if err := recordNodeVersion(ctx, s.db, node, string(meta), expiresAt); err != nil {
+			return err
+		}
+	}
+
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO nodes (id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO nodes (id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+sqlNow+`)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			type = excluded.type,
@@ -116,11 +316,16 @@ func (s *SQLiteStore) UpsertNode(ctx context.Context, node models.Node) error {
 			provider = excluded.provider,
 			metadata = excluded.metadata,
 			expires_at = excluded.expires_at,
-			last_seen = excluded.last_seen
+			last_seen = excluded.last_seen,
+			updated_at = excluded.updated_at
 	`, node.ID, node.Name, string(node.Type), node.Source, node.SourceFile,
 		node.Provider, string(meta), expiresAt,
 		node.LastSeen.Format(time.RFC3339), node.FirstSeen.Format(time.RFC3339))
-	return err
+	if err != nil {
+		return err
+	}
+	s.publishUpserts([]models.Node{node}, nil)
+	return nil
 }
 
 // UpsertEdge inserts or updates an edge in the store.
@@ -131,17 +336,25 @@ func (s *SQLiteStore) UpsertEdge(ctx context.Context, edge models.Edge) error {
 	}
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO edges (id, from_id, to_id, type, metadata)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO edges (id, from_id, to_id, type, metadata, weight, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, `+sqlNow+`)
 		ON CONFLICT(from_id, to_id, type) DO UPDATE SET
-			metadata = excluded.metadata
-	`, edge.ID, edge.FromID, edge.ToID, string(edge.Type), string(meta))
-	return err
+			metadata = excluded.metadata,
+			weight = excluded.weight,
+			updated_at = excluded.updated_at
+	`, edge.ID, edge.FromID, edge.ToID, string(edge.Type), string(meta), edgeWeight(edge))
+	if err != nil {
+		return err
+	}
+	s.publishUpserts(nil, []models.Edge{edge})
+	return nil
 }
 
 // UpsertBatch inserts or updates all nodes and edges within a single database
 // transaction. This is significantly faster and more consistent than
-// individual UpsertNode/UpsertEdge calls for bulk operations.
+// individual UpsertNode/UpsertEdge calls for bulk operations: on an on-disk
+// WAL database, 1,000 nodes take ~18ms batched versus ~140ms one at a time
+// (see BenchmarkUpsertNodes_1000).
 func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edges []models.Edge) error {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -149,9 +362,68 @@ func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edge
 	}
 	defer tx.Rollback() //nolint:errcheck // rolled back on error; commit below on success
 
+	if err := s.upsertNodesTx(ctx, tx, nodes); err != nil {
+		return err
+	}
+	if err := upsertEdgesTx(ctx, tx, edges); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.publishUpserts(nodes, edges)
+	return nil
+}
+
+// UpsertNodes inserts or updates nodes within a single transaction; if any
+// node fails, none are written.
+func (s *SQLiteStore) UpsertNodes(ctx context.Context, nodes []models.Node) error {
+	return s.UpsertBatch(ctx, nodes, nil)
+}
+
+// UpsertEdges inserts or updates edges within a single transaction; if any
+// edge fails (e.g. an endpoint is missing), none are written.
+func (s *SQLiteStore) UpsertEdges(ctx context.Context, edges []models.Edge) error {
+	return s.UpsertBatch(ctx, nil, edges)
+}
+
+// ReplaceGraph deletes every node and edge and writes nodes and edges in
+// their place, in one transaction: on any error the old graph is kept.
+// Scan records and node history are left alone; node tags go with their
+// nodes.
+func (s *SQLiteStore) ReplaceGraph(ctx context.Context, nodes []models.Node, edges []models.Edge) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // rolled back on error; commit below on success
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM edges`); err != nil {
+		return fmt.Errorf("deleting edges: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM nodes`); err != nil {
+		return fmt.Errorf("deleting nodes: %w", err)
+	}
+	if err := s.upsertNodesTx(ctx, tx, nodes); err != nil {
+		return err
+	}
+	if err := upsertEdgesTx(ctx, tx, edges); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.changes.publish(ChangeEvent{Op: ChangeReset})
+	return nil
+}
+
+func (s *SQLiteStore) upsertNodesTx(ctx context.Context, tx *sql.Tx, nodes []models.Node) error {
+	if len(nodes) == 0 {
+		return nil
+	}
 	nodeStmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO nodes (id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO nodes (id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+sqlNow+`)
 		ON CONFLICT(id) DO UPDATE SET
 			name = excluded.name,
 			type = excluded.type,
@@ -160,7 +432,8 @@ func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edge
 			provider = excluded.provider,
 			metadata = excluded.metadata,
 			expires_at = excluded.expires_at,
-			last_seen = excluded.last_seen
+			last_seen = excluded.last_seen,
+			updated_at = excluded.updated_at
 	`)
 	if err != nil {
 		return fmt.Errorf("preparing node statement: %w", err)
@@ -177,6 +450,11 @@ func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edge
 			t := node.ExpiresAt.Format(time.RFC3339)
 			expiresAt = &t
 		}
+		if s.history {
+			if err := recordNodeVersion(ctx, tx, node, string(meta), expiresAt); err != nil {
+				return err
+			}
+		}
 		if _, err := nodeStmt.ExecContext(ctx,
 			node.ID, node.Name, string(node.Type), node.Source, node.SourceFile,
 			node.Provider, string(meta), expiresAt,
@@ -185,12 +463,29 @@ func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edge
 			return fmt.Errorf("upserting node %s: %w", node.ID, err)
 		}
 	}
+	return nil
+}
+
+// edgeWeight returns the weight column value for e: NULL unless e sets
+// its own weight.
+func edgeWeight(e models.Edge) any {
+	if e.Weight > 0 {
+		return e.Weight
+	}
+	return nil
+}
 
+func upsertEdgesTx(ctx context.Context, tx *sql.Tx, edges []models.Edge) error {
+	if len(edges) == 0 {
+		return nil
+	}
 	edgeStmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO edges (id, from_id, to_id, type, metadata)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO edges (id, from_id, to_id, type, metadata, weight, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, `+sqlNow+`)
 		ON CONFLICT(from_id, to_id, type) DO UPDATE SET
-			metadata = excluded.metadata
+			metadata = excluded.metadata,
+			weight = excluded.weight,
+			updated_at = excluded.updated_at
 	`)
 	if err != nil {
 		return fmt.Errorf("preparing edge statement: %w", err)
@@ -203,19 +498,30 @@ func (s *SQLiteStore) UpsertBatch(ctx context.Context, nodes []models.Node, edge
 			return fmt.Errorf("marshaling edge metadata: %w", err)
 		}
 		if _, err := edgeStmt.ExecContext(ctx,
-			edge.ID, edge.FromID, edge.ToID, string(edge.Type), string(meta),
+			edge.ID, edge.FromID, edge.ToID, string(edge.Type), string(meta), edgeWeight(edge),
 		); err != nil {
 			return fmt.Errorf("upserting edge %s: %w", edge.ID, err)
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
-// GetNode retrieves a single node by ID.
+// GetNode retrieves a single node by ID, or nil if there is none.
+// Unlike the list methods it also loads the node's tags.
 func (s *SQLiteStore) GetNode(ctx context.Context, id string) (*models.Node, error) {
 	row := s.db.QueryRowContext(ctx, `SELECT id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen FROM nodes WHERE id = ?`, id)
-	return scanNode(row)
+	n, err := scanNode(row)
+	if err != nil || n == nil {
+		return n, err
+	}
+	tags, err := s.GetTags(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		n.Tags = tags
+	}
+	return n, nil
 }
 
 func scanNode(row interface{ Scan(dest ...any) error }) (*models.Node, error) {
@@ -256,7 +562,90 @@ func scanNode(row interface{ Scan(dest ...any) error }) (*models.Node, error) {
 
 // ListNodes returns nodes matching the given filter.
 func (s *SQLiteStore) ListNodes(ctx context.Context, filter NodeFilter) ([]models.Node, error) {
-	query := `SELECT id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen FROM nodes WHERE 1=1`
+	where, args := nodeFilterClause(filter)
+	return s.queryNodes(ctx, where, args, filter)
+}
+
+// SearchNodes returns nodes matching query and the filter. A query of the
+// form key=value matches nodes whose metadata has that key and value;
+// anything else is a case-insensitive substring match against the node's
+// ID, name and metadata values.
+func (s *SQLiteStore) SearchNodes(ctx context.Context, query string, filter NodeFilter) ([]models.Node, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+	if key, value, ok := strings.Cut(query, "="); ok && key != "" && !strings.ContainsAny(key, " \t") {
+		filter.MetadataKey, filter.MetadataValue = key, value
+		return s.ListNodes(ctx, filter)
+	}
+
+	where, args := nodeFilterClause(filter)
+	pattern := "%" + escapeLike(query) + "%"
+	where += ` AND (id LIKE ? ESCAPE '\' OR name LIKE ? ESCAPE '\'
+		OR EXISTS (SELECT 1 FROM json_each(nodes.metadata) WHERE json_each.value LIKE ? ESCAPE '\'))`
+	args = append(args, pattern, pattern, pattern)
+	return s.queryNodes(ctx, where, args, filter)
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// queryNodes selects the nodes matching where, applying the filter's
+// order, limit and offset.
+func (s *SQLiteStore) queryNodes(ctx context.Context, where string, args []any, filter NodeFilter) ([]models.Node, error) {
+	query := `SELECT id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen FROM nodes WHERE 1=1` + where
+
+	if filter.OrderBy == "" {
+		query += ` ORDER BY type, name, id`
+	} else {
+		col, ok := NodeOrderColumns[strings.TrimPrefix(filter.OrderBy, "-")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported node order %q", filter.OrderBy)
+		}
+		dir := "ASC"
+		if strings.HasPrefix(filter.OrderBy, "-") {
+			dir = "DESC"
+		}
+		// id breaks ties so pages don't overlap
+		query += ` ORDER BY ` + col + ` ` + dir + `, id` //#nosec G202 -- column from NodeOrderColumns
+	}
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, max(filter.Offset, 0))
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck // best-effort cleanup
+
+	var nodes []models.Node
+	for rows.Next() {
+		n, err := scanNode(rows)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, *n)
+	}
+	return nodes, rows.Err()
+}
+
+// CountNodes returns how many nodes match the filter, ignoring its Limit,
+// Offset and OrderBy.
+func (s *SQLiteStore) CountNodes(ctx context.Context, filter NodeFilter) (int, error) {
+	where, args := nodeFilterClause(filter)
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM nodes WHERE 1=1`+where, args...).Scan(&n)
+	return n, err
+}
+
+// nodeFilterClause builds the AND conditions for a NodeFilter.
+func nodeFilterClause(filter NodeFilter) (string, []any) {
+	var query string
 	var args []any
 
 	if filter.Type != "" {
@@ -271,34 +660,59 @@ func (s *SQLiteStore) ListNodes(ctx context.Context, filter NodeFilter) ([]model
 		query += ` AND provider = ?`
 		args = append(args, filter.Provider)
 	}
+	if filter.Status != "" {
+		query += ` AND json_extract(metadata, '$.tf_status') = ?`
+		args = append(args, filter.Status)
+	}
+	if filter.MetadataKey != "" {
+		// json_each avoids building a JSON path from the key.
+		if filter.MetadataValue != "" {
+			query += ` AND EXISTS (SELECT 1 FROM json_each(nodes.metadata) WHERE json_each.key = ? AND json_each.value = ?)`
+			args = append(args, filter.MetadataKey, filter.MetadataValue)
+		} else {
+			query += ` AND EXISTS (SELECT 1 FROM json_each(nodes.metadata) WHERE json_each.key = ?)`
+			args = append(args, filter.MetadataKey)
+		}
+	}
+	if filter.TagKey != "" {
+		if filter.TagValue != "" {
+			query += ` AND EXISTS (SELECT 1 FROM node_tags WHERE node_tags.node_id = nodes.id AND key = ? AND value = ?)`
+			args = append(args, filter.TagKey, filter.TagValue)
+		} else {
+			query += ` AND EXISTS (SELECT 1 FROM node_tags WHERE node_tags.node_id = nodes.id AND key = ?)`
+			args = append(args, filter.TagKey)
+		}
+	}
 	if filter.StaleDays > 0 {
 		threshold := time.Now().Add(-time.Duration(filter.StaleDays) * 24 * time.Hour).Format(time.RFC3339)
 		query += ` AND last_seen < ?`
 		args = append(args, threshold)
 	}
-
-	query += ` ORDER BY type, name`
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
+	if !filter.UpdatedSince.IsZero() {
+		query += ` AND updated_at >= ?`
+		args = append(args, filter.UpdatedSince.UTC().Format(time.RFC3339))
 	}
-	defer rows.Close() //nolint:errcheck // best-effort cleanup
-
-	var nodes []models.Node
-	for rows.Next() {
-		n, err := scanNode(rows)
-		if err != nil {
-			return nil, err
+	if !filter.FirstSeenBefore.IsZero() {
+		query += ` AND first_seen < ?`
+		args = append(args, filter.FirstSeenBefore.Format(time.RFC3339))
+	}
+	if len(filter.ExcludeSources) > 0 {
+		query += ` AND source NOT IN (?` + strings.Repeat(`, ?`, len(filter.ExcludeSources)-1) + `)`
+		for _, src := range filter.ExcludeSources {
+			args = append(args, src)
 		}
-		nodes = append(nodes, *n)
 	}
-	return nodes, rows.Err()
+	if filter.Expr != nil {
+		cond, exprArgs := filter.Expr.sql()
+		query += ` AND ` + cond
+		args = append(args, exprArgs...)
+	}
+	return query, args
 }
 
 // ListEdges returns edges matching the given filter.
 func (s *SQLiteStore) ListEdges(ctx context.Context, filter EdgeFilter) ([]models.Edge, error) {
-	query := `SELECT id, from_id, to_id, type, metadata FROM edges WHERE 1=1`
+	query := `SELECT id, from_id, to_id, type, metadata, weight FROM edges WHERE 1=1`
 	var args []any
 
 	if filter.Type != "" {
@@ -313,6 +727,10 @@ func (s *SQLiteStore) ListEdges(ctx context.Context, filter EdgeFilter) ([]model
 		query += ` AND to_id = ?`
 		args = append(args, filter.ToID)
 	}
+	if !filter.UpdatedSince.IsZero() {
+		query += ` AND updated_at >= ?`
+		args = append(args, filter.UpdatedSince.UTC().Format(time.RFC3339))
+	}
 
 	query += ` ORDER BY type, from_id`
 
@@ -336,8 +754,9 @@ func (s *SQLiteStore) ListEdges(ctx context.Context, filter EdgeFilter) ([]model
 func scanEdge(row interface{ Scan(dest ...any) error }) (*models.Edge, error) {
 	var e models.Edge
 	var meta sql.NullString
+	var weight sql.NullFloat64
 
-	err := row.Scan(&e.ID, &e.FromID, &e.ToID, &e.Type, &meta)
+	err := row.Scan(&e.ID, &e.FromID, &e.ToID, &e.Type, &meta, &weight)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -351,6 +770,7 @@ func scanEdge(row interface{ Scan(dest ...any) error }) (*models.Edge, error) {
 	if e.Metadata == nil {
 		e.Metadata = make(map[string]string)
 	}
+	e.Weight = weight.Float64
 
 	return &e, nil
 }
@@ -396,10 +816,155 @@ func (s *SQLiteStore) GetEdgesTo(ctx context.Context, nodeID string) ([]models.E
 
 // DeleteNode removes a node and its edges from the store.
 func (s *SQLiteStore) DeleteNode(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM nodes WHERE id = ?`, id)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM nodes WHERE id = ?`, id); err != nil {
+		return err
+	}
+	s.changes.publish(ChangeEvent{Op: ChangeDelete, NodeID: id})
+	return nil
+}
+
+// DeleteNodes removes the nodes with the given IDs, and their edges, in one
+// transaction: if any delete fails, none are removed. It returns how many
+// of the IDs existed and were deleted.
+func (s *SQLiteStore) DeleteNodes(ctx context.Context, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // rolled back on error; commit below on success
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM nodes WHERE id = ?`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close() //nolint:errcheck // best-effort cleanup
+
+	var deleted []string
+	for _, id := range ids {
+		res, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return 0, fmt.Errorf("deleting node %s: %w", id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			deleted = append(deleted, id)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	for _, id := range deleted {
+		s.changes.publish(ChangeEvent{Op: ChangeDelete, NodeID: id})
+	}
+	return len(deleted), nil
+}
+
+// deleteEdges removes the edges with the given IDs and returns how many
+// existed.
+func (s *SQLiteStore) deleteEdges(ctx context.Context, ids []string) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		res, err := s.db.ExecContext(ctx, `DELETE FROM edges WHERE id = ?`, id)
+		if err != nil {
+			return deleted, fmt.Errorf("deleting edge %s: %w", id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SetTag sets a tag on a node, replacing any previous value for the key.
+// Tags are kept apart from Metadata, so re-scans that upsert the node leave
+// them in place; they are removed only with the node itself.
+func (s *SQLiteStore) SetTag(ctx context.Context, id, key, value string) error {
+	_, err := s.UpdateTags(ctx, id, map[string]string{key: value}, nil)
 	return err
 }
 
+// DeleteTag removes a tag from a node. Removing a tag the node does not
+// have is not an error.
+func (s *SQLiteStore) DeleteTag(ctx context.Context, id, key string) error {
+	_, err := s.UpdateTags(ctx, id, nil, []string{key})
+	return err
+}
+
+// UpdateTags sets and removes tags on a node in one transaction and
+// returns the node's tags afterwards. It returns ErrNodeNotFound if the
+// node does not exist.
+func (s *SQLiteStore) UpdateTags(ctx context.Context, id string, set map[string]string, remove []string) (map[string]string, error) {
+	for k, v := range set {
+		if err := ValidateTag(k, v); err != nil {
+			return nil, err
+		}
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // rolled back on error; commit below on success
+
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM nodes WHERE id = ?`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, id)
+	}
+	for _, k := range remove {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM node_tags WHERE node_id = ? AND key = ?`, id, k); err != nil {
+			return nil, fmt.Errorf("removing tag %s: %w", k, err)
+		}
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for k, v := range set {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO node_tags (node_id, key, value, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(node_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+		`, id, k, v, now); err != nil {
+			return nil, fmt.Errorf("setting tag %s: %w", k, err)
+		}
+	}
+	tags, err := queryTags(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if n, err := s.GetNode(ctx, id); err == nil && n != nil {
+		s.changes.publish(ChangeEvent{Op: ChangeUpsert, Node: n})
+	}
+	return tags, nil
+}
+
+// GetTags returns a node's tags. A node without tags, or one that does not
+// exist, has an empty map.
+func (s *SQLiteStore) GetTags(ctx context.Context, id string) (map[string]string, error) {
+	return queryTags(ctx, s.db, id)
+}
+
+func queryTags(ctx context.Context, q sqlQuerier, id string) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, `SELECT key, value FROM node_tags WHERE node_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("reading tags: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // best-effort cleanup
+
+	tags := make(map[string]string)
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		tags[k] = v
+	}
+	return tags, rows.Err()
+}
+
 // NodeCount returns the total number of nodes.
 func (s *SQLiteStore) NodeCount(ctx context.Context) (int, error) {
 	var count int
@@ -417,8 +982,8 @@ func (s *SQLiteStore) EdgeCount(ctx context.Context) (int, error) {
 // RecordScan inserts a new scan record and returns its ID.
 func (s *SQLiteStore) RecordScan(ctx context.Context, scan Scan) (int64, error) {
 	res, err := s.db.ExecContext(ctx, `
-		INSERT INTO scans (source, source_path, started_at, status) VALUES (?, ?, ?, ?)
-	`, scan.Source, scan.SourcePath, scan.StartedAt.Format(time.RFC3339), scan.Status)
+		INSERT INTO scans (source, source_path, started_at, status, request) VALUES (?, ?, ?, ?, ?)
+	`, scan.Source, scan.SourcePath, scan.StartedAt.Format(time.RFC3339), scan.Status, nullableJSON(scan.Request))
 	if err != nil {
 		return 0, err
 	}
@@ -437,7 +1002,7 @@ func (s *SQLiteStore) UpdateScan(ctx context.Context, id int64, status string, n
 // ListScans returns the most recent scan records, up to limit.
 func (s *SQLiteStore) ListScans(ctx context.Context, limit int) ([]Scan, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, source, source_path, started_at, finished_at, nodes_found, edges_found, status
+		SELECT id, source, source_path, started_at, finished_at, nodes_found, edges_found, status, request
 		FROM scans ORDER BY id DESC LIMIT ?
 	`, limit)
 	if err != nil {
@@ -447,22 +1012,53 @@ func (s *SQLiteStore) ListScans(ctx context.Context, limit int) ([]Scan, error)
 
 	var scans []Scan
 	for rows.Next() {
-		var sc Scan
-		var finishedAt sql.NullString
-		var startedAt string
-		if err := rows.Scan(&sc.ID, &sc.Source, &sc.SourcePath, &startedAt, &finishedAt, &sc.NodesFound, &sc.EdgesFound, &sc.Status); err != nil {
+		sc, err := scanScan(rows)
+		if err != nil {
 			return nil, err
 		}
-		sc.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
-		if finishedAt.Valid {
-			t, _ := time.Parse(time.RFC3339, finishedAt.String)
-			sc.FinishedAt = &t
-		}
-		scans = append(scans, sc)
+		scans = append(scans, *sc)
 	}
 	return scans, rows.Err()
 }
 
+// GetScan returns a single scan record by ID, or nil if it does not exist.
+func (s *SQLiteStore) GetScan(ctx context.Context, id int64) (*Scan, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, source, source_path, started_at, finished_at, nodes_found, edges_found, status, request
+		FROM scans WHERE id = ?
+	`, id)
+	sc, err := scanScan(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sc, err
+}
+
+func scanScan(row interface{ Scan(dest ...any) error }) (*Scan, error) {
+	var sc Scan
+	var finishedAt, request sql.NullString
+	var startedAt string
+	if err := row.Scan(&sc.ID, &sc.Source, &sc.SourcePath, &startedAt, &finishedAt, &sc.NodesFound, &sc.EdgesFound, &sc.Status, &request); err != nil {
+		return nil, err
+	}
+	sc.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	if finishedAt.Valid {
+		t, _ := time.Parse(time.RFC3339, finishedAt.String)
+		sc.FinishedAt = &t
+	}
+	if request.Valid && request.String != "" {
+		sc.Request = json.RawMessage(request.String)
+	}
+	return &sc, nil
+}
+
+func nullableJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
 // NodeCountByType returns node counts grouped by type.
 func (s *SQLiteStore) NodeCountByType(ctx context.Context) (map[string]int, error) {
 	rows, err := s.db.QueryContext(ctx, `SELECT type, COUNT(*) FROM nodes GROUP BY type ORDER BY type`)
@@ -530,6 +1126,31 @@ func (s *SQLiteStore) ExpiringNodes(ctx context.Context, days int) ([]models.Nod
 	return nodes, rows.Err()
 }
 
+// NodesWithExpiry returns every node with an expiry date, including those
+// already expired, soonest first.
+func (s *SQLiteStore) NodesWithExpiry(ctx context.Context) ([]models.Node, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen
+		FROM nodes
+		WHERE expires_at IS NOT NULL
+		ORDER BY expires_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck // best-effort cleanup
+
+	var nodes []models.Node
+	for rows.Next() {
+		n, err := scanNode(rows)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, *n)
+	}
+	return nodes, rows.Err()
+}
+
 // AllEdges returns all edges (used by graph traversal).
 func (s *SQLiteStore) AllEdges(ctx context.Context) ([]models.Edge, error) {
 	return s.ListEdges(ctx, EdgeFilter{})
@@ -554,13 +1175,19 @@ func (s *SQLiteStore) BuildAdjacency(ctx context.Context) (downstream map[string
 }
 
 // FindOrphanNodes returns nodes that have no edges (neither incoming nor outgoing).
+// Provider and region nodes and the edges to them are ignored, so grouping
+// does not hide orphans.
 func (s *SQLiteStore) FindOrphanNodes(ctx context.Context) ([]models.Node, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, name, type, source, source_file, provider, metadata, expires_at, last_seen, first_seen
 		FROM nodes
-		WHERE id NOT IN (SELECT from_id FROM edges UNION SELECT to_id FROM edges)
+		WHERE type NOT IN (?, ?)
+		  AND id NOT IN (
+			SELECT from_id FROM edges WHERE to_id NOT IN (SELECT id FROM nodes WHERE type IN (?, ?))
+			UNION SELECT to_id FROM edges
+		  )
 		ORDER BY type, name
-	`)
+	`, models.AssetProvider, models.AssetRegion, models.AssetProvider, models.AssetRegion)
 	if err != nil {
 		return nil, err
 	}
@@ -606,6 +1233,180 @@ func (s *SQLiteStore) GetDiff(ctx context.Context, scanID int64) (*DriftSummary,
 	return &summary, nil
 }
 
+// sqlQuerier is satisfied by both *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// RecordNodeVersion adds node to its history if it differs from the stored
+// node, or if the node is new. It must run before the node is upserted.
+func (s *SQLiteStore) RecordNodeVersion(ctx context.Context, node models.Node) error {
+	meta, err := json.Marshal(node.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshaling metadata: %w", err)
+	}
+	var expiresAt *string
+	if node.ExpiresAt != nil {
+		t := node.ExpiresAt.Format(time.RFC3339)
+		expiresAt = &t
+	}
+	return recordNodeVersion(ctx, s.db, node, string(meta), expiresAt)
+}
+
+func recordNodeVersion(ctx context.Context, q sqlQuerier, node models.Node, meta string, expiresAt *string) error {
+	var name, typ, source string
+	var provider, storedMeta, storedExpires sql.NullString
+	err := q.QueryRowContext(ctx, `
+		SELECT name, type, source, provider, metadata, expires_at FROM nodes WHERE id = ?
+	`, node.ID).Scan(&name, &typ, &source, &provider, &storedMeta, &storedExpires)
+	switch {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return fmt.Errorf("reading node %s for history: %w", node.ID, err)
+	default:
+		unchanged := name == node.Name && typ == string(node.Type) && source == node.Source &&
+			provider.String == node.Provider && storedMeta.String == meta &&
+			storedExpires.Valid == (expiresAt != nil) && (expiresAt == nil || storedExpires.String == *expiresAt)
+		if unchanged {
+			return nil
+		}
+		// A node stored before history was enabled has no versions yet;
+		// keep its current state so the first change has a baseline.
+		if _, err := q.ExecContext(ctx, `
+			INSERT INTO node_history (node_id, name, type, source, provider, metadata, expires_at, recorded_at)
+			SELECT id, name, type, source, provider, metadata, expires_at, last_seen FROM nodes
+			WHERE id = ? AND NOT EXISTS (SELECT 1 FROM node_history WHERE node_id = ?)
+		`, node.ID, node.ID); err != nil {
+			return fmt.Errorf("recording baseline version of %s: %w", node.ID, err)
+		}
+	}
+
+	recordedAt := node.LastSeen
+	if recordedAt.IsZero() {
+		recordedAt = time.Now()
+	}
+	if _, err := q.ExecContext(ctx, `
+		INSERT INTO node_history (node_id, name, type, source, provider, metadata, expires_at, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, node.ID, node.Name, string(node.Type), node.Source, node.Provider, meta, expiresAt,
+		recordedAt.Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("recording version of %s: %w", node.ID, err)
+	}
+	return nil
+}
+
+// NodeHistory returns the recorded versions of a node, oldest first.
+func (s *SQLiteStore) NodeHistory(ctx context.Context, id string) ([]NodeVersion, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT node_id, name, type, source, provider, metadata, expires_at, recorded_at
+		FROM node_history WHERE node_id = ? ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck // best-effort cleanup
+
+	var versions []NodeVersion
+	for rows.Next() {
+		var v NodeVersion
+		var provider, meta, expiresAt sql.NullString
+		var recordedAt string
+		if err := rows.Scan(&v.NodeID, &v.Name, &v.Type, &v.Source, &provider, &meta, &expiresAt, &recordedAt); err != nil {
+			return nil, err
+		}
+		v.Provider = provider.String
+		if meta.Valid {
+			_ = json.Unmarshal([]byte(meta.String), &v.Metadata)
+		}
+		if v.Metadata == nil {
+			v.Metadata = make(map[string]string)
+		}
+		if expiresAt.Valid {
+			if t, err := time.Parse(time.RFC3339, expiresAt.String); err == nil {
+				v.ExpiresAt = &t
+			}
+		}
+		v.RecordedAt, _ = time.Parse(time.RFC3339, recordedAt)
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// LastAlertSent returns when the alert with the given dedup key was last
+// sent, and false if it never was.
+func (s *SQLiteStore) LastAlertSent(ctx context.Context, key string) (time.Time, bool, error) {
+	var sentAt string
+	err := s.db.QueryRowContext(ctx, `SELECT sent_at FROM alert_log WHERE key = ?`, key).Scan(&sentAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	t, err := time.Parse(time.RFC3339, sentAt)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing alert_log.sent_at: %w", err)
+	}
+	return t, true, nil
+}
+
+// RecordAlertSent stores when the alert with the given dedup key was sent.
+func (s *SQLiteStore) RecordAlertSent(ctx context.Context, key string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO alert_log (key, sent_at) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET sent_at = excluded.sent_at
+	`, key, at.UTC().Format(time.RFC3339))
+	return err
+}
+
+// SyncedAt returns when the named sync last succeeded, and false if it
+// never has.
+func (s *SQLiteStore) SyncedAt(ctx context.Context, name string) (time.Time, bool, error) {
+	var syncedAt string
+	err := s.db.QueryRowContext(ctx, `SELECT synced_at FROM sync_state WHERE name = ?`, name).Scan(&syncedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	t, err := time.Parse(time.RFC3339, syncedAt)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("parsing sync_state.synced_at: %w", err)
+	}
+	return t, true, nil
+}
+
+// SetSyncedAt records that the named sync succeeded at the given time.
+func (s *SQLiteStore) SetSyncedAt(ctx context.Context, name string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sync_state (name, synced_at) VALUES (?, ?)
+		ON CONFLICT(name) DO UPDATE SET synced_at = excluded.synced_at
+	`, name, at.UTC().Format(time.RFC3339))
+	return err
+}
+
+// nodeIDs returns the ID of every node.
+func (s *SQLiteStore) nodeIDs(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM nodes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck // best-effort cleanup
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // GenerateEdgeID creates a deterministic edge ID.
 func GenerateEdgeID(fromID, toID string, edgeType models.EdgeType) string {
 	return strings.Join([]string{fromID, string(edgeType), toID}, "->")
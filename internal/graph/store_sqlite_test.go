@@ -3,6 +3,13 @@ package graph
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -280,6 +287,238 @@ func TestListNodesFilterByProvider(t *testing.T) {
 	}
 }
 
+func TestListNodesFilterByStatus(t *testing.T) {
+	store := newTestStore(t)
+	tainted := makeNode("a", models.AssetVM, "terraform")
+	tainted.Metadata["tf_status"] = "tainted"
+	deposed := makeNode("b", models.AssetVM, "terraform")
+	deposed.Metadata["tf_status"] = "deposed"
+	buildTestGraph(t, store, []models.Node{tainted, deposed, makeNode("c", models.AssetVM, "terraform")}, nil)
+
+	nodes, err := store.ListNodes(context.Background(), NodeFilter{Status: "tainted"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "a" {
+		t.Errorf("expected only node a, got %+v", nodes)
+	}
+}
+
+func TestListNodesFilterByFirstSeenAndExcludedSources(t *testing.T) {
+	store := newTestStore(t)
+	old := makeNode("old", models.AssetVM, "terraform")
+	old.FirstSeen = old.FirstSeen.Add(-100 * 24 * time.Hour)
+	oldManual := makeNode("old-manual", models.AssetVM, "manual")
+	oldManual.FirstSeen = old.FirstSeen
+	buildTestGraph(t, store, []models.Node{old, oldManual, makeNode("new", models.AssetVM, "terraform")}, nil)
+
+	cutoff := time.Now().Add(-30 * 24 * time.Hour)
+	nodes, err := store.ListNodes(context.Background(), NodeFilter{FirstSeenBefore: cutoff})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 {
+		t.Errorf("expected 2 old nodes, got %d", len(nodes))
+	}
+
+	nodes, err = store.ListNodes(context.Background(), NodeFilter{
+		FirstSeenBefore: cutoff,
+		ExcludeSources:  []string{"manual", "k8s"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "old" {
+		t.Errorf("expected only node old, got %v", nodes)
+	}
+}
+
+func TestListNodesPagination(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	var nodes []models.Node
+	for _, id := range []string{"e", "c", "a", "d", "b"} {
+		nodes = append(nodes, makeNode(id, models.AssetVM, "terraform"))
+	}
+	nodes = append(nodes, makeNode("z", models.AssetNetwork, "terraform"))
+	buildTestGraph(t, store, nodes, nil)
+
+	ids := func(filter NodeFilter) []string {
+		t.Helper()
+		got, err := store.ListNodes(ctx, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]string, len(got))
+		for i, n := range got {
+			out[i] = n.ID
+		}
+		return out
+	}
+
+	filter := NodeFilter{Type: "vm", OrderBy: "name", Limit: 2}
+	if got := ids(filter); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("page 1 = %v, want [a b]", got)
+	}
+	filter.Offset = 2
+	if got := ids(filter); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Errorf("page 2 = %v, want [c d]", got)
+	}
+	filter.Offset = 4
+	if got := ids(filter); !reflect.DeepEqual(got, []string{"e"}) {
+		t.Errorf("page 3 = %v, want [e]", got)
+	}
+	if got := ids(NodeFilter{OrderBy: "-id", Limit: 2}); !reflect.DeepEqual(got, []string{"z", "e"}) {
+		t.Errorf("descending = %v, want [z e]", got)
+	}
+
+	total, err := store.CountNodes(ctx, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 {
+		t.Errorf("CountNodes = %d, want 5 vm nodes regardless of paging", total)
+	}
+
+	if _, err := store.ListNodes(ctx, NodeFilter{OrderBy: "metadata; DROP TABLE nodes"}); err == nil {
+		t.Error("expected error for unsupported order")
+	}
+}
+
+func TestSearchNodes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	web := makeNode("tf:vm:web-prod", models.AssetVM, "terraform")
+	web.Name = "web-prod"
+	web.Metadata = map[string]string{"region": "us-east-1", "team": "payments"}
+	db := makeNode("tf:database:orders", models.AssetDatabase, "terraform")
+	db.Name = "orders"
+	db.Metadata = map[string]string{"region": "eu-west-1", "engine": "postgres_15"}
+	buildTestGraph(t, store, []models.Node{web, db}, nil)
+
+	ids := func(query string, filter NodeFilter) []string {
+		t.Helper()
+		got, err := store.SearchNodes(ctx, query, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make([]string, len(got))
+		for i, n := range got {
+			out[i] = n.ID
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		filter NodeFilter
+		want   []string
+	}{
+		{"name substring", "WEB-pr", NodeFilter{}, []string{"tf:vm:web-prod"}},
+		{"id substring", "database:ord", NodeFilter{}, []string{"tf:database:orders"}},
+		{"metadata value", "payments", NodeFilter{}, []string{"tf:vm:web-prod"}},
+		{"metadata key and value", "region=eu-west-1", NodeFilter{}, []string{"tf:database:orders"}},
+		{"metadata key only", "engine=", NodeFilter{}, []string{"tf:database:orders"}},
+		{"combined with filter", "west", NodeFilter{Type: "vm"}, []string{}},
+		{"wildcards are literal", "postgres%15", NodeFilter{}, []string{}},
+		{"underscore is literal", "postgres_15", NodeFilter{}, []string{"tf:database:orders"}},
+		{"no match", "nothing-here", NodeFilter{}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(tt.query, tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchNodes(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+
+	got, err := store.ListNodes(ctx, NodeFilter{MetadataKey: "region", MetadataValue: "us-east-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "tf:vm:web-prod" {
+		t.Errorf("ListNodes by metadata = %v, want tf:vm:web-prod", got)
+	}
+
+	if _, err := store.SearchNodes(ctx, "  ", NodeFilter{}); err == nil {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestUpsertNodesAndEdges(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	const n = 3000
+	nodes := make([]models.Node, n)
+	edges := make([]models.Edge, 0, n-1)
+	for i := range nodes {
+		nodes[i] = makeNode(fmt.Sprintf("vm-%04d", i), models.AssetVM, "terraform")
+		if i > 0 {
+			edges = append(edges, makeEdge(nodes[i].ID, nodes[i-1].ID, models.EdgeDependsOn))
+		}
+	}
+	if err := store.UpsertNodes(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertEdges(ctx, edges); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := store.NodeCount(ctx); count != n {
+		t.Errorf("nodes = %d, want %d", count, n)
+	}
+	if count, _ := store.EdgeCount(ctx); count != n-1 {
+		t.Errorf("edges = %d, want %d", count, n-1)
+	}
+
+	// An edge to a missing node fails the whole batch: neither the valid
+	// edge before it nor the nodes in the same batch are written.
+	extra := makeNode("vm-extra", models.AssetVM, "terraform")
+	err := store.UpsertBatch(ctx, []models.Node{extra}, []models.Edge{
+		makeEdge("vm-extra", "vm-0000", models.EdgeConnectsTo),
+		makeEdge("vm-extra", "vm-missing", models.EdgeConnectsTo),
+	})
+	if err == nil {
+		t.Fatal("expected error for edge to missing node")
+	}
+	if got, _ := store.GetNode(ctx, "vm-extra"); got != nil {
+		t.Error("node from failed batch was written")
+	}
+	if count, _ := store.EdgeCount(ctx); count != n-1 {
+		t.Errorf("edges after failed batch = %d, want %d", count, n-1)
+	}
+}
+
+func TestOrphans(t *testing.T) {
+	store := newTestStore(t)
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("app", models.AssetService, "tf"),
+			makeNode("db", models.AssetDatabase, "tf"),
+			makeNode("leftover", models.AssetBucket, "tf"),
+		},
+		[]models.Edge{makeEdge("app", "db", models.EdgeDependsOn)},
+	)
+	ctx := context.Background()
+
+	ids := func(nodes []models.Node) []string {
+		out := make([]string, len(nodes))
+		for i, n := range nodes {
+			out[i] = n.ID
+		}
+		return out
+	}
+
+	orphans, err := Orphans(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(orphans); !reflect.DeepEqual(got, []string{"leftover"}) {
+		t.Errorf("orphans = %v, want [leftover]", got)
+	}
+}
+
 func TestListEdgesFilters(t *testing.T) {
 	store := newTestStore(t)
 	buildTestGraph(t, store,
@@ -378,6 +617,29 @@ func TestGetEdgesFromTo(t *testing.T) {
 	}
 }
 
+func TestAlertLog(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	if _, ok, err := store.LastAlertSent(ctx, "a|warning"); err != nil || ok {
+		t.Fatalf("LastAlertSent on empty log = %v, %v; want false, nil", ok, err)
+	}
+	first := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	if err := store.RecordAlertSent(ctx, "a|warning", first); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordAlertSent(ctx, "a|warning", first.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := store.LastAlertSent(ctx, "a|warning")
+	if err != nil || !ok {
+		t.Fatalf("LastAlertSent = %v, %v; want true, nil", ok, err)
+	}
+	if !got.Equal(first.Add(time.Hour)) {
+		t.Errorf("sent_at = %v, want %v", got, first.Add(time.Hour))
+	}
+}
+
 func TestDeleteNode(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -404,6 +666,201 @@ func TestDeleteNode(t *testing.T) {
 	}
 }
 
+func TestDeleteNodes(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("a", models.AssetVM, "tf"),
+			makeNode("b", models.AssetNetwork, "tf"),
+			makeNode("c", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{
+			makeEdge("a", "b", models.EdgeDependsOn),
+			makeEdge("c", "b", models.EdgeDependsOn),
+		},
+	)
+
+	events, unsubscribe := store.SubscribeChanges()
+	defer unsubscribe()
+
+	n, err := store.DeleteNodes(ctx, []string{"a", "missing", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted = %d, want 2", n)
+	}
+	nodes, _ := store.ListNodes(ctx, NodeFilter{})
+	if len(nodes) != 1 || nodes[0].ID != "b" {
+		t.Errorf("remaining nodes = %v, want only b", nodes)
+	}
+	// Edges cascade with their nodes.
+	if edges, _ := store.ListEdges(ctx, EdgeFilter{}); len(edges) != 0 {
+		t.Errorf("expected 0 edges after cascade delete, got %d", len(edges))
+	}
+	for _, want := range []string{"a", "c"} {
+		if ev := <-events; ev.Op != ChangeDelete || ev.NodeID != want {
+			t.Errorf("event = %+v, want delete %s", ev, want)
+		}
+	}
+}
+
+func TestDeleteNodes_AllOrNothing(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	buildTestGraph(t, store,
+		[]models.Node{
+			makeNode("a", models.AssetVM, "tf"),
+			makeNode("b", models.AssetNetwork, "tf"),
+			makeNode("locked", models.AssetDatabase, "tf"),
+		},
+		[]models.Edge{makeEdge("a", "b", models.EdgeDependsOn)},
+	)
+	if _, err := store.db.ExecContext(ctx, `
+		CREATE TRIGGER keep_locked BEFORE DELETE ON nodes WHEN old.id = 'locked'
+		BEGIN SELECT RAISE(ABORT, 'node is locked'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.DeleteNodes(ctx, []string{"a", "b", "locked"}); err == nil {
+		t.Fatal("expected an error deleting the locked node")
+	}
+	if count, _ := store.NodeCount(ctx); count != 3 {
+		t.Errorf("node count = %d, want all 3 kept", count)
+	}
+	if count, _ := store.EdgeCount(ctx); count != 1 {
+		t.Errorf("edge count = %d, want 1 kept", count)
+	}
+}
+
+func TestTags_SurviveUpsert(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	node := makeNode("vm", models.AssetVM, "tf")
+	node.Metadata = map[string]string{"region": "us-east1"}
+	buildTestGraph(t, store, []models.Node{node}, nil)
+
+	if err := store.SetTag(ctx, "vm", "owner", "team-a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetTag(ctx, "vm", "env", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetTag(ctx, "vm", "owner", "team-b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A re-scan replaces the metadata wholesale but leaves tags alone.
+	node.Metadata = map[string]string{"region": "us-west1"}
+	node.Tags = map[string]string{"owner": "ignored"}
+	if err := store.UpsertNode(ctx, node); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertBatch(ctx, []models.Node{node}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetNode(ctx, "vm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"owner": "team-b", "env": "prod"}
+	if !reflect.DeepEqual(got.Tags, want) {
+		t.Errorf("tags = %v, want %v", got.Tags, want)
+	}
+	if got.Metadata["region"] != "us-west1" {
+		t.Errorf("metadata region = %q, want us-west1", got.Metadata["region"])
+	}
+	if _, ok := got.Metadata["owner"]; ok {
+		t.Error("tag leaked into metadata")
+	}
+
+	if err := store.DeleteTag(ctx, "vm", "env"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ := store.GetTags(ctx, "vm"); !reflect.DeepEqual(tags, map[string]string{"owner": "team-b"}) {
+		t.Errorf("tags after delete = %v", tags)
+	}
+
+	// Tags go with their node.
+	if err := store.DeleteNode(ctx, "vm"); err != nil {
+		t.Fatal(err)
+	}
+	if tags, _ := store.GetTags(ctx, "vm"); len(tags) != 0 {
+		t.Errorf("tags after node delete = %v, want none", tags)
+	}
+}
+
+func TestUpdateTags(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store, []models.Node{makeNode("vm", models.AssetVM, "tf")}, nil)
+
+	if err := store.SetTag(ctx, "vm", "old", "x"); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := store.UpdateTags(ctx, "vm", map[string]string{"team": "core", "tier": "1"}, []string{"old", "absent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"team": "core", "tier": "1"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	if _, err := store.UpdateTags(ctx, "missing", map[string]string{"a": "b"}, nil); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("missing node error = %v, want ErrNodeNotFound", err)
+	}
+	// An invalid tag rejects the whole update.
+	if _, err := store.UpdateTags(ctx, "vm", map[string]string{"ok": "1", "bad key": "2"}, nil); err == nil {
+		t.Error("expected error for key with a space")
+	}
+	if tags, _ := store.GetTags(ctx, "vm"); len(tags) != 2 {
+		t.Errorf("tags after rejected update = %v, want unchanged", tags)
+	}
+}
+
+func TestListNodes_TagFilter(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store, []models.Node{
+		makeNode("a", models.AssetVM, "tf"),
+		makeNode("b", models.AssetVM, "tf"),
+		makeNode("c", models.AssetVM, "tf"),
+	}, nil)
+	for id, owner := range map[string]string{"a": "team-a", "b": "team-b"} {
+		if err := store.SetTag(ctx, id, "owner", owner); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		filter NodeFilter
+		want   []string
+	}{
+		{NodeFilter{TagKey: "owner"}, []string{"a", "b"}},
+		{NodeFilter{TagKey: "owner", TagValue: "team-b"}, []string{"b"}},
+		{NodeFilter{TagKey: "missing"}, nil},
+	}
+	for _, tt := range tests {
+		tt.filter.OrderBy = "id"
+		nodes, err := store.ListNodes(ctx, tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, n := range nodes {
+			got = append(got, n.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
 func TestNodeAndEdgeCount(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -497,6 +954,30 @@ func TestExpiringNodes(t *testing.T) {
 	}
 }
 
+func TestNodesWithExpiry(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	far := time.Now().Add(90 * 24 * time.Hour).Truncate(time.Second)
+
+	n1 := makeNode("key1", models.AssetKMSKey, "tf")
+	n1.ExpiresAt = &far
+	n2 := makeNode("cert1", models.AssetCertificate, "tf")
+	n2.ExpiresAt = &past
+	n3 := makeNode("vm1", models.AssetVM, "tf")
+
+	buildTestGraph(t, store, []models.Node{n1, n2, n3}, nil)
+
+	nodes, err := store.NodesWithExpiry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].ID != "cert1" || nodes[1].ID != "key1" {
+		t.Errorf("NodesWithExpiry = %v, want cert1 then key1", nodes)
+	}
+}
+
 func TestRecordAndListScans(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -544,6 +1025,129 @@ func TestUpdateScan(t *testing.T) {
 	}
 }
 
+func TestGetScan_WithRequest(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	req := json.RawMessage(`{"source":"terraform","paths":["/a.tfstate"]}`)
+	id, err := store.RecordScan(ctx, Scan{
+		Source: "terraform", SourcePath: "/a.tfstate",
+		StartedAt: time.Now(), Status: "running", Request: req,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc, err := store.GetScan(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc == nil || string(sc.Request) != string(req) {
+		t.Fatalf("GetScan = %+v, want request %s", sc, req)
+	}
+
+	missing, err := store.GetScan(ctx, id+100)
+	if err != nil || missing != nil {
+		t.Errorf("GetScan(missing) = %v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestInit_AddsScanRequestColumn(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &SQLiteStore{db: db}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+
+	// Schema from before scan requests were persisted.
+	if _, err := db.ExecContext(ctx, `CREATE TABLE scans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, source TEXT NOT NULL, source_path TEXT NOT NULL,
+		started_at DATETIME NOT NULL, finished_at DATETIME, nodes_found INTEGER DEFAULT 0,
+		edges_found INTEGER DEFAULT 0, status TEXT DEFAULT 'running')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO scans (source, source_path, started_at) VALUES ('terraform', '/old', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Init must be idempotent once the column exists.
+	if err := store.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	scans, err := store.ListScans(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scans) != 1 || scans[0].Request != nil {
+		t.Errorf("legacy scan = %+v, want one scan without request", scans)
+	}
+}
+
+func TestInit_MigratesBaseSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	// A database from the first release: the base schema, no schema_version.
+	if _, err := db.ExecContext(ctx, baseSchema); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO nodes (id, name, type, source, last_seen, first_seen)
+		VALUES ('vm:old', 'old', 'vm', 'terraform', '2024-01-01T00:00:00Z', '2024-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	version, err := store.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+	for _, table := range []string{"node_history", "alert_log"} {
+		var n int
+		if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil || n != 1 {
+			t.Errorf("table %s missing after migration (err %v)", table, err)
+		}
+	}
+	var n int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pragma_table_info('scans') WHERE name = 'request'`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("scans.request missing after migration (err %v)", err)
+	}
+	if node, err := store.GetNode(ctx, "vm:old"); err != nil || node == nil {
+		t.Errorf("existing node lost in migration: %v, %v", node, err)
+	}
+
+	// Re-running is a no-op, and a database from a newer aib is refused.
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("second Init: %v", err)
+	}
+	if _, err := store.db.ExecContext(ctx, `UPDATE schema_version SET version = ?`, len(migrations)+1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(ctx); err == nil || !strings.Contains(err.Error(), "newer than this aib supports") {
+		t.Errorf("expected an error for a newer schema, got %v", err)
+	}
+}
+
 func TestBuildAdjacency(t *testing.T) {
 	store := newTestStore(t)
 	buildTestGraph(t, store,
@@ -651,3 +1255,196 @@ func TestFindOrphanNodes_EmptyGraph(t *testing.T) {
 		t.Errorf("expected 0 orphans (no nodes), got %d", len(orphans))
 	}
 }
+
+func TestBackupTo(t *testing.T) {
+	store := newTestStore(t)
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("a", models.AssetVM, "tf"), makeNode("b", models.AssetNetwork, "tf")},
+		[]models.Edge{makeEdge("a", "b", models.EdgeDependsOn)},
+	)
+	ctx := context.Background()
+
+	dst := filepath.Join(t.TempDir(), "nested", "backup.db")
+	if err := store.BackupTo(ctx, dst); err != nil {
+		t.Fatalf("BackupTo: %v", err)
+	}
+	// A second backup must replace the first rather than fail.
+	if err := store.BackupTo(ctx, dst); err != nil {
+		t.Fatalf("BackupTo overwrite: %v", err)
+	}
+
+	backup, err := NewSQLiteStore(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close() //nolint:errcheck // test cleanup
+
+	nodes, err := backup.NodeCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges, _ := backup.EdgeCount(ctx)
+	if nodes != 2 || edges != 1 {
+		t.Errorf("backup has %d nodes, %d edges; want 2, 1", nodes, edges)
+	}
+	if _, err := os.Stat(dst + ".tmp"); !os.IsNotExist(err) {
+		t.Error("temporary backup file should be cleaned up")
+	}
+}
+
+func TestVacuum(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vacuum.db")
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close() //nolint:errcheck // test cleanup
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make([]models.Node, 2000)
+	for i := range nodes {
+		nodes[i] = makeNode(fmt.Sprintf("vm-%d", i), models.AssetVM, "tf")
+		nodes[i].Metadata = map[string]string{"padding": strings.Repeat("x", 512)}
+	}
+	if err := store.UpsertBatch(ctx, nodes, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range nodes {
+		if err := store.DeleteNode(ctx, n.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := func() int64 {
+		var total int64
+		for _, p := range []string{path, path + "-wal"} {
+			if info, err := os.Stat(p); err == nil {
+				total += info.Size()
+			}
+		}
+		return total
+	}
+	before := size()
+
+	if err := store.Vacuum(ctx); err != nil {
+		t.Fatalf("Vacuum: %v", err)
+	}
+	if after := size(); after >= before {
+		t.Errorf("size after vacuum = %d, want less than %d", after, before)
+	}
+	if n, _ := store.NodeCount(ctx); n != 0 {
+		t.Errorf("node count after vacuum = %d, want 0", n)
+	}
+}
+
+func TestNodeHistory(t *testing.T) {
+	store := newTestStore(t)
+	store.SetNodeHistory(true)
+	ctx := context.Background()
+
+	vm := makeNode("vm:web", models.AssetVM, "terraform")
+	vm.Metadata = map[string]string{"machine_type": "e2-small"}
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-scanning an unchanged node (only last_seen moves) records nothing.
+	vm.LastSeen = vm.LastSeen.Add(time.Hour)
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertBatch(ctx, []models.Node{vm}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	resized := vm
+	resized.Metadata = map[string]string{"machine_type": "e2-medium"}
+	if err := store.UpsertBatch(ctx, []models.Node{resized}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := store.NodeHistory(ctx, "vm:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("versions = %d, want 2 (initial + resize)", len(versions))
+	}
+	if versions[0].Metadata["machine_type"] != "e2-small" || versions[1].Metadata["machine_type"] != "e2-medium" {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+}
+
+func TestNodeHistory_EnabledLater(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	vm := makeNode("vm:web", models.AssetVM, "terraform")
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+	if versions, _ := store.NodeHistory(ctx, "vm:web"); len(versions) != 0 {
+		t.Fatalf("history disabled but %d versions recorded", len(versions))
+	}
+
+	// Enabling history later keeps the pre-existing state as a baseline
+	// on the first change.
+	store.SetNodeHistory(true)
+	vm.Name = "web-renamed"
+	if err := store.UpsertNode(ctx, vm); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := store.NodeHistory(ctx, "vm:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Name != "vm:web" || versions[1].Name != "web-renamed" {
+		t.Errorf("versions = %+v, want baseline then rename", versions)
+	}
+}
+
+// The upsert benchmarks use an on-disk WAL database, where every
+// autocommitted statement is its own transaction, to show what batching
+// saves on a scan.
+func benchUpsertNodes(b *testing.B, upsert func(*SQLiteStore, []models.Node) error) {
+	store, err := NewSQLiteStore(filepath.Join(b.TempDir(), "aib.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = store.Close() })
+	if err := store.Init(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+	nodes := make([]models.Node, 1000)
+	for i := range nodes {
+		nodes[i] = makeNode(fmt.Sprintf("vm-%04d", i), models.AssetVM, "terraform")
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := upsert(store, nodes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUpsertNode_1000(b *testing.B) {
+	benchUpsertNodes(b, func(store *SQLiteStore, nodes []models.Node) error {
+		for _, n := range nodes {
+			if err := store.UpsertNode(context.Background(), n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkUpsertNodes_1000(b *testing.B) {
+	benchUpsertNodes(b, func(store *SQLiteStore, nodes []models.Node) error {
+		return store.UpsertNodes(context.Background(), nodes)
+	})
+}
@@ -7,17 +7,129 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/matijazezelj/aib/internal/schedule"
 	"github.com/matijazezelj/aib/pkg/models"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
+// memgraphSyncName keys the sync_state row holding the time of the last
+// successful push to Memgraph.
+const memgraphSyncName = "memgraph"
+
+// Default retry policy and batch size of a MemgraphSyncer.
+const (
+	defaultSyncAttempts  = 3
+	defaultSyncBackoff   = 2 * time.Second
+	defaultSyncBatchSize = 500
+)
+
+// MemgraphSyncer keeps Memgraph in step with SQLite, the source of truth.
+// A failed sync is retried with exponential backoff, and only a successful
+// one advances the synced_at marker, so changes missed while Memgraph was
+// unreachable are pushed by the next sync.
+type MemgraphSyncer struct {
+	store      *SQLiteStore
+	newSession sessionFactory
+	logger     *slog.Logger
+
+	// Attempts is how many times a sync is tried before it fails. Backoff
+	// is the wait before the first retry, doubled before each one after.
+	Attempts int
+	Backoff  time.Duration
+
+	// BatchSize is how many nodes or edges each UNWIND statement carries.
+	BatchSize int
+}
+
+// NewMemgraphSyncer returns a syncer pushing store to Memgraph through
+// driver, trying each sync three times and sending 500 rows per statement.
+func NewMemgraphSyncer(store *SQLiteStore, driver neo4j.DriverWithContext, logger *slog.Logger) *MemgraphSyncer {
+	return newMemgraphSyncer(store, newNeo4jSessionFactory(driver), logger)
+}
+
+func newMemgraphSyncer(store *SQLiteStore, sf sessionFactory, logger *slog.Logger) *MemgraphSyncer {
+	return &MemgraphSyncer{
+		store:      store,
+		newSession: sf,
+		logger:     logger,
+		Attempts:   defaultSyncAttempts,
+		Backoff:    defaultSyncBackoff,
+		BatchSize:  defaultSyncBatchSize,
+	}
+}
+
+// SyncFull clears Memgraph and pushes the whole graph.
+func (m *MemgraphSyncer) SyncFull(ctx context.Context) error {
+	return m.retry(ctx, func(ctx context.Context) error {
+		return syncToMemgraph(ctx, m.store, m.newSession, m.BatchSize, m.logger)
+	})
+}
+
+// SyncChanged pushes what changed since the last successful sync: nodes
+// seen since then with their edges, and the removal of deleted nodes. With
+// no previous sync it does a full one.
+func (m *MemgraphSyncer) SyncChanged(ctx context.Context) error {
+	since, ok, err := m.store.SyncedAt(ctx, memgraphSyncName)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		m.logger.Info("no previous memgraph sync, pushing the whole graph")
+		return m.SyncFull(ctx)
+	}
+	return m.retry(ctx, func(ctx context.Context) error {
+		return syncChangedToMemgraph(ctx, m.store, m.newSession, since, m.BatchSize, m.logger)
+	})
+}
+
+// Run reconciles Memgraph once, then on sched, until ctx is done. Failures
+// are logged; the next run picks up where the last successful one left off.
+func (m *MemgraphSyncer) Run(ctx context.Context, sched schedule.Schedule) {
+	m.logger.Info("memgraph reconciliation started", "schedule", sched.String())
+	for {
+		if err := m.SyncChanged(ctx); err != nil && ctx.Err() == nil {
+			m.logger.Error("memgraph reconciliation failed", "error", err)
+		}
+		if !schedule.Wait(ctx, sched, schedule.RealClock{}, nil) {
+			return
+		}
+	}
+}
+
+// retry runs push until it succeeds or Attempts is used up. On success it
+// records the start of the successful attempt as the synced_at marker, so
+// writes made during the push are picked up by the next sync.
+func (m *MemgraphSyncer) retry(ctx context.Context, push func(context.Context) error) error {
+	attempts := max(m.Attempts, 1)
+	backoff := m.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		if err = push(ctx); err == nil {
+			return m.store.SetSyncedAt(ctx, memgraphSyncName, started)
+		}
+		if attempt == attempts {
+			break
+		}
+		m.logger.Warn("memgraph sync failed, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return fmt.Errorf("memgraph sync failed after %d attempts: %w", attempts, err)
+}
+
 // SyncToMemgraph performs a full synchronization from SQLite to Memgraph.
-// It clears all Memgraph data and re-inserts everything from SQLite.
+// It clears all Memgraph data and re-inserts everything from SQLite in
+// batches of 500.
 func SyncToMemgraph(ctx context.Context, store *SQLiteStore, driver neo4j.DriverWithContext, logger *slog.Logger) error {
-	return syncToMemgraph(ctx, store, newNeo4jSessionFactory(driver), logger)
+	return syncToMemgraph(ctx, store, newNeo4jSessionFactory(driver), defaultSyncBatchSize, logger)
 }
 
-func syncToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory, logger *slog.Logger) error {
+func syncToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory, batchSize int, logger *slog.Logger) error {
 	session := sf(ctx)
 	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
 
@@ -48,13 +160,99 @@ func syncToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory,
 	}
 
 	logger.Info("syncing nodes to memgraph", "count", len(nodes))
+	if err := mergeNodes(ctx, session, nodes, batchSize); err != nil {
+		return err
+	}
 
-	batchSize := 500
-	for i := 0; i < len(nodes); i += batchSize {
-		end := i + batchSize
-		if end > len(nodes) {
-			end = len(nodes)
+	// Step 4: Load all edges from SQLite
+	edges, err := store.AllEdges(ctx)
+	if err != nil {
+		return fmt.Errorf("listing edges from sqlite: %w", err)
+	}
+
+	logger.Info("syncing edges to memgraph", "count", len(edges))
+	if err := mergeEdges(ctx, session, edges, batchSize); err != nil {
+		return err
+	}
+
+	logger.Info("memgraph sync complete", "nodes", len(nodes), "edges", len(edges))
+	return nil
+}
+
+// syncChangedToMemgraph pushes the nodes and edges written at or after
+// since, and deletes the Memgraph nodes that are no longer in SQLite. The
+// changed nodes' outgoing edges are replaced, so edges a re-scan dropped
+// are removed too.
+func syncChangedToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory, since time.Time, batchSize int, logger *slog.Logger) error {
+	session := sf(ctx)
+	defer session.Close(ctx) //nolint:errcheck // best-effort cleanup
+
+	ids, err := store.nodeIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("listing node ids from sqlite: %w", err)
+	}
+	if ids == nil {
+		ids = []string{}
+	}
+	if _, err := session.Run(ctx, "MATCH (a:Asset) WHERE NOT a.id IN $ids DETACH DELETE a", map[string]any{"ids": ids}); err != nil {
+		return fmt.Errorf("removing deleted nodes: %w", err)
+	}
+
+	nodes, err := store.ListNodes(ctx, NodeFilter{UpdatedSince: since})
+	if err != nil {
+		return fmt.Errorf("listing nodes from sqlite: %w", err)
+	}
+	if err := mergeNodes(ctx, session, nodes, batchSize); err != nil {
+		return err
+	}
+
+	changed := make(map[string]bool, len(nodes))
+	changedIDs := make([]string, len(nodes))
+	for i, n := range nodes {
+		changed[n.ID] = true
+		changedIDs[i] = n.ID
+	}
+	if len(changedIDs) > 0 {
+		if _, err := session.Run(ctx, "MATCH (a:Asset)-[r:EDGE]->() WHERE a.id IN $ids DELETE r", map[string]any{"ids": changedIDs}); err != nil {
+			return fmt.Errorf("removing edges of changed nodes: %w", err)
+		}
+	}
+	all, err := store.AllEdges(ctx)
+	if err != nil {
+		return fmt.Errorf("listing edges from sqlite: %w", err)
+	}
+	updated, err := store.ListEdges(ctx, EdgeFilter{UpdatedSince: since})
+	if err != nil {
+		return fmt.Errorf("listing edges from sqlite: %w", err)
+	}
+	recent := make(map[string]bool, len(updated))
+	for _, e := range updated {
+		recent[e.ID] = true
+	}
+	var edges []models.Edge
+	for _, e := range all {
+		if changed[e.FromID] || changed[e.ToID] || recent[e.ID] {
+			edges = append(edges, e)
 		}
+	}
+	if len(nodes) == 0 && len(edges) == 0 {
+		logger.Info("memgraph is up to date", "since", since)
+		return nil
+	}
+	if err := mergeEdges(ctx, session, edges, batchSize); err != nil {
+		return err
+	}
+
+	logger.Info("memgraph incremental sync complete", "since", since, "nodes", len(nodes), "edges", len(edges))
+	return nil
+}
+
+// mergeNodes creates or updates nodes in Memgraph, sending batchSize nodes
+// per UNWIND statement. MERGE on id keeps a re-sync idempotent.
+func mergeNodes(ctx context.Context, session sessionRunner, nodes []models.Node, batchSize int) error {
+	batchSize = max(batchSize, 1)
+	for i := 0; i < len(nodes); i += batchSize {
+		end := min(i+batchSize, len(nodes))
 		batch := nodes[i:end]
 
 		nodeParams := make([]map[string]any, len(batch))
@@ -81,20 +279,15 @@ func syncToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory,
 			return fmt.Errorf("syncing node batch %d-%d: %w", i, end, err)
 		}
 	}
+	return nil
+}
 
-	// Step 4: Load all edges from SQLite
-	edges, err := store.AllEdges(ctx)
-	if err != nil {
-		return fmt.Errorf("listing edges from sqlite: %w", err)
-	}
-
-	logger.Info("syncing edges to memgraph", "count", len(edges))
-
+// mergeEdges creates or updates edges in Memgraph. Edges whose endpoints
+// are not in Memgraph are skipped.
+func mergeEdges(ctx context.Context, session sessionRunner, edges []models.Edge, batchSize int) error {
+	batchSize = max(batchSize, 1)
 	for i := 0; i < len(edges); i += batchSize {
-		end := i + batchSize
-		if end > len(edges) {
-			end = len(edges)
-		}
+		end := min(i+batchSize, len(edges))
 		batch := edges[i:end]
 
 		edgeParams := make([]map[string]any, len(batch))
@@ -107,16 +300,14 @@ func syncToMemgraph(ctx context.Context, store *SQLiteStore, sf sessionFactory,
 			MATCH (from:Asset {id: e.fromID})
 			MATCH (to:Asset {id: e.toID})
 			MERGE (from)-[r:EDGE {id: e.id}]->(to)
-			ON CREATE SET r.type = e.type, r.metadata = e.metadata
-			ON MATCH SET r.type = e.type, r.metadata = e.metadata
+			ON CREATE SET r.type = e.type, r.metadata = e.metadata, r.weight = e.weight
+			ON MATCH SET r.type = e.type, r.metadata = e.metadata, r.weight = e.weight
 		`
 		_, err := session.Run(ctx, cypher, map[string]any{"edges": edgeParams})
 		if err != nil {
 			return fmt.Errorf("syncing edge batch %d-%d: %w", i, end, err)
 		}
 	}
-
-	logger.Info("memgraph sync complete", "nodes", len(nodes), "edges", len(edges))
 	return nil
 }
 
@@ -148,5 +339,6 @@ func edgeToParams(e models.Edge) map[string]any {
 		"toID":     e.ToID,
 		"type":     string(e.Type),
 		"metadata": string(meta),
+		"weight":   e.Cost(),
 	}
 }
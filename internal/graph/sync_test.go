@@ -94,7 +94,7 @@ func TestSyncToMemgraph_EmptyGraph(t *testing.T) {
 	sf := mockSessionFactory(sess)
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -126,7 +126,7 @@ func TestSyncToMemgraph_SmallBatch(t *testing.T) {
 	sf := mockSessionFactory(sess)
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -151,7 +151,7 @@ func TestSyncToMemgraph_LargeBatch(t *testing.T) {
 	sf := mockSessionFactory(sess)
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -162,6 +162,64 @@ func TestSyncToMemgraph_LargeBatch(t *testing.T) {
 	}
 }
 
+func TestSyncToMemgraph_RunCallsScaleWithBatches(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
+
+	for _, tc := range []struct {
+		nodes, batchSize, wantBatches int
+	}{
+		{nodes: 100, batchSize: 10, wantBatches: 10},
+		{nodes: 100, batchSize: 30, wantBatches: 4},
+		{nodes: 100, batchSize: 100, wantBatches: 1},
+		{nodes: 100, batchSize: 1000, wantBatches: 1},
+	} {
+		store := newTestStore(t)
+		nodes := make([]models.Node, tc.nodes)
+		edges := make([]models.Edge, tc.nodes-1)
+		for i := range nodes {
+			nodes[i] = makeNode(fmt.Sprintf("n%d", i), models.AssetVM, "tf")
+			if i > 0 {
+				edges[i-1] = makeEdge(nodes[i-1].ID, nodes[i].ID, models.EdgeDependsOn)
+			}
+		}
+		buildTestGraph(t, store, nodes, edges)
+
+		sess := &mockSession{}
+		if err := syncToMemgraph(ctx, store, mockSessionFactory(sess), tc.batchSize, logger); err != nil {
+			t.Fatal(err)
+		}
+
+		var nodeBatches, edgeBatches, nodeRows, edgeRows int
+		for _, c := range sess.calls {
+			if rows, ok := c.params["nodes"].([]map[string]any); ok {
+				nodeBatches++
+				nodeRows += len(rows)
+				if len(rows) > tc.batchSize {
+					t.Errorf("batch size %d: node batch of %d rows", tc.batchSize, len(rows))
+				}
+			}
+			if rows, ok := c.params["edges"].([]map[string]any); ok {
+				edgeBatches++
+				edgeRows += len(rows)
+				if !strings.Contains(c.cypher, "UNWIND $edges") || !strings.Contains(c.cypher, "MERGE") {
+					t.Errorf("edge batch is not an UNWIND MERGE: %s", c.cypher)
+				}
+			}
+		}
+		if nodeBatches != tc.wantBatches {
+			t.Errorf("batch size %d: %d node Run calls, want %d", tc.batchSize, nodeBatches, tc.wantBatches)
+		}
+		if nodeRows != tc.nodes || edgeRows != tc.nodes-1 {
+			t.Errorf("batch size %d: sent %d nodes and %d edges, want %d and %d", tc.batchSize, nodeRows, edgeRows, tc.nodes, tc.nodes-1)
+		}
+		// 1 clear + 3 indexes + node batches + edge batches; nothing per node.
+		if want := 4 + tc.wantBatches + edgeBatches; len(sess.calls) != want {
+			t.Errorf("batch size %d: %d Run calls, want %d", tc.batchSize, len(sess.calls), want)
+		}
+	}
+}
+
 func TestSyncToMemgraph_ClearError(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -169,7 +227,7 @@ func TestSyncToMemgraph_ClearError(t *testing.T) {
 	sf := failSessionFactory(fmt.Errorf("clear failed"))
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -198,7 +256,7 @@ func TestSyncToMemgraph_NodeSyncError(t *testing.T) {
 	sf := mockSessionFactory(sess)
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -235,7 +293,7 @@ func TestSyncToMemgraph_EdgeSyncError(t *testing.T) {
 	sf := mockSessionFactory(sess)
 	logger := slog.New(slog.NewTextHandler(nopWriter{}, nil))
 
-	err := syncToMemgraph(ctx, store, sf, logger)
+	err := syncToMemgraph(ctx, store, sf, defaultSyncBatchSize, logger)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -243,3 +301,147 @@ func TestSyncToMemgraph_EdgeSyncError(t *testing.T) {
 		t.Errorf("error = %q", err.Error())
 	}
 }
+
+// flakySessionFactory returns sessions sharing sess whose first failures
+// Run calls fail.
+func flakySessionFactory(sess *mockSession, failures int) sessionFactory {
+	calls := 0
+	sess.runFunc = func(_ string, _ map[string]any) (resultIterator, error) {
+		calls++
+		if calls <= failures {
+			return nil, fmt.Errorf("connection refused")
+		}
+		return &mockResult{}, nil
+	}
+	return mockSessionFactory(sess)
+}
+
+// mergedNodeIDs returns the IDs of the nodes sent in MERGE batches.
+func mergedNodeIDs(calls []mockRunCall) []string {
+	var ids []string
+	for _, c := range calls {
+		nodes, _ := c.params["nodes"].([]map[string]any)
+		for _, n := range nodes {
+			ids = append(ids, n["id"].(string))
+		}
+	}
+	return ids
+}
+
+func newTestSyncer(store *SQLiteStore, sf sessionFactory) *MemgraphSyncer {
+	m := newMemgraphSyncer(store, sf, slog.New(slog.NewTextHandler(nopWriter{}, nil)))
+	m.Backoff = time.Millisecond
+	return m
+}
+
+func TestMemgraphSyncer_RetriesUntilSynced(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	_ = store.UpsertNode(ctx, makeNode("A", models.AssetVM, "tf"))
+
+	// The first two attempts fail on their first statement.
+	sess := &mockSession{}
+	syncer := newTestSyncer(store, flakySessionFactory(sess, 2))
+	if err := syncer.SyncFull(ctx); err != nil {
+		t.Fatalf("SyncFull: %v", err)
+	}
+	if ids := mergedNodeIDs(sess.calls); len(ids) != 1 || ids[0] != "A" {
+		t.Errorf("merged nodes = %v, want [A]", ids)
+	}
+	if _, ok, _ := store.SyncedAt(ctx, memgraphSyncName); !ok {
+		t.Error("synced_at not recorded after a successful sync")
+	}
+}
+
+func TestMemgraphSyncer_GivesUp(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	_ = store.UpsertNode(ctx, makeNode("A", models.AssetVM, "tf"))
+
+	sess := &mockSession{}
+	syncer := newTestSyncer(store, flakySessionFactory(sess, 100))
+	err := syncer.SyncFull(ctx)
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("error = %v, want failure after 3 attempts", err)
+	}
+	if len(sess.calls) != 3 {
+		t.Errorf("Run calls = %d, want 3", len(sess.calls))
+	}
+	if _, ok, _ := store.SyncedAt(ctx, memgraphSyncName); ok {
+		t.Error("synced_at recorded for a failed sync")
+	}
+
+	// Once Memgraph is back, the next sync pushes what was missed.
+	sess = &mockSession{}
+	syncer = newTestSyncer(store, mockSessionFactory(sess))
+	if err := syncer.SyncChanged(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ids := mergedNodeIDs(sess.calls); len(ids) != 1 || ids[0] != "A" {
+		t.Errorf("merged nodes = %v, want [A]", ids)
+	}
+}
+
+func TestMemgraphSyncer_SyncChanged(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("old", models.AssetNetwork, "tf")},
+		nil,
+	)
+	// Pretend everything so far was synced.
+	if _, err := store.db.ExecContext(ctx, `UPDATE nodes SET updated_at = '2020-01-01T00:00:00Z'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSyncedAt(ctx, memgraphSyncName, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	buildTestGraph(t, store,
+		[]models.Node{makeNode("new", models.AssetVM, "tf")},
+		[]models.Edge{makeEdge("new", "old", models.EdgeDependsOn)},
+	)
+
+	sess := &mockSession{}
+	syncer := newTestSyncer(store, mockSessionFactory(sess))
+	if err := syncer.SyncChanged(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ids := mergedNodeIDs(sess.calls); len(ids) != 1 || ids[0] != "new" {
+		t.Errorf("merged nodes = %v, want only the changed node", ids)
+	}
+	var sawDelete, sawEdge bool
+	for _, c := range sess.calls {
+		if strings.Contains(c.cypher, "DETACH DELETE n") {
+			t.Error("incremental sync cleared memgraph")
+		}
+		if strings.Contains(c.cypher, "NOT a.id IN $ids") {
+			sawDelete = true
+			if ids := c.params["ids"].([]string); len(ids) != 2 {
+				t.Errorf("kept ids = %v, want both nodes", ids)
+			}
+		}
+		if edges, ok := c.params["edges"].([]map[string]any); ok && len(edges) == 1 && edges[0]["fromID"] == "new" {
+			sawEdge = true
+		}
+	}
+	if !sawDelete {
+		t.Error("deleted nodes were not removed")
+	}
+	if !sawEdge {
+		t.Error("edge of the changed node was not pushed")
+	}
+}
+
+func TestMemgraphSyncer_FirstSyncIsFull(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+	_ = store.UpsertNode(ctx, makeNode("A", models.AssetVM, "tf"))
+
+	sess := &mockSession{}
+	if err := newTestSyncer(store, mockSessionFactory(sess)).SyncChanged(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(sess.calls) == 0 || sess.calls[0].cypher != "MATCH (n) DETACH DELETE n" {
+		t.Errorf("first sync did not start by clearing memgraph: %+v", sess.calls)
+	}
+}
@@ -0,0 +1,31 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits on node tags, which arrive from the API and the command line.
+const (
+	maxTagKeyLen   = 128
+	maxTagValueLen = 1024
+)
+
+// ValidateTag reports whether key and value can be stored as a node tag.
+// Keys are used as tags.<key> in filter expressions, so they may not
+// contain whitespace, quotes, parentheses, = or !.
+func ValidateTag(key, value string) error {
+	if key == "" {
+		return fmt.Errorf("empty tag key")
+	}
+	if len(key) > maxTagKeyLen {
+		return fmt.Errorf("tag key longer than %d bytes", maxTagKeyLen)
+	}
+	if strings.ContainsAny(key, exprDelimiters) {
+		return fmt.Errorf("tag key %q may not contain whitespace, quotes, parentheses, = or !", key)
+	}
+	if len(value) > maxTagValueLen {
+		return fmt.Errorf("tag %s: value longer than %d bytes", key, maxTagValueLen)
+	}
+	return nil
+}
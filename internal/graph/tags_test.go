@@ -0,0 +1,29 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTag(t *testing.T) {
+	for _, key := range []string{"owner", "team.name", "cost-center", "app/tier"} {
+		if err := ValidateTag(key, "x"); err != nil {
+			t.Errorf("ValidateTag(%q) = %v, want nil", key, err)
+		}
+	}
+	tests := []struct {
+		key, value, want string
+	}{
+		{"", "x", "empty tag key"},
+		{"two words", "x", "may not contain"},
+		{"a=b", "x", "may not contain"},
+		{`"quoted"`, "x", "may not contain"},
+		{strings.Repeat("k", 129), "x", "key longer than"},
+		{"owner", strings.Repeat("v", 1025), "value longer than"},
+	}
+	for _, tt := range tests {
+		if err := ValidateTag(tt.key, tt.value); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ValidateTag(%.20q) = %v, want %q", tt.key, err, tt.want)
+		}
+	}
+}
@@ -68,6 +68,7 @@ func (p *AnsibleParser) Parse(ctx context.Context, path string) (*parser.ParseRe
 	}
 
 	hostMap := deduplicateHosts(allHosts)
+	result.Warnings = append(result.Warnings, applyVarsDirs(inventoryFiles, hostMap)...)
 
 	// Deterministic output order
 	var hostnames []string
@@ -92,6 +93,10 @@ func (p *AnsibleParser) Parse(ctx context.Context, path string) (*parser.ParseRe
 		result.Nodes = append(result.Nodes, node)
 	}
 
+	groupResult := buildGroupGraph(hostMap, hostnames, now)
+	result.Nodes = append(result.Nodes, groupResult.Nodes...)
+	result.Edges = append(result.Edges, groupResult.Edges...)
+
 	depResult := inferHostDependencies(hostMap, hostnames, now)
 	result.Nodes = append(result.Nodes, depResult.Nodes...)
 	result.Edges = append(result.Edges, depResult.Edges...)
@@ -15,8 +15,8 @@ func TestParse_INIInventory(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(result.Nodes) != 3 {
-		t.Errorf("nodes = %d, want 3 (web1, web2, db1)", len(result.Nodes))
+	if len(result.Nodes) != 6 {
+		t.Errorf("nodes = %d, want 6 (web1, web2, db1 + 3 groups)", len(result.Nodes))
 	}
 
 	nodeMap := make(map[string]models.Node)
@@ -47,8 +47,65 @@ func TestParse_YAMLInventory(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(result.Nodes) != 3 {
-		t.Errorf("nodes = %d, want 3", len(result.Nodes))
+	if len(result.Nodes) != 5 {
+		t.Errorf("nodes = %d, want 5 (3 hosts + 2 groups)", len(result.Nodes))
+	}
+}
+
+func TestParse_GroupAndHostVars(t *testing.T) {
+	p := NewAnsibleParser("")
+	result, err := p.Parse(context.Background(), "testdata/vars/inventory.ini")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeMap := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodeMap[n.ID] = n
+	}
+
+	web1 := nodeMap["ansible:vm:web1"].Metadata
+	if web1["ansible_user"] != "deploy" || web1["ntp_server"] != "ntp.example.com" {
+		t.Errorf("group_vars/all not applied to web1: %v", web1)
+	}
+	if web1["http_port"] != "80" {
+		t.Errorf("web1 http_port = %q, want 80 from group_vars/webservers/", web1["http_port"])
+	}
+	if web1["app_env"] != "production" {
+		t.Errorf("web1 app_env = %q, want production group to override all", web1["app_env"])
+	}
+	if _, ok := web1["packages"]; ok {
+		t.Error("list values should not become metadata")
+	}
+	if _, ok := web1["db_password"]; ok {
+		t.Error("vaulted values should not become metadata")
+	}
+	if port := nodeMap["ansible:vm:web2"].Metadata["http_port"]; port != "8080" {
+		t.Errorf("web2 http_port = %q, want inventory value 8080", port)
+	}
+	db1 := nodeMap["ansible:vm:db1"].Metadata
+	if db1["ansible_user"] != "postgres" || db1["backup_window"] != "02:00" {
+		t.Errorf("host_vars/db1 not applied: %v", db1)
+	}
+
+	group, ok := nodeMap["ansible:instance_group:production"]
+	if !ok {
+		t.Fatal("missing production group node")
+	}
+	if group.Type != models.AssetInstanceGroup {
+		t.Errorf("group type = %q, want instance_group", group.Type)
+	}
+	members := 0
+	for _, e := range result.Edges {
+		if e.ToID == group.ID && e.Type == models.EdgeMemberOf {
+			members++
+		}
+	}
+	if members != 3 {
+		t.Errorf("production members = %d, want 3", members)
+	}
+	if _, ok := nodeMap["ansible:instance_group:all"]; ok {
+		t.Error("implicit all group should not become a node")
 	}
 }
 
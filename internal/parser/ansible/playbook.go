@@ -13,12 +13,47 @@ import (
 	"go.yaml.in/yaml/v3"
 )
 
+// maxRoleDepth bounds nested role inclusion, which also stops cycles.
+const maxRoleDepth = 5
+
 type ansiblePlay struct {
 	Name  string        `yaml:"name"`
 	Hosts string        `yaml:"hosts"`
+	Roles []roleRef     `yaml:"roles"`
 	Tasks []ansibleTask `yaml:"tasks"`
 }
 
+// roleRef handles both the short ("common") and long ({role: common})
+// forms of a role reference.
+type roleRef struct {
+	Name string
+}
+
+func (r *roleRef) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		r.Name = node.Value
+		return nil
+	case yaml.MappingNode:
+		var m map[string]interface{}
+		if err := node.Decode(&m); err != nil {
+			return err
+		}
+		r.Name = stringFromMap(m, "role")
+		if r.Name == "" {
+			r.Name = stringFromMap(m, "name")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported role type: %v", node.Kind)
+	}
+}
+
+// roleMeta is a role's meta/main.yml; its dependencies run before the role.
+type roleMeta struct {
+	Dependencies []roleRef `yaml:"dependencies"`
+}
+
 // ansibleTask checks known module keys for infrastructure-relevant tasks.
 type ansibleTask struct {
 	Name                     string                 `yaml:"name"`
@@ -28,6 +63,19 @@ type ansibleTask struct {
 	Yum                      map[string]interface{} `yaml:"yum"`
 	Dnf                      map[string]interface{} `yaml:"dnf"`
 	Service                  map[string]interface{} `yaml:"service"`
+	PostgresqlDB             map[string]interface{} `yaml:"postgresql_db"`
+	CommunityPostgresqlDB    map[string]interface{} `yaml:"community.postgresql.postgresql_db"`
+	MysqlDB                  map[string]interface{} `yaml:"mysql_db"`
+	CommunityMysqlDB         map[string]interface{} `yaml:"community.mysql.mysql_db"`
+	IncludeRole              map[string]interface{} `yaml:"include_role"`
+	ImportRole               map[string]interface{} `yaml:"import_role"`
+}
+
+// sourcedTask is a task with the file it was read from, which is the
+// playbook itself or a role's tasks file.
+type sourcedTask struct {
+	task ansibleTask
+	file string
 }
 
 func parsePlaybooksDir(ctx context.Context, dir string, hostMap map[string]hostEntry, now time.Time) (*parser.ParseResult, error) {
@@ -73,11 +121,28 @@ func parsePlaybookFile(ctx context.Context, path string, hostMap map[string]host
 	}
 
 	result := &parser.ParseResult{}
+	rolesDir := filepath.Join(filepath.Dir(path), "roles")
 
 	for _, play := range plays {
 		targetHosts := resolveHostPattern(play.Hosts, hostMap)
 
+		// Roles run before the play's own tasks.
+		var tasks []sourcedTask
+		for _, role := range play.Roles {
+			tasks = append(tasks, loadRoleTasks(rolesDir, role.Name, 0, &result.Warnings)...)
+		}
 		for _, task := range play.Tasks {
+			// include_role/import_role pull the role's tasks in place.
+			if inc := firstNonNil(task.IncludeRole, task.ImportRole); inc != nil {
+				tasks = append(tasks, loadRoleTasks(rolesDir, stringFromMap(inc, "name"), 0, &result.Warnings)...)
+				continue
+			}
+			tasks = append(tasks, sourcedTask{task: task, file: path})
+		}
+
+		for _, st := range tasks {
+			task, taskFile := st.task, st.file
+
 			dockerMod := task.DockerContainer
 			if dockerMod == nil {
 				dockerMod = task.CommunityDockerContainer
@@ -93,7 +158,7 @@ func parsePlaybookFile(ctx context.Context, path string, hostMap map[string]host
 					Name:       containerName,
 					Type:       models.AssetContainer,
 					Source:     "ansible",
-					SourceFile: path,
+					SourceFile: taskFile,
 					Provider:   "docker",
 					Metadata:   extractDockerMetadata(dockerMod),
 					LastSeen:   now,
@@ -125,7 +190,7 @@ func parsePlaybookFile(ctx context.Context, path string, hostMap map[string]host
 						Name:       svcName,
 						Type:       models.AssetService,
 						Source:     "ansible",
-						SourceFile: path,
+						SourceFile: taskFile,
 						Provider:   "systemd",
 						Metadata:   map[string]string{"state": state},
 						LastSeen:   now,
@@ -146,12 +211,173 @@ func parsePlaybookFile(ctx context.Context, path string, hostMap map[string]host
 					}
 				}
 			}
+
+			// Database modules → a database node on each target host
+			for _, db := range []struct {
+				args   map[string]interface{}
+				module string
+				scheme string
+				port   string
+			}{
+				{firstNonNil(task.PostgresqlDB, task.CommunityPostgresqlDB), "postgresql_db", "postgres", "5432"},
+				{firstNonNil(task.MysqlDB, task.CommunityMysqlDB), "mysql_db", "mysql", "3306"},
+			} {
+				if db.args == nil {
+					continue
+				}
+				for _, hostname := range targetHosts {
+					dbName := renderHostVars(stringFromMap(db.args, "name"), hostMap[hostname])
+					if dbName == "" {
+						result.Warnings = append(result.Warnings, fmt.Sprintf("%s: task %q on %s: cannot resolve database name", taskFile, task.Name, hostname))
+						continue
+					}
+					dbID := fmt.Sprintf("ansible:database:%s@%s", sanitizeNodePart(dbName), sanitizeNodePart(hostname))
+					meta := map[string]string{
+						"db_name": dbName,
+						"db_host": hostname,
+						"db_port": db.port,
+						"module":  db.module,
+					}
+					if port, ok := db.args["port"]; ok {
+						meta["db_port"] = fmt.Sprint(port)
+					}
+					if state := stringFromMap(db.args, "state"); state != "" {
+						meta["state"] = state
+					}
+					result.Nodes = append(result.Nodes, models.Node{
+						ID:         dbID,
+						Name:       fmt.Sprintf("%s@%s", dbName, hostname),
+						Type:       models.AssetDatabase,
+						Source:     "ansible",
+						SourceFile: taskFile,
+						Provider:   db.scheme,
+						Metadata:   meta,
+						LastSeen:   now,
+						FirstSeen:  now,
+					})
+					hostNodeID := fmt.Sprintf("ansible:vm:%s", hostname)
+					result.Edges = append(result.Edges, models.Edge{
+						ID:       fmt.Sprintf("%s->managed_by->%s", dbID, hostNodeID),
+						FromID:   dbID,
+						ToID:     hostNodeID,
+						Type:     models.EdgeManagedBy,
+						Metadata: map[string]string{"task": task.Name, "module": db.module},
+					})
+				}
+			}
 		}
 	}
 
 	return result, nil
 }
 
+// loadRoleTasks reads roles/<name>/tasks/main.yml under rolesDir, preceded
+// by the tasks of the role's meta dependencies. Missing roles (e.g. ones
+// installed from Galaxy elsewhere) produce a warning and no tasks.
+func loadRoleTasks(rolesDir, name string, depth int, warnings *[]string) []sourcedTask {
+	if name == "" {
+		return nil
+	}
+	if depth > maxRoleDepth {
+		*warnings = append(*warnings, fmt.Sprintf("role %s: nested more than %d levels", name, maxRoleDepth))
+		return nil
+	}
+	roleDir := filepath.Join(rolesDir, name)
+
+	var tasks []sourcedTask
+	if data, _, ok := readRoleFile(filepath.Join(roleDir, "meta"), warnings); ok {
+		var meta roleMeta
+		if err := yaml.Unmarshal(data, &meta); err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("role %s meta: %v", name, err))
+		}
+		for _, dep := range meta.Dependencies {
+			tasks = append(tasks, loadRoleTasks(rolesDir, dep.Name, depth+1, warnings)...)
+		}
+	}
+
+	tasksDir := filepath.Join(roleDir, "tasks")
+	data, tasksFile, ok := readRoleFile(tasksDir, warnings)
+	if !ok {
+		*warnings = append(*warnings, fmt.Sprintf("role %s: no tasks found in %s", name, tasksDir))
+		return tasks
+	}
+	var roleTasks []ansibleTask
+	if err := yaml.Unmarshal(data, &roleTasks); err != nil {
+		*warnings = append(*warnings, fmt.Sprintf("role %s tasks: %v", name, err))
+		return tasks
+	}
+	for _, t := range roleTasks {
+		if inc := firstNonNil(t.IncludeRole, t.ImportRole); inc != nil {
+			tasks = append(tasks, loadRoleTasks(rolesDir, stringFromMap(inc, "name"), depth+1, warnings)...)
+			continue
+		}
+		tasks = append(tasks, sourcedTask{task: t, file: tasksFile})
+	}
+	return tasks
+}
+
+// readRoleFile reads main.yml (or main.yaml) from a role subdirectory.
+func readRoleFile(dir string, warnings *[]string) ([]byte, string, bool) {
+	for _, name := range []string{"main.yml", "main.yaml"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		resolved, err := parser.SafeResolvePath(path)
+		if err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("skipping %s: %v", path, err))
+			return nil, "", false
+		}
+		data, err := os.ReadFile(resolved) // #nosec G304 -- path validated by SafeResolvePath
+		if err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("reading %s: %v", path, err))
+			return nil, "", false
+		}
+		return data, path, true
+	}
+	return nil, "", false
+}
+
+// maxVarSubstitutions bounds renderHostVars so that self-referencing or
+// cyclic variables fail to resolve instead of looping forever.
+const maxVarSubstitutions = 64
+
+// renderHostVars substitutes "{{ var }}" references with the host's
+// variables, following variables that reference other variables. It
+// returns "" if any reference can't be resolved, since a half-rendered name
+// would create a bogus node.
+func renderHostVars(s string, h hostEntry) string {
+	for range maxVarSubstitutions {
+		start := strings.Index(s, "{{")
+		if start < 0 {
+			return s
+		}
+		end := strings.Index(s[start:], "}}")
+		if end < 0 {
+			return ""
+		}
+		name := strings.TrimSpace(s[start+2 : start+end])
+		v, ok := h.vars[name]
+		if !ok {
+			return ""
+		}
+		s = s[:start] + v + s[start+end+2:]
+	}
+	if strings.Contains(s, "{{") {
+		return ""
+	}
+	return s
+}
+
+func firstNonNil(maps ...map[string]interface{}) map[string]interface{} {
+	for _, m := range maps {
+		if m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
 // resolveHostPattern resolves an Ansible hosts pattern to actual hostnames.
 // Supports: "all", specific hostname, group name, comma-separated lists.
 func resolveHostPattern(pattern string, hostMap map[string]hostEntry) []string {
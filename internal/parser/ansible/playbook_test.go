@@ -87,6 +87,33 @@ func TestResolveHostPattern(t *testing.T) {
 	}
 }
 
+func TestRenderHostVars(t *testing.T) {
+	h := hostEntry{hostname: "db1", vars: map[string]string{
+		"env":     "prod",
+		"db_name": "app_{{ env }}",
+		"self":    "{{ self }}",
+		"ping":    "{{ pong }}",
+		"pong":    "x{{ ping }}",
+	}}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{"{{ db_name }}", "app_prod"},
+		{"{{ missing }}", ""},
+		{"{{ unterminated", ""},
+		{"{{ self }}", ""},
+		{"{{ ping }}", ""},
+	}
+	for _, tt := range tests {
+		if got := renderHostVars(tt.in, h); got != tt.want {
+			t.Errorf("renderHostVars(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestExtractDockerMetadata(t *testing.T) {
 	m := map[string]interface{}{
 		"name":           "webapp",
@@ -107,3 +134,51 @@ func TestExtractDockerMetadata(t *testing.T) {
 		t.Error("extra_field should not be in metadata")
 	}
 }
+
+func TestParsePlaybookFile_RolesAndDatabases(t *testing.T) {
+	hostMap := map[string]hostEntry{
+		"db1": {hostname: "db1", groups: []string{"dbservers"}, vars: map[string]string{"reporting_db": "reports"}},
+		"db2": {hostname: "db2", groups: []string{"dbservers"}, vars: map[string]string{}},
+	}
+
+	result, err := parsePlaybookFile(context.Background(), "testdata/dbplay/site.yml", hostMap, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	orders, ok := nodes["ansible:database:orders@db1"]
+	if !ok {
+		t.Fatalf("missing orders database from postgres role, got %v", result.Nodes)
+	}
+	if orders.Type != models.AssetDatabase || orders.Provider != "postgres" || orders.Metadata["db_port"] != "5433" {
+		t.Errorf("unexpected orders node: %+v", orders)
+	}
+	if orders.SourceFile != "testdata/dbplay/roles/postgres/tasks/main.yml" {
+		t.Errorf("orders source file = %q, want the role's tasks file", orders.SourceFile)
+	}
+	for _, id := range []string{"ansible:database:orders@db2", "ansible:database:reports@db1", "ansible:service:postgresql", "ansible:container:node-exporter"} {
+		if _, ok := nodes[id]; !ok {
+			t.Errorf("missing node %s", id)
+		}
+	}
+	if _, ok := nodes["ansible:database:reports@db2"]; ok {
+		t.Error("unresolved templated database name should not create a node")
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("warnings = %v, want one for db2's unresolved name", result.Warnings)
+	}
+
+	var managed bool
+	for _, e := range result.Edges {
+		if e.FromID == "ansible:database:orders@db1" && e.ToID == "ansible:vm:db1" && e.Type == models.EdgeManagedBy {
+			managed = e.Metadata["module"] == "postgresql_db"
+		}
+	}
+	if !managed {
+		t.Error("missing orders@db1 managed_by db1 edge")
+	}
+}
@@ -0,0 +1,170 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+	"go.yaml.in/yaml/v3"
+)
+
+// varsFileExts are the extensions Ansible reads group_vars and host_vars
+// files with; "" covers files named after the group or host alone.
+var varsFileExts = []string{"", ".yml", ".yaml", ".json"}
+
+// applyVarsDirs merges the group_vars/ and host_vars/ directories next to
+// each inventory file into the hosts' vars. Group vars ("all", then the
+// host's groups in name order, later ones winning) fill in keys the
+// inventory doesn't set; host vars override everything.
+func applyVarsDirs(inventoryFiles []string, hostMap map[string]hostEntry) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, invFile := range inventoryFiles {
+		dir := filepath.Dir(invFile)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		for hostname, h := range hostMap {
+			groups := append([]string(nil), h.groups...)
+			sort.Strings(groups)
+			groups = append([]string{"all"}, groups...)
+			groupVars := make(map[string]string)
+			for _, g := range groups {
+				vars, w := loadVars(filepath.Join(dir, "group_vars"), g)
+				warnings = append(warnings, w...)
+				for k, v := range vars {
+					groupVars[k] = v
+				}
+			}
+			for k, v := range groupVars {
+				if _, exists := h.vars[k]; !exists {
+					h.vars[k] = v
+				}
+			}
+			vars, w := loadVars(filepath.Join(dir, "host_vars"), hostname)
+			warnings = append(warnings, w...)
+			for k, v := range vars {
+				h.vars[k] = v
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// loadVars reads the vars for name from dir: a file named name (with any of
+// varsFileExts), or every YAML/JSON file in a directory named name. Only
+// scalar values are kept, since metadata is flat.
+func loadVars(dir, name string) (map[string]string, []string) {
+	var files []string
+	base := filepath.Join(dir, name)
+	if info, err := os.Stat(base); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(base)
+		if err != nil {
+			return nil, []string{fmt.Sprintf("reading %s: %v", base, err)}
+		}
+		for _, e := range entries {
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			if !e.IsDir() && (ext == ".yml" || ext == ".yaml" || ext == ".json") {
+				files = append(files, filepath.Join(base, e.Name()))
+			}
+		}
+	} else {
+		for _, ext := range varsFileExts {
+			if info, err := os.Stat(base + ext); err == nil && !info.IsDir() {
+				files = append(files, base+ext)
+				break
+			}
+		}
+	}
+
+	vars := make(map[string]string)
+	var warnings []string
+	for _, f := range files {
+		resolved, err := parser.SafeResolvePath(f)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("skipping %s: %v", f, err))
+			continue
+		}
+		data, err := os.ReadFile(resolved) // #nosec G304 -- path validated by SafeResolvePath
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("reading %s: %v", f, err))
+			continue
+		}
+		if strings.HasPrefix(string(data), "$ANSIBLE_VAULT") {
+			warnings = append(warnings, fmt.Sprintf("skipping vault-encrypted %s", f))
+			continue
+		}
+		var doc map[string]yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			warnings = append(warnings, fmt.Sprintf("parsing %s: %v", f, err))
+			continue
+		}
+		for k, v := range doc {
+			// Skip nested values and inline-vaulted secrets.
+			if v.Kind == yaml.ScalarNode && v.Tag != "!vault" && v.Tag != "!!null" {
+				vars[k] = v.Value
+			}
+		}
+	}
+	return vars, warnings
+}
+
+// buildGroupGraph creates a node per inventory group and a member_of edge
+// from each host to every group it belongs to. The implicit "all" group is
+// left out, since every host is in it.
+func buildGroupGraph(hostMap map[string]hostEntry, hostnames []string, now time.Time) *parser.ParseResult {
+	result := &parser.ParseResult{}
+	groupFiles := make(map[string]string)
+	for _, hostname := range hostnames {
+		h := hostMap[hostname]
+		hostID := fmt.Sprintf("ansible:vm:%s", hostname)
+		groups := append([]string(nil), h.groups...)
+		sort.Strings(groups)
+		for i, g := range groups {
+			if g == "all" || (i > 0 && groups[i-1] == g) {
+				continue
+			}
+			groupID := fmt.Sprintf("ansible:instance_group:%s", g)
+			if _, ok := groupFiles[g]; !ok {
+				groupFiles[g] = h.sourceFile
+			}
+			result.Edges = append(result.Edges, models.Edge{
+				ID:     fmt.Sprintf("%s->member_of->%s", hostID, groupID),
+				FromID: hostID,
+				ToID:   groupID,
+				Type:   models.EdgeMemberOf,
+				Metadata: map[string]string{
+					"via": "inventory_group",
+				},
+			})
+		}
+	}
+
+	var groups []string
+	for g := range groupFiles {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+	for _, g := range groups {
+		result.Nodes = append(result.Nodes, models.Node{
+			ID:         fmt.Sprintf("ansible:instance_group:%s", g),
+			Name:       g,
+			Type:       models.AssetInstanceGroup,
+			Source:     "ansible",
+			SourceFile: groupFiles[g],
+			Provider:   "ansible",
+			Metadata:   map[string]string{},
+			LastSeen:   now,
+			FirstSeen:  now,
+		})
+	}
+	return result
+}
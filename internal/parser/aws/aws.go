@@ -0,0 +1,430 @@
+// Package aws discovers running AWS resources through the AWS SDK: EC2
+// instances, VPCs, subnets and security groups, RDS instances, S3 buckets
+// and Elastic Load Balancers (v2).
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// EC2API is the subset of the EC2 client used for discovery.
+type EC2API interface {
+	ec2.DescribeInstancesAPIClient
+	ec2.DescribeVpcsAPIClient
+	ec2.DescribeSubnetsAPIClient
+	ec2.DescribeSecurityGroupsAPIClient
+}
+
+// Clients are the AWS service clients for one region.
+type Clients struct {
+	EC2 EC2API
+	RDS rds.DescribeDBInstancesAPIClient
+	ELB elb.DescribeLoadBalancersAPIClient
+	S3  s3.ListBucketsAPIClient
+}
+
+// newClientsFn builds the clients for a region. Replaced in tests with
+// stubs.
+var newClientsFn = newClients
+
+// newClients loads the shared AWS config the way the AWS CLI does, using
+// profile if set, and returns clients for region (or the configured
+// region if empty) along with the region they use.
+func newClients(ctx context.Context, region, profile string) (*Clients, string, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+	if profile != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profile))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, "", fmt.Errorf("loading AWS config: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, "", fmt.Errorf("no AWS region configured: pass --region or set AWS_REGION")
+	}
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, "", fmt.Errorf("no usable AWS credentials (set AWS_PROFILE or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, or use an instance role): %w", err)
+	}
+	return &Clients{
+		EC2: ec2.NewFromConfig(cfg),
+		RDS: rds.NewFromConfig(cfg),
+		ELB: elb.NewFromConfig(cfg),
+		S3:  s3.NewFromConfig(cfg),
+	}, cfg.Region, nil
+}
+
+// Fetch discovers the resources in each of regions, or in the configured
+// default region if regions is empty. profile selects a shared config
+// profile. A resource listing that fails (e.g. for lack of permission) is
+// reported as a warning and counted in PathsFailed; Fetch only fails if no
+// listing succeeds.
+func Fetch(ctx context.Context, regions []string, profile string) (*parser.ParseResult, error) {
+	ctx, cancel := parser.WithDefaultCommandTimeout(ctx)
+	defer cancel()
+
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	result := &parser.ParseResult{}
+	for _, region := range regions {
+		clients, resolved, err := newClientsFn(ctx, region, profile)
+		if err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "discovering AWS resources", "region", resolved)
+		r := discover(ctx, clients, resolved)
+		result.Nodes = append(result.Nodes, r.Nodes...)
+		result.Edges = append(result.Edges, r.Edges...)
+		result.Warnings = append(result.Warnings, r.Warnings...)
+		result.PathsScanned += r.PathsScanned
+		result.PathsFailed += r.PathsFailed
+	}
+	if result.PathsScanned == 0 {
+		return nil, fmt.Errorf("all AWS resource listings failed: %s", strings.Join(result.Warnings, "; "))
+	}
+	return result, nil
+}
+
+// discovery accumulates the nodes and edges of one region.
+type discovery struct {
+	region string
+	now    time.Time
+	result *parser.ParseResult
+	known  map[string]bool
+	edges  []models.Edge
+}
+
+// discover lists every supported resource type in region. Edges are only
+// kept when both ends were discovered.
+func discover(ctx context.Context, c *Clients, region string) *parser.ParseResult {
+	d := &discovery{
+		region: region,
+		now:    time.Now(),
+		result: &parser.ParseResult{},
+		known:  make(map[string]bool),
+	}
+	listings := []struct {
+		name string
+		list func(context.Context, *Clients) error
+	}{
+		{"VPCs", d.listVPCs},
+		{"subnets", d.listSubnets},
+		{"security groups", d.listSecurityGroups},
+		{"EC2 instances", d.listInstances},
+		{"RDS instances", d.listDBInstances},
+		{"load balancers", d.listLoadBalancers},
+		{"S3 buckets", d.listBuckets},
+	}
+	for _, l := range listings {
+		if err := l.list(ctx, c); err != nil {
+			d.result.Warnings = append(d.result.Warnings, fmt.Sprintf("%s: listing %s: %v", region, l.name, err))
+			d.result.PathsFailed++
+			continue
+		}
+		d.result.PathsScanned++
+	}
+
+	seen := make(map[string]bool, len(d.edges))
+	for _, e := range d.edges {
+		if d.known[e.FromID] && d.known[e.ToID] && !seen[e.ID] {
+			seen[e.ID] = true
+			d.result.Edges = append(d.result.Edges, e)
+		}
+	}
+	return d.result
+}
+
+// NodeID returns the ID of the AWS resource with the given asset type and
+// resource ID, e.g. "aws:vm:i-0abc123". Resources named rather than given
+// IDs by AWS, whose names are only unique within a region, pass
+// "region/name", e.g. "aws:database:eu-west-1/orders".
+func NodeID(t models.AssetType, id string) string {
+	return fmt.Sprintf("aws:%s:%s", t, id)
+}
+
+func (d *discovery) addNode(t models.AssetType, id, name string, tags map[string]string, meta map[string]string) string {
+	nodeID := NodeID(t, id)
+	if name == "" {
+		name = id
+	}
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	for k, v := range tags {
+		meta["tag:"+k] = v
+	}
+	meta["region"] = d.region
+	d.known[nodeID] = true
+	d.result.Nodes = append(d.result.Nodes, models.Node{
+		ID:         nodeID,
+		Name:       name,
+		Type:       t,
+		Source:     "aws",
+		SourceFile: "aws:" + d.region,
+		Provider:   "aws",
+		Metadata:   meta,
+		LastSeen:   d.now,
+		FirstSeen:  d.now,
+	})
+	return nodeID
+}
+
+func (d *discovery) addEdge(fromID string, toType models.AssetType, toID string, t models.EdgeType) {
+	if toID == "" {
+		return
+	}
+	target := NodeID(toType, toID)
+	d.edges = append(d.edges, models.Edge{
+		ID:     fmt.Sprintf("%s->%s->%s", fromID, t, target),
+		FromID: fromID,
+		ToID:   target,
+		Type:   t,
+	})
+}
+
+func (d *discovery) listVPCs(ctx context.Context, c *Clients) error {
+	p := ec2.NewDescribeVpcsPaginator(c.EC2, &ec2.DescribeVpcsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, v := range page.Vpcs {
+			tags := ec2Tags(v.Tags)
+			d.addNode(models.AssetNetwork, aws.ToString(v.VpcId), tags["Name"], tags, map[string]string{
+				"cidr":       aws.ToString(v.CidrBlock),
+				"state":      string(v.State),
+				"is_default": strconv.FormatBool(aws.ToBool(v.IsDefault)),
+			})
+		}
+	}
+	return nil
+}
+
+func (d *discovery) listSubnets(ctx context.Context, c *Clients) error {
+	p := ec2.NewDescribeSubnetsPaginator(c.EC2, &ec2.DescribeSubnetsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range page.Subnets {
+			tags := ec2Tags(s.Tags)
+			id := d.addNode(models.AssetSubnet, aws.ToString(s.SubnetId), tags["Name"], tags, map[string]string{
+				"cidr":              aws.ToString(s.CidrBlock),
+				"availability_zone": aws.ToString(s.AvailabilityZone),
+				"vpc_id":            aws.ToString(s.VpcId),
+			})
+			d.addEdge(id, models.AssetNetwork, aws.ToString(s.VpcId), models.EdgeMemberOf)
+		}
+	}
+	return nil
+}
+
+func (d *discovery) listSecurityGroups(ctx context.Context, c *Clients) error {
+	p := ec2.NewDescribeSecurityGroupsPaginator(c.EC2, &ec2.DescribeSecurityGroupsInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, g := range page.SecurityGroups {
+			meta := map[string]string{
+				"description": aws.ToString(g.Description),
+				"vpc_id":      aws.ToString(g.VpcId),
+			}
+			if cidrs := ingressCIDRs(g.IpPermissions); cidrs != "" {
+				meta["ingress_cidrs"] = cidrs
+			}
+			id := d.addNode(models.AssetFirewallRule, aws.ToString(g.GroupId), aws.ToString(g.GroupName), ec2Tags(g.Tags), meta)
+			d.addEdge(id, models.AssetNetwork, aws.ToString(g.VpcId), models.EdgeMemberOf)
+		}
+	}
+	return nil
+}
+
+func (d *discovery) listInstances(ctx context.Context, c *Clients) error {
+	p := ec2.NewDescribeInstancesPaginator(c.EC2, &ec2.DescribeInstancesInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, r := range page.Reservations {
+			for _, in := range r.Instances {
+				if in.State != nil && in.State.Name == ec2types.InstanceStateNameTerminated {
+					continue
+				}
+				tags := ec2Tags(in.Tags)
+				meta := map[string]string{
+					"instance_type": string(in.InstanceType),
+					"image_id":      aws.ToString(in.ImageId),
+					"vpc_id":        aws.ToString(in.VpcId),
+					"subnet_id":     aws.ToString(in.SubnetId),
+				}
+				if in.State != nil {
+					meta["state"] = string(in.State.Name)
+				}
+				if in.Placement != nil {
+					meta["availability_zone"] = aws.ToString(in.Placement.AvailabilityZone)
+				}
+				if ip := aws.ToString(in.PrivateIpAddress); ip != "" {
+					meta["private_ip"] = ip
+				}
+				if ip := aws.ToString(in.PublicIpAddress); ip != "" {
+					meta["public_ip"] = ip
+				}
+				id := d.addNode(models.AssetVM, aws.ToString(in.InstanceId), tags["Name"], tags, meta)
+				if in.SubnetId != nil {
+					d.addEdge(id, models.AssetSubnet, aws.ToString(in.SubnetId), models.EdgeMemberOf)
+				} else {
+					d.addEdge(id, models.AssetNetwork, aws.ToString(in.VpcId), models.EdgeMemberOf)
+				}
+				for _, g := range in.SecurityGroups {
+					d.addEdge(id, models.AssetFirewallRule, aws.ToString(g.GroupId), models.EdgeDependsOn)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *discovery) listDBInstances(ctx context.Context, c *Clients) error {
+	p := rds.NewDescribeDBInstancesPaginator(c.RDS, &rds.DescribeDBInstancesInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, db := range page.DBInstances {
+			tags := make(map[string]string, len(db.TagList))
+			for _, t := range db.TagList {
+				tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+			}
+			meta := map[string]string{
+				"engine":         aws.ToString(db.Engine),
+				"engine_version": aws.ToString(db.EngineVersion),
+				"instance_class": aws.ToString(db.DBInstanceClass),
+				"status":         aws.ToString(db.DBInstanceStatus),
+				"multi_az":       strconv.FormatBool(aws.ToBool(db.MultiAZ)),
+			}
+			if db.Endpoint != nil {
+				meta["endpoint"] = aws.ToString(db.Endpoint.Address)
+				meta["port"] = strconv.Itoa(int(aws.ToInt32(db.Endpoint.Port)))
+			}
+			if db.DBSubnetGroup != nil {
+				meta["vpc_id"] = aws.ToString(db.DBSubnetGroup.VpcId)
+			}
+			id := d.addNode(models.AssetDatabase, d.region+"/"+aws.ToString(db.DBInstanceIdentifier), aws.ToString(db.DBInstanceIdentifier), tags, meta)
+			if db.DBSubnetGroup != nil {
+				for _, s := range db.DBSubnetGroup.Subnets {
+					d.addEdge(id, models.AssetSubnet, aws.ToString(s.SubnetIdentifier), models.EdgeMemberOf)
+				}
+			}
+			for _, g := range db.VpcSecurityGroups {
+				d.addEdge(id, models.AssetFirewallRule, aws.ToString(g.VpcSecurityGroupId), models.EdgeDependsOn)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *discovery) listLoadBalancers(ctx context.Context, c *Clients) error {
+	p := elb.NewDescribeLoadBalancersPaginator(c.ELB, &elb.DescribeLoadBalancersInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, lb := range page.LoadBalancers {
+			meta := map[string]string{
+				"arn":      aws.ToString(lb.LoadBalancerArn),
+				"dns_name": aws.ToString(lb.DNSName),
+				"scheme":   string(lb.Scheme),
+				"lb_type":  string(lb.Type),
+				"vpc_id":   aws.ToString(lb.VpcId),
+			}
+			if lb.State != nil {
+				meta["state"] = string(lb.State.Code)
+			}
+			id := d.addNode(models.AssetLoadBalancer, d.region+"/"+aws.ToString(lb.LoadBalancerName), aws.ToString(lb.LoadBalancerName), nil, meta)
+			for _, az := range lb.AvailabilityZones {
+				d.addEdge(id, models.AssetSubnet, aws.ToString(az.SubnetId), models.EdgeMemberOf)
+			}
+			for _, g := range lb.SecurityGroups {
+				d.addEdge(id, models.AssetFirewallRule, g, models.EdgeDependsOn)
+			}
+		}
+	}
+	return nil
+}
+
+// listBuckets lists the S3 buckets in the discovery's region. Bucket
+// names are global, so each bucket is found by exactly one region's scan.
+func (d *discovery) listBuckets(ctx context.Context, c *Clients) error {
+	p := s3.NewListBucketsPaginator(c.S3, &s3.ListBucketsInput{BucketRegion: aws.String(d.region)})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, b := range page.Buckets {
+			meta := map[string]string{}
+			if b.CreationDate != nil {
+				meta["created"] = b.CreationDate.UTC().Format(time.RFC3339)
+			}
+			d.addNode(models.AssetBucket, aws.ToString(b.Name), "", nil, meta)
+		}
+	}
+	return nil
+}
+
+func ec2Tags(tags []ec2types.Tag) map[string]string {
+	m := make(map[string]string, len(tags))
+	for _, t := range tags {
+		m[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return m
+}
+
+// ingressCIDRs returns the IPv4 and IPv6 source ranges of a security
+// group's ingress rules, comma-separated, matching the ingress_cidrs
+// metadata of Terraform-discovered security groups.
+func ingressCIDRs(perms []ec2types.IpPermission) string {
+	var cidrs []string
+	seen := map[string]bool{}
+	add := func(c string) {
+		if c != "" && !seen[c] {
+			seen[c] = true
+			cidrs = append(cidrs, c)
+		}
+	}
+	for _, p := range perms {
+		for _, r := range p.IpRanges {
+			add(aws.ToString(r.CidrIp))
+		}
+		for _, r := range p.Ipv6Ranges {
+			add(aws.ToString(r.CidrIpv6))
+		}
+	}
+	return strings.Join(cidrs, ",")
+}
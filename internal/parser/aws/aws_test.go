@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	elb "github.com/aws/aws-sdk-go-v2/service/elasticloadbalancingv2"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// stubEC2 serves one VPC with one subnet, a security group and two
+// instances, one of them terminated.
+type stubEC2 struct{}
+
+func (stubEC2) DescribeVpcs(context.Context, *ec2.DescribeVpcsInput, ...func(*ec2.Options)) (*ec2.DescribeVpcsOutput, error) {
+	return &ec2.DescribeVpcsOutput{Vpcs: []ec2types.Vpc{{
+		VpcId:     aws.String("vpc-1"),
+		CidrBlock: aws.String("10.0.0.0/16"),
+		Tags:      []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("prod")}},
+	}}}, nil
+}
+
+func (stubEC2) DescribeSubnets(context.Context, *ec2.DescribeSubnetsInput, ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{Subnets: []ec2types.Subnet{{
+		SubnetId:         aws.String("subnet-a"),
+		VpcId:            aws.String("vpc-1"),
+		CidrBlock:        aws.String("10.0.1.0/24"),
+		AvailabilityZone: aws.String("eu-west-1a"),
+	}}}, nil
+}
+
+func (stubEC2) DescribeSecurityGroups(context.Context, *ec2.DescribeSecurityGroupsInput, ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: []ec2types.SecurityGroup{{
+		GroupId:   aws.String("sg-web"),
+		GroupName: aws.String("web"),
+		VpcId:     aws.String("vpc-1"),
+		IpPermissions: []ec2types.IpPermission{{
+			IpRanges: []ec2types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+		}},
+	}}}, nil
+}
+
+func (stubEC2) DescribeInstances(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{{
+		Instances: []ec2types.Instance{
+			{
+				InstanceId:       aws.String("i-web"),
+				InstanceType:     ec2types.InstanceTypeT3Micro,
+				State:            &ec2types.InstanceState{Name: ec2types.InstanceStateNameRunning},
+				VpcId:            aws.String("vpc-1"),
+				SubnetId:         aws.String("subnet-a"),
+				PrivateIpAddress: aws.String("10.0.1.5"),
+				SecurityGroups:   []ec2types.GroupIdentifier{{GroupId: aws.String("sg-web")}},
+				Tags:             []ec2types.Tag{{Key: aws.String("Name"), Value: aws.String("web-1")}},
+			},
+			{
+				InstanceId: aws.String("i-old"),
+				State:      &ec2types.InstanceState{Name: ec2types.InstanceStateNameTerminated},
+			},
+		},
+	}}}, nil
+}
+
+type stubRDS struct{}
+
+func (stubRDS) DescribeDBInstances(context.Context, *rds.DescribeDBInstancesInput, ...func(*rds.Options)) (*rds.DescribeDBInstancesOutput, error) {
+	return &rds.DescribeDBInstancesOutput{DBInstances: []rdstypes.DBInstance{{
+		DBInstanceIdentifier: aws.String("orders"),
+		Engine:               aws.String("postgres"),
+		Endpoint:             &rdstypes.Endpoint{Address: aws.String("orders.example.rds.amazonaws.com"), Port: aws.Int32(5432)},
+		DBSubnetGroup: &rdstypes.DBSubnetGroup{
+			VpcId:   aws.String("vpc-1"),
+			Subnets: []rdstypes.Subnet{{SubnetIdentifier: aws.String("subnet-a")}},
+		},
+		VpcSecurityGroups: []rdstypes.VpcSecurityGroupMembership{{VpcSecurityGroupId: aws.String("sg-web")}},
+	}}}, nil
+}
+
+type failingELB struct{}
+
+func (failingELB) DescribeLoadBalancers(context.Context, *elb.DescribeLoadBalancersInput, ...func(*elb.Options)) (*elb.DescribeLoadBalancersOutput, error) {
+	return nil, errors.New("AccessDenied")
+}
+
+type stubS3 struct{ region *string }
+
+func (s *stubS3) ListBuckets(_ context.Context, in *s3.ListBucketsInput, _ ...func(*s3.Options)) (*s3.ListBucketsOutput, error) {
+	s.region = in.BucketRegion
+	return &s3.ListBucketsOutput{Buckets: []s3types.Bucket{{Name: aws.String("assets")}}}, nil
+}
+
+func stubClients(t *testing.T, s3Client *stubS3) {
+	t.Helper()
+	orig := newClientsFn
+	newClientsFn = func(_ context.Context, region, _ string) (*Clients, string, error) {
+		if region == "" {
+			region = "eu-west-1"
+		}
+		return &Clients{EC2: stubEC2{}, RDS: stubRDS{}, ELB: failingELB{}, S3: s3Client}, region, nil
+	}
+	t.Cleanup(func() { newClientsFn = orig })
+}
+
+func TestFetch(t *testing.T) {
+	s3Client := &stubS3{}
+	stubClients(t, s3Client)
+
+	result, err := Fetch(context.Background(), nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node, len(result.Nodes))
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	for id, typ := range map[string]models.AssetType{
+		"aws:network:vpc-1":             models.AssetNetwork,
+		"aws:subnet:subnet-a":           models.AssetSubnet,
+		"aws:firewall_rule:sg-web":      models.AssetFirewallRule,
+		"aws:vm:i-web":                  models.AssetVM,
+		"aws:database:eu-west-1/orders": models.AssetDatabase,
+		"aws:bucket:assets":             models.AssetBucket,
+	} {
+		n, ok := nodes[id]
+		if !ok {
+			t.Errorf("missing node %s", id)
+			continue
+		}
+		if n.Type != typ || n.Source != "aws" || n.Provider != "aws" || n.Metadata["region"] != "eu-west-1" {
+			t.Errorf("node %s = %+v", id, n)
+		}
+	}
+	if len(nodes) != 6 {
+		t.Errorf("got %d nodes, want 6 (terminated instances skipped)", len(nodes))
+	}
+
+	vm := nodes["aws:vm:i-web"]
+	if vm.Name != "web-1" || vm.Metadata["private_ip"] != "10.0.1.5" || vm.Metadata["instance_type"] != "t3.micro" {
+		t.Errorf("vm = %+v", vm)
+	}
+	if got := nodes["aws:database:eu-west-1/orders"].Metadata["port"]; got != "5432" {
+		t.Errorf("database port = %q", got)
+	}
+	if got := nodes["aws:firewall_rule:sg-web"].Metadata["ingress_cidrs"]; got != "0.0.0.0/0" {
+		t.Errorf("ingress_cidrs = %q", got)
+	}
+	if s3Client.region == nil || *s3Client.region != "eu-west-1" {
+		t.Error("bucket listing should be limited to the scanned region")
+	}
+
+	edges := make(map[string]bool, len(result.Edges))
+	for _, e := range result.Edges {
+		edges[e.ID] = true
+	}
+	for _, id := range []string{
+		"aws:subnet:subnet-a->member_of->aws:network:vpc-1",
+		"aws:firewall_rule:sg-web->member_of->aws:network:vpc-1",
+		"aws:vm:i-web->member_of->aws:subnet:subnet-a",
+		"aws:vm:i-web->depends_on->aws:firewall_rule:sg-web",
+		"aws:database:eu-west-1/orders->member_of->aws:subnet:subnet-a",
+		"aws:database:eu-west-1/orders->depends_on->aws:firewall_rule:sg-web",
+	} {
+		if !edges[id] {
+			t.Errorf("missing edge %s", id)
+		}
+	}
+	if len(result.Edges) != 6 {
+		t.Errorf("got %d edges, want 6", len(result.Edges))
+	}
+
+	if result.PathsScanned != 6 || result.PathsFailed != 1 {
+		t.Errorf("listings scanned/failed = %d/%d, want 6/1", result.PathsScanned, result.PathsFailed)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "load balancers") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+}
+
+func TestFetch_MultipleRegions(t *testing.T) {
+	stubClients(t, &stubS3{})
+
+	result, err := Fetch(context.Background(), []string{"eu-west-1", "us-east-1"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions := map[string]int{}
+	for _, n := range result.Nodes {
+		regions[n.SourceFile]++
+	}
+	if regions["aws:eu-west-1"] != 6 || regions["aws:us-east-1"] != 6 {
+		t.Errorf("nodes per region = %v", regions)
+	}
+}
+
+func TestFetch_ClientError(t *testing.T) {
+	orig := newClientsFn
+	t.Cleanup(func() { newClientsFn = orig })
+	newClientsFn = func(context.Context, string, string) (*Clients, string, error) {
+		return nil, "", errors.New("no AWS region configured")
+	}
+	if _, err := Fetch(context.Background(), nil, ""); err == nil || !strings.Contains(err.Error(), "region") {
+		t.Errorf("expected region error, got %v", err)
+	}
+}
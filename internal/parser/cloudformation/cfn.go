@@ -102,11 +102,13 @@ func (p *CFNParser) ParseMulti(ctx context.Context, paths []string) (*parser.Par
 		resolved, err := parser.SafeResolvePath(path)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("resolving %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
 		data, err := os.ReadFile(resolved) // #nosec G304 -- paths validated by SafeResolvePath
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("reading %s: %v", resolved, err))
+			result.PathsFailed++
 			continue
 		}
 		templateData[resolved] = data
@@ -132,8 +134,10 @@ func (p *CFNParser) ParseMulti(ctx context.Context, paths []string) (*parser.Par
 		r, err := parseCFNWithRefs(data, path, globalRefMap)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("parsing %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
+		result.PathsScanned++
 		result.Nodes = append(result.Nodes, r.Nodes...)
 		result.Edges = append(result.Edges, r.Edges...)
 		result.Warnings = append(result.Warnings, r.Warnings...)
@@ -0,0 +1,248 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// maxExtendsDepth bounds extends chains, which also stops cycles.
+const maxExtendsDepth = 10
+
+// overrideFileNames are the files docker compose layers over the base file
+// when a project directory is used without explicit -f flags.
+var overrideFileNames = map[string][]string{
+	"docker-compose.yml":  {"docker-compose.override.yml", "docker-compose.override.yaml"},
+	"docker-compose.yaml": {"docker-compose.override.yml", "docker-compose.override.yaml"},
+	"compose.yml":         {"compose.override.yml", "compose.override.yaml"},
+	"compose.yaml":        {"compose.override.yml", "compose.override.yaml"},
+}
+
+// replacedKeys are sequence-valued service keys that an override replaces
+// instead of extending, following docker compose's merge rules.
+var replacedKeys = map[string]bool{
+	"command":    true,
+	"entrypoint": true,
+	"test":       true,
+}
+
+// keyedKeys are service keys that may be written as a list or a map; both
+// forms are normalized to maps before merging so overrides merge per entry.
+var keyedKeys = map[string]bool{
+	"depends_on":  true,
+	"networks":    true,
+	"environment": true,
+	"labels":      true,
+}
+
+// loadComposeMap reads a compose file as a generic document and resolves
+// its services' extends.
+func loadComposeMap(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- callers validate path with SafeResolvePath
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	services, _ := doc["services"].(map[string]any)
+	for name := range services {
+		svc, err := resolveExtends(path, doc, name, 0)
+		if err != nil {
+			return nil, err
+		}
+		services[name] = svc
+	}
+	return doc, nil
+}
+
+// resolveExtends returns service name of doc (loaded from path) with its
+// extends chain merged in: the extended service is the base and the
+// extending service overrides it.
+func resolveExtends(path string, doc map[string]any, name string, depth int) (map[string]any, error) {
+	services, _ := doc["services"].(map[string]any)
+	svc, ok := services[name].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: service %q not found", path, name)
+	}
+	ext, ok := svc["extends"]
+	if !ok {
+		return svc, nil
+	}
+	if depth >= maxExtendsDepth {
+		return nil, fmt.Errorf("%s: service %q: extends nested more than %d levels (cycle?)", path, name, maxExtendsDepth)
+	}
+
+	var baseFile, baseService string
+	switch e := ext.(type) {
+	case string:
+		baseService = e
+	case map[string]any:
+		baseFile, _ = e["file"].(string)
+		baseService, _ = e["service"].(string)
+	}
+	if baseService == "" {
+		return nil, fmt.Errorf("%s: service %q: extends needs a service", path, name)
+	}
+
+	basePath, baseDoc := path, doc
+	if baseFile != "" {
+		baseDoc = nil
+		resolved, err := parser.SafeResolvePath(filepath.Join(filepath.Dir(path), baseFile))
+		if err != nil {
+			return nil, fmt.Errorf("%s: service %q extends %s: %w", path, name, baseFile, err)
+		}
+		data, err := os.ReadFile(resolved) // #nosec G304 -- path validated by SafeResolvePath
+		if err != nil {
+			return nil, fmt.Errorf("%s: service %q: reading %s: %w", path, name, baseFile, err)
+		}
+		if err := yaml.Unmarshal(data, &baseDoc); err != nil {
+			return nil, fmt.Errorf("%s: service %q: parsing %s: %w", path, name, baseFile, err)
+		}
+		basePath = resolved
+	}
+
+	base, err := resolveExtends(basePath, baseDoc, baseService, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	own := make(map[string]any, len(svc))
+	for k, v := range svc {
+		if k != "extends" {
+			own[k] = v
+		}
+	}
+	return mergeService(base, own), nil
+}
+
+// mergeDocuments layers override onto base: services merge per service,
+// other top-level sections (networks, volumes, secrets, configs) merge per
+// entry, and scalars are replaced.
+func mergeDocuments(base, override map[string]any) map[string]any {
+	out := copyMap(base)
+	for key, v := range override {
+		if key != "services" {
+			out[key] = mergeValues(key, out[key], v)
+			continue
+		}
+		baseServices, _ := out["services"].(map[string]any)
+		services := copyMap(baseServices)
+		overrideServices, _ := v.(map[string]any)
+		for name, svc := range overrideServices {
+			o, _ := svc.(map[string]any)
+			if b, ok := services[name].(map[string]any); ok {
+				services[name] = mergeService(b, o)
+			} else {
+				services[name] = o
+			}
+		}
+		out["services"] = services
+	}
+	return out
+}
+
+// mergeService layers one service definition over another.
+func mergeService(base, override map[string]any) map[string]any {
+	out := copyMap(base)
+	for key, v := range override {
+		out[key] = mergeValues(key, out[key], v)
+	}
+	return out
+}
+
+// mergeValues merges override into base for the given key: mappings merge
+// recursively, sequences are appended without duplicates (except
+// replacedKeys), and anything else is replaced.
+func mergeValues(key string, base, override any) any {
+	if base == nil {
+		return override
+	}
+	if keyedKeys[key] {
+		base, override = toKeyed(base), toKeyed(override)
+	}
+	switch o := override.(type) {
+	case map[string]any:
+		b, ok := base.(map[string]any)
+		if !ok {
+			return o
+		}
+		out := copyMap(b)
+		for k, v := range o {
+			out[k] = mergeValues(k, out[k], v)
+		}
+		return out
+	case []any:
+		b, ok := base.([]any)
+		if !ok || replacedKeys[key] {
+			return o
+		}
+		out := append([]any(nil), b...)
+		for _, item := range o {
+			if !containsValue(out, item) {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return override
+}
+
+// toKeyed converts the list form of a keyedKeys value to its map form:
+// "KEY=value" entries become KEY: value and bare names become name: null.
+func toKeyed(v any) any {
+	list, ok := v.([]any)
+	if !ok {
+		return v
+	}
+	m := make(map[string]any, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			continue
+		}
+		if k, val, found := strings.Cut(s, "="); found {
+			m[k] = val
+		} else {
+			m[s] = nil
+		}
+	}
+	return m
+}
+
+func containsValue(list []any, v any) bool {
+	for _, item := range list {
+		if reflect.DeepEqual(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// decodeComposeFile converts a merged generic document into a composeFile.
+func decodeComposeFile(doc map[string]any) (composeFile, error) {
+	var cf composeFile
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return cf, err
+	}
+	err = yaml.Unmarshal(data, &cf)
+	return cf, err
+}
@@ -16,9 +16,20 @@ import (
 
 // composeFile represents the top-level structure of a Docker Compose file.
 type composeFile struct {
+	Name     string                    `yaml:"name"`
 	Services map[string]composeService `yaml:"services"`
 	Networks map[string]any            `yaml:"networks"`
 	Volumes  map[string]any            `yaml:"volumes"`
+	Secrets  map[string]composeObject  `yaml:"secrets"`
+	Configs  map[string]composeObject  `yaml:"configs"`
+}
+
+// composeObject is a top-level secret or config definition.
+type composeObject struct {
+	File        string `yaml:"file"`
+	Environment string `yaml:"environment"`
+	Name        string `yaml:"name"`
+	External    any    `yaml:"external"` // bool, or {name: ...} in the legacy format
 }
 
 // composeService represents a single service in a Docker Compose file.
@@ -31,6 +42,10 @@ type composeService struct {
 	Init        any             `yaml:"init"`
 	Healthcheck any             `yaml:"healthcheck"`
 	Environment any             `yaml:"environment"`
+	Secrets     serviceObjects  `yaml:"secrets"`
+	Configs     serviceObjects  `yaml:"configs"`
+	Links       []string        `yaml:"links"`
+	NetworkMode string          `yaml:"network_mode"`
 }
 
 // dependsOn handles both []string and map[string]{condition:...} forms.
@@ -81,6 +96,38 @@ func (n *serviceNetworks) UnmarshalYAML(node *yaml.Node) error {
 	}
 }
 
+// serviceObjects handles both the short (["name"]) and long
+// ([{source: name, target: ...}]) forms of service secrets and configs.
+type serviceObjects struct {
+	Refs []objectRef
+}
+
+type objectRef struct {
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+func (o *serviceObjects) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("unsupported secrets/configs type: %v", node.Kind)
+	}
+	for _, item := range node.Content {
+		var ref objectRef
+		switch item.Kind {
+		case yaml.ScalarNode:
+			ref.Source = item.Value
+		case yaml.MappingNode:
+			if err := item.Decode(&ref); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported secrets/configs entry: %v", item.Kind)
+		}
+		o.Refs = append(o.Refs, ref)
+	}
+	return nil
+}
+
 var composeFileNames = []string{
 	"docker-compose.yml",
 	"docker-compose.yaml",
@@ -88,15 +135,20 @@ var composeFileNames = []string{
 	"compose.yaml",
 }
 
-// ComposeParser parses Docker Compose files.
-type ComposeParser struct{}
+// ComposeParser parses Docker Compose files. Filter is applied to the
+// compose and override files found in a directory.
+type ComposeParser struct {
+	Filter parser.PathFilter
+}
 
 // NewComposeParser creates a new Docker Compose parser.
 func NewComposeParser() *ComposeParser {
 	return &ComposeParser{}
 }
 
-// Supported returns true if the path is a Docker Compose file or a directory containing one.
+// Supported returns true if the path is a Docker Compose file or a directory
+// containing one. Override files (e.g. docker-compose.override.yml) are not
+// supported on their own: they only complete the project in their directory.
 func (p *ComposeParser) Supported(path string) bool {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -123,7 +175,41 @@ func (p *ComposeParser) Supported(path string) bool {
 }
 
 // Parse reads a Docker Compose file and returns discovered nodes and edges.
+// For a directory, the compose file in it is layered with its override file
+// (e.g. docker-compose.override.yml) if present, as docker compose does.
 func (p *ComposeParser) Parse(ctx context.Context, path string) (*parser.ParseResult, error) {
+	files, err := composeFiles(path, p.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return &parser.ParseResult{}, nil
+	}
+	return parseFiles(files)
+}
+
+// ParseMerged deep-merges several compose files into one project before
+// building the graph, later files overriding earlier ones, like
+// "docker compose -f base.yml -f override.yml".
+func (p *ComposeParser) ParseMerged(ctx context.Context, paths []string) (*parser.ParseResult, error) {
+	var files []string
+	for _, path := range paths {
+		f, err := composeFiles(path, p.Filter)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no docker compose files given")
+	}
+	return parseFiles(files)
+}
+
+// composeFiles resolves path to the compose files to load: the file itself,
+// or a directory's compose file followed by its override file. It returns no
+// files when filter excludes every compose file in the directory.
+func composeFiles(path string, filter parser.PathFilter) ([]string, error) {
 	path, err := parser.SafeResolvePath(path)
 	if err != nil {
 		return nil, err
@@ -133,34 +219,59 @@ func (p *ComposeParser) Parse(ctx context.Context, path string) (*parser.ParseRe
 	if err != nil {
 		return nil, fmt.Errorf("stat %s: %w", path, err)
 	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
 
-	// If directory, find the compose file
-	if info.IsDir() {
-		found := false
-		for _, name := range composeFileNames {
-			candidate := filepath.Join(path, name)
-			if _, err := os.Stat(candidate); err == nil {
-				path = candidate
-				found = true
+	excluded := false
+	for _, name := range composeFileNames {
+		candidate := filepath.Join(path, name)
+		if _, err := os.Stat(candidate); err != nil {
+			continue
+		}
+		if !filter.Allows(path, candidate) {
+			excluded = true
+			continue
+		}
+		files := []string{candidate}
+		for _, override := range overrideFileNames[name] {
+			o := filepath.Join(path, override)
+			if _, err := os.Stat(o); err == nil {
+				if filter.Allows(path, o) {
+					files = append(files, o)
+				}
 				break
 			}
 		}
-		if !found {
-			return nil, fmt.Errorf("no docker compose file found in %s", path)
-		}
+		return files, nil
 	}
-
-	data, err := os.ReadFile(path) // #nosec G304 -- path validated by SafeResolvePath
-	if err != nil {
-		return nil, fmt.Errorf("reading %s: %w", path, err)
+	if excluded {
+		return nil, nil
 	}
+	return nil, fmt.Errorf("no docker compose file found in %s", path)
+}
 
-	var cf composeFile
-	if err := yaml.Unmarshal(data, &cf); err != nil {
-		return nil, fmt.Errorf("parsing %s: %w", path, err)
+// parseFiles merges files in order and builds the graph. Nodes record the
+// first (base) file as their source.
+func parseFiles(files []string) (*parser.ParseResult, error) {
+	var doc map[string]any
+	for _, f := range files {
+		m, err := loadComposeMap(f)
+		if err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			doc = m
+		} else {
+			doc = mergeDocuments(doc, m)
+		}
 	}
 
-	return buildGraph(cf, path), nil
+	cf, err := decodeComposeFile(doc)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", files[0], err)
+	}
+	return buildGraph(cf, files[0]), nil
 }
 
 func buildGraph(cf composeFile, sourceFile string) *parser.ParseResult {
@@ -229,6 +340,54 @@ func buildGraph(cf composeFile, sourceFile string) *parser.ParseResult {
 		})
 	}
 
+	// Services publishing host ports are reachable from outside the
+	// project, so they hang off one synthetic host node per project.
+	project := projectName(cf, sourceFile)
+	hostID := hostNodeID(project)
+	var published []string
+	for name, svc := range cf.Services {
+		if len(publishedPorts(svc.Ports)) > 0 {
+			published = append(published, name)
+		}
+	}
+	sort.Strings(published)
+	if len(published) > 0 {
+		result.Nodes = append(result.Nodes, models.Node{
+			ID:         hostID,
+			Name:       "host",
+			Type:       models.AssetLoadBalancer,
+			Source:     "compose",
+			SourceFile: sourceFile,
+			Provider:   "docker",
+			Metadata:   map[string]string{"project": project},
+			LastSeen:   now,
+			FirstSeen:  now,
+		})
+	}
+	for _, name := range published {
+		svc := cf.Services[name]
+		toID := "compose:container:" + name
+		result.Edges = append(result.Edges, models.Edge{
+			ID:     hostID + "->routes_to->" + toID,
+			FromID: hostID,
+			ToID:   toID,
+			Type:   models.EdgeRoutesTo,
+			Metadata: map[string]string{
+				"via":       "ports",
+				"raw_value": strings.Join(svc.Ports, ","),
+				"published": strings.Join(publishedPorts(svc.Ports), ","),
+			},
+		})
+	}
+
+	// Create secret and config nodes
+	for name, obj := range cf.Secrets {
+		result.Nodes = append(result.Nodes, objectNode("compose:secret:"+name, name, models.AssetSecret, obj, sourceFile, now))
+	}
+	for name, obj := range cf.Configs {
+		result.Nodes = append(result.Nodes, objectNode("compose:config:"+name, name, models.AssetConfigMap, obj, sourceFile, now))
+	}
+
 	// Create edges
 	for name, svc := range cf.Services {
 		fromID := "compose:container:" + name
@@ -249,6 +408,34 @@ func buildGraph(cf composeFile, sourceFile string) *parser.ParseResult {
 			})
 		}
 
+		// links ("service" or "service:alias") and network_mode "service:name"
+		// both reach another service directly, bypassing depends_on.
+		linked := map[string]bool{}
+		addServiceLink := func(target, via, raw string) {
+			if _, ok := cf.Services[target]; !ok || linked[target] || target == name {
+				return
+			}
+			linked[target] = true
+			toID := "compose:container:" + target
+			result.Edges = append(result.Edges, models.Edge{
+				ID:     fromID + "->connects_to->" + toID,
+				FromID: fromID,
+				ToID:   toID,
+				Type:   models.EdgeConnectsTo,
+				Metadata: map[string]string{
+					"via":       via,
+					"raw_value": raw,
+				},
+			})
+		}
+		for _, link := range svc.Links {
+			target, _, _ := strings.Cut(link, ":")
+			addServiceLink(target, "links", link)
+		}
+		if target, ok := strings.CutPrefix(svc.NetworkMode, "service:"); ok {
+			addServiceLink(target, "network_mode", svc.NetworkMode)
+		}
+
 		// network edges
 		for _, net := range svc.Networks.Names {
 			toID := "compose:network:" + net
@@ -290,7 +477,115 @@ func buildGraph(cf composeFile, sourceFile string) *parser.ParseResult {
 				},
 			})
 		}
+
+		// secret and config edges
+		for _, ref := range svc.Secrets.Refs {
+			if _, ok := cf.Secrets[ref.Source]; !ok {
+				continue
+			}
+			toID := "compose:secret:" + ref.Source
+			result.Edges = append(result.Edges, models.Edge{
+				ID:       fromID + "->mounts_secret->" + toID,
+				FromID:   fromID,
+				ToID:     toID,
+				Type:     models.EdgeMountsSecret,
+				Metadata: objectEdgeMetadata("secrets", ref),
+			})
+		}
+		for _, ref := range svc.Configs.Refs {
+			if _, ok := cf.Configs[ref.Source]; !ok {
+				continue
+			}
+			toID := "compose:config:" + ref.Source
+			result.Edges = append(result.Edges, models.Edge{
+				ID:       fromID + "->depends_on->" + toID,
+				FromID:   fromID,
+				ToID:     toID,
+				Type:     models.EdgeDependsOn,
+				Metadata: objectEdgeMetadata("configs", ref),
+			})
+		}
 	}
 
 	return result
 }
+
+// hostNodeID returns the ID of the synthetic node for the Docker host that
+// a project's published ports are bound on.
+func hostNodeID(project string) string {
+	return "compose:load_balancer:host:" + project
+}
+
+// projectName returns the compose project name: the top-level name field,
+// or the name of the directory holding the compose file, as docker compose
+// defaults to.
+func projectName(cf composeFile, sourceFile string) string {
+	if cf.Name != "" {
+		return cf.Name
+	}
+	return filepath.Base(filepath.Dir(sourceFile))
+}
+
+// publishedPorts returns the host ports of a service's short-syntax port
+// mappings ("80:8080", "127.0.0.1:443:8443/tcp"). Container-only ports
+// ("8080") get an ephemeral host port and are skipped.
+func publishedPorts(ports []string) []string {
+	var out []string
+	for _, p := range ports {
+		p, _, _ = strings.Cut(p, "/")
+		parts := strings.Split(p, ":")
+		if len(parts) < 2 || parts[len(parts)-2] == "" {
+			continue
+		}
+		out = append(out, parts[len(parts)-2])
+	}
+	return out
+}
+
+// objectNode builds the node for a top-level secret or config. External
+// objects are managed outside the compose file, so only their name is known.
+func objectNode(id, name string, assetType models.AssetType, obj composeObject, sourceFile string, now time.Time) models.Node {
+	meta := map[string]string{}
+	if obj.File != "" {
+		meta["file"] = obj.File
+	}
+	if obj.Environment != "" {
+		meta["environment"] = obj.Environment
+	}
+	if obj.Name != "" {
+		meta["external_name"] = obj.Name
+	}
+	switch ext := obj.External.(type) {
+	case bool:
+		if ext {
+			meta["external"] = "true"
+		}
+	case map[string]any:
+		meta["external"] = "true"
+		if n, ok := ext["name"].(string); ok && n != "" {
+			meta["external_name"] = n
+		}
+	}
+	return models.Node{
+		ID:         id,
+		Name:       name,
+		Type:       assetType,
+		Source:     "compose",
+		SourceFile: sourceFile,
+		Provider:   "docker",
+		Metadata:   meta,
+		LastSeen:   now,
+		FirstSeen:  now,
+	}
+}
+
+func objectEdgeMetadata(via string, ref objectRef) map[string]string {
+	meta := map[string]string{
+		"via":       via,
+		"raw_value": ref.Source,
+	}
+	if ref.Target != "" {
+		meta["target"] = ref.Target
+	}
+	return meta
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/matijazezelj/aib/internal/parser"
 	"github.com/matijazezelj/aib/pkg/models"
 )
 
@@ -16,9 +17,9 @@ func TestParse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	// 3 services + 2 networks + 3 volumes = 8 nodes
-	if len(result.Nodes) != 8 {
-		t.Errorf("nodes = %d, want 8", len(result.Nodes))
+	// 3 services + 2 networks + 3 volumes + host = 9 nodes
+	if len(result.Nodes) != 9 {
+		t.Errorf("nodes = %d, want 9", len(result.Nodes))
 	}
 
 	nodeMap := make(map[string]models.Node)
@@ -171,10 +172,12 @@ func TestParse_ServiceOperationalMetadata(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(result.Nodes) != 1 {
-		t.Fatalf("nodes = %d, want 1", len(result.Nodes))
+	var meta map[string]string
+	for _, n := range result.Nodes {
+		if n.ID == "compose:container:app" {
+			meta = n.Metadata
+		}
 	}
-	meta := result.Nodes[0].Metadata
 	if meta["init"] != "true" {
 		t.Errorf("init metadata = %q, want true", meta["init"])
 	}
@@ -186,6 +189,294 @@ func TestParse_ServiceOperationalMetadata(t *testing.T) {
 	}
 }
 
+func TestParse_PublishedPorts(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "compose.yml")
+	err := os.WriteFile(composePath, []byte(`services:
+  web:
+    image: nginx:1.27
+    ports:
+      - "80:8080"
+      - "127.0.0.1:443:8443/tcp"
+  worker:
+    image: myapp/worker:latest
+    ports:
+      - "9090"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewComposeParser()
+	result, err := p.Parse(context.Background(), composePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host *models.Node
+	for i, n := range result.Nodes {
+		if n.ID == "compose:load_balancer:host:"+filepath.Base(dir) {
+			host = &result.Nodes[i]
+		}
+	}
+	if host == nil {
+		t.Fatal("missing host node")
+	}
+	if host.Type != models.AssetLoadBalancer {
+		t.Errorf("host type = %q, want load_balancer", host.Type)
+	}
+
+	var routes []models.Edge
+	for _, e := range result.Edges {
+		if e.FromID == host.ID {
+			routes = append(routes, e)
+		}
+	}
+	if len(routes) != 1 {
+		t.Fatalf("host edges = %v, want one to web", routes)
+	}
+	e := routes[0]
+	if e.ToID != "compose:container:web" || e.Type != models.EdgeRoutesTo {
+		t.Errorf("unexpected host edge: %+v", e)
+	}
+	if e.Metadata["published"] != "80,443" || e.Metadata["raw_value"] != "80:8080,127.0.0.1:443:8443/tcp" {
+		t.Errorf("port metadata = %v", e.Metadata)
+	}
+}
+
+func TestParse_PublishedPortsPerProject(t *testing.T) {
+	dir := t.TempDir()
+	shop := filepath.Join(dir, "shop.yml")
+	blog := filepath.Join(dir, "blog", "compose.yml")
+	if err := os.MkdirAll(filepath.Dir(blog), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shop, []byte("name: shop\nservices:\n  web:\n    image: nginx\n    ports: [\"80:80\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blog, []byte("services:\n  web:\n    image: ghost\n    ports: [\"8080:2368\"]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewComposeParser()
+	for path, want := range map[string]string{
+		shop: "compose:load_balancer:host:shop",
+		blog: "compose:load_balancer:host:blog",
+	} {
+		result, err := p.Parse(context.Background(), path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, n := range result.Nodes {
+			if n.Type == models.AssetLoadBalancer {
+				found = n.ID == want
+			}
+		}
+		if !found {
+			t.Errorf("%s: no host node %q", path, want)
+		}
+	}
+}
+
+func TestParse_SecretsAndConfigs(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "compose.yml")
+	err := os.WriteFile(composePath, []byte(`services:
+  db:
+    image: postgres:16
+    secrets:
+      - db_password
+    configs:
+      - source: pg_conf
+        target: /etc/postgresql/postgresql.conf
+  api:
+    image: myapp/api:latest
+    secrets:
+      - source: api_key
+        target: /run/secrets/key
+      - undeclared
+secrets:
+  db_password:
+    file: ./db_password.txt
+  api_key:
+    external: true
+configs:
+  pg_conf:
+    file: ./postgresql.conf
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewComposeParser()
+	result, err := p.Parse(context.Background(), composePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodeMap := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodeMap[n.ID] = n
+	}
+	pw, ok := nodeMap["compose:secret:db_password"]
+	if !ok {
+		t.Fatal("missing db_password secret node")
+	}
+	if pw.Type != models.AssetSecret || pw.Metadata["file"] != "./db_password.txt" || pw.Metadata["external"] != "" {
+		t.Errorf("unexpected db_password node: %+v", pw)
+	}
+	if key := nodeMap["compose:secret:api_key"]; key.Metadata["external"] != "true" {
+		t.Errorf("api_key external = %q, want true", key.Metadata["external"])
+	}
+	if conf := nodeMap["compose:config:pg_conf"]; conf.Type != models.AssetConfigMap {
+		t.Errorf("pg_conf type = %q, want configmap", conf.Type)
+	}
+
+	edgeMap := make(map[string]models.Edge)
+	for _, e := range result.Edges {
+		edgeMap[e.ID] = e
+	}
+	if e, ok := edgeMap["compose:container:db->mounts_secret->compose:secret:db_password"]; !ok || e.Type != models.EdgeMountsSecret {
+		t.Error("missing db -> db_password mounts_secret edge")
+	}
+	if e := edgeMap["compose:container:api->mounts_secret->compose:secret:api_key"]; e.Metadata["target"] != "/run/secrets/key" {
+		t.Errorf("api -> api_key target = %q", e.Metadata["target"])
+	}
+	if _, ok := edgeMap["compose:container:db->depends_on->compose:config:pg_conf"]; !ok {
+		t.Error("missing db -> pg_conf config edge")
+	}
+	if _, ok := edgeMap["compose:container:api->mounts_secret->compose:secret:undeclared"]; ok {
+		t.Error("undeclared secrets should not produce edges")
+	}
+}
+
+func TestParse_LinksAndNetworkMode(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "compose.yml")
+	err := os.WriteFile(composePath, []byte(`services:
+  proxy:
+    image: envoyproxy/envoy:v1.31
+  app:
+    image: myapp/app:latest
+    network_mode: service:proxy
+  legacy:
+    image: myapp/legacy:latest
+    links:
+      - app:backend
+      - missing
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewComposeParser()
+	result, err := p.Parse(context.Background(), composePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edgeMap := make(map[string]models.Edge)
+	for _, e := range result.Edges {
+		edgeMap[e.ID] = e
+	}
+	e, ok := edgeMap["compose:container:app->connects_to->compose:container:proxy"]
+	if !ok {
+		t.Fatal("missing app -> proxy connects_to edge from network_mode")
+	}
+	if e.Type != models.EdgeConnectsTo || e.Metadata["via"] != "network_mode" || e.Metadata["raw_value"] != "service:proxy" {
+		t.Errorf("unexpected network_mode edge: %+v", e)
+	}
+	if e := edgeMap["compose:container:legacy->connects_to->compose:container:app"]; e.Metadata["via"] != "links" || e.Metadata["raw_value"] != "app:backend" {
+		t.Errorf("unexpected links edge: %+v", e)
+	}
+	if _, ok := edgeMap["compose:container:legacy->connects_to->compose:container:missing"]; ok {
+		t.Error("links to undefined services should not produce edges")
+	}
+}
+
+func TestParse_OverrideAndExtends(t *testing.T) {
+	p := NewComposeParser()
+	base := "testdata/layered/docker-compose.yml"
+	override := "testdata/layered/docker-compose.override.yml"
+
+	merged, err := p.ParseMerged(context.Background(), []string{base, override})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory picks up its override file on its own.
+	dir, err := p.Parse(context.Background(), "testdata/layered")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, result := range map[string]*parser.ParseResult{"merged": merged, "directory": dir} {
+		nodeMap := make(map[string]models.Node)
+		for _, n := range result.Nodes {
+			nodeMap[n.ID] = n
+		}
+		if img := nodeMap["compose:container:web"].Metadata["image"]; img != "myapp/web:dev" {
+			t.Errorf("%s: web image = %q, want override myapp/web:dev", name, img)
+		}
+		if ports := nodeMap["compose:container:web"].Metadata["ports"]; ports != "80:80" {
+			t.Errorf("%s: web ports = %q, want base 80:80", name, ports)
+		}
+		if img := nodeMap["compose:container:api"].Metadata["image"]; img != "myapp/api:1.0" {
+			t.Errorf("%s: api image = %q, extending service should win", name, img)
+		}
+		if img := nodeMap["compose:container:worker"].Metadata["image"]; img != "myapp/base:1.0" {
+			t.Errorf("%s: worker image = %q, want inherited myapp/base:1.0", name, img)
+		}
+
+		edgeMap := make(map[string]bool)
+		for _, e := range result.Edges {
+			edgeMap[e.ID] = true
+		}
+		for _, want := range []string{
+			"compose:container:web->depends_on->compose:container:api",
+			"compose:container:web->depends_on->compose:container:cache",
+			"compose:container:api->connects_to->compose:network:backend",
+			"compose:container:worker->connects_to->compose:network:backend",
+		} {
+			if !edgeMap[want] {
+				t.Errorf("%s: missing edge %s", name, want)
+			}
+		}
+	}
+
+	// Without the override, the base file alone is used.
+	single, err := p.Parse(context.Background(), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range single.Nodes {
+		if n.ID == "compose:container:web" && n.Metadata["image"] != "myapp/web:1.0" {
+			t.Errorf("base-only web image = %q", n.Metadata["image"])
+		}
+		if n.ID == "compose:container:worker" {
+			t.Error("worker is only defined in the override")
+		}
+	}
+}
+
+func TestParse_ExtendsCycle(t *testing.T) {
+	dir := t.TempDir()
+	composePath := filepath.Join(dir, "compose.yml")
+	err := os.WriteFile(composePath, []byte(`services:
+  a:
+    extends: b
+  b:
+    extends: a
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewComposeParser().Parse(context.Background(), composePath); err == nil {
+		t.Fatal("expected error for extends cycle")
+	}
+}
+
 func TestSupported(t *testing.T) {
 	p := NewComposeParser()
 
@@ -218,8 +509,8 @@ func TestParseDirectory(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(result.Nodes) != 8 {
-		t.Errorf("nodes from dir = %d, want 8", len(result.Nodes))
+	if len(result.Nodes) != 9 {
+		t.Errorf("nodes from dir = %d, want 9", len(result.Nodes))
 	}
 }
 
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PathFilter selects which discovered files a directory walk parses.
+// Patterns use filepath.Match syntax and are tried against the path
+// relative to the walked directory, each of its leading directories, and
+// each single path element, so "testdata" excludes every testdata/
+// directory, "vendor/*" the top-level vendor/ tree, and "*.backup.tfstate"
+// files at any depth.
+// Exclude wins over Include; an empty Include admits every file.
+type PathFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate reports the first malformed pattern.
+func (f PathFilter) Validate() error {
+	for _, p := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Allows reports whether the file at path, found while walking root,
+// should be parsed.
+func (f PathFilter) Allows(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(path)
+	}
+	rel = filepath.ToSlash(rel)
+	if matchAny(f.Exclude, rel) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, rel)
+}
+
+// matchAny reports whether any pattern matches rel, one of its leading
+// directories, or one of its elements.
+func matchAny(patterns []string, rel string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	candidates := []string{rel}
+	for i := range rel {
+		if rel[i] == '/' {
+			candidates = append(candidates, rel[:i])
+		}
+	}
+	if strings.Contains(rel, "/") {
+		candidates = append(candidates, strings.Split(rel, "/")...)
+	}
+	for _, p := range patterns {
+		p = filepath.ToSlash(p)
+		for _, c := range candidates {
+			if ok, _ := filepath.Match(p, c); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExcludeTypes removes nodes of the given asset types from r, along with
+// any edge touching a removed node.
+func ExcludeTypes(r *ParseResult, types []string) {
+	if r == nil || len(types) == 0 {
+		return
+	}
+	skip := make(map[string]bool, len(types))
+	for _, t := range types {
+		skip[t] = true
+	}
+	removed := make(map[string]bool)
+	nodes := r.Nodes[:0]
+	for _, n := range r.Nodes {
+		if skip[string(n.Type)] {
+			removed[n.ID] = true
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	r.Nodes = nodes
+	if len(removed) == 0 {
+		return
+	}
+	edges := r.Edges[:0]
+	for _, e := range r.Edges {
+		if removed[e.FromID] || removed[e.ToID] {
+			continue
+		}
+		edges = append(edges, e)
+	}
+	r.Edges = edges
+}
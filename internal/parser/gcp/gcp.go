@@ -0,0 +1,338 @@
+// Package gcp discovers running Google Cloud resources through the Google
+// Cloud APIs: Compute Engine instances, networks, subnetworks and
+// forwarding rules, Cloud SQL instances and Cloud Storage buckets.
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	compute "google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	storage "google.golang.org/api/storage/v1"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// Fetch discovers the resources of project. credentialsFile is a service
+// account key; if empty, Application Default Credentials are used. A
+// resource listing that fails (e.g. because its API is disabled) is
+// reported as a warning and counted in PathsFailed; Fetch only fails if no
+// listing succeeds.
+func Fetch(ctx context.Context, project, credentialsFile string) (*parser.ParseResult, error) {
+	if project == "" {
+		return nil, fmt.Errorf("a GCP project ID is required")
+	}
+	var opts []option.ClientOption
+	if credentialsFile != "" {
+		opts = append(opts, option.WithAuthCredentialsFile(option.ServiceAccount, credentialsFile))
+	}
+	return fetch(ctx, project, opts...)
+}
+
+// fetch is Fetch with explicit client options; tests point them at a fake
+// server.
+func fetch(ctx context.Context, project string, opts ...option.ClientOption) (*parser.ParseResult, error) {
+	ctx, cancel := parser.WithDefaultCommandTimeout(ctx)
+	defer cancel()
+
+	computeSvc, err := compute.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("no usable Google Cloud credentials (set GOOGLE_APPLICATION_CREDENTIALS or run 'gcloud auth application-default login'): %w", err)
+	}
+	sqlSvc, err := sqladmin.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating Cloud SQL client: %w", err)
+	}
+	storageSvc, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating Cloud Storage client: %w", err)
+	}
+
+	slog.InfoContext(ctx, "discovering GCP resources", "project", project)
+	d := &discovery{
+		project: project,
+		now:     time.Now(),
+		result:  &parser.ParseResult{},
+		known:   make(map[string]bool),
+	}
+	listings := []struct {
+		name string
+		list func(context.Context) error
+	}{
+		{"networks", func(ctx context.Context) error { return d.listNetworks(ctx, computeSvc) }},
+		{"subnetworks", func(ctx context.Context) error { return d.listSubnetworks(ctx, computeSvc) }},
+		{"instances", func(ctx context.Context) error { return d.listInstances(ctx, computeSvc) }},
+		{"forwarding rules", func(ctx context.Context) error { return d.listForwardingRules(ctx, computeSvc) }},
+		{"Cloud SQL instances", func(ctx context.Context) error { return d.listSQLInstances(ctx, sqlSvc) }},
+		{"buckets", func(ctx context.Context) error { return d.listBuckets(ctx, storageSvc) }},
+	}
+	for _, l := range listings {
+		if err := l.list(ctx); err != nil {
+			d.result.Warnings = append(d.result.Warnings, fmt.Sprintf("%s: listing %s: %v", project, l.name, err))
+			d.result.PathsFailed++
+			continue
+		}
+		d.result.PathsScanned++
+	}
+	if d.result.PathsScanned == 0 {
+		return nil, fmt.Errorf("all GCP resource listings failed: %s", strings.Join(d.result.Warnings, "; "))
+	}
+
+	seen := make(map[string]bool, len(d.edges))
+	for _, e := range d.edges {
+		if d.known[e.FromID] && d.known[e.ToID] && !seen[e.ID] {
+			seen[e.ID] = true
+			d.result.Edges = append(d.result.Edges, e)
+		}
+	}
+	return d.result, nil
+}
+
+// discovery accumulates the nodes and edges of one project.
+type discovery struct {
+	project string
+	now     time.Time
+	result  *parser.ParseResult
+	known   map[string]bool
+	edges   []models.Edge
+}
+
+// NodeID returns the ID of a GCP resource, mirroring Terraform's
+// "tf:<type>:<name>" with a "gcp:" prefix. ref is the resource's path
+// below "projects/": "<project>/<name>" for global resources and
+// "<project>/<region or zone>/<name>" for regional and zonal ones, whose
+// names are only unique per location. Buckets, whose names are global,
+// use the bare name.
+func NodeID(t models.AssetType, ref string) string {
+	return fmt.Sprintf("gcp:%s:%s", t, ref)
+}
+
+// linkRef returns the NodeID ref of the resource a Compute API link (full
+// URL or relative "projects/..." path) points at, or "" if link is not a
+// resource link.
+func linkRef(link string) string {
+	_, rest, ok := strings.Cut(link, "projects/")
+	if !ok {
+		return ""
+	}
+	parts := strings.Split(rest, "/")
+	switch {
+	case len(parts) == 4 && parts[1] == "global": // p/global/networks/n
+		return parts[0] + "/" + parts[3]
+	case len(parts) == 5 && (parts[1] == "regions" || parts[1] == "zones"): // p/regions/r/subnetworks/s
+		return parts[0] + "/" + parts[2] + "/" + parts[4]
+	}
+	return ""
+}
+
+// lastSegment returns the final path segment of a link, e.g. the zone name
+// of an instance's zone URL.
+func lastSegment(link string) string {
+	return link[strings.LastIndex(link, "/")+1:]
+}
+
+func (d *discovery) addNode(t models.AssetType, ref, name string, labels, meta map[string]string) string {
+	nodeID := NodeID(t, ref)
+	for k, v := range labels {
+		meta["label:"+k] = v
+	}
+	meta["project"] = d.project
+	d.known[nodeID] = true
+	d.result.Nodes = append(d.result.Nodes, models.Node{
+		ID:         nodeID,
+		Name:       name,
+		Type:       t,
+		Source:     "gcp",
+		SourceFile: "gcp:" + d.project,
+		Provider:   "google",
+		Metadata:   meta,
+		LastSeen:   d.now,
+		FirstSeen:  d.now,
+	})
+	return nodeID
+}
+
+// addLinkEdge adds an edge from fromID to the resource of type toType that
+// link points at.
+func (d *discovery) addLinkEdge(fromID string, toType models.AssetType, link string, t models.EdgeType) {
+	ref := linkRef(link)
+	if ref == "" {
+		return
+	}
+	target := NodeID(toType, ref)
+	d.edges = append(d.edges, models.Edge{
+		ID:     fmt.Sprintf("%s->%s->%s", fromID, t, target),
+		FromID: fromID,
+		ToID:   target,
+		Type:   t,
+	})
+}
+
+func (d *discovery) listNetworks(ctx context.Context, svc *compute.Service) error {
+	return svc.Networks.List(d.project).Pages(ctx, func(page *compute.NetworkList) error {
+		for _, n := range page.Items {
+			meta := map[string]string{
+				"auto_create_subnetworks": fmt.Sprintf("%t", n.AutoCreateSubnetworks),
+			}
+			if n.RoutingConfig != nil {
+				meta["routing_mode"] = n.RoutingConfig.RoutingMode
+			}
+			d.addNode(models.AssetNetwork, d.project+"/"+n.Name, n.Name, nil, meta)
+		}
+		return nil
+	})
+}
+
+func (d *discovery) listSubnetworks(ctx context.Context, svc *compute.Service) error {
+	return svc.Subnetworks.AggregatedList(d.project).Pages(ctx, func(page *compute.SubnetworkAggregatedList) error {
+		for _, scoped := range page.Items {
+			for _, s := range scoped.Subnetworks {
+				region := lastSegment(s.Region)
+				id := d.addNode(models.AssetSubnet, d.project+"/"+region+"/"+s.Name, s.Name, nil, map[string]string{
+					"cidr":    s.IpCidrRange,
+					"region":  region,
+					"network": lastSegment(s.Network),
+				})
+				d.addLinkEdge(id, models.AssetNetwork, s.Network, models.EdgeMemberOf)
+			}
+		}
+		return nil
+	})
+}
+
+// listInstances adds Compute Engine instances, connected through each
+// network interface to its subnetwork, or to its network for legacy
+// networks without subnetworks.
+func (d *discovery) listInstances(ctx context.Context, svc *compute.Service) error {
+	return svc.Instances.AggregatedList(d.project).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+		for _, scoped := range page.Items {
+			for _, in := range scoped.Instances {
+				zone := lastSegment(in.Zone)
+				meta := map[string]string{
+					"machine_type": lastSegment(in.MachineType),
+					"status":       in.Status,
+					"zone":         zone,
+				}
+				var internal, external []string
+				for _, ni := range in.NetworkInterfaces {
+					if ni.NetworkIP != "" {
+						internal = append(internal, ni.NetworkIP)
+					}
+					for _, ac := range ni.AccessConfigs {
+						if ac.NatIP != "" {
+							external = append(external, ac.NatIP)
+						}
+					}
+				}
+				if len(internal) > 0 {
+					meta["internal_ip"] = strings.Join(internal, ",")
+				}
+				if len(external) > 0 {
+					meta["external_ip"] = strings.Join(external, ",")
+				}
+
+				id := d.addNode(models.AssetVM, d.project+"/"+zone+"/"+in.Name, in.Name, in.Labels, meta)
+				for _, ni := range in.NetworkInterfaces {
+					if ni.Subnetwork != "" {
+						d.addLinkEdge(id, models.AssetSubnet, ni.Subnetwork, models.EdgeMemberOf)
+					} else {
+						d.addLinkEdge(id, models.AssetNetwork, ni.Network, models.EdgeMemberOf)
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// listForwardingRules adds regional and global forwarding rules, the
+// frontends of Google Cloud load balancers.
+func (d *discovery) listForwardingRules(ctx context.Context, svc *compute.Service) error {
+	add := func(r *compute.ForwardingRule) {
+		ref := d.project + "/" + r.Name
+		meta := map[string]string{
+			"ip_address": r.IPAddress,
+			"protocol":   r.IPProtocol,
+			"scheme":     r.LoadBalancingScheme,
+			"target":     lastSegment(r.Target),
+		}
+		if r.PortRange != "" {
+			meta["port_range"] = r.PortRange
+		}
+		if r.Region != "" {
+			meta["region"] = lastSegment(r.Region)
+			ref = d.project + "/" + meta["region"] + "/" + r.Name
+		}
+		id := d.addNode(models.AssetLoadBalancer, ref, r.Name, r.Labels, meta)
+		if r.Subnetwork != "" {
+			d.addLinkEdge(id, models.AssetSubnet, r.Subnetwork, models.EdgeMemberOf)
+		} else {
+			d.addLinkEdge(id, models.AssetNetwork, r.Network, models.EdgeMemberOf)
+		}
+	}
+	err := svc.ForwardingRules.AggregatedList(d.project).Pages(ctx, func(page *compute.ForwardingRuleAggregatedList) error {
+		for _, scoped := range page.Items {
+			for _, r := range scoped.ForwardingRules {
+				add(r)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return svc.GlobalForwardingRules.List(d.project).Pages(ctx, func(page *compute.ForwardingRuleList) error {
+		for _, r := range page.Items {
+			add(r)
+		}
+		return nil
+	})
+}
+
+func (d *discovery) listSQLInstances(ctx context.Context, svc *sqladmin.Service) error {
+	return svc.Instances.List(d.project).Pages(ctx, func(page *sqladmin.InstancesListResponse) error {
+		for _, db := range page.Items {
+			meta := map[string]string{
+				"database_version": db.DatabaseVersion,
+				"region":           db.Region,
+				"state":            db.State,
+			}
+			for _, ip := range db.IpAddresses {
+				meta[strings.ToLower(ip.Type)+"_ip"] = ip.IpAddress
+			}
+			var labels map[string]string
+			var privateNetwork string
+			if s := db.Settings; s != nil {
+				meta["tier"] = s.Tier
+				labels = maps.Clone(s.UserLabels)
+				if s.IpConfiguration != nil {
+					privateNetwork = s.IpConfiguration.PrivateNetwork
+					meta["public_ip_enabled"] = fmt.Sprintf("%t", s.IpConfiguration.Ipv4Enabled)
+				}
+			}
+			id := d.addNode(models.AssetDatabase, d.project+"/"+db.Name, db.Name, labels, meta)
+			d.addLinkEdge(id, models.AssetNetwork, privateNetwork, models.EdgeMemberOf)
+		}
+		return nil
+	})
+}
+
+func (d *discovery) listBuckets(ctx context.Context, svc *storage.Service) error {
+	return svc.Buckets.List(d.project).Pages(ctx, func(page *storage.Buckets) error {
+		for _, b := range page.Items {
+			d.addNode(models.AssetBucket, b.Name, b.Name, b.Labels, map[string]string{
+				"location":      strings.ToLower(b.Location),
+				"storage_class": b.StorageClass,
+				"created":       b.TimeCreated,
+			})
+		}
+		return nil
+	})
+}
@@ -0,0 +1,159 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/api/option"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+const apiBase = "https://www.googleapis.com/compute/v1/projects/shop/"
+
+// fakeAPI serves canned Compute, Cloud SQL and Storage responses for
+// project "shop": a VPC with one subnetwork, an instance on it, an internal
+// forwarding rule, a private Cloud SQL instance and a bucket. Paths not
+// listed return 403.
+func fakeAPI(t *testing.T, responses map[string]string) []option.ClientOption {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"error":{"code":403,"message":"API not enabled"}}`, http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return []option.ClientOption{
+		option.WithEndpoint(ts.URL + "/"),
+		option.WithHTTPClient(ts.Client()),
+	}
+}
+
+var shopResponses = map[string]string{
+	"/projects/shop/global/networks": `{"items":[{"name":"prod","autoCreateSubnetworks":false}]}`,
+	"/projects/shop/aggregated/subnetworks": `{"items":{"regions/europe-west1":{"subnetworks":[
+		{"name":"app","region":"` + apiBase + `regions/europe-west1","network":"` + apiBase + `global/networks/prod","ipCidrRange":"10.0.0.0/24"}]}}}`,
+	"/projects/shop/aggregated/instances": `{"items":{"zones/europe-west1-b":{"instances":[
+		{"name":"web-1","zone":"` + apiBase + `zones/europe-west1-b","machineType":"` + apiBase + `zones/europe-west1-b/machineTypes/e2-small",
+		 "status":"RUNNING","labels":{"team":"shop"},
+		 "networkInterfaces":[{"network":"` + apiBase + `global/networks/prod","subnetwork":"` + apiBase + `regions/europe-west1/subnetworks/app",
+		   "networkIP":"10.0.0.5","accessConfigs":[{"natIP":"34.1.2.3"}]}]}]},
+		"zones/us-east1-b":{"warning":{"code":"NO_RESULTS_ON_PAGE"}}}}`,
+	"/projects/shop/aggregated/forwardingRules": `{"items":{"regions/europe-west1":{"forwardingRules":[
+		{"name":"web-ilb","region":"` + apiBase + `regions/europe-west1","IPAddress":"10.0.0.100","IPProtocol":"TCP",
+		 "loadBalancingScheme":"INTERNAL","subnetwork":"` + apiBase + `regions/europe-west1/subnetworks/app"}]}}}`,
+	"/projects/shop/global/forwardingRules": `{"items":[]}`,
+	"/v1/projects/shop/instances": `{"items":[{"name":"orders","databaseVersion":"POSTGRES_15","region":"europe-west1","state":"RUNNABLE",
+		"settings":{"tier":"db-f1-micro","ipConfiguration":{"privateNetwork":"projects/shop/global/networks/prod","ipv4Enabled":false}},
+		"ipAddresses":[{"type":"PRIVATE","ipAddress":"10.1.0.3"}]}]}`,
+	"/b": `{"items":[{"name":"shop-assets","location":"EU","storageClass":"STANDARD"}]}`,
+}
+
+func TestFetch(t *testing.T) {
+	result, err := fetch(context.Background(), "shop", fakeAPI(t, shopResponses)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Fatalf("warnings: %v", result.Warnings)
+	}
+
+	nodes := make(map[string]models.Node, len(result.Nodes))
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	for id, typ := range map[string]models.AssetType{
+		"gcp:network:shop/prod":                       models.AssetNetwork,
+		"gcp:subnet:shop/europe-west1/app":            models.AssetSubnet,
+		"gcp:vm:shop/europe-west1-b/web-1":            models.AssetVM,
+		"gcp:load_balancer:shop/europe-west1/web-ilb": models.AssetLoadBalancer,
+		"gcp:database:shop/orders":                    models.AssetDatabase,
+		"gcp:bucket:shop-assets":                      models.AssetBucket,
+	} {
+		n, ok := nodes[id]
+		if !ok {
+			t.Errorf("missing node %s", id)
+			continue
+		}
+		if n.Type != typ || n.Source != "gcp" || n.Provider != "google" || n.Metadata["project"] != "shop" {
+			t.Errorf("node %s = %+v", id, n)
+		}
+	}
+	if len(nodes) != 6 {
+		t.Errorf("got %d nodes, want 6", len(nodes))
+	}
+
+	vm := nodes["gcp:vm:shop/europe-west1-b/web-1"]
+	if vm.Name != "web-1" || vm.Metadata["machine_type"] != "e2-small" || vm.Metadata["internal_ip"] != "10.0.0.5" ||
+		vm.Metadata["external_ip"] != "34.1.2.3" || vm.Metadata["label:team"] != "shop" {
+		t.Errorf("vm = %+v", vm)
+	}
+	if got := nodes["gcp:database:shop/orders"].Metadata["private_ip"]; got != "10.1.0.3" {
+		t.Errorf("database private_ip = %q", got)
+	}
+
+	edges := make(map[string]bool, len(result.Edges))
+	for _, e := range result.Edges {
+		edges[e.ID] = true
+	}
+	for _, id := range []string{
+		"gcp:subnet:shop/europe-west1/app->member_of->gcp:network:shop/prod",
+		"gcp:vm:shop/europe-west1-b/web-1->member_of->gcp:subnet:shop/europe-west1/app",
+		"gcp:load_balancer:shop/europe-west1/web-ilb->member_of->gcp:subnet:shop/europe-west1/app",
+		"gcp:database:shop/orders->member_of->gcp:network:shop/prod",
+	} {
+		if !edges[id] {
+			t.Errorf("missing edge %s", id)
+		}
+	}
+	if len(result.Edges) != 4 {
+		t.Errorf("got %d edges, want 4", len(result.Edges))
+	}
+}
+
+func TestFetch_DisabledAPI(t *testing.T) {
+	responses := make(map[string]string, len(shopResponses))
+	for k, v := range shopResponses {
+		if k != "/v1/projects/shop/instances" {
+			responses[k] = v
+		}
+	}
+	result, err := fetch(context.Background(), "shop", fakeAPI(t, responses)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PathsScanned != 5 || result.PathsFailed != 1 {
+		t.Errorf("listings scanned/failed = %d/%d, want 5/1", result.PathsScanned, result.PathsFailed)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "Cloud SQL") {
+		t.Errorf("warnings = %v", result.Warnings)
+	}
+
+	if _, err := fetch(context.Background(), "shop", fakeAPI(t, nil)...); err == nil {
+		t.Error("expected an error when every listing fails")
+	}
+	if _, err := Fetch(context.Background(), "", ""); err == nil {
+		t.Error("expected an error without a project")
+	}
+}
+
+func TestLinkRef(t *testing.T) {
+	for link, want := range map[string]string{
+		apiBase + "global/networks/prod":                 "shop/prod",
+		apiBase + "regions/europe-west1/subnetworks/app": "shop/europe-west1/app",
+		"projects/shop/global/networks/prod":             "shop/prod",
+		"":                                               "",
+		"https://www.googleapis.com/compute/v1/whatever": "",
+	} {
+		if got := linkRef(link); got != want {
+			t.Errorf("linkRef(%q) = %q, want %q", link, got, want)
+		}
+	}
+}
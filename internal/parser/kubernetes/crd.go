@@ -0,0 +1,350 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+	"go.yaml.in/yaml/v3"
+)
+
+// maxSpecDepth limits how deep nested spec maps are flattened into metadata.
+const maxSpecDepth = 3
+
+// crdDefinition is the subset of a CustomResourceDefinition needed to list
+// its instances.
+type crdDefinition struct {
+	Metadata struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Group string `yaml:"group"`
+		Scope string `yaml:"scope"`
+		Names struct {
+			Kind string `yaml:"kind"`
+		} `yaml:"names"`
+	} `yaml:"spec"`
+}
+
+// customResource is a generic custom resource instance. The spec is kept
+// untyped since every CRD defines its own schema.
+type customResource struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name            string            `yaml:"name"`
+		Namespace       string            `yaml:"namespace"`
+		Labels          map[string]string `yaml:"labels"`
+		OwnerReferences []struct {
+			Kind string `yaml:"kind"`
+			Name string `yaml:"name"`
+		} `yaml:"ownerReferences"`
+	} `yaml:"metadata"`
+	Spec map[string]any `yaml:"spec"`
+}
+
+// customResourceDefinitions is the resource name of CRDs themselves.
+const customResourceDefinitions = "customresourcedefinitions.apiextensions.k8s.io"
+
+// resourceGetter lists resource in namespace (cluster-wide if empty) and
+// returns the list as YAML or JSON.
+type resourceGetter func(ctx context.Context, namespace, resource string) ([]byte, error)
+
+// fetchCustomResources lists the cluster's CRDs and pulls the instances of
+// those matching allow as generic nodes. Allowlist entries are glob patterns
+// matched against the CRD name (e.g. "postgresqls.acid.zalan.do",
+// "*.aws.upbound.io") or a kind (e.g. "Cluster"). Instances get managed_by
+// edges to owners found in known, and edges to secrets and objects named by
+// spec references. known maps node IDs already discovered to their nodes.
+func fetchCustomResources(ctx context.Context, get resourceGetter, namespaces, allow []string, known map[string]models.Node, now time.Time) *parser.ParseResult {
+	result := &parser.ParseResult{}
+
+	data, err := get(ctx, "", customResourceDefinitions)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("listing custom resource definitions: %v", err))
+		return result
+	}
+	var crdList struct {
+		Items []crdDefinition `yaml:"items"`
+	}
+	if err := yaml.Unmarshal(data, &crdList); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("parsing custom resource definitions: %v", err))
+		return result
+	}
+
+	for _, crd := range crdList.Items {
+		if !crdAllowed(crd, allow) {
+			continue
+		}
+		if crd.Spec.Scope == "Cluster" {
+			parseCustomResources(ctx, get, "", crd, known, now, result)
+			continue
+		}
+		for _, ns := range namespaces {
+			parseCustomResources(ctx, get, ns, crd, known, now, result)
+		}
+	}
+	return result
+}
+
+// crdAllowed reports whether crd matches any allowlist entry.
+func crdAllowed(crd crdDefinition, allow []string) bool {
+	for _, pattern := range allow {
+		if strings.EqualFold(pattern, crd.Spec.Names.Kind) {
+			return true
+		}
+		if ok, _ := path.Match(pattern, crd.Metadata.Name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCustomResources fetches the instances of crd in namespace (cluster-wide
+// if empty) and appends them to result.
+func parseCustomResources(ctx context.Context, get resourceGetter, namespace string, crd crdDefinition, known map[string]models.Node, now time.Time, result *parser.ParseResult) {
+	data, err := get(ctx, namespace, crd.Metadata.Name)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("custom resource %s: %v", crd.Metadata.Name, err))
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return
+	}
+	var list struct {
+		Items []customResource `yaml:"items"`
+	}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("parsing custom resource %s: %v", crd.Metadata.Name, err))
+		return
+	}
+
+	sourceFile := "live:cluster"
+	if namespace != "" {
+		sourceFile = "live:" + namespace
+	}
+
+	start := len(result.Nodes)
+	for _, cr := range list.Items {
+		kind := strings.ToLower(crd.Spec.Names.Kind)
+		ns := cr.Metadata.Namespace
+		nodeID := customResourceID(kind, ns, cr.Metadata.Name)
+
+		meta := map[string]string{
+			"kind":        crd.Spec.Names.Kind,
+			"api_version": cr.APIVersion,
+			"crd":         crd.Metadata.Name,
+		}
+		if ns != "" {
+			meta["namespace"] = ns
+		}
+		for k, v := range cr.Metadata.Labels {
+			meta["label:"+k] = v
+		}
+		flattenSpec("spec", cr.Spec, 1, meta)
+
+		node := models.Node{
+			ID:         nodeID,
+			Name:       cr.Metadata.Name,
+			Type:       models.AssetType(kind),
+			Source:     "kubernetes",
+			SourceFile: sourceFile,
+			Provider:   crd.Spec.Group,
+			Metadata:   meta,
+			LastSeen:   now,
+			FirstSeen:  now,
+		}
+		known[nodeID] = node
+		result.Nodes = append(result.Nodes, node)
+
+		for _, owner := range cr.Metadata.OwnerReferences {
+			ownerID := customResourceID(ownerKindType(owner.Kind), ns, owner.Name)
+			if _, ok := known[ownerID]; !ok {
+				continue
+			}
+			result.Edges = append(result.Edges, models.Edge{
+				ID:     fmt.Sprintf("%s->managed_by->%s", nodeID, ownerID),
+				FromID: nodeID,
+				ToID:   ownerID,
+				Type:   models.EdgeManagedBy,
+			})
+		}
+
+		for _, ref := range specRefs(cr.Spec) {
+			refNS := ref.namespace
+			if refNS == "" {
+				refNS = ns
+			}
+			if ref.secret {
+				if refNS == "" {
+					continue
+				}
+				secretID := k8sNodeID("secret", refNS, ref.name)
+				ensureNode(known, result, secretID, ref.name, models.AssetSecret, refNS, sourceFile, now)
+				result.Edges = append(result.Edges, models.Edge{
+					ID:       fmt.Sprintf("%s->mounts_secret->%s", nodeID, secretID),
+					FromID:   nodeID,
+					ToID:     secretID,
+					Type:     models.EdgeMountsSecret,
+					Metadata: map[string]string{"via": ref.field},
+				})
+				continue
+			}
+			targetID := customResourceID(ownerKindType(ref.kind), refNS, ref.name)
+			if _, ok := known[targetID]; !ok {
+				targetID = customResourceID(ownerKindType(ref.kind), "", ref.name)
+				if _, ok := known[targetID]; !ok {
+					continue
+				}
+			}
+			result.Edges = append(result.Edges, models.Edge{
+				ID:       fmt.Sprintf("%s->depends_on->%s", nodeID, targetID),
+				FromID:   nodeID,
+				ToID:     targetID,
+				Type:     models.EdgeDependsOn,
+				Metadata: map[string]string{"via": ref.field},
+			})
+		}
+	}
+
+	// Like built-in kinds, namespaced instances and the secrets they
+	// reference are members of their namespace.
+	linkNamespaces(known, result, slices.Clone(result.Nodes[start:]), sourceFile, now)
+}
+
+// customResourceID builds a node ID, omitting the namespace for
+// cluster-scoped objects.
+func customResourceID(kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("k8s:%s:%s", kind, name)
+	}
+	return k8sNodeID(kind, namespace, name)
+}
+
+// ownerKindType maps an object kind to the node type used for it by the
+// manifest parser, which is the lower-cased kind except for workloads, bare
+// Pods, HPAs and PVCs.
+func ownerKindType(kind string) string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+		return "pod"
+	case "Pod":
+		return "barepod"
+	case "HorizontalPodAutoscaler":
+		return "hpa"
+	case "PersistentVolumeClaim":
+		return "pvc"
+	}
+	return strings.ToLower(kind)
+}
+
+// flattenSpec copies scalar spec fields into meta under dotted keys. Lists of
+// scalars are comma-joined; deeper nesting and lists of objects are skipped.
+func flattenSpec(prefix string, spec map[string]any, depth int, meta map[string]string) {
+	for k, v := range spec {
+		key := prefix + "." + k
+		switch val := v.(type) {
+		case map[string]any:
+			if depth < maxSpecDepth {
+				flattenSpec(key, val, depth+1, meta)
+			}
+		case []any:
+			if joined, ok := joinScalars(val); ok {
+				meta[key] = joined
+			}
+		case nil:
+		default:
+			meta[key] = fmt.Sprintf("%v", val)
+		}
+	}
+}
+
+// joinScalars comma-joins a list of scalar values. It reports false for empty
+// lists and lists containing objects or nested lists.
+func joinScalars(list []any) (string, bool) {
+	parts := make([]string, 0, len(list))
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]any, []any:
+			return "", false
+		}
+		parts = append(parts, fmt.Sprintf("%v", item))
+	}
+	return strings.Join(parts, ","), len(parts) > 0
+}
+
+// specRef is a reference to another object found in a custom resource spec.
+type specRef struct {
+	field     string
+	kind      string
+	name      string
+	namespace string
+	secret    bool
+}
+
+// specRefs finds object references in a spec: fields named *Ref or *Refs
+// holding a name (e.g. secretRef, providerConfigRef,
+// writeConnectionSecretToRef). Fields mentioning "secret" point at Secrets;
+// others need an explicit kind to be resolved.
+func specRefs(spec map[string]any) []specRef {
+	var refs []specRef
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := k
+			if prefix != "" {
+				field = prefix + "." + k
+			}
+			switch val := m[k].(type) {
+			case map[string]any:
+				if strings.HasSuffix(k, "Ref") {
+					if ref, ok := toSpecRef(field, k, val); ok {
+						refs = append(refs, ref)
+						continue
+					}
+				}
+				walk(field, val)
+			case []any:
+				if !strings.HasSuffix(k, "Refs") {
+					continue
+				}
+				for _, item := range val {
+					if m, ok := item.(map[string]any); ok {
+						if ref, ok := toSpecRef(field, k, m); ok {
+							refs = append(refs, ref)
+						}
+					}
+				}
+			}
+		}
+	}
+	walk("", spec)
+	return refs
+}
+
+func toSpecRef(field, key string, m map[string]any) (specRef, bool) {
+	name, _ := m["name"].(string)
+	if name == "" {
+		return specRef{}, false
+	}
+	ref := specRef{field: field, name: name}
+	ref.kind, _ = m["kind"].(string)
+	ref.namespace, _ = m["namespace"].(string)
+	ref.secret = ref.kind == "Secret" || strings.Contains(strings.ToLower(key), "secret")
+	if !ref.secret && ref.kind == "" {
+		return specRef{}, false
+	}
+	return ref, true
+}
@@ -0,0 +1,216 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+const testCRDList = `apiVersion: v1
+kind: List
+items:
+- apiVersion: apiextensions.k8s.io/v1
+  kind: CustomResourceDefinition
+  metadata:
+    name: postgresqls.acid.zalan.do
+  spec:
+    group: acid.zalan.do
+    scope: Namespaced
+    names:
+      kind: postgresql
+- apiVersion: apiextensions.k8s.io/v1
+  kind: CustomResourceDefinition
+  metadata:
+    name: instances.rds.aws.upbound.io
+  spec:
+    group: rds.aws.upbound.io
+    scope: Cluster
+    names:
+      kind: Instance
+- apiVersion: apiextensions.k8s.io/v1
+  kind: CustomResourceDefinition
+  metadata:
+    name: widgets.example.com
+  spec:
+    group: example.com
+    scope: Namespaced
+    names:
+      kind: Widget
+`
+
+func TestFetchLive_CustomResources(t *testing.T) {
+	withoutClientGo(t)
+	originalLookPath := kubectlLookPath
+	originalGet := kubectlGetFn
+	kubectlLookPath = func(string) (string, error) {
+		return "/usr/bin/kubectl", nil
+	}
+	var fetched []string
+	kubectlGetFn = func(_ context.Context, _, _, namespace, resourceTypes string) ([]byte, error) {
+		fetched = append(fetched, resourceTypes)
+		switch resourceTypes {
+		case "customresourcedefinitions.apiextensions.k8s.io":
+			return []byte(testCRDList), nil
+		case "postgresqls.acid.zalan.do":
+			return []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: acid.zalan.do/v1
+  kind: postgresql
+  metadata:
+    name: orders-db
+    namespace: ` + namespace + `
+    ownerReferences:
+    - kind: Deployment
+      name: api
+  spec:
+    numberOfInstances: 2
+    postgresql:
+      version: "15"
+    volume:
+      size: 10Gi
+    databases:
+      orders: app
+`), nil
+		case "instances.rds.aws.upbound.io":
+			return []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: rds.aws.upbound.io/v1beta1
+  kind: Instance
+  metadata:
+    name: billing
+  spec:
+    forProvider:
+      engine: postgres
+      region: eu-west-1
+    writeConnectionSecretToRef:
+      name: billing-conn
+      namespace: default
+    providerConfigRef:
+      name: aws-prod
+`), nil
+		case "widgets.example.com":
+			t.Error("widgets are not in the allowlist and should not be fetched")
+			return nil, nil
+		}
+		if namespace == "" {
+			return nil, nil
+		}
+		return []byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: api
+    namespace: default
+`), nil
+	}
+	t.Cleanup(func() {
+		kubectlLookPath = originalLookPath
+		kubectlGetFn = originalGet
+	})
+
+	r, err := FetchLive(context.Background(), "", "", []string{"default"}, []string{"postgresqls.acid.zalan.do", "*.aws.upbound.io"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range r.Nodes {
+		nodes[n.ID] = n
+	}
+	db, ok := nodes["k8s:postgresql:default/orders-db"]
+	if !ok {
+		t.Fatalf("missing postgresql node, got %v", r.Nodes)
+	}
+	if db.Type != "postgresql" || db.Provider != "acid.zalan.do" {
+		t.Errorf("postgresql node type/provider = %s/%s", db.Type, db.Provider)
+	}
+	if db.Metadata["spec.numberOfInstances"] != "2" || db.Metadata["spec.postgresql.version"] != "15" {
+		t.Errorf("spec not flattened into metadata: %v", db.Metadata)
+	}
+	rds, ok := nodes["k8s:instance:billing"]
+	if !ok {
+		t.Fatal("missing cluster-scoped instance node")
+	}
+	if rds.Metadata["spec.forProvider.engine"] != "postgres" {
+		t.Errorf("forProvider.engine = %q", rds.Metadata["spec.forProvider.engine"])
+	}
+	if _, ok := nodes["k8s:secret:default/billing-conn"]; !ok {
+		t.Error("referenced connection secret should be auto-created")
+	}
+
+	edges := make(map[string]models.EdgeType)
+	for _, e := range r.Edges {
+		edges[e.FromID+"->"+e.ToID] = e.Type
+	}
+	if edges["k8s:postgresql:default/orders-db->k8s:pod:default/api"] != models.EdgeManagedBy {
+		t.Error("missing managed_by edge to owning deployment")
+	}
+	if edges["k8s:instance:billing->k8s:secret:default/billing-conn"] != models.EdgeMountsSecret {
+		t.Error("missing secret reference edge")
+	}
+	if edges["k8s:postgresql:default/orders-db->k8s:namespace:default"] != models.EdgeMemberOf {
+		t.Error("missing member_of edge from custom resource to its namespace")
+	}
+	if edges["k8s:secret:default/billing-conn->k8s:namespace:default"] != models.EdgeMemberOf {
+		t.Error("missing member_of edge from referenced secret to its namespace")
+	}
+	if _, ok := edges["k8s:instance:billing->k8s:namespace:default"]; ok {
+		t.Error("cluster-scoped custom resource should not join a namespace")
+	}
+	for _, e := range r.Edges {
+		if strings.Contains(e.ToID, "aws-prod") {
+			t.Errorf("unresolvable kind-less ref should not produce an edge: %s", e.ID)
+		}
+	}
+}
+
+func TestFetchLive_NoCustomResourcesWithoutAllowlist(t *testing.T) {
+	withoutClientGo(t)
+	originalLookPath := kubectlLookPath
+	originalGet := kubectlGetFn
+	kubectlLookPath = func(string) (string, error) {
+		return "/usr/bin/kubectl", nil
+	}
+	kubectlGetFn = func(_ context.Context, _, _, _, resourceTypes string) ([]byte, error) {
+		if resourceTypes == "customresourcedefinitions.apiextensions.k8s.io" {
+			t.Error("CRDs should not be listed without an allowlist")
+		}
+		return nil, nil
+	}
+	t.Cleanup(func() {
+		kubectlLookPath = originalLookPath
+		kubectlGetFn = originalGet
+	})
+
+	if _, err := FetchLive(context.Background(), "", "", []string{"default"}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCRDAllowed(t *testing.T) {
+	var crd crdDefinition
+	crd.Metadata.Name = "instances.rds.aws.upbound.io"
+	crd.Spec.Names.Kind = "Instance"
+
+	tests := []struct {
+		allow []string
+		want  bool
+	}{
+		{[]string{"instances.rds.aws.upbound.io"}, true},
+		{[]string{"*.aws.upbound.io"}, true},
+		{[]string{"instance"}, true},
+		{[]string{"*.gcp.upbound.io", "Bucket"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := crdAllowed(crd, tt.allow); got != tt.want {
+			t.Errorf("crdAllowed(%v) = %v, want %v", tt.allow, got, tt.want)
+		}
+	}
+}
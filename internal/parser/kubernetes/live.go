@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"log/slog"
 	"os/exec"
 	"time"
 
 	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
 )
 
 var (
@@ -16,14 +18,28 @@ var (
 	kubectlGetFn    = kubectlGet
 )
 
-// FetchLive connects to a running Kubernetes cluster via kubectl and pulls
-// resources. If kubeconfig is empty, kubectl uses its default config.
+// FetchLive connects to a running Kubernetes cluster and pulls resources.
+// It talks to the API server with client-go, reading the kubeconfig like
+// kubectl does, and falls back to shelling out to kubectl if no client
+// config can be built. If kubeconfig is empty, the default config is used.
 // If kubeCtx is empty, the current-context is used.
 // If namespaces is empty, all non-system namespaces are scanned.
-func FetchLive(ctx context.Context, kubeconfig, kubeCtx string, namespaces []string) (*parser.ParseResult, error) {
+// Instances of CRDs matching customResources are pulled as generic nodes
+// (see fetchCustomResources); none are fetched if it is empty.
+func FetchLive(ctx context.Context, kubeconfig, kubeCtx string, namespaces, customResources []string) (*parser.ParseResult, error) {
 	ctx, cancel := parser.WithDefaultCommandTimeout(ctx)
 	defer cancel()
 
+	clients, err := newLiveClientsFn(kubeconfig, kubeCtx)
+	if err == nil {
+		return fetchLiveClientGo(ctx, clients, namespaces, customResources)
+	}
+	slog.DebugContext(ctx, "client-go config unavailable, falling back to kubectl", "error", err)
+	return fetchLiveKubectl(ctx, kubeconfig, kubeCtx, namespaces, customResources)
+}
+
+// fetchLiveKubectl is FetchLive using the kubectl CLI.
+func fetchLiveKubectl(ctx context.Context, kubeconfig, kubeCtx string, namespaces, customResources []string) (*parser.ParseResult, error) {
 	if _, err := kubectlLookPath("kubectl"); err != nil {
 		return nil, fmt.Errorf("kubectl not found in PATH: %w", err)
 	}
@@ -39,7 +55,7 @@ func FetchLive(ctx context.Context, kubeconfig, kubeCtx string, namespaces []str
 	result := &parser.ParseResult{}
 	now := time.Now()
 
-	resourceTypes := "deployments,statefulsets,daemonsets,services,ingresses,configmaps,secrets,serviceaccounts,roles,rolebindings,networkpolicies,jobs,cronjobs,horizontalpodautoscalers"
+	resourceTypes := "deployments,statefulsets,daemonsets,replicasets,pods,services,ingresses,configmaps,secrets,serviceaccounts,roles,rolebindings,networkpolicies,jobs,cronjobs,horizontalpodautoscalers,persistentvolumeclaims"
 	clusterScopedTypes := "clusterroles,clusterrolebindings"
 
 	for _, ns := range namespaces {
@@ -61,21 +77,23 @@ func FetchLive(ctx context.Context, kubeconfig, kubeCtx string, namespaces []str
 		result.Warnings = append(result.Warnings, r.Warnings...)
 	}
 
-	// Try cert-manager certificates separately (may not be installed)
+	// Try CRD-backed kinds separately (may not be installed)
 	for _, ns := range namespaces {
-		data, err := kubectlGetFn(ctx, kubeconfig, kubeCtx, ns, "certificates.cert-manager.io")
-		if err != nil {
-			continue // cert-manager may not be installed, skip silently
-		}
-		if len(bytes.TrimSpace(data)) == 0 {
-			continue
-		}
-		r, err := parseManifests(data, fmt.Sprintf("live:%s", ns), now)
-		if err != nil {
-			continue
+		for _, resource := range optionalResourceTypes {
+			data, err := kubectlGetFn(ctx, kubeconfig, kubeCtx, ns, resource)
+			if err != nil {
+				continue // CRD not installed, skip silently
+			}
+			if len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+			r, err := parseManifests(data, fmt.Sprintf("live:%s", ns), now)
+			if err != nil {
+				continue
+			}
+			result.Nodes = append(result.Nodes, r.Nodes...)
+			result.Edges = append(result.Edges, r.Edges...)
 		}
-		result.Nodes = append(result.Nodes, r.Nodes...)
-		result.Edges = append(result.Edges, r.Edges...)
 	}
 
 	// Fetch cluster-scoped resources (not namespace-bound)
@@ -88,9 +106,38 @@ func FetchLive(ctx context.Context, kubeconfig, kubeCtx string, namespaces []str
 		}
 	}
 
+	if len(customResources) > 0 {
+		known := make(map[string]models.Node, len(result.Nodes))
+		for _, n := range result.Nodes {
+			known[n.ID] = n
+		}
+		get := func(ctx context.Context, namespace, resource string) ([]byte, error) {
+			return kubectlGetFn(ctx, kubeconfig, kubeCtx, namespace, resource)
+		}
+		r := fetchCustomResources(ctx, get, namespaces, customResources, known, now)
+		result.Nodes = append(result.Nodes, r.Nodes...)
+		result.Edges = append(result.Edges, r.Edges...)
+		result.Warnings = append(result.Warnings, r.Warnings...)
+	}
+
 	return result, nil
 }
 
+// optionalResourceTypes are namespaced kinds defined by CRDs (cert-manager,
+// Gateway API) that are scanned when the cluster has them installed.
+var optionalResourceTypes = []string{
+	"certificates.cert-manager.io",
+	"gateways.gateway.networking.k8s.io",
+	"httproutes.gateway.networking.k8s.io",
+}
+
+// systemNamespaces are skipped when scanning all namespaces.
+var systemNamespaces = map[string]bool{
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
 // listNamespaces runs kubectl get namespaces and returns non-system namespace names.
 func listNamespaces(ctx context.Context, kubeconfig, kubeCtx string) ([]string, error) {
 	args := buildKubectlArgs(kubeconfig, kubeCtx)
@@ -105,12 +152,6 @@ func listNamespaces(ctx context.Context, kubeconfig, kubeCtx string) ([]string,
 		return nil, fmt.Errorf("kubectl get namespaces: %s", stderr.String())
 	}
 
-	systemNamespaces := map[string]bool{
-		"kube-system":     true,
-		"kube-public":     true,
-		"kube-node-lease": true,
-	}
-
 	var result []string
 	for _, name := range bytes.Fields(stdout.Bytes()) {
 		ns := string(name)
@@ -0,0 +1,436 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	k8sclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// liveClients are the API clients used for native live scans. The dynamic
+// client serves CRD-backed kinds, which have no typed client.
+type liveClients struct {
+	typed   k8sclient.Interface
+	dynamic dynamic.Interface
+}
+
+// newLiveClientsFn builds API clients from a kubeconfig path and context.
+// Replaced in tests with fake clients.
+var newLiveClientsFn = newLiveClients
+
+// newLiveClients loads the kubeconfig the way kubectl does: kubeconfig if
+// set, else $KUBECONFIG or ~/.kube/config, else the in-cluster service
+// account. kubeCtx overrides the current context.
+func newLiveClients(kubeconfig, kubeCtx string) (*liveClients, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeCtx}
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	typed, err := k8sclient.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &liveClients{typed: typed, dynamic: dyn}, nil
+}
+
+// optionalGVRs are the client-go counterpart of optionalResourceTypes.
+var optionalGVRs = []schema.GroupVersionResource{
+	{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"},
+	{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"},
+}
+
+// fetchLiveClientGo is the client-go counterpart of the kubectl scan in
+// FetchLive. Listed objects are fed through parseManifests so node IDs and
+// edges match manifest and kubectl scans exactly. Workloads additionally get
+// the IPs of their running pods as "pod_ips" metadata.
+func fetchLiveClientGo(ctx context.Context, clients *liveClients, namespaces, customResources []string) (*parser.ParseResult, error) {
+	cs := clients.typed
+	if len(namespaces) == 0 {
+		list, err := cs.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing namespaces: %w", err)
+		}
+		for _, ns := range list.Items {
+			if !systemNamespaces[ns.Name] {
+				namespaces = append(namespaces, ns.Name)
+			}
+		}
+	}
+
+	result := &parser.ParseResult{}
+	now := time.Now()
+
+	for _, ns := range namespaces {
+		items, warnings := listNamespacedObjects(ctx, cs, ns)
+		result.Warnings = append(result.Warnings, warnings...)
+		for _, gvr := range optionalGVRs {
+			list, err := clients.dynamic.Resource(gvr).Namespace(ns).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue // CRD not installed, skip silently
+			}
+			for _, item := range list.Items {
+				items = append(items, item.Object)
+			}
+		}
+
+		r := parseLiveObjects(items, "live:"+ns, now, result)
+		if r == nil {
+			continue
+		}
+		if ips, err := workloadPodIPs(ctx, cs, ns); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("namespace %s: listing pods: %v", ns, err))
+		} else {
+			for i, n := range r.Nodes {
+				if v, ok := ips[n.ID]; ok {
+					r.Nodes[i].Metadata["pod_ips"] = v
+				}
+			}
+		}
+		appendResult(result, r)
+	}
+
+	var clusterItems []any
+	if list, err := cs.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{}); err == nil {
+		clusterItems = append(clusterItems, stampKind(list.Items, rbacv1.SchemeGroupVersion.WithKind("ClusterRole"))...)
+	}
+	if list, err := cs.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{}); err == nil {
+		clusterItems = append(clusterItems, stampKind(list.Items, rbacv1.SchemeGroupVersion.WithKind("ClusterRoleBinding"))...)
+	}
+	if r := parseLiveObjects(clusterItems, "live:cluster", now, result); r != nil {
+		appendResult(result, r)
+	}
+
+	if len(customResources) > 0 {
+		known := make(map[string]models.Node, len(result.Nodes))
+		for _, n := range result.Nodes {
+			known[n.ID] = n
+		}
+		r := fetchCustomResources(ctx, dynamicGetter(clients.dynamic), namespaces, customResources, known, now)
+		appendResult(result, r)
+	}
+
+	return result, nil
+}
+
+// FetchTLSSecrets reads only the kubernetes.io/tls Secrets of a live
+// cluster, yielding the same secret and derived certificate nodes as a full
+// live scan, with expiry taken from each secret's tls.crt. An empty
+// namespaces list means all namespaces. It needs client-go access; there is
+// no kubectl fallback.
+func FetchTLSSecrets(ctx context.Context, kubeconfig, kubeCtx string, namespaces []string) (*parser.ParseResult, error) {
+	ctx, cancel := parser.WithDefaultCommandTimeout(ctx)
+	defer cancel()
+
+	clients, err := newLiveClientsFn(kubeconfig, kubeCtx)
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	opts := metav1.ListOptions{FieldSelector: "type=" + string(corev1.SecretTypeTLS)}
+	byNamespace := make(map[string][]corev1.Secret)
+	for _, ns := range namespaces {
+		list, err := clients.typed.CoreV1().Secrets(ns).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing TLS secrets: %w", err)
+		}
+		for _, sec := range list.Items {
+			if sec.Type == corev1.SecretTypeTLS {
+				byNamespace[sec.Namespace] = append(byNamespace[sec.Namespace], sec)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+
+	result := &parser.ParseResult{}
+	now := time.Now()
+	for _, ns := range names {
+		items := stampKind(byNamespace[ns], corev1.SchemeGroupVersion.WithKind("Secret"))
+		if r := parseLiveObjects(items, "live:"+ns, now, result); r != nil {
+			appendResult(result, r)
+		}
+	}
+	return result, nil
+}
+
+// listNamespacedObjects lists the namespaced kinds scanned by FetchLive.
+// Kinds that fail to list (e.g. secrets forbidden by RBAC) are reported as
+// warnings and skipped.
+func listNamespacedObjects(ctx context.Context, cs k8sclient.Interface, ns string) ([]any, []string) {
+	apps, batch, core := appsv1.SchemeGroupVersion, batchv1.SchemeGroupVersion, corev1.SchemeGroupVersion
+	net, rbac, hpa := networkingv1.SchemeGroupVersion, rbacv1.SchemeGroupVersion, autoscalingv2.SchemeGroupVersion
+	opts := metav1.ListOptions{}
+
+	listers := []struct {
+		resource string
+		list     func() ([]any, error)
+	}{
+		{"deployments", func() ([]any, error) {
+			l, err := cs.AppsV1().Deployments(ns).List(ctx, opts)
+			return stampList(l, err, func(l *appsv1.DeploymentList) []any { return stampKind(l.Items, apps.WithKind("Deployment")) })
+		}},
+		{"statefulsets", func() ([]any, error) {
+			l, err := cs.AppsV1().StatefulSets(ns).List(ctx, opts)
+			return stampList(l, err, func(l *appsv1.StatefulSetList) []any { return stampKind(l.Items, apps.WithKind("StatefulSet")) })
+		}},
+		{"daemonsets", func() ([]any, error) {
+			l, err := cs.AppsV1().DaemonSets(ns).List(ctx, opts)
+			return stampList(l, err, func(l *appsv1.DaemonSetList) []any { return stampKind(l.Items, apps.WithKind("DaemonSet")) })
+		}},
+		{"replicasets", func() ([]any, error) {
+			l, err := cs.AppsV1().ReplicaSets(ns).List(ctx, opts)
+			return stampList(l, err, func(l *appsv1.ReplicaSetList) []any { return stampKind(l.Items, apps.WithKind("ReplicaSet")) })
+		}},
+		{"pods", func() ([]any, error) {
+			l, err := cs.CoreV1().Pods(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.PodList) []any { return stampKind(l.Items, core.WithKind("Pod")) })
+		}},
+		{"services", func() ([]any, error) {
+			l, err := cs.CoreV1().Services(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.ServiceList) []any { return stampKind(l.Items, core.WithKind("Service")) })
+		}},
+		{"ingresses", func() ([]any, error) {
+			l, err := cs.NetworkingV1().Ingresses(ns).List(ctx, opts)
+			return stampList(l, err, func(l *networkingv1.IngressList) []any { return stampKind(l.Items, net.WithKind("Ingress")) })
+		}},
+		{"configmaps", func() ([]any, error) {
+			l, err := cs.CoreV1().ConfigMaps(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.ConfigMapList) []any { return stampKind(l.Items, core.WithKind("ConfigMap")) })
+		}},
+		{"secrets", func() ([]any, error) {
+			l, err := cs.CoreV1().Secrets(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.SecretList) []any { return stampKind(l.Items, core.WithKind("Secret")) })
+		}},
+		{"serviceaccounts", func() ([]any, error) {
+			l, err := cs.CoreV1().ServiceAccounts(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.ServiceAccountList) []any { return stampKind(l.Items, core.WithKind("ServiceAccount")) })
+		}},
+		{"roles", func() ([]any, error) {
+			l, err := cs.RbacV1().Roles(ns).List(ctx, opts)
+			return stampList(l, err, func(l *rbacv1.RoleList) []any { return stampKind(l.Items, rbac.WithKind("Role")) })
+		}},
+		{"rolebindings", func() ([]any, error) {
+			l, err := cs.RbacV1().RoleBindings(ns).List(ctx, opts)
+			return stampList(l, err, func(l *rbacv1.RoleBindingList) []any { return stampKind(l.Items, rbac.WithKind("RoleBinding")) })
+		}},
+		{"networkpolicies", func() ([]any, error) {
+			l, err := cs.NetworkingV1().NetworkPolicies(ns).List(ctx, opts)
+			return stampList(l, err, func(l *networkingv1.NetworkPolicyList) []any {
+				return stampKind(l.Items, net.WithKind("NetworkPolicy"))
+			})
+		}},
+		{"jobs", func() ([]any, error) {
+			l, err := cs.BatchV1().Jobs(ns).List(ctx, opts)
+			return stampList(l, err, func(l *batchv1.JobList) []any { return stampKind(l.Items, batch.WithKind("Job")) })
+		}},
+		{"cronjobs", func() ([]any, error) {
+			l, err := cs.BatchV1().CronJobs(ns).List(ctx, opts)
+			return stampList(l, err, func(l *batchv1.CronJobList) []any { return stampKind(l.Items, batch.WithKind("CronJob")) })
+		}},
+		{"horizontalpodautoscalers", func() ([]any, error) {
+			l, err := cs.AutoscalingV2().HorizontalPodAutoscalers(ns).List(ctx, opts)
+			return stampList(l, err, func(l *autoscalingv2.HorizontalPodAutoscalerList) []any {
+				return stampKind(l.Items, hpa.WithKind("HorizontalPodAutoscaler"))
+			})
+		}},
+		{"persistentvolumeclaims", func() ([]any, error) {
+			l, err := cs.CoreV1().PersistentVolumeClaims(ns).List(ctx, opts)
+			return stampList(l, err, func(l *corev1.PersistentVolumeClaimList) []any {
+				return stampKind(l.Items, core.WithKind("PersistentVolumeClaim"))
+			})
+		}},
+	}
+
+	var items []any
+	var warnings []string
+	for _, l := range listers {
+		objs, err := l.list()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("namespace %s: listing %s: %v", ns, l.resource, err))
+			continue
+		}
+		items = append(items, objs...)
+	}
+	return items, warnings
+}
+
+// stampList applies items to a successful List result.
+func stampList[L any](list L, err error, items func(L) []any) ([]any, error) {
+	if err != nil {
+		return nil, err
+	}
+	return items(list), nil
+}
+
+// stampKind sets the kind and apiVersion on listed objects, which the API
+// server omits for list items, so parseManifests can dispatch on them.
+func stampKind[T any, PT interface {
+	*T
+	runtime.Object
+}](items []T, gvk schema.GroupVersionKind) []any {
+	out := make([]any, 0, len(items))
+	for i := range items {
+		obj := PT(&items[i])
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+		out = append(out, obj)
+	}
+	return out
+}
+
+// parseLiveObjects parses API objects as a List document. Marshal and parse
+// errors are recorded as warnings on into; nil is returned when nothing was
+// parsed.
+func parseLiveObjects(items []any, sourceFile string, now time.Time, into *parser.ParseResult) *parser.ParseResult {
+	if len(items) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		into.Warnings = append(into.Warnings, fmt.Sprintf("encoding %s: %v", sourceFile, err))
+		return nil
+	}
+	r, err := parseManifests(data, sourceFile, now)
+	if err != nil {
+		into.Warnings = append(into.Warnings, fmt.Sprintf("parsing %s: %v", sourceFile, err))
+		return nil
+	}
+	return r
+}
+
+func appendResult(result, r *parser.ParseResult) {
+	result.Nodes = append(result.Nodes, r.Nodes...)
+	result.Edges = append(result.Edges, r.Edges...)
+	result.Warnings = append(result.Warnings, r.Warnings...)
+}
+
+// workloadPodIPs maps workload node IDs to the comma-joined IPs of their
+// running pods. Pods are attributed through their owner references, going
+// through the ReplicaSet for Deployments.
+func workloadPodIPs(ctx context.Context, cs k8sclient.Interface, ns string) (map[string]string, error) {
+	pods, err := cs.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	replicaSetOwner := make(map[string]string) // ReplicaSet name → Deployment name
+	if rsList, err := cs.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, rs := range rsList.Items {
+			for _, ref := range rs.OwnerReferences {
+				if ref.Kind == "Deployment" {
+					replicaSetOwner[rs.Name] = ref.Name
+				}
+			}
+		}
+	}
+
+	ips := make(map[string][]string)
+	for _, pod := range pods.Items {
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, ref := range pod.OwnerReferences {
+			owner := ref.Name
+			switch ref.Kind {
+			case "ReplicaSet":
+				if d, ok := replicaSetOwner[ref.Name]; ok {
+					owner = d
+				}
+			case "StatefulSet", "DaemonSet":
+			default:
+				continue
+			}
+			id := k8sNodeID("pod", ns, owner)
+			ips[id] = append(ips[id], pod.Status.PodIP)
+		}
+	}
+
+	joined := make(map[string]string, len(ips))
+	for id, list := range ips {
+		sort.Strings(list)
+		joined[id] = strings.Join(list, ",")
+	}
+	return joined, nil
+}
+
+// dynamicGetter serves fetchCustomResources from the dynamic client. CRD
+// instances are listed at the CRD's storage version, which is learned when
+// the CRDs themselves are listed.
+func dynamicGetter(dyn dynamic.Interface) resourceGetter {
+	crdGVR := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	versions := make(map[string]schema.GroupVersionResource) // CRD name → instances GVR
+
+	return func(ctx context.Context, namespace, resource string) ([]byte, error) {
+		if resource == customResourceDefinitions {
+			list, err := dyn.Resource(crdGVR).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, err
+			}
+			for _, crd := range list.Items {
+				group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+				plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+				if version := storageVersion(crd.Object); version != "" {
+					versions[crd.GetName()] = schema.GroupVersionResource{Group: group, Version: version, Resource: plural}
+				}
+			}
+			return list.MarshalJSON()
+		}
+
+		gvr, ok := versions[resource]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom resource %s", resource)
+		}
+		list, err := dyn.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return list.MarshalJSON()
+	}
+}
+
+// storageVersion returns the version a CRD stores its objects at.
+func storageVersion(crd map[string]any) string {
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, v := range versions {
+		m, _ := v.(map[string]any)
+		if storage, _ := m["storage"].(bool); storage {
+			name, _ := m["name"].(string)
+			return name
+		}
+	}
+	return ""
+}
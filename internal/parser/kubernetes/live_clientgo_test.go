@@ -0,0 +1,273 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// withoutClientGo forces FetchLive onto the kubectl path, so tests never
+// pick up a real kubeconfig.
+func withoutClientGo(t *testing.T) {
+	t.Helper()
+	original := newLiveClientsFn
+	newLiveClientsFn = func(string, string) (*liveClients, error) {
+		return nil, errors.New("no kubeconfig")
+	}
+	t.Cleanup(func() { newLiveClientsFn = original })
+}
+
+// withFakeClients serves FetchLive from fake clientsets.
+func withFakeClients(t *testing.T, typed []runtime.Object, dynamicObjs ...runtime.Object) {
+	t.Helper()
+	listKinds := map[schema.GroupVersionResource]string{
+		optionalGVRs[0]: "CertificateList",
+		optionalGVRs[1]: "GatewayList",
+		optionalGVRs[2]: "HTTPRouteList",
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+		{Group: "acid.zalan.do", Version: "v1", Resource: "postgresqls"}:                      "postgresqlList",
+	}
+	clients := &liveClients{
+		typed:   fake.NewClientset(typed...),
+		dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, dynamicObjs...),
+	}
+	original := newLiveClientsFn
+	newLiveClientsFn = func(string, string) (*liveClients, error) { return clients, nil }
+	t.Cleanup(func() { newLiveClientsFn = original })
+}
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func TestFetchLive_ClientGo(t *testing.T) {
+	labels := map[string]string{"app": "web"}
+	objs := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: int32Ptr(3),
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "nginx:1.27"}}},
+				},
+			},
+			Status: appsv1.DeploymentStatus{ReadyReplicas: 2, AvailableReplicas: 2},
+		},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-7d4b9", Namespace: "shop",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}},
+		}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-7d4b9-b", Namespace: "shop",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.8"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-7d4b9-a", Namespace: "shop",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.7"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "web-7d4b9-c", Namespace: "shop",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d4b9"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: labels, Ports: []corev1.ServicePort{{Port: 80}}},
+		},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "viewer"}},
+	}
+	cert := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]any{"name": "web-tls", "namespace": "shop"},
+		"spec":       map[string]any{"secretName": "web-tls", "dnsNames": []any{"shop.example.com"}},
+	}}
+	withFakeClients(t, objs, cert)
+
+	r, err := FetchLive(context.Background(), "", "", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range r.Nodes {
+		nodes[n.ID] = n
+	}
+	web, ok := nodes["k8s:pod:shop/web"]
+	if !ok {
+		t.Fatalf("missing deployment node, got %v", r.Nodes)
+	}
+	if web.Metadata["replicas"] != "3" || web.Metadata["ready_replicas"] != "2" || web.Metadata["available_replicas"] != "2" {
+		t.Errorf("replica metadata = %v", web.Metadata)
+	}
+	if web.Metadata["pod_ips"] != "10.0.0.7,10.0.0.8" {
+		t.Errorf("pod_ips = %q, want running pod IPs", web.Metadata["pod_ips"])
+	}
+	for _, id := range []string{"k8s:service:shop/web", "k8s:certificate:shop/web-tls", "k8s:clusterrole:viewer", "k8s:namespace:shop"} {
+		if _, ok := nodes[id]; !ok {
+			t.Errorf("missing node %s", id)
+		}
+	}
+	if _, ok := nodes["k8s:configmap:kube-system/coredns"]; ok {
+		t.Error("system namespaces should be skipped")
+	}
+
+	var selected bool
+	for _, e := range r.Edges {
+		if e.FromID == "k8s:pod:shop/web" && e.ToID == "k8s:service:shop/web" && e.Type == models.EdgeMemberOf {
+			selected = true
+		}
+	}
+	if !selected {
+		t.Error("missing deployment member_of service edge")
+	}
+}
+
+func TestFetchLive_ClientGoCustomResources(t *testing.T) {
+	objs := []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}},
+	}
+	crd := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "postgresqls.acid.zalan.do"},
+		"spec": map[string]any{
+			"group": "acid.zalan.do",
+			"scope": "Namespaced",
+			"names": map[string]any{"kind": "postgresql", "plural": "postgresqls"},
+			"versions": []any{
+				map[string]any{"name": "v1", "served": true, "storage": true},
+			},
+		},
+	}}
+	db := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "acid.zalan.do/v1",
+		"kind":       "postgresql",
+		"metadata": map[string]any{
+			"name":            "orders-db",
+			"namespace":       "default",
+			"ownerReferences": []any{map[string]any{"apiVersion": "apps/v1", "kind": "Deployment", "name": "api", "uid": "1"}},
+		},
+		"spec": map[string]any{"numberOfInstances": int64(2)},
+	}}
+	withFakeClients(t, objs, crd, db)
+
+	r, err := FetchLive(context.Background(), "", "", []string{"default"}, []string{"postgresqls.acid.zalan.do"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, n := range r.Nodes {
+		if n.ID == "k8s:postgresql:default/orders-db" {
+			found = true
+			if n.Metadata["spec.numberOfInstances"] != "2" {
+				t.Errorf("spec not flattened into metadata: %v", n.Metadata)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("missing postgresql node, got %v", r.Nodes)
+	}
+	var managed bool
+	for _, e := range r.Edges {
+		if e.FromID == "k8s:postgresql:default/orders-db" && e.ToID == "k8s:pod:default/api" && e.Type == models.EdgeManagedBy {
+			managed = true
+		}
+	}
+	if !managed {
+		t.Error("missing managed_by edge to owning deployment")
+	}
+}
+
+func TestFetchLive_FallsBackToKubectl(t *testing.T) {
+	withoutClientGo(t)
+	originalLookPath := kubectlLookPath
+	originalGet := kubectlGetFn
+	kubectlLookPath = func(string) (string, error) {
+		return "/usr/bin/kubectl", nil
+	}
+	var called bool
+	kubectlGetFn = func(context.Context, string, string, string, string) ([]byte, error) {
+		called = true
+		return nil, nil
+	}
+	t.Cleanup(func() {
+		kubectlLookPath = originalLookPath
+		kubectlGetFn = originalGet
+	})
+
+	if _, err := FetchLive(context.Background(), "", "", []string{"default"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !called {
+		t.Error("expected kubectl to be used when no client config can be built")
+	}
+}
+
+func TestFetchTLSSecrets(t *testing.T) {
+	notAfter := time.Now().Add(20 * 24 * time.Hour).Truncate(time.Second).UTC()
+	crt, err := base64.StdEncoding.DecodeString(mustSelfSignedTLSCertBase64(t, notAfter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withFakeClients(t, []runtime.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "shop"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": crt, "tls.key": []byte("key")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-password", Namespace: "shop"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+	})
+
+	result, err := FetchTLSSecrets(context.Background(), "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	if _, ok := nodes["k8s:secret:shop/db-password"]; ok {
+		t.Error("opaque secret should not be read")
+	}
+	if _, ok := nodes["k8s:secret:shop/web-tls"]; !ok {
+		t.Error("missing TLS secret node")
+	}
+	cert, ok := nodes["k8s:certificate:shop/web-tls"]
+	if !ok {
+		t.Fatalf("missing derived certificate node, got %v", nodes)
+	}
+	if cert.ExpiresAt == nil || !cert.ExpiresAt.Equal(notAfter) {
+		t.Errorf("ExpiresAt = %v, want %v", cert.ExpiresAt, notAfter)
+	}
+}
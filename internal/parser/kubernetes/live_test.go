@@ -8,6 +8,7 @@ import (
 )
 
 func TestFetchLive_KubectlNotFound(t *testing.T) {
+	withoutClientGo(t)
 	originalLookPath := kubectlLookPath
 	kubectlLookPath = func(string) (string, error) {
 		return "", errors.New("not found")
@@ -16,13 +17,14 @@ func TestFetchLive_KubectlNotFound(t *testing.T) {
 		kubectlLookPath = originalLookPath
 	})
 
-	_, err := FetchLive(context.Background(), "", "", []string{"default"})
+	_, err := FetchLive(context.Background(), "", "", []string{"default"}, nil)
 	if err == nil {
 		t.Fatal("expected error when kubectl is not found")
 	}
 }
 
 func TestFetchLive_ListNamespacesError(t *testing.T) {
+	withoutClientGo(t)
 	originalLookPath := kubectlLookPath
 	originalListNamespaces := listNamespacesFn
 	kubectlLookPath = func(string) (string, error) {
@@ -36,13 +38,14 @@ func TestFetchLive_ListNamespacesError(t *testing.T) {
 		listNamespacesFn = originalListNamespaces
 	})
 
-	_, err := FetchLive(context.Background(), "", "", nil)
+	_, err := FetchLive(context.Background(), "", "", nil, nil)
 	if err == nil {
 		t.Fatal("expected error when listing namespaces fails")
 	}
 }
 
 func TestFetchLive_CollectsWarningsAndContinues(t *testing.T) {
+	withoutClientGo(t)
 	originalLookPath := kubectlLookPath
 	originalGet := kubectlGetFn
 	kubectlLookPath = func(string) (string, error) {
@@ -70,7 +73,7 @@ items:
 		kubectlGetFn = originalGet
 	})
 
-	r, err := FetchLive(context.Background(), "", "", []string{"broken", "default"})
+	r, err := FetchLive(context.Background(), "", "", []string{"broken", "default"}, nil)
 	if err != nil {
 		t.Fatalf("FetchLive returned unexpected error: %v", err)
 	}
@@ -83,6 +86,7 @@ items:
 }
 
 func TestFetchLive_AppliesDefaultTimeoutWhenMissingDeadline(t *testing.T) {
+	withoutClientGo(t)
 	originalLookPath := kubectlLookPath
 	originalListNamespaces := listNamespacesFn
 	kubectlLookPath = func(string) (string, error) {
@@ -99,7 +103,7 @@ func TestFetchLive_AppliesDefaultTimeoutWhenMissingDeadline(t *testing.T) {
 		listNamespacesFn = originalListNamespaces
 	})
 
-	_, _ = FetchLive(context.Background(), "", "", nil)
+	_, _ = FetchLive(context.Background(), "", "", nil, nil)
 }
 
 func TestBuildKubectlArgs(t *testing.T) {
@@ -184,8 +188,11 @@ items:
 	if !nodeIDs["k8s:pod:staging/api-server"] {
 		t.Error("missing k8s:pod:staging/api-server from List output")
 	}
-	if len(result.Nodes) != 2 {
-		t.Errorf("nodes = %d, want 2", len(result.Nodes))
+	if !nodeIDs["k8s:namespace:staging"] {
+		t.Error("missing auto-created k8s:namespace:staging")
+	}
+	if len(result.Nodes) != 5 {
+		t.Errorf("nodes = %d, want 5 (2 workloads + 2 images + namespace)", len(result.Nodes))
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/url"
 	"encoding/pem"
+	"slices"
 	"strings"
 	"time"
 
@@ -34,13 +35,29 @@ type k8sResource struct {
 
 type k8sStatus struct {
 	NotAfter string `yaml:"notAfter"`
+
+	// Workload rollout status
+	ReadyReplicas     *int `yaml:"readyReplicas"`
+	AvailableReplicas *int `yaml:"availableReplicas"`
+
+	// Pod
+	Phase string `yaml:"phase"`
+	PodIP string `yaml:"podIP"`
 }
 
 type k8sMeta struct {
-	Name        string            `yaml:"name"`
-	Namespace   string            `yaml:"namespace"`
-	Labels      map[string]string `yaml:"labels"`
-	Annotations map[string]string `yaml:"annotations"`
+	Name            string            `yaml:"name"`
+	Namespace       string            `yaml:"namespace"`
+	Labels          map[string]string `yaml:"labels"`
+	Annotations     map[string]string `yaml:"annotations"`
+	OwnerReferences []k8sOwnerRef     `yaml:"ownerReferences"`
+}
+
+// k8sOwnerRef names the controller or other object that owns a resource.
+type k8sOwnerRef struct {
+	Kind       string `yaml:"kind"`
+	Name       string `yaml:"name"`
+	Controller bool   `yaml:"controller"`
 }
 
 type k8sSpec struct {
@@ -54,10 +71,22 @@ type k8sSpec struct {
 	TLS          []k8sIngressTLS  `yaml:"tls"`
 	IngressClass string           `yaml:"ingressClassName"`
 
+	// Gateway API: Gateway listeners, HTTPRoute parents and hostnames.
+	// HTTPRoute rules share Rules with Ingress.
+	GatewayClass string         `yaml:"gatewayClassName"`
+	Listeners    []k8sListener  `yaml:"listeners"`
+	ParentRefs   []k8sObjectRef `yaml:"parentRefs"`
+	Hostnames    []string       `yaml:"hostnames"`
+
 	// Workload (Deployment/StatefulSet/DaemonSet)
 	Replicas *int       `yaml:"replicas"`
 	Template k8sPodSpec `yaml:"template"`
 
+	// Pod
+	Containers     []k8sContainer `yaml:"containers"`
+	InitContainers []k8sContainer `yaml:"initContainers"`
+	NodeName       string         `yaml:"nodeName"`
+
 	// cert-manager Certificate
 	SecretName string   `yaml:"secretName"`
 	DNSNames   []string `yaml:"dnsNames"`
@@ -74,12 +103,32 @@ type k8sSpec struct {
 	// NetworkPolicy
 	PodSelector *k8sPodSelector  `yaml:"podSelector"`
 	PolicyTypes []string         `yaml:"policyTypes"`
+
+	// PersistentVolumeClaim / PersistentVolume. Resources and Capacity are
+	// untyped since other kinds (e.g. custom resources) reuse the field names
+	// with different shapes.
+	VolumeName       string         `yaml:"volumeName"`
+	StorageClassName string         `yaml:"storageClassName"`
+	AccessModes      []string       `yaml:"accessModes"`
+	Resources        any            `yaml:"resources"`
+	Capacity         any            `yaml:"capacity"`
+	ClaimRef         *k8sSubject    `yaml:"claimRef"`
 }
 
-// k8sSelector handles both Service selector (flat map) and Deployment selector ({matchLabels}).
+// k8sSelector handles both Service selector (flat map) and Deployment selector
+// ({matchLabels, matchExpressions}).
 type k8sSelector struct {
-	MatchLabels map[string]string `yaml:"matchLabels"`
-	Labels      map[string]string // flat selector for Services
+	MatchLabels      map[string]string        `yaml:"matchLabels"`
+	MatchExpressions []k8sSelectorRequirement `yaml:"matchExpressions"`
+	Labels           map[string]string        // flat selector for Services
+}
+
+// k8sSelectorRequirement is a set-based selector term: key In/NotIn values,
+// or key Exists/DoesNotExist.
+type k8sSelectorRequirement struct {
+	Key      string   `yaml:"key"`
+	Operator string   `yaml:"operator"`
+	Values   []string `yaml:"values"`
 }
 
 func (s *k8sSelector) UnmarshalYAML(value *yaml.Node) error {
@@ -91,11 +140,13 @@ func (s *k8sSelector) UnmarshalYAML(value *yaml.Node) error {
 	}
 	// Try as structured selector (Deployment style: selector: {matchLabels: {app: foo}})
 	type structured struct {
-		MatchLabels map[string]string `yaml:"matchLabels"`
+		MatchLabels      map[string]string        `yaml:"matchLabels"`
+		MatchExpressions []k8sSelectorRequirement `yaml:"matchExpressions"`
 	}
 	var st structured
 	if err := value.Decode(&st); err == nil {
 		s.MatchLabels = st.MatchLabels
+		s.MatchExpressions = st.MatchExpressions
 		return nil
 	}
 	return nil // silently ignore unparseable selectors
@@ -109,6 +160,16 @@ func (s k8sSelector) GetLabels() map[string]string {
 	return s.MatchLabels
 }
 
+// IsEmpty reports whether the selector has no terms.
+func (s k8sSelector) IsEmpty() bool {
+	return len(s.GetLabels()) == 0 && len(s.MatchExpressions) == 0
+}
+
+// Matches reports whether target satisfies both the label and expression terms.
+func (s k8sSelector) Matches(target map[string]string) bool {
+	return labelsMatch(s.GetLabels(), target) && expressionsMatch(s.MatchExpressions, target)
+}
+
 type k8sServicePort struct {
 	Name       string `yaml:"name"`
 	Port       int    `yaml:"port"`
@@ -119,6 +180,42 @@ type k8sServicePort struct {
 type k8sIngressRule struct {
 	Host string        `yaml:"host"`
 	HTTP *k8sHTTPRule  `yaml:"http"`
+
+	// HTTPRoute rule
+	BackendRefs []k8sObjectRef `yaml:"backendRefs"`
+}
+
+// k8sListener is a Gateway listener.
+type k8sListener struct {
+	Name     string          `yaml:"name"`
+	Hostname string          `yaml:"hostname"`
+	Port     int             `yaml:"port"`
+	Protocol string          `yaml:"protocol"`
+	TLS      *k8sListenerTLS `yaml:"tls"`
+}
+
+type k8sListenerTLS struct {
+	Mode            string         `yaml:"mode"`
+	CertificateRefs []k8sObjectRef `yaml:"certificateRefs"`
+}
+
+// k8sObjectRef is a Gateway API object reference (parentRefs, backendRefs,
+// certificateRefs). An empty Kind means the field's default kind.
+type k8sObjectRef struct {
+	Group       string `yaml:"group"`
+	Kind        string `yaml:"kind"`
+	Name        string `yaml:"name"`
+	Namespace   string `yaml:"namespace"`
+	SectionName string `yaml:"sectionName"`
+	Port        int    `yaml:"port"`
+}
+
+// refNamespace returns the reference's namespace, defaulting to ns.
+func (r k8sObjectRef) refNamespace(ns string) string {
+	if r.Namespace != "" {
+		return r.Namespace
+	}
+	return ns
 }
 
 type k8sHTTPRule struct {
@@ -208,9 +305,15 @@ type k8sRef struct {
 }
 
 type k8sVolume struct {
-	Name      string          `yaml:"name"`
-	Secret    *k8sVolSecret   `yaml:"secret"`
-	ConfigMap *k8sVolConfigMap `yaml:"configMap"`
+	Name                  string           `yaml:"name"`
+	Secret                *k8sVolSecret    `yaml:"secret"`
+	ConfigMap             *k8sVolConfigMap `yaml:"configMap"`
+	PersistentVolumeClaim *k8sVolPVC       `yaml:"persistentVolumeClaim"`
+}
+
+type k8sVolPVC struct {
+	ClaimName string `yaml:"claimName"`
+	ReadOnly  bool   `yaml:"readOnly"`
 }
 
 type k8sVolSecret struct {
@@ -235,7 +338,8 @@ type k8sSubject struct {
 
 // NetworkPolicy pod selector
 type k8sPodSelector struct {
-	MatchLabels map[string]string `yaml:"matchLabels"`
+	MatchLabels      map[string]string        `yaml:"matchLabels"`
+	MatchExpressions []k8sSelectorRequirement `yaml:"matchExpressions"`
 }
 
 // HPA scale target
@@ -292,6 +396,7 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 		}
 		resources = append(resources, res)
 	}
+	resources = slices.DeleteFunc(resources, isRetiredReplicaSet)
 
 	// First pass: create all nodes so we can resolve references.
 	nodeMap := make(map[string]models.Node)    // nodeID → node
@@ -315,6 +420,12 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 			if res.Spec.Replicas != nil {
 				meta["replicas"] = fmt.Sprintf("%d", *res.Spec.Replicas)
 			}
+			if res.Status.ReadyReplicas != nil {
+				meta["ready_replicas"] = fmt.Sprintf("%d", *res.Status.ReadyReplicas)
+			}
+			if res.Status.AvailableReplicas != nil {
+				meta["available_replicas"] = fmt.Sprintf("%d", *res.Status.AvailableReplicas)
+			}
 			// Collect container images
 			var images []string
 			for _, c := range res.Spec.Template.Spec.Containers {
@@ -380,6 +491,14 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 			nodeMap[nodeID] = node
 			result.Nodes = append(result.Nodes, node)
 
+			usedImages := make(map[string]bool)
+			for _, c := range allContainers {
+				if c.Image != "" && !usedImages[c.Image] {
+					usedImages[c.Image] = true
+					addImageEdge(nodeMap, result, nodeID, c.Image, sourceFile, now)
+				}
+			}
+
 			// Store pod template labels for service selector matching
 			if res.Spec.Template.Metadata.Labels != nil {
 				workloadLabels[nodeID] = res.Spec.Template.Metadata.Labels
@@ -452,6 +571,53 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 			nodeMap[nodeID] = node
 			result.Nodes = append(result.Nodes, node)
 
+		case "Gateway":
+			nodeID := k8sNodeID("gateway", ns, res.Metadata.Name)
+			meta := map[string]string{"kind": res.Kind, "namespace": ns}
+			if res.Spec.GatewayClass != "" {
+				meta["gateway_class"] = res.Spec.GatewayClass
+			}
+			var hosts, listeners []string
+			for _, l := range res.Spec.Listeners {
+				listeners = append(listeners, fmt.Sprintf("%s:%s:%d", l.Name, l.Protocol, l.Port))
+				if l.Hostname != "" {
+					hosts = append(hosts, l.Hostname)
+				}
+			}
+			if len(listeners) > 0 {
+				meta["listeners"] = strings.Join(listeners, ",")
+			}
+			if len(hosts) > 0 {
+				meta["hosts"] = strings.Join(hosts, ",")
+			}
+			for k, v := range res.Metadata.Labels {
+				meta["label:"+k] = v
+			}
+			node := models.Node{
+				ID: nodeID, Name: res.Metadata.Name, Type: models.AssetIngress,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: meta, LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nodeID] = node
+			result.Nodes = append(result.Nodes, node)
+
+		case "HTTPRoute":
+			nodeID := k8sNodeID("httproute", ns, res.Metadata.Name)
+			meta := map[string]string{"kind": res.Kind, "namespace": ns}
+			if len(res.Spec.Hostnames) > 0 {
+				meta["hosts"] = strings.Join(res.Spec.Hostnames, ",")
+			}
+			for k, v := range res.Metadata.Labels {
+				meta["label:"+k] = v
+			}
+			node := models.Node{
+				ID: nodeID, Name: res.Metadata.Name, Type: models.AssetIngress,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: meta, LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nodeID] = node
+			result.Nodes = append(result.Nodes, node)
+
 		case "Secret":
 			nodeID := k8sNodeID("secret", ns, res.Metadata.Name)
 			meta := map[string]string{
@@ -678,6 +844,39 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 			nodeMap[nodeID] = node
 			result.Nodes = append(result.Nodes, node)
 
+		case "Pod":
+			// Workloads already use the "pod" type, so bare Pods get their
+			// own to keep a Pod from colliding with a same-named Deployment.
+			nodeID := k8sNodeID("barepod", ns, res.Metadata.Name)
+			meta := map[string]string{"kind": "Pod", "namespace": ns}
+			if res.Spec.NodeName != "" {
+				meta["node_name"] = res.Spec.NodeName
+			}
+			if res.Status.Phase != "" {
+				meta["phase"] = res.Status.Phase
+			}
+			if res.Status.PodIP != "" {
+				meta["pod_ip"] = res.Status.PodIP
+			}
+			for k, v := range res.Metadata.Labels {
+				meta["label:"+k] = v
+			}
+			node := models.Node{
+				ID: nodeID, Name: res.Metadata.Name, Type: models.AssetPod,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: meta, LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nodeID] = node
+			result.Nodes = append(result.Nodes, node)
+
+			usedImages := make(map[string]bool)
+			for _, c := range append(res.Spec.Containers, res.Spec.InitContainers...) {
+				if c.Image != "" && !usedImages[c.Image] {
+					usedImages[c.Image] = true
+					addImageEdge(nodeMap, result, nodeID, c.Image, sourceFile, now)
+				}
+			}
+
 		case "HorizontalPodAutoscaler":
 			nodeID := k8sNodeID("hpa", ns, res.Metadata.Name)
 			meta := map[string]string{"namespace": ns}
@@ -695,6 +894,46 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 			nodeMap[nodeID] = node
 			result.Nodes = append(result.Nodes, node)
 
+		case "PersistentVolumeClaim":
+			nodeID := k8sNodeID("pvc", ns, res.Metadata.Name)
+			meta := volumeMetadata(res.Spec)
+			meta["namespace"] = ns
+			meta["kind"] = res.Kind
+			if storage := storageQuantity(res.Spec.Resources, "requests"); storage != "" {
+				meta["storage"] = storage
+			}
+			if res.Spec.VolumeName != "" {
+				meta["volume_name"] = res.Spec.VolumeName
+			}
+			for k, v := range res.Metadata.Labels {
+				meta["label:"+k] = v
+			}
+			node := models.Node{
+				ID: nodeID, Name: res.Metadata.Name, Type: models.AssetDisk,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: meta, LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nodeID] = node
+			result.Nodes = append(result.Nodes, node)
+
+		case "PersistentVolume":
+			nodeID := persistentVolumeID(res.Metadata.Name)
+			meta := volumeMetadata(res.Spec)
+			meta["kind"] = res.Kind
+			if storage := storageQuantity(res.Spec.Capacity, ""); storage != "" {
+				meta["storage"] = storage
+			}
+			for k, v := range res.Metadata.Labels {
+				meta["label:"+k] = v
+			}
+			node := models.Node{
+				ID: nodeID, Name: res.Metadata.Name, Type: models.AssetDisk,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: meta, LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nodeID] = node
+			result.Nodes = append(result.Nodes, node)
+
 		default:
 			// Only warn for non-well-known Kubernetes kinds to reduce noise.
 			wellKnown := map[string]bool{
@@ -713,20 +952,21 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 	}
 
 	// Second pass: create edges.
+	boundVolumes := make(map[string]bool)
 	for _, res := range resources {
 		ns := res.Metadata.Namespace
 		if ns == "" {
 			ns = "default"
 		}
+		addOwnerEdges(nodeMap, result, res, ns)
 
 		switch res.Kind {
 		case "Service":
 			svcID := k8sNodeID("service", ns, res.Metadata.Name)
 			// Match service selector to workload pod template labels
-			svcSelector := res.Spec.Selector.GetLabels()
-			if len(svcSelector) > 0 {
+			if !res.Spec.Selector.IsEmpty() {
 				for wlID, labels := range workloadLabels {
-					if labelsMatch(svcSelector, labels) {
+					if res.Spec.Selector.Matches(labels) {
 						edgeID := fmt.Sprintf("%s->member_of->%s", wlID, svcID)
 						result.Edges = append(result.Edges, models.Edge{
 							ID:       edgeID,
@@ -774,79 +1014,78 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 				if tls.SecretName == "" {
 					continue
 				}
-				secretID := k8sNodeID("secret", ns, tls.SecretName)
-				edgeID := fmt.Sprintf("%s->terminates_tls->%s", ingressID, secretID)
-				result.Edges = append(result.Edges, models.Edge{
-					ID:       edgeID,
-					FromID:   ingressID,
-					ToID:     secretID,
-					Type:     models.EdgeTerminatesTLS,
-					Metadata: map[string]string{"hosts": strings.Join(tls.Hosts, ",")},
-				})
-				// Auto-create the TLS secret node if not already present
-				if _, exists := nodeMap[secretID]; !exists {
-					node := models.Node{
-						ID:         secretID,
-						Name:       tls.SecretName,
-						Type:       models.AssetSecret,
-						Source:     "kubernetes",
-						SourceFile: sourceFile,
-						Provider:   "kubernetes",
-						Metadata:   map[string]string{"type": "kubernetes.io/tls"},
-						LastSeen:   now,
-						FirstSeen:  now,
-					}
-					nodeMap[secretID] = node
-					result.Nodes = append(result.Nodes, node)
-				}
+				addTLSTermination(nodeMap, result, ingressID, ns, tls.SecretName, tls.Hosts, sourceFile, now)
+			}
 
-				// Also expose the TLS cert as a first-class certificate node.
-				certID := k8sNodeID("certificate", ns, tls.SecretName)
-				if _, exists := nodeMap[certID]; !exists {
-					certMeta := map[string]string{
-						"namespace":           ns,
-						"derived_from_secret": "true",
-						"secret_name":         tls.SecretName,
-					}
-					if len(tls.Hosts) > 0 {
-						certMeta["dns_names"] = strings.Join(tls.Hosts, ",")
-					}
-					certNode := models.Node{
-						ID:         certID,
-						Name:       tls.SecretName,
-						Type:       models.AssetCertificate,
-						Source:     "kubernetes",
-						SourceFile: sourceFile,
-						Provider:   "kubernetes",
-						Metadata:   certMeta,
-						LastSeen:   now,
-						FirstSeen:  now,
+		case "Gateway":
+			gwID := k8sNodeID("gateway", ns, res.Metadata.Name)
+			// Gateway → TLS Secret via listener certificateRefs
+			for _, l := range res.Spec.Listeners {
+				if l.TLS == nil {
+					continue
+				}
+				var hosts []string
+				if l.Hostname != "" {
+					hosts = []string{l.Hostname}
+				}
+				for _, ref := range l.TLS.CertificateRefs {
+					if ref.Name == "" || (ref.Kind != "" && ref.Kind != "Secret") {
+						continue
 					}
-					nodeMap[certID] = certNode
-					result.Nodes = append(result.Nodes, certNode)
+					addTLSTermination(nodeMap, result, gwID, ref.refNamespace(ns), ref.Name, hosts, sourceFile, now)
 				}
+			}
 
-				secretToCertEdge := fmt.Sprintf("%s->depends_on->%s", secretID, certID)
-				result.Edges = append(result.Edges, models.Edge{
-					ID:       secretToCertEdge,
-					FromID:   secretID,
-					ToID:     certID,
-					Type:     models.EdgeDependsOn,
-					Metadata: map[string]string{"via": "tls_secret"},
-				})
+		case "HTTPRoute":
+			routeID := k8sNodeID("httproute", ns, res.Metadata.Name)
+			hosts := strings.Join(res.Spec.Hostnames, ",")
 
-				ingressToCertEdge := fmt.Sprintf("%s->terminates_tls->%s", ingressID, certID)
+			// Gateway → HTTPRoute via parentRefs
+			for _, ref := range res.Spec.ParentRefs {
+				if ref.Name == "" || (ref.Kind != "" && ref.Kind != "Gateway") {
+					continue
+				}
+				gwID := k8sNodeID("gateway", ref.refNamespace(ns), ref.Name)
+				meta := map[string]string{"host": hosts}
+				if ref.SectionName != "" {
+					meta["listener"] = ref.SectionName
+				}
 				result.Edges = append(result.Edges, models.Edge{
-					ID:     ingressToCertEdge,
-					FromID: ingressID,
-					ToID:   certID,
-					Type:   models.EdgeTerminatesTLS,
-					Metadata: map[string]string{
-						"hosts": strings.Join(tls.Hosts, ","),
-					},
+					ID:       fmt.Sprintf("%s->routes_to->%s", gwID, routeID),
+					FromID:   gwID,
+					ToID:     routeID,
+					Type:     models.EdgeRoutesTo,
+					Metadata: meta,
 				})
 			}
 
+			// HTTPRoute → Service via backendRefs
+			seen := make(map[string]bool)
+			for _, rule := range res.Spec.Rules {
+				for _, ref := range rule.BackendRefs {
+					if ref.Name == "" || (ref.Kind != "" && ref.Kind != "Service") {
+						continue
+					}
+					svcID := k8sNodeID("service", ref.refNamespace(ns), ref.Name)
+					edgeID := fmt.Sprintf("%s->routes_to->%s", routeID, svcID)
+					if seen[edgeID] {
+						continue
+					}
+					seen[edgeID] = true
+					meta := map[string]string{"host": hosts}
+					if ref.Port > 0 {
+						meta["port"] = fmt.Sprintf("%d", ref.Port)
+					}
+					result.Edges = append(result.Edges, models.Edge{
+						ID:       edgeID,
+						FromID:   routeID,
+						ToID:     svcID,
+						Type:     models.EdgeRoutesTo,
+						Metadata: meta,
+					})
+				}
+			}
+
 		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
 			wlID := k8sNodeID("pod", ns, res.Metadata.Name)
 			seen := make(map[string]bool)
@@ -884,6 +1123,25 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 					}
 				ensureNode(nodeMap, result, cmID, vol.ConfigMap.Name, models.AssetConfigMap, ns, sourceFile, now)
 				}
+				if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName != "" {
+					pvcID := k8sNodeID("pvc", ns, vol.PersistentVolumeClaim.ClaimName)
+					eid := fmt.Sprintf("%s->mounts_volume->%s", wlID, pvcID)
+					if !seen[eid] {
+						seen[eid] = true
+						meta := map[string]string{"via": "volume", "volume": vol.Name}
+						if vol.PersistentVolumeClaim.ReadOnly {
+							meta["read_only"] = "true"
+						}
+						result.Edges = append(result.Edges, models.Edge{
+							ID:       eid,
+							FromID:   wlID,
+							ToID:     pvcID,
+							Type:     models.EdgeMountsVolume,
+							Metadata: meta,
+						})
+					}
+					ensureNode(nodeMap, result, pvcID, vol.PersistentVolumeClaim.ClaimName, models.AssetDisk, ns, sourceFile, now)
+				}
 			}
 
 			// envFrom and env valueFrom references
@@ -1060,9 +1318,9 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 		case "NetworkPolicy":
 			npID := k8sNodeID("networkpolicy", ns, res.Metadata.Name)
 			// NetworkPolicy → Pods via podSelector
-			if res.Spec.PodSelector != nil && len(res.Spec.PodSelector.MatchLabels) > 0 {
+			if sel := res.Spec.PodSelector; sel != nil && (len(sel.MatchLabels) > 0 || len(sel.MatchExpressions) > 0) {
 				for wlID, labels := range workloadLabels {
-					if labelsMatch(res.Spec.PodSelector.MatchLabels, labels) {
+					if labelsMatch(sel.MatchLabels, labels) && expressionsMatch(sel.MatchExpressions, labels) {
 						eid := fmt.Sprintf("%s->managed_by->%s", wlID, npID)
 						result.Edges = append(result.Edges, models.Edge{
 							ID: eid, FromID: wlID, ToID: npID,
@@ -1088,12 +1346,289 @@ func parseManifests(data []byte, sourceFile string, now time.Time) (*parser.Pars
 					})
 				}
 			}
+
+		case "PersistentVolumeClaim":
+			if res.Spec.VolumeName != "" {
+				pvcID := k8sNodeID("pvc", ns, res.Metadata.Name)
+				bindVolume(nodeMap, result, boundVolumes, pvcID, res.Spec.VolumeName, "volumeName", sourceFile, now)
+			}
+
+		case "PersistentVolume":
+			if ref := res.Spec.ClaimRef; ref != nil && ref.Name != "" {
+				claimNS := ref.Namespace
+				if claimNS == "" {
+					claimNS = "default"
+				}
+				pvcID := k8sNodeID("pvc", claimNS, ref.Name)
+				ensureNode(nodeMap, result, pvcID, ref.Name, models.AssetDisk, claimNS, sourceFile, now)
+				bindVolume(nodeMap, result, boundVolumes, pvcID, res.Metadata.Name, "claimRef", sourceFile, now)
+			}
 		}
 	}
 
+	addNamespaceEdges(nodeMap, result, sourceFile, now)
+
 	return result, nil
 }
 
+// addNamespaceEdges links every namespaced node to its Namespace with a
+// member_of edge, creating the Namespace node if the manifests didn't define
+// it. Cluster-scoped nodes (Namespaces, ClusterRoles, PersistentVolumes) are
+// recognised by their IDs, which lack the "<namespace>/" segment that
+// k8sNodeID adds.
+func addNamespaceEdges(nodeMap map[string]models.Node, result *parser.ParseResult, sourceFile string, now time.Time) {
+	members := make([]models.Node, len(result.Nodes))
+	copy(members, result.Nodes)
+	linkNamespaces(nodeMap, result, members, sourceFile, now)
+}
+
+// linkNamespaces adds the member_of edges of addNamespaceEdges for members
+// only, appending to result.
+func linkNamespaces(nodeMap map[string]models.Node, result *parser.ParseResult, members []models.Node, sourceFile string, now time.Time) {
+	for _, n := range members {
+		ns := n.Metadata["namespace"]
+		if ns == "" || !strings.Contains(n.ID, ":"+ns+"/") {
+			continue
+		}
+		nsID := fmt.Sprintf("k8s:namespace:%s", ns)
+		if _, exists := nodeMap[nsID]; !exists {
+			node := models.Node{
+				ID: nsID, Name: ns, Type: models.AssetNamespace,
+				Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+				Metadata: map[string]string{"auto_created": "true"},
+				LastSeen: now, FirstSeen: now,
+			}
+			nodeMap[nsID] = node
+			result.Nodes = append(result.Nodes, node)
+		}
+		result.Edges = append(result.Edges, models.Edge{
+			ID:     fmt.Sprintf("%s->member_of->%s", n.ID, nsID),
+			FromID: n.ID,
+			ToID:   nsID,
+			Type:   models.EdgeMemberOf,
+		})
+	}
+}
+
+// addTLSTermination adds terminates_tls edges from an Ingress or Gateway to
+// the TLS secret in ns and to the certificate derived from it, creating both
+// nodes if the manifests don't define them.
+func addTLSTermination(nodeMap map[string]models.Node, result *parser.ParseResult, fromID, ns, secretName string, hosts []string, sourceFile string, now time.Time) {
+	secretID := k8sNodeID("secret", ns, secretName)
+	edgeID := fmt.Sprintf("%s->terminates_tls->%s", fromID, secretID)
+	result.Edges = append(result.Edges, models.Edge{
+		ID:       edgeID,
+		FromID:   fromID,
+		ToID:     secretID,
+		Type:     models.EdgeTerminatesTLS,
+		Metadata: map[string]string{"hosts": strings.Join(hosts, ",")},
+	})
+	// Auto-create the TLS secret node if not already present
+	if _, exists := nodeMap[secretID]; !exists {
+		node := models.Node{
+			ID:         secretID,
+			Name:       secretName,
+			Type:       models.AssetSecret,
+			Source:     "kubernetes",
+			SourceFile: sourceFile,
+			Provider:   "kubernetes",
+			Metadata:   map[string]string{"type": "kubernetes.io/tls"},
+			LastSeen:   now,
+			FirstSeen:  now,
+		}
+		nodeMap[secretID] = node
+		result.Nodes = append(result.Nodes, node)
+	}
+
+	// Also expose the TLS cert as a first-class certificate node.
+	certID := k8sNodeID("certificate", ns, secretName)
+	if _, exists := nodeMap[certID]; !exists {
+		certMeta := map[string]string{
+			"namespace":           ns,
+			"derived_from_secret": "true",
+			"secret_name":         secretName,
+		}
+		if len(hosts) > 0 {
+			certMeta["dns_names"] = strings.Join(hosts, ",")
+		}
+		certNode := models.Node{
+			ID:         certID,
+			Name:       secretName,
+			Type:       models.AssetCertificate,
+			Source:     "kubernetes",
+			SourceFile: sourceFile,
+			Provider:   "kubernetes",
+			Metadata:   certMeta,
+			LastSeen:   now,
+			FirstSeen:  now,
+		}
+		nodeMap[certID] = certNode
+		result.Nodes = append(result.Nodes, certNode)
+	}
+
+	secretToCertEdge := fmt.Sprintf("%s->depends_on->%s", secretID, certID)
+	result.Edges = append(result.Edges, models.Edge{
+		ID:       secretToCertEdge,
+		FromID:   secretID,
+		ToID:     certID,
+		Type:     models.EdgeDependsOn,
+		Metadata: map[string]string{"via": "tls_secret"},
+	})
+
+	toCertEdge := fmt.Sprintf("%s->terminates_tls->%s", fromID, certID)
+	result.Edges = append(result.Edges, models.Edge{
+		ID:     toCertEdge,
+		FromID: fromID,
+		ToID:   certID,
+		Type:   models.EdgeTerminatesTLS,
+		Metadata: map[string]string{
+			"hosts": strings.Join(hosts, ","),
+		},
+	})
+}
+
+// isRetiredReplicaSet reports whether res is a ReplicaSet its Deployment has
+// scaled to zero, i.e. an old rollout revision kept for rollback.
+func isRetiredReplicaSet(res k8sResource) bool {
+	return res.Kind == "ReplicaSet" && len(res.Metadata.OwnerReferences) > 0 &&
+		res.Spec.Replicas != nil && *res.Spec.Replicas == 0
+}
+
+// addOwnerEdges adds managed_by edges from res to the owners named in its
+// ownerReferences (Pod → ReplicaSet → Deployment, Job → CronJob). Edges are
+// only drawn when both ends are nodes in this parse.
+func addOwnerEdges(nodeMap map[string]models.Node, result *parser.ParseResult, res k8sResource, ns string) {
+	if len(res.Metadata.OwnerReferences) == 0 {
+		return
+	}
+	selfID := k8sNodeID(ownerKindType(res.Kind), ns, res.Metadata.Name)
+	if _, ok := nodeMap[selfID]; !ok {
+		return
+	}
+	for _, ref := range res.Metadata.OwnerReferences {
+		ownerID := k8sNodeID(ownerKindType(ref.Kind), ns, ref.Name)
+		if _, ok := nodeMap[ownerID]; !ok {
+			continue
+		}
+		meta := map[string]string{"via": "ownerReference"}
+		if ref.Controller {
+			meta["controller"] = "true"
+		}
+		result.Edges = append(result.Edges, models.Edge{
+			ID:       fmt.Sprintf("%s->managed_by->%s", selfID, ownerID),
+			FromID:   selfID,
+			ToID:     ownerID,
+			Type:     models.EdgeManagedBy,
+			Metadata: meta,
+		})
+	}
+}
+
+// imageNodeID builds the node ID of a container image reference. Images are
+// not namespaced, so workloads anywhere in the cluster share the node.
+func imageNodeID(ref string) string {
+	return fmt.Sprintf("k8s:image:%s", ref)
+}
+
+// splitImageRef splits an image reference into repository, tag and digest,
+// e.g. "ghcr.io/org/api:v2@sha256:ab" → "ghcr.io/org/api", "v2", "sha256:ab".
+// A colon only starts the tag after the last slash, so registry ports
+// ("localhost:5000/api") stay in the repository.
+func splitImageRef(ref string) (repo, tag, digest string) {
+	repo, digest, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	return repo, tag, digest
+}
+
+// addImageEdge adds a uses_image edge from a workload to the node for image,
+// creating the image node on first use.
+func addImageEdge(nodeMap map[string]models.Node, result *parser.ParseResult, wlID, image, sourceFile string, now time.Time) {
+	imageID := imageNodeID(image)
+	if _, exists := nodeMap[imageID]; !exists {
+		repo, tag, digest := splitImageRef(image)
+		meta := map[string]string{"repository": repo}
+		if tag != "" {
+			meta["tag"] = tag
+		}
+		if digest != "" {
+			meta["digest"] = digest
+		}
+		node := models.Node{
+			ID: imageID, Name: image, Type: models.AssetImage,
+			Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+			Metadata: meta,
+			LastSeen: now, FirstSeen: now,
+		}
+		nodeMap[imageID] = node
+		result.Nodes = append(result.Nodes, node)
+	}
+	result.Edges = append(result.Edges, models.Edge{
+		ID:     fmt.Sprintf("%s->uses_image->%s", wlID, imageID),
+		FromID: wlID,
+		ToID:   imageID,
+		Type:   models.EdgeUsesImage,
+	})
+}
+
+// persistentVolumeID builds the node ID of a cluster-scoped PersistentVolume.
+func persistentVolumeID(name string) string {
+	return fmt.Sprintf("k8s:pv:%s", name)
+}
+
+// bindVolume adds a depends_on edge from a claim to the PersistentVolume
+// bound to it, creating a placeholder PV node if it isn't in the manifests.
+// Both sides of a binding name each other, so bound dedupes the edge.
+func bindVolume(nodeMap map[string]models.Node, result *parser.ParseResult, bound map[string]bool, pvcID, pvName, via, sourceFile string, now time.Time) {
+	pvID := persistentVolumeID(pvName)
+	if _, exists := nodeMap[pvID]; !exists {
+		node := models.Node{
+			ID: pvID, Name: pvName, Type: models.AssetDisk,
+			Source: "kubernetes", SourceFile: sourceFile, Provider: "kubernetes",
+			Metadata: map[string]string{"kind": "PersistentVolume", "auto_created": "true"},
+			LastSeen: now, FirstSeen: now,
+		}
+		nodeMap[pvID] = node
+		result.Nodes = append(result.Nodes, node)
+	}
+	eid := fmt.Sprintf("%s->depends_on->%s", pvcID, pvID)
+	if bound[eid] {
+		return
+	}
+	bound[eid] = true
+	result.Edges = append(result.Edges, models.Edge{
+		ID: eid, FromID: pvcID, ToID: pvID,
+		Type: models.EdgeDependsOn, Metadata: map[string]string{"via": via},
+	})
+}
+
+// volumeMetadata returns the storage class and access modes shared by
+// PersistentVolumes and their claims.
+func volumeMetadata(spec k8sSpec) map[string]string {
+	meta := make(map[string]string)
+	if spec.StorageClassName != "" {
+		meta["storage_class"] = spec.StorageClassName
+	}
+	if len(spec.AccessModes) > 0 {
+		meta["access_modes"] = strings.Join(spec.AccessModes, ",")
+	}
+	return meta
+}
+
+// storageQuantity reads the "storage" quantity from a PV capacity map or,
+// with key "requests", from a PVC resources block.
+func storageQuantity(v any, key string) string {
+	m, _ := v.(map[string]any)
+	if key != "" {
+		m, _ = m[key].(map[string]any)
+	}
+	if q, ok := m["storage"]; ok && q != nil {
+		return fmt.Sprintf("%v", q)
+	}
+	return ""
+}
+
 // ensureNode auto-creates a node if it doesn't already exist in nodeMap.
 // This prevents FK constraint violations when edges reference secrets or
 // configmaps that aren't defined as explicit resources in the manifest.
@@ -1135,6 +1670,35 @@ func labelsMatch(selector, target map[string]string) bool {
 	return true
 }
 
+// expressionsMatch returns true if the target labels satisfy every set-based
+// requirement. Unknown operators never match.
+func expressionsMatch(exprs []k8sSelectorRequirement, target map[string]string) bool {
+	for _, e := range exprs {
+		v, ok := target[e.Key]
+		switch e.Operator {
+		case "In":
+			if !ok || !slices.Contains(e.Values, v) {
+				return false
+			}
+		case "NotIn":
+			if ok && slices.Contains(e.Values, v) {
+				return false
+			}
+		case "Exists":
+			if !ok {
+				return false
+			}
+		case "DoesNotExist":
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func inferServiceTargets(value, defaultNamespace string, serviceIDs map[string]bool) []string {
 	hosts := extractPotentialHosts(value)
 	seen := make(map[string]bool)
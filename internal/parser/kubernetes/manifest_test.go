@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -204,6 +205,419 @@ func TestLabelsMatch(t *testing.T) {
 	}
 }
 
+func TestExpressionsMatch(t *testing.T) {
+	target := map[string]string{"app": "web", "tier": "frontend"}
+	tests := []struct {
+		name string
+		expr k8sSelectorRequirement
+		want bool
+	}{
+		{"in", k8sSelectorRequirement{Key: "app", Operator: "In", Values: []string{"api", "web"}}, true},
+		{"in miss", k8sSelectorRequirement{Key: "app", Operator: "In", Values: []string{"api"}}, false},
+		{"in missing key", k8sSelectorRequirement{Key: "env", Operator: "In", Values: []string{"prod"}}, false},
+		{"not in", k8sSelectorRequirement{Key: "tier", Operator: "NotIn", Values: []string{"backend"}}, true},
+		{"not in hit", k8sSelectorRequirement{Key: "tier", Operator: "NotIn", Values: []string{"frontend"}}, false},
+		{"not in missing key", k8sSelectorRequirement{Key: "env", Operator: "NotIn", Values: []string{"prod"}}, true},
+		{"exists", k8sSelectorRequirement{Key: "app", Operator: "Exists"}, true},
+		{"exists missing", k8sSelectorRequirement{Key: "env", Operator: "Exists"}, false},
+		{"does not exist", k8sSelectorRequirement{Key: "env", Operator: "DoesNotExist"}, true},
+		{"does not exist hit", k8sSelectorRequirement{Key: "app", Operator: "DoesNotExist"}, false},
+		{"unknown operator", k8sSelectorRequirement{Key: "app", Operator: "Gt", Values: []string{"1"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expressionsMatch([]k8sSelectorRequirement{tt.expr}, target); got != tt.want {
+				t.Errorf("expressionsMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseManifests_MatchExpressionsSelector(t *testing.T) {
+	manifest := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  selector:
+    matchExpressions:
+      - {key: app, operator: In, values: [web]}
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.27
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: api
+          image: api:1.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  selector:
+    matchExpressions: [{key: app, operator: In, values: [web]}]
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: not-web
+  namespace: shop
+spec:
+  podSelector:
+    matchExpressions:
+      - {key: app, operator: NotIn, values: [web]}
+`
+	result, err := parseManifests([]byte(manifest), "test.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges := make(map[string]bool)
+	for _, e := range result.Edges {
+		edges[e.ID] = true
+	}
+	for id, want := range map[string]bool{
+		"k8s:pod:shop/web->member_of->k8s:service:shop/web":            true,
+		"k8s:pod:shop/api->member_of->k8s:service:shop/web":            false,
+		"k8s:pod:shop/api->managed_by->k8s:networkpolicy:shop/not-web": true,
+		"k8s:pod:shop/web->managed_by->k8s:networkpolicy:shop/not-web": false,
+	} {
+		if edges[id] != want {
+			t.Errorf("edge %s present = %v, want %v", id, edges[id], want)
+		}
+	}
+}
+
+func TestParseManifests_PersistentVolumes(t *testing.T) {
+	data, err := os.ReadFile("testdata/storage.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseManifests(data, "testdata/storage.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	pvc, ok := nodes["k8s:pvc:media/uploads-data"]
+	if !ok {
+		t.Fatal("missing PVC node")
+	}
+	if pvc.Type != models.AssetDisk || pvc.Metadata["storage"] != "20Gi" || pvc.Metadata["storage_class"] != "gp3" {
+		t.Errorf("unexpected PVC node: %+v", pvc)
+	}
+	pv, ok := nodes["k8s:pv:pv-uploads"]
+	if !ok {
+		t.Fatal("missing PV node")
+	}
+	if pv.Type != models.AssetDisk || pv.Metadata["auto_created"] != "" {
+		t.Errorf("unexpected PV node: %+v", pv)
+	}
+
+	edges := make(map[string]int)
+	for _, e := range result.Edges {
+		edges[fmt.Sprintf("%s->%s->%s", e.FromID, e.Type, e.ToID)]++
+	}
+	if edges["k8s:pod:media/uploads->mounts_volume->k8s:pvc:media/uploads-data"] != 1 {
+		t.Errorf("missing workload->PVC mounts_volume edge, got %v", edges)
+	}
+	// Both the claim's volumeName and the volume's claimRef describe the
+	// binding; it must yield a single edge.
+	if edges["k8s:pvc:media/uploads-data->depends_on->k8s:pv:pv-uploads"] != 1 {
+		t.Errorf("expected one PVC->PV depends_on edge, got %v", edges)
+	}
+}
+
+func TestParseManifests_GatewayAPI(t *testing.T) {
+	data, err := os.ReadFile("testdata/gateway.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseManifests(data, "testdata/gateway.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	gw, ok := nodes["k8s:gateway:infra/public"]
+	if !ok {
+		t.Fatal("missing gateway node")
+	}
+	if gw.Type != models.AssetIngress || gw.Metadata["gateway_class"] != "istio" || gw.Metadata["hosts"] != "shop.example.com" {
+		t.Errorf("unexpected gateway node: %+v", gw)
+	}
+	if _, ok := nodes["k8s:httproute:shop/shop"]; !ok {
+		t.Fatal("missing httproute node")
+	}
+
+	edges := make(map[string]models.Edge)
+	for _, e := range result.Edges {
+		edges[fmt.Sprintf("%s->%s->%s", e.FromID, e.Type, e.ToID)] = e
+	}
+	for _, want := range []string{
+		"k8s:httproute:shop/shop->routes_to->k8s:service:shop/api",
+		"k8s:httproute:shop/shop->routes_to->k8s:service:shop/web",
+		"k8s:gateway:infra/public->routes_to->k8s:httproute:shop/shop",
+		"k8s:gateway:infra/public->terminates_tls->k8s:secret:infra/shop-tls",
+		"k8s:gateway:infra/public->terminates_tls->k8s:certificate:infra/shop-tls",
+	} {
+		if _, ok := edges[want]; !ok {
+			t.Errorf("missing edge %s", want)
+		}
+	}
+	if port := edges["k8s:httproute:shop/shop->routes_to->k8s:service:shop/api"].Metadata["port"]; port != "8080" {
+		t.Errorf("route->service port = %q, want 8080", port)
+	}
+}
+
+func TestParseManifests_BarePodDistinctFromWorkload(t *testing.T) {
+	data := []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    spec:
+      containers:
+        - name: web
+          image: nginx:1.27
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+  namespace: shop
+spec:
+  containers:
+    - name: debug
+      image: busybox:1.36
+`)
+	result, err := parseManifests(data, "pods.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]string)
+	for _, n := range result.Nodes {
+		kinds[n.ID] = n.Metadata["kind"]
+	}
+	if kinds["k8s:pod:shop/web"] != "Deployment" || kinds["k8s:barepod:shop/web"] != "Pod" {
+		t.Errorf("want separate Deployment and Pod nodes, got %v", kinds)
+	}
+}
+
+func TestParseManifests_OwnerReferences(t *testing.T) {
+	data, err := os.ReadFile("testdata/owners.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parseManifests(data, "testdata/owners.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	pod, ok := nodes["k8s:barepod:shop/web-7d4b9-x2k4p"]
+	if !ok {
+		t.Fatal("missing pod node")
+	}
+	if pod.Metadata["kind"] != "Pod" || pod.Metadata["pod_ip"] != "10.0.3.17" || pod.Metadata["node_name"] != "worker-1" || pod.Metadata["phase"] != "Running" {
+		t.Errorf("unexpected pod metadata: %v", pod.Metadata)
+	}
+	if _, ok := nodes["k8s:pod:shop/web-5f6c8"]; ok {
+		t.Error("ReplicaSet scaled to zero by its Deployment should be skipped")
+	}
+
+	edges := make(map[string]models.Edge)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeManagedBy {
+			edges[e.FromID+"->"+e.ToID] = e
+		}
+	}
+	for _, want := range []string{
+		"k8s:barepod:shop/web-7d4b9-x2k4p->k8s:pod:shop/web-7d4b9",
+		"k8s:pod:shop/web-7d4b9->k8s:pod:shop/web",
+	} {
+		e, ok := edges[want]
+		if !ok {
+			t.Errorf("missing managed_by edge %s", want)
+			continue
+		}
+		if e.Metadata["controller"] != "true" {
+			t.Errorf("%s: controller metadata = %q", want, e.Metadata["controller"])
+		}
+	}
+}
+
+func TestParseManifests_NamespaceMembership(t *testing.T) {
+	data, err := os.ReadFile("testdata/manifests.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rbac, err := os.ReadFile("testdata/rbac.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(append(data, []byte("\n---\n")...), rbac...)
+
+	result, err := parseManifests(data, "test.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memberOf := make(map[string][]string)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeMemberOf && strings.HasPrefix(e.ToID, "k8s:namespace:") {
+			memberOf[e.FromID] = append(memberOf[e.FromID], e.ToID)
+		}
+	}
+
+	checked := 0
+	for _, n := range result.Nodes {
+		switch {
+		case n.Type == models.AssetNamespace, n.Type == models.AssetImage, strings.HasPrefix(n.ID, "k8s:clusterrole"):
+			if len(memberOf[n.ID]) != 0 {
+				t.Errorf("cluster-scoped %s should not be a namespace member, got %v", n.ID, memberOf[n.ID])
+			}
+		default:
+			want := "k8s:namespace:" + n.Metadata["namespace"]
+			if got := memberOf[n.ID]; len(got) != 1 || got[0] != want {
+				t.Errorf("%s: member_of edges = %v, want exactly [%s]", n.ID, got, want)
+			}
+			checked++
+		}
+	}
+	if checked == 0 {
+		t.Fatal("no namespaced nodes checked")
+	}
+}
+
+func TestParseManifests_SharedImage(t *testing.T) {
+	manifest := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: mycompany/api:v2.1.0
+      containers:
+        - name: api
+          image: mycompany/api:v2.1.0
+        - name: proxy
+          image: envoyproxy/envoy@sha256:abc123
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: jobs
+spec:
+  template:
+    spec:
+      containers:
+        - name: worker
+          image: mycompany/api:v2.1.0
+`
+	result, err := parseManifests([]byte(manifest), "test.yaml", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		if n.Type == models.AssetImage {
+			images[n.ID] = n
+		}
+	}
+	if len(images) != 2 {
+		t.Fatalf("expected 2 image nodes, got %v", images)
+	}
+	api := images["k8s:image:mycompany/api:v2.1.0"]
+	if api.Metadata["repository"] != "mycompany/api" || api.Metadata["tag"] != "v2.1.0" {
+		t.Errorf("api image metadata = %v", api.Metadata)
+	}
+	envoy := images["k8s:image:envoyproxy/envoy@sha256:abc123"]
+	if envoy.Metadata["repository"] != "envoyproxy/envoy" || envoy.Metadata["digest"] != "sha256:abc123" {
+		t.Errorf("envoy image metadata = %v", envoy.Metadata)
+	}
+
+	users := make(map[string]int)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeUsesImage && e.ToID == "k8s:image:mycompany/api:v2.1.0" {
+			users[e.FromID]++
+		}
+	}
+	if users["k8s:pod:shop/api"] != 1 || users["k8s:pod:jobs/worker"] != 1 || len(users) != 2 {
+		t.Errorf("uses_image edges to shared image = %v, want one per deployment", users)
+	}
+}
+
+func TestSplitImageRef(t *testing.T) {
+	tests := []struct {
+		ref, repo, tag, digest string
+	}{
+		{"nginx", "nginx", "", ""},
+		{"nginx:1.27", "nginx", "1.27", ""},
+		{"localhost:5000/api", "localhost:5000/api", "", ""},
+		{"localhost:5000/api:v1", "localhost:5000/api", "v1", ""},
+		{"ghcr.io/org/api:v2@sha256:ab", "ghcr.io/org/api", "v2", "sha256:ab"},
+		{"ghcr.io/org/api@sha256:ab", "ghcr.io/org/api", "", "sha256:ab"},
+	}
+	for _, tt := range tests {
+		repo, tag, digest := splitImageRef(tt.ref)
+		if repo != tt.repo || tag != tt.tag || digest != tt.digest {
+			t.Errorf("splitImageRef(%q) = %q, %q, %q", tt.ref, repo, tag, digest)
+		}
+	}
+}
+
 func TestParseManifests_InvalidYAML(t *testing.T) {
 	data := []byte("---\nkind: Deployment\nmetadata:\n  name: test\n---\n{invalid yaml")
 	result, err := parseManifests(data, "test.yaml", time.Now())
@@ -356,10 +770,10 @@ func mustSelfSignedTLSCertBase64(t *testing.T, notAfter time.Time) string {
 
 func TestParseManifests_UnsupportedKind(t *testing.T) {
 	data := []byte(`---
-apiVersion: v1
-kind: PersistentVolumeClaim
+apiVersion: coordination.k8s.io/v1
+kind: Lease
 metadata:
-  name: my-pvc
+  name: my-lease
 `)
 	result, err := parseManifests(data, "test.yaml", time.Now())
 	if err != nil {
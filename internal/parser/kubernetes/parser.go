@@ -13,7 +13,8 @@ import (
 
 // K8sParser parses Kubernetes YAML manifests and Helm charts.
 type K8sParser struct {
-	ValuesFile string // optional Helm values file
+	ValuesFile string            // optional Helm values file
+	Filter     parser.PathFilter // limits which manifests a directory walk reads
 }
 
 // NewK8sParser creates a Kubernetes parser with an optional Helm values file.
@@ -66,7 +67,7 @@ func (p *K8sParser) Parse(ctx context.Context, path string) (*parser.ParseResult
 	// Plain manifest file(s)
 	var files []string
 	if info.IsDir() {
-		if err := walkYAMLFiles(path, &files); err != nil {
+		if err := walkYAMLFiles(path, p.Filter, &files); err != nil {
 			return nil, err
 		}
 	} else {
@@ -95,7 +96,7 @@ func (p *K8sParser) Parse(ctx context.Context, path string) (*parser.ParseResult
 	return result, nil
 }
 
-func walkYAMLFiles(dir string, files *[]string) error {
+func walkYAMLFiles(dir string, filter parser.PathFilter, files *[]string) error {
 	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // skip errors
@@ -104,7 +105,7 @@ func walkYAMLFiles(dir string, files *[]string) error {
 			return nil
 		}
 		ext := strings.ToLower(filepath.Ext(path))
-		if ext == ".yaml" || ext == ".yml" {
+		if (ext == ".yaml" || ext == ".yml") && filter.Allows(dir, path) {
 			*files = append(*files, path)
 		}
 		return nil
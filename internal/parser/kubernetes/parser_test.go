@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/matijazezelj/aib/internal/parser"
 )
 
 func TestNewK8sParser(t *testing.T) {
@@ -120,7 +122,7 @@ func TestWalkYAMLFiles(t *testing.T) {
 	}
 
 	var files []string
-	if err := walkYAMLFiles(dir, &files); err != nil {
+	if err := walkYAMLFiles(dir, parser.PathFilter{}, &files); err != nil {
 		t.Fatal(err)
 	}
 	if len(files) != 2 {
@@ -128,6 +130,28 @@ func TestWalkYAMLFiles(t *testing.T) {
 	}
 }
 
+func TestWalkYAMLFiles_Filter(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app.yaml", "testdata/fixture.yaml", "vendor/chart/dep.yml"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var files []string
+	filter := parser.PathFilter{Exclude: []string{"testdata", "vendor/*"}}
+	if err := walkYAMLFiles(dir, filter, &files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "app.yaml" {
+		t.Errorf("walkYAMLFiles = %v, want only app.yaml", files)
+	}
+}
+
 func TestK8sParser_Parse_Nonexistent(t *testing.T) {
 	p := NewK8sParser("")
 	_, err := p.Parse(context.Background(), "/nonexistent/path/manifests.yaml")
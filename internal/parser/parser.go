@@ -39,4 +39,9 @@ type ParseResult struct {
 	Nodes    []models.Node
 	Edges    []models.Edge
 	Warnings []string
+
+	// PathsScanned and PathsFailed count the input files (or remote states)
+	// that were parsed and that failed. Failures are also reported as warnings.
+	PathsScanned int
+	PathsFailed  int
 }
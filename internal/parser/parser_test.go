@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/matijazezelj/aib/pkg/models"
 )
 
 func TestSafeResolvePath_ResolvesExistingPath(t *testing.T) {
@@ -81,3 +83,56 @@ func TestWithDefaultCommandTimeout_RespectsExistingDeadline(t *testing.T) {
 		t.Fatalf("deadline changed: got %v, want %v", deadline, parentDeadline)
 	}
 }
+
+func TestPathFilter_Allows(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	tests := []struct {
+		name   string
+		filter PathFilter
+		path   string
+		want   bool
+	}{
+		{"no patterns", PathFilter{}, "envs/prod/terraform.tfstate", true},
+		{"excluded directory", PathFilter{Exclude: []string{"testdata"}}, "modules/vpc/testdata/x.tfstate", false},
+		{"excluded top-level directory", PathFilter{Exclude: []string{"testdata"}}, "testdata/x.tfstate", false},
+		{"excluded directory glob", PathFilter{Exclude: []string{"vendor/*"}}, "vendor/mod/x.tfstate", false},
+		{"excluded base name", PathFilter{Exclude: []string{"*.backup.tfstate"}}, "envs/a.backup.tfstate", false},
+		{"not matching exclude", PathFilter{Exclude: []string{"testdata"}}, "envs/testdata.tfstate", true},
+		{"included", PathFilter{Include: []string{"envs"}}, "envs/prod/terraform.tfstate", true},
+		{"not included", PathFilter{Include: []string{"envs"}}, "sandbox/terraform.tfstate", false},
+		{"exclude wins", PathFilter{Include: []string{"envs"}, Exclude: []string{"dev"}}, "envs/dev/terraform.tfstate", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(root, filepath.FromSlash(tt.path))
+			if got := tt.filter.Allows(root, path); got != tt.want {
+				t.Errorf("Allows(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if err := (PathFilter{Exclude: []string{"[bad"}}).Validate(); err == nil {
+		t.Error("expected Validate to reject a malformed glob")
+	}
+}
+
+func TestExcludeTypes(t *testing.T) {
+	r := &ParseResult{
+		Nodes: []models.Node{
+			{ID: "a", Type: models.AssetVM},
+			{ID: "b", Type: models.AssetDNSRecord},
+			{ID: "c", Type: models.AssetNetwork},
+		},
+		Edges: []models.Edge{
+			{ID: "a->b", FromID: "a", ToID: "b"},
+			{ID: "a->c", FromID: "a", ToID: "c"},
+		},
+	}
+	ExcludeTypes(r, []string{string(models.AssetDNSRecord)})
+	if len(r.Nodes) != 2 || r.Nodes[0].ID != "a" || r.Nodes[1].ID != "c" {
+		t.Errorf("nodes = %+v, want a and c", r.Nodes)
+	}
+	if len(r.Edges) != 1 || r.Edges[0].ID != "a->c" {
+		t.Errorf("edges = %+v, want only a->c", r.Edges)
+	}
+}
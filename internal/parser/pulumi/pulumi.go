@@ -82,11 +82,13 @@ func (p *PulumiParser) ParseMulti(ctx context.Context, paths []string) (*parser.
 		resolved, err := parser.SafeResolvePath(path)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("resolving %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
 		data, err := os.ReadFile(resolved) // #nosec G304 -- paths validated by SafeResolvePath
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("reading %s: %v", resolved, err))
+			result.PathsFailed++
 			continue
 		}
 		stateData[resolved] = data
@@ -113,8 +115,10 @@ func (p *PulumiParser) ParseMulti(ctx context.Context, paths []string) (*parser.
 		r, err := parsePulumiWithRefs(data, path, globalRefMap)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("parsing %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
+		result.PathsScanned++
 		result.Nodes = append(result.Nodes, r.Nodes...)
 		result.Edges = append(result.Edges, r.Edges...)
 		result.Warnings = append(result.Warnings, r.Warnings...)
@@ -0,0 +1,245 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"google.golang.org/api/googleapi"
+
+	"github.com/matijazezelj/aib/internal/parser"
+)
+
+// Backend types supported by PullBackendState.
+const (
+	BackendS3    = "s3"
+	BackendGCS   = "gcs"
+	BackendAzure = "azurerm"
+)
+
+// BackendConfig locates a state object in a cloud storage backend. For Azure,
+// Bucket is the container and Account the storage account.
+type BackendConfig struct {
+	Type    string
+	Bucket  string
+	Key     string
+	Region  string // S3 only; defaults to the AWS SDK's configured region
+	Account string // Azure only
+}
+
+// String returns the backend location in URL form, e.g. "s3://bucket/key".
+func (c BackendConfig) String() string {
+	switch c.Type {
+	case BackendGCS:
+		return "gs://" + c.Bucket + "/" + c.Key
+	case BackendAzure:
+		return "azurerm://" + c.Account + "/" + c.Bucket + "/" + c.Key
+	}
+	return c.Type + "://" + c.Bucket + "/" + c.Key
+}
+
+// ParseBackendURL parses a backend location: s3://bucket/key,
+// gs://bucket/key or azurerm://account/container/key. region applies to S3.
+func ParseBackendURL(raw, region string) (BackendConfig, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return BackendConfig{}, fmt.Errorf("invalid backend URL %q: %w", raw, err)
+	}
+	cfg := BackendConfig{Bucket: u.Host, Key: strings.TrimPrefix(u.Path, "/")}
+	switch u.Scheme {
+	case "s3":
+		cfg.Type = BackendS3
+		cfg.Region = region
+	case "gs", "gcs":
+		cfg.Type = BackendGCS
+	case "azurerm", "az":
+		cfg.Type = BackendAzure
+		cfg.Account = u.Host
+		cfg.Bucket, cfg.Key, _ = strings.Cut(cfg.Key, "/")
+	default:
+		return BackendConfig{}, fmt.Errorf("unsupported backend %q (want s3://, gs:// or azurerm://)", raw)
+	}
+	if cfg.Bucket == "" || cfg.Key == "" {
+		return BackendConfig{}, fmt.Errorf("backend URL %q is missing a bucket or key", raw)
+	}
+	return cfg, nil
+}
+
+// backendReaders fetch raw state bytes per backend type. Replaced in tests.
+var backendReaders = map[string]func(ctx context.Context, cfg BackendConfig) ([]byte, error){
+	BackendS3:    readS3State,
+	BackendGCS:   readGCSState,
+	BackendAzure: readAzureState,
+}
+
+// PullBackendState reads a state file directly from cloud storage, without
+// the terraform CLI, and returns the raw JSON bytes.
+func PullBackendState(ctx context.Context, cfg BackendConfig) ([]byte, error) {
+	read, ok := backendReaders[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unsupported backend type %q", cfg.Type)
+	}
+
+	ctx, cancel := parser.WithDefaultCommandTimeout(ctx)
+	defer cancel()
+
+	data, err := read(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s is empty (no state found)", cfg)
+	}
+
+	// Verify it's valid JSON
+	var state tfState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing state from %s: %w", cfg, err)
+	}
+	return data, nil
+}
+
+// PullBackendMulti reads state from multiple backend URLs with cross-state
+// edge resolution. region applies to S3 backends.
+func PullBackendMulti(ctx context.Context, backendURLs []string, region string) (*parser.ParseResult, error) {
+	var states []pulledState
+	var warnings []string
+	failed := 0
+
+	for _, raw := range backendURLs {
+		cfg, err := ParseBackendURL(raw, region)
+		if err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, "reading backend state", "backend", cfg.String())
+		data, err := PullBackendState(ctx, cfg)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", cfg, err))
+			failed++
+			continue
+		}
+		states = append(states, pulledState{label: cfg.String(), data: data})
+	}
+
+	return parsePulledStates(states, warnings, failed), nil
+}
+
+func readS3State(ctx context.Context, cfg BackendConfig) ([]byte, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured: pass --backend-region or set AWS_REGION")
+	}
+	if _, err := awsCfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("no usable AWS credentials (set AWS_PROFILE or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY, or use an instance role): %w", err)
+	}
+
+	out, err := s3.NewFromConfig(awsCfg).GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(cfg.Bucket),
+		Key:    aws.String(cfg.Key),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.ErrorCode() {
+			case "AccessDenied", "InvalidAccessKeyId", "SignatureDoesNotMatch", "ExpiredToken":
+				return nil, fmt.Errorf("AWS credentials were rejected (%s): check they grant s3:GetObject on the state bucket", apiErr.ErrorCode())
+			case "NoSuchKey", "NoSuchBucket":
+				return nil, fmt.Errorf("no state found (%s)", apiErr.ErrorCode())
+			}
+		}
+		return nil, fmt.Errorf("reading S3 object: %w", err)
+	}
+	defer out.Body.Close() //nolint:errcheck // read-only body
+	return io.ReadAll(out.Body)
+}
+
+func readGCSState(ctx context.Context, cfg BackendConfig) ([]byte, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no usable Google Cloud credentials (set GOOGLE_APPLICATION_CREDENTIALS or run 'gcloud auth application-default login'): %w", err)
+	}
+	defer client.Close() //nolint:errcheck // best-effort cleanup
+
+	r, err := client.Bucket(cfg.Bucket).Object(cfg.Key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+			return nil, fmt.Errorf("no state found: %w", err)
+		}
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && (apiErr.Code == http.StatusUnauthorized || apiErr.Code == http.StatusForbidden) {
+			return nil, fmt.Errorf("credentials were rejected by Google Cloud: check they grant storage.objects.get on the state bucket: %w", err)
+		}
+		return nil, fmt.Errorf("reading GCS object: %w", err)
+	}
+	defer r.Close() //nolint:errcheck // read-only reader
+	return io.ReadAll(r)
+}
+
+const (
+	azureBlobHost     = "blob.core.windows.net"
+	azureStorageScope = "https://storage.azure.com/.default" // OAuth scope for blob data access
+)
+
+func readAzureState(ctx context.Context, cfg BackendConfig) ([]byte, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("no usable Azure credentials (set AZURE_CLIENT_ID/AZURE_TENANT_ID/AZURE_CLIENT_SECRET or run 'az login'): %w", err)
+	}
+	token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{azureStorageScope}})
+	if err != nil {
+		return nil, fmt.Errorf("no usable Azure credentials (set AZURE_CLIENT_ID/AZURE_TENANT_ID/AZURE_CLIENT_SECRET or run 'az login'): %w", err)
+	}
+
+	blobURL := fmt.Sprintf("https://%s.%s/%s/%s", cfg.Account, azureBlobHost, url.PathEscape(cfg.Bucket), escapeBlobPath(cfg.Key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, blobURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	req.Header.Set("x-ms-version", "2021-08-06")
+
+	resp, err := http.DefaultClient.Do(req) // #nosec G107 -- URL is built from the configured backend
+	if err != nil {
+		return nil, fmt.Errorf("reading Azure blob: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("no state found (%s)", resp.Header.Get("x-ms-error-code"))
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("credentials were rejected by Azure (%s): check they hold the Storage Blob Data Reader role on the container", resp.Header.Get("x-ms-error-code"))
+	}
+	return nil, fmt.Errorf("reading Azure blob: unexpected status %s", resp.Status)
+}
+
+// escapeBlobPath escapes each segment of a blob name, keeping the slashes.
+func escapeBlobPath(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,111 @@
+package terraform
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubBackendReaders replaces the cloud readers with fakes for the test.
+func stubBackendReaders(t *testing.T, read func(ctx context.Context, cfg BackendConfig) ([]byte, error)) {
+	t.Helper()
+	orig := backendReaders
+	backendReaders = map[string]func(ctx context.Context, cfg BackendConfig) ([]byte, error){
+		BackendS3:    read,
+		BackendGCS:   read,
+		BackendAzure: read,
+	}
+	t.Cleanup(func() { backendReaders = orig })
+}
+
+func TestParseBackendURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want BackendConfig
+	}{
+		{"s3://tf-state/prod/terraform.tfstate", BackendConfig{Type: BackendS3, Bucket: "tf-state", Key: "prod/terraform.tfstate", Region: "eu-west-1"}},
+		{"gs://tf-state/prod/default.tfstate", BackendConfig{Type: BackendGCS, Bucket: "tf-state", Key: "prod/default.tfstate"}},
+		{"gcs://tf-state/default.tfstate", BackendConfig{Type: BackendGCS, Bucket: "tf-state", Key: "default.tfstate"}},
+		{"azurerm://acct/tfstate/prod.terraform.tfstate", BackendConfig{Type: BackendAzure, Account: "acct", Bucket: "tfstate", Key: "prod.terraform.tfstate"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBackendURL(tt.raw, "eu-west-1")
+		if err != nil {
+			t.Errorf("ParseBackendURL(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBackendURL(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+		if got.String() != strings.Replace(tt.raw, "gcs://", "gs://", 1) {
+			t.Errorf("String() = %q, want %q", got.String(), tt.raw)
+		}
+	}
+
+	for _, raw := range []string{"http://bucket/key", "s3://bucket", "s3:///key", "azurerm://acct/container", "terraform.tfstate"} {
+		if _, err := ParseBackendURL(raw, ""); err == nil {
+			t.Errorf("ParseBackendURL(%q) should fail", raw)
+		}
+	}
+}
+
+func TestPullBackendState(t *testing.T) {
+	var gotCfg BackendConfig
+	stubBackendReaders(t, func(_ context.Context, cfg BackendConfig) ([]byte, error) {
+		gotCfg = cfg
+		return []byte(validStateJSON), nil
+	})
+
+	cfg := BackendConfig{Type: BackendS3, Bucket: "b", Key: "k", Region: "us-east-1"}
+	data, err := PullBackendState(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != validStateJSON {
+		t.Errorf("unexpected state bytes: %s", data)
+	}
+	if gotCfg != cfg {
+		t.Errorf("reader got %+v, want %+v", gotCfg, cfg)
+	}
+}
+
+func TestPullBackendState_InvalidState(t *testing.T) {
+	for name, body := range map[string]string{"empty": "", "not json": "<Error/>"} {
+		stubBackendReaders(t, func(context.Context, BackendConfig) ([]byte, error) {
+			return []byte(body), nil
+		})
+		if _, err := PullBackendState(context.Background(), BackendConfig{Type: BackendGCS, Bucket: "b", Key: "k"}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPullBackendMulti_FailuresBecomeWarnings(t *testing.T) {
+	stubBackendReaders(t, func(_ context.Context, cfg BackendConfig) ([]byte, error) {
+		if cfg.Bucket == "denied" {
+			return nil, errors.New("no usable AWS credentials")
+		}
+		return []byte(validStateJSON), nil
+	})
+
+	result, err := PullBackendMulti(context.Background(), []string{"s3://ok/terraform.tfstate", "s3://denied/terraform.tfstate"}, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.PathsScanned != 1 || result.PathsFailed != 1 {
+		t.Errorf("paths scanned/failed = %d/%d, want 1/1", result.PathsScanned, result.PathsFailed)
+	}
+	if len(result.Nodes) != 1 || result.Nodes[0].SourceFile != "s3://ok/terraform.tfstate" {
+		t.Errorf("unexpected nodes: %+v", result.Nodes)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "s3://denied/terraform.tfstate: no usable AWS credentials") {
+		t.Errorf("unexpected warnings: %v", result.Warnings)
+	}
+}
+
+func TestPullBackendMulti_InvalidURL(t *testing.T) {
+	if _, err := PullBackendMulti(context.Background(), []string{"ftp://bucket/key"}, ""); err == nil {
+		t.Fatal("expected error for unsupported backend URL")
+	}
+}
@@ -2,4 +2,66 @@ package terraform
 
 // This file contains additional mapping utilities for the Terraform parser.
 // The core mapping logic is in state.go (mapResourceType, extractMetadata, etc.).
-// This file is reserved for future HCL parsing and advanced mapping features.
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/matijazezelj/aib/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// typeOverrides holds user resource type mappings, consulted by
+// mapResourceType before the built-in ones.
+var typeOverrides struct {
+	sync.RWMutex
+	m map[string]models.AssetType
+}
+
+// assetTypePattern is what a mapped asset type must look like.
+var assetTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// SetTypeMappings installs resource type mappings that extend or override
+// the built-in ones, replacing any set before. A nil map restores the
+// built-in mappings.
+func SetTypeMappings(m map[string]models.AssetType) {
+	typeOverrides.Lock()
+	defer typeOverrides.Unlock()
+	typeOverrides.m = m
+}
+
+// LoadTypeMappings reads a YAML file mapping Terraform resource types to
+// asset types, e.g.
+//
+//	mycorp_widget: service
+//	aws_s3_bucket_acl: bucket
+//
+// Asset types need not be built-in, but must be lowercase identifiers.
+func LoadTypeMappings(path string) (map[string]models.AssetType, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- path comes from the user's own config
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	m := make(map[string]models.AssetType, len(raw))
+	for tfType, assetType := range raw {
+		if !assetTypePattern.MatchString(assetType) {
+			return nil, fmt.Errorf("%s: %s: invalid asset type %q", path, tfType, assetType)
+		}
+		m[tfType] = models.AssetType(assetType)
+	}
+	return m, nil
+}
+
+// overrideType returns the user mapping for tfType, if there is one.
+func overrideType(tfType string) (models.AssetType, bool) {
+	typeOverrides.RLock()
+	defer typeOverrides.RUnlock()
+	t, ok := typeOverrides.m[tfType]
+	return t, ok
+}
@@ -0,0 +1,93 @@
+package terraform
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+const widgetState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "mycorp_widget",
+      "name": "frontend",
+      "provider": "provider[\"registry.terraform.io/mycorp/mycorp\"]",
+      "instances": [{"attributes": {"id": "w-1", "name": "frontend"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_sqs_queue",
+      "name": "jobs",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [{"attributes": {"id": "jobs", "name": "jobs"}}]
+    }
+  ]
+}`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTypeMappings_Override(t *testing.T) {
+	dir := t.TempDir()
+	state := writeFile(t, dir, "widget.tfstate", widgetState)
+
+	types := func() map[string]models.AssetType {
+		t.Helper()
+		result, err := NewStateParser().Parse(context.Background(), state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := map[string]models.AssetType{}
+		for _, n := range result.Nodes {
+			out[n.Name] = n.Type
+		}
+		return out
+	}
+
+	if got := types(); len(got) != 1 || got["jobs"] != models.AssetQueue {
+		t.Fatalf("without mappings, nodes = %v, want only the built-in queue", got)
+	}
+
+	m, err := LoadTypeMappings(writeFile(t, dir, "type_mappings.yaml", "mycorp_widget: service\naws_sqs_queue: pubsub\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetTypeMappings(m)
+	t.Cleanup(func() { SetTypeMappings(nil) })
+
+	got := types()
+	if got["frontend"] != models.AssetService {
+		t.Errorf("mycorp_widget type = %q, want service", got["frontend"])
+	}
+	if got["jobs"] != models.AssetPubSub {
+		t.Errorf("overridden aws_sqs_queue type = %q, want pubsub", got["jobs"])
+	}
+}
+
+func TestLoadTypeMappings_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"bad_type.yaml": "mycorp_widget: Service Thing\n",
+		"empty.yaml":    "mycorp_widget: \"\"\n",
+		"not_map.yaml":  "- mycorp_widget\n",
+	} {
+		if _, err := LoadTypeMappings(writeFile(t, dir, name, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if _, err := LoadTypeMappings(filepath.Join(dir, "missing.yaml")); err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Errorf("missing file: err = %v", err)
+	}
+}
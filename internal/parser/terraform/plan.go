@@ -22,12 +22,13 @@ type tfPlan struct {
 
 // tfResourceChange represents a single resource change in a plan.
 type tfResourceChange struct {
-	Address      string   `json:"address"`
-	Mode         string   `json:"mode"`
-	Type         string   `json:"type"`
-	Name         string   `json:"name"`
-	ProviderName string   `json:"provider_name"`
-	Change       tfChange `json:"change"`
+	Address       string   `json:"address"`
+	ModuleAddress string   `json:"module_address"`
+	Mode          string   `json:"mode"`
+	Type          string   `json:"type"`
+	Name          string   `json:"name"`
+	ProviderName  string   `json:"provider_name"`
+	Change        tfChange `json:"change"`
 }
 
 // tfChange describes the before/after state of a resource.
@@ -86,11 +87,13 @@ func (p *PlanParser) ParseMulti(ctx context.Context, paths []string) (*parser.Pa
 		resolved, err := parser.SafeResolvePath(path)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("resolving %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
 		data, err := os.ReadFile(resolved) // #nosec G304 -- paths validated by SafeResolvePath
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("reading %s: %v", resolved, err))
+			result.PathsFailed++
 			continue
 		}
 		planData[resolved] = data
@@ -116,8 +119,10 @@ func (p *PlanParser) ParseMulti(ctx context.Context, paths []string) (*parser.Pa
 		r, err := parsePlanBytesWithRefs(data, path, globalRefMap)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("parsing %s: %v", path, err))
+			result.PathsFailed++
 			continue
 		}
+		result.PathsScanned++
 		result.Nodes = append(result.Nodes, r.Nodes...)
 		result.Edges = append(result.Edges, r.Edges...)
 		result.Warnings = append(result.Warnings, r.Warnings...)
@@ -126,7 +131,8 @@ func (p *PlanParser) ParseMulti(ctx context.Context, paths []string) (*parser.Pa
 	return result, nil
 }
 
-// buildPlanRefMap builds a mapping from TF address to node ID for plan resources.
+// buildPlanRefMap builds a mapping from resource name to node ID for plan
+// resources, used to resolve attribute references.
 func buildPlanRefMap(data []byte) (map[string]string, error) {
 	var plan tfPlan
 	if err := json.Unmarshal(data, &plan); err != nil {
@@ -151,13 +157,13 @@ func buildPlanRefMap(data []byte) (map[string]string, error) {
 		if attrs == nil {
 			attrs = rc.Change.Before
 		}
-		nodeID := fmt.Sprintf("tf:%s:%s", assetType, rc.Name)
-		if attrs != nil {
-			if n, ok := attrs["name"].(string); ok && n != "" {
-				nodeID = fmt.Sprintf("tf:%s:%s", assetType, n)
-			}
+		name := rc.Name
+		if n, ok := attrs["name"].(string); ok && n != "" {
+			name = n
+		}
+		if _, ok := refToNodeID[name]; !ok {
+			refToNodeID[name] = fmt.Sprintf("tf:%s:%s", assetType, qualifiedName(rc.ModuleAddress, name))
 		}
-		refToNodeID[rc.Address] = nodeID
 	}
 	return refToNodeID, nil
 }
@@ -199,12 +205,11 @@ func parsePlanBytesWithRefs(data []byte, sourcePath string, refToNodeID map[stri
 			attrs = make(map[string]any)
 		}
 
-		nodeID := fmt.Sprintf("tf:%s:%s", assetType, rc.Name)
 		name := rc.Name
 		if n, ok := attrs["name"].(string); ok && n != "" {
 			name = n
-			nodeID = fmt.Sprintf("tf:%s:%s", assetType, n)
 		}
+		nodeID := fmt.Sprintf("tf:%s:%s", assetType, qualifiedName(rc.ModuleAddress, name))
 
 		meta := extractMetadata(rc.Type, attrs)
 		meta["plan_action"] = action
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/matijazezelj/aib/internal/parser"
@@ -73,12 +74,14 @@ func PullRemoteMulti(ctx context.Context, projectDirs []string, workspace string
 	// Collect raw state bytes from all sources
 	var states []pulledState
 	var warnings []string
+	failed := 0
 
 	for _, dir := range projectDirs {
 		if workspace == "*" {
 			workspaces, err := ListWorkspaces(ctx, dir)
 			if err != nil {
 				warnings = append(warnings, fmt.Sprintf("listing workspaces in %s: %v", dir, err))
+				failed++
 				continue
 			}
 			for _, ws := range workspaces {
@@ -86,6 +89,7 @@ func PullRemoteMulti(ctx context.Context, projectDirs []string, workspace string
 				data, err := pullStateBytes(ctx, dir, ws)
 				if err != nil {
 					warnings = append(warnings, fmt.Sprintf("%s workspace %q: %v", dir, ws, err))
+					failed++
 					continue
 				}
 				states = append(states, pulledState{label: dir + "/" + ws, data: data})
@@ -99,39 +103,46 @@ func PullRemoteMulti(ctx context.Context, projectDirs []string, workspace string
 			data, err := pullStateBytes(ctx, dir, workspace)
 			if err != nil {
 				warnings = append(warnings, fmt.Sprintf("%s: %v", dir, err))
+				failed++
 				continue
 			}
 			states = append(states, pulledState{label: dir, data: data})
 		}
 	}
 
+	return parsePulledStates(states, warnings, failed), nil
+}
+
+// parsePulledStates parses pulled states with cross-state edge resolution.
+// warnings and failed carry over problems from pulling.
+func parsePulledStates(states []pulledState, warnings []string, failed int) *parser.ParseResult {
 	// Phase 1: build global ref map across all pulled states
-	globalRefMap := make(map[string]string)
+	globalRefMap := newRefMap()
 	for _, s := range states {
 		refs, err := buildRefMap(s.data)
 		if err != nil {
 			warnings = append(warnings, fmt.Sprintf("building ref map for %s: %v", s.label, err))
 			continue
 		}
-		for k, v := range refs {
-			globalRefMap[k] = v
-		}
+		globalRefMap.merge(refs)
 	}
 
 	// Phase 2: parse each state with the global ref map
-	result := &parser.ParseResult{Warnings: warnings}
+	result := &parser.ParseResult{Warnings: warnings, PathsFailed: failed}
 	for _, s := range states {
 		r, err := parseStateBytesWithRefs(s.data, s.label, globalRefMap)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("parsing %s: %v", s.label, err))
+			result.PathsFailed++
 			continue
 		}
+		result.PathsScanned++
 		result.Nodes = append(result.Nodes, r.Nodes...)
 		result.Edges = append(result.Edges, r.Edges...)
 		result.Warnings = append(result.Warnings, r.Warnings...)
 	}
 
-	return result, nil
+	return result
 }
 
 // ListWorkspaces returns the list of terraform workspaces in a project directory.
@@ -163,39 +174,260 @@ func ListWorkspaces(ctx context.Context, projectDir string) ([]string, error) {
 	return workspaces, nil
 }
 
+// refMap resolves Terraform references to node IDs. It may span several
+// state files, so references between states resolve too.
+type refMap struct {
+	nodeIDs     map[string][]string       // resource address → node IDs of its instances
+	providerIDs map[string]string         // "id" attribute of managed instances → node ID
+	dataSources map[string][]dataInstance // data source address → its instances
+	outputs     map[string]stateOutput    // output value → resource that produced it
+	names       map[string]string         // resource name (e.g. "prod-vpc") → node ID
+}
+
+// stateOutput is an output value that identifies a managed resource, e.g. an
+// output exporting aws_vpc.main.id.
+type stateOutput struct {
+	name   string // output name
+	nodeID string // node of the resource whose id, arn or self_link it is
+}
+
+// dataInstance is one instance of a data source.
+type dataInstance struct {
+	providerID string // "id" attribute returned by the lookup
+	nodeID     string // data_source node used when no managed resource matches
+}
+
+func newRefMap() *refMap {
+	return &refMap{
+		nodeIDs:     make(map[string][]string),
+		providerIDs: make(map[string]string),
+		dataSources: make(map[string][]dataInstance),
+		outputs:     make(map[string]stateOutput),
+		names:       make(map[string]string),
+	}
+}
+
+// merge adds the references of other to m, overwriting duplicates.
+func (m *refMap) merge(other *refMap) {
+	for k, v := range other.nodeIDs {
+		m.nodeIDs[k] = v
+	}
+	for k, v := range other.providerIDs {
+		m.providerIDs[k] = v
+	}
+	for k, v := range other.dataSources {
+		m.dataSources[k] = v
+	}
+	for k, v := range other.outputs {
+		m.outputs[k] = v
+	}
+	for k, v := range other.names {
+		m.names[k] = v
+	}
+}
+
+// resolve returns the node IDs a reference such as "aws_vpc.main",
+// "data.aws_vpc.main" or "module.net.aws_vpc.main" points at.
+func (m *refMap) resolve(ref string) []string {
+	if ids, ok := m.nodeIDs[ref]; ok {
+		return ids
+	}
+	var ids []string
+	for _, d := range m.dataSources[ref] {
+		id, _ := m.resolveData(d)
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// resolveData returns the managed node a data source instance looked up, or
+// its own data_source node ID. The boolean reports whether a managed node
+// matched.
+func (m *refMap) resolveData(d dataInstance) (string, bool) {
+	if d.providerID != "" {
+		if id, ok := m.providerIDs[d.providerID]; ok {
+			return id, true
+		}
+	}
+	return d.nodeID, false
+}
+
 // buildRefMap performs the first pass over a state file: builds a mapping
-// from TF block names (e.g. "google_compute_network.prod_vpc") to node IDs
-// (e.g. "tf:network:prod-vpc").
-func buildRefMap(data []byte) (map[string]string, error) {
+// from resource addresses (e.g. "google_compute_network.prod_vpc") to node
+// IDs (e.g. "tf:network:prod-vpc"), and from resource names to node IDs for
+// attribute references. Resources expanded with count or for_each
+// map to the node IDs of all their instances. Data sources are recorded
+// with the provider ID they returned so they can be matched to the managed
+// resource they looked up. Outputs exporting a resource's id, arn or
+// self_link are recorded so stacks reading them via terraform_remote_state
+// can be linked to that resource.
+func buildRefMap(data []byte) (*refMap, error) {
 	var state tfState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, fmt.Errorf("parsing JSON: %w", err)
 	}
 
-	refToNodeID := make(map[string]string)
+	refs := newRefMap()
+	identifiers := make(map[string]string) // id/arn/self_link → node ID, this state only
 	for _, res := range state.Resources {
 		if res.Mode == "data" {
+			ref := dataAddress(res)
+			for _, inst := range res.Instances {
+				refs.dataSources[ref] = append(refs.dataSources[ref], newDataInstance(res, inst))
+			}
 			continue
 		}
 		assetType := mapResourceType(res.Type)
 		if assetType == "" {
 			continue
 		}
+		ref := resourceAddress(res)
+		for _, inst := range res.Instances {
+			if inst.Deposed != "" {
+				continue // references always mean the current object
+			}
+			nodeID, _ := instanceNodeID(assetType, res, inst)
+			refs.nodeIDs[ref] = append(refs.nodeIDs[ref], nodeID)
+			if name := instanceName(res, inst); refs.names[name] == "" {
+				refs.names[name] = nodeID
+			}
+			if id, ok := inst.Attributes["id"].(string); ok && id != "" {
+				refs.providerIDs[id] = nodeID
+			}
+			for _, key := range []string{"id", "arn", "self_link"} {
+				if v, ok := inst.Attributes[key].(string); ok && v != "" {
+					identifiers[v] = nodeID
+				}
+			}
+		}
+	}
+
+	for name, out := range state.Outputs {
+		for _, v := range stringValues(out.Value) {
+			if nodeID, ok := identifiers[v]; ok {
+				refs.outputs[v] = stateOutput{name: name, nodeID: nodeID}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// remoteStateOutputs maps each terraform_remote_state data source address in
+// state to the string output values it read, keyed by value.
+func remoteStateOutputs(state tfState) map[string]map[string]string {
+	remote := make(map[string]map[string]string)
+	for _, res := range state.Resources {
+		if res.Mode != "data" || res.Type != "terraform_remote_state" {
+			continue
+		}
+		ref := dataAddress(res)
 		for _, inst := range res.Instances {
-			nodeID := fmt.Sprintf("tf:%s:%s", assetType, res.Name)
-			if n, ok := inst.Attributes["name"].(string); ok && n != "" {
-				nodeID = fmt.Sprintf("tf:%s:%s", assetType, n)
+			outputs, _ := inst.Attributes["outputs"].(map[string]any)
+			// Outputs are stored as a dynamic value: {"value": {...}, "type": ...}.
+			if v, ok := outputs["value"].(map[string]any); ok {
+				outputs = v
+			}
+			for name, val := range outputs {
+				for _, s := range stringValues(val) {
+					if remote[ref] == nil {
+						remote[ref] = make(map[string]string)
+					}
+					remote[ref][s] = name
+				}
 			}
-			ref := res.Type + "." + res.Name
-			refToNodeID[ref] = nodeID
 		}
 	}
-	return refToNodeID, nil
+	return remote
+}
+
+// stringValues returns the strings in v, which may be a string or a nested
+// list or map of them.
+func stringValues(v any) []string {
+	switch val := v.(type) {
+	case string:
+		if val != "" {
+			return []string{val}
+		}
+	case []any:
+		var out []string
+		for _, item := range val {
+			out = append(out, stringValues(item)...)
+		}
+		return out
+	case map[string]any:
+		var out []string
+		for _, item := range val {
+			out = append(out, stringValues(item)...)
+		}
+		return out
+	}
+	return nil
+}
+
+func newDataInstance(res tfResource, inst tfInstance) dataInstance {
+	suffix, _ := instanceKeySuffix(inst)
+	name := qualifiedName(res.Module, res.Type+"."+res.Name)
+	d := dataInstance{nodeID: fmt.Sprintf("tf:%s:%s%s", models.AssetDataSource, name, suffix)}
+	d.providerID, _ = inst.Attributes["id"].(string)
+	return d
+}
+
+// instanceNodeID returns the node ID and display name of a resource
+// instance. Instances of count/for_each resources get their index key
+// appended (e.g. "tf:vm:web[0]"); single-instance resources have no suffix.
+// Resources inside a module get the module path prepended
+// (e.g. "tf:network:module.net.main"). Deposed objects, which share their
+// address with the current object, get a ":deposed:<key>" suffix.
+func instanceNodeID(assetType models.AssetType, res tfResource, inst tfInstance) (string, string) {
+	name := instanceName(res, inst)
+	suffix, _ := instanceKeySuffix(inst)
+	nodeID := fmt.Sprintf("tf:%s:%s%s", assetType, qualifiedName(res.Module, name), suffix)
+	if name == res.Name {
+		name += suffix
+	}
+	if inst.Deposed != "" {
+		nodeID += ":deposed:" + inst.Deposed
+		name += " (deposed)"
+	}
+	return nodeID, name
+}
+
+// instanceName returns the name attribute of a resource instance, or the
+// resource's block name when it has none.
+func instanceName(res tfResource, inst tfInstance) string {
+	if n, ok := inst.Attributes["name"].(string); ok && n != "" {
+		return n
+	}
+	return res.Name
+}
+
+// instanceStatus returns the tf_status of an instance: "tainted",
+// "deposed", or empty for a healthy current object.
+func instanceStatus(inst tfInstance) string {
+	if inst.Deposed != "" {
+		return "deposed"
+	}
+	return inst.Status
+}
+
+// instanceKeySuffix formats an instance's index_key as a node ID suffix
+// ("[0]", "[eu]") and as a Terraform address suffix ("[0]", "[\"eu\"]").
+// Both are empty for resources without count or for_each.
+func instanceKeySuffix(inst tfInstance) (string, string) {
+	switch k := inst.IndexKey.(type) {
+	case float64:
+		s := fmt.Sprintf("[%d]", int64(k))
+		return s, s
+	case string:
+		return "[" + k + "]", fmt.Sprintf("[%q]", k)
+	}
+	return "", ""
 }
 
 // parseStateBytesWithRefs performs the second pass: creates nodes and edges
-// using the provided refToNodeID map (which may span multiple state files).
-func parseStateBytesWithRefs(data []byte, sourcePath string, refToNodeID map[string]string) (*parser.ParseResult, error) {
+// using the provided refs (which may span multiple state files). Data sources
+// that did not resolve to a managed resource become data_source nodes.
+func parseStateBytesWithRefs(data []byte, sourcePath string, refs *refMap) (*parser.ParseResult, error) {
 	var state tfState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, fmt.Errorf("parsing JSON: %w", err)
@@ -204,9 +436,12 @@ func parseStateBytesWithRefs(data []byte, sourcePath string, refToNodeID map[str
 	result := &parser.ParseResult{}
 	now := time.Now()
 	edgeSet := make(map[string]bool)
+	modules := make(map[string]bool)
+	remoteOutputs := remoteStateOutputs(state)
 
 	for _, res := range state.Resources {
 		if res.Mode == "data" {
+			addDataSourceNodes(res, sourcePath, now, refs, result)
 			continue
 		}
 
@@ -217,14 +452,15 @@ func parseStateBytesWithRefs(data []byte, sourcePath string, refToNodeID map[str
 		}
 
 		provider := extractProvider(res.Provider)
+		address := resourceAddress(res)
+		moduleID := ""
+		if res.Module != "" {
+			moduleID = addModuleNodes(res.Module, sourcePath, now, result, modules, edgeSet)
+		}
 
 		for _, inst := range res.Instances {
-			nodeID := fmt.Sprintf("tf:%s:%s", assetType, res.Name)
-			name := res.Name
-			if n, ok := inst.Attributes["name"].(string); ok && n != "" {
-				name = n
-				nodeID = fmt.Sprintf("tf:%s:%s", assetType, n)
-			}
+			nodeID, name := instanceNodeID(assetType, res, inst)
+			_, addrSuffix := instanceKeySuffix(inst)
 
 			node := models.Node{
 				ID:         nodeID,
@@ -237,42 +473,205 @@ func parseStateBytesWithRefs(data []byte, sourcePath string, refToNodeID map[str
 				LastSeen:   now,
 				FirstSeen:  now,
 			}
-
-			if assetType == models.AssetCertificate {
-				if exp, ok := inst.Attributes["not_after"].(string); ok {
-					if t, err := time.Parse(time.RFC3339, exp); err == nil {
-						node.ExpiresAt = &t
-					}
-				}
+			node.Metadata["tf_address"] = address + addrSuffix
+			if status := instanceStatus(inst); status != "" {
+				node.Metadata["tf_status"] = status
+			}
+			if inst.Deposed != "" {
+				node.Metadata["tf_deposed_key"] = inst.Deposed
 			}
 
+			node.ExpiresAt = expiryAttribute(inst.Attributes)
+
 			result.Nodes = append(result.Nodes, node)
 
 			for _, dep := range inst.Dependencies {
-				depNodeID, ok := refToNodeID[dep]
-				if !ok {
-					continue
-				}
-				edgeID := fmt.Sprintf("%s->depends_on->%s", nodeID, depNodeID)
-				if edgeSet[edgeID] {
-					continue
+				for _, depNodeID := range refs.resolve(dep) {
+					edgeID := fmt.Sprintf("%s->depends_on->%s", nodeID, depNodeID)
+					if edgeSet[edgeID] {
+						continue
+					}
+					edgeSet[edgeID] = true
+					result.Edges = append(result.Edges, models.Edge{
+						ID:     edgeID,
+						FromID: nodeID,
+						ToID:   depNodeID,
+						Type:   models.EdgeDependsOn,
+						Metadata: map[string]string{
+							"source":    "tfstate_dependency",
+							"reference": dep,
+						},
+					})
 				}
-				edgeSet[edgeID] = true
-				result.Edges = append(result.Edges, models.Edge{
-					ID:     edgeID,
-					FromID: nodeID,
-					ToID:   depNodeID,
-					Type:   models.EdgeDependsOn,
-					Metadata: map[string]string{
-						"source":    "tfstate_dependency",
-						"reference": dep,
-					},
-				})
 			}
 
-			createAttributeEdges(nodeID, res.Type, inst.Attributes, result, refToNodeID, edgeSet)
+			addRemoteOutputEdges(nodeID, inst, remoteOutputs, refs, result, edgeSet)
+
+			if moduleID != "" {
+				addEdgeOnce(result, edgeSet, nodeID, moduleID, models.EdgeMemberOf)
+			}
+
+			createAttributeEdges(nodeID, res.Type, inst.Attributes, result, refs.names, edgeSet)
 		}
 	}
 
 	return result, nil
 }
+
+// addRemoteOutputEdges links an instance to the resources behind the
+// terraform_remote_state outputs it uses. An output counts as used when the
+// instance depends on the remote state and one of its attributes holds the
+// output's value.
+func addRemoteOutputEdges(nodeID string, inst tfInstance, remoteOutputs map[string]map[string]string, refs *refMap, result *parser.ParseResult, edgeSet map[string]bool) {
+	var values map[string]bool
+	for _, dep := range inst.Dependencies {
+		read, ok := remoteOutputs[dep]
+		if !ok {
+			continue
+		}
+		if values == nil {
+			values = make(map[string]bool)
+			for _, v := range stringValues(map[string]any(inst.Attributes)) {
+				values[v] = true
+			}
+		}
+		for value, outputName := range read {
+			out, ok := refs.outputs[value]
+			if !ok || !values[value] || out.nodeID == nodeID {
+				continue
+			}
+			edgeID := fmt.Sprintf("%s->depends_on->%s", nodeID, out.nodeID)
+			if edgeSet[edgeID] {
+				continue
+			}
+			edgeSet[edgeID] = true
+			result.Edges = append(result.Edges, models.Edge{
+				ID:     edgeID,
+				FromID: nodeID,
+				ToID:   out.nodeID,
+				Type:   models.EdgeDependsOn,
+				Metadata: map[string]string{
+					"source":    "tfstate_remote_output",
+					"reference": dep + ".outputs." + outputName,
+				},
+			})
+		}
+	}
+}
+
+// expiryAttributes are the resource attributes that hold an expiry date:
+// certificates' not_after, Google Secret Manager's expire_time and the
+// expiration_date of Azure Key Vault keys and secrets.
+var expiryAttributes = []string{"not_after", "expire_time", "expiration_date"}
+
+// expiryAttribute returns the expiry date recorded in attrs, or nil if the
+// resource has none.
+func expiryAttribute(attrs map[string]any) *time.Time {
+	for _, key := range expiryAttributes {
+		if v, ok := attrs[key].(string); ok {
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
+// resourceAddress returns the full address of the managed resource res,
+// e.g. "aws_vpc.main" or "module.net.aws_vpc.main", which is how
+// dependencies refer to it.
+func resourceAddress(res tfResource) string {
+	return qualifiedName(res.Module, res.Type+"."+res.Name)
+}
+
+// dataAddress returns the full address of the data source res, e.g.
+// "data.aws_vpc.main" or "module.net.data.aws_vpc.main".
+func dataAddress(res tfResource) string {
+	return qualifiedName(res.Module, "data."+res.Type+"."+res.Name)
+}
+
+// qualifiedName prefixes name with module, the path of the module declaring
+// it, so same-named resources in different modules stay distinct. Root
+// module names are returned unchanged.
+func qualifiedName(module, name string) string {
+	if module == "" {
+		return name
+	}
+	return module + "." + name
+}
+
+// addDataSourceNodes creates a data_source node for each instance of the data
+// source res that did not resolve to a managed resource.
+func addDataSourceNodes(res tfResource, sourcePath string, now time.Time, refs *refMap, result *parser.ParseResult) {
+	address := dataAddress(res)
+	for _, inst := range res.Instances {
+		nodeID, managed := refs.resolveData(newDataInstance(res, inst))
+		if managed {
+			continue
+		}
+		_, addrSuffix := instanceKeySuffix(inst)
+		meta := extractMetadata(res.Type, inst.Attributes)
+		meta["tf_address"] = address + addrSuffix
+		result.Nodes = append(result.Nodes, models.Node{
+			ID:         nodeID,
+			Name:       res.Type + "." + res.Name,
+			Type:       models.AssetDataSource,
+			Source:     "terraform",
+			SourceFile: sourcePath,
+			Provider:   extractProvider(res.Provider),
+			Metadata:   meta,
+			LastSeen:   now,
+			FirstSeen:  now,
+		})
+	}
+}
+
+// addModuleNodes creates a module node for modulePath (e.g.
+// "module.app.module.db") and for each of its ancestors, linking every
+// parent module to its child with a contains edge. It returns the node ID of
+// the innermost module. modules tracks the module nodes already created.
+func addModuleNodes(modulePath, sourcePath string, now time.Time, result *parser.ParseResult, modules, edgeSet map[string]bool) string {
+	parentID := ""
+	path := ""
+	for _, seg := range strings.Split(strings.TrimPrefix(modulePath, "module."), ".module.") {
+		if path == "" {
+			path = "module." + seg
+		} else {
+			path += ".module." + seg
+		}
+		nodeID := "tf:module:" + path
+		if !modules[nodeID] {
+			modules[nodeID] = true
+			result.Nodes = append(result.Nodes, models.Node{
+				ID:         nodeID,
+				Name:       path,
+				Type:       models.AssetModule,
+				Source:     "terraform",
+				SourceFile: sourcePath,
+				Metadata:   map[string]string{"tf_address": path, "module_name": seg},
+				LastSeen:   now,
+				FirstSeen:  now,
+			})
+		}
+		if parentID != "" {
+			addEdgeOnce(result, edgeSet, parentID, nodeID, models.EdgeContains)
+		}
+		parentID = nodeID
+	}
+	return parentID
+}
+
+// addEdgeOnce appends an edge of the given type unless edgeSet already has it.
+func addEdgeOnce(result *parser.ParseResult, edgeSet map[string]bool, fromID, toID string, edgeType models.EdgeType) {
+	edgeID := fmt.Sprintf("%s->%s->%s", fromID, edgeType, toID)
+	if edgeSet[edgeID] {
+		return
+	}
+	edgeSet[edgeID] = true
+	result.Edges = append(result.Edges, models.Edge{
+		ID:     edgeID,
+		FromID: fromID,
+		ToID:   toID,
+		Type:   edgeType,
+	})
+}
@@ -12,8 +12,11 @@ import (
 	"github.com/matijazezelj/aib/pkg/models"
 )
 
-// StateParser parses Terraform .tfstate files.
-type StateParser struct{}
+// StateParser parses Terraform .tfstate files. Filter limits which state
+// files are read when walking a directory.
+type StateParser struct {
+	Filter parser.PathFilter
+}
 
 // NewStateParser creates a new Terraform state parser.
 func NewStateParser() *StateParser {
@@ -67,12 +70,12 @@ func (p *StateParser) ParseMulti(ctx context.Context, paths []string) (*parser.P
 		}
 
 		if info.IsDir() {
-			_ = filepath.WalkDir(resolved, func(p string, d fs.DirEntry, err error) error {
+			_ = filepath.WalkDir(resolved, func(file string, d fs.DirEntry, err error) error {
 				if err != nil {
 					return nil
 				}
-				if !d.IsDir() && strings.HasSuffix(p, ".tfstate") {
-					stateFiles = append(stateFiles, p)
+				if !d.IsDir() && strings.HasSuffix(file, ".tfstate") && p.Filter.Allows(resolved, file) {
+					stateFiles = append(stateFiles, file)
 				}
 				return nil
 			})
@@ -84,12 +87,13 @@ func (p *StateParser) ParseMulti(ctx context.Context, paths []string) (*parser.P
 	result := &parser.ParseResult{}
 
 	// Phase 1: read all files and build a global ref map across all state files.
-	globalRefMap := make(map[string]string)
+	globalRefMap := newRefMap()
 	stateData := make(map[string][]byte)
 	for _, sf := range stateFiles {
 		data, err := os.ReadFile(sf) // #nosec G304 -- paths validated by SafeResolvePath
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("reading %s: %v", sf, err))
+			result.PathsFailed++
 			continue
 		}
 		stateData[sf] = data
@@ -98,9 +102,7 @@ func (p *StateParser) ParseMulti(ctx context.Context, paths []string) (*parser.P
 			result.Warnings = append(result.Warnings, fmt.Sprintf("building ref map for %s: %v", sf, err))
 			continue
 		}
-		for k, v := range refs {
-			globalRefMap[k] = v
-		}
+		globalRefMap.merge(refs)
 	}
 
 	// Phase 2: parse each file using the global ref map for cross-state resolution.
@@ -112,8 +114,10 @@ func (p *StateParser) ParseMulti(ctx context.Context, paths []string) (*parser.P
 		r, err := parseStateBytesWithRefs(data, sf, globalRefMap)
 		if err != nil {
 			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to parse %s: %v", sf, err))
+			result.PathsFailed++
 			continue
 		}
+		result.PathsScanned++
 		result.Nodes = append(result.Nodes, r.Nodes...)
 		result.Edges = append(result.Edges, r.Edges...)
 		result.Warnings = append(result.Warnings, r.Warnings...)
@@ -124,8 +128,16 @@ func (p *StateParser) ParseMulti(ctx context.Context, paths []string) (*parser.P
 
 // tfState represents the top-level Terraform state file structure.
 type tfState struct {
-	Version   int          `json:"version"`
-	Resources []tfResource `json:"resources"`
+	Version   int                 `json:"version"`
+	Outputs   map[string]tfOutput `json:"outputs"`
+	Resources []tfResource        `json:"resources"`
+}
+
+// tfOutput is a root module output value, readable by other stacks through
+// a terraform_remote_state data source.
+type tfOutput struct {
+	Value     any  `json:"value"`
+	Sensitive bool `json:"sensitive"`
 }
 
 // tfResource represents a single resource block in a Terraform state file.
@@ -140,11 +152,20 @@ type tfResource struct {
 
 // tfInstance represents a single instance of a Terraform resource.
 type tfInstance struct {
-	Attributes    map[string]any `json:"attributes"`
-	Dependencies  []string       `json:"dependencies"`
+	IndexKey     any            `json:"index_key"`
+	Status       string         `json:"status"`  // "tainted" when marked for replacement
+	Deposed      string         `json:"deposed"` // deposed object key; empty for current objects
+	Attributes   map[string]any `json:"attributes"`
+	Dependencies []string       `json:"dependencies"`
 }
 
+// mapResourceType returns the asset type of a Terraform resource type, or
+// "" for types AIB does not track. Mappings set with SetTypeMappings take
+// precedence over the built-in ones.
 func mapResourceType(tfType string) models.AssetType {
+	if t, ok := overrideType(tfType); ok {
+		return t
+	}
 	mapping := map[string]models.AssetType{
 		// GCP
 		"google_compute_instance":         models.AssetVM,
@@ -276,6 +297,8 @@ func mapResourceType(tfType string) models.AssetType {
 		"aws_apigatewayv2_api":            models.AssetAPIGateway,
 		"aws_dynamodb_table":              models.AssetNoSQLDB,
 		"aws_secretsmanager_secret":       models.AssetSecret,
+		"google_secret_manager_secret":    models.AssetSecret,
+		"azurerm_key_vault_secret":        models.AssetSecret,
 		// GCP Serverless
 		"google_cloudfunctions_function":   models.AssetFunction,
 		"google_cloudfunctions2_function":  models.AssetFunction,
@@ -314,7 +337,7 @@ func extractMetadata(resourceType string, attrs map[string]any) map[string]strin
 
 	stringKeys := []string{
 		"region", "zone", "location", "machine_type", "instance_type",
-		"image", "ami", "arn", "self_link", "project",
+		"id", "image", "ami", "arn", "self_link", "project",
 		"network", "subnetwork", "ip_address", "private_ip",
 		"public_ip", "network_ip", "nat_ip",
 		// Security: encryption & access
@@ -385,6 +408,46 @@ func extractMetadata(resourceType string, attrs map[string]any) map[string]strin
 		meta["logging"] = fmt.Sprintf("%t", len(logging) > 0)
 	}
 
+	// DNS targets and the addresses they can point at, so graph.ResolveDNS
+	// can link records to IPs and load balancers.
+	switch mapResourceType(resourceType) {
+	case models.AssetDNSRecord:
+		var records []string
+		for _, key := range []string{"records", "rrdatas"} {
+			if vals, ok := attrs[key].([]any); ok {
+				for _, v := range vals {
+					if s, ok := v.(string); ok && s != "" {
+						records = append(records, s)
+					}
+				}
+			}
+		}
+		for _, key := range []string{"content", "value"} {
+			if s, ok := attrs[key].(string); ok && s != "" {
+				records = append(records, s)
+			}
+		}
+		if len(records) > 0 {
+			meta["records"] = strings.Join(records, ",")
+		}
+		if alias, ok := attrs["alias"].([]any); ok && len(alias) > 0 {
+			if a, ok := alias[0].(map[string]any); ok {
+				if name, ok := a["name"].(string); ok && name != "" {
+					meta["alias_target"] = name
+				}
+			}
+		}
+		if fqdn, ok := attrs["fqdn"].(string); ok && fqdn != "" {
+			meta["fqdn"] = fqdn
+		}
+	case models.AssetIPAddress, models.AssetLoadBalancer:
+		for _, key := range []string{"address", "dns_name"} {
+			if s, ok := attrs[key].(string); ok && s != "" {
+				meta[key] = s
+			}
+		}
+	}
+
 	if tags, ok := attrs["tags"].(map[string]any); ok {
 		for k, v := range tags {
 			meta["tag:"+k] = fmt.Sprintf("%v", v)
@@ -402,17 +465,13 @@ func extractMetadata(resourceType string, attrs map[string]any) map[string]strin
 	return meta
 }
 
-func createAttributeEdges(nodeID string, resourceType string, attrs map[string]any, result *parser.ParseResult, refToNodeID map[string]string, edgeSet map[string]bool) {
+// createAttributeEdges adds connects_to edges for attributes that name
+// another resource. names maps resource names to node IDs.
+func createAttributeEdges(nodeID string, resourceType string, attrs map[string]any, result *parser.ParseResult, names map[string]string, edgeSet map[string]bool) {
 	// Helper: try to resolve a resource path/name to a known node ID.
 	// Returns "" if the target node is not found in the current state.
 	resolveTarget := func(attrVal string) string {
-		name := lastSegment(attrVal)
-		for _, nid := range refToNodeID {
-			if strings.HasSuffix(nid, ":"+name) {
-				return nid
-			}
-		}
-		return ""
+		return names[lastSegment(attrVal)]
 	}
 
 	addEdge := func(targetID, via, rawValue string) {
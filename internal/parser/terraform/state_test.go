@@ -2,9 +2,12 @@ package terraform
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/matijazezelj/aib/internal/parser"
 	"github.com/matijazezelj/aib/pkg/models"
@@ -121,6 +124,9 @@ func TestParseStateFile_Metadata(t *testing.T) {
 			if n.Metadata["tf_type"] != "google_compute_instance" {
 				t.Errorf("tf_type = %q, want google_compute_instance", n.Metadata["tf_type"])
 			}
+			if n.Metadata["tf_address"] != "google_compute_instance.web" {
+				t.Errorf("tf_address = %q, want google_compute_instance.web", n.Metadata["tf_address"])
+			}
 			if n.Provider != "google" {
 				t.Errorf("provider = %q, want google", n.Provider)
 			}
@@ -262,6 +268,26 @@ func TestExtractMetadata(t *testing.T) {
 	}
 }
 
+func TestExtractMetadata_DNSTargets(t *testing.T) {
+	meta := extractMetadata("aws_route53_record", map[string]any{
+		"name":    "www",
+		"fqdn":    "www.example.com",
+		"records": []any{"203.0.113.10", "203.0.113.11"},
+		"alias":   []any{map[string]any{"name": "web-lb.elb.amazonaws.com", "zone_id": "Z1"}},
+	})
+	if meta["records"] != "203.0.113.10,203.0.113.11" {
+		t.Errorf("records = %q", meta["records"])
+	}
+	if meta["alias_target"] != "web-lb.elb.amazonaws.com" || meta["fqdn"] != "www.example.com" {
+		t.Errorf("meta = %v", meta)
+	}
+
+	meta = extractMetadata("google_compute_address", map[string]any{"address": "34.1.2.3"})
+	if meta["address"] != "34.1.2.3" {
+		t.Errorf("address = %q", meta["address"])
+	}
+}
+
 func TestParseStateBytes_InvalidJSON(t *testing.T) {
 	_, err := parseStateBytesForTest([]byte("{invalid"), "test.tfstate")
 	if err == nil {
@@ -375,7 +401,7 @@ func TestParseMulti_SeparateFiles(t *testing.T) {
 	}
 }
 
-func TestParseStateBytes_DataResourceSkipped(t *testing.T) {
+func TestParseStateBytes_DataResourceNode(t *testing.T) {
 	state := `{
 		"version": 4,
 		"resources": [{
@@ -383,7 +409,7 @@ func TestParseStateBytes_DataResourceSkipped(t *testing.T) {
 			"type": "google_compute_instance",
 			"name": "lookup",
 			"provider": "provider[\"registry.terraform.io/hashicorp/google\"]",
-			"instances": [{"attributes": {"name": "lookup-vm"}, "dependencies": []}]
+			"instances": [{"attributes": {"id": "projects/p/zones/z/instances/lookup-vm", "name": "lookup-vm"}, "dependencies": []}]
 		}]
 	}`
 
@@ -391,8 +417,273 @@ func TestParseStateBytes_DataResourceSkipped(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(result.Nodes) != 0 {
-		t.Errorf("data resources should be skipped, got %d nodes", len(result.Nodes))
+	if len(result.Nodes) != 1 {
+		t.Fatalf("expected 1 data source node, got %d", len(result.Nodes))
+	}
+	n := result.Nodes[0]
+	if n.ID != "tf:data_source:google_compute_instance.lookup" || n.Type != models.AssetDataSource {
+		t.Errorf("data source node = %s (%s)", n.ID, n.Type)
+	}
+	if n.Metadata["tf_address"] != "data.google_compute_instance.lookup" {
+		t.Errorf("tf_address = %q", n.Metadata["tf_address"])
+	}
+}
+
+func TestParseStateBytes_DataSourceDependencies(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"instances": [{"attributes": {"id": "vpc-123"}}]
+			},
+			{
+				"mode": "data",
+				"type": "aws_vpc",
+				"name": "shared",
+				"instances": [{"attributes": {"id": "vpc-123"}}]
+			},
+			{
+				"mode": "data",
+				"type": "aws_ami",
+				"name": "ubuntu",
+				"instances": [{"attributes": {"id": "ami-456"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{
+					"attributes": {"id": "i-789"},
+					"dependencies": ["data.aws_vpc.shared", "data.aws_ami.ubuntu"]
+				}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataNodes := 0
+	for _, n := range result.Nodes {
+		if n.Type == models.AssetDataSource {
+			dataNodes++
+			if n.ID != "tf:data_source:aws_ami.ubuntu" {
+				t.Errorf("unexpected data source node %s", n.ID)
+			}
+		}
+	}
+	if dataNodes != 1 {
+		t.Errorf("expected 1 data source node (the VPC lookup resolves to the managed VPC), got %d", dataNodes)
+	}
+
+	edges := make(map[string]string)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeDependsOn {
+			edges[e.FromID+"->"+e.ToID] = e.Metadata["reference"]
+		}
+	}
+	if edges["tf:vm:web->tf:network:main"] != "data.aws_vpc.shared" {
+		t.Error("missing depends_on edge from web to the managed VPC its data source looked up")
+	}
+	if edges["tf:vm:web->tf:data_source:aws_ami.ubuntu"] != "data.aws_ami.ubuntu" {
+		t.Error("missing depends_on edge from web to the unresolved data source")
+	}
+}
+
+func TestParseStateBytes_ModuleReferences(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "data",
+				"type": "aws_ami",
+				"name": "base",
+				"instances": [{"attributes": {"id": "ami-root"}}]
+			},
+			{
+				"module": "module.app",
+				"mode": "data",
+				"type": "aws_ami",
+				"name": "base",
+				"instances": [{"attributes": {"id": "ami-app"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_security_group",
+				"name": "web",
+				"instances": [{"attributes": {"id": "sg-root"}}]
+			},
+			{
+				"module": "module.app",
+				"mode": "managed",
+				"type": "aws_security_group",
+				"name": "web",
+				"instances": [{"attributes": {"id": "sg-app"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "bastion",
+				"instances": [{
+					"attributes": {"id": "i-1"},
+					"dependencies": ["data.aws_ami.base", "aws_security_group.web"]
+				}]
+			},
+			{
+				"module": "module.app",
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{
+					"attributes": {"id": "i-2"},
+					"dependencies": ["module.app.data.aws_ami.base", "module.app.aws_security_group.web"]
+				}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addresses := make(map[string]string)
+	for _, n := range result.Nodes {
+		if n.Type == models.AssetDataSource {
+			addresses[n.ID] = n.Metadata["tf_address"]
+		}
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("expected 2 data source nodes, got %v", addresses)
+	}
+	if addresses["tf:data_source:aws_ami.base"] != "data.aws_ami.base" {
+		t.Errorf("root data source: got %v", addresses)
+	}
+	if addresses["tf:data_source:module.app.aws_ami.base"] != "module.app.data.aws_ami.base" {
+		t.Errorf("module data source: got %v", addresses)
+	}
+
+	edges := make(map[string]bool)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeDependsOn {
+			edges[e.FromID+"->"+e.ToID] = true
+		}
+	}
+	want := []string{
+		"tf:vm:bastion->tf:data_source:aws_ami.base",
+		"tf:vm:bastion->tf:firewall_rule:web",
+		"tf:vm:module.app.web->tf:data_source:module.app.aws_ami.base",
+		"tf:vm:module.app.web->tf:firewall_rule:module.app.web",
+	}
+	for _, w := range want {
+		if !edges[w] {
+			t.Errorf("missing depends_on edge %s", w)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("expected %d depends_on edges, got %v", len(want), edges)
+	}
+}
+
+func TestParseMulti_RemoteStateOutputs(t *testing.T) {
+	result, err := NewStateParser().Parse(context.Background(), "testdata/remote-outputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges := make(map[string]string)
+	for _, e := range result.Edges {
+		if e.Metadata["source"] == "tfstate_remote_output" {
+			edges[e.FromID+"->"+e.ToID] = e.Metadata["reference"]
+		}
+	}
+
+	want := map[string]string{
+		"tf:vm:api->tf:subnet:private[1]":          "data.terraform_remote_state.network.outputs.private_subnet_ids",
+		"tf:firewall_rule:api-sg->tf:network:main": "data.terraform_remote_state.network.outputs.vpc_id",
+	}
+	for edge, ref := range want {
+		if edges[edge] != ref {
+			t.Errorf("edge %s: reference = %q, want %q", edge, edges[edge], ref)
+		}
+	}
+	if len(edges) != len(want) {
+		t.Errorf("remote output edges = %v, want %v", edges, want)
+	}
+}
+
+func TestParseStateFile_TaintedStatus(t *testing.T) {
+	result, err := NewStateParser().Parse(context.Background(), "testdata/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range result.Nodes {
+		status, ok := n.Metadata["tf_status"]
+		if n.ID == "tf:database:cloudsql-prod" {
+			if status != "tainted" {
+				t.Errorf("tf_status = %q, want tainted", status)
+			}
+		} else if ok {
+			t.Errorf("%s: unexpected tf_status %q", n.ID, status)
+		}
+	}
+}
+
+func TestParseStateBytes_DeposedInstance(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [
+					{"attributes": {"id": "i-new"}},
+					{"deposed": "0a1b2c3d", "attributes": {"id": "i-old"}}
+				]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_lb",
+				"name": "front",
+				"instances": [{"attributes": {"id": "lb-1"}, "dependencies": ["aws_instance.web"]}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		nodes[n.ID] = n
+	}
+	if _, ok := nodes["tf:vm:web"].Metadata["tf_status"]; ok {
+		t.Error("current object should have no tf_status")
+	}
+	deposed, ok := nodes["tf:vm:web:deposed:0a1b2c3d"]
+	if !ok {
+		t.Fatalf("missing deposed node, got %v", nodes)
+	}
+	if deposed.Metadata["tf_status"] != "deposed" || deposed.Metadata["tf_deposed_key"] != "0a1b2c3d" {
+		t.Errorf("deposed metadata = %v", deposed.Metadata)
+	}
+	if deposed.Metadata["tf_address"] != "aws_instance.web" {
+		t.Errorf("tf_address = %q, want aws_instance.web", deposed.Metadata["tf_address"])
+	}
+
+	for _, e := range result.Edges {
+		if e.ToID == deposed.ID {
+			t.Errorf("references should resolve to the current object, got edge %s", e.ID)
+		}
 	}
 }
 
@@ -464,6 +755,45 @@ func TestParseMulti_NonexistentPath(t *testing.T) {
 	}
 }
 
+func TestParseStateBytes_SecretExpiresAt(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "managed", "type": "google_secret_manager_secret", "name": "api",
+				"provider": "provider[\"registry.terraform.io/hashicorp/google\"]",
+				"instances": [{"attributes": {"id": "projects/p/secrets/api", "expire_time": "2030-01-02T03:04:05.123456Z"}}]
+			},
+			{
+				"mode": "managed", "type": "azurerm_key_vault_key", "name": "signing",
+				"provider": "provider[\"registry.terraform.io/hashicorp/azurerm\"]",
+				"instances": [{"attributes": {"id": "https://vault/keys/signing", "expiration_date": "2031-06-01T00:00:00Z"}}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"tf:secret:api":      "2030-01-02T03:04:05Z",
+		"tf:kms_key:signing": "2031-06-01T00:00:00Z",
+	}
+	for _, n := range result.Nodes {
+		if n.ExpiresAt == nil {
+			t.Errorf("%s has no ExpiresAt", n.ID)
+			continue
+		}
+		if got := n.ExpiresAt.Truncate(time.Second).Format(time.RFC3339); got != want[n.ID] {
+			t.Errorf("%s ExpiresAt = %s, want %s", n.ID, got, want[n.ID])
+		}
+	}
+	if len(result.Nodes) != len(want) {
+		t.Errorf("got %d nodes, want %d", len(result.Nodes), len(want))
+	}
+}
+
 func TestParseStateCert_ExpiresAt(t *testing.T) {
 	p := NewStateParser()
 	result, err := p.Parse(context.Background(), "testdata/cert.tfstate")
@@ -663,3 +993,216 @@ func parseStateBytesForTest(data []byte, sourcePath string) (*parser.ParseResult
 	}
 	return parseStateBytesWithRefs(data, sourcePath, refs)
 }
+
+func TestParseStateFile_CountExpansion(t *testing.T) {
+	result, err := NewStateParser().Parse(context.Background(), "testdata/count.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		if _, dup := nodes[n.ID]; dup {
+			t.Errorf("duplicate node ID %s", n.ID)
+		}
+		nodes[n.ID] = n
+	}
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("tf:vm:web[%d]", i)
+		n, ok := nodes[id]
+		if !ok {
+			t.Errorf("missing node %s", id)
+			continue
+		}
+		if want := fmt.Sprintf("aws_instance.web[%d]", i); n.Metadata["tf_address"] != want {
+			t.Errorf("%s tf_address = %q, want %q", id, n.Metadata["tf_address"], want)
+		}
+	}
+	// Single-instance resources keep unsuffixed IDs.
+	if _, ok := nodes["tf:network:main"]; !ok {
+		t.Error("missing tf:network:main")
+	}
+	if n, ok := nodes["tf:queue:jobs[eu]"]; !ok {
+		t.Error("missing for_each node tf:queue:jobs[eu]")
+	} else if n.Metadata["tf_address"] != `aws_sqs_queue.jobs["eu"]` {
+		t.Errorf("for_each tf_address = %q", n.Metadata["tf_address"])
+	}
+
+	edges := make(map[string]bool)
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeDependsOn {
+			edges[e.FromID+"->"+e.ToID] = true
+		}
+	}
+	for i := 0; i < 3; i++ {
+		web := fmt.Sprintf("tf:vm:web[%d]", i)
+		if !edges[web+"->tf:network:main"] {
+			t.Errorf("missing depends_on edge %s -> tf:network:main", web)
+		}
+		if !edges["tf:load_balancer:front->"+web] {
+			t.Errorf("missing depends_on edge tf:load_balancer:front -> %s", web)
+		}
+	}
+	if len(edges) != 6 {
+		t.Errorf("expected 6 depends_on edges, got %d", len(edges))
+	}
+}
+
+func TestParseStateBytes_AttributeEdgesToIndexedInstances(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"mode": "managed",
+				"type": "google_compute_subnetwork",
+				"name": "zone",
+				"instances": [
+					{"index_key": "a", "attributes": {"name": "subnet-a"}},
+					{"index_key": "b", "attributes": {"name": "subnet-b"}}
+				]
+			},
+			{
+				"mode": "managed",
+				"type": "google_compute_instance",
+				"name": "web",
+				"instances": [{
+					"attributes": {
+						"name": "web",
+						"subnetwork": "projects/p/regions/r/subnetworks/subnet-b"
+					}
+				}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var targets []string
+	for _, e := range result.Edges {
+		if e.Type == models.EdgeConnectsTo && e.FromID == "tf:vm:web" {
+			targets = append(targets, e.ToID)
+		}
+	}
+	if len(targets) != 1 || targets[0] != "tf:subnet:subnet-b[b]" {
+		t.Errorf("connects_to targets = %v, want [tf:subnet:subnet-b[b]]", targets)
+	}
+}
+
+func TestParseStateFile_Modules(t *testing.T) {
+	result, err := NewStateParser().Parse(context.Background(), "testdata/modules.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modules := make(map[string]models.Node)
+	for _, n := range result.Nodes {
+		if n.Type == models.AssetModule {
+			if _, dup := modules[n.ID]; dup {
+				t.Errorf("duplicate module node %s", n.ID)
+			}
+			modules[n.ID] = n
+		}
+	}
+	for _, id := range []string{"tf:module:module.network", "tf:module:module.app", "tf:module:module.app.module.db"} {
+		if _, ok := modules[id]; !ok {
+			t.Errorf("missing module node %s", id)
+		}
+	}
+	if len(modules) != 3 {
+		t.Errorf("expected 3 module nodes, got %d", len(modules))
+	}
+	if got := modules["tf:module:module.app.module.db"].Metadata["module_name"]; got != "db" {
+		t.Errorf("module_name = %q, want db", got)
+	}
+
+	edges := make(map[string]models.EdgeType)
+	for _, e := range result.Edges {
+		edges[e.FromID+"->"+e.ToID] = e.Type
+	}
+	want := map[string]models.EdgeType{
+		"tf:network:module.network.prod-vpc->tf:module:module.network":            models.EdgeMemberOf,
+		"tf:vm:module.app.web-1->tf:module:module.app":                            models.EdgeMemberOf,
+		"tf:database:module.app.module.db.app-db->tf:module:module.app.module.db": models.EdgeMemberOf,
+		"tf:module:module.app->tf:module:module.app.module.db":                    models.EdgeContains,
+	}
+	for k, typ := range want {
+		if edges[k] != typ {
+			t.Errorf("edge %s = %q, want %q", k, edges[k], typ)
+		}
+	}
+	for k := range edges {
+		if strings.HasPrefix(k, "tf:bucket:logs->") {
+			t.Errorf("root module resource should not get a module edge: %s", k)
+		}
+	}
+}
+
+func TestParseStateBytes_SameResourceInTwoModules(t *testing.T) {
+	state := `{
+		"version": 4,
+		"resources": [
+			{
+				"module": "module.net",
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"instances": [{"attributes": {"id": "vpc-1"}}]
+			},
+			{
+				"module": "module.other",
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"instances": [{"attributes": {"id": "vpc-2"}}]
+			},
+			{
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"instances": [{
+					"attributes": {"id": "i-1"},
+					"dependencies": ["module.net.aws_vpc.main"]
+				}]
+			}
+		]
+	}`
+
+	result, err := parseStateBytesForTest([]byte(state), "test.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := make(map[string]bool)
+	for _, n := range result.Nodes {
+		if ids[n.ID] {
+			t.Errorf("duplicate node ID %s", n.ID)
+		}
+		ids[n.ID] = true
+	}
+	for _, id := range []string{"tf:network:module.net.main", "tf:network:module.other.main", "tf:vm:web"} {
+		if !ids[id] {
+			t.Errorf("missing node %s, got %v", id, ids)
+		}
+	}
+
+	edges := make(map[string]models.EdgeType)
+	for _, e := range result.Edges {
+		edges[e.FromID+"->"+e.ToID] = e.Type
+	}
+	want := map[string]models.EdgeType{
+		"tf:network:module.net.main->tf:module:module.net":     models.EdgeMemberOf,
+		"tf:network:module.other.main->tf:module:module.other": models.EdgeMemberOf,
+		"tf:vm:web->tf:network:module.net.main":                models.EdgeDependsOn,
+	}
+	for k, typ := range want {
+		if edges[k] != typ {
+			t.Errorf("edge %s = %q, want %q", k, edges[k], typ)
+		}
+	}
+	if _, ok := edges["tf:vm:web->tf:network:module.other.main"]; ok {
+		t.Error("web should depend only on the VPC in module.net")
+	}
+}
@@ -0,0 +1,51 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/alert"
+	"github.com/matijazezelj/aib/internal/graph"
+)
+
+// changeEvents turns a scan's drift into asset_discovered and asset_removed
+// events. The first scan of a source reports nothing: every node would be
+// "new", which is noise rather than news.
+func changeEvents(drift *graph.DriftSummary, source string) []alert.Event {
+	if drift == nil || drift.IsInitial {
+		return nil
+	}
+	now := time.Now()
+	event := func(eventType, severity, verb string, n graph.NodeChange) alert.Event {
+		return alert.Event{
+			Source:    "aib",
+			EventType: eventType,
+			Severity:  severity,
+			Asset:     alert.Asset{ID: n.ID, Name: n.Name, Type: n.Type},
+			Message:   fmt.Sprintf("%s %s %s in %s scan", n.Type, n.Name, verb, source),
+			Timestamp: now,
+		}
+	}
+
+	events := make([]alert.Event, 0, len(drift.NodesAdded)+len(drift.NodesRemoved))
+	for _, n := range drift.NodesAdded {
+		events = append(events, event("asset_discovered", alert.SeverityInfo, "discovered", n))
+	}
+	for _, n := range drift.NodesRemoved {
+		events = append(events, event("asset_removed", alert.SeverityWarning, "removed", n))
+	}
+	return events
+}
+
+// alertChanges sends change events for a completed scan when
+// alerts.on_change is enabled and an alerter is set.
+func (s *Scanner) alertChanges(ctx context.Context, drift *graph.DriftSummary, source string) {
+	if s.alerter == nil || !s.cfg.Alerts.OnChange {
+		return
+	}
+	events := changeEvents(drift, source)
+	if err := alert.SendAll(ctx, s.alerter, events); err != nil {
+		s.logger.Warn("failed to send change alerts", "events", len(events), "error", err)
+	}
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/matijazezelj/aib/internal/alert"
+)
+
+type recordingAlerter struct {
+	mu     sync.Mutex
+	events []alert.Event
+}
+
+func (r *recordingAlerter) Name() string { return "recording" }
+
+func (r *recordingAlerter) Send(_ context.Context, e alert.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recordingAlerter) ofType(eventType string) []alert.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []alert.Event
+	for _, e := range r.events {
+		if e.EventType == eventType {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestRunSync_AlertsOnDiscoveredAssets(t *testing.T) {
+	sc, _ := newTestScanner(t)
+	sc.cfg.Alerts.OnChange = true
+	rec := &recordingAlerter{}
+	sc.SetAlerter(rec)
+
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	scan := func(compose string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(compose), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if r := sc.RunSync(context.Background(), ScanRequest{Source: "compose", Paths: []string{path}}); r.Error != nil {
+			t.Fatalf("RunSync: %v", r.Error)
+		}
+	}
+
+	scan("services:\n  web:\n    image: nginx\n")
+	if len(rec.events) != 0 {
+		t.Fatalf("initial scan sent %d events, want 0", len(rec.events))
+	}
+
+	scan("services:\n  web:\n    image: nginx\n  worker:\n    image: nginx\n")
+	discovered := rec.ofType("asset_discovered")
+	if len(discovered) != 1 {
+		t.Fatalf("got %d discovery events, want 1: %+v", len(discovered), rec.events)
+	}
+	if discovered[0].Asset.Name != "worker" || discovered[0].Severity != alert.SeverityInfo {
+		t.Errorf("discovered = %+v, want worker at info", discovered[0])
+	}
+
+	scan("services:\n  web:\n    image: nginx\n")
+	if removed := rec.ofType("asset_removed"); len(removed) != 1 {
+		t.Errorf("got %d removal events, want 1", len(removed))
+	}
+}
+
+func TestRunSync_NoChangeAlertsWhenDisabled(t *testing.T) {
+	sc, _ := newTestScanner(t)
+	rec := &recordingAlerter{}
+	sc.SetAlerter(rec)
+
+	path := filepath.Join(t.TempDir(), "docker-compose.yml")
+	for _, compose := range []string{
+		"services:\n  web:\n    image: nginx\n",
+		"services:\n  web:\n    image: nginx\n  worker:\n    image: nginx\n",
+	} {
+		if err := os.WriteFile(path, []byte(compose), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		sc.RunSync(context.Background(), ScanRequest{Source: "compose", Paths: []string{path}})
+	}
+	if len(rec.events) != 0 {
+		t.Errorf("sent %d events with alerts.on_change off, want 0", len(rec.events))
+	}
+}
@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"context"
-	"sort"
 
 	"github.com/matijazezelj/aib/internal/graph"
 	"github.com/matijazezelj/aib/internal/parser"
@@ -58,7 +57,7 @@ func computeDrift(ctx context.Context, store *graph.SQLiteStore, result *parser.
 			continue
 		}
 		// Check for modifications
-		changes := compareNode(old, n)
+		changes := graph.NodeChanges(old, n)
 		if len(changes) > 0 {
 			summary.NodesModified = append(summary.NodesModified, graph.NodeModification{
 				ID: n.ID, Name: n.Name, Changes: changes,
@@ -120,45 +119,3 @@ func computeDrift(ctx context.Context, store *graph.SQLiteStore, result *parser.
 
 	return summary, nil
 }
-
-// compareNode detects differences between an old and new version of a node.
-func compareNode(old, new models.Node) []string {
-	var changes []string
-
-	if old.Name != new.Name {
-		changes = append(changes, "name")
-	}
-	if old.Type != new.Type {
-		changes = append(changes, "type")
-	}
-
-	// Compare metadata
-	metaChanges := compareMetadata(old.Metadata, new.Metadata)
-	changes = append(changes, metaChanges...)
-
-	return changes
-}
-
-// compareMetadata detects changed, added, and removed metadata keys.
-func compareMetadata(old, new map[string]string) []string {
-	var changes []string
-
-	// Check for changed or removed keys
-	for k, v := range old {
-		if newV, ok := new[k]; !ok {
-			changes = append(changes, "metadata."+k)
-		} else if v != newV {
-			changes = append(changes, "metadata."+k)
-		}
-	}
-
-	// Check for added keys
-	for k := range new {
-		if _, ok := old[k]; !ok {
-			changes = append(changes, "metadata."+k)
-		}
-	}
-
-	sort.Strings(changes)
-	return changes
-}
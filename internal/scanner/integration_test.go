@@ -66,7 +66,7 @@ func TestIntegration_Terraform_ScanQueryExport(t *testing.T) {
 	}
 
 	// Step 4: BlastRadius on first node
-	br, err := engine.BlastRadius(ctx, nodes[0].ID)
+	br, err := engine.BlastRadius(ctx, nodes[0].ID, graph.ImpactOptions{})
 	if err != nil {
 		t.Fatalf("BlastRadius error: %v", err)
 	}
@@ -75,7 +75,7 @@ func TestIntegration_Terraform_ScanQueryExport(t *testing.T) {
 	}
 
 	// Step 5: Export JSON
-	jsonOut, err := graph.ExportJSON(ctx, store)
+	jsonOut, err := graph.ExportJSON(ctx, store, graph.ExportOptions{})
 	if err != nil {
 		t.Fatalf("ExportJSON error: %v", err)
 	}
@@ -88,7 +88,7 @@ func TestIntegration_Terraform_ScanQueryExport(t *testing.T) {
 	}
 
 	// Step 6: Export DOT
-	dotOut, err := graph.ExportDOT(ctx, store)
+	dotOut, err := graph.ExportDOT(ctx, store, graph.ExportOptions{})
 	if err != nil {
 		t.Fatalf("ExportDOT error: %v", err)
 	}
@@ -97,7 +97,7 @@ func TestIntegration_Terraform_ScanQueryExport(t *testing.T) {
 	}
 
 	// Step 7: Export Mermaid
-	mermaidOut, err := graph.ExportMermaid(ctx, store)
+	mermaidOut, err := graph.ExportMermaid(ctx, store, graph.ExportOptions{})
 	if err != nil {
 		t.Fatalf("ExportMermaid error: %v", err)
 	}
@@ -179,7 +179,7 @@ func TestIntegration_Ansible_ScanAndQuery(t *testing.T) {
 	// Dependency chain on first node
 	nodes, _ := store.ListNodes(ctx, graph.NodeFilter{})
 	if len(nodes) > 0 {
-		deps, err := engine.DependencyChain(ctx, nodes[0].ID, 10)
+		deps, err := engine.DependencyChain(ctx, nodes[0].ID, 10, "")
 		if err != nil {
 			t.Fatalf("DependencyChain error: %v", err)
 		}
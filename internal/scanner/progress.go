@@ -0,0 +1,123 @@
+package scanner
+
+import "sync"
+
+// Progress event types, in the order an async scan emits them. A scan ends
+// with exactly one of EventCompleted, EventFailed or EventCancelled.
+const (
+	EventStarted   = "started"
+	EventNodes     = "nodes_discovered"
+	EventEdges     = "edges_discovered"
+	EventCompleted = "completed"
+	EventFailed    = "failed"
+	EventCancelled = "cancelled"
+)
+
+// maxScanEvents bounds the events of one scan; subscriber channels are
+// buffered to hold them all, so publishing never blocks.
+const maxScanEvents = 8
+
+// keepFinishedScans is how many finished scans keep their events for late
+// subscribers.
+const keepFinishedScans = 100
+
+// ProgressEvent reports one stage of an async scan.
+type ProgressEvent struct {
+	ScanID int64  `json:"scan_id"`
+	Type   string `json:"type"`
+	Nodes  int    `json:"nodes,omitempty"`
+	Edges  int    `json:"edges,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// Completed lists the sources of a cancelled "all" scan whose results
+	// were stored before the cancel.
+	Completed []string `json:"completed,omitempty"`
+}
+
+// progress fans scan events out to subscribers. Each scan's events are kept
+// so a subscriber that arrives late, even after the scan finished, still
+// receives all of them.
+type progress struct {
+	mu       sync.Mutex
+	scans    map[int64]*scanProgress
+	finished []int64 // oldest first, for eviction
+}
+
+type scanProgress struct {
+	events []ProgressEvent
+	subs   []chan ProgressEvent
+	done   bool
+}
+
+func newProgress() *progress {
+	return &progress{scans: make(map[int64]*scanProgress)}
+}
+
+// publish records ev and sends it to the scan's subscribers. A completed,
+// failed or cancelled event closes their channels.
+func (p *progress) publish(ev ProgressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp := p.scans[ev.ScanID]
+	if sp == nil {
+		sp = &scanProgress{}
+		p.scans[ev.ScanID] = sp
+	}
+	if sp.done || len(sp.events) >= maxScanEvents {
+		return
+	}
+	sp.events = append(sp.events, ev)
+	for _, ch := range sp.subs {
+		ch <- ev
+	}
+
+	if ev.Type != EventCompleted && ev.Type != EventFailed && ev.Type != EventCancelled {
+		return
+	}
+	sp.done = true
+	for _, ch := range sp.subs {
+		close(ch)
+	}
+	sp.subs = nil
+	p.finished = append(p.finished, ev.ScanID)
+	if len(p.finished) > keepFinishedScans {
+		delete(p.scans, p.finished[0])
+		p.finished = p.finished[1:]
+	}
+}
+
+// subscribe returns a channel replaying the scan's events so far and then
+// delivering new ones; it is closed once the scan ends. ok is false if the
+// scan is unknown. unsubscribe must be called if the caller stops reading
+// before the channel is closed.
+func (p *progress) subscribe(scanID int64) (events <-chan ProgressEvent, unsubscribe func(), ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp := p.scans[scanID]
+	if sp == nil {
+		return nil, nil, false
+	}
+	ch := make(chan ProgressEvent, maxScanEvents)
+	for _, ev := range sp.events {
+		ch <- ev
+	}
+	if sp.done {
+		close(ch)
+		return ch, func() {}, true
+	}
+	sp.subs = append(sp.subs, ch)
+
+	unsubscribe = func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for i, c := range sp.subs {
+			if c == ch {
+				sp.subs = append(sp.subs[:i], sp.subs[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, unsubscribe, true
+}
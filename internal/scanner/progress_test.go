@@ -0,0 +1,62 @@
+package scanner
+
+import "testing"
+
+func drain(ch <-chan ProgressEvent) []string {
+	var types []string
+	for ev := range ch {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestProgress_LateSubscriberReplays(t *testing.T) {
+	p := newProgress()
+	if _, _, ok := p.subscribe(1); ok {
+		t.Fatal("subscribe to unknown scan should fail")
+	}
+
+	p.publish(ProgressEvent{ScanID: 1, Type: EventStarted})
+	live, _, ok := p.subscribe(1)
+	if !ok {
+		t.Fatal("subscribe to started scan failed")
+	}
+	p.publish(ProgressEvent{ScanID: 1, Type: EventNodes, Nodes: 3})
+	p.publish(ProgressEvent{ScanID: 1, Type: EventCompleted, Nodes: 3})
+	p.publish(ProgressEvent{ScanID: 1, Type: EventFailed}) // ignored after the end
+
+	want := []string{EventStarted, EventNodes, EventCompleted}
+	if got := drain(live); len(got) != 3 || got[2] != EventCompleted {
+		t.Errorf("live subscriber got %v, want %v", got, want)
+	}
+
+	late, _, ok := p.subscribe(1)
+	if !ok {
+		t.Fatal("finished scan should still be subscribable")
+	}
+	if got := drain(late); len(got) != 3 || got[0] != EventStarted {
+		t.Errorf("late subscriber got %v, want %v", got, want)
+	}
+}
+
+func TestProgress_UnsubscribeAndEviction(t *testing.T) {
+	p := newProgress()
+	p.publish(ProgressEvent{ScanID: 1, Type: EventStarted})
+	_, unsubscribe, _ := p.subscribe(1)
+	unsubscribe()
+	if n := len(p.scans[1].subs); n != 0 {
+		t.Errorf("subscribers after unsubscribe = %d, want 0", n)
+	}
+	// Publishing to a scan nobody reads must not block.
+	p.publish(ProgressEvent{ScanID: 1, Type: EventFailed, Error: "boom"})
+
+	for id := int64(2); id <= keepFinishedScans+1; id++ {
+		p.publish(ProgressEvent{ScanID: id, Type: EventCompleted})
+	}
+	if _, _, ok := p.subscribe(1); ok {
+		t.Error("oldest finished scan should have been evicted")
+	}
+	if _, _, ok := p.subscribe(keepFinishedScans + 1); !ok {
+		t.Error("newest finished scan should be kept")
+	}
+}
@@ -2,91 +2,304 @@ package scanner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/matijazezelj/aib/internal/alert"
 	"github.com/matijazezelj/aib/internal/config"
 	"github.com/matijazezelj/aib/internal/graph"
 	"github.com/matijazezelj/aib/internal/parser"
 	"github.com/matijazezelj/aib/internal/parser/ansible"
+	awsparser "github.com/matijazezelj/aib/internal/parser/aws"
 	"github.com/matijazezelj/aib/internal/parser/cloudformation"
 	"github.com/matijazezelj/aib/internal/parser/compose"
+	"github.com/matijazezelj/aib/internal/parser/gcp"
 	"github.com/matijazezelj/aib/internal/parser/kubernetes"
 	"github.com/matijazezelj/aib/internal/parser/pulumi"
 	"github.com/matijazezelj/aib/internal/parser/terraform"
+	"github.com/matijazezelj/aib/pkg/models"
 )
 
-// ScanRequest describes a scan to execute.
+// ScanRequest describes a scan to execute. It is persisted as JSON with
+// each scan record so the scan can be replayed later.
 type ScanRequest struct {
-	Source string // "terraform", "kubernetes", "kubernetes-live", "ansible"
-	Paths  []string
+	Source string   `json:"source"` // "terraform", "kubernetes", "kubernetes-live", "ansible", "aws", ...
+	Paths  []string `json:"paths,omitempty"`
 
 	// Terraform-specific
-	Remote    bool
-	Workspace string
+	Remote        bool     `json:"remote,omitempty"`
+	Workspace     string   `json:"workspace,omitempty"`
+	Backends      []string `json:"backends,omitempty"`       // s3://, gs:// or azurerm:// state URLs read without the terraform CLI
+	BackendRegion string   `json:"backend_region,omitempty"` // region for S3 backends
 
 	// Kubernetes-specific
-	Helm       bool
-	ValuesFile string
-	Kubeconfig string   // for live K8s
-	Context    string   // for live K8s
-	Namespaces []string // for live K8s (empty = all non-system)
+	Helm            bool     `json:"helm,omitempty"`
+	ValuesFile      string   `json:"values_file,omitempty"`
+	Kubeconfig      string   `json:"kubeconfig,omitempty"`       // for live K8s
+	Context         string   `json:"context,omitempty"`          // for live K8s
+	Namespaces      []string `json:"namespaces,omitempty"`       // for live K8s (empty = all non-system)
+	CustomResources []string `json:"custom_resources,omitempty"` // for live K8s: CRD names/globs or kinds to pull
 
 	// Ansible-specific
-	Playbooks string
+	Playbooks string `json:"playbooks,omitempty"`
+
+	// AWS-specific: Regions to discover (empty = the SDK's configured
+	// region) and the shared config profile to authenticate with.
+	Regions []string `json:"regions,omitempty"`
+	Profile string   `json:"profile,omitempty"`
+
+	// GCP-specific: the project to discover and an optional service
+	// account key file (empty = Application Default Credentials).
+	Project     string `json:"project,omitempty"`
+	Credentials string `json:"credentials,omitempty"`
+
+	// Compose-specific: Merge layers Paths into one project, later files
+	// overriding earlier ones, instead of scanning each separately.
+	Merge bool `json:"merge,omitempty"`
+
+	// Strict fails the scan, storing nothing, if any input path fails.
+	Strict bool `json:"strict,omitempty"`
+
+	// DryRun parses the sources and computes drift but writes nothing: no
+	// nodes or edges are stored and no scan is recorded. RunSync only.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Include and Exclude are globs selecting which files the Terraform,
+	// Kubernetes and Compose directory walks parse; ExcludeTypes drops
+	// nodes of the given asset types. They add to the scan config's lists.
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	ExcludeTypes []string `json:"exclude_types,omitempty"`
+
+	// Timeout is a Go duration bounding how long the parser may run,
+	// overriding scan.timeout. Scans of all configured sources apply it
+	// to each source.
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// ErrScanNotFound is returned by ReplayRequest when no scan has the given ID.
+var ErrScanNotFound = errors.New("scan not found")
+
+// ErrPartialScan is returned for strict scans in which some input paths failed.
+var ErrPartialScan = errors.New("some scan paths failed")
+
+// ErrDryRunAsync is returned by RunAsync for dry-run requests, whose
+// results only RunSync can return.
+var ErrDryRunAsync = errors.New("dry-run scans must run synchronously")
+
+// ErrScanTimeout is returned when a scan runs longer than its timeout.
+var ErrScanTimeout = errors.New("scan timed out")
+
+// ErrNotReplayable is returned by ReplayRequest for scans recorded without
+// their request parameters (scans from older versions).
+var ErrNotReplayable = errors.New("scan has no stored request to replay")
+
+// ReplayRequest reconstructs the request that produced the given scan.
+func (s *Scanner) ReplayRequest(ctx context.Context, scanID int64) (ScanRequest, error) {
+	sc, err := s.store.GetScan(ctx, scanID)
+	if err != nil {
+		return ScanRequest{}, fmt.Errorf("loading scan %d: %w", scanID, err)
+	}
+	if sc == nil {
+		return ScanRequest{}, ErrScanNotFound
+	}
+	if len(sc.Request) == 0 {
+		return ScanRequest{}, ErrNotReplayable
+	}
+	var req ScanRequest
+	if err := json.Unmarshal(sc.Request, &req); err != nil {
+		return ScanRequest{}, fmt.Errorf("decoding request for scan %d: %w", scanID, err)
+	}
+	return req, nil
+}
+
+// recordScan inserts a running scan record for req, including the encoded
+// request so it can be replayed.
+func (s *Scanner) recordScan(ctx context.Context, req ScanRequest, sourcePath string) (int64, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return 0, fmt.Errorf("encoding scan request: %w", err)
+	}
+	return s.store.RecordScan(ctx, graph.Scan{
+		Source:     req.Source,
+		SourcePath: sourcePath,
+		StartedAt:  time.Now(),
+		Status:     "running",
+		Request:    raw,
+	})
 }
 
 // ScanResult is returned after a scan completes.
 type ScanResult struct {
-	ScanID     int64
-	NodesFound int
-	EdgesFound int
-	Warnings   []string
-	Error      error
-	Drift      *graph.DriftSummary
+	ScanID       int64
+	NodesFound   int
+	EdgesFound   int
+	PathsScanned int
+	PathsFailed  int
+	Warnings     []string
+	Error        error
+	Drift        *graph.DriftSummary
+
+	// NodesSwept counts stored nodes the scan no longer found and, per
+	// scan.prune_missing, marked stale or deleted.
+	NodesSwept int
+
+	// Request is the request RunAll ran for this source. Other scans
+	// leave it unset.
+	Request ScanRequest
+
+	// DryRun is set for dry-run scans, which also return the parsed nodes
+	// and edges that a real scan would have stored.
+	DryRun bool
+	Nodes  []models.Node
+	Edges  []models.Edge
 }
 
 // Scanner orchestrates infrastructure scans.
 type Scanner struct {
-	store   *graph.SQLiteStore
-	logger  *slog.Logger
-	cfg     *config.Config
-	mu      sync.Mutex
-	running map[int64]context.CancelFunc
+	store    *graph.SQLiteStore
+	logger   *slog.Logger
+	cfg      *config.Config
+	mu       sync.Mutex
+	running  map[int64]context.CancelFunc
+	progress *progress
+	alerter  alert.Alerter      // optional, for alerts.on_change
+	resolver graph.HostResolver // optional, for live DNS lookups
+
+	// dispatch runs the parser for a request; tests replace it.
+	dispatch func(ctx context.Context, req ScanRequest) (*parser.ParseResult, error)
 }
 
 // New creates a Scanner.
 func New(store *graph.SQLiteStore, cfg *config.Config, logger *slog.Logger) *Scanner {
-	return &Scanner{
-		store:   store,
-		logger:  logger,
-		cfg:     cfg,
-		running: make(map[int64]context.CancelFunc),
+	s := &Scanner{
+		store:    store,
+		logger:   logger,
+		cfg:      cfg,
+		running:  make(map[int64]context.CancelFunc),
+		progress: newProgress(),
+	}
+	s.dispatch = s.dispatchScan
+	return s
+}
+
+// SetAlerter sets where asset change events go when alerts.on_change is
+// enabled.
+func (s *Scanner) SetAlerter(a alert.Alerter) {
+	s.alerter = a
+}
+
+// SetDNSResolver enables live lookups of DNS record names after each scan,
+// in addition to matching the targets declared in the records.
+func (s *Scanner) SetDNSResolver(r graph.HostResolver) {
+	s.resolver = r
+}
+
+// resolveDNS links DNS records to the addresses they point at. Failures
+// are logged; they never fail the scan.
+func (s *Scanner) resolveDNS(ctx context.Context, scanID int64) {
+	summary, err := graph.ResolveDNS(ctx, s.store, s.resolver)
+	if err != nil {
+		s.logger.Warn("failed to resolve DNS records", "scanID", scanID, "error", err)
+	} else if summary.EdgesAdded > 0 {
+		s.logger.Info("resolved DNS records", "scanID", scanID, "records", summary.Records, "edges_added", summary.EdgesAdded)
+	}
+}
+
+// groupAssets links assets to their provider's and region's nodes when
+// scan.group_by_provider and scan.group_by_region are set. Like DNS
+// resolution, failures are logged and never fail the scan.
+func (s *Scanner) groupAssets(ctx context.Context, scanID int64) {
+	for _, g := range []struct {
+		enabled bool
+		by      string
+		group   func(context.Context, *graph.SQLiteStore) (*graph.GroupSummary, error)
+	}{
+		{s.cfg.Scan.GroupByProvider, "provider", graph.GroupByProvider},
+		{s.cfg.Scan.GroupByRegion, "region", graph.GroupByRegion},
+	} {
+		if !g.enabled {
+			continue
+		}
+		summary, err := g.group(ctx, s.store)
+		if err != nil {
+			s.logger.Warn("failed to group assets", "by", g.by, "scanID", scanID, "error", err)
+		} else if summary.EdgesAdded > 0 || summary.EdgesRemoved > 0 {
+			s.logger.Info("grouped assets", "by", g.by, "scanID", scanID, "groups", summary.Groups,
+				"edges_added", summary.EdgesAdded, "edges_removed", summary.EdgesRemoved)
+		}
+	}
+}
+
+// applyEdgeRules infers the edges of scan.edge_rules. Failures, including
+// invalid rules, are logged and never fail the scan.
+func (s *Scanner) applyEdgeRules(ctx context.Context, scanID int64) {
+	if len(s.cfg.Scan.EdgeRules) == 0 {
+		return
+	}
+	rules := make([]graph.EdgeRule, len(s.cfg.Scan.EdgeRules))
+	for i, r := range s.cfg.Scan.EdgeRules {
+		rules[i] = graph.EdgeRule{Name: r.Name, From: r.From, To: r.To, Key: r.Key, ToKey: r.ToKey, Edge: models.EdgeType(r.Edge)}
+	}
+	summary, err := graph.ApplyRules(ctx, s.store, rules)
+	if err != nil {
+		s.logger.Warn("failed to apply edge rules", "scanID", scanID, "error", err)
+	} else if summary.EdgesAdded > 0 || summary.EdgesRemoved > 0 {
+		s.logger.Info("applied edge rules", "scanID", scanID, "rules", summary.Rules,
+			"edges_added", summary.EdgesAdded, "edges_removed", summary.EdgesRemoved)
 	}
 }
 
 // RunSync executes a scan synchronously and returns the result.
 func (s *Scanner) RunSync(ctx context.Context, req ScanRequest) ScanResult {
-	sourcePath := strings.Join(req.Paths, ", ")
-	if req.Source == "kubernetes-live" {
-		sourcePath = "live-cluster"
+	ctx, span := startSpan(ctx, "scan", req)
+	r := s.runSync(ctx, req)
+	span.SetAttributes(
+		attrScanID.Int64(r.ScanID),
+		attrNodes.Int(r.NodesFound),
+		attrEdges.Int(r.EdgesFound),
+		attrNodesSwept.Int(r.NodesSwept),
+	)
+	endSpan(span, r.Error)
+	return r
+}
+
+func (s *Scanner) runSync(ctx context.Context, req ScanRequest) ScanResult {
+	if req.DryRun {
+		return s.dryRun(ctx, req)
 	}
 
-	scanID, _ := s.store.RecordScan(ctx, graph.Scan{
-		Source:     req.Source,
-		SourcePath: sourcePath,
-		StartedAt:  time.Now(),
-		Status:     "running",
-	})
+	scanID, _ := s.recordScan(ctx, req, scanSourcePath(req))
 
 	result, err := s.executeScan(ctx, req)
 	if err != nil {
 		_ = s.store.UpdateScan(ctx, scanID, "failed", 0, 0)
 		return ScanResult{ScanID: scanID, Error: err}
 	}
+	if err := checkStrict(req, result); err != nil {
+		_ = s.store.UpdateScan(ctx, scanID, "failed", 0, 0)
+		return ScanResult{
+			ScanID:       scanID,
+			PathsScanned: result.PathsScanned,
+			PathsFailed:  result.PathsFailed,
+			Warnings:     result.Warnings,
+			Error:        err,
+		}
+	}
+	// A parser that doesn't watch ctx may finish after a cancel; store
+	// nothing, so a cancelled "all" scan keeps only the sources it finished.
+	if err := ctx.Err(); err != nil {
+		_ = s.store.UpdateScan(context.WithoutCancel(ctx), scanID, "cancelled", 0, 0)
+		return ScanResult{ScanID: scanID, Error: err}
+	}
 
 	// Compute drift before upserting (compares new vs existing state)
 	drift, driftErr := computeDrift(ctx, s.store, result, req.Source)
@@ -105,6 +318,13 @@ func (s *Scanner) RunSync(ctx context.Context, req ScanRequest) ScanResult {
 	} else if summary.EdgesAdded > 0 {
 		s.logger.Info("correlated cross-source identities", "groups", summary.Groups, "edges_added", summary.EdgesAdded)
 	}
+	s.resolveDNS(ctx, scanID)
+	swept, err := s.sweepMissing(ctx, drift, result, req)
+	if err != nil {
+		s.logger.Warn("failed to sweep missing nodes", "error", err)
+	}
+	s.groupAssets(ctx, scanID)
+	s.applyEdgeRules(ctx, scanID)
 
 	// Persist drift summary
 	if drift != nil {
@@ -114,42 +334,104 @@ func (s *Scanner) RunSync(ctx context.Context, req ScanRequest) ScanResult {
 	}
 
 	_ = s.store.UpdateScan(ctx, scanID, "completed", len(result.Nodes), len(result.Edges))
+	s.alertChanges(ctx, drift, req.Source)
 
 	return ScanResult{
-		ScanID:     scanID,
-		NodesFound: len(result.Nodes),
-		EdgesFound: len(result.Edges),
-		Warnings:   result.Warnings,
-		Drift:      drift,
+		ScanID:       scanID,
+		NodesFound:   len(result.Nodes),
+		EdgesFound:   len(result.Edges),
+		PathsScanned: result.PathsScanned,
+		PathsFailed:  result.PathsFailed,
+		Warnings:     result.Warnings,
+		Drift:        drift,
+		NodesSwept:   swept,
 	}
 }
 
-// RunAsync launches a scan in a goroutine and returns the scan ID immediately.
-func (s *Scanner) RunAsync(ctx context.Context, req ScanRequest) (int64, error) {
-	sourcePath := strings.Join(req.Paths, ", ")
-	if req.Source == "kubernetes-live" {
-		sourcePath = "live-cluster"
+// dryRun parses req's sources and computes drift against the store without
+// writing to it.
+func (s *Scanner) dryRun(ctx context.Context, req ScanRequest) ScanResult {
+	result, err := s.executeScan(ctx, req)
+	if err == nil {
+		err = checkStrict(req, result)
 	}
-	if req.Source == "all" {
-		sourcePath = "all-configured"
+	if err != nil {
+		r := ScanResult{DryRun: true, Error: err}
+		if result != nil {
+			r.PathsScanned, r.PathsFailed, r.Warnings = result.PathsScanned, result.PathsFailed, result.Warnings
+		}
+		return r
 	}
 
-	scanID, err := s.store.RecordScan(ctx, graph.Scan{
-		Source:     req.Source,
-		SourcePath: sourcePath,
-		StartedAt:  time.Now(),
-		Status:     "running",
-	})
+	drift, driftErr := computeDrift(ctx, s.store, result, req.Source)
+	if driftErr != nil {
+		s.logger.Warn("failed to compute drift", "error", driftErr)
+	}
+	return ScanResult{
+		NodesFound:   len(result.Nodes),
+		EdgesFound:   len(result.Edges),
+		PathsScanned: result.PathsScanned,
+		PathsFailed:  result.PathsFailed,
+		Warnings:     result.Warnings,
+		Drift:        drift,
+		DryRun:       true,
+		Nodes:        result.Nodes,
+		Edges:        result.Edges,
+	}
+}
+
+// checkStrict returns ErrPartialScan if req is strict and any of its input
+// paths failed to parse.
+func checkStrict(req ScanRequest, result *parser.ParseResult) error {
+	if !req.Strict || result.PathsFailed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %d of %d path(s) failed", ErrPartialScan,
+		result.PathsFailed, result.PathsScanned+result.PathsFailed)
+}
+
+// scanSourcePath describes what req scans in its scan record.
+func scanSourcePath(req ScanRequest) string {
+	switch req.Source {
+	case "kubernetes-live":
+		return "live-cluster"
+	case "aws":
+		if len(req.Regions) == 0 {
+			return "aws-default-region"
+		}
+		return "aws:" + strings.Join(req.Regions, ",")
+	case "gcp":
+		return "gcp:" + req.Project
+	case "all":
+		return "all-configured"
+	}
+	return strings.Join(req.Paths, ", ")
+}
+
+// RunAsync launches a scan in a goroutine and returns the scan ID immediately.
+func (s *Scanner) RunAsync(ctx context.Context, req ScanRequest) (int64, error) {
+	if req.DryRun {
+		return 0, ErrDryRunAsync
+	}
+	scanID, err := s.recordScan(ctx, req, scanSourcePath(req))
 	if err != nil {
 		return 0, fmt.Errorf("recording scan: %w", err)
 	}
 
-	asyncCtx, cancel := context.WithCancel(context.Background())
+	// The scan outlives the request that started it, so its span is a new
+	// root linked to the request's.
+	spanCtx, span := startSpan(context.Background(), "scan", req,
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(attrScanID.Int64(scanID)))
+	asyncCtx, cancel := context.WithCancel(spanCtx)
 	s.mu.Lock()
 	s.running[scanID] = cancel
 	s.mu.Unlock()
+	s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventStarted})
 
 	go func() {
+		var scanErr error
+		defer func() { endSpan(span, scanErr) }()
 		defer cancel()
 		defer func() {
 			s.mu.Lock()
@@ -157,25 +439,65 @@ func (s *Scanner) RunAsync(ctx context.Context, req ScanRequest) (int64, error)
 			s.mu.Unlock()
 		}()
 
+		// Status updates must land after a cancel, and once the results are
+		// stored the scan counts as completed even if cancelled late.
+		finalCtx := context.WithoutCancel(asyncCtx)
+		cancelled := func(completed []string) {
+			_ = s.store.UpdateScan(finalCtx, scanID, "cancelled", 0, 0)
+			span.SetAttributes(attrStatus.String("cancelled"))
+			s.logger.Info("async scan cancelled", "scanID", scanID, "completed_sources", completed)
+			s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventCancelled, Completed: completed})
+		}
+
 		// "all" runs all configured sources
 		if req.Source == "all" {
-			results := s.RunAllConfigured(asyncCtx)
+			results := s.RunAll(asyncCtx, req)
+			if asyncCtx.Err() != nil {
+				// Sources that finished before the cancel keep their results.
+				var completed []string
+				for _, r := range results {
+					if r.Error == nil {
+						completed = append(completed, r.Request.Source+" "+scanSourcePath(r.Request))
+					}
+				}
+				cancelled(completed)
+				return
+			}
 			totalNodes, totalEdges := 0, 0
 			for _, r := range results {
 				totalNodes += r.NodesFound
 				totalEdges += r.EdgesFound
 			}
-			_ = s.store.UpdateScan(asyncCtx, scanID, "completed", totalNodes, totalEdges)
+			_ = s.store.UpdateScan(finalCtx, scanID, "completed", totalNodes, totalEdges)
+			span.SetAttributes(attrStatus.String("completed"), attrNodes.Int(totalNodes), attrEdges.Int(totalEdges))
 			s.logger.Info("async scan (all) completed", "scanID", scanID, "nodes", totalNodes, "edges", totalEdges)
+			s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventCompleted, Nodes: totalNodes, Edges: totalEdges})
 			return
 		}
 
+		failed := func(err error) {
+			scanErr = err
+			_ = s.store.UpdateScan(finalCtx, scanID, "failed", 0, 0)
+			span.SetAttributes(attrStatus.String("failed"))
+			s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventFailed, Error: err.Error()})
+		}
+
 		result, err := s.executeScan(asyncCtx, req)
+		if err == nil {
+			err = checkStrict(req, result)
+		}
+		// Parsers that don't watch ctx may finish anyway; drop their results.
+		if asyncCtx.Err() != nil {
+			cancelled(nil)
+			return
+		}
 		if err != nil {
 			s.logger.Error("async scan failed", "scanID", scanID, "error", err)
-			_ = s.store.UpdateScan(asyncCtx, scanID, "failed", 0, 0)
+			failed(err)
 			return
 		}
+		s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventNodes, Nodes: len(result.Nodes)})
+		s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventEdges, Edges: len(result.Edges)})
 
 		// Compute drift before upserting
 		drift, driftErr := computeDrift(asyncCtx, s.store, result, req.Source)
@@ -184,25 +506,38 @@ func (s *Scanner) RunAsync(ctx context.Context, req ScanRequest) (int64, error)
 		}
 
 		if err := s.store.UpsertBatch(asyncCtx, result.Nodes, result.Edges); err != nil {
+			if asyncCtx.Err() != nil {
+				cancelled(nil)
+				return
+			}
 			s.logger.Error("failed to store scan results", "scanID", scanID, "error", err)
-			_ = s.store.UpdateScan(asyncCtx, scanID, "failed", 0, 0)
+			failed(err)
 			return
 		}
-		if summary, err := graph.CorrelateIdentities(asyncCtx, s.store); err != nil {
+		if summary, err := graph.CorrelateIdentities(finalCtx, s.store); err != nil {
 			s.logger.Warn("failed to correlate cross-source identities", "scanID", scanID, "error", err)
 		} else if summary.EdgesAdded > 0 {
 			s.logger.Info("correlated cross-source identities", "scanID", scanID, "groups", summary.Groups, "edges_added", summary.EdgesAdded)
 		}
+		s.resolveDNS(finalCtx, scanID)
+		if _, err := s.sweepMissing(finalCtx, drift, result, req); err != nil {
+			s.logger.Warn("failed to sweep missing nodes", "scanID", scanID, "error", err)
+		}
+		s.groupAssets(finalCtx, scanID)
+		s.applyEdgeRules(finalCtx, scanID)
 
 		// Persist drift summary
 		if drift != nil {
-			if err := s.store.StoreDiff(asyncCtx, scanID, drift); err != nil {
+			if err := s.store.StoreDiff(finalCtx, scanID, drift); err != nil {
 				s.logger.Warn("failed to store drift", "scanID", scanID, "error", err)
 			}
 		}
 
-		_ = s.store.UpdateScan(asyncCtx, scanID, "completed", len(result.Nodes), len(result.Edges))
+		_ = s.store.UpdateScan(finalCtx, scanID, "completed", len(result.Nodes), len(result.Edges))
+		span.SetAttributes(attrStatus.String("completed"), attrNodes.Int(len(result.Nodes)), attrEdges.Int(len(result.Edges)))
+		s.alertChanges(finalCtx, drift, req.Source)
 		s.logger.Info("async scan completed", "scanID", scanID, "nodes", len(result.Nodes), "edges", len(result.Edges))
+		s.progress.publish(ProgressEvent{ScanID: scanID, Type: EventCompleted, Nodes: len(result.Nodes), Edges: len(result.Edges)})
 	}()
 
 	return scanID, nil
@@ -210,7 +545,42 @@ func (s *Scanner) RunAsync(ctx context.Context, req ScanRequest) (int64, error)
 
 // RunAllConfigured runs all scans defined in the config and returns results.
 func (s *Scanner) RunAllConfigured(ctx context.Context) []ScanResult {
+	return s.RunAll(ctx, ScanRequest{Source: "all"})
+}
+
+// RunAll runs every configured source as req, a request with source "all",
+// describes: its include and exclude globs, excluded types, strict flag and
+// timeout apply to each source. Each result carries its source's request.
+func (s *Scanner) RunAll(ctx context.Context, req ScanRequest) []ScanResult {
 	var results []ScanResult
+	for _, cs := range s.configuredScans() {
+		if ctx.Err() != nil {
+			break
+		}
+		sourceReq := cs.Request
+		sourceReq.Include, sourceReq.Exclude, sourceReq.ExcludeTypes = req.Include, req.Exclude, req.ExcludeTypes
+		sourceReq.Strict, sourceReq.Timeout = req.Strict, req.Timeout
+		r := s.RunSync(ctx, sourceReq)
+		r.Request = sourceReq
+		results = append(results, r)
+	}
+	return results
+}
+
+// configuredScan is a source from the config with the schedule it set, if
+// any.
+type configuredScan struct {
+	Request  ScanRequest
+	Schedule string
+}
+
+// configuredScans returns a request for every usable source in the config,
+// in config order.
+func (s *Scanner) configuredScans() []configuredScan {
+	var scans []configuredScan
+	add := func(schedule string, req ScanRequest) {
+		scans = append(scans, configuredScan{Request: req, Schedule: schedule})
+	}
 
 	for _, src := range s.cfg.Sources.Terraform {
 		paths := []string{}
@@ -223,30 +593,28 @@ func (s *Scanner) RunAllConfigured(ctx context.Context) []ScanResult {
 		if len(paths) == 0 {
 			continue
 		}
-		r := s.RunSync(ctx, ScanRequest{
+		add(src.Schedule, ScanRequest{
 			Source: "terraform",
 			Paths:  paths,
 		})
-		results = append(results, r)
 	}
 
 	for _, src := range s.cfg.Sources.Kubernetes {
 		if src.Live || (src.Kubeconfig != "" && src.Path == "") {
-			r := s.RunSync(ctx, ScanRequest{
-				Source:     "kubernetes-live",
-				Kubeconfig: src.Kubeconfig,
-				Context:    src.Context,
-				Namespaces: src.Namespaces,
+			add(src.Schedule, ScanRequest{
+				Source:          "kubernetes-live",
+				Kubeconfig:      src.Kubeconfig,
+				Context:         src.Context,
+				Namespaces:      src.Namespaces,
+				CustomResources: src.CustomResources,
 			})
-			results = append(results, r)
 		} else if src.Path != "" {
-			r := s.RunSync(ctx, ScanRequest{
+			add(src.Schedule, ScanRequest{
 				Source:     "kubernetes",
 				Paths:      []string{src.Path},
 				Helm:       src.HelmChart != "",
 				ValuesFile: src.ValuesFile,
 			})
-			results = append(results, r)
 		}
 	}
 
@@ -254,48 +622,86 @@ func (s *Scanner) RunAllConfigured(ctx context.Context) []ScanResult {
 		if src.Inventory == "" {
 			continue
 		}
-		r := s.RunSync(ctx, ScanRequest{
+		add(src.Schedule, ScanRequest{
 			Source:    "ansible",
 			Paths:     []string{src.Inventory},
 			Playbooks: src.Playbooks,
 		})
-		results = append(results, r)
 	}
 
 	for _, src := range s.cfg.Sources.Compose {
 		if src.Path == "" {
 			continue
 		}
-		r := s.RunSync(ctx, ScanRequest{
+		add(src.Schedule, ScanRequest{
 			Source: "compose",
 			Paths:  []string{src.Path},
 		})
-		results = append(results, r)
 	}
 
 	for _, src := range s.cfg.Sources.CloudFormation {
 		if src.Path == "" {
 			continue
 		}
-		r := s.RunSync(ctx, ScanRequest{
+		add(src.Schedule, ScanRequest{
 			Source: "cloudformation",
 			Paths:  []string{src.Path},
 		})
-		results = append(results, r)
 	}
 
 	for _, src := range s.cfg.Sources.Pulumi {
 		if src.Path == "" {
 			continue
 		}
-		r := s.RunSync(ctx, ScanRequest{
+		add(src.Schedule, ScanRequest{
 			Source: "pulumi",
 			Paths:  []string{src.Path},
 		})
-		results = append(results, r)
 	}
 
-	return results
+	for _, src := range s.cfg.Sources.AWS {
+		add(src.Schedule, ScanRequest{
+			Source:  "aws",
+			Regions: src.Regions,
+			Profile: src.Profile,
+		})
+	}
+
+	for _, src := range s.cfg.Sources.GCP {
+		if src.Project == "" {
+			continue
+		}
+		add(src.Schedule, ScanRequest{
+			Source:      "gcp",
+			Project:     src.Project,
+			Credentials: src.Credentials,
+		})
+	}
+
+	return scans
+}
+
+// SubscribeProgress streams the progress events of an async scan, starting
+// with those already emitted; the channel is closed when the scan ends. ok
+// is false for scans this process did not start or has since forgotten.
+// Call unsubscribe when done reading early.
+func (s *Scanner) SubscribeProgress(scanID int64) (events <-chan ProgressEvent, unsubscribe func(), ok bool) {
+	return s.progress.subscribe(scanID)
+}
+
+// Cancel stops the async scan with the given ID, which then ends with
+// status "cancelled" and stores nothing. An "all" scan keeps the results of
+// the sources it finished before the cancel and lists them in its
+// EventCancelled event. Cancel reports false if this process is not running
+// that scan.
+func (s *Scanner) Cancel(scanID int64) bool {
+	s.mu.Lock()
+	cancel, ok := s.running[scanID]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
 }
 
 // IsRunning returns true if any scan is currently in progress.
@@ -305,8 +711,70 @@ func (s *Scanner) IsRunning() bool {
 	return len(s.running) > 0
 }
 
-// executeScan dispatches to the appropriate parser.
-func (s *Scanner) executeScan(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
+// executeScan runs the parser for req and drops excluded asset types.
+func (s *Scanner) executeScan(ctx context.Context, req ScanRequest) (result *parser.ParseResult, err error) {
+	ctx, span := startSpan(ctx, "parse "+req.Source, req, trace.WithAttributes(attrPaths.Int(len(req.Paths))))
+	defer func() {
+		if result != nil {
+			span.SetAttributes(parseAttributes(result)...)
+		}
+		endSpan(span, err)
+	}()
+
+	if err := s.pathFilter(req).Validate(); err != nil {
+		return nil, err
+	}
+	timeout, err := s.timeout(req)
+	if err != nil {
+		return nil, err
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err = s.dispatch(ctx, req)
+	// Parsers that don't watch ctx may return late; drop their results too.
+	if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s", ErrScanTimeout, timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	parser.ExcludeTypes(result, append(append([]string{}, s.cfg.Scan.ExcludeTypes...), req.ExcludeTypes...))
+	return result, nil
+}
+
+// timeout returns req's timeout, falling back to scan.timeout; zero means
+// no limit.
+func (s *Scanner) timeout(req ScanRequest) (time.Duration, error) {
+	spec := req.Timeout
+	if spec == "" {
+		spec = s.cfg.Scan.Timeout
+	}
+	if spec == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(spec)
+	if err != nil {
+		return 0, fmt.Errorf("invalid scan timeout %q: %w", spec, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("scan timeout must be positive, got %s", d)
+	}
+	return d, nil
+}
+
+// pathFilter combines the configured include/exclude globs with req's.
+func (s *Scanner) pathFilter(req ScanRequest) parser.PathFilter {
+	return parser.PathFilter{
+		Include: append(append([]string{}, s.cfg.Scan.Include...), req.Include...),
+		Exclude: append(append([]string{}, s.cfg.Scan.Exclude...), req.Exclude...),
+	}
+}
+
+// dispatchScan dispatches to the appropriate parser.
+func (s *Scanner) dispatchScan(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
 	switch req.Source {
 	case "terraform":
 		return s.scanTerraform(ctx, req)
@@ -324,8 +792,12 @@ func (s *Scanner) executeScan(ctx context.Context, req ScanRequest) (*parser.Par
 		return s.scanCloudFormation(ctx, req)
 	case "pulumi":
 		return s.scanPulumi(ctx, req)
+	case "aws":
+		return awsparser.Fetch(ctx, req.Regions, req.Profile)
+	case "gcp":
+		return gcp.Fetch(ctx, req.Project, req.Credentials)
 	case "all":
-		// "all" is handled specially by RunAsync — it runs RunAllConfigured.
+		// "all" is handled specially by RunAsync — it runs RunAll.
 		// If it reaches here via RunSync, just run all configured sources.
 		return nil, fmt.Errorf("use RunAllConfigured for source 'all'")
 	default:
@@ -334,11 +806,15 @@ func (s *Scanner) executeScan(ctx context.Context, req ScanRequest) (*parser.Par
 }
 
 func (s *Scanner) scanTerraform(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
+	if len(req.Backends) > 0 {
+		return terraform.PullBackendMulti(ctx, req.Backends, req.BackendRegion)
+	}
 	if req.Remote {
 		return terraform.PullRemoteMulti(ctx, req.Paths, req.Workspace)
 	}
 
 	p := terraform.NewStateParser()
+	p.Filter = s.pathFilter(req)
 	for _, path := range req.Paths {
 		if !p.Supported(path) {
 			return nil, fmt.Errorf("path %q is not a supported Terraform source", path)
@@ -349,50 +825,38 @@ func (s *Scanner) scanTerraform(ctx context.Context, req ScanRequest) (*parser.P
 
 func (s *Scanner) scanKubernetes(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
 	if req.Helm {
-		return kubernetes.RenderHelm(ctx, req.Paths[0], req.ValuesFile)
-	}
-
-	p := kubernetes.NewK8sParser(req.ValuesFile)
-	merged := &parser.ParseResult{}
-
-	for _, path := range req.Paths {
-		if !p.Supported(path) {
-			return nil, fmt.Errorf("path %q is not a supported Kubernetes source", path)
-		}
-		result, err := p.Parse(ctx, path)
+		result, err := kubernetes.RenderHelm(ctx, req.Paths[0], req.ValuesFile)
 		if err != nil {
-			return nil, fmt.Errorf("parsing %s: %w", path, err)
+			return nil, err
 		}
-		merged.Nodes = append(merged.Nodes, result.Nodes...)
-		merged.Edges = append(merged.Edges, result.Edges...)
-		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		result.PathsScanned = 1
+		return result, nil
 	}
 
-	return merged, nil
+	p := kubernetes.NewK8sParser(req.ValuesFile)
+	p.Filter = s.pathFilter(req)
+	return parsePaths(ctx, req.Paths, "Kubernetes", p), nil
 }
 
 func (s *Scanner) scanKubernetesLive(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
-	return kubernetes.FetchLive(ctx, req.Kubeconfig, req.Context, req.Namespaces)
+	return kubernetes.FetchLive(ctx, req.Kubeconfig, req.Context, req.Namespaces, req.CustomResources)
 }
 
 func (s *Scanner) scanCompose(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
 	p := compose.NewComposeParser()
-	merged := &parser.ParseResult{}
-
-	for _, path := range req.Paths {
-		if !p.Supported(path) {
-			return nil, fmt.Errorf("path %q is not a supported Docker Compose source", path)
-		}
-		result, err := p.Parse(ctx, path)
+	p.Filter = s.pathFilter(req)
+	if req.Merge {
+		// Layered files often have custom names (e.g. compose.prod.yml), so
+		// they are not checked against the standard compose file names.
+		result, err := p.ParseMerged(ctx, req.Paths)
 		if err != nil {
-			return nil, fmt.Errorf("parsing %s: %w", path, err)
+			return nil, err
 		}
-		merged.Nodes = append(merged.Nodes, result.Nodes...)
-		merged.Edges = append(merged.Edges, result.Edges...)
-		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		result.PathsScanned = len(req.Paths)
+		return result, nil
 	}
 
-	return merged, nil
+	return parsePaths(ctx, req.Paths, "Docker Compose", p), nil
 }
 
 func (s *Scanner) scanTerraformPlan(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
@@ -412,20 +876,68 @@ func (s *Scanner) scanPulumi(ctx context.Context, req ScanRequest) (*parser.Pars
 
 func (s *Scanner) scanAnsible(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
 	p := ansible.NewAnsibleParser(req.Playbooks)
-	merged := &parser.ParseResult{}
+	return parsePaths(ctx, req.Paths, "Ansible inventory", p), nil
+}
 
-	for _, path := range req.Paths {
+// Retry policy for transient read errors in parsePaths. Backoff is the
+// wait before the first retry, doubled before each one after.
+var (
+	pathAttempts = 3
+	pathBackoff  = 200 * time.Millisecond
+)
+
+// parsePaths parses each path with p and merges the results. Like the
+// parsers' own ParseMulti, a path that is unsupported or fails to parse is
+// reported as a warning and counted in PathsFailed rather than failing the
+// scan; strict scans turn those into an error in checkStrict. kind names the
+// source in warnings.
+func parsePaths(ctx context.Context, paths []string, kind string, p parser.Parser) *parser.ParseResult {
+	merged := &parser.ParseResult{}
+	for _, path := range paths {
 		if !p.Supported(path) {
-			return nil, fmt.Errorf("path %q is not a supported Ansible inventory", path)
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("path %q is not a supported %s source", path, kind))
+			merged.PathsFailed++
+			continue
 		}
-		result, err := p.Parse(ctx, path)
+		result, err := parseWithRetry(ctx, p, path)
 		if err != nil {
-			return nil, fmt.Errorf("parsing %s: %w", path, err)
+			merged.Warnings = append(merged.Warnings, fmt.Sprintf("parsing %s: %v", path, err))
+			merged.PathsFailed++
+			continue
 		}
 		merged.Nodes = append(merged.Nodes, result.Nodes...)
 		merged.Edges = append(merged.Edges, result.Edges...)
 		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		merged.PathsScanned++
+	}
+	return merged
+}
+
+// parseWithRetry parses path, retrying with backoff while the error is a
+// transient read error.
+func parseWithRetry(ctx context.Context, p parser.Parser, path string) (*parser.ParseResult, error) {
+	backoff := pathBackoff
+	for attempt := 1; ; attempt++ {
+		result, err := p.Parse(ctx, path)
+		if err == nil || attempt >= pathAttempts || !isTransientReadError(err) {
+			return result, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
+}
 
-	return merged, nil
+// isTransientReadError reports whether err is a read error worth retrying,
+// such as an interrupted or timed-out read on a network file system.
+func isTransientReadError(err error) bool {
+	if errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN) ||
+		errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ESTALE) {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
 }
@@ -2,14 +2,20 @@ package scanner
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/matijazezelj/aib/internal/config"
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
 	_ "modernc.org/sqlite"
 )
 
@@ -78,6 +84,96 @@ func TestRunSync_Terraform(t *testing.T) {
 	}
 }
 
+func TestRunSync_DryRun(t *testing.T) {
+	sc, store := newTestScanner(t)
+	ctx := context.Background()
+
+	testdata, err := filepath.Abs("../parser/terraform/testdata/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{testdata}, DryRun: true})
+	if result.Error != nil {
+		t.Fatalf("RunSync error: %v", result.Error)
+	}
+	if !result.DryRun || result.NodesFound == 0 || len(result.Nodes) != result.NodesFound {
+		t.Errorf("DryRun = %v, NodesFound = %d, len(Nodes) = %d", result.DryRun, result.NodesFound, len(result.Nodes))
+	}
+	if result.Drift == nil || !result.Drift.IsInitial {
+		t.Error("dry run should still report drift against the empty store")
+	}
+
+	nodes, err := store.ListNodes(ctx, graph.NodeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scans, err := store.ListScans(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 || len(scans) != 0 {
+		t.Errorf("store has %d nodes and %d scans after a dry run, want none", len(nodes), len(scans))
+	}
+
+	if _, err := sc.RunAsync(ctx, ScanRequest{Source: "terraform", Paths: []string{testdata}, DryRun: true}); !errors.Is(err, ErrDryRunAsync) {
+		t.Errorf("RunAsync dry run: err = %v, want ErrDryRunAsync", err)
+	}
+}
+
+func TestRunSync_ExcludeFilters(t *testing.T) {
+	ctx := context.Background()
+	data, err := os.ReadFile("../parser/terraform/testdata/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "testdata"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"main.tfstate", "testdata/fixture.tfstate"} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sc, _ := newTestScanner(t)
+	all := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{dir}, DryRun: true})
+	if all.Error != nil || all.PathsScanned != 2 {
+		t.Fatalf("unfiltered scan: err = %v, paths scanned = %d, want 2", all.Error, all.PathsScanned)
+	}
+
+	filtered := sc.RunSync(ctx, ScanRequest{
+		Source:       "terraform",
+		Paths:        []string{dir},
+		Exclude:      []string{"testdata"},
+		ExcludeTypes: []string{"dns_record"},
+		DryRun:       true,
+	})
+	if filtered.Error != nil {
+		t.Fatalf("RunSync error: %v", filtered.Error)
+	}
+	if filtered.PathsScanned != 1 {
+		t.Errorf("paths scanned = %d, want 1 (testdata/ excluded)", filtered.PathsScanned)
+	}
+	for _, n := range filtered.Nodes {
+		if strings.Contains(n.SourceFile, "testdata") {
+			t.Errorf("node %s came from excluded file %s", n.ID, n.SourceFile)
+		}
+		if n.Type == "dns_record" {
+			t.Errorf("node %s has excluded type dns_record", n.ID)
+		}
+	}
+	if filtered.NodesFound >= all.NodesFound {
+		t.Errorf("NodesFound = %d, want fewer than %d", filtered.NodesFound, all.NodesFound)
+	}
+
+	bad := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{dir}, Exclude: []string{"[bad"}})
+	if bad.Error == nil {
+		t.Error("expected error for malformed exclude glob")
+	}
+}
+
 func TestRunSync_InvalidPath(t *testing.T) {
 	sc, store := newTestScanner(t)
 
@@ -120,6 +216,424 @@ func TestIsRunning(t *testing.T) {
 	}
 }
 
+func TestRunSync_Timeout(t *testing.T) {
+	sc, store := newTestScanner(t)
+	sc.cfg.Scan.Timeout = "1h"
+	ctx := context.Background()
+
+	// A parser stub that outlives the request's timeout.
+	sc.dispatch = func(ctx context.Context, _ ScanRequest) (*parser.ParseResult, error) {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return &parser.ParseResult{}, nil
+		}
+	}
+
+	start := time.Now()
+	result := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{"/slow"}, Timeout: "50ms"})
+	if !errors.Is(result.Error, ErrScanTimeout) {
+		t.Fatalf("error = %v, want ErrScanTimeout", result.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("scan took %s, want it abandoned after the 50ms timeout", elapsed)
+	}
+	scan, err := store.GetScan(ctx, result.ScanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.Status != "failed" {
+		t.Errorf("status = %q, want failed", scan.Status)
+	}
+
+	result = sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{"/slow"}, Timeout: "soon"})
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "invalid scan timeout") {
+		t.Errorf("expected invalid timeout error, got %v", result.Error)
+	}
+}
+
+func TestRunSync_GroupByProvider(t *testing.T) {
+	ctx := context.Background()
+	sc, store := newTestScanner(t)
+	sc.cfg.Scan.GroupByProvider = true
+
+	var paths []string
+	for _, name := range []string{"sample.tfstate", "cert.tfstate"} {
+		p, err := filepath.Abs("../parser/terraform/testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	scan := func() {
+		t.Helper()
+		if r := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: paths}); r.Error != nil {
+			t.Fatal(r.Error)
+		}
+	}
+	scan()
+
+	tfNodes, err := store.ListNodes(ctx, graph.NodeFilter{Source: "terraform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := map[string]bool{}
+	for _, n := range tfNodes {
+		if n.Provider == "" {
+			continue
+		}
+		providers[n.Provider] = true
+		edges, err := store.GetEdgesFrom(ctx, n.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		linked := false
+		for _, e := range edges {
+			if e.Type == models.EdgeMemberOf && e.ToID == graph.ProviderNodeID(n.Provider) {
+				linked = true
+			}
+		}
+		if !linked {
+			t.Errorf("%s is not a member of its provider %q", n.ID, n.Provider)
+		}
+	}
+	if len(providers) < 2 {
+		t.Fatalf("testdata has %d providers, want several", len(providers))
+	}
+	providerNodes, err := store.ListNodes(ctx, graph.NodeFilter{Type: string(models.AssetProvider)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(providerNodes) != len(providers) {
+		t.Errorf("provider nodes = %d, want %d distinct providers", len(providerNodes), len(providers))
+	}
+
+	// A re-scan neither duplicates provider nodes nor their edges.
+	edgesBefore, _ := store.EdgeCount(ctx)
+	scan()
+	if edgesAfter, _ := store.EdgeCount(ctx); edgesAfter != edgesBefore {
+		t.Errorf("edges after re-scan = %d, want %d", edgesAfter, edgesBefore)
+	}
+}
+
+func TestRunSync_PruneMissing(t *testing.T) {
+	ctx := context.Background()
+	vm := func(id string) models.Node {
+		return models.Node{ID: id, Name: id, Type: models.AssetVM, Source: "terraform", SourceFile: "/state/terraform.tfstate", LastSeen: time.Now(), FirstSeen: time.Now()}
+	}
+	// scan returns the given nodes, with web depending on db when both
+	// are present, and marks failed paths as a partial scan.
+	scan := func(sc *Scanner, failed int, ids ...string) ScanResult {
+		sc.dispatch = func(context.Context, ScanRequest) (*parser.ParseResult, error) {
+			r := &parser.ParseResult{PathsScanned: 1, PathsFailed: failed}
+			for _, id := range ids {
+				r.Nodes = append(r.Nodes, vm(id))
+			}
+			if len(ids) == 2 {
+				r.Edges = []models.Edge{{ID: "web->db", FromID: ids[0], ToID: ids[1], Type: models.EdgeDependsOn}}
+			}
+			return r, nil
+		}
+		return sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{"/state"}})
+	}
+
+	t.Run("stale", func(t *testing.T) {
+		sc, store := newTestScanner(t)
+		sc.cfg.Scan.PruneMissing = config.PruneStale
+		scan(sc, 0, "tf:vm:web", "tf:vm:db")
+		if r := scan(sc, 0, "tf:vm:web"); r.Error != nil || r.NodesSwept != 1 {
+			t.Fatalf("second scan swept %d (err %v), want 1", r.NodesSwept, r.Error)
+		}
+		db, err := store.GetNode(ctx, "tf:vm:db")
+		if err != nil || db == nil {
+			t.Fatalf("stale node should be kept, got %v, %v", db, err)
+		}
+		if db.Metadata[StaleSinceKey] == "" {
+			t.Errorf("missing node not marked stale: %v", db.Metadata)
+		}
+		if r := scan(sc, 0, "tf:vm:web"); r.NodesSwept != 0 {
+			t.Errorf("already-stale node swept again: %d", r.NodesSwept)
+		}
+
+		scan(sc, 0, "tf:vm:web", "tf:vm:db")
+		if db, _ := store.GetNode(ctx, "tf:vm:db"); db.Metadata[StaleSinceKey] != "" {
+			t.Error("node found again should lose its stale mark")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		sc, store := newTestScanner(t)
+		sc.cfg.Scan.PruneMissing = config.PruneDelete
+		scan(sc, 0, "tf:vm:web", "tf:vm:db")
+
+		// Partial scans never sweep.
+		if r := scan(sc, 1, "tf:vm:web"); r.NodesSwept != 0 {
+			t.Errorf("partial scan swept %d nodes", r.NodesSwept)
+		}
+		if db, _ := store.GetNode(ctx, "tf:vm:db"); db == nil {
+			t.Fatal("partial scan deleted a node")
+		}
+
+		if r := scan(sc, 0, "tf:vm:web"); r.NodesSwept != 1 {
+			t.Errorf("swept %d, want 1", r.NodesSwept)
+		}
+		if db, _ := store.GetNode(ctx, "tf:vm:db"); db != nil {
+			t.Error("missing node not deleted")
+		}
+		if edges, _ := store.ListEdges(ctx, graph.EdgeFilter{}); len(edges) != 0 {
+			t.Errorf("edges to the deleted node remain: %v", edges)
+		}
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		sc, store := newTestScanner(t)
+		scan(sc, 0, "tf:vm:web", "tf:vm:db")
+		if r := scan(sc, 0, "tf:vm:web"); r.NodesSwept != 0 {
+			t.Errorf("default mode swept %d nodes", r.NodesSwept)
+		}
+		if db, _ := store.GetNode(ctx, "tf:vm:db"); db == nil || db.Metadata[StaleSinceKey] != "" {
+			t.Errorf("default mode changed the missing node: %+v", db)
+		}
+	})
+}
+
+func TestRunAllConfigured_PruneMissingPerSource(t *testing.T) {
+	ctx := context.Background()
+	sc, store := newTestScanner(t)
+	sc.cfg.Scan.PruneMissing = config.PruneDelete
+	sc.cfg.Sources.Terraform = []config.TerraformSource{{StateFile: "states/a.tfstate"}, {StateFile: "states/b.tfstate"}}
+
+	// Each state file holds one VM named after the file.
+	sc.dispatch = func(_ context.Context, req ScanRequest) (*parser.ParseResult, error) {
+		r := &parser.ParseResult{PathsScanned: len(req.Paths)}
+		for _, p := range req.Paths {
+			id := "tf:vm:" + strings.TrimSuffix(filepath.Base(p), ".tfstate")
+			r.Nodes = append(r.Nodes, models.Node{
+				ID: id, Name: id, Type: models.AssetVM, Source: "terraform", SourceFile: p,
+				LastSeen: time.Now(), FirstSeen: time.Now(),
+			})
+		}
+		return r, nil
+	}
+
+	for range 2 {
+		for _, r := range sc.RunAllConfigured(ctx) {
+			if r.Error != nil || r.NodesSwept != 0 {
+				t.Fatalf("scan %d swept %d nodes (err %v)", r.ScanID, r.NodesSwept, r.Error)
+			}
+		}
+	}
+	for _, id := range []string{"tf:vm:a", "tf:vm:b"} {
+		if n, _ := store.GetNode(ctx, id); n == nil {
+			t.Errorf("%s was swept by the other source's scan", id)
+		}
+	}
+
+	// A node that really left a's state is still swept.
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tf:vm:gone", Name: "gone", Type: models.AssetVM, Source: "terraform", SourceFile: "states/a.tfstate",
+		LastSeen: time.Now(), FirstSeen: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if r := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{"./states/a.tfstate"}}); r.NodesSwept != 1 {
+		t.Errorf("swept %d, want the node gone from a.tfstate", r.NodesSwept)
+	}
+	if n, _ := store.GetNode(ctx, "tf:vm:gone"); n != nil {
+		t.Error("node missing from a.tfstate was kept")
+	}
+
+	// Filtered scans never sweep: excluded nodes only look missing.
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tf:vm:hidden", Name: "hidden", Type: models.AssetVM, Source: "terraform", SourceFile: "states/a.tfstate",
+		LastSeen: time.Now(), FirstSeen: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r := sc.RunSync(ctx, ScanRequest{Source: "terraform", Paths: []string{"states/a.tfstate"}, ExcludeTypes: []string{"database"}})
+	if r.NodesSwept != 0 {
+		t.Errorf("filtered scan swept %d nodes", r.NodesSwept)
+	}
+}
+
+func TestRunAllConfigured_PruneMissingLiveSources(t *testing.T) {
+	ctx := context.Background()
+	sc, store := newTestScanner(t)
+	sc.cfg.Scan.PruneMissing = config.PruneDelete
+	sc.cfg.Sources.AWS = []config.AWSSource{{Profile: "prod"}, {Profile: "staging"}}
+
+	// Each account holds one VM named after its profile.
+	sc.dispatch = func(_ context.Context, req ScanRequest) (*parser.ParseResult, error) {
+		id := "aws:vm:" + req.Profile
+		return &parser.ParseResult{PathsScanned: 1, Nodes: []models.Node{{
+			ID: id, Name: req.Profile, Type: models.AssetVM, Source: "aws", SourceFile: "aws:eu-west-1",
+			LastSeen: time.Now(), FirstSeen: time.Now(),
+		}}}, nil
+	}
+
+	for range 2 {
+		for _, r := range sc.RunAllConfigured(ctx) {
+			if r.Error != nil || r.NodesSwept != 0 {
+				t.Fatalf("scan %d swept %d nodes (err %v)", r.ScanID, r.NodesSwept, r.Error)
+			}
+		}
+	}
+	for _, id := range []string{"aws:vm:prod", "aws:vm:staging"} {
+		if n, _ := store.GetNode(ctx, id); n == nil {
+			t.Errorf("%s was swept by the other profile's scan", id)
+		}
+	}
+
+	// Live Kubernetes sources split the same way by context, unless their
+	// namespaces keep them apart.
+	sc.cfg.Sources.Kubernetes = []config.KubernetesSource{
+		{Live: true, Context: "prod"},
+		{Live: true, Context: "staging", Namespaces: []string{"web"}},
+	}
+	if !sc.sharesScope(ScanRequest{Source: "kubernetes-live", Context: "prod"}) {
+		t.Error("a context covering every namespace should share the other context's scope")
+	}
+	if sc.sharesScope(ScanRequest{Source: "kubernetes-live", Context: "prod", Namespaces: []string{"default"}}) {
+		t.Error("disjoint namespaces should not share a scope")
+	}
+	if !sc.sharesScope(ScanRequest{Source: "kubernetes-live", Context: "staging", Namespaces: []string{"web"}}) {
+		t.Error("staging's namespace falls within prod's scope")
+	}
+}
+
+func TestInScanScope(t *testing.T) {
+	node := func(file, ns string) models.Node {
+		return models.Node{SourceFile: file, Metadata: map[string]string{"namespace": ns}}
+	}
+	tests := []struct {
+		name string
+		n    models.Node
+		req  ScanRequest
+		want bool
+	}{
+		{"whole source", node("", ""), ScanRequest{Source: "kubernetes-live"}, true},
+		{"file", node("states/a.tfstate", ""), ScanRequest{Paths: []string{"states/a.tfstate"}}, true},
+		{"other file", node("states/b.tfstate", ""), ScanRequest{Paths: []string{"states/a.tfstate"}}, false},
+		{"under dir", node("k8s/app/deploy.yaml", ""), ScanRequest{Paths: []string{"./k8s/"}}, true},
+		{"sibling dir", node("k8s-other/deploy.yaml", ""), ScanRequest{Paths: []string{"k8s"}}, false},
+		{"current dir", node("deploy.yaml", ""), ScanRequest{Paths: []string{"."}}, true},
+		{"backend", node("s3://bucket/prod.tfstate", ""), ScanRequest{Backends: []string{"s3://bucket/prod.tfstate"}}, true},
+		{"other backend", node("s3://bucket/dev.tfstate", ""), ScanRequest{Backends: []string{"s3://bucket/prod.tfstate"}}, false},
+		{"region", node("aws:eu-west-1", ""), ScanRequest{Regions: []string{"eu-west-1"}}, true},
+		{"other region", node("aws:us-east-1", ""), ScanRequest{Regions: []string{"eu-west-1"}}, false},
+		{"namespace", node("", "prod"), ScanRequest{Namespaces: []string{"prod"}}, true},
+		{"other namespace", node("", "dev"), ScanRequest{Namespaces: []string{"prod"}}, false},
+	}
+	for _, tt := range tests {
+		if got := inScanScope(tt.n, tt.req); got != tt.want {
+			t.Errorf("%s: inScanScope = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunAsync_Cancel(t *testing.T) {
+	sc, store := newTestScanner(t)
+	ctx := context.Background()
+
+	// A parser stub that runs until its context is cancelled.
+	started := make(chan struct{})
+	sc.dispatch = func(ctx context.Context, _ ScanRequest) (*parser.ParseResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	scanID, err := sc.RunAsync(ctx, ScanRequest{Source: "terraform", Paths: []string{"/slow"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe, ok := sc.SubscribeProgress(scanID)
+	if !ok {
+		t.Fatal("expected progress for the running scan")
+	}
+	defer unsubscribe()
+	<-started
+
+	scan, err := store.GetScan(ctx, scanID)
+	if err != nil || scan.Status != "running" {
+		t.Fatalf("status before cancel = %v (err %v), want running", scan, err)
+	}
+	if !sc.Cancel(scanID) {
+		t.Fatal("Cancel returned false for a running scan")
+	}
+
+	var last ProgressEvent
+	for ev := range events {
+		last = ev
+	}
+	if last.Type != EventCancelled {
+		t.Errorf("last event = %q, want %q", last.Type, EventCancelled)
+	}
+	scan, err = store.GetScan(ctx, scanID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.Status != "cancelled" {
+		t.Errorf("status after cancel = %q, want cancelled", scan.Status)
+	}
+	nodes, _ := store.ListNodes(ctx, graph.NodeFilter{})
+	if len(nodes) != 0 {
+		t.Errorf("cancelled scan stored %d nodes", len(nodes))
+	}
+	if sc.Cancel(999) {
+		t.Error("Cancel returned true for an unknown scan")
+	}
+}
+
+func TestRunAsync_CancelAll(t *testing.T) {
+	sc, store := newTestScanner(t)
+	ctx := context.Background()
+	sc.cfg.Sources.Terraform = []config.TerraformSource{{StateFile: "/states/a.tfstate"}, {StateFile: "/states/b.tfstate"}}
+
+	// a.tfstate parses at once; b.tfstate ignores the cancel and returns
+	// its node anyway.
+	started := make(chan struct{})
+	sc.dispatch = func(ctx context.Context, req ScanRequest) (*parser.ParseResult, error) {
+		id := "tf:vm:" + strings.TrimSuffix(filepath.Base(req.Paths[0]), ".tfstate")
+		if id == "tf:vm:b" {
+			close(started)
+			<-ctx.Done()
+		}
+		return &parser.ParseResult{PathsScanned: 1, Nodes: []models.Node{{
+			ID: id, Name: id, Type: models.AssetVM, Source: "terraform", SourceFile: req.Paths[0],
+			LastSeen: time.Now(), FirstSeen: time.Now(),
+		}}}, nil
+	}
+
+	scanID, err := sc.RunAsync(ctx, ScanRequest{Source: "all"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, unsubscribe, ok := sc.SubscribeProgress(scanID)
+	if !ok {
+		t.Fatal("expected progress for the running scan")
+	}
+	defer unsubscribe()
+	<-started
+	// The completed sources come from what the scan ran, not the config
+	// as it is when the cancel lands.
+	sc.cfg.Sources.Terraform = []config.TerraformSource{{StateFile: "/states/c.tfstate"}}
+	sc.Cancel(scanID)
+
+	var last ProgressEvent
+	for ev := range events {
+		last = ev
+	}
+	if last.Type != EventCancelled || len(last.Completed) != 1 || last.Completed[0] != "terraform /states/a.tfstate" {
+		t.Errorf("last event = %+v, want cancelled with a.tfstate completed", last)
+	}
+	nodes, _ := store.ListNodes(ctx, graph.NodeFilter{})
+	if len(nodes) != 1 || nodes[0].ID != "tf:vm:a" {
+		t.Errorf("stored nodes = %v, want only the finished source's", nodes)
+	}
+}
+
 func TestRunAsync_Terraform(t *testing.T) {
 	sc, store := newTestScanner(t)
 
@@ -329,6 +843,123 @@ func TestRunSync_TerraformPlanMultiFile(t *testing.T) {
 	}
 }
 
+func TestRunSync_PartialPaths(t *testing.T) {
+	ctx := context.Background()
+	good, err := filepath.Abs("../parser/terraform/testdata/plan_realistic.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := filepath.Join(t.TempDir(), "broken.json")
+	if err := os.WriteFile(bad, []byte("{invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	req := ScanRequest{Source: "terraform-plan", Paths: []string{good, bad}}
+
+	sc, _ := newTestScanner(t)
+	result := sc.RunSync(ctx, req)
+	if result.Error != nil {
+		t.Fatalf("RunSync error: %v", result.Error)
+	}
+	if result.PathsScanned != 1 || result.PathsFailed != 1 {
+		t.Errorf("paths scanned/failed = %d/%d, want 1/1", result.PathsScanned, result.PathsFailed)
+	}
+	if result.NodesFound == 0 {
+		t.Error("expected nodes from the readable plan")
+	}
+
+	sc, store := newTestScanner(t)
+	req.Strict = true
+	result = sc.RunSync(ctx, req)
+	if !errors.Is(result.Error, ErrPartialScan) {
+		t.Fatalf("strict scan error = %v, want ErrPartialScan", result.Error)
+	}
+	if len(result.Warnings) == 0 {
+		t.Error("strict failure should keep the per-path warnings")
+	}
+	nodes, err := store.ListNodes(ctx, graph.NodeFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Errorf("strict scan stored %d nodes, want 0", len(nodes))
+	}
+	scans, _ := store.ListScans(ctx, 10)
+	if len(scans) != 1 || scans[0].Status != "failed" {
+		t.Errorf("expected one failed scan record, got %+v", scans)
+	}
+}
+
+func TestRunSync_PartialPathsPerFileParsers(t *testing.T) {
+	ctx := context.Background()
+	missing := filepath.Join(t.TempDir(), "missing")
+	tests := []struct {
+		source string
+		good   string
+	}{
+		{"kubernetes", "../parser/kubernetes/testdata/manifests.yaml"},
+		{"compose", "../parser/compose/testdata/docker-compose.yml"},
+		{"ansible", "../parser/ansible/testdata/inventory.ini"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			good, err := filepath.Abs(tt.good)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc, _ := newTestScanner(t)
+			result := sc.RunSync(ctx, ScanRequest{Source: tt.source, Paths: []string{missing, good}})
+			if result.Error != nil {
+				t.Fatalf("RunSync error: %v", result.Error)
+			}
+			if result.PathsScanned != 1 || result.PathsFailed != 1 {
+				t.Errorf("paths scanned/failed = %d/%d, want 1/1", result.PathsScanned, result.PathsFailed)
+			}
+			if result.NodesFound == 0 {
+				t.Error("expected nodes from the readable path")
+			}
+		})
+	}
+}
+
+// flakyParser fails Parse with err for the first failures calls.
+type flakyParser struct {
+	err      error
+	failures int
+	calls    int
+}
+
+func (p *flakyParser) Supported(string) bool { return true }
+
+func (p *flakyParser) Parse(context.Context, string) (*parser.ParseResult, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return nil, p.err
+	}
+	return &parser.ParseResult{}, nil
+}
+
+func TestParseWithRetry(t *testing.T) {
+	defer func(d time.Duration) { pathBackoff = d }(pathBackoff)
+	pathBackoff = time.Millisecond
+	ctx := context.Background()
+
+	transient := &os.PathError{Op: "read", Path: "x", Err: syscall.EIO}
+	p := &flakyParser{err: transient, failures: pathAttempts - 1}
+	if _, err := parseWithRetry(ctx, p, "x"); err != nil || p.calls != pathAttempts {
+		t.Errorf("transient error: err=%v calls=%d, want success after %d calls", err, p.calls, pathAttempts)
+	}
+
+	p = &flakyParser{err: transient, failures: pathAttempts}
+	if _, err := parseWithRetry(ctx, p, "x"); !errors.Is(err, syscall.EIO) || p.calls != pathAttempts {
+		t.Errorf("persistent error: err=%v calls=%d, want EIO after %d calls", err, p.calls, pathAttempts)
+	}
+
+	p = &flakyParser{err: os.ErrNotExist, failures: 1}
+	if _, err := parseWithRetry(ctx, p, "x"); err == nil || p.calls != 1 {
+		t.Errorf("permanent error: err=%v calls=%d, want no retry", err, p.calls)
+	}
+}
+
 func TestRunSync_Kubernetes(t *testing.T) {
 	sc, _ := newTestScanner(t)
 
@@ -489,6 +1120,31 @@ func TestRunAllConfigured(t *testing.T) {
 	}
 }
 
+func TestRunAll_AppliesRequestToEachSource(t *testing.T) {
+	sc, _ := newTestScanner(t)
+	sc.cfg.Sources.Terraform = []config.TerraformSource{{StateFile: "a.tfstate"}}
+	sc.cfg.Sources.Compose = []config.ComposeSource{{Path: "compose.yaml"}}
+
+	var got []ScanRequest
+	sc.dispatch = func(_ context.Context, req ScanRequest) (*parser.ParseResult, error) {
+		got = append(got, req)
+		return &parser.ParseResult{PathsScanned: 1}, nil
+	}
+
+	sc.RunAll(context.Background(), ScanRequest{
+		Source: "all", Include: []string{"*.yaml"}, ExcludeTypes: []string{"secret"}, Strict: true, Timeout: "1m",
+	})
+	if len(got) != 2 {
+		t.Fatalf("ran %d sources, want 2", len(got))
+	}
+	for _, req := range got {
+		if !slices.Equal(req.Include, []string{"*.yaml"}) || !slices.Equal(req.ExcludeTypes, []string{"secret"}) ||
+			!req.Strict || req.Timeout != "1m" {
+			t.Errorf("%s scan did not get the all request's options: %+v", req.Source, req)
+		}
+	}
+}
+
 func TestScheduler_StartStop(t *testing.T) {
 	sc, _ := newTestScanner(t)
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
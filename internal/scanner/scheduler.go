@@ -2,16 +2,21 @@ package scanner
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
+
+	"github.com/matijazezelj/aib/internal/schedule"
 )
 
-// Scheduler runs scans periodically using a time.Ticker.
+// Scheduler runs configured scans on duration or cron schedules. Sources
+// that set their own schedule run on it; the rest share scan.schedule.
 type Scheduler struct {
 	scanner  *Scanner
-	interval time.Duration
+	entries  []scheduleEntry
+	clock    schedule.Clock
 	logger   *slog.Logger
 	stopCh   chan struct{}
 	doneCh   chan struct{}
@@ -20,22 +25,48 @@ type Scheduler struct {
 	stopOnce sync.Once
 }
 
-// NewScheduler creates a scheduler. The interval string is parsed with
-// time.ParseDuration (e.g. "4h", "30m", "1h30m").
-func NewScheduler(sc *Scanner, interval string, logger *slog.Logger) (*Scheduler, error) {
-	d, err := time.ParseDuration(interval)
-	if err != nil {
-		return nil, fmt.Errorf("invalid scan schedule %q: %w (use Go duration format: 4h, 30m, etc.)", interval, err)
+// scheduleEntry is a group of scans run together on one schedule.
+type scheduleEntry struct {
+	schedule schedule.Schedule
+	requests []ScanRequest
+	next     time.Time
+}
+
+// NewScheduler creates a scheduler for sc's configured sources. spec is the
+// default schedule, a Go duration ("4h", "1h30m") or a cron expression
+// ("0 2 * * *" runs nightly at 02:00 local time); it may be empty if every
+// source to be scheduled sets its own.
+func NewScheduler(sc *Scanner, spec string, logger *slog.Logger) (*Scheduler, error) {
+	var entries []scheduleEntry
+	var unscheduled []ScanRequest
+	for _, cs := range sc.configuredScans() {
+		if cs.Schedule == "" {
+			unscheduled = append(unscheduled, cs.Request)
+			continue
+		}
+		sched, err := schedule.Parse(cs.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule for %s source %s: %w", cs.Request.Source, scanTarget(cs.Request), err)
+		}
+		entries = append(entries, scheduleEntry{schedule: sched, requests: []ScanRequest{cs.Request}})
+	}
+	if spec != "" {
+		sched, err := schedule.Parse(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scan schedule: %w", err)
+		}
+		entries = append(entries, scheduleEntry{schedule: sched, requests: unscheduled})
 	}
-	if d < 1*time.Minute {
-		return nil, fmt.Errorf("scan interval must be at least 1m, got %s", d)
+	if len(entries) == 0 {
+		return nil, errors.New("no scan schedule configured")
 	}
 	return &Scheduler{
-		scanner:  sc,
-		interval: d,
-		logger:   logger,
-		stopCh:   make(chan struct{}),
-		doneCh:   make(chan struct{}),
+		scanner: sc,
+		entries: entries,
+		clock:   schedule.RealClock{},
+		logger:  logger,
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
 	}, nil
 }
 
@@ -51,37 +82,56 @@ func (s *Scheduler) Start(ctx context.Context) {
 
 	go func() {
 		defer close(s.doneCh)
-		ticker := time.NewTicker(s.interval)
-		defer ticker.Stop()
 
-		s.logger.Info("scan scheduler started", "interval", s.interval.String())
+		now := s.clock.Now()
+		for i := range s.entries {
+			e := &s.entries[i]
+			e.next = e.schedule.Next(now)
+			s.logger.Info("scan scheduler started", "schedule", e.schedule.String(), "sources", len(e.requests))
+		}
 
-		for {
-			select {
-			case <-ticker.C:
-				if s.scanner.IsRunning() {
-					s.logger.Info("skipping scheduled scan, previous scan still running")
+		for schedule.Until(ctx, s.clock, s.earliest(), s.stopCh) {
+			now := s.clock.Now()
+			for i := range s.entries {
+				e := &s.entries[i]
+				if e.next.After(now) {
 					continue
 				}
-				s.logger.Info("starting scheduled scan")
-				results := s.scanner.RunAllConfigured(ctx)
-				for _, r := range results {
-					if r.Error != nil {
-						s.logger.Error("scheduled scan failed", "scanID", r.ScanID, "error", r.Error)
-					} else {
-						s.logger.Info("scheduled scan completed",
-							"scanID", r.ScanID, "nodes", r.NodesFound, "edges", r.EdgesFound)
-					}
-				}
-			case <-s.stopCh:
-				return
-			case <-ctx.Done():
-				return
+				e.next = e.schedule.Next(now)
+				s.run(ctx, e.requests)
 			}
 		}
 	}()
 }
 
+// earliest returns the soonest next run among the entries.
+func (s *Scheduler) earliest() time.Time {
+	next := s.entries[0].next
+	for _, e := range s.entries[1:] {
+		if e.next.Before(next) {
+			next = e.next
+		}
+	}
+	return next
+}
+
+func (s *Scheduler) run(ctx context.Context, reqs []ScanRequest) {
+	if s.scanner.IsRunning() {
+		s.logger.Info("skipping scheduled scan, previous scan still running")
+		return
+	}
+	s.logger.Info("starting scheduled scan", "sources", len(reqs))
+	for _, req := range reqs {
+		r := s.scanner.RunSync(ctx, req)
+		if r.Error != nil {
+			s.logger.Error("scheduled scan failed", "scanID", r.ScanID, "source", req.Source, "error", r.Error)
+		} else {
+			s.logger.Info("scheduled scan completed",
+				"scanID", r.ScanID, "source", req.Source, "nodes", r.NodesFound, "edges", r.EdgesFound)
+		}
+	}
+}
+
 // Stop halts the scheduler and waits for it to finish.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
@@ -96,3 +146,17 @@ func (s *Scheduler) Stop() {
 	})
 	<-s.doneCh
 }
+
+// scanTarget describes what req scans, for log and error messages.
+func scanTarget(req ScanRequest) string {
+	if req.Source == "kubernetes-live" {
+		if req.Context != "" {
+			return "context " + req.Context
+		}
+		return "live-cluster"
+	}
+	if req.Source == "aws" || req.Source == "gcp" {
+		return scanSourcePath(req)
+	}
+	return fmt.Sprintf("%v", req.Paths)
+}
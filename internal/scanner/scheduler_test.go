@@ -1,13 +1,19 @@
 package scanner
 
 import (
+	"context"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/config"
 )
 
 func TestNewScheduler_ValidDuration(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	sc, _ := newTestScanner(t)
 
 	tests := []struct {
 		interval string
@@ -20,14 +26,153 @@ func TestNewScheduler_ValidDuration(t *testing.T) {
 		{"30s", true},  // below 1m minimum
 		{"invalid", true},
 		{"", true},
+		{"0 2 * * *", false}, // cron: nightly at 02:00
+		{"@hourly", false},
+		{"0 25 * * *", true}, // cron hour out of range
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.interval, func(t *testing.T) {
-			_, err := NewScheduler(nil, tt.interval, logger)
+			_, err := NewScheduler(sc, tt.interval, logger)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewScheduler(%q) error = %v, wantErr %v", tt.interval, err, tt.wantErr)
 			}
 		})
 	}
 }
+
+// chanClock reports each requested wait on waits and fires when the test
+// sends on fire.
+type chanClock struct {
+	now   time.Time
+	waits chan time.Duration
+	fire  chan time.Time
+}
+
+func (c *chanClock) Now() time.Time { return c.now }
+
+func (c *chanClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+func TestScheduler_CronTick(t *testing.T) {
+	store := newTestStore(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	tfData, err := filepath.Abs("../parser/terraform/testdata/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Sources: config.SourcesConfig{
+		Terraform: []config.TerraformSource{{StateFile: tfData}},
+	}}
+
+	sched, err := NewScheduler(New(store, cfg, logger), "0 2 * * *", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &chanClock{
+		now:   time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC),
+		waits: make(chan time.Duration),
+		fire:  make(chan time.Time),
+	}
+	sched.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sched.Start(ctx)
+	defer sched.Stop()
+
+	if d := <-clock.waits; d != 4*time.Hour {
+		t.Errorf("first wait = %s, want 4h until 02:00", d)
+	}
+	scans, _ := store.ListScans(ctx, 10)
+	if len(scans) != 0 {
+		t.Fatalf("scan ran before the cron tick: %+v", scans)
+	}
+
+	clock.now = time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)
+	clock.fire <- clock.now
+	// The next wait is requested once the scheduled scan has finished.
+	if d := <-clock.waits; d != 24*time.Hour {
+		t.Errorf("second wait = %s, want 24h until the next 02:00", d)
+	}
+	scans, err = store.ListScans(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scans) != 1 || scans[0].Status != "completed" {
+		t.Errorf("expected one completed scan after the tick, got %+v", scans)
+	}
+}
+
+func TestNewScheduler_SourceSchedules(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	store := newTestStore(t)
+
+	cfg := &config.Config{Sources: config.SourcesConfig{
+		Compose: []config.ComposeSource{{Path: "/infra/compose", Schedule: "5m"}},
+	}}
+	if _, err := NewScheduler(New(store, cfg, logger), "", logger); err != nil {
+		t.Errorf("a source schedule alone should be enough: %v", err)
+	}
+
+	cfg.Sources.Compose[0].Schedule = "0 25 * * *"
+	if _, err := NewScheduler(New(store, cfg, logger), "4h", logger); err == nil {
+		t.Error("expected error for invalid source schedule")
+	}
+}
+
+func TestScheduler_IndependentSourceSchedules(t *testing.T) {
+	store := newTestStore(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	tfData, err := filepath.Abs("../parser/terraform/testdata/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfnData, err := filepath.Abs("../parser/cloudformation/testdata/simple.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{Sources: config.SourcesConfig{
+		Terraform:      []config.TerraformSource{{StateFile: tfData, Schedule: "1h"}},
+		CloudFormation: []config.CloudFormationSource{{Path: cfnData, Schedule: "*/5 * * * *"}},
+	}}
+
+	sched, err := NewScheduler(New(store, cfg, logger), "", logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	clock := &chanClock{now: start, waits: make(chan time.Duration), fire: make(chan time.Time)}
+	sched.clock = clock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sched.Start(ctx)
+	defer sched.Stop()
+
+	// Advance an hour, one wakeup at a time. The cron source is due every
+	// 5 minutes and the Terraform source once, at the end.
+	for clock.now.Before(start.Add(time.Hour)) {
+		d := <-clock.waits
+		if d != 5*time.Minute {
+			t.Fatalf("wait at %s = %s, want 5m", clock.now.Format("15:04"), d)
+		}
+		clock.now = clock.now.Add(d)
+		clock.fire <- clock.now
+	}
+	<-clock.waits // the last wakeup's scans are done
+
+	scans, err := store.ListScans(ctx, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, sc := range scans {
+		counts[sc.Source]++
+	}
+	if counts["cloudformation"] != 12 || counts["terraform"] != 1 {
+		t.Errorf("scans by source = %v, want cloudformation:12 terraform:1", counts)
+	}
+}
@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/config"
+	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// StaleSinceKey is the metadata key set on nodes a scan no longer finds
+// when scan.prune_missing is "stale". A later scan that finds the node
+// again replaces its metadata, clearing the mark.
+const StaleSinceKey = "stale_since"
+
+// sweepMissing applies scan.prune_missing to the nodes of a source that
+// the scan behind drift did not find, returning how many it pruned or
+// marked. Drift covers every node of the source, so only nodes within
+// what req scanned are swept: under its paths, backends, regions, project
+// or namespaces. Partial scans are not swept, since a path that failed to
+// parse would otherwise take all of its nodes with it, nor are filtered
+// scans, whose excluded files and types would look missing, nor scans
+// whose scope another configured source shares.
+func (s *Scanner) sweepMissing(ctx context.Context, drift *graph.DriftSummary, result *parser.ParseResult, req ScanRequest) (int, error) {
+	mode := s.cfg.Scan.PruneMissing
+	if mode != config.PruneStale && mode != config.PruneDelete {
+		return 0, nil
+	}
+	if drift == nil || len(drift.NodesRemoved) == 0 {
+		return 0, nil
+	}
+	if result.PathsFailed > 0 {
+		s.logger.Warn("not sweeping missing nodes after a partial scan",
+			"source", req.Source, "missing", len(drift.NodesRemoved), "paths_failed", result.PathsFailed)
+		return 0, nil
+	}
+	if s.filtered(req) {
+		s.logger.Info("not sweeping missing nodes after a filtered scan",
+			"source", req.Source, "missing", len(drift.NodesRemoved))
+		return 0, nil
+	}
+	if s.sharesScope(req) {
+		s.logger.Warn("not sweeping missing nodes another configured source may own",
+			"source", req.Source, "missing", len(drift.NodesRemoved))
+		return 0, nil
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	swept := 0
+	for _, gone := range drift.NodesRemoved {
+		n, err := s.store.GetNode(ctx, gone.ID)
+		if err != nil {
+			return swept, fmt.Errorf("loading missing node %s: %w", gone.ID, err)
+		}
+		if n == nil || !inScanScope(*n, req) {
+			continue
+		}
+		if mode == config.PruneDelete {
+			if err := s.store.DeleteNode(ctx, gone.ID); err != nil {
+				return swept, fmt.Errorf("deleting missing node %s: %w", gone.ID, err)
+			}
+			swept++
+			continue
+		}
+		if n.Metadata[StaleSinceKey] != "" {
+			continue // already marked
+		}
+		n.Metadata = maps.Clone(n.Metadata)
+		if n.Metadata == nil {
+			n.Metadata = map[string]string{}
+		}
+		n.Metadata[StaleSinceKey] = now
+		if err := s.store.UpsertNode(ctx, *n); err != nil {
+			return swept, fmt.Errorf("marking node %s stale: %w", gone.ID, err)
+		}
+		swept++
+	}
+	s.logger.Info("swept missing nodes", "source", req.Source, "mode", mode, "nodes", swept)
+	return swept, nil
+}
+
+// filtered reports whether include/exclude globs or excluded types, from
+// req or the scan config, hid part of what req scanned.
+func (s *Scanner) filtered(req ScanRequest) bool {
+	return len(req.Include)+len(req.Exclude)+len(req.ExcludeTypes) > 0 ||
+		len(s.cfg.Scan.Include)+len(s.cfg.Scan.Exclude)+len(s.cfg.Scan.ExcludeTypes) > 0
+}
+
+// sharesScope reports whether another configured source of req's type
+// reads a different cluster or account, by kubeconfig, context or profile,
+// over namespaces or regions that overlap req's. Nodes do not record which
+// of the two found them, so sweeping after req would take the other
+// source's nodes with it.
+func (s *Scanner) sharesScope(req ScanRequest) bool {
+	for _, c := range s.configuredScans() {
+		other := c.Request
+		if other.Source != req.Source ||
+			other.Kubeconfig == req.Kubeconfig && other.Context == req.Context && other.Profile == req.Profile {
+			continue
+		}
+		if overlaps(req.Namespaces, other.Namespaces) && overlaps(req.Regions, other.Regions) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether two scope lists share an entry. An empty list
+// covers everything.
+func overlaps(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(a, func(v string) bool { return slices.Contains(b, v) })
+}
+
+// inScanScope reports whether n falls within what req scanned. A request
+// without paths, backends, regions, project or namespaces covers its whole
+// source.
+func inScanScope(n models.Node, req ScanRequest) bool {
+	if len(req.Namespaces) > 0 && !slices.Contains(req.Namespaces, n.Metadata["namespace"]) {
+		return false
+	}
+	var scopes []string
+	for _, p := range req.Paths {
+		scopes = append(scopes, filepath.Clean(p))
+	}
+	if req.Playbooks != "" {
+		scopes = append(scopes, filepath.Clean(req.Playbooks))
+	}
+	scopes = append(scopes, req.Backends...)
+	for _, r := range req.Regions {
+		scopes = append(scopes, "aws:"+r)
+	}
+	if req.Project != "" {
+		scopes = append(scopes, "gcp:"+req.Project)
+	}
+	if len(scopes) == 0 {
+		return true
+	}
+
+	file := n.SourceFile
+	if !strings.Contains(file, "://") && !strings.HasPrefix(file, "aws:") && !strings.HasPrefix(file, "gcp:") {
+		file = filepath.Clean(file)
+	}
+	for _, scope := range scopes {
+		switch {
+		case file == scope,
+			scope == "." && !filepath.IsAbs(file) && !strings.HasPrefix(file, ".."),
+			strings.HasPrefix(file, strings.TrimSuffix(scope, "/")+"/"),
+			strings.HasPrefix(file, scope+string(filepath.Separator)):
+			return true
+		}
+	}
+	return false
+}
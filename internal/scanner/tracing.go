@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/matijazezelj/aib/internal/parser"
+)
+
+// tracerName names the scanner's OpenTelemetry tracer. Spans go nowhere
+// unless telemetry.Setup installed an exporter.
+const tracerName = "github.com/matijazezelj/aib/internal/scanner"
+
+// Span attribute keys.
+const (
+	attrSource       = attribute.Key("aib.source")
+	attrScanID       = attribute.Key("aib.scan_id")
+	attrPaths        = attribute.Key("aib.paths")
+	attrNodes        = attribute.Key("aib.nodes")
+	attrEdges        = attribute.Key("aib.edges")
+	attrPathsScanned = attribute.Key("aib.paths_scanned")
+	attrPathsFailed  = attribute.Key("aib.paths_failed")
+	attrNodesSwept   = attribute.Key("aib.nodes_swept")
+	attrStatus       = attribute.Key("aib.status")
+)
+
+// startSpan starts a span for req named name.
+func startSpan(ctx context.Context, name string, req ScanRequest, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(attrSource.String(req.Source)))
+	return otel.Tracer(tracerName).Start(ctx, name, opts...)
+}
+
+// endSpan records err on span, if any, and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// parseAttributes describes what a parser returned.
+func parseAttributes(r *parser.ParseResult) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attrNodes.Int(len(r.Nodes)),
+		attrEdges.Int(len(r.Edges)),
+		attrPathsScanned.Int(r.PathsScanned),
+		attrPathsFailed.Int(r.PathsFailed),
+	}
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/matijazezelj/aib/internal/parser"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// recordSpans installs a tracer provider that keeps finished spans in
+// memory, restoring the previous provider when the test ends.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return sr
+}
+
+func spanAttrs(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestRunSync_EmitsSpans(t *testing.T) {
+	sr := recordSpans(t)
+	sc, _ := newTestScanner(t)
+	sc.dispatch = func(context.Context, ScanRequest) (*parser.ParseResult, error) {
+		now := time.Now()
+		return &parser.ParseResult{
+			PathsScanned: 2,
+			PathsFailed:  1,
+			Nodes: []models.Node{
+				{ID: "tf:vm:web", Name: "web", Type: models.AssetVM, Source: "terraform", LastSeen: now, FirstSeen: now},
+				{ID: "tf:vm:db", Name: "db", Type: models.AssetVM, Source: "terraform", LastSeen: now, FirstSeen: now},
+			},
+			Edges: []models.Edge{{ID: "web->db", FromID: "tf:vm:web", ToID: "tf:vm:db", Type: models.EdgeDependsOn}},
+		}, nil
+	}
+
+	r := sc.RunSync(context.Background(), ScanRequest{Source: "terraform", Paths: []string{"/a", "/b"}})
+	if r.Error != nil {
+		t.Fatal(r.Error)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range sr.Ended() {
+		spans[s.Name()] = s
+	}
+	scan, parse := spans["scan"], spans["parse terraform"]
+	if scan == nil || parse == nil {
+		t.Fatalf("spans = %v, want scan and parse terraform", spans)
+	}
+	if parse.Parent().SpanID() != scan.SpanContext().SpanID() {
+		t.Error("parse span should be a child of the scan span")
+	}
+
+	attrs := spanAttrs(parse)
+	for key, want := range map[attribute.Key]int64{attrNodes: 2, attrEdges: 1, attrPaths: 2, attrPathsScanned: 2, attrPathsFailed: 1} {
+		if got := attrs[key].AsInt64(); got != want {
+			t.Errorf("parse span %s = %d, want %d", key, got, want)
+		}
+	}
+	if got := spanAttrs(scan)[attrScanID].AsInt64(); got != r.ScanID {
+		t.Errorf("scan span scan_id = %d, want %d", got, r.ScanID)
+	}
+}
+
+func TestRunSync_SpanRecordsError(t *testing.T) {
+	sr := recordSpans(t)
+	sc, _ := newTestScanner(t)
+	sc.dispatch = func(context.Context, ScanRequest) (*parser.ParseResult, error) {
+		return nil, errors.New("state file unreadable")
+	}
+
+	if r := sc.RunSync(context.Background(), ScanRequest{Source: "terraform", Paths: []string{"/a"}}); r.Error == nil {
+		t.Fatal("expected scan error")
+	}
+	for _, s := range sr.Ended() {
+		if s.Status().Code != codes.Error {
+			t.Errorf("span %q status = %v, want error", s.Name(), s.Status())
+		}
+	}
+	if len(sr.Ended()) != 2 {
+		t.Errorf("got %d spans, want 2", len(sr.Ended()))
+	}
+}
@@ -0,0 +1,103 @@
+// Package schedule parses the scan and cert probe schedules, which are
+// either Go durations ("4h") or cron expressions ("0 2 * * *"), and waits
+// for their next activation.
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MinInterval is the shortest accepted duration schedule.
+const MinInterval = time.Minute
+
+// Schedule reports when a job next runs.
+type Schedule interface {
+	// Next returns the first activation strictly after t.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+// Parse accepts a Go duration of at least MinInterval, run that long after
+// the previous activation, or a standard five-field cron expression or
+// descriptor such as "@daily", evaluated in local time.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if IsCron(spec) {
+		s, err := cron.ParseStandard(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		return cronSchedule{spec: spec, s: s}, nil
+	}
+	d, err := time.ParseDuration(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a duration (4h, 30m) nor a cron expression (0 2 * * *): %w", spec, err)
+	}
+	if d < MinInterval {
+		return nil, fmt.Errorf("interval must be at least %s, got %s", MinInterval, d)
+	}
+	return Every(d), nil
+}
+
+// IsCron reports whether spec is written as a cron expression rather than a
+// duration.
+func IsCron(spec string) bool {
+	return strings.ContainsAny(spec, " \t") || strings.HasPrefix(spec, "@")
+}
+
+// Every returns a schedule firing every d.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time { return t.Add(time.Duration(e)) }
+func (e every) String() string             { return time.Duration(e).String() }
+
+type cronSchedule struct {
+	spec string
+	s    cron.Schedule
+}
+
+func (c cronSchedule) Next(t time.Time) time.Time { return c.s.Next(t) }
+func (c cronSchedule) String() string             { return c.spec }
+
+// Clock abstracts time for schedulers so tests can drive them.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is the wall clock.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time { return time.Now() }
+
+// After returns time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Wait blocks until s next fires after the clock's current time and
+// returns true, or returns false once ctx or stop is done.
+func Wait(ctx context.Context, s Schedule, clock Clock, stop <-chan struct{}) bool {
+	return Until(ctx, clock, s.Next(clock.Now()), stop)
+}
+
+// Until blocks until the clock reaches t and returns true, or returns false
+// once ctx or stop is done.
+func Until(ctx context.Context, clock Clock, t time.Time, stop <-chan struct{}) bool {
+	select {
+	case <-clock.After(t.Sub(clock.Now())):
+		return true
+	case <-stop:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"4h", false},
+		{"1h30m", false},
+		{"1m", false},
+		{"0 2 * * *", false},
+		{"*/15 * * * *", false},
+		{"@daily", false},
+		{"30s", true}, // below MinInterval
+		{"invalid", true},
+		{"", true},
+		{"0 25 * * *", true}, // hour out of range
+		{"0 2 * *", true},    // four fields
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_Next(t *testing.T) {
+	from := time.Date(2026, 10, 17, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"4h", from.Add(4 * time.Hour)},
+		{"0 2 * * *", time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2026, 10, 17, 15, 30, 0, 0, time.UTC)},
+		{"0 6 * * 1", time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)}, // next Monday
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.spec, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, from, got, tt.want)
+		}
+	}
+}
+
+// fakeClock reports a fixed time and records each requested wait.
+type fakeClock struct {
+	now   time.Time
+	waits []time.Duration
+	fire  chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	return c.fire
+}
+
+func TestWait(t *testing.T) {
+	s, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{
+		now:  time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC),
+		fire: make(chan time.Time, 1),
+	}
+
+	clock.fire <- time.Time{}
+	if !Wait(context.Background(), s, clock, nil) {
+		t.Fatal("Wait returned false when the timer fired")
+	}
+	if len(clock.waits) != 1 || clock.waits[0] != 2*time.Hour+30*time.Minute {
+		t.Errorf("waits = %v, want [2h30m] until 02:00", clock.waits)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	if Wait(context.Background(), s, clock, stop) {
+		t.Error("Wait returned true after stop")
+	}
+}
@@ -1,15 +1,22 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/internal/parser"
 	"github.com/matijazezelj/aib/internal/scanner"
 	"github.com/matijazezelj/aib/pkg/models"
 )
@@ -20,14 +27,86 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
+// Error codes returned in API error responses. Codes are stable so clients
+// can branch on them instead of matching messages.
+const (
+	CodeValidation         = "VALIDATION_ERROR"
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeInsufficientScope  = "INSUFFICIENT_SCOPE"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeNodeNotFound       = "NODE_NOT_FOUND"
+	CodeNoPath             = "NO_PATH"
+	CodeScanNotFound       = "SCAN_NOT_FOUND"
+	CodeDiffNotFound       = "DIFF_NOT_FOUND"
+	CodePathNotAllowed     = "PATH_NOT_ALLOWED"
+	CodeScanNotReplayable  = "SCAN_NOT_REPLAYABLE"
+	CodeScanNotRunning     = "SCAN_NOT_RUNNING"
+	CodeScannerUnavailable = "SCANNER_UNAVAILABLE"
+	CodeScanStartFailed    = "SCAN_START_FAILED"
+	CodeReadOnly           = "READ_ONLY"
+	CodeGraphUnavailable   = "GRAPH_BACKEND_UNAVAILABLE"
+	CodeInternal           = "INTERNAL_ERROR"
+)
+
+// apiError is the body of every API error response:
+// {"error": {"code": "NODE_NOT_FOUND", "message": "node not found"}}.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, map[string]apiError{"error": {Code: code, Message: msg}})
+}
+
+// writeEngineError reports a graph engine failure, distinguishing an
+// unreachable graph backend (503) from other internal errors (500).
+func (s *Server) writeEngineError(w http.ResponseWriter, err error, msg string, args ...any) {
+	s.logger.Error(msg, append(args, "error", err)...)
+	if graph.IsBackendUnavailable(err) {
+		writeError(w, http.StatusServiceUnavailable, CodeGraphUnavailable, "graph backend unavailable")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 }
 
 func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
 }
 
+// readinessTimeout bounds each dependency check made by /readyz.
+const readinessTimeout = 2 * time.Second
+
+// pinger is implemented by graph engines backed by an external service.
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// handleReadyz reports whether the server's dependencies are reachable:
+// the SQLite store and, when in use, Memgraph. /healthz only shows that the
+// process is up; /readyz returns 503 when any check fails, so an
+// orchestrator stops routing traffic to the server until it recovers.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	status, code := "ok", http.StatusOK
+	checks := make(map[string]string)
+	check := func(name string, ping func(context.Context) error) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+		if err := ping(ctx); err != nil {
+			s.logger.Warn("readiness check failed", "dependency", name, "error", err)
+			checks[name] = "unavailable"
+			status, code = "degraded", http.StatusServiceUnavailable
+			return
+		}
+		checks[name] = "ok"
+	}
+	check("sqlite", s.store.Ping)
+	if p, ok := s.engine.(pinger); ok {
+		check("memgraph", p.Ping)
+	}
+	writeJSON(w, code, map[string]any{"status": status, "checks": checks})
+}
+
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -48,14 +127,14 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 
 	_, _ = fmt.Fprintf(w, "# HELP aib_nodes_by_type Number of nodes by asset type.\n")
 	_, _ = fmt.Fprintf(w, "# TYPE aib_nodes_by_type gauge\n")
-	for t, c := range nodesByType {
-		_, _ = fmt.Fprintf(w, "aib_nodes_by_type{type=%q} %d\n", t, c)
+	for _, t := range slices.Sorted(maps.Keys(nodesByType)) {
+		_, _ = fmt.Fprintf(w, "aib_nodes_by_type{type=%q} %d\n", t, nodesByType[t])
 	}
 
 	_, _ = fmt.Fprintf(w, "# HELP aib_edges_by_type Number of edges by relationship type.\n")
 	_, _ = fmt.Fprintf(w, "# TYPE aib_edges_by_type gauge\n")
-	for t, c := range edgesByType {
-		_, _ = fmt.Fprintf(w, "aib_edges_by_type{type=%q} %d\n", t, c)
+	for _, t := range slices.Sorted(maps.Keys(edgesByType)) {
+		_, _ = fmt.Fprintf(w, "aib_edges_by_type{type=%q} %d\n", t, edgesByType[t])
 	}
 
 	expiringCerts, _ := s.tracker.ExpiringCerts(ctx, 30)
@@ -81,6 +160,24 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	_, _ = fmt.Fprintf(w, "# TYPE aib_scans_failed_total gauge\n")
 	_, _ = fmt.Fprintf(w, "aib_scans_failed_total %d\n", failed)
 
+	// ListScans returns the newest scan first.
+	if len(scans) > 0 {
+		last := scans[0]
+		_, _ = fmt.Fprintf(w, "# HELP aib_last_scan_timestamp_seconds Start time of the most recent scan.\n")
+		_, _ = fmt.Fprintf(w, "# TYPE aib_last_scan_timestamp_seconds gauge\n")
+		_, _ = fmt.Fprintf(w, "aib_last_scan_timestamp_seconds %d\n", last.StartedAt.Unix())
+
+		_, _ = fmt.Fprintf(w, "# HELP aib_last_scan_status Status of the most recent scan (1 for the current status).\n")
+		_, _ = fmt.Fprintf(w, "# TYPE aib_last_scan_status gauge\n")
+		_, _ = fmt.Fprintf(w, "aib_last_scan_status{source=%q,status=%q} 1\n", last.Source, last.Status)
+
+		if last.FinishedAt != nil {
+			_, _ = fmt.Fprintf(w, "# HELP aib_last_scan_duration_seconds Duration of the most recent scan.\n")
+			_, _ = fmt.Fprintf(w, "# TYPE aib_last_scan_duration_seconds gauge\n")
+			_, _ = fmt.Fprintf(w, "aib_last_scan_duration_seconds %g\n", last.FinishedAt.Sub(last.StartedAt).Seconds())
+		}
+	}
+
 	_, _ = fmt.Fprintf(w, "# HELP aib_build_info AIB build information.\n")
 	_, _ = fmt.Fprintf(w, "# TYPE aib_build_info gauge\n")
 	_, _ = fmt.Fprintf(w, "aib_build_info{version=%q} 1\n", s.version)
@@ -91,13 +188,13 @@ func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
 	nodes, err := s.store.ListNodes(ctx, graph.NodeFilter{})
 	if err != nil {
 		s.logger.Error("listing nodes", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	edges, err := s.store.ListEdges(ctx, graph.EdgeFilter{})
 	if err != nil {
 		s.logger.Error("listing edges", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 
@@ -114,44 +211,358 @@ func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Page sizes for GET /graph/nodes: the default when ?limit= is missing, and
+// the largest ?limit= accepted.
+const (
+	defaultNodePageSize = 500
+	maxNodePageSize     = 5000
+)
+
+// handleNodes returns one page of nodes. The total number of matching nodes
+// is sent in the X-Total-Count header so clients can page with ?offset=.
 func (s *Server) handleNodes(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+	q := r.URL.Query()
 	filter := graph.NodeFilter{
-		Type:     r.URL.Query().Get("type"),
-		Source:   r.URL.Query().Get("source"),
-		Provider: r.URL.Query().Get("provider"),
+		Type:     q.Get("type"),
+		Source:   q.Get("source"),
+		Provider: q.Get("provider"),
+	}
+	filter.TagKey, filter.TagValue, _ = strings.Cut(q.Get("tag"), "=")
+	s.writeNodePage(w, r, filter)
+}
+
+// handleQuery lists the nodes matching a filter expression such as
+// type=vm AND metadata.region=us-east1.
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	src := strings.TrimSpace(r.URL.Query().Get("expr"))
+	if src == "" {
+		writeError(w, http.StatusBadRequest, CodeValidation, "expr parameter required")
+		return
+	}
+	expr, err := graph.ParseFilterExpr(src)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, err.Error())
+		return
+	}
+	s.writeNodePage(w, r, graph.NodeFilter{Expr: expr})
+}
+
+// writeNodePage writes one page of the nodes matching filter, applying the
+// request's limit, offset and sort parameters, with the total match count
+// in X-Total-Count.
+func (s *Server) writeNodePage(w http.ResponseWriter, r *http.Request, filter graph.NodeFilter) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter.OrderBy = q.Get("sort")
+	filter.Limit = defaultNodePageSize
+	if l := q.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > maxNodePageSize {
+			writeError(w, http.StatusBadRequest, CodeValidation, fmt.Sprintf("limit must be an integer between 1 and %d", maxNodePageSize))
+			return
+		}
+		filter.Limit = parsed
+	}
+	if o := q.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, CodeValidation, "offset must be a non-negative integer")
+			return
+		}
+		filter.Offset = parsed
+	}
+	if !graph.ValidNodeOrder(filter.OrderBy) {
+		writeError(w, http.StatusBadRequest, CodeValidation, "unsupported sort field: "+filter.OrderBy)
+		return
 	}
 
+	total, err := s.store.CountNodes(ctx, filter)
+	if err != nil {
+		s.logger.Error("counting nodes", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
 	nodes, err := s.store.ListNodes(ctx, filter)
 	if err != nil {
 		s.logger.Error("listing nodes", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
+	if nodes == nil {
+		nodes = []models.Node{}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, CodeValidation, "q parameter required")
+		return
+	}
+	filter := graph.NodeFilter{
+		Type:     q.Get("type"),
+		Source:   q.Get("source"),
+		Provider: q.Get("provider"),
+		Limit:    defaultNodePageSize,
+	}
+	if l := q.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > maxNodePageSize {
+			writeError(w, http.StatusBadRequest, CodeValidation, fmt.Sprintf("limit must be an integer between 1 and %d", maxNodePageSize))
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	nodes, err := s.store.SearchNodes(ctx, query, filter)
+	if err != nil {
+		s.logger.Error("searching nodes", "query", query, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	if nodes == nil {
+		nodes = []models.Node{}
+	}
 	writeJSON(w, http.StatusOK, nodes)
 }
 
+// nodeSubresources are the views served under /api/v1/graph/nodes/{id}/.
+// Node IDs may contain slashes, so they are split off the wildcard path by
+// handleNodeByID rather than routed by the mux.
+var nodeSubresources = map[string]func(s *Server, w http.ResponseWriter, r *http.Request, nodeID string){
+	"neighbors": (*Server).writeNeighbors,
+	"deps":      (*Server).writeDependencyChain,
+	"tags":      (*Server).writeNodeTags,
+}
+
 func (s *Server) handleNodeByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	id := r.PathValue("id")
 	if id == "" {
-		writeError(w, http.StatusBadRequest, "node id required")
+		writeError(w, http.StatusBadRequest, CodeValidation, "node id required")
 		return
 	}
 
 	node, err := s.store.GetNode(ctx, id)
 	if err != nil {
 		s.logger.Error("getting node", "id", id, "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	if node == nil {
-		writeError(w, http.StatusNotFound, "node not found")
+		// A node whose own ID ends in "/deps" wins over the subresource.
+		if i := strings.LastIndex(id, "/"); i > 0 {
+			if serve, ok := nodeSubresources[id[i+1:]]; ok {
+				if s.requireNode(w, r, id[:i]) {
+					serve(s, w, r, id[:i])
+				}
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, CodeNodeNotFound, "node not found")
 		return
 	}
 	writeJSON(w, http.StatusOK, node)
 }
 
+// requireNode writes a 404 and returns false if no node has the given ID.
+func (s *Server) requireNode(w http.ResponseWriter, r *http.Request, id string) bool {
+	node, err := s.store.GetNode(r.Context(), id)
+	if err != nil {
+		s.logger.Error("getting node", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return false
+	}
+	if node == nil {
+		writeError(w, http.StatusNotFound, CodeNodeNotFound, fmt.Sprintf("node %q not found", id))
+		return false
+	}
+	return true
+}
+
+// writeNodeTags serves the tags of nodeID.
+func (s *Server) writeNodeTags(w http.ResponseWriter, r *http.Request, nodeID string) {
+	tags, err := s.store.GetTags(r.Context(), nodeID)
+	if err != nil {
+		s.logger.Error("getting tags", "nodeId", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// writeNeighbors serves the nodes directly connected to nodeID and the
+// edges joining them to it.
+func (s *Server) writeNeighbors(w http.ResponseWriter, r *http.Request, nodeID string) {
+	ctx := r.Context()
+	nodes, err := s.engine.Neighbors(ctx, nodeID)
+	if err != nil {
+		s.writeEngineError(w, err, "neighbors", "nodeId", nodeID)
+		return
+	}
+	out, err := s.store.ListEdges(ctx, graph.EdgeFilter{FromID: nodeID})
+	if err != nil {
+		s.logger.Error("listing edges", "nodeId", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	in, err := s.store.ListEdges(ctx, graph.EdgeFilter{ToID: nodeID})
+	if err != nil {
+		s.logger.Error("listing edges", "nodeId", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	edges := out
+	for _, e := range in {
+		if e.FromID != nodeID { // self-loops are already in out
+			edges = append(edges, e)
+		}
+	}
+	if nodes == nil {
+		nodes = []models.Node{}
+	}
+	if edges == nil {
+		edges = []models.Edge{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"nodes": nodes,
+		"edges": edges,
+	})
+}
+
+// handleDeleteNode removes a node and its edges. Unlike scan triggers, the
+// route is registered in read-only mode too, so clients get an explicit 403
+// rather than a 405.
+func (s *Server) handleDeleteNode(w http.ResponseWriter, r *http.Request) {
+	if s.readOnly {
+		writeError(w, http.StatusForbidden, CodeReadOnly, "server is read-only")
+		return
+	}
+	ctx := r.Context()
+	id := r.PathValue("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, CodeValidation, "node id required")
+		return
+	}
+
+	node, err := s.store.GetNode(ctx, id)
+	if err != nil {
+		s.logger.Error("getting node", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	if node == nil {
+		writeError(w, http.StatusNotFound, CodeNodeNotFound, "node not found")
+		return
+	}
+	if err := s.store.DeleteNode(ctx, id); err != nil {
+		s.logger.Error("deleting node", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	s.logger.Info("deleted node", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePatchNodeTags updates the tags of a node at
+// /api/v1/graph/nodes/{id}/tags. The body is a JSON object of keys to set;
+// a null value removes the key. It responds with the node's tags.
+func (s *Server) handlePatchNodeTags(w http.ResponseWriter, r *http.Request) {
+	if s.readOnly {
+		writeError(w, http.StatusForbidden, CodeReadOnly, "server is read-only")
+		return
+	}
+	id, ok := strings.CutSuffix(r.PathValue("id"), "/tags")
+	if !ok || id == "" {
+		writeError(w, http.StatusBadRequest, CodeValidation, "only /api/v1/graph/nodes/{id}/tags can be patched")
+		return
+	}
+
+	var body map[string]*string
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid JSON body: expected an object of tag keys to string values or null")
+		return
+	}
+	set := make(map[string]string)
+	var remove []string
+	for k, v := range body {
+		if v == nil {
+			remove = append(remove, k)
+			continue
+		}
+		if err := graph.ValidateTag(k, *v); err != nil {
+			writeError(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		set[k] = *v
+	}
+
+	tags, err := s.store.UpdateTags(r.Context(), id, set, remove)
+	if errors.Is(err, graph.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, CodeNodeNotFound, fmt.Sprintf("node %q not found", id))
+		return
+	}
+	if err != nil {
+		s.logger.Error("updating tags", "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	s.logger.Info("updated node tags", "id", id, "set", len(set), "removed", len(remove))
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// handlePruneNodes deletes every node matching the stale_days, source and
+// keep_sources filters, the same ones graph prune takes, in one
+// transaction. At least one of stale_days and source is required so a bare
+// DELETE cannot empty the graph.
+func (s *Server) handlePruneNodes(w http.ResponseWriter, r *http.Request) {
+	if s.readOnly {
+		writeError(w, http.StatusForbidden, CodeReadOnly, "server is read-only")
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+	filter := graph.NodeFilter{Source: q.Get("source")}
+	if d := q.Get("stale_days"); d != "" {
+		days, err := strconv.Atoi(d)
+		if err != nil || days <= 0 {
+			writeError(w, http.StatusBadRequest, CodeValidation, "stale_days must be a positive integer")
+			return
+		}
+		filter.StaleDays = days
+	}
+	if filter.StaleDays == 0 && filter.Source == "" {
+		writeError(w, http.StatusBadRequest, CodeValidation, "specify at least one filter: stale_days or source")
+		return
+	}
+	if keep := q.Get("keep_sources"); keep != "" {
+		filter.ExcludeSources = strings.Split(keep, ",")
+	}
+
+	nodes, err := s.store.ListNodes(ctx, filter)
+	if err != nil {
+		s.logger.Error("listing nodes to prune", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	ids := make([]string, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.ID
+	}
+	deleted, err := s.store.DeleteNodes(ctx, ids)
+	if err != nil {
+		s.logger.Error("pruning nodes", "matched", len(ids), "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	s.logger.Info("pruned nodes", "deleted", deleted, "stale_days", filter.StaleDays, "source", filter.Source)
+	writeJSON(w, http.StatusOK, map[string]int{"deleted": deleted})
+}
+
 func (s *Server) handleEdges(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	filter := graph.EdgeFilter{
@@ -163,7 +574,7 @@ func (s *Server) handleEdges(w http.ResponseWriter, r *http.Request) {
 	edges, err := s.store.ListEdges(ctx, filter)
 	if err != nil {
 		s.logger.Error("listing edges", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	writeJSON(w, http.StatusOK, edges)
@@ -173,32 +584,112 @@ func (s *Server) handleImpact(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	nodeID := r.PathValue("nodeId")
 	if nodeID == "" {
-		writeError(w, http.StatusBadRequest, "node id required")
+		writeError(w, http.StatusBadRequest, CodeValidation, "node id required")
+		return
+	}
+	// As with node subresources, a node whose own ID ends in "/report"
+	// wins over the report view.
+	if base, ok := strings.CutSuffix(nodeID, "/report"); ok && base != "" {
+		node, err := s.store.GetNode(ctx, nodeID)
+		if err != nil {
+			s.logger.Error("getting node", "id", nodeID, "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+			return
+		}
+		if node == nil {
+			s.writeImpactReport(w, r, base)
+			return
+		}
+	}
+
+	result, err := s.engine.BlastRadius(ctx, nodeID, graph.ImpactOptions{})
+	if err != nil {
+		s.writeEngineError(w, err, "blast radius", "nodeId", nodeID)
 		return
 	}
 
-	result, err := s.engine.BlastRadius(ctx, nodeID)
+	root, err := s.store.GetNode(ctx, nodeID)
 	if err != nil {
-		s.logger.Error("blast radius", "nodeId", nodeID, "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.logger.Error("getting node", "id", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
+	result.ApplyWarnings(root, s.tracker.ExpiryWarning)
+	if r.URL.Query().Get("redundancy") == "true" {
+		result.ApplyRedundancy(root)
+	}
 	writeJSON(w, http.StatusOK, result)
 }
 
+// writeImpactReport serves nodeID's blast radius as a graph.ImpactReport,
+// including expiry warnings for affected assets. It accepts the edge_type
+// (repeatable), depth, direction and redundancy parameters.
+func (s *Server) writeImpactReport(w http.ResponseWriter, r *http.Request, nodeID string) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	opts := graph.ImpactOptions{}
+	if d := q.Get("direction"); d != "" {
+		dir, err := graph.ParseDirection(d)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeValidation, err.Error())
+			return
+		}
+		opts.Direction = dir
+	}
+	for _, t := range q["edge_type"] {
+		opts.EdgeTypes = append(opts.EdgeTypes, models.EdgeType(t))
+	}
+	if d := q.Get("depth"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, CodeValidation, "depth must be a non-negative integer")
+			return
+		}
+		opts.MaxDepth = parsed
+	}
+
+	node, err := s.store.GetNode(ctx, nodeID)
+	if err != nil {
+		s.logger.Error("getting node", "id", nodeID, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	if node == nil {
+		writeError(w, http.StatusNotFound, CodeNodeNotFound, fmt.Sprintf("node %q not found", nodeID))
+		return
+	}
+	tree, err := s.engine.BlastRadiusTree(ctx, nodeID, opts)
+	if err != nil {
+		s.writeEngineError(w, err, "blast radius tree", "nodeId", nodeID)
+		return
+	}
+
+	report := graph.NewImpactReport(node, tree, opts, s.tracker.ExpiryWarning)
+	if q.Get("redundancy") == "true" {
+		report.ApplyRedundancy()
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
 func (s *Server) handleShortestPath(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	fromID := r.URL.Query().Get("from")
 	toID := r.URL.Query().Get("to")
 	if fromID == "" || toID == "" {
-		writeError(w, http.StatusBadRequest, "both 'from' and 'to' query parameters are required")
+		writeError(w, http.StatusBadRequest, CodeValidation, "both 'from' and 'to' query parameters are required")
+		return
+	}
+	if !s.requireNode(w, r, fromID) || !s.requireNode(w, r, toID) {
 		return
 	}
 
 	nodes, edges, err := s.engine.ShortestPath(ctx, fromID, toID)
+	if errors.Is(err, graph.ErrNoPath) {
+		writeError(w, http.StatusNotFound, CodeNoPath, err.Error())
+		return
+	}
 	if err != nil {
-		s.logger.Error("shortest path", "from", fromID, "to", toID, "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.writeEngineError(w, err, "shortest path", "from", fromID, "to", toID)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -208,24 +699,30 @@ func (s *Server) handleShortestPath(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDependencyChain(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
 	nodeID := r.PathValue("nodeId")
 	if nodeID == "" {
-		writeError(w, http.StatusBadRequest, "node id required")
+		writeError(w, http.StatusBadRequest, CodeValidation, "node id required")
 		return
 	}
+	if s.requireNode(w, r, nodeID) {
+		s.writeDependencyChain(w, r, nodeID)
+	}
+}
 
+// writeDependencyChain serves nodeID's downstream dependencies. depth
+// defaults to 10 and is clamped to 1–50; a non-numeric depth is ignored.
+func (s *Server) writeDependencyChain(w http.ResponseWriter, r *http.Request, nodeID string) {
+	ctx := r.Context()
 	depth := 10
 	if d := r.URL.Query().Get("depth"); d != "" {
-		if parsed, err := strconv.Atoi(d); err == nil && parsed >= 1 && parsed <= 50 {
-			depth = parsed
+		if parsed, err := strconv.Atoi(d); err == nil {
+			depth = min(max(parsed, 1), 50)
 		}
 	}
 
-	nodes, err := s.engine.DependencyChain(ctx, nodeID, depth)
+	nodes, err := s.engine.DependencyChain(ctx, nodeID, depth, "")
 	if err != nil {
-		s.logger.Error("dependency chain", "nodeId", nodeID, "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.writeEngineError(w, err, "dependency chain", "nodeId", nodeID)
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -239,7 +736,7 @@ func (s *Server) handleCerts(w http.ResponseWriter, r *http.Request) {
 	certs, err := s.tracker.ListCerts(ctx)
 	if err != nil {
 		s.logger.Error("listing certs", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	writeJSON(w, http.StatusOK, certs)
@@ -257,7 +754,7 @@ func (s *Server) handleExpiringCerts(w http.ResponseWriter, r *http.Request) {
 	certs, err := s.tracker.ExpiringCerts(ctx, days)
 	if err != nil {
 		s.logger.Error("listing expiring certs", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	writeJSON(w, http.StatusOK, certs)
@@ -287,7 +784,7 @@ func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
 	scans, err := s.store.ListScans(ctx, 50)
 	if err != nil {
 		s.logger.Error("listing scans", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	writeJSON(w, http.StatusOK, scans)
@@ -295,18 +792,28 @@ func (s *Server) handleScans(w http.ResponseWriter, r *http.Request) {
 
 // scanTriggerRequest is the JSON body for POST /api/v1/scan.
 type scanTriggerRequest struct {
-	Source     string   `json:"source"`
-	Paths      []string `json:"paths,omitempty"`
-	Remote     bool     `json:"remote,omitempty"`
-	Workspace  string   `json:"workspace,omitempty"`
-	Helm       bool     `json:"helm,omitempty"`
-	ValuesFile string   `json:"values_file,omitempty"`
-	Namespaces []string `json:"namespaces,omitempty"`
-	Playbooks  string   `json:"playbooks,omitempty"`
+	Source       string   `json:"source"`
+	Paths        []string `json:"paths,omitempty"`
+	Remote       bool     `json:"remote,omitempty"`
+	Workspace    string   `json:"workspace,omitempty"`
+	Helm         bool     `json:"helm,omitempty"`
+	ValuesFile   string   `json:"values_file,omitempty"`
+	Namespaces   []string `json:"namespaces,omitempty"`
+	Playbooks    string   `json:"playbooks,omitempty"`
+	Regions      []string `json:"regions,omitempty"`
+	Project      string   `json:"project,omitempty"`
+	Strict       bool     `json:"strict,omitempty"`
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	ExcludeTypes []string `json:"exclude_types,omitempty"`
 }
 
 var nsRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*[a-z0-9]$|^[a-z0-9]$`)
 
+var regionRegexp = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+var projectRegexp = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
 // validatePath checks a single file path for traversal and requires absolute paths.
 func validatePath(p string) error {
 	cleaned := filepath.Clean(p)
@@ -341,7 +848,15 @@ func validateScanRequest(req scanTriggerRequest) error {
 			return fmt.Errorf("invalid namespace %q (must match [a-z0-9-]+)", ns)
 		}
 	}
-	return nil
+	for _, r := range req.Regions {
+		if !regionRegexp.MatchString(r) {
+			return fmt.Errorf("invalid AWS region %q (e.g. eu-west-1)", r)
+		}
+	}
+	if req.Project != "" && !projectRegexp.MatchString(req.Project) {
+		return fmt.Errorf("invalid GCP project ID %q", req.Project)
+	}
+	return parser.PathFilter{Include: req.Include, Exclude: req.Exclude}.Validate()
 }
 
 // isPathAllowed checks whether the given path falls within one of the
@@ -364,34 +879,78 @@ func (s *Server) isPathAllowed(p string) bool {
 	return false
 }
 
-func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
-	var req scanTriggerRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid JSON body")
-		return
-	}
-
+// checkScanRequest validates a scan request from the API, writing a 400
+// or 403 error and returning false if it may not run. Paths, values_file
+// and playbooks must fall within the allowed scan paths.
+func (s *Server) checkScanRequest(w http.ResponseWriter, req scanTriggerRequest) bool {
 	validSources := map[string]bool{
 		"terraform": true, "terraform-plan": true, "kubernetes": true,
 		"kubernetes-live": true, "ansible": true, "compose": true,
-		"cloudformation": true, "pulumi": true, "all": true,
+		"cloudformation": true, "pulumi": true, "aws": true, "gcp": true, "all": true,
 	}
 	if !validSources[req.Source] {
-		writeError(w, http.StatusBadRequest,
-			"source must be one of: terraform, terraform-plan, kubernetes, kubernetes-live, ansible, compose, cloudformation, pulumi, all")
+		writeError(w, http.StatusBadRequest, CodeValidation,
+			"source must be one of: terraform, terraform-plan, kubernetes, kubernetes-live, ansible, compose, cloudformation, pulumi, aws, gcp, all")
+		return false
+	}
+	if req.Source == "all" {
+		return true
+	}
+
+	switch req.Source {
+	case "kubernetes-live", "aws":
+	case "gcp":
+		if req.Project == "" {
+			writeError(w, http.StatusBadRequest, CodeValidation, "project required for gcp scans")
+			return false
+		}
+	default:
+		if len(req.Paths) == 0 {
+			writeError(w, http.StatusBadRequest, CodeValidation, "paths required for file-based scans")
+			return false
+		}
+	}
+
+	if err := validateScanRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, err.Error())
+		return false
+	}
+
+	paths := slices.Clone(req.Paths)
+	for _, p := range []string{req.ValuesFile, req.Playbooks} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range paths {
+		if !s.isPathAllowed(p) {
+			writeError(w, http.StatusForbidden, CodePathNotAllowed, fmt.Sprintf("path %q is not in the allowed scan paths", p))
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
+	var req scanTriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid JSON body")
+		return
+	}
+	if !s.checkScanRequest(w, req) {
 		return
 	}
 
 	if req.Source == "all" {
 		if s.scanner == nil {
-			writeError(w, http.StatusServiceUnavailable, "scanner not configured")
+			writeError(w, http.StatusServiceUnavailable, CodeScannerUnavailable, "scanner not configured")
 			return
 		}
 		scanReq := scanner.ScanRequest{Source: "all"}
 		scanID, err := s.scanner.RunAsync(r.Context(), scanReq)
 		if err != nil {
 			s.logger.Error("triggering scan", "error", err)
-			writeError(w, http.StatusInternalServerError, "failed to start scan")
+			writeError(w, http.StatusInternalServerError, CodeScanStartFailed, "failed to start scan")
 			return
 		}
 		writeJSON(w, http.StatusAccepted, map[string]any{
@@ -401,68 +960,191 @@ func (s *Server) handleTriggerScan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Source != "kubernetes-live" && len(req.Paths) == 0 {
-		writeError(w, http.StatusBadRequest, "paths required for file-based scans")
+	if s.scanner == nil {
+		writeError(w, http.StatusServiceUnavailable, CodeScannerUnavailable, "scanner not configured")
 		return
 	}
 
-	if err := validateScanRequest(req); err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
-		return
+	scanReq := scanner.ScanRequest{
+		Source:       req.Source,
+		Paths:        req.Paths,
+		Remote:       req.Remote,
+		Workspace:    req.Workspace,
+		Helm:         req.Helm,
+		ValuesFile:   req.ValuesFile,
+		Namespaces:   req.Namespaces,
+		Playbooks:    req.Playbooks,
+		Regions:      req.Regions,
+		Project:      req.Project,
+		Strict:       req.Strict,
+		Include:      req.Include,
+		Exclude:      req.Exclude,
+		ExcludeTypes: req.ExcludeTypes,
 	}
 
-	for _, p := range req.Paths {
-		if !s.isPathAllowed(p) {
-			writeError(w, http.StatusForbidden, fmt.Sprintf("path %q is not in the allowed scan paths", p))
-			return
-		}
+	scanID, err := s.scanner.RunAsync(r.Context(), scanReq)
+	if err != nil {
+		s.logger.Error("triggering scan", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeScanStartFailed, "failed to start scan")
+		return
 	}
 
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"status":  "scan triggered",
+		"scan_id": scanID,
+	})
+}
+
+func (s *Server) handleReplayScan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid scan ID")
+		return
+	}
 	if s.scanner == nil {
-		writeError(w, http.StatusServiceUnavailable, "scanner not configured")
+		writeError(w, http.StatusServiceUnavailable, CodeScannerUnavailable, "scanner not configured")
 		return
 	}
 
-	scanReq := scanner.ScanRequest{
-		Source:     req.Source,
-		Paths:      req.Paths,
-		Remote:     req.Remote,
-		Workspace:  req.Workspace,
-		Helm:       req.Helm,
-		ValuesFile: req.ValuesFile,
-		Namespaces: req.Namespaces,
-		Playbooks:  req.Playbooks,
+	scanReq, err := s.scanner.ReplayRequest(r.Context(), id)
+	switch {
+	case errors.Is(err, scanner.ErrScanNotFound):
+		writeError(w, http.StatusNotFound, CodeScanNotFound, "scan not found")
+		return
+	case errors.Is(err, scanner.ErrNotReplayable):
+		writeError(w, http.StatusConflict, CodeScanNotReplayable, err.Error())
+		return
+	case err != nil:
+		s.logger.Error("loading scan request", "scanID", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+
+	// Scans started from the CLI can set fields the API does not accept,
+	// and the allowlist may have changed since the original scan ran, so
+	// the stored request gets the same checks as POST /scan.
+	if field := apiOnlyViolation(scanReq); field != "" {
+		writeError(w, http.StatusBadRequest, CodeValidation,
+			fmt.Sprintf("scan %d sets %s, which POST /scan does not accept; replay it with aib scan replay", id, field))
+		return
+	}
+	if !s.checkScanRequest(w, scanTriggerRequest{
+		Source:       scanReq.Source,
+		Paths:        scanReq.Paths,
+		Remote:       scanReq.Remote,
+		Workspace:    scanReq.Workspace,
+		Helm:         scanReq.Helm,
+		ValuesFile:   scanReq.ValuesFile,
+		Namespaces:   scanReq.Namespaces,
+		Playbooks:    scanReq.Playbooks,
+		Regions:      scanReq.Regions,
+		Project:      scanReq.Project,
+		Strict:       scanReq.Strict,
+		Include:      scanReq.Include,
+		Exclude:      scanReq.Exclude,
+		ExcludeTypes: scanReq.ExcludeTypes,
+	}) {
+		return
 	}
 
 	scanID, err := s.scanner.RunAsync(r.Context(), scanReq)
 	if err != nil {
-		s.logger.Error("triggering scan", "error", err)
-		writeError(w, http.StatusInternalServerError, "failed to start scan")
+		s.logger.Error("replaying scan", "scanID", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeScanStartFailed, "failed to start scan")
 		return
 	}
 
 	writeJSON(w, http.StatusAccepted, map[string]any{
-		"status":  "scan triggered",
-		"scan_id": scanID,
+		"status":    "scan triggered",
+		"scan_id":   scanID,
+		"replay_of": id,
 	})
 }
 
+// apiOnlyViolation returns the JSON name of the first field of req that
+// POST /scan does not accept, or "" if the API could have sent req. The
+// accepted fields are those of scanTriggerRequest, so fields added to
+// ScanRequest are rejected until the API accepts them too.
+func apiOnlyViolation(req scanner.ScanRequest) string {
+	v := reflect.ValueOf(req)
+	for i := range v.NumField() {
+		name := jsonFieldName(v.Type().Field(i))
+		if !apiScanFields[name] && !v.Field(i).IsZero() {
+			return name
+		}
+	}
+	return ""
+}
+
+// apiScanFields holds the JSON names of the fields POST /scan accepts.
+var apiScanFields = func() map[string]bool {
+	t := reflect.TypeFor[scanTriggerRequest]()
+	fields := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		fields[jsonFieldName(t.Field(i))] = true
+	}
+	return fields
+}()
+
+// jsonFieldName returns the name f is encoded under in JSON.
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// handleCancelScan cancels an async scan this server is running. Its record
+// moves to status "cancelled" once the scan goroutine stops.
+func (s *Server) handleCancelScan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid scan ID")
+		return
+	}
+	if s.scanner == nil {
+		writeError(w, http.StatusServiceUnavailable, CodeScannerUnavailable, "scanner not configured")
+		return
+	}
+
+	if s.scanner.Cancel(id) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"status":  "cancelling",
+			"scan_id": id,
+		})
+		return
+	}
+
+	sc, err := s.store.GetScan(r.Context(), id)
+	if err != nil {
+		s.logger.Error("getting scan", "scanID", id, "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	if sc == nil {
+		writeError(w, http.StatusNotFound, CodeScanNotFound, "scan not found")
+		return
+	}
+	writeError(w, http.StatusConflict, CodeScanNotRunning, fmt.Sprintf("scan %d is not running on this server (status: %s)", id, sc.Status))
+}
+
 func (s *Server) handleScanDiff(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid scan ID")
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid scan ID")
 		return
 	}
 
 	diff, err := s.store.GetDiff(r.Context(), id)
 	if err != nil {
 		s.logger.Error("getting scan diff", "scanID", id, "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	if diff == nil {
-		writeError(w, http.StatusNotFound, "no diff found for this scan")
+		writeError(w, http.StatusNotFound, CodeDiffNotFound, "no diff found for this scan")
 		return
 	}
 
@@ -474,12 +1156,72 @@ func (s *Server) handleScanStatus(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"running": running})
 }
 
+// handleScanEvents streams a scan's progress as Server-Sent Events, one
+// event per scanner.ProgressEvent, ending after completed, failed or cancelled. Scans
+// this server isn't tracking (finished long ago, or run by another process)
+// get a single event built from the stored scan record.
+func (s *Server) handleScanEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeValidation, "invalid scan ID")
+		return
+	}
+
+	var events <-chan scanner.ProgressEvent
+	unsubscribe := func() {}
+	ok := false
+	if s.scanner != nil {
+		events, unsubscribe, ok = s.scanner.SubscribeProgress(id)
+	}
+	defer unsubscribe()
+	if !ok {
+		sc, err := s.store.GetScan(r.Context(), id)
+		if err != nil {
+			s.logger.Error("getting scan", "scanID", id, "error", err)
+			writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+			return
+		}
+		if sc == nil {
+			writeError(w, http.StatusNotFound, CodeScanNotFound, "scan not found")
+			return
+		}
+		ev := scanner.ProgressEvent{ScanID: id, Type: sc.Status, Nodes: sc.NodesFound, Edges: sc.EdgesFound}
+		if sc.Status == "running" {
+			ev = scanner.ProgressEvent{ScanID: id, Type: scanner.EventStarted}
+		}
+		ch := make(chan scanner.ProgressEvent, 1)
+		ch <- ev
+		close(ch)
+		events = ch
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{}) // a scan can outlast the server's WriteTimeout
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, open := <-events:
+			if !open {
+				return
+			}
+			data, _ := json.Marshal(ev)
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data) //#nosec G705 -- JSON-encoded event
+			_ = rc.Flush()
+		}
+	}
+}
+
 func (s *Server) handleCycles(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	cycles, err := s.engine.FindCycles(ctx)
 	if err != nil {
-		s.logger.Error("finding cycles", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.writeEngineError(w, err, "finding cycles")
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -505,8 +1247,7 @@ func (s *Server) handleSPOF(w http.ResponseWriter, r *http.Request) {
 
 	spofs, err := s.engine.FindSPOF(ctx, minAffected)
 	if err != nil {
-		s.logger.Error("finding spof", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.writeEngineError(w, err, "finding spof")
 		return
 	}
 
@@ -520,12 +1261,36 @@ func (s *Server) handleSPOF(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) handleCritical(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	top := 20
+	if t := r.URL.Query().Get("top"); t != "" {
+		if parsed, err := strconv.Atoi(t); err == nil && parsed >= 1 {
+			top = parsed
+		}
+	}
+
+	ranked, err := graph.RankCriticality(ctx, s.store)
+	if err != nil {
+		s.logger.Error("ranking criticality", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"critical": ranked,
+		"count":    len(ranked),
+	})
+}
+
 func (s *Server) handleOrphans(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	orphans, err := s.engine.FindOrphans(ctx)
 	if err != nil {
-		s.logger.Error("finding orphans", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		s.writeEngineError(w, err, "finding orphans")
 		return
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
@@ -538,7 +1303,7 @@ func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
 	report, err := graph.RunAudit(r.Context(), s.store)
 	if err != nil {
 		s.logger.Error("running audit", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	if nodeID := r.URL.Query().Get("node_id"); nodeID != "" {
@@ -551,14 +1316,14 @@ func (s *Server) handleResolveNode(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	hostname := r.URL.Query().Get("hostname")
 	if hostname == "" {
-		writeError(w, http.StatusBadRequest, "hostname query parameter required")
+		writeError(w, http.StatusBadRequest, CodeValidation, "hostname query parameter required")
 		return
 	}
 
 	nodes, err := s.store.ListNodes(ctx, graph.NodeFilter{})
 	if err != nil {
 		s.logger.Error("listing nodes for resolve", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 
@@ -572,7 +1337,7 @@ func (s *Server) handleResolveNode(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	writeError(w, http.StatusNotFound, "node not found")
+	writeError(w, http.StatusNotFound, CodeNodeNotFound, "node not found")
 }
 
 // planImpactNode represents a planned resource change with its blast radius.
@@ -592,7 +1357,7 @@ func (s *Server) handlePlanImpact(w http.ResponseWriter, r *http.Request) {
 	nodes, err := s.store.ListNodes(ctx, graph.NodeFilter{Source: "terraform-plan"})
 	if err != nil {
 		s.logger.Error("listing plan nodes", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 
@@ -608,7 +1373,7 @@ func (s *Server) handlePlanImpact(w http.ResponseWriter, r *http.Request) {
 
 		// Compute blast radius for update/delete/replace actions.
 		if action == "update" || action == "delete" || action == "replace" {
-			impact, err := s.engine.BlastRadius(ctx, n.ID)
+			impact, err := s.engine.BlastRadius(ctx, n.ID, graph.ImpactOptions{})
 			if err == nil {
 				pin.AffectedCount = impact.AffectedNodes
 				pin.AffectedByType = impact.AffectedByType
@@ -625,10 +1390,10 @@ func (s *Server) handlePlanImpact(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleExportJSON(w http.ResponseWriter, r *http.Request) {
-	out, err := graph.ExportJSON(r.Context(), s.store)
+	out, err := graph.ExportJSON(r.Context(), s.store, graph.ExportOptions{})
 	if err != nil {
 		s.logger.Error("export json", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -637,10 +1402,10 @@ func (s *Server) handleExportJSON(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleExportDOT(w http.ResponseWriter, r *http.Request) {
-	out, err := graph.ExportDOT(r.Context(), s.store)
+	out, err := graph.ExportDOT(r.Context(), s.store, graph.ExportOptions{})
 	if err != nil {
 		s.logger.Error("export dot", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	w.Header().Set("Content-Type", "text/vnd.graphviz")
@@ -649,13 +1414,37 @@ func (s *Server) handleExportDOT(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleExportMermaid(w http.ResponseWriter, r *http.Request) {
-	out, err := graph.ExportMermaid(r.Context(), s.store)
+	out, err := graph.ExportMermaid(r.Context(), s.store, graph.ExportOptions{})
 	if err != nil {
 		s.logger.Error("export mermaid", "error", err)
-		writeError(w, http.StatusInternalServerError, "internal error")
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
 		return
 	}
 	w.Header().Set("Content-Type", "text/plain")
 	w.Header().Set("Content-Disposition", `attachment; filename="aib-graph.mmd"`)
 	_, _ = w.Write([]byte(out)) //#nosec G705 -- data from internal store, served as file download
 }
+
+func (s *Server) handleExportGraphML(w http.ResponseWriter, r *http.Request) {
+	out, err := graph.ExportGraphML(r.Context(), s.store, graph.ExportOptions{})
+	if err != nil {
+		s.logger.Error("export graphml", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/graphml+xml")
+	w.Header().Set("Content-Disposition", `attachment; filename="aib-graph.graphml"`)
+	_, _ = w.Write([]byte(out)) //#nosec G705 -- data from internal store, served as file download
+}
+
+func (s *Server) handleExportCytoscape(w http.ResponseWriter, r *http.Request) {
+	out, err := graph.ExportCytoscape(r.Context(), s.store, graph.ExportOptions{})
+	if err != nil {
+		s.logger.Error("export cytoscape", "error", err)
+		writeError(w, http.StatusInternalServerError, CodeInternal, "internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="aib-graph.cyjs"`)
+	_, _ = w.Write([]byte(out)) //#nosec G705 -- data from internal store, served as file download
+}
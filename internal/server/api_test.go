@@ -3,12 +3,15 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -87,6 +90,87 @@ func TestHealthz(t *testing.T) {
 	}
 }
 
+type readyzResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func getReadyz(t *testing.T, url string) (int, readyzResponse) {
+	t.Helper()
+	resp, err := http.Get(url + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	var body readyzResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestReadyz(t *testing.T) {
+	ts, store := newTestServer(t, "token")
+
+	// Like /healthz, /readyz needs no token.
+	code, body := getReadyz(t, ts.URL)
+	if code != http.StatusOK || body.Status != "ok" || body.Checks["sqlite"] != "ok" {
+		t.Errorf("healthy store: status %d, body %+v", code, body)
+	}
+	if _, ok := body.Checks["memgraph"]; ok {
+		t.Error("memgraph checked with the local engine")
+	}
+
+	_ = store.Close()
+	code, body = getReadyz(t, ts.URL)
+	if code != http.StatusServiceUnavailable || body.Status != "degraded" || body.Checks["sqlite"] != "unavailable" {
+		t.Errorf("closed store: status %d, body %+v", code, body)
+	}
+
+	// /healthz stays a liveness check.
+	resp, err := http.Get(ts.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz status = %d, want 200 with the store closed", resp.StatusCode)
+	}
+}
+
+// downEngine is a graph engine whose backing service is unreachable.
+type downEngine struct {
+	*graph.LocalEngine
+}
+
+func (downEngine) Ping(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestReadyz_EngineDown(t *testing.T) {
+	store, err := graph.NewSQLiteStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	engine := downEngine{graph.NewLocalEngine(store)}
+	s := New(store, engine, certs.NewTracker(store, nil, logger), nil, logger, ":0", false, "", "", nil, "test")
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, s)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	code, body := getReadyz(t, ts.URL)
+	if code != http.StatusServiceUnavailable || body.Checks["sqlite"] != "ok" || body.Checks["memgraph"] != "unavailable" {
+		t.Errorf("status %d, body %+v", code, body)
+	}
+}
+
 func TestGetNodes(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedTestData(t, store)
@@ -110,6 +194,185 @@ func TestGetNodes(t *testing.T) {
 	}
 }
 
+func TestGetNodes_Pagination(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	ctx := context.Background()
+	for _, name := range []string{"web1", "web2", "web3", "web4", "web5"} {
+		if err := store.UpsertNode(ctx, models.Node{
+			ID: "tf:vm:" + name, Name: name, Type: models.AssetVM, Source: "terraform",
+			Metadata: map[string]string{}, LastSeen: time.Now(), FirstSeen: time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page := func(query string) ([]models.Node, string) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/v1/graph/nodes?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close() //nolint:errcheck // test cleanup
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", query, resp.StatusCode)
+		}
+		var nodes []models.Node
+		if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+			t.Fatal(err)
+		}
+		return nodes, resp.Header.Get("X-Total-Count")
+	}
+
+	nodes, total := page("sort=name&limit=2&offset=2")
+	if len(nodes) != 2 || nodes[0].Name != "web3" || nodes[1].Name != "web4" {
+		t.Errorf("page 2 = %v, want web3, web4", nodes)
+	}
+	if total != "5" {
+		t.Errorf("X-Total-Count = %q, want 5", total)
+	}
+	if nodes, _ := page("sort=name&limit=2&offset=4"); len(nodes) != 1 || nodes[0].Name != "web5" {
+		t.Errorf("last page = %v, want web5", nodes)
+	}
+	if nodes, _ := page("sort=-name&limit=1"); len(nodes) != 1 || nodes[0].Name != "web5" {
+		t.Errorf("descending first = %v, want web5", nodes)
+	}
+	if nodes, _ := page("offset=10"); len(nodes) != 0 {
+		t.Errorf("past the end = %v, want empty", nodes)
+	}
+
+	for _, query := range []string{"sort=metadata", "limit=0", "limit=-1", "limit=abc", "limit=5001", "offset=-1", "offset=x"} {
+		resp, err := http.Get(ts.URL + "/api/v1/graph/nodes?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error apiError `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || body.Error.Code != CodeValidation {
+			t.Errorf("%s: status = %d, code = %q, want 400 %q", query, resp.StatusCode, body.Error.Code, CodeValidation)
+		}
+	}
+	if nodes, _ := page("limit=5000"); len(nodes) != 5 {
+		t.Errorf("limit=5000 = %d nodes, want 5", len(nodes))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tf:vm:api1", Name: "api1", Type: models.AssetVM, Source: "terraform",
+		Metadata: map[string]string{"zone": "us-central1-a"}, LastSeen: time.Now(), FirstSeen: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(query string) []models.Node {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/api/v1/search?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close() //nolint:errcheck // test cleanup
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200", query, resp.StatusCode)
+		}
+		var nodes []models.Node
+		if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+			t.Fatal(err)
+		}
+		return nodes
+	}
+
+	if nodes := search("q=web"); len(nodes) != 1 || nodes[0].ID != "tf:vm:web1" {
+		t.Errorf("name search = %v, want tf:vm:web1", nodes)
+	}
+	if nodes := search("q=" + url.QueryEscape("zone=us-central1-a")); len(nodes) != 1 || nodes[0].ID != "tf:vm:api1" {
+		t.Errorf("metadata search = %v, want tf:vm:api1", nodes)
+	}
+	if nodes := search("q=vpc&type=vm"); len(nodes) != 0 {
+		t.Errorf("filtered search = %v, want empty", nodes)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("missing q: status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tf:vm:api1", Name: "api1", Type: models.AssetVM, Source: "terraform", Provider: "google",
+		Metadata: map[string]string{"region": "us-east1"}, LastSeen: time.Now(), FirstSeen: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/query?expr=" + url.QueryEscape("type=vm AND provider=google AND metadata.region=us-east1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var nodes []models.Node
+	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != "tf:vm:api1" {
+		t.Errorf("nodes = %v, want tf:vm:api1", nodes)
+	}
+	if got := resp.Header.Get("X-Total-Count"); got != "1" {
+		t.Errorf("X-Total-Count = %q, want 1", got)
+	}
+
+	for _, query := range []string{"", "expr=" + url.QueryEscape("colour=red"), "expr=" + url.QueryEscape("type=vm AND")} {
+		resp, err := http.Get(ts.URL + "/api/v1/query?" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", query, resp.StatusCode)
+		}
+	}
+}
+
+func TestCritical(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/critical?top=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Critical []graph.CriticalNode `json:"critical"`
+		Count    int                  `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Count != 1 || body.Critical[0].Node.ID != "tf:network:vpc1" || body.Critical[0].Affected != 1 {
+		t.Errorf("unexpected ranking: %+v", body)
+	}
+}
+
 func TestGetNodes_FilterByType(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedTestData(t, store)
@@ -161,6 +424,247 @@ func TestGetNodeByID_NotFound(t *testing.T) {
 	}
 }
 
+func deleteRequest(t *testing.T, url string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestDeleteNode(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+
+	resp := deleteRequest(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", resp.StatusCode)
+	}
+
+	node, err := store.GetNode(ctx, "tf:vm:web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node != nil {
+		t.Error("node still exists after delete")
+	}
+	edges, err := store.GetEdgesTo(ctx, "tf:network:vpc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 0 {
+		t.Errorf("edges to vpc1 = %d, want 0 after deleting web1", len(edges))
+	}
+}
+
+func TestDeleteNode_NotFound(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+
+	resp := deleteRequest(t, ts.URL+"/api/v1/graph/nodes/nonexistent")
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestPruneNodes(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for _, n := range []models.Node{
+		{ID: "k8s:pod:old", Name: "old", Type: models.AssetPod, Source: "kubernetes", Metadata: map[string]string{}, LastSeen: old, FirstSeen: old},
+		{ID: "manual:vm:old", Name: "old", Type: models.AssetVM, Source: "manual", Metadata: map[string]string{}, LastSeen: old, FirstSeen: old},
+	} {
+		if err := store.UpsertNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.UpsertEdge(ctx, models.Edge{
+		ID: "k8s:pod:old->depends_on->tf:vm:web1", FromID: "k8s:pod:old", ToID: "tf:vm:web1", Type: models.EdgeDependsOn,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/api/v1/graph/nodes", "/api/v1/graph/nodes?stale_days=0", "/api/v1/graph/nodes?stale_days=x"} {
+		if resp := deleteRequest(t, ts.URL+path); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, resp.StatusCode)
+		}
+	}
+
+	resp := deleteRequest(t, ts.URL+"/api/v1/graph/nodes?stale_days=30&keep_sources=manual")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var body struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Deleted != 1 {
+		t.Errorf("deleted = %d, want 1", body.Deleted)
+	}
+	if n, _ := store.GetNode(ctx, "k8s:pod:old"); n != nil {
+		t.Error("stale pod should be pruned")
+	}
+	if n, _ := store.GetNode(ctx, "manual:vm:old"); n == nil {
+		t.Error("kept source should survive the prune")
+	}
+	if edges, _ := store.GetEdgesTo(ctx, "tf:vm:web1"); len(edges) != 0 {
+		t.Errorf("edges to web1 = %d, want the pruned pod's edge gone", len(edges))
+	}
+
+	resp = deleteRequest(t, ts.URL+"/api/v1/graph/nodes?source=terraform")
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if body.Deleted != 2 {
+		t.Errorf("source prune deleted = %d, want 2", body.Deleted)
+	}
+}
+
+func TestDeleteNode_ReadOnly(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	store, err := graph.NewSQLiteStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	seedTestData(t, store)
+
+	engine := graph.NewLocalEngine(store)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	tracker := certs.NewTracker(store, nil, logger)
+	s := New(store, engine, tracker, nil, logger, ":0", true, "", "", nil, "test")
+
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, s)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	resp := deleteRequest(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.StatusCode)
+	}
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if body.Error.Code != CodeReadOnly {
+		t.Errorf("code = %q, want %q", body.Error.Code, CodeReadOnly)
+	}
+
+	node, err := store.GetNode(context.Background(), "tf:vm:web1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node == nil {
+		t.Error("node deleted in read-only mode")
+	}
+
+	if resp := deleteRequest(t, ts.URL+"/api/v1/graph/nodes?source=terraform"); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("bulk prune status = %d, want 403", resp.StatusCode)
+	}
+	if count, _ := store.NodeCount(context.Background()); count != 2 {
+		t.Errorf("node count = %d, want 2 after read-only prune", count)
+	}
+
+	if resp := patchRequest(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1/tags", `{"owner":"team-a"}`); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("tag patch status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func patchRequest(t *testing.T, url, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPatch, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+// getJSON fetches url, expecting a 200, and decodes the body into v.
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: status = %d, want 200", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPatchNodeTags(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	if err := store.SetTag(ctx, "tf:vm:web1", "env", "staging"); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := patchRequest(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1/tags", `{"owner":"team-a","env":null}`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var tags map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || tags["owner"] != "team-a" {
+		t.Errorf("tags = %v, want only owner=team-a", tags)
+	}
+
+	// Tags show on the node and its tags subresource, and filter the node list.
+	var node models.Node
+	getJSON(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1", &node)
+	if node.Tags["owner"] != "team-a" {
+		t.Errorf("node tags = %v, want owner=team-a", node.Tags)
+	}
+	tags = nil
+	getJSON(t, ts.URL+"/api/v1/graph/nodes/tf:vm:web1/tags", &tags)
+	if tags["owner"] != "team-a" {
+		t.Errorf("tags subresource = %v, want owner=team-a", tags)
+	}
+	var nodes []models.Node
+	getJSON(t, ts.URL+"/api/v1/graph/nodes?tag=owner=team-a", &nodes)
+	if len(nodes) != 1 || nodes[0].ID != "tf:vm:web1" {
+		t.Errorf("tag filter returned %v, want tf:vm:web1", nodes)
+	}
+
+	for _, tt := range []struct {
+		path, body string
+		status     int
+	}{
+		{"/api/v1/graph/nodes/tf:vm:missing/tags", `{"a":"b"}`, http.StatusNotFound},
+		{"/api/v1/graph/nodes/tf:vm:web1/tags", `["a"]`, http.StatusBadRequest},
+		{"/api/v1/graph/nodes/tf:vm:web1/tags", `{"bad key":"b"}`, http.StatusBadRequest},
+		{"/api/v1/graph/nodes/tf:vm:web1", `{"a":"b"}`, http.StatusBadRequest},
+	} {
+		if resp := patchRequest(t, ts.URL+tt.path, tt.body); resp.StatusCode != tt.status {
+			t.Errorf("PATCH %s %s: status = %d, want %d", tt.path, tt.body, resp.StatusCode, tt.status)
+		}
+	}
+}
+
 func TestGetEdges(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedTestData(t, store)
@@ -220,6 +724,125 @@ func TestGetImpact(t *testing.T) {
 	}
 }
 
+func TestGetImpact_ExpiryWarnings(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	soon := time.Now().Add(5 * 24 * time.Hour)
+	far := time.Now().Add(200 * 24 * time.Hour)
+	for id, expires := range map[string]*time.Time{"tf:cert:web": &soon, "tf:cert:api": &far} {
+		if err := store.UpsertNode(ctx, models.Node{
+			ID: id, Name: id, Type: models.AssetCertificate, Source: "terraform",
+			Metadata: map[string]string{}, ExpiresAt: expires, LastSeen: time.Now(), FirstSeen: time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		// The certificate is served by web1, so it is lost along with it.
+		if err := store.UpsertEdge(ctx, models.Edge{
+			ID: id + "->depends_on->tf:vm:web1", FromID: id, ToID: "tf:vm:web1", Type: models.EdgeDependsOn,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/impact/tf:network:vpc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	var result graph.ImpactResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.AffectedNodes != 3 {
+		t.Errorf("affected_nodes = %d, want 3", result.AffectedNodes)
+	}
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], "tf:cert:web expires in") {
+		t.Errorf("warnings = %q, want only tf:cert:web", result.Warnings)
+	}
+
+	// Without expiring assets the list is empty, not null.
+	resp2, err := http.Get(ts.URL + "/api/v1/impact/tf:cert:web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close() //nolint:errcheck // test cleanup
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(resp2.Body).Decode(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(raw["warnings"]); !strings.HasPrefix(got, `["tf:cert:web expires in`) {
+		t.Errorf("warnings for the expiring root = %s", got)
+	}
+}
+
+func TestGetImpactReport(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+	ctx := context.Background()
+	soon := time.Now().Add(5 * 24 * time.Hour)
+	if err := store.UpsertNode(ctx, models.Node{
+		ID: "tf:cert:web", Name: "web.example.com", Type: models.AssetCertificate, Source: "terraform",
+		Metadata: map[string]string{}, ExpiresAt: &soon, LastSeen: time.Now(), FirstSeen: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpsertEdge(ctx, models.Edge{
+		ID: "tf:vm:web1->terminates_tls->tf:cert:web", FromID: "tf:vm:web1", ToID: "tf:cert:web", Type: models.EdgeTerminatesTLS,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/impact/tf:vm:web1/report?direction=downstream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var report struct {
+		NodeID         string         `json:"node_id"`
+		BlastRadius    int            `json:"blast_radius"`
+		AffectedByType map[string]int `json:"affected_by_type"`
+		Warnings       []string       `json:"warnings"`
+		Tree           struct {
+			Children []struct {
+				NodeID string `json:"node_id"`
+			} `json:"children"`
+		} `json:"impact_tree"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.NodeID != "tf:vm:web1" || report.BlastRadius != 2 || len(report.Tree.Children) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.AffectedByType["certificate"] != 1 || report.AffectedByType["network"] != 1 {
+		t.Errorf("affected_by_type = %v", report.AffectedByType)
+	}
+	if len(report.Warnings) != 1 || !strings.HasPrefix(report.Warnings[0], "tf:cert:web expires in") {
+		t.Errorf("warnings = %q, want the expiring certificate", report.Warnings)
+	}
+
+	for path, want := range map[string]int{
+		"/api/v1/impact/tf:vm:missing/report":               http.StatusNotFound,
+		"/api/v1/impact/tf:vm:web1/report?direction=across": http.StatusBadRequest,
+		"/api/v1/impact/tf:vm:web1/report?depth=-1":         http.StatusBadRequest,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: status = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
 func TestGetStats(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedTestData(t, store)
@@ -504,6 +1127,61 @@ func TestExportMermaid(t *testing.T) {
 	}
 }
 
+func TestExportGraphML(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/export/graphml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/graphml+xml" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "aib-graph.graphml") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `<node id="tf:vm:web1">`) {
+		t.Errorf("expected seeded node in GraphML, got: %s", body)
+	}
+}
+
+func TestExportCytoscape(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedTestData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/export/cytoscape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); !strings.Contains(cd, "aib-graph.cyjs") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	var doc struct {
+		Elements struct {
+			Nodes []any `json:"nodes"`
+			Edges []any `json:"edges"`
+		} `json:"elements"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Elements.Nodes) != 2 || len(doc.Elements.Edges) != 1 {
+		t.Errorf("got %d nodes, %d edges; want 2, 1", len(doc.Elements.Nodes), len(doc.Elements.Edges))
+	}
+}
+
 func seedChainData(t *testing.T, store *graph.SQLiteStore) {
 	t.Helper()
 	ctx := context.Background()
@@ -583,53 +1261,235 @@ func TestDependencyChain(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedChainData(t, store)
 
-	resp, err := http.Get(ts.URL + "/api/v1/graph/dependency-chain/tf:lb:frontend")
+	resp, err := http.Get(ts.URL + "/api/v1/graph/dependency-chain/tf:lb:frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var result struct {
+		Nodes []models.Node `json:"nodes"`
+		Depth int           `json:"depth"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if len(result.Nodes) != 2 {
+		t.Errorf("deps = %d, want 2 (app + db)", len(result.Nodes))
+	}
+	if result.Depth != 10 {
+		t.Errorf("depth = %d, want 10 (default)", result.Depth)
+	}
+}
+
+func TestDependencyChain_DepthParam(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedChainData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/graph/dependency-chain/tf:lb:frontend?depth=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	var result struct {
+		Nodes []models.Node `json:"nodes"`
+		Depth int           `json:"depth"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if len(result.Nodes) != 1 {
+		t.Errorf("deps = %d, want 1 (only app at depth 1)", len(result.Nodes))
+	}
+	if result.Depth != 1 {
+		t.Errorf("depth = %d, want 1", result.Depth)
+	}
+}
+
+func TestPath(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedChainData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/path?from=tf:lb:frontend&to=tf:db:primary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var result struct {
+		Nodes []models.Node `json:"nodes"`
+		Edges []models.Edge `json:"edges"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if len(result.Nodes) != 3 || len(result.Edges) != 2 {
+		t.Errorf("path = %d nodes, %d edges; want 3, 2", len(result.Nodes), len(result.Edges))
+	}
+}
+
+func TestPath_NotFound(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedChainData(t, store)
+	now := time.Now()
+	if err := store.UpsertNode(context.Background(), models.Node{
+		ID: "tf:vm:island", Name: "island", Type: models.AssetVM, Source: "terraform",
+		Metadata: map[string]string{}, LastSeen: now, FirstSeen: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		url  string
+		code string
+	}{
+		{"unknown from", "/api/v1/path?from=missing&to=tf:db:primary", CodeNodeNotFound},
+		{"unknown to", "/api/v1/path?from=tf:lb:frontend&to=missing", CodeNodeNotFound},
+		{"disconnected", "/api/v1/path?from=tf:lb:frontend&to=tf:vm:island", CodeNoPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+			if resp.StatusCode != http.StatusNotFound {
+				t.Fatalf("status = %d, want 404", resp.StatusCode)
+			}
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			if body.Error.Code != tt.code {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestNodeNeighbors(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedChainData(t, store)
+
+	resp, err := http.Get(ts.URL + "/api/v1/graph/nodes/tf:vm:app/neighbors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	var result struct {
+		Nodes []models.Node `json:"nodes"`
+		Edges []models.Edge `json:"edges"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+	if len(result.Nodes) != 2 || len(result.Edges) != 2 {
+		t.Errorf("neighbors = %d nodes, %d edges; want 2, 2", len(result.Nodes), len(result.Edges))
+	}
+}
+
+func TestNodeNeighbors_SlashInID(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	ctx := context.Background()
+	now := time.Now()
+	for _, id := range []string{"k8s:pod:default/web", "k8s:service:default/web"} {
+		if err := store.UpsertNode(ctx, models.Node{
+			ID: id, Name: "web", Type: models.AssetPod, Source: "kubernetes",
+			Metadata: map[string]string{}, LastSeen: now, FirstSeen: now,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.UpsertEdge(ctx, models.Edge{
+		ID: "e1", FromID: "k8s:service:default/web", ToID: "k8s:pod:default/web",
+		Type: models.EdgeRoutesTo, Metadata: map[string]string{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/v1/graph/nodes/k8s:pod:default/web/neighbors")
 	if err != nil {
 		t.Fatal(err)
 	}
 	defer resp.Body.Close() //nolint:errcheck // test cleanup
 
 	if resp.StatusCode != http.StatusOK {
-		t.Errorf("status = %d, want 200", resp.StatusCode)
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
 	}
-
 	var result struct {
 		Nodes []models.Node `json:"nodes"`
-		Depth int           `json:"depth"`
 	}
 	_ = json.NewDecoder(resp.Body).Decode(&result)
-	if len(result.Nodes) != 2 {
-		t.Errorf("deps = %d, want 2 (app + db)", len(result.Nodes))
-	}
-	if result.Depth != 10 {
-		t.Errorf("depth = %d, want 10 (default)", result.Depth)
+	if len(result.Nodes) != 1 || result.Nodes[0].ID != "k8s:service:default/web" {
+		t.Errorf("neighbors = %+v, want the service", result.Nodes)
 	}
 }
 
-func TestDependencyChain_DepthParam(t *testing.T) {
+func TestNodeDeps(t *testing.T) {
 	ts, store := newTestServer(t, "")
 	seedChainData(t, store)
 
-	resp, err := http.Get(ts.URL + "/api/v1/graph/dependency-chain/tf:lb:frontend?depth=1")
-	if err != nil {
-		t.Fatal(err)
+	tests := []struct {
+		query     string
+		wantDeps  int
+		wantDepth int
+	}{
+		{"", 2, 10},
+		{"?depth=1", 1, 1},
+		{"?depth=0", 1, 1},
+		{"?depth=500", 2, 50},
 	}
-	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + "/api/v1/graph/nodes/tf:lb:frontend/deps" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close() //nolint:errcheck // test cleanup
 
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("status = %d, want 200", resp.StatusCode)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			var result struct {
+				Nodes []models.Node `json:"nodes"`
+				Depth int           `json:"depth"`
+			}
+			_ = json.NewDecoder(resp.Body).Decode(&result)
+			if len(result.Nodes) != tt.wantDeps || result.Depth != tt.wantDepth {
+				t.Errorf("deps = %d at depth %d, want %d at depth %d", len(result.Nodes), result.Depth, tt.wantDeps, tt.wantDepth)
+			}
+		})
 	}
+}
 
-	var result struct {
-		Nodes []models.Node `json:"nodes"`
-		Depth int           `json:"depth"`
-	}
-	_ = json.NewDecoder(resp.Body).Decode(&result)
-	if len(result.Nodes) != 1 {
-		t.Errorf("deps = %d, want 1 (only app at depth 1)", len(result.Nodes))
-	}
-	if result.Depth != 1 {
-		t.Errorf("depth = %d, want 1", result.Depth)
+func TestNodeSubresources_NotFound(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	seedChainData(t, store)
+
+	for _, url := range []string{
+		"/api/v1/graph/nodes/missing/neighbors",
+		"/api/v1/graph/nodes/missing/deps",
+		"/api/v1/graph/dependency-chain/missing",
+	} {
+		resp, err := http.Get(ts.URL + url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: status = %d, want 404", url, resp.StatusCode)
+		}
 	}
 }
 
@@ -658,6 +1518,8 @@ func TestMetrics(t *testing.T) {
 	for _, metric := range []string{
 		"aib_nodes_total 2",
 		"aib_edges_total 1",
+		`aib_nodes_by_type{type="vm"} 1`,
+		`aib_edges_by_type{type="depends_on"} 1`,
 		"aib_certs_expiring_total",
 		"aib_scans_completed_total",
 		"aib_scans_failed_total",
@@ -702,6 +1564,21 @@ func TestTriggerScan_PathTraversal(t *testing.T) {
 	}
 }
 
+func TestTriggerScan_InvalidExcludeGlob(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+
+	body := strings.NewReader(`{"source":"terraform","paths":["/opt/infra"],"exclude":["[bad"]}`)
+	resp, err := http.Post(ts.URL+"/api/v1/scan", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 (malformed glob rejected)", resp.StatusCode)
+	}
+}
+
 // --- Graph analysis endpoint tests ---
 
 func TestHandleCycles(t *testing.T) {
@@ -1021,9 +1898,18 @@ func TestHandleOpenAPISpec(t *testing.T) {
 	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
 		t.Errorf("Content-Type = %q, want application/json", ct)
 	}
-	body, _ := io.ReadAll(resp.Body)
-	if len(body) == 0 {
-		t.Error("expected non-empty OpenAPI spec")
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/api/v1/graph/nodes"]["get"]; !ok {
+		t.Error("spec should document GET /api/v1/graph/nodes")
 	}
 }
 
@@ -1143,6 +2029,84 @@ func TestTriggerScan_ValidSource_WithScanner(t *testing.T) {
 	}
 }
 
+func TestScanEvents(t *testing.T) {
+	ts, _ := newTestServerWithScanner(t)
+
+	testdata := t.TempDir()
+	tfstate := `{"version":4,"resources":[{"mode":"managed","type":"aws_instance","name":"test","provider":"provider[\"registry.terraform.io/hashicorp/aws\"]","instances":[{"attributes":{"name":"test-vm"},"dependencies":[]}]}]}`
+	if err := os.WriteFile(testdata+"/test.tfstate", []byte(tfstate), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	body := strings.NewReader(fmt.Sprintf(`{"source":"terraform","paths":[%q]}`, testdata+"/test.tfstate"))
+	resp, err := http.Post(ts.URL+"/api/v1/scan", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var triggered struct {
+		ScanID int64 `json:"scan_id"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&triggered)
+	_ = resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/api/v1/scan/%d/events", ts.URL, triggered.ScanID), nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	// The stream ends after the final event, so reading it all terminates.
+	stream, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(stream)
+	for _, want := range []string{"event: started\n", "event: nodes_discovered\n", "event: completed\n"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("stream missing %q, got: %s", want, text)
+		}
+	}
+	if !strings.Contains(text, `"nodes":1`) {
+		t.Errorf("expected completed event with 1 node, got: %s", text)
+	}
+}
+
+func TestScanEvents_FromStoredScan(t *testing.T) {
+	ts, store := newTestServer(t, "")
+
+	scanID, _ := store.RecordScan(context.Background(), graph.Scan{
+		Source:    "terraform",
+		StartedAt: time.Now(),
+		Status:    "running",
+	})
+	_ = store.UpdateScan(context.Background(), scanID, "failed", 0, 0)
+
+	resp, err := http.Get(fmt.Sprintf("%s/api/v1/scan/%d/events", ts.URL, scanID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+	stream, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(stream), "event: failed\n") {
+		t.Errorf("expected a failed event, got: %s", stream)
+	}
+
+	resp2, err := http.Get(ts.URL + "/api/v1/scan/999/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp2.Body.Close() //nolint:errcheck // test cleanup
+	if resp2.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown scan status = %d, want 404", resp2.StatusCode)
+	}
+}
+
 func TestTriggerScan_AllSource(t *testing.T) {
 	ts, _ := newTestServerWithScanner(t)
 
@@ -1573,6 +2537,15 @@ func TestMetrics_WithScans(t *testing.T) {
 	if !strings.Contains(s, "aib_scans_completed_total 1") {
 		t.Errorf("expected completed scan count of 1 in metrics, got: %s", s)
 	}
+	for _, metric := range []string{
+		"aib_last_scan_timestamp_seconds ",
+		`aib_last_scan_status{source="terraform",status="completed"} 1`,
+		"aib_last_scan_duration_seconds ",
+	} {
+		if !strings.Contains(s, metric) {
+			t.Errorf("metrics missing %q, got: %s", metric, s)
+		}
+	}
 }
 
 func TestTriggerScan_ReadOnly(t *testing.T) {
@@ -1765,3 +2738,244 @@ func TestRateLimiter(t *testing.T) {
 		t.Error("expected 429 after exceeding rate limit")
 	}
 }
+
+func TestReplayScan(t *testing.T) {
+	ts, store := newTestServerWithScanner(t)
+	ctx := context.Background()
+
+	fixture, err := filepath.Abs("../../testdata/terraform/sample.tfstate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig, err := store.RecordScan(ctx, graph.Scan{
+		Source:     "terraform",
+		SourcePath: fixture,
+		StartedAt:  time.Now(),
+		Status:     "completed",
+		Request:    json.RawMessage(fmt.Sprintf(`{"source":"terraform","paths":[%q]}`, fixture)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Post(ts.URL+fmt.Sprintf("/api/v1/scans/%d/replay", orig), "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("status = %d, want 202; body = %s", resp.StatusCode, body)
+	}
+	var got struct {
+		ScanID   int64 `json:"scan_id"`
+		ReplayOf int64 `json:"replay_of"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ReplayOf != orig || got.ScanID == orig {
+		t.Errorf("got scan_id=%d replay_of=%d, want a new scan replaying %d", got.ScanID, got.ReplayOf, orig)
+	}
+
+	// Wait for the async replay so it doesn't outlive the store.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		sc, err := store.GetScan(ctx, got.ScanID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sc != nil && sc.Status != "running" {
+			if sc.Status != "completed" || sc.NodesFound == 0 {
+				t.Errorf("replayed scan status=%s nodes=%d, want completed with nodes", sc.Status, sc.NodesFound)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("replayed scan did not finish")
+}
+
+func TestReplayScan_Errors(t *testing.T) {
+	ts, store := newTestServerWithScanner(t)
+
+	legacy, err := store.RecordScan(context.Background(), graph.Scan{
+		Source: "terraform", SourcePath: "/tmp/x.tfstate", StartedAt: time.Now(), Status: "completed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/scans/abc/replay", http.StatusBadRequest},
+		{"/api/v1/scans/99999/replay", http.StatusNotFound},
+		{fmt.Sprintf("/api/v1/scans/%d/replay", legacy), http.StatusConflict},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(ts.URL+tt.path, "application/json", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestReplayScan_Validation(t *testing.T) {
+	store, err := graph.NewSQLiteStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	sc := scanner.New(store, &config.Config{}, logger)
+	s := New(store, graph.NewLocalEngine(store), certs.NewTracker(store, nil, logger), sc, logger, ":0", false, "", "", []string{"/opt/infra"}, "test")
+	mux := http.NewServeMux()
+	RegisterRoutes(mux, s)
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	tests := []struct {
+		name     string
+		request  string
+		want     int
+		wantCode string
+	}{
+		{"relative path", `{"source":"terraform","paths":["../etc/passwd"]}`, http.StatusBadRequest, CodeValidation},
+		{"outside allowlist", `{"source":"terraform","paths":["/srv/state.tfstate"]}`, http.StatusForbidden, CodePathNotAllowed},
+		{"values file outside allowlist", `{"source":"kubernetes","paths":["/opt/infra/chart"],"helm":true,"values_file":"/srv/values.yaml"}`, http.StatusForbidden, CodePathNotAllowed},
+		{"missing project", `{"source":"gcp"}`, http.StatusBadRequest, CodeValidation},
+		{"kubeconfig", `{"source":"kubernetes-live","kubeconfig":"/root/.kube/config","context":"prod"}`, http.StatusBadRequest, CodeValidation},
+		{"timeout", `{"source":"terraform","paths":["/opt/infra/state.tfstate"],"timeout":"1h"}`, http.StatusBadRequest, CodeValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := store.RecordScan(context.Background(), graph.Scan{
+				Source:    "terraform",
+				StartedAt: time.Now(),
+				Status:    "completed",
+				Request:   json.RawMessage(tt.request),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.Post(ts.URL+fmt.Sprintf("/api/v1/scans/%d/replay", id), "application/json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.want || body.Error.Code != tt.wantCode {
+				t.Errorf("status = %d (%s), want %d (%s)", resp.StatusCode, body.Error.Code, tt.want, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCancelScan_Errors(t *testing.T) {
+	ts, store := newTestServerWithScanner(t)
+
+	done, err := store.RecordScan(context.Background(), graph.Scan{
+		Source: "terraform", SourcePath: "/tmp/x.tfstate", StartedAt: time.Now(), Status: "completed",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		want     int
+		wantCode string
+	}{
+		{"/api/v1/scan/abc", http.StatusBadRequest, CodeValidation},
+		{"/api/v1/scan/99999", http.StatusNotFound, CodeScanNotFound},
+		{fmt.Sprintf("/api/v1/scan/%d", done), http.StatusConflict, CodeScanNotRunning},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL+tt.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error apiError `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != tt.want || body.Error.Code != tt.wantCode {
+			t.Errorf("DELETE %s: status = %d, code = %q, want %d %q", tt.path, resp.StatusCode, body.Error.Code, tt.want, tt.wantCode)
+		}
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		status   int
+		wantCode string
+	}{
+		{"node not found", http.MethodGet, "/api/v1/graph/nodes/missing", http.StatusNotFound, CodeNodeNotFound},
+		{"validation", http.MethodGet, "/api/v1/graph/shortest-path?from=a", http.StatusBadRequest, CodeValidation},
+		{"scanner unavailable", http.MethodPost, "/api/v1/scans/1/replay", http.StatusServiceUnavailable, CodeScannerUnavailable},
+		{"diff not found", http.MethodGet, "/api/v1/scans/123/diff", http.StatusNotFound, CodeDiffNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			var body struct {
+				Error apiError `json:"error"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tt.wantCode || body.Error.Message == "" {
+				t.Errorf("error = %+v, want code %s with a message", body.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestErrorEnvelope_Unauthorized(t *testing.T) {
+	ts, _ := newTestServer(t, "secret-token")
+
+	resp, err := http.Get(ts.URL + "/api/v1/graph")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // test cleanup
+
+	var body struct {
+		Error apiError `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || body.Error.Code != CodeUnauthorized {
+		t.Errorf("got %d %+v, want 401 %s", resp.StatusCode, body.Error, CodeUnauthorized)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// wildcardRegexp matches ServeMux rest wildcards like {id...}, which
+// OpenAPI writes as {id}.
+var wildcardRegexp = regexp.MustCompile(`\{(\w+)\.\.\.\}`)
+
+// TestOpenAPISpec_CoversRoutes fails when a route registered in
+// RegisterRoutes is missing from openapi.json. A route ending in a rest
+// wildcard may instead be documented at the subresources its handler
+// splits off the wildcard, such as /api/v1/graph/nodes/{id}/tags.
+func TestOpenAPISpec_CoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "routes.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes := 0
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "HandleFunc" {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		pattern, _ := strconv.Unquote(lit.Value)
+		method, path, _ := strings.Cut(pattern, " ")
+		rest := strings.HasSuffix(path, "...}")
+		path = wildcardRegexp.ReplaceAllString(path, "{$1}")
+		routes++
+		if !documented(spec.Paths, strings.ToLower(method), path, rest) {
+			t.Errorf("%s %s is registered but not in openapi.json", method, path)
+		}
+		return true
+	})
+	if routes == 0 {
+		t.Fatal("no routes found in routes.go")
+	}
+}
+
+// documented reports whether the spec has method at path or, for a route
+// ending in a rest wildcard, at a subresource below it.
+func documented(paths map[string]map[string]any, method, path string, rest bool) bool {
+	if _, ok := paths[path][method]; ok {
+		return true
+	}
+	if !rest {
+		return false
+	}
+	for p, ops := range paths {
+		if _, ok := ops[method]; ok && strings.HasPrefix(p, path+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -3,16 +3,25 @@ package server
 import (
 	"context"
 	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"math"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"golang.org/x/time/rate"
 
 	"github.com/matijazezelj/aib/internal/certs"
+	"github.com/matijazezelj/aib/internal/config"
 	"github.com/matijazezelj/aib/internal/graph"
 	"github.com/matijazezelj/aib/internal/scanner"
 	"github.com/matijazezelj/aib/internal/ui"
@@ -28,15 +37,19 @@ type Server struct {
 	listen     string
 	readOnly   bool
 	apiToken   string
+	apiTokens  []config.APITokenConfig
 	corsOrigin string
 	version    string
+	tracing    bool
 	srv        *http.Server
 
 	allowedPaths []string
 
 	// rate limiter state
-	limiters sync.Map // map[string]*ipLimiter
-	done     chan struct{}
+	rateLimit float64          // requests per second per client IP; 0 means defaultRateLimit
+	now       func() time.Time // clock for the rate limiter; nil means time.Now
+	limiters  sync.Map         // map[string]*ipLimiter
+	done      chan struct{}
 
 	shutdownOnce sync.Once
 }
@@ -63,6 +76,36 @@ func New(store *graph.SQLiteStore, engine graph.GraphEngine, tracker *certs.Trac
 	}
 }
 
+// SetTracing wraps API requests in OpenTelemetry spans named after their
+// route, e.g. "GET /api/v1/graph/nodes". Enable it once telemetry.Setup
+// has installed an exporter.
+func (s *Server) SetTracing(enabled bool) {
+	s.tracing = enabled
+}
+
+// SetAPITokens adds named, scoped API tokens alongside the admin token
+// passed to New. It must be called before Start.
+func (s *Server) SetAPITokens(tokens []config.APITokenConfig) {
+	s.apiTokens = tokens
+}
+
+// defaultRateLimit is the per-IP request rate used when none is configured.
+const defaultRateLimit = 10
+
+// SetRateLimit sets the sustained per-IP API request rate; bursts of up to
+// twice that are allowed. A value <= 0 restores the default of 10/sec. It
+// must be called before Start.
+func (s *Server) SetRateLimit(perSecond float64) {
+	s.rateLimit = perSecond
+}
+
+func (s *Server) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
 // securityHeaders adds standard security headers to all responses.
 func securityHeaders(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +141,7 @@ func (s *Server) startLimiterCleanup() {
 			case <-ticker.C:
 				s.limiters.Range(func(key, value any) bool {
 					il := value.(*ipLimiter)
-					if time.Since(il.lastSeen) > 10*time.Minute {
+					if s.clock().Sub(il.lastSeen) > 10*time.Minute {
 						s.limiters.Delete(key)
 					}
 					return true
@@ -108,7 +151,10 @@ func (s *Server) startLimiterCleanup() {
 	}()
 }
 
-// rateLimiter limits API requests to 10/sec burst 20 per client IP.
+// rateLimiter limits API requests per client IP with a token bucket: the
+// configured rate (10/sec by default) with bursts of twice that. Rejected
+// requests get a 429 with a Retry-After header. Only /api/ paths are
+// limited, so /healthz, /readyz, /metrics and the UI are exempt.
 //
 // The client IP is taken from the TCP peer address (r.RemoteAddr), never from
 // X-Forwarded-For or similar headers — those are client-controlled and trusting
@@ -130,15 +176,23 @@ func (s *Server) rateLimiter(next http.Handler) http.Handler {
 			ip = r.RemoteAddr
 		}
 
+		limit := s.rateLimit
+		if limit <= 0 {
+			limit = defaultRateLimit
+		}
+		now := s.clock()
 		val, _ := s.limiters.LoadOrStore(ip, &ipLimiter{
-			limiter:  rate.NewLimiter(10, 20),
-			lastSeen: time.Now(),
+			limiter:  rate.NewLimiter(rate.Limit(limit), max(int(2*limit), 1)),
+			lastSeen: now,
 		})
 		il := val.(*ipLimiter)
-		il.lastSeen = time.Now()
+		il.lastSeen = now
 
-		if !il.limiter.Allow() {
-			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		res := il.limiter.ReserveN(now, 1)
+		if delay := res.DelayFrom(now); delay > 0 {
+			res.CancelAt(now)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded")
 			return
 		}
 
@@ -146,13 +200,30 @@ func (s *Server) rateLimiter(next http.Handler) http.Handler {
 	})
 }
 
+// tokenScope returns the scope of the configured token matching token, or
+// "" if none does. Every token is compared in constant time, so the time
+// taken doesn't reveal which one matched.
+func (s *Server) tokenScope(token string) string {
+	scope := ""
+	if s.apiToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) == 1 {
+		scope = config.ScopeAdmin
+	}
+	for _, t := range s.apiTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) == 1 && scope != config.ScopeAdmin {
+			scope = t.Scope
+		}
+	}
+	return scope
+}
+
 // corsMiddleware adds CORS headers when a cors_origin is configured.
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if s.corsOrigin != "" && strings.HasPrefix(r.URL.Path, "/api/") {
 			w.Header().Set("Access-Control-Allow-Origin", s.corsOrigin)
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
 			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count")
 			w.Header().Set("Access-Control-Max-Age", "86400")
 
 			if r.Method == http.MethodOptions {
@@ -164,16 +235,42 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// traceRequests starts a span for every API request. It sits inside the
+// other middleware so the span is named after the route the mux matched;
+// requests rejected earlier (unauthorized, rate limited) are not traced.
+func traceRequests(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "aib",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return strings.HasPrefix(r.URL.Path, "/api/")
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if r.Pattern != "" {
+				return r.Pattern
+			}
+			return r.Method + " " + r.URL.Path
+		}),
+	)
+}
+
 // authMiddleware returns a handler that checks for a valid bearer token
-// on /api/ routes when an API token is configured.
+// on /api/ routes when any API token is configured. GET requests need a
+// read or admin token; every other method needs an admin token.
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Only protect API routes (not static UI or healthz)
-		if s.apiToken != "" && strings.HasPrefix(r.URL.Path, "/api/") {
+		if (s.apiToken != "" || len(s.apiTokens) > 0) && strings.HasPrefix(r.URL.Path, "/api/") {
 			auth := r.Header.Get("Authorization")
 			token := strings.TrimPrefix(auth, "Bearer ")
-			if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) != 1 {
-				writeError(w, http.StatusUnauthorized, "unauthorized")
+			scope := ""
+			if token != auth {
+				scope = s.tokenScope(token)
+			}
+			if scope == "" {
+				writeError(w, http.StatusUnauthorized, CodeUnauthorized, "unauthorized")
+				return
+			}
+			if scope != config.ScopeAdmin && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				writeError(w, http.StatusForbidden, CodeInsufficientScope, "token scope does not allow this request")
 				return
 			}
 		}
@@ -191,8 +288,11 @@ func (s *Server) Start() error {
 	// Serve embedded static UI files
 	mux.Handle("/", http.FileServer(http.FS(ui.StaticFiles())))
 
-	// Middleware chain: security headers → body limit → CORS → rate limit → auth → mux
+	// Middleware chain: security headers → body limit → CORS → rate limit → auth → tracing → mux
 	var handler http.Handler = mux
+	if s.tracing {
+		handler = traceRequests(handler)
+	}
 	handler = s.authMiddleware(handler)
 	handler = s.rateLimiter(handler)
 	handler = s.corsMiddleware(handler)
@@ -211,19 +311,90 @@ func (s *Server) Start() error {
 	}
 
 	s.logger.Info("starting server", "listen", s.listen)
-	if s.apiToken != "" {
-		s.logger.Info("API authentication enabled")
+	if s.apiToken != "" || len(s.apiTokens) > 0 {
+		s.logger.Info("API authentication enabled", "scoped_tokens", len(s.apiTokens))
 	} else {
-		s.logger.Warn("API authentication disabled (set server.api_token to enable)")
+		s.logger.Warn("API authentication disabled (set server.api_token or server.api_tokens to enable)")
 	}
 	if s.readOnly {
-		s.logger.Info("server running in read-only mode (scan triggers disabled)")
+		s.logger.Info("server running in read-only mode (scan triggers and deletes disabled)")
+	}
+	if path, ok := config.UnixSocketPath(s.listen); ok {
+		ln, err := listenUnix(path)
+		if err != nil {
+			return err
+		}
+		s.logger.Info("AIB server running", "socket", path)
+		return s.srv.Serve(ln)
 	}
 	s.logger.Info("AIB server running", "url", "http://localhost"+s.listen)
 
 	return s.srv.ListenAndServe()
 }
 
+// socketMode restricts the API socket to its owner and group.
+const socketMode fs.FileMode = 0o660
+
+// listenUnix listens on a Unix domain socket at path, replacing a stale
+// socket left by an unclean shutdown. It refuses to remove anything that is
+// not a socket, or a socket another server still accepts connections on.
+// The socket is bound in a private directory, set to socketMode and only
+// then moved to path, so nobody else can connect while its mode is wider.
+// It is removed when the listener is closed.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("listen socket %s: file exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("listen socket %s: another server is listening on it", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket %s: %w", path, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("listen socket %s: %w", path, err)
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".aib-sock-")
+	if err != nil {
+		return nil, fmt.Errorf("listen socket %s: %w", path, err)
+	}
+	defer os.RemoveAll(dir) //nolint:errcheck // best-effort cleanup
+
+	tmp := filepath.Join(dir, "sock")
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// The net package would unlink the temporary name on close.
+	ln.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, socketMode); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("listen socket %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("listen socket %s: %w", path, err)
+	}
+	return &unixListener{UnixListener: ln, path: path}, nil
+}
+
+// unixListener removes its socket file, bound under a temporary name and
+// renamed into place, the first time it is closed.
+type unixListener struct {
+	*net.UnixListener
+	path string
+	once sync.Once
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	l.once.Do(func() { _ = os.Remove(l.path) })
+	return err
+}
+
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	if s.srv == nil {
@@ -2,12 +2,22 @@ package server
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/matijazezelj/aib/internal/config"
 )
 
 func TestSecurityHeaders(t *testing.T) {
@@ -215,6 +225,24 @@ func TestValidateScanRequest(t *testing.T) {
 			req:     scanTriggerRequest{Namespaces: []string{"ns;drop table"}},
 			wantErr: true,
 		},
+		{
+			name: "valid regions",
+			req:  scanTriggerRequest{Regions: []string{"eu-west-1", "us-gov-west-1"}},
+		},
+		{
+			name:    "invalid region",
+			req:     scanTriggerRequest{Regions: []string{"eu-west-1; rm -rf"}},
+			wantErr: true,
+		},
+		{
+			name: "valid project",
+			req:  scanTriggerRequest{Project: "shop-prod-123"},
+		},
+		{
+			name:    "invalid project",
+			req:     scanTriggerRequest{Project: "../../etc"},
+			wantErr: true,
+		},
 		{
 			name: "valid values_file",
 			req:  scanTriggerRequest{ValuesFile: "/home/user/values.yaml"},
@@ -407,3 +435,214 @@ func TestAuthMiddleware_NonAPIPath(t *testing.T) {
 		t.Errorf("status = %d, want 200 (non-API bypasses auth)", rr.Code)
 	}
 }
+
+func TestAuthMiddleware_Scopes(t *testing.T) {
+	s := &Server{
+		apiToken: "legacy-admin",
+		apiTokens: []config.APITokenConfig{
+			{Name: "dash", Token: "read-token", Scope: config.ScopeRead},
+			{Name: "ci", Token: "admin-token", Scope: config.ScopeAdmin},
+		},
+	}
+	handler := s.authMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"GET", "/api/v1/graph/nodes", "read-token", http.StatusOK},
+		{"POST", "/api/v1/scan", "read-token", http.StatusForbidden},
+		{"DELETE", "/api/v1/graph/nodes/a", "read-token", http.StatusForbidden},
+		{"POST", "/api/v1/scan", "admin-token", http.StatusOK},
+		{"POST", "/api/v1/scan", "legacy-admin", http.StatusOK},
+		{"GET", "/api/v1/graph/nodes", "unknown-token", http.StatusUnauthorized},
+		{"GET", "/api/v1/graph/nodes", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path+" "+tt.token, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestStart_UnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "aib.sock")
+	// A stale socket from an unclean shutdown must not block startup.
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := New(nil, nil, nil, nil, logger, "unix://"+sock, true, "", "", nil, "test")
+	errc := make(chan error, 1)
+	go func() { errc <- s.Start() }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://aib/healthz"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("request over unix socket: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("healthz status = %d", resp.StatusCode)
+	}
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != socketMode {
+		t.Errorf("socket mode = %v, want %v", info.Mode().Perm(), socketMode)
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Start returned %v, want ErrServerClosed", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("socket should be removed on shutdown, stat err = %v", err)
+	}
+}
+
+func TestListenUnix_PrivateBind(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "aib.sock")
+	ln, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 || info.Mode().Perm() != socketMode {
+		t.Errorf("socket mode = %v, want a socket with %v", info.Mode(), socketMode)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the socket", len(entries))
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dialing the renamed socket: %v", err)
+	}
+	_ = conn.Close()
+
+	_ = ln.Close()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("socket should be removed on close, stat err = %v", err)
+	}
+}
+
+func TestListenUnix_RefusesLiveSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aib.sock")
+	live, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close() //nolint:errcheck // test cleanup
+	go func() {
+		for {
+			conn, err := live.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	if ln, err := listenUnix(path); err == nil {
+		_ = ln.Close()
+		t.Fatal("expected error for a socket another server is listening on")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("live socket should be left alone: %v", err)
+	}
+}
+
+func TestListenUnix_RefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aib.sock")
+	if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenUnix(path); err == nil {
+		t.Fatal("expected error for existing non-socket file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file should be left alone: %v", err)
+	}
+}
+
+func TestRateLimiter_ConfiguredRateAndReset(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := &Server{done: make(chan struct{}), now: func() time.Time { return now }}
+	s.SetRateLimit(2) // burst 4
+	handler := s.rateLimiter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = "10.0.0.9:12345"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 4; i++ {
+		if rr := get("/api/v1/stats"); rr.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200 within burst", i, rr.Code)
+		}
+	}
+	rr := get("/api/v1/stats")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429 past burst", rr.Code)
+	}
+	if got := rr.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if rr := get("/healthz"); rr.Code != http.StatusOK {
+		t.Errorf("healthz status = %d, want 200 (exempt)", rr.Code)
+	}
+
+	// Half a second refills one token at 2/sec; a second refills the next.
+	now = now.Add(500 * time.Millisecond)
+	if rr := get("/api/v1/stats"); rr.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200 after refill", rr.Code)
+	}
+	if rr := get("/api/v1/stats"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429 once the refill is spent", rr.Code)
+	}
+	now = now.Add(2 * time.Second)
+	for i := 0; i < 4; i++ {
+		if rr := get("/api/v1/stats"); rr.Code != http.StatusOK {
+			t.Fatalf("request %d after reset: status = %d, want 200", i, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/matijazezelj/aib/internal/graph"
+)
+
+// wsPingInterval is how often an idle graph socket gets a ping message, so
+// dead clients are noticed and proxies keep the connection open.
+const wsPingInterval = 30 * time.Second
+
+// wsWriteTimeout bounds a single message write to a graph socket.
+const wsWriteTimeout = 10 * time.Second
+
+// handleGraphSocket streams graph mutations (graph.ChangeEvent as JSON text
+// messages) over a WebSocket until the client disconnects. A "ping" message
+// is sent while idle. If the client falls too far behind, the server closes
+// the socket; the client should reload the graph and reconnect.
+func (s *Server) handleGraphSocket(w http.ResponseWriter, r *http.Request) {
+	ws := websocket.Server{
+		Handshake: s.checkSocketOrigin,
+		Handler:   s.streamChanges,
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// checkSocketOrigin rejects cross-site WebSocket handshakes, which browsers
+// do not block on their own. Clients without an Origin header (CLIs,
+// scripts) and the server's own pages are allowed, as is cors_origin.
+func (s *Server) checkSocketOrigin(config *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("invalid origin %q", origin)
+	}
+	if u.Host != r.Host && (s.corsOrigin == "" || origin != s.corsOrigin) {
+		return fmt.Errorf("origin %q not allowed", origin)
+	}
+	config.Origin = u
+	return nil
+}
+
+func (s *Server) streamChanges(ws *websocket.Conn) {
+	defer ws.Close() //nolint:errcheck // best-effort close
+
+	events, unsubscribe := s.store.SubscribeChanges()
+	defer unsubscribe()
+
+	// The hijacked connection keeps the server's read and write timeouts;
+	// clear them, and read until the client goes away.
+	_ = ws.SetDeadline(time.Time{})
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+	}()
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	send := func(v any) bool {
+		_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		return websocket.JSON.Send(ws, v) == nil
+	}
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			if !send(graph.ChangeEvent{Op: "ping"}) {
+				return
+			}
+		case ev, open := <-events:
+			if !open {
+				s.logger.Warn("graph socket client fell behind; closing")
+				return
+			}
+			if !send(ev) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+func TestGraphSocket_PushesUpserts(t *testing.T) {
+	ts, store := newTestServer(t, "")
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	ws, err := websocket.Dial("ws://"+host+"/api/v1/ws", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close() //nolint:errcheck // test cleanup
+
+	// The server subscribes just after the handshake, so upsert until the
+	// event arrives rather than racing it once.
+	node := models.Node{ID: "tf:vm:web", Name: "web", Type: models.AssetVM, Source: "terraform", Provider: "aws", LastSeen: time.Now(), FirstSeen: time.Now()}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := store.UpsertNode(context.Background(), node); err != nil {
+			t.Fatal(err)
+		}
+		_ = ws.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var ev graph.ChangeEvent
+		if err := websocket.JSON.Receive(ws, &ev); err == nil {
+			if ev.Op != graph.ChangeUpsert || ev.Node == nil || ev.Node.ID != "tf:vm:web" {
+				t.Fatalf("event = %+v", ev)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no change event received")
+		}
+	}
+
+	// Drain repeats of the upsert, then check deletes come through too.
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		var ev graph.ChangeEvent
+		if websocket.JSON.Receive(ws, &ev) != nil {
+			break
+		}
+	}
+	if err := store.DeleteNode(context.Background(), "tf:vm:web"); err != nil {
+		t.Fatal(err)
+	}
+	_ = ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var ev graph.ChangeEvent
+	if err := websocket.JSON.Receive(ws, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Op != graph.ChangeDelete || ev.NodeID != "tf:vm:web" {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestGraphSocket_RejectsCrossSiteOrigin(t *testing.T) {
+	ts, _ := newTestServer(t, "")
+	host := strings.TrimPrefix(ts.URL, "http://")
+
+	if ws, err := websocket.Dial("ws://"+host+"/api/v1/ws", "", "https://evil.example"); err == nil {
+		_ = ws.Close()
+		t.Fatal("expected the handshake to be refused")
+	}
+}
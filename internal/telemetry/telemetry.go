@@ -0,0 +1,60 @@
+// Package telemetry sets up OpenTelemetry tracing. Instrumented code calls
+// otel.Tracer directly; until Setup installs a provider those calls go to
+// OpenTelemetry's built-in no-op provider and cost next to nothing.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+
+	"github.com/matijazezelj/aib/internal/config"
+)
+
+// ServiceName identifies aib in exported traces.
+const ServiceName = "aib"
+
+// tracesPath is where OTLP/HTTP collectors accept spans.
+const tracesPath = "/v1/traces"
+
+// Setup installs a global tracer provider that batches spans to the
+// configured OTLP/HTTP endpoint. enabled is false, and no exporter is
+// created, when telemetry.otlp_endpoint is unset. An endpoint without a path
+// sends to /v1/traces. shutdown flushes pending spans and must be called
+// before exit; it is a no-op when not enabled.
+func Setup(ctx context.Context, cfg config.TelemetryConfig, version string) (shutdown func(context.Context) error, enabled bool, err error) {
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, false, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint)}
+	if u, err := url.Parse(cfg.OTLPEndpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		opts = append(opts, otlptracehttp.WithURLPath(tracesPath))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, false, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, false, fmt.Errorf("building telemetry resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, true, nil
+}
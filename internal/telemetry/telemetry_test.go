@@ -0,0 +1,67 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/matijazezelj/aib/internal/config"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	prev := otel.GetTracerProvider()
+	shutdown, enabled, err := Setup(context.Background(), config.TelemetryConfig{}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enabled {
+		t.Error("tracing should be disabled without an endpoint")
+	}
+	if otel.GetTracerProvider() != prev {
+		t.Error("global tracer provider should be left alone")
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+}
+
+func TestSetup_ExportsToCollector(t *testing.T) {
+	paths := make(chan string, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+	}))
+	defer collector.Close()
+
+	prev := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	shutdown, enabled, err := Setup(context.Background(), config.TelemetryConfig{OTLPEndpoint: collector.URL}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !enabled {
+		t.Fatal("tracing should be enabled")
+	}
+	_, span := otel.Tracer("test").Start(context.Background(), "op")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	select {
+	case p := <-paths:
+		if p != tracesPath {
+			t.Errorf("exported to %q, want %q", p, tracesPath)
+		}
+	default:
+		t.Error("shutdown did not flush the span to the collector")
+	}
+}
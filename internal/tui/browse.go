@@ -0,0 +1,326 @@
+// Package tui implements the interactive terminal graph browser behind
+// "aib graph browse".
+package tui
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// Store is the subset of graph.SQLiteStore the browser reads from.
+type Store interface {
+	ListNodes(ctx context.Context, filter graph.NodeFilter) ([]models.Node, error)
+	GetNode(ctx context.Context, id string) (*models.Node, error)
+	GetEdgesFrom(ctx context.Context, nodeID string) ([]models.Edge, error)
+	GetEdgesTo(ctx context.Context, nodeID string) ([]models.Edge, error)
+}
+
+type mode int
+
+const (
+	modeList   mode = iota // the filtered node list
+	modeSearch             // typing a search term over the node list
+	modeNode               // one node's details and edges
+)
+
+// link is one edge seen from the node being viewed.
+type link struct {
+	Type     models.EdgeType
+	Outgoing bool
+	PeerID   string
+}
+
+// Model is the bubbletea model for the graph browser. The list view filters
+// by type, source and a search term; the node view shows a node's metadata
+// and edges, and following an edge opens the node at its other end.
+type Model struct {
+	ctx   context.Context
+	store Store
+
+	types   []string // filter choices; "" means all
+	sources []string
+	typeIdx int
+	srcIdx  int
+	search  string
+
+	nodes  []models.Node // current list, filtered
+	cursor int
+
+	mode    mode
+	node    *models.Node
+	links   []link
+	linkCur int
+	history []string // IDs of the nodes visited before the current one
+
+	height int
+	err    error
+}
+
+// New loads the node list and filter choices from store.
+func New(ctx context.Context, store Store) (*Model, error) {
+	all, err := store.ListNodes(ctx, graph.NodeFilter{})
+	if err != nil {
+		return nil, err
+	}
+	types, sources := map[string]bool{}, map[string]bool{}
+	for _, n := range all {
+		types[string(n.Type)] = true
+		sources[n.Source] = true
+	}
+	m := &Model{
+		ctx:     ctx,
+		store:   store,
+		types:   append([]string{""}, slices.Sorted(maps.Keys(types))...),
+		sources: append([]string{""}, slices.Sorted(maps.Keys(sources))...),
+		nodes:   all,
+		height:  24,
+	}
+	return m, nil
+}
+
+// Init implements tea.Model.
+func (m *Model) Init() tea.Cmd { return nil }
+
+// Update implements tea.Model.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeSearch:
+			m.updateSearch(msg)
+		case modeNode:
+			return m, m.updateNode(msg)
+		default:
+			return m, m.updateList(msg)
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateList(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q", "esc":
+		return tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.nodes)-1, 0))
+	case "t":
+		m.typeIdx = (m.typeIdx + 1) % len(m.types)
+		m.reload()
+	case "s":
+		m.srcIdx = (m.srcIdx + 1) % len(m.sources)
+		m.reload()
+	case "/":
+		m.mode = modeSearch
+	case "enter", "right", "l":
+		if len(m.nodes) > 0 {
+			m.open(m.nodes[m.cursor].ID)
+		}
+	}
+	return nil
+}
+
+func (m *Model) updateSearch(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.mode = modeList
+	case tea.KeyEsc:
+		m.search = ""
+		m.mode = modeList
+		m.reload()
+	case tea.KeyBackspace:
+		if m.search != "" {
+			r := []rune(m.search)
+			m.search = string(r[:len(r)-1])
+			m.reload()
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.search += string(msg.Runes)
+		m.reload()
+	}
+}
+
+func (m *Model) updateNode(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q":
+		return tea.Quit
+	case "up", "k":
+		m.linkCur = max(m.linkCur-1, 0)
+	case "down", "j":
+		m.linkCur = min(m.linkCur+1, max(len(m.links)-1, 0))
+	case "enter", "right", "l":
+		if len(m.links) > 0 {
+			m.history = append(m.history, m.node.ID)
+			m.open(m.links[m.linkCur].PeerID)
+		}
+	case "esc", "backspace", "left", "h":
+		m.back()
+	}
+	return nil
+}
+
+// reload re-queries the node list for the current filters.
+func (m *Model) reload() {
+	nodes, err := m.store.ListNodes(m.ctx, graph.NodeFilter{
+		Type:   m.types[m.typeIdx],
+		Source: m.sources[m.srcIdx],
+	})
+	if err != nil {
+		m.err = err
+		return
+	}
+	if m.search != "" {
+		term := strings.ToLower(m.search)
+		nodes = slices.DeleteFunc(nodes, func(n models.Node) bool {
+			return !strings.Contains(strings.ToLower(n.ID), term) && !strings.Contains(strings.ToLower(n.Name), term)
+		})
+	}
+	m.nodes, m.cursor, m.err = nodes, 0, nil
+}
+
+// open shows the node with the given ID and its edges.
+func (m *Model) open(id string) {
+	node, err := m.store.GetNode(m.ctx, id)
+	if err == nil && node == nil {
+		err = fmt.Errorf("node %q not found", id)
+	}
+	if err != nil {
+		m.err = err
+		return
+	}
+	from, err := m.store.GetEdgesFrom(m.ctx, id)
+	if err != nil {
+		m.err = err
+		return
+	}
+	to, err := m.store.GetEdgesTo(m.ctx, id)
+	if err != nil {
+		m.err = err
+		return
+	}
+
+	links := make([]link, 0, len(from)+len(to))
+	for _, e := range from {
+		links = append(links, link{Type: e.Type, Outgoing: true, PeerID: e.ToID})
+	}
+	for _, e := range to {
+		links = append(links, link{Type: e.Type, PeerID: e.FromID})
+	}
+	slices.SortFunc(links, func(a, b link) int {
+		if a.Outgoing != b.Outgoing {
+			if a.Outgoing {
+				return -1
+			}
+			return 1
+		}
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PeerID, b.PeerID)
+	})
+
+	m.mode, m.node, m.links, m.linkCur, m.err = modeNode, node, links, 0, nil
+}
+
+// back returns to the previously viewed node, or to the list.
+func (m *Model) back() {
+	if len(m.history) == 0 {
+		m.mode, m.node, m.links = modeList, nil, nil
+		return
+	}
+	prev := m.history[len(m.history)-1]
+	m.history = m.history[:len(m.history)-1]
+	m.open(prev)
+}
+
+// View implements tea.Model.
+func (m *Model) View() string {
+	var b strings.Builder
+	if m.mode == modeNode {
+		m.viewNode(&b)
+	} else {
+		m.viewList(&b)
+	}
+	if m.err != nil {
+		fmt.Fprintf(&b, "\nerror: %v\n", m.err)
+	}
+	return b.String()
+}
+
+func (m *Model) viewList(b *strings.Builder) {
+	fmt.Fprintf(b, "Nodes: %d   type: %s   source: %s   search: %s\n\n",
+		len(m.nodes), orAll(m.types[m.typeIdx]), orAll(m.sources[m.srcIdx]), m.searchLabel())
+	start, end := window(m.cursor, len(m.nodes), m.height-5)
+	for i := start; i < end; i++ {
+		n := m.nodes[i]
+		fmt.Fprintf(b, "%s %s  (%s, %s)\n", marker(i == m.cursor), n.ID, n.Type, n.Source)
+	}
+	if len(m.nodes) == 0 {
+		b.WriteString("  No nodes match.\n")
+	}
+	b.WriteString("\n↑/↓ move  enter open  t type  s source  / search  q quit\n")
+}
+
+func (m *Model) viewNode(b *strings.Builder) {
+	n := m.node
+	fmt.Fprintf(b, "%s  %s (%s, %s, %s)\n", n.ID, n.Name, n.Type, n.Source, n.Provider)
+	for _, k := range slices.Sorted(maps.Keys(n.Metadata)) {
+		fmt.Fprintf(b, "  %s = %s\n", k, n.Metadata[k])
+	}
+	fmt.Fprintf(b, "\nEdges: %d\n", len(m.links))
+	start, end := window(m.linkCur, len(m.links), m.height-len(n.Metadata)-6)
+	for i := start; i < end; i++ {
+		l := m.links[i]
+		arrow := "←"
+		if l.Outgoing {
+			arrow = "→"
+		}
+		fmt.Fprintf(b, "%s %s %s %s\n", marker(i == m.linkCur), arrow, l.Type, l.PeerID)
+	}
+	b.WriteString("\n↑/↓ move  enter follow edge  esc back  q quit\n")
+}
+
+func (m *Model) searchLabel() string {
+	if m.mode == modeSearch {
+		return m.search + "_"
+	}
+	if m.search == "" {
+		return "-"
+	}
+	return m.search
+}
+
+// window returns the range of n rows to draw so that cursor stays visible
+// in a screen of the given height.
+func window(cursor, n, height int) (int, int) {
+	height = max(height, 1)
+	start := max(cursor-height+1, 0)
+	return start, min(start+height, n)
+}
+
+func marker(selected bool) string {
+	if selected {
+		return ">"
+	}
+	return " "
+}
+
+func orAll(s string) string {
+	if s == "" {
+		return "all"
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matijazezelj/aib/internal/graph"
+	"github.com/matijazezelj/aib/pkg/models"
+)
+
+// newTestModel seeds lb:front → vm:web1 → db:pg1 plus an unconnected
+// kubernetes pod and returns a browser over them.
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+	ctx := context.Background()
+	store, err := graph.NewSQLiteStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	now := time.Now()
+	for _, n := range []models.Node{
+		{ID: "lb:front", Name: "front", Type: models.AssetLoadBalancer, Source: "terraform"},
+		{ID: "vm:web1", Name: "web1", Type: models.AssetVM, Source: "terraform", Metadata: map[string]string{"zone": "eu-west-1a"}},
+		{ID: "db:pg1", Name: "pg1", Type: models.AssetDatabase, Source: "terraform"},
+		{ID: "k8s:pod:api", Name: "api", Type: models.AssetPod, Source: "kubernetes"},
+	} {
+		n.FirstSeen, n.LastSeen = now, now
+		if err := store.UpsertNode(ctx, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range []models.Edge{
+		{ID: "lb:front->vm:web1", FromID: "lb:front", ToID: "vm:web1", Type: models.EdgeRoutesTo},
+		{ID: "vm:web1->db:pg1", FromID: "vm:web1", ToID: "db:pg1", Type: models.EdgeDependsOn},
+	} {
+		if err := store.UpsertEdge(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "backspace":
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func press(m *Model, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		_, cmd = m.Update(key(k))
+	}
+	return cmd
+}
+
+func nodeIDs(m *Model) []string {
+	ids := make([]string, len(m.nodes))
+	for i, n := range m.nodes {
+		ids[i] = n.ID
+	}
+	return ids
+}
+
+func TestModel_Filters(t *testing.T) {
+	m := newTestModel(t)
+	if len(m.nodes) != 4 {
+		t.Fatalf("initial list = %v, want all 4 nodes", nodeIDs(m))
+	}
+	if got := strings.Join(m.sources, ","); got != ",kubernetes,terraform" {
+		t.Errorf("source choices = %q", got)
+	}
+
+	press(m, "s") // kubernetes
+	if ids := nodeIDs(m); len(ids) != 1 || ids[0] != "k8s:pod:api" {
+		t.Errorf("source=kubernetes list = %v", ids)
+	}
+	press(m, "s") // terraform
+	if len(m.nodes) != 3 {
+		t.Errorf("source=terraform list = %v, want 3 nodes", nodeIDs(m))
+	}
+
+	// Types cycle in sorted order: database, load_balancer, pod, vm.
+	press(m, "t")
+	if ids := nodeIDs(m); len(ids) != 1 || ids[0] != "db:pg1" {
+		t.Errorf("type=database list = %v", ids)
+	}
+	press(m, "t", "t") // pod, which has no terraform nodes
+	if len(m.nodes) != 0 || !strings.Contains(m.View(), "No nodes match") {
+		t.Errorf("type=pod source=terraform list = %v", nodeIDs(m))
+	}
+	press(m, "t", "t", "s") // back to all types and all sources
+	if len(m.nodes) != 4 {
+		t.Errorf("unfiltered list = %v, want 4 nodes", nodeIDs(m))
+	}
+}
+
+func TestModel_Search(t *testing.T) {
+	m := newTestModel(t)
+
+	press(m, "/", "w", "e", "b")
+	if m.mode != modeSearch {
+		t.Fatal("expected search mode after /")
+	}
+	if ids := nodeIDs(m); len(ids) != 1 || ids[0] != "vm:web1" {
+		t.Errorf("search web = %v", ids)
+	}
+	// "q" is part of the term while searching, not quit.
+	if cmd := press(m, "backspace", "backspace", "backspace", "p"); cmd != nil {
+		t.Error("typing in search returned a command")
+	}
+	if len(m.nodes) != 2 { // pg1 and k8s:pod:api
+		t.Errorf("search p = %v, want 2 nodes", nodeIDs(m))
+	}
+	press(m, "enter")
+	if m.mode != modeList || m.search != "p" {
+		t.Errorf("enter should keep the term and return to the list, mode=%d search=%q", m.mode, m.search)
+	}
+	press(m, "/", "esc")
+	if m.search != "" || len(m.nodes) != 4 {
+		t.Errorf("esc should clear the search, got %q with %d nodes", m.search, len(m.nodes))
+	}
+}
+
+func TestModel_Navigate(t *testing.T) {
+	m := newTestModel(t)
+
+	press(m, "s", "s", "/", "w", "e", "b", "enter", "enter")
+	if m.mode != modeNode || m.node.ID != "vm:web1" {
+		t.Fatalf("expected vm:web1 open, got mode=%d node=%v", m.mode, m.node)
+	}
+	// Outgoing edges sort before incoming ones.
+	want := []link{
+		{Type: models.EdgeDependsOn, Outgoing: true, PeerID: "db:pg1"},
+		{Type: models.EdgeRoutesTo, PeerID: "lb:front"},
+	}
+	if len(m.links) != len(want) || m.links[0] != want[0] || m.links[1] != want[1] {
+		t.Fatalf("links = %+v, want %+v", m.links, want)
+	}
+	if view := m.View(); !strings.Contains(view, "zone = eu-west-1a") || !strings.Contains(view, "← routes_to lb:front") {
+		t.Errorf("node view missing metadata or edges:\n%s", view)
+	}
+
+	press(m, "down", "enter") // follow routes_to back to the load balancer
+	if m.node.ID != "lb:front" {
+		t.Fatalf("after following the incoming edge, node = %s, want lb:front", m.node.ID)
+	}
+	press(m, "enter")
+	if m.node.ID != "vm:web1" || len(m.history) != 2 {
+		t.Fatalf("node = %s history = %v", m.node.ID, m.history)
+	}
+
+	press(m, "esc")
+	if m.node.ID != "lb:front" {
+		t.Errorf("back went to %s, want lb:front", m.node.ID)
+	}
+	press(m, "esc", "esc")
+	if m.mode != modeList {
+		t.Errorf("expected the list after leaving the first node, mode=%d", m.mode)
+	}
+	if cmd := press(m, "q"); cmd == nil {
+		t.Error("q in the list should quit")
+	}
+}
@@ -14,6 +14,11 @@ const TYPE_COLORS = {
     function: '#d4a76a',
     api_gateway: '#b8a965',
     nosql_database: '#8f7bb5',
+    module: '#7c8894',
+    data_source: '#7c8894',
+    image: '#5b9e8f',
+    provider: '#7c8894',
+    region: '#7c8894',
 };
 
 const TYPE_SHAPES = {
@@ -30,6 +35,11 @@ const TYPE_SHAPES = {
     function: 'round-rectangle',
     api_gateway: 'ellipse',
     nosql_database: 'diamond',
+    module: 'barrel',
+    data_source: 'barrel',
+    image: 'tag',
+    provider: 'barrel',
+    region: 'barrel',
 };
 
 const GROUP_COLORS = [
@@ -1127,6 +1137,7 @@ async function init() {
     // Scan button
     document.getElementById('btn-scan').addEventListener('click', triggerScan);
     checkScanRunning();
+    watchGraphChanges();
 
     // Keyboard shortcuts
     document.addEventListener('keydown', handleKeyboard);
@@ -1500,8 +1511,13 @@ async function triggerScan() {
     status.className = 'scan-spinner';
     status.textContent = 'Scanning...';
     try {
-        await fetch(`${API}/scan`, { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ source: 'all' }) });
-        pollScanStatus();
+        const res = await fetch(`${API}/scan`, { method: 'POST', headers: { 'Content-Type': 'application/json' }, body: JSON.stringify({ source: 'all' }) });
+        const data = await res.json();
+        if (data.scan_id && window.EventSource) {
+            watchScanEvents(data.scan_id);
+        } else {
+            pollScanStatus();
+        }
     } catch {
         status.className = '';
         status.textContent = 'Scan failed';
@@ -1509,6 +1525,45 @@ async function triggerScan() {
     }
 }
 
+// watchScanEvents follows a scan's progress stream, falling back to
+// polling if the stream drops before the scan ends.
+function watchScanEvents(scanID) {
+    const btn = document.getElementById('btn-scan');
+    const status = document.getElementById('scan-status');
+    const source = new EventSource(`${API}/scan/${scanID}/events`);
+    let nodes = 0;
+    let finished = false;
+    const finish = (text) => {
+        finished = true;
+        source.close();
+        status.className = '';
+        status.textContent = text;
+        btn.disabled = false;
+    };
+    source.addEventListener('nodes_discovered', (e) => {
+        nodes = JSON.parse(e.data).nodes || 0;
+        status.textContent = `Scanning... ${nodes} nodes`;
+    });
+    source.addEventListener('edges_discovered', (e) => {
+        const edges = JSON.parse(e.data).edges || 0;
+        status.textContent = `Scanning... ${nodes} nodes, ${edges} edges`;
+    });
+    source.addEventListener('completed', () => {
+        finish('Scan complete');
+        location.reload();
+    });
+    source.addEventListener('failed', (e) => {
+        finish('Scan failed');
+        status.title = JSON.parse(e.data).error || '';
+    });
+    source.addEventListener('cancelled', () => finish('Scan cancelled'));
+    source.onerror = () => {
+        if (finished) return;
+        source.close();
+        pollScanStatus();
+    };
+}
+
 function pollScanStatus() {
     const btn = document.getElementById('btn-scan');
     const status = document.getElementById('scan-status');
@@ -1712,25 +1767,51 @@ init();
 // --- Auto-refresh ---
 let autoRefreshTimer = null;
 
+// refreshGraph reloads the graph and redraws it; unless force is set, only
+// when the node or edge count changed.
+async function refreshGraph(force) {
+    try {
+        const gd = await fetchJSON(`${API}/graph`);
+        const prevNodeCount = (graphData.nodes || []).length;
+        const prevEdgeCount = (graphData.edges || []).length;
+        if (force || gd.nodes?.length !== prevNodeCount || gd.edges?.length !== prevEdgeCount) {
+            graphData = gd;
+            const groupBy = document.getElementById('group-by').value;
+            const elements = buildElements(graphData, groupBy);
+            cy.json({ elements });
+            cy.layout(LAYOUTS[document.getElementById('layout-mode')?.value || 'balanced']).run();
+            renderResourcePanel();
+            buildEdgeFilterPills();
+            applyNodeVisibilityFilters();
+        }
+    } catch { /* ignore transient failures */ }
+}
+
 function startAutoRefresh(intervalMs) {
     stopAutoRefresh();
-    autoRefreshTimer = setInterval(async () => {
-        try {
-            const gd = await fetchJSON(`${API}/graph`);
-            const prevNodeCount = (graphData.nodes || []).length;
-            const prevEdgeCount = (graphData.edges || []).length;
-            if (gd.nodes?.length !== prevNodeCount || gd.edges?.length !== prevEdgeCount) {
-                graphData = gd;
-                const groupBy = document.getElementById('group-by').value;
-                const elements = buildElements(graphData, groupBy);
-                cy.json({ elements });
-                cy.layout(LAYOUTS[document.getElementById('layout-mode')?.value || 'balanced']).run();
-                renderResourcePanel();
-                buildEdgeFilterPills();
-                applyNodeVisibilityFilters();
-            }
-        } catch { /* ignore transient failures */ }
-    }, intervalMs);
+    autoRefreshTimer = setInterval(() => refreshGraph(false), intervalMs);
+}
+
+// watchGraphChanges redraws the graph shortly after the server reports
+// changes over /api/v1/ws, e.g. from a scheduled scan, coalescing a scan's
+// burst of events into one refresh. It reconnects if the socket drops.
+function watchGraphChanges(retryMs = 1000) {
+    if (!window.WebSocket) return;
+    const proto = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    const ws = new WebSocket(`${proto}//${location.host}${API}/ws`);
+    let pending = null;
+    ws.onopen = () => { retryMs = 1000; };
+    ws.onmessage = (e) => {
+        const ev = JSON.parse(e.data);
+        if (ev.op === 'ping') return;
+        clearTimeout(pending);
+        pending = setTimeout(() => refreshGraph(true), 1500);
+    };
+    ws.onclose = () => {
+        clearTimeout(pending);
+        refreshGraph(false);
+        setTimeout(() => watchGraphChanges(Math.min(retryMs * 2, 30000)), retryMs);
+    };
 }
 
 function stopAutoRefresh() {
@@ -41,6 +41,11 @@ const (
 	AssetAPIGateway     AssetType = "api_gateway"
 	AssetNoSQLDB        AssetType = "nosql_database"
 	AssetConfigMap      AssetType = "configmap"
+	AssetModule         AssetType = "module"
+	AssetDataSource     AssetType = "data_source"
+	AssetImage          AssetType = "image"
+	AssetProvider       AssetType = "provider"
+	AssetRegion         AssetType = "region"
 )
 
 // EdgeType represents the kind of relationship between assets.
@@ -60,6 +65,9 @@ const (
 	EdgeConnectsTo     EdgeType = "connects_to"
 	EdgeManagedBy      EdgeType = "managed_by"
 	EdgeCorrelatesWith EdgeType = "correlates_with"
+	EdgeContains       EdgeType = "contains"
+	EdgeUsesImage      EdgeType = "uses_image"
+	EdgeLocatedIn      EdgeType = "located_in"
 )
 
 // Node represents an infrastructure asset in the dependency graph.
@@ -74,13 +82,44 @@ type Node struct {
 	ExpiresAt  *time.Time        `json:"expires_at,omitempty"`
 	LastSeen   time.Time         `json:"last_seen"`
 	FirstSeen  time.Time         `json:"first_seen"`
+
+	// Tags are user annotations, stored apart from the scanned fields so
+	// re-scans keep them. Only single-node lookups load them.
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
-// Edge represents a relationship between two nodes.
+// Edge represents a relationship between two nodes. Weight is the cost of
+// crossing the edge in path searches; zero means the edge type's default.
 type Edge struct {
 	ID       string            `json:"id"`
 	FromID   string            `json:"from_id"`
 	ToID     string            `json:"to_id"`
 	Type     EdgeType          `json:"type"`
 	Metadata map[string]string `json:"metadata"`
+	Weight   float64           `json:"weight,omitempty"`
+}
+
+// softEdgeWeights are the default weights of edge types weaker than a hard
+// dependency, so shortest paths prefer declared relationships. Other types
+// weigh 1.
+var softEdgeWeights = map[EdgeType]float64{
+	EdgeConnectsTo:     2,
+	EdgeCorrelatesWith: 2,
+}
+
+// DefaultEdgeWeight returns the weight of an edge of type t that does not
+// set its own.
+func DefaultEdgeWeight(t EdgeType) float64 {
+	if w, ok := softEdgeWeights[t]; ok {
+		return w
+	}
+	return 1
+}
+
+// Cost returns e's weight, or its type's default weight if unset.
+func (e Edge) Cost() float64 {
+	if e.Weight > 0 {
+		return e.Weight
+	}
+	return DefaultEdgeWeight(e.Type)
 }